@@ -1,50 +1,67 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"log"
-	utils "the-dancing-pony-v2-lcwqre/Utils"
+	cache "the-dancing-pony-v2-lcwqre/caching"
+	"the-dancing-pony-v2-lcwqre/eventbus"
+	"the-dancing-pony-v2-lcwqre/jobs"
+	"the-dancing-pony-v2-lcwqre/media"
+	"the-dancing-pony-v2-lcwqre/migrations"
 	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/payments"
+	"the-dancing-pony-v2-lcwqre/realtime"
 	"the-dancing-pony-v2-lcwqre/repository"
 	"the-dancing-pony-v2-lcwqre/service"
+	"the-dancing-pony-v2-lcwqre/storage"
+	"the-dancing-pony-v2-lcwqre/webhooks"
 
 	"github.com/go-playground/validator/v10"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func SetupDatabase(user string, password string, dbName string) (*gorm.DB, error) {
-	// Construct the Data Source Name (DSN) for PostgreSQL
-	dsn := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable", user, password, dbName)
-
-	// Open a connection to the database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// OpenDatabase connects to PostgreSQL without touching the schema.
+func OpenDatabase(settings DatabaseSettings) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(settings.DSN()), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("Failed to connect to the database: %v", err)
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	return db, nil
+}
 
+// SetupDatabase connects to PostgreSQL and checks the schema is up to date, applying the
+// pending migrations first when migrate is set.
+func SetupDatabase(settings DatabaseSettings, migrate bool) (*gorm.DB, error) {
+	db, err := OpenDatabase(settings)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get the underlying SQL DB instance
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to get SQL DB instance: %v", err)
-	}
-
-	// Ensure the uuid-ossp extension is installed
-	if _, err := sqlDB.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`); err != nil {
-		log.Fatalf("Failed to create uuid-ossp extension: %v", err)
+		return nil, fmt.Errorf("failed to get SQL DB instance: %w", err)
 	}
-
-	// Perform database migrations
-	err = db.AutoMigrate(&model.Dish{}, &model.Rating{}, &model.User{}, &model.Permission{}, &model.Restaurant{})
+	migrator, err := migrations.NewMigrator(sqlDB)
 	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+		return nil, err
+	}
+	if migrate {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		return nil, err
 	}
 
-	fmt.Println("Database migrated successfully")
 	initializePermissions(db)
 	return db, nil
 }
+
 func initializePermissions(db *gorm.DB) {
 	permissions := []model.Permission{
 		{Name: "customer"},
@@ -65,21 +82,46 @@ func initializePermissions(db *gorm.DB) {
 			if err := db.Create(&perm).Error; err != nil {
 				log.Fatalf("Failed to create permission %s: %v", perm.Name, err)
 			} else {
-				fmt.Printf("Created permission: %s\n", perm.Name)
+				log.Printf("Created permission: %s", perm.Name)
 			}
 		} else {
-			fmt.Printf("Permission already exists: %s\n", perm.Name)
+			log.Printf("Permission already exists: %s", perm.Name)
 		}
 	}
 }
 
-// initializeServices sets up the dish and auth services
-func InitializeServices(db *gorm.DB, validate *validator.Validate, s3uploader *utils.S3Uploader) (service.DishesService, service.AuthService, service.RestaurantsService) {
+// initializeServices sets up the dish, auth, restaurant, menu, dish image, order, cart, payment, reservation, floor plan, promotion, loyalty, tax and webhook services
+func InitializeServices(db *gorm.DB, validate *validator.Validate, objectStore storage.ObjectStore, imageProcessor *media.ImageProcessor, appCache cache.Cache, cursors *pagination.CursorSigner, paymentProvider payments.PaymentProvider, paymentCurrency string, captureMethod string, events realtime.Publisher, bus *eventbus.Bus, webhookSender *webhooks.Sender, webhookPolicy webhooks.RetryPolicy, jobClient *jobs.Client, jwtSecret []byte) (service.DishesService, service.AuthService, service.RestaurantsService, service.MenuService, service.DishImagesService, service.OrderService, service.CartService, service.PaymentService, service.ReservationService, service.FloorPlanService, service.PromotionService, service.LoyaltyService, service.TaxService, service.WebhookService) {
 	dishRepository := repository.NewDishesRepositoryImpl(db)
+	dishChangesRepository := repository.NewDishChangesRepositoryImpl(db)
+	dishImagesRepository := repository.NewDishImagesRepositoryImpl(db)
 	resturantRepository := repository.NewRestaurantsRepositoryImpl(db)
+	orderRepository := repository.NewOrdersRepositoryImpl(db)
+	cartRepository := repository.NewCartsRepositoryImpl(db)
+	paymentRepository := repository.NewPaymentsRepositoryImpl(db)
+	reservationRepository := repository.NewReservationsRepositoryImpl(db)
+	floorPlanRepository := repository.NewFloorPlanRepositoryImpl(db)
+	promotionRepository := repository.NewPromotionsRepositoryImpl(db)
+	loyaltyRepository := repository.NewLoyaltyRepositoryImpl(db)
+	taxRepository := repository.NewTaxRepositoryImpl(db)
+	webhooksRepository := repository.NewWebhooksRepositoryImpl(db)
 	userRepo := repository.NewUserRepository(db)
-	dishService := service.NewDishesServiceImpl(dishRepository, validate, s3uploader)
-	resturantService := service.NewRestaurantsServiceImpl(resturantRepository, validate)
-	authService := service.NewAuthService(userRepo)
-	return dishService, authService, resturantService
+	// Events reach webhook subscriptions as well as realtime subscribers
+	events = service.NewWebhookPublisher(events, webhooksRepository)
+	service.SubscribeOutboxConsumers(bus, appCache, events)
+	dishService := service.NewDishesServiceImpl(dishRepository, validate, objectStore, imageProcessor, appCache, cursors)
+	resturantService := service.NewRestaurantsServiceImpl(resturantRepository, validate, appCache, cursors)
+	authService := service.NewAuthService(userRepo, jwtSecret)
+	menuService := service.NewMenuServiceImpl(dishRepository, dishChangesRepository, objectStore, validate, appCache, events)
+	dishImagesService := service.NewDishImagesServiceImpl(dishRepository, dishImagesRepository, objectStore, imageProcessor, appCache, jobClient)
+	orderService := service.NewOrderServiceImpl(orderRepository, dishRepository, promotionRepository, loyaltyRepository, taxRepository, cursors, events)
+	cartService := service.NewCartServiceImpl(cartRepository, dishRepository, promotionRepository, loyaltyRepository, taxRepository, events)
+	paymentService := service.NewPaymentServiceImpl(paymentRepository, orderRepository, paymentProvider, paymentCurrency, captureMethod)
+	reservationService := service.NewReservationServiceImpl(reservationRepository, floorPlanRepository, cursors, events)
+	floorPlanService := service.NewFloorPlanServiceImpl(floorPlanRepository)
+	promotionService := service.NewPromotionServiceImpl(promotionRepository)
+	loyaltyService := service.NewLoyaltyServiceImpl(loyaltyRepository, cursors)
+	taxService := service.NewTaxServiceImpl(taxRepository, orderRepository, resturantRepository, paymentCurrency)
+	webhookService := service.NewWebhookServiceImpl(webhooksRepository, webhookSender, webhookPolicy, cursors)
+	return dishService, authService, resturantService, menuService, dishImagesService, orderService, cartService, paymentService, reservationService, floorPlanService, promotionService, loyaltyService, taxService, webhookService
 }
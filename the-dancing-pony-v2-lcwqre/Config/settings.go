@@ -0,0 +1,259 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cache "the-dancing-pony-v2-lcwqre/caching"
+	"the-dancing-pony-v2-lcwqre/payments"
+	"the-dancing-pony-v2-lcwqre/webhooks"
+)
+
+// Settings is the configuration shared by every command of the binary. Each setting is
+// read from its default, the config file, its environment variable and its flag, each
+// overriding the previous.
+//
+// Settings are described by their tags: key names them in the config file, within the
+// key of their section; env is their environment variable, from which the flag name is
+// derived; secret settings are redacted when shown; validate holds the rules they are
+// checked against once loaded.
+type Settings struct {
+	Port             int    `key:"port" env:"PORT" validate:"min=1,max=65535"`
+	PublicURL        string `key:"public_url" env:"PUBLIC_URL" validate:"url"` // Base URL clients reach the API at, used in signed links; defaults to localhost and the port
+	RequireIfMatch   bool   `key:"require_if_match" env:"REQUIRE_IF_MATCH"`    // Require If-Match on updates and deletes; can be turned off for clients without ETags
+	LogLevel         string `key:"log_level" env:"LOG_LEVEL" validate:"oneof=trace debug info warn error"`
+	JWTSecret        string `key:"jwt_secret" env:"JWT_SECRET" secret:"true"`                 // Signs access tokens
+	CursorSigningKey string `key:"cursor_signing_key" env:"CURSOR_SIGNING_KEY" secret:"true"` // Signs pagination cursors
+	DevRandomKeys    bool   `key:"dev_random_keys" env:"DEV_RANDOM_KEYS"`                     // Replace missing secrets and signing keys by random ones lost on restart; for development only
+
+	Server   ServerSettings   `key:"server"`
+	Database DatabaseSettings `key:"database"`
+	Redis    RedisSettings    `key:"redis"`
+	Cache    CacheSettings    `key:"cache"`
+	Storage  StorageSettings  `key:"storage"`
+	Images   ImageSettings    `key:"images"`
+	Payments PaymentSettings  `key:"payments"`
+	Realtime RealtimeSettings `key:"realtime"`
+	Webhooks WebhookSettings  `key:"webhooks"`
+	Outbox   OutboxSettings   `key:"outbox"`
+	Jobs     JobSettings      `key:"jobs"`
+
+	sources map[string]string // Where each setting was read from, by key
+}
+
+// ServerSettings configure the HTTP server and how it shuts down. Timeouts of 0 are off.
+type ServerSettings struct {
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" validate:"gte=0"`
+	ReadTimeout       time.Duration `key:"read_timeout" env:"SERVER_READ_TIMEOUT" validate:"gte=0"`        // Whole request, body included
+	WriteTimeout      time.Duration `key:"write_timeout" env:"SERVER_WRITE_TIMEOUT" validate:"gte=0"`      // Whole response; event streams are exempt
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" validate:"gte=0"`        // Keep-alive connections between requests
+	DrainDelay        time.Duration `key:"drain_delay" env:"SERVER_DRAIN_DELAY" validate:"gte=0"`          // Time readiness fails before draining, for load balancers to stop routing
+	ShutdownTimeout   time.Duration `key:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" validate:"gt=0"` // Time in-flight requests are given to finish
+}
+
+// DatabaseSettings configure the PostgreSQL connection.
+type DatabaseSettings struct {
+	Host           string `key:"host" env:"DB_HOST" validate:"required"`
+	Port           int    `key:"port" env:"DB_PORT" validate:"min=1,max=65535"`
+	User           string `key:"user" env:"DB_USER" validate:"required"`
+	Password       string `key:"password" env:"DB_PASSWORD" secret:"true"`
+	Name           string `key:"name" env:"DB_NAME" validate:"required"`
+	SSLMode        string `key:"sslmode" env:"DB_SSLMODE" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	MigrateOnStart bool   `key:"migrate_on_start" env:"MIGRATE_ON_START"` // Apply pending migrations before serving instead of refusing to start
+}
+
+// DSN returns the connection string of the database. Values are quoted, so hosts may be
+// socket directories and passwords may hold spaces and quotes.
+func (d DatabaseSettings) DSN() string {
+	quote := func(value string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(d.Host), d.Port, quote(d.User), quote(d.Password), quote(d.Name), d.SSLMode)
+}
+
+// RedisSettings configure the Redis server shared by the cache, realtime hub, outbox stream and job queue.
+type RedisSettings struct {
+	Addr     string `key:"addr" env:"REDIS_ADDR"`
+	Password string `key:"password" env:"REDIS_PASSWORD" secret:"true"`
+}
+
+// CacheSettings configure the response cache.
+type CacheSettings struct {
+	Driver     string        `key:"driver" env:"CACHE_DRIVER" validate:"oneof=tiered memory none"`
+	StaleTTL   time.Duration `key:"stale_ttl" env:"CACHE_STALE_TTL" validate:"gte=0"`     // Stale-while-revalidate window, 0 to turn it off
+	VersionTTL time.Duration `key:"version_ttl" env:"CACHE_VERSION_TTL" validate:"gt=0"`  // Delay before writes on other instances are seen
+	MaxEntries int           `key:"max_entries" env:"CACHE_MAX_ENTRIES" validate:"min=1"` // In-process entries
+
+	// How long each type of entry stays fresh
+	TTLDishDetail       time.Duration `key:"ttl_dish_detail" env:"CACHE_TTL_DISH_DETAIL" validate:"gt=0"`
+	TTLDishList         time.Duration `key:"ttl_dish_list" env:"CACHE_TTL_DISH_LIST" validate:"gt=0"`
+	TTLDishSearch       time.Duration `key:"ttl_dish_search" env:"CACHE_TTL_DISH_SEARCH" validate:"gt=0"`
+	TTLRestaurantList   time.Duration `key:"ttl_restaurant_list" env:"CACHE_TTL_RESTAURANT_LIST" validate:"gt=0"`
+	TTLRestaurantSearch time.Duration `key:"ttl_restaurant_search" env:"CACHE_TTL_RESTAURANT_SEARCH" validate:"gt=0"`
+}
+
+// TTLs returns the lifetimes of the entry types.
+func (c CacheSettings) TTLs() cache.TTLs {
+	return cache.TTLs{
+		cache.DishDetail:       c.TTLDishDetail,
+		cache.DishList:         c.TTLDishList,
+		cache.DishSearch:       c.TTLDishSearch,
+		cache.RestaurantList:   c.TTLRestaurantList,
+		cache.RestaurantSearch: c.TTLRestaurantSearch,
+	}
+}
+
+// StorageSettings configure the object store for dish images.
+type StorageSettings struct {
+	Driver           string        `key:"driver" env:"STORAGE_DRIVER" validate:"oneof=local s3 memory"`
+	LocalDir         string        `key:"local_dir" env:"STORAGE_LOCAL_DIR" validate:"required"`
+	SigningKey       string        `key:"signing_key" env:"STORAGE_SIGNING_KEY" secret:"true"` // Signs file links
+	URLTTL           time.Duration `key:"url_ttl" env:"STORAGE_URL_TTL" validate:"gt=0"`
+	S3Bucket         string        `key:"s3_bucket" env:"S3_BUCKET"`
+	S3Region         string        `key:"s3_region" env:"AWS_REGION"`
+	S3Endpoint       string        `key:"s3_endpoint" env:"S3_ENDPOINT"`
+	S3AccessKey      string        `key:"s3_access_key" env:"AWS_ACCESS_KEY_ID"`
+	S3SecretKey      string        `key:"s3_secret_key" env:"AWS_SECRET_ACCESS_KEY" secret:"true"`
+	S3ForcePathStyle bool          `key:"s3_force_path_style" env:"S3_FORCE_PATH_STYLE"`
+	S3PublicURL      string        `key:"s3_public_url" env:"S3_PUBLIC_URL"`
+}
+
+// ImageSettings limit the dish images accepted.
+type ImageSettings struct {
+	MaxBytes     int64 `key:"max_bytes" env:"IMAGE_MAX_BYTES" validate:"min=1"`
+	MaxDimension int   `key:"max_dimension" env:"IMAGE_MAX_DIMENSION" validate:"min=1"` // Pixels per side
+}
+
+// PaymentSettings configure the payment provider.
+type PaymentSettings struct {
+	Provider         string        `key:"provider" env:"PAYMENT_PROVIDER" validate:"oneof=fake"`
+	WebhookSecret    string        `key:"webhook_secret" env:"PAYMENT_WEBHOOK_SECRET" secret:"true"` // Signs the provider's webhooks
+	FakeWebhookDelay time.Duration `key:"fake_webhook_delay" env:"FAKE_PAYMENT_WEBHOOK_DELAY" validate:"gte=0"`
+	Currency         string        `key:"currency" env:"PAYMENT_CURRENCY" validate:"len=3"`
+	CaptureMethod    string        `key:"capture_method" env:"PAYMENT_CAPTURE_METHOD" validate:"oneof=automatic manual"`
+}
+
+// RealtimeSettings configure the realtime hub.
+type RealtimeSettings struct {
+	Driver      string `key:"driver" env:"REALTIME_DRIVER" validate:"oneof=memory redis"`
+	HistorySize int    `key:"history_size" env:"REALTIME_HISTORY_SIZE" validate:"min=1"` // Events kept per restaurant for reconnects
+}
+
+// WebhookSettings configure outbound webhooks.
+type WebhookSettings struct {
+	TestReceiver         bool          `key:"test_receiver" env:"WEBHOOK_TEST_RECEIVER"`                   // Mount the local receiver for trying subscriptions out
+	AllowPrivateNetworks bool          `key:"allow_private_networks" env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS"` // Defaults to TestReceiver, whose receiver is served by this host
+	Timeout              time.Duration `key:"timeout" env:"WEBHOOK_TIMEOUT" validate:"gt=0"`               // Per attempt
+	MaxAttempts          int           `key:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" validate:"min=1"`
+	DisableAfter         int           `key:"disable_after" env:"WEBHOOK_DISABLE_AFTER" validate:"min=0"` // 0 never disables
+	DispatchInterval     time.Duration `key:"dispatch_interval" env:"WEBHOOK_DISPATCH_INTERVAL" validate:"gt=0"`
+}
+
+// Policy returns the retry policy of deliveries.
+func (w WebhookSettings) Policy() webhooks.RetryPolicy {
+	policy := webhooks.DefaultRetryPolicy
+	policy.MaxAttempts = w.MaxAttempts
+	policy.DisableAfter = w.DisableAfter
+	return policy
+}
+
+// OutboxSettings configure the relay of outbox events.
+type OutboxSettings struct {
+	RedisStream       string        `key:"redis_stream" env:"OUTBOX_REDIS_STREAM"` // Stream events are appended to, none when empty
+	RedisStreamMaxLen int64         `key:"redis_stream_maxlen" env:"OUTBOX_REDIS_STREAM_MAXLEN" validate:"min=1"`
+	RelayInterval     time.Duration `key:"relay_interval" env:"OUTBOX_RELAY_INTERVAL" validate:"gt=0"`
+	Retention         time.Duration `key:"retention" env:"OUTBOX_RETENTION" validate:"gt=0"` // Time relayed events are kept
+}
+
+// JobSettings configure the background job queue and the worker.
+type JobSettings struct {
+	Driver                string        `key:"driver" env:"JOBS_DRIVER" validate:"oneof=postgres redis"`
+	Concurrency           int           `key:"concurrency" env:"JOBS_CONCURRENCY" validate:"min=1"` // Jobs run at once per process
+	PollInterval          time.Duration `key:"poll_interval" env:"JOBS_POLL_INTERVAL" validate:"gt=0"`
+	MenuSchedulerInterval time.Duration `key:"menu_scheduler_interval" env:"MENU_SCHEDULER_INTERVAL" validate:"gt=0"`
+	ServerRunsWorker      bool          `key:"server_runs_worker" env:"SERVER_RUNS_WORKER"`                       // Run background work in the server too
+	WorkerMetricsAddr     string        `key:"worker_metrics_addr" env:"WORKER_METRICS_ADDR" validate:"required"` // Address the worker serves its metrics on
+}
+
+// defaultSettings returns the settings used when no source sets them.
+func defaultSettings() Settings {
+	return Settings{
+		Port:           8080,
+		RequireIfMatch: true,
+		LogLevel:       "info",
+		Server: ServerSettings{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseSettings{
+			Host:    "localhost",
+			Port:    5432,
+			SSLMode: "disable",
+		},
+		Cache: CacheSettings{
+			Driver:              "tiered",
+			StaleTTL:            30 * time.Second,
+			VersionTTL:          time.Second,
+			MaxEntries:          10000,
+			TTLDishDetail:       cache.DefaultTTL,
+			TTLDishList:         cache.DefaultTTL,
+			TTLDishSearch:       cache.DefaultTTL,
+			TTLRestaurantList:   cache.DefaultTTL,
+			TTLRestaurantSearch: cache.DefaultTTL,
+		},
+		Storage: StorageSettings{
+			Driver:   "local",
+			LocalDir: "uploads",
+			URLTTL:   24 * time.Hour,
+		},
+		Images: ImageSettings{
+			MaxBytes:     10 << 20,
+			MaxDimension: 8000,
+		},
+		Payments: PaymentSettings{
+			Provider:         "fake",
+			FakeWebhookDelay: 5 * time.Second,
+			Currency:         "USD",
+			CaptureMethod:    payments.CaptureAutomatic,
+		},
+		Realtime: RealtimeSettings{
+			Driver:      "memory",
+			HistorySize: 500,
+		},
+		Webhooks: WebhookSettings{
+			Timeout:          10 * time.Second,
+			MaxAttempts:      webhooks.DefaultRetryPolicy.MaxAttempts,
+			DisableAfter:     webhooks.DefaultRetryPolicy.DisableAfter,
+			DispatchInterval: 5 * time.Second,
+		},
+		Outbox: OutboxSettings{
+			RedisStreamMaxLen: 100000,
+			RelayInterval:     time.Second,
+			Retention:         7 * 24 * time.Hour,
+		},
+		Jobs: JobSettings{
+			Driver:                "postgres",
+			Concurrency:           4,
+			PollInterval:          time.Second,
+			MenuSchedulerInterval: 30 * time.Second,
+			ServerRunsWorker:      true,
+			WorkerMetricsAddr:     ":9091",
+		},
+	}
+}
+
+// deriveDefaults fills in the settings whose defaults depend on other settings.
+func (s *Settings) deriveDefaults() {
+	if s.PublicURL == "" {
+		s.PublicURL = fmt.Sprintf("http://localhost:%d", s.Port)
+	}
+	if _, set := s.sources["webhooks.allow_private_networks"]; !set {
+		s.Webhooks.AllowPrivateNetworks = s.Webhooks.TestReceiver
+	}
+}
@@ -0,0 +1,399 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the values of secret settings when they are shown.
+const redacted = "[redacted]"
+
+// Setting is a setting with its effective value and the source it was read from.
+type Setting struct {
+	Key    string
+	Env    string
+	Value  string
+	Source string // "default", "file:<path>", "env:<variable>" or "flag:-<name>"
+}
+
+// settingField is a setting described by the tags of its field in Settings.
+type settingField struct {
+	key    string
+	env    string
+	flag   string
+	secret bool
+	index  []int
+}
+
+// settingFields are the settings in the order of their fields.
+var settingFields = collectSettingFields(reflect.TypeOf(Settings{}), "", nil)
+
+// collectSettingFields walks the fields of a settings struct, descending into sections.
+func collectSettingFields(t reflect.Type, prefix string, index []int) []settingField {
+	var fields []settingField
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		key, ok := structField.Tag.Lookup("key")
+		if !ok || !structField.IsExported() {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		env, ok := structField.Tag.Lookup("env")
+		if !ok {
+			fields = append(fields, collectSettingFields(structField.Type, prefix+key+".", fieldIndex)...)
+			continue
+		}
+		fields = append(fields, settingField{
+			key:    prefix + key,
+			env:    env,
+			flag:   strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+			secret: structField.Tag.Get("secret") == "true",
+			index:  fieldIndex,
+		})
+	}
+	return fields
+}
+
+// ErrInvalidFlags is returned by LoadSettings for flags that cannot be parsed.
+var ErrInvalidFlags = errors.New("invalid flags")
+
+// LoadSettings reads the settings from, in increasing precedence, their defaults, the
+// config file given by -config or CONFIG_FILE, the environment and the flags at the
+// start of args. The .env file is loaded into the environment when there is one,
+// without overriding variables already set. Empty variables count as unset, and a
+// variable whose name ends in _FILE gives the path of a file holding the value, for
+// secrets mounted as files.
+//
+// It returns the arguments after the flags, and an error listing every invalid setting,
+// ErrInvalidFlags for flags that cannot be parsed or flag.ErrHelp when they asked for help.
+func LoadSettings(args []string) (Settings, []string, error) {
+	settings := defaultSettings()
+	settings.sources = map[string]string{}
+
+	flags := flag.NewFlagSet("thedancingpony", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", "", "")
+	flagValues := map[string]string{}
+	for _, field := range settingFields {
+		key := field.key
+		set := func(value string) error {
+			flagValues[key] = value
+			return nil
+		}
+		if reflect.ValueOf(settings).FieldByIndex(field.index).Kind() == reflect.Bool {
+			flags.BoolFunc(field.flag, "", set)
+		} else {
+			flags.Func(field.flag, "", set)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return settings, nil, err
+		}
+		return settings, nil, fmt.Errorf("%w: %v", ErrInvalidFlags, err)
+	}
+	args = flags.Args()
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return settings, args, fmt.Errorf("loading .env: %w", err)
+	}
+
+	var problems []string
+	path := *configFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	var fileValues map[string]string
+	if path != "" {
+		var err error
+		if fileValues, err = readSettingsFile(path); err != nil {
+			return settings, args, err
+		}
+	}
+
+	value := reflect.ValueOf(&settings).Elem()
+	for _, field := range settingFields {
+		raw, source, set := "", "", false
+		if fileValue, ok := fileValues[field.key]; ok {
+			raw, source, set = fileValue, "file:"+path, true
+			delete(fileValues, field.key)
+		}
+		if envValue, envSource, ok, err := lookupEnv(field.env); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		} else if ok {
+			raw, source, set = envValue, envSource, true
+		}
+		if flagValue, ok := flagValues[field.key]; ok {
+			raw, source, set = flagValue, "flag:-"+field.flag, true
+		}
+		if !set {
+			continue
+		}
+		if err := parseSetting(value.FieldByIndex(field.index), raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s (%s) from %s %v", field.key, field.env, source, err))
+			continue
+		}
+		settings.sources[field.key] = source
+	}
+
+	// Keys left in the file match no setting, and are most likely misspelt
+	unknown := make([]string, 0, len(fileValues))
+	for key := range fileValues {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("%s in %s is not a setting", key, path))
+	}
+
+	settings.deriveDefaults()
+	problems = append(problems, settings.validate()...)
+	if len(problems) > 0 {
+		return settings, args, fmt.Errorf("invalid settings:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return settings, args, nil
+}
+
+// readSettingsFile reads a YAML or TOML config file, whose sections nest like the keys of
+// the settings, and returns its values by key.
+func readSettingsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	document := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flattenSettings(document, "", values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flattenSettings adds the values of a document to values, under keys joining the names
+// of their sections with dots.
+func flattenSettings(document map[string]interface{}, prefix string, values map[string]string) error {
+	for name, value := range document {
+		key := prefix + name
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := flattenSettings(value, key+".", values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s must be a single value, not a list", key)
+		case nil:
+			// Keys without a value leave the setting alone, like empty variables
+		case float64:
+			values[key] = strconv.FormatFloat(value, 'f', -1, 64)
+		case time.Time:
+			values[key] = value.Format(time.RFC3339)
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// lookupEnv returns the value of a variable, or the content of the file named by the
+// variable with the _FILE suffix, and the source it was read from.
+func lookupEnv(name string) (string, string, bool, error) {
+	value := os.Getenv(name)
+	path := os.Getenv(name + "_FILE")
+	switch {
+	case value != "" && path != "":
+		return "", "", false, fmt.Errorf("%s and %s_FILE are both set, only one may be", name, name)
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", false, fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), "env:" + name + "_FILE", true, nil
+	case value != "":
+		return value, "env:" + name, true, nil
+	}
+	return "", "", false, nil
+}
+
+// parseSetting sets a field of the settings from its text.
+func parseSetting(field reflect.Value, raw string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 5m, got %q", raw)
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", raw)
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be a whole number, got %q", raw)
+		}
+		field.SetInt(value)
+	default:
+		return fmt.Errorf("has an unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// formatSetting returns the text of a field of the settings.
+func formatSetting(field reflect.Value) string {
+	if duration, ok := field.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+	return fmt.Sprint(field.Interface())
+}
+
+// validate checks the settings against the rules of their fields, and the rules that
+// span several settings. It returns a message per invalid setting.
+func (s Settings) validate() []string {
+	fields := map[string]settingField{}
+	for _, field := range settingFields {
+		fields[field.key] = field
+	}
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(structField reflect.StructField) string {
+		return structField.Tag.Get("key")
+	})
+
+	var problems []string
+	var validationErrors validator.ValidationErrors
+	if err := validate.Struct(s); errors.As(err, &validationErrors) {
+		for _, fieldError := range validationErrors {
+			// Namespaces start with the name of the Settings type
+			key := fieldError.Namespace()[strings.Index(fieldError.Namespace(), ".")+1:]
+			field := fields[key]
+			message := validationMessage(fieldError)
+			if !field.secret && fieldError.Tag() != "required" {
+				message += fmt.Sprintf(", got %q", formatSetting(reflect.ValueOf(fieldError.Value())))
+			}
+			problems = append(problems, fmt.Sprintf("%s (%s) %s", key, field.env, message))
+		}
+	} else if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	requireWhen := func(key string, value string, condition string) {
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("%s (%s) is required when %s", key, fields[key].env, condition))
+		}
+	}
+	if !s.DevRandomKeys {
+		requireWhen("jwt_secret", s.JWTSecret, "dev_random_keys is false")
+		requireWhen("cursor_signing_key", s.CursorSigningKey, "dev_random_keys is false")
+		requireWhen("storage.signing_key", s.Storage.SigningKey, "dev_random_keys is false")
+		requireWhen("payments.webhook_secret", s.Payments.WebhookSecret, "dev_random_keys is false")
+	}
+	if s.Storage.Driver == "s3" {
+		requireWhen("storage.s3_bucket", s.Storage.S3Bucket, "storage.driver is s3")
+		requireWhen("storage.s3_region", s.Storage.S3Region, "storage.driver is s3")
+	}
+	if s.Realtime.Driver == "redis" {
+		requireWhen("redis.addr", s.Redis.Addr, "realtime.driver is redis")
+	}
+	if s.Jobs.Driver == "redis" {
+		requireWhen("redis.addr", s.Redis.Addr, "jobs.driver is redis")
+	}
+	if s.Outbox.RedisStream != "" {
+		requireWhen("redis.addr", s.Redis.Addr, "outbox.redis_stream is set")
+	}
+	return problems
+}
+
+// validationMessage describes a failed validation rule.
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldError.Param(), " ", ", ")
+	case "url":
+		return "must be an absolute URL"
+	case "len":
+		return fmt.Sprintf("must be %s characters long", fieldError.Param())
+	case "gt":
+		return "must be positive"
+	case "gte", "min":
+		if fieldError.Param() == "0" {
+			return "must not be negative"
+		}
+		return "must be at least " + fieldError.Param()
+	case "max":
+		return "must be at most " + fieldError.Param()
+	}
+	return fmt.Sprintf("fails the %s rule", fieldError.Tag())
+}
+
+// Effective returns every setting with its value, secrets redacted, and its source.
+func (s Settings) Effective() []Setting {
+	value := reflect.ValueOf(s)
+	settings := make([]Setting, 0, len(settingFields))
+	for _, field := range settingFields {
+		setting := Setting{
+			Key:    field.key,
+			Env:    field.env,
+			Value:  formatSetting(value.FieldByIndex(field.index)),
+			Source: s.sources[field.key],
+		}
+		if setting.Source == "" {
+			setting.Source = "default"
+		}
+		if field.secret && setting.Value != "" {
+			setting.Value = redacted
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// PrintSettings lists the settings with their variable, flag and default.
+func PrintSettings(w io.Writer) {
+	defaults := reflect.ValueOf(defaultSettings())
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "KEY\tVARIABLE\tFLAG\tDEFAULT")
+	for _, field := range settingFields {
+		fmt.Fprintf(table, "%s\t%s\t-%s\t%s\n", field.key, field.env, field.flag, formatSetting(defaults.FieldByIndex(field.index)))
+	}
+	table.Flush()
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// clearSettingsEnv empties the variables of every setting for the test; empty variables
+// are ignored, so only those the test sets are read.
+func clearSettingsEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	for _, field := range settingFields {
+		t.Setenv(field.env, "")
+		t.Setenv(field.env+"_FILE", "")
+	}
+}
+
+// effective returns the effective setting of a key.
+func effective(t *testing.T, settings Settings, key string) Setting {
+	t.Helper()
+	for _, setting := range settings.Effective() {
+		if setting.Key == key {
+			return setting
+		}
+	}
+	t.Fatalf("no setting %s", key)
+	return Setting{}
+}
+
+func TestLoadSettings(t *testing.T) {
+	type want struct {
+		key    string
+		value  string
+		source string // "file" stands for the config file of the test
+	}
+	tests := []struct {
+		name     string
+		file     string            // YAML config file, none when empty
+		env      map[string]string // Variables set on top of the database user and name
+		envFiles map[string]string // Contents of files named by the variable with the _FILE suffix
+		args     []string
+		want     []want
+		wantArgs []string
+		wantErr  []string // Problems the error lists
+	}{
+		{
+			name: "defaults",
+			want: []want{{key: "port", value: "8080", source: "default"}, {key: "database.port", value: "5432", source: "default"}},
+		},
+		{
+			name: "file overrides defaults",
+			file: "port: 9000\ndatabase:\n  port: 6543\n",
+			want: []want{{key: "port", value: "9000", source: "file"}, {key: "database.port", value: "6543", source: "file"}},
+		},
+		{
+			name: "env overrides file",
+			file: "port: 9000\n",
+			env:  map[string]string{"PORT": "9100"},
+			want: []want{{key: "port", value: "9100", source: "env:PORT"}},
+		},
+		{
+			name:     "flags override env",
+			file:     "port: 9000\n",
+			env:      map[string]string{"PORT": "9100"},
+			args:     []string{"-port", "9200", "migrate", "up"},
+			want:     []want{{key: "port", value: "9200", source: "flag:-port"}},
+			wantArgs: []string{"migrate", "up"},
+		},
+		{
+			name:     "value read from file variable",
+			envFiles: map[string]string{"DB_PASSWORD": "mellon\n"},
+			want:     []want{{key: "database.password", value: redacted, source: "env:DB_PASSWORD_FILE"}},
+		},
+		{
+			name:     "variable and file variable both set",
+			env:      map[string]string{"DB_PASSWORD": "mellon"},
+			envFiles: map[string]string{"DB_PASSWORD": "mellon"},
+			wantErr:  []string{"DB_PASSWORD and DB_PASSWORD_FILE are both set"},
+		},
+		{
+			name:    "unknown key in file",
+			file:    "port: 9000\ndatabase:\n  prot: 6543\n",
+			wantErr: []string{"database.prot in "},
+		},
+		{
+			name:    "invalid value",
+			env:     map[string]string{"CACHE_TTL_DISH_LIST": "ten minutes"},
+			wantErr: []string{"cache.ttl_dish_list (CACHE_TTL_DISH_LIST) from env:CACHE_TTL_DISH_LIST must be a duration"},
+		},
+		{
+			name: "signing keys required without random keys",
+			env:  map[string]string{"DEV_RANDOM_KEYS": "false", "JWT_SECRET": "secret"},
+			wantErr: []string{
+				"cursor_signing_key (CURSOR_SIGNING_KEY) is required",
+				"storage.signing_key (STORAGE_SIGNING_KEY) is required",
+				"payments.webhook_secret (PAYMENT_WEBHOOK_SECRET) is required",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearSettingsEnv(t)
+			t.Setenv("DB_USER", "dancingponysvc")
+			t.Setenv("DB_NAME", "dancingpony")
+			t.Setenv("DEV_RANDOM_KEYS", "true")
+			dir := t.TempDir()
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			for name, content := range tt.envFiles {
+				path := filepath.Join(dir, strings.ToLower(name))
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv(name+"_FILE", path)
+			}
+			file := ""
+			if tt.file != "" {
+				file = filepath.Join(dir, "config.yaml")
+				if err := os.WriteFile(file, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv("CONFIG_FILE", file)
+			}
+
+			settings, args, err := LoadSettings(tt.args)
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("LoadSettings() error = nil, want %q", tt.wantErr)
+				}
+				for _, problem := range tt.wantErr {
+					if !strings.Contains(err.Error(), problem) {
+						t.Errorf("LoadSettings() error = %v, want it to contain %q", err, problem)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadSettings() error = %v", err)
+			}
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("LoadSettings() args = %q, want %q", args, tt.wantArgs)
+			}
+			for _, w := range tt.want {
+				if w.source == "file" {
+					w.source = "file:" + file
+				}
+				got := effective(t, settings, w.key)
+				if got.Value != w.value || got.Source != w.source {
+					t.Errorf("%s = %q from %s, want %q from %s", w.key, got.Value, got.Source, w.value, w.source)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSettingsTrimsFileVariables(t *testing.T) {
+	clearSettingsEnv(t)
+	t.Setenv("DB_USER", "dancingponysvc")
+	t.Setenv("DB_NAME", "dancingpony")
+	t.Setenv("DEV_RANDOM_KEYS", "true")
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("mellon\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_PASSWORD_FILE", path)
+
+	settings, _, err := LoadSettings(nil)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if settings.Database.Password != "mellon" {
+		t.Errorf("Database.Password = %q, want %q", settings.Database.Password, "mellon")
+	}
+}
+
+func TestSettingsEffectiveRedactsSecrets(t *testing.T) {
+	settings := defaultSettings()
+	settings.JWTSecret = "jwt secret"
+	settings.Database.Password = "db password"
+	settings.Database.User = "dancingponysvc"
+	settings.sources = map[string]string{"jwt_secret": "env:JWT_SECRET"}
+
+	tests := []struct {
+		key        string
+		wantValue  string
+		wantSource string
+	}{
+		{key: "jwt_secret", wantValue: redacted, wantSource: "env:JWT_SECRET"},
+		{key: "database.password", wantValue: redacted, wantSource: "default"},
+		{key: "cursor_signing_key", wantValue: "", wantSource: "default"}, // Empty secrets show they are not set
+		{key: "database.user", wantValue: "dancingponysvc", wantSource: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got := effective(t, settings, tt.key)
+			if got.Value != tt.wantValue || got.Source != tt.wantSource {
+				t.Errorf("Effective() %s = %q from %s, want %q from %s", tt.key, got.Value, got.Source, tt.wantValue, tt.wantSource)
+			}
+		})
+	}
+	for _, setting := range settings.Effective() {
+		if strings.Contains(setting.Value, "secret") || strings.Contains(setting.Value, "password") {
+			t.Errorf("Effective() shows %s = %q", setting.Key, setting.Value)
+		}
+	}
+}
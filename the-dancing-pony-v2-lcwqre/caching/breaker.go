@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	breakerThreshold = 5                // Consecutive failures that open the circuit
+	breakerCooldown  = 10 * time.Second // How long the circuit stays open before Redis is probed again
+)
+
+// errCircuitOpen is returned instead of calling Redis while the circuit is open.
+var errCircuitOpen = errors.New("cache circuit is open")
+
+// breaker stops calls to Redis after consecutive failures, so an unreachable Redis
+// costs nothing instead of a network timeout per request. Once the cooldown has
+// passed a single call is let through to probe whether Redis is back.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may go to Redis.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the circuit with the outcome of a call.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if errors.Is(err, context.Canceled) {
+		return // The caller gave up; says nothing about Redis
+	}
+	if err == nil || errors.Is(err, redis.Nil) {
+		if b.failures >= b.threshold {
+			log.Info().Msg("Redis is reachable again, closing cache circuit")
+			cacheCircuitOpen.Set(0)
+		}
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures == b.threshold {
+		log.Warn().Err(err).Dur("cooldown", b.cooldown).Msg("Redis is failing, opening cache circuit")
+		cacheCircuitOpen.Set(1)
+	}
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// trip opens the circuit straight away.
+func (b *breaker) trip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = b.threshold
+	b.openUntil = time.Now().Add(b.cooldown)
+	cacheCircuitOpen.Set(1)
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestBreaker(t *testing.T) {
+	failure := errors.New("connection refused")
+	tests := []struct {
+		name      string
+		threshold int
+		cooldown  time.Duration
+		outcomes  []error // Recorded in order before allow is called
+		want      []bool  // Results of consecutive calls to allow
+	}{
+		{name: "closed", threshold: 3, cooldown: time.Hour, want: []bool{true, true}},
+		{name: "below threshold", threshold: 3, cooldown: time.Hour, outcomes: []error{failure, failure}, want: []bool{true}},
+		{name: "opens at threshold", threshold: 3, cooldown: time.Hour, outcomes: []error{failure, failure, failure}, want: []bool{false, false}},
+		{name: "success resets the count", threshold: 3, cooldown: time.Hour, outcomes: []error{failure, failure, nil, failure, failure}, want: []bool{true}},
+		{name: "missing key is a success", threshold: 3, cooldown: time.Hour, outcomes: []error{failure, failure, redis.Nil, failure, failure}, want: []bool{true}},
+		{name: "canceled calls are ignored", threshold: 3, cooldown: time.Hour, outcomes: []error{failure, failure, context.Canceled}, want: []bool{true}},
+		{name: "canceled calls do not reset", threshold: 3, cooldown: time.Hour, outcomes: []error{failure, failure, context.Canceled, failure}, want: []bool{false}},
+		{name: "one probe after the cooldown", threshold: 1, cooldown: 0, outcomes: []error{failure}, want: []bool{true, false}},
+		{name: "success closes the circuit", threshold: 1, cooldown: time.Hour, outcomes: []error{failure, nil}, want: []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(tt.threshold, tt.cooldown)
+			for _, err := range tt.outcomes {
+				b.record(err)
+			}
+			got := make([]bool, len(tt.want))
+			for i := range got {
+				got[i] = b.allow()
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerProbeOutcome(t *testing.T) {
+	failure := errors.New("connection refused")
+	tests := []struct {
+		name  string
+		probe error
+		want  bool // Whether calls are allowed right after the probe
+	}{
+		{name: "success closes", probe: nil, want: true},
+		{name: "failure reopens", probe: failure, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(1, 50*time.Millisecond)
+			b.record(failure)
+			if b.allow() {
+				t.Fatal("allow() = true during the cooldown")
+			}
+			time.Sleep(60 * time.Millisecond)
+			if !b.allow() {
+				t.Fatal("allow() = false after the cooldown, want a probe")
+			}
+			b.record(tt.probe)
+			if got := b.allow(); got != tt.want {
+				t.Errorf("allow() after the probe = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Cache keeps rendered responses so repeated reads skip the database. Entries are
+// grouped in namespaces; invalidating a namespace drops all of its entries at once.
+type Cache interface {
+	// Fetch returns the cached value of an entry, calling load and storing its
+	// result on a miss. Cache failures never fail the call; the value is loaded instead.
+	Fetch(ctx context.Context, entry Entry, load func() ([]byte, error)) ([]byte, error)
+
+	// Invalidate drops every entry of the given namespaces.
+	Invalidate(ctx context.Context, namespaces ...string) error
+}
+
+// EntryType names a kind of cached entry. Each type has its own TTL and metrics label.
+type EntryType string
+
+// Cached entry types.
+const (
+	DishDetail       EntryType = "dish_detail"
+	DishList         EntryType = "dish_list"
+	DishSearch       EntryType = "dish_search"
+	RestaurantList   EntryType = "restaurant_list"
+	RestaurantSearch EntryType = "restaurant_search"
+)
+
+// EntryTypes lists every entry type, for configuring TTLs.
+var EntryTypes = []EntryType{DishDetail, DishList, DishSearch, RestaurantList, RestaurantSearch}
+
+// DefaultTTL applies to entry types without a configured TTL.
+const DefaultTTL = 10 * time.Minute
+
+// RestaurantsNamespace holds the restaurant list and search entries.
+const RestaurantsNamespace = "restaurants"
+
+// TTLs maps entry types to how long their entries stay fresh.
+type TTLs map[EntryType]time.Duration
+
+// For returns the TTL of an entry type.
+func (t TTLs) For(entryType EntryType) time.Duration {
+	if ttl, ok := t[entryType]; ok && ttl > 0 {
+		return ttl
+	}
+	return DefaultTTL
+}
+
+// Entry identifies a cached value. Entries are grouped in namespaces that are invalidated together.
+type Entry struct {
+	Namespace string
+	Type      EntryType
+	Key       string
+}
+
+// RestaurantDishesNamespace holds every dish detail, list and search entry of a restaurant.
+func RestaurantDishesNamespace(restaurantId string) string {
+	return "restaurant:" + restaurantId + ":dishes"
+}
+
+// Config selects and tunes the cache implementation.
+type Config struct {
+	Driver string // "tiered", "memory" or "none"
+
+	RedisAddr     string
+	RedisPassword string
+
+	TTLs       TTLs
+	StaleTTL   time.Duration // How long an expired entry may still be served while it is refreshed
+	VersionTTL time.Duration // How long a namespace version read from Redis is trusted before it is read again
+	MaxEntries int           // Capacity of the in-process LRU
+}
+
+// NewCache creates the cache selected by cfg.Driver. An unreachable Redis does not
+// fail startup; the tiered cache serves from process memory until Redis is back.
+func NewCache(ctx context.Context, cfg Config) (Cache, error) {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.VersionTTL <= 0 {
+		cfg.VersionTTL = time.Second
+	}
+	if cfg.StaleTTL < 0 {
+		cfg.StaleTTL = 0
+	}
+
+	switch strings.ToLower(cfg.Driver) {
+	case "", "tiered":
+		client, err := NewRedisClient(ctx, cfg.RedisAddr, cfg.RedisPassword)
+		tiered := NewTieredCache(client, cfg)
+		if err != nil {
+			log.Warn().Err(err).Msg("Redis is unreachable, caching in process until it is back")
+			tiered.breaker.trip()
+		}
+		return tiered, nil
+	case "memory":
+		return NewMemoryCache(cfg), nil
+	case "none":
+		return NopCache{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", cfg.Driver)
+	}
+}
+
+// FetchJSON is Fetch for JSON encoded values.
+func FetchJSON[T any](ctx context.Context, c Cache, entry Entry, load func() (T, error)) (T, error) {
+	var value T
+	data, err := c.Fetch(ctx, entry, func() ([]byte, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	})
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		// An entry written by an older release; load a fresh value instead
+		cacheEvictions.WithLabelValues("corrupt").Inc()
+		log.Warn().Err(err).Str("type", string(entry.Type)).Msg("Discarding undecodable cache entry")
+		return load()
+	}
+	return value, nil
+}
+
+// NopCache caches nothing: every fetch loads the value.
+type NopCache struct{}
+
+// Fetch calls load.
+func (NopCache) Fetch(ctx context.Context, entry Entry, load func() ([]byte, error)) ([]byte, error) {
+	return load()
+}
+
+// Invalidate does nothing.
+func (NopCache) Invalidate(ctx context.Context, namespaces ...string) error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// item is a cached value with its freshness deadlines.
+type item struct {
+	value      []byte
+	freshUntil time.Time // Served as is until then
+	expiresAt  time.Time // Served stale while it is refreshed until then
+}
+
+// fresh reports whether the item can be served without refreshing it.
+func (i item) fresh(now time.Time) bool {
+	return now.Before(i.freshUntil)
+}
+
+// lru is a bounded in-process set of items that drops the least recently used item when full.
+type lru struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // Most recently used first
+}
+
+type lruEntry struct {
+	key  string
+	item item
+}
+
+func newLRU(capacity int) *lru {
+	return &lru{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the item stored under key unless it has expired.
+func (l *lru) get(key string, now time.Time) (item, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.items[key]
+	if !ok {
+		return item{}, false
+	}
+	entry := element.Value.(*lruEntry)
+	if !now.Before(entry.item.expiresAt) {
+		l.remove(element)
+		cacheEvictions.WithLabelValues("expired").Inc()
+		return item{}, false
+	}
+	l.order.MoveToFront(element)
+	return entry.item, true
+}
+
+// set stores an item, evicting the least recently used items beyond capacity.
+func (l *lru) set(key string, it item) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.items[key]; ok {
+		element.Value.(*lruEntry).item = it
+		l.order.MoveToFront(element)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruEntry{key: key, item: it})
+	for l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+		cacheEvictions.WithLabelValues("capacity").Inc()
+	}
+}
+
+func (l *lru) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.items, element.Value.(*lruEntry).key)
+}
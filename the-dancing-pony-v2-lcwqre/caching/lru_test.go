@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	type op struct {
+		set     bool // Store key, otherwise look it up
+		key     string
+		expired bool // Store an item that has already expired
+		want    bool // Whether a lookup finds the key
+	}
+	tests := []struct {
+		name     string
+		capacity int
+		ops      []op
+	}{
+		{
+			name:     "evicts the least recently used",
+			capacity: 2,
+			ops: []op{
+				{set: true, key: "a"}, {set: true, key: "b"},
+				{key: "a", want: true}, // a is now more recent than b
+				{set: true, key: "c"},
+				{key: "b", want: false}, {key: "a", want: true}, {key: "c", want: true},
+			},
+		},
+		{
+			name:     "overwriting does not evict",
+			capacity: 2,
+			ops: []op{
+				{set: true, key: "a"}, {set: true, key: "b"}, {set: true, key: "a"},
+				{key: "a", want: true}, {key: "b", want: true},
+			},
+		},
+		{
+			name:     "overwriting makes the key recent",
+			capacity: 2,
+			ops: []op{
+				{set: true, key: "a"}, {set: true, key: "b"}, {set: true, key: "a"}, {set: true, key: "c"},
+				{key: "a", want: true}, {key: "b", want: false},
+			},
+		},
+		{
+			name:     "expired items are dropped",
+			capacity: 2,
+			ops: []op{
+				{set: true, key: "a", expired: true}, {set: true, key: "b"},
+				{key: "a", want: false}, {key: "b", want: true},
+			},
+		},
+		{
+			name:     "missing key",
+			capacity: 1,
+			ops:      []op{{key: "a", want: false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			l := newLRU(tt.capacity)
+			for i, o := range tt.ops {
+				if o.set {
+					it := item{value: []byte(o.key), freshUntil: now.Add(time.Minute), expiresAt: now.Add(time.Minute)}
+					if o.expired {
+						it.freshUntil, it.expiresAt = now.Add(-time.Minute), now
+					}
+					l.set(o.key, it)
+					continue
+				}
+				it, ok := l.get(o.key, now)
+				if ok != o.want {
+					t.Fatalf("op %d: get(%q) found = %v, want %v", i, o.key, ok, o.want)
+				}
+				if ok && string(it.value) != o.key {
+					t.Fatalf("op %d: get(%q) = %q", i, o.key, it.value)
+				}
+			}
+			if l.order.Len() > tt.capacity || len(l.items) != l.order.Len() {
+				t.Errorf("lru holds %d items in order and %d in the index, capacity %d", l.order.Len(), len(l.items), tt.capacity)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	cacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Number of cache lookups served fresh from the cache, by tier.",
+		},
+		[]string{"type", "tier"},
+	)
+	cacheStaleHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_stale_hits_total",
+			Help: "Number of cache lookups served stale while the entry is refreshed.",
+		},
+		[]string{"type"},
+	)
+	cacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Number of cache lookups that had to load the value.",
+		},
+		[]string{"type"},
+	)
+	cacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Number of cache evictions, by reason.",
+		},
+		[]string{"reason"},
+	)
+	cacheErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_errors_total",
+			Help: "Number of failed cache operations.",
+		},
+		[]string{"op"},
+	)
+	cacheCircuitOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_circuit_open",
+			Help: "1 while Redis calls are bypassed after repeated failures, 0 otherwise.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cacheHits, cacheStaleHits, cacheMisses, cacheEvictions, cacheErrors, cacheCircuitOpen)
+}
@@ -2,20 +2,25 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
-var ctx = context.Background()
-
-// RedisClient is a global Redis client instance
-var RedisClient *redis.Client
-
-func InitRedisClient(addr, password string) error {
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       0,
+// NewRedisClient creates a Redis client and pings it. The client is returned even
+// when the ping fails, so callers can decide whether Redis is required.
+func NewRedisClient(ctx context.Context, addr, password string) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     password,
+		DB:           0,
+		DialTimeout:  time.Second,
+		ReadTimeout:  500 * time.Millisecond, // A slower cache read is not worth waiting for
+		WriteTimeout: 500 * time.Millisecond,
 	})
-	return nil
+	if err := client.Ping(ctx).Err(); err != nil {
+		return client, fmt.Errorf("error pinging redis at %s: %w", addr, err)
+	}
+	return client, nil
 }
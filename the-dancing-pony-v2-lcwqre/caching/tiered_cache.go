@@ -0,0 +1,336 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// TieredCache keeps entries in a bounded in-process LRU (L1) in front of Redis (L2).
+//
+// Every key embeds the current version of its namespace, so a single increment of
+// the version in Redis orphans every entry of the namespace at once; orphaned entries
+// are never read again and expire with their TTL. Versions are trusted in process
+// for VersionTTL, which bounds how long another instance's write can go unseen.
+// Versions never go backwards: a namespace invalidated while Redis was down keeps
+// its local version, which is written back to Redis once it is reachable again.
+//
+// Concurrent misses of the same entry share one load, and an expired entry is
+// served stale for StaleTTL while a single background load refreshes it. Redis
+// calls go through a circuit breaker, so while Redis is down the cache keeps
+// working from process memory without waiting on network timeouts.
+type TieredCache struct {
+	l1         *lru
+	l2         *redis.Client // Nil keeps every entry and namespace version in process
+	breaker    *breaker
+	loads      singleflight.Group
+	ttls       TTLs
+	staleTTL   time.Duration
+	versionTTL time.Duration
+
+	mu       sync.Mutex
+	versions map[string]namespaceVersion
+}
+
+// namespaceVersion is the last known version of a namespace.
+type namespaceVersion struct {
+	version   int64
+	checkedAt time.Time
+}
+
+// NewTieredCache creates a cache with an in-process L1 in front of a Redis client.
+func NewTieredCache(client *redis.Client, cfg Config) *TieredCache {
+	return &TieredCache{
+		l1:         newLRU(cfg.MaxEntries),
+		l2:         client,
+		breaker:    newBreaker(breakerThreshold, breakerCooldown),
+		ttls:       cfg.TTLs,
+		staleTTL:   cfg.StaleTTL,
+		versionTTL: cfg.VersionTTL,
+		versions:   make(map[string]namespaceVersion),
+	}
+}
+
+// NewMemoryCache creates a cache that lives in process only, for tests and single-instance setups.
+func NewMemoryCache(cfg Config) *TieredCache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	return NewTieredCache(nil, cfg)
+}
+
+// Fetch returns the cached value of an entry, calling load and storing its result on a miss.
+func (c *TieredCache) Fetch(ctx context.Context, entry Entry, load func() ([]byte, error)) ([]byte, error) {
+	// The version is read once, so a value loaded while a write invalidates the
+	// namespace is stored under the old version and never served.
+	key := entryKey(entry, c.version(ctx, entry.Namespace))
+	now := time.Now()
+
+	if it, tier, ok := c.lookup(ctx, key, now); ok {
+		if it.fresh(now) {
+			cacheHits.WithLabelValues(string(entry.Type), tier).Inc()
+			return it.value, nil
+		}
+		// Serve the stale value; the refresh runs once however many requests see it
+		cacheStaleHits.WithLabelValues(string(entry.Type)).Inc()
+		c.loads.DoChan(key, func() (interface{}, error) {
+			value, err := c.load(key, entry.Type, load)
+			if err != nil {
+				log.Warn().Err(err).Str("key", key).Msg("Error refreshing stale cache entry")
+			}
+			return value, err
+		})
+		return it.value, nil
+	}
+
+	cacheMisses.WithLabelValues(string(entry.Type)).Inc()
+	value, err, _ := c.loads.Do(key, func() (interface{}, error) {
+		return c.load(key, entry.Type, load)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// Invalidate drops every entry of the given namespaces by bumping their versions.
+// The local versions are bumped even when Redis is unavailable, so this instance
+// stops serving the entries straight away.
+func (c *TieredCache) Invalidate(ctx context.Context, namespaces ...string) error {
+	cacheEvictions.WithLabelValues("invalidated").Add(float64(len(namespaces)))
+	now := time.Now()
+	if c.l2 == nil {
+		c.bumpLocal(namespaces, now)
+		return nil
+	}
+
+	for i, namespace := range namespaces {
+		known, _ := c.known(namespace)
+		var version int64
+		err := c.redis("invalidate", func() error {
+			var err error
+			version, err = c.raiseVersion(ctx, namespace, known.version, now.UnixNano(), true)
+			return err
+		})
+		if err != nil {
+			c.bumpLocal(namespaces[i:], now)
+			return fmt.Errorf("error invalidating cache namespaces: %w", err)
+		}
+		c.remember(namespace, version, now)
+	}
+	return nil
+}
+
+// lookup finds an item in L1, then in L2. Items found in L2 are copied to L1.
+func (c *TieredCache) lookup(ctx context.Context, key string, now time.Time) (item, string, bool) {
+	if it, ok := c.l1.get(key, now); ok {
+		return it, "l1", true
+	}
+	if c.l2 == nil {
+		return item{}, "", false
+	}
+
+	var data []byte
+	err := c.redis("get", func() error {
+		var err error
+		data, err = c.l2.Get(ctx, key).Bytes()
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, redis.Nil) && !errors.Is(err, errCircuitOpen) {
+			log.Warn().Err(err).Str("key", key).Msg("Error reading cache entry")
+		}
+		return item{}, "", false
+	}
+	it, ok := decodeItem(data)
+	if !ok || !now.Before(it.expiresAt) {
+		return item{}, "", false
+	}
+	c.l1.set(key, it)
+	return it, "l2", true
+}
+
+// load calls the loader and stores its result in both tiers.
+func (c *TieredCache) load(key string, entryType EntryType, load func() ([]byte, error)) ([]byte, error) {
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ttl := c.ttls.For(entryType)
+	it := item{value: value, freshUntil: now.Add(ttl), expiresAt: now.Add(ttl + c.staleTTL)}
+	c.l1.set(key, it)
+	if c.l2 != nil {
+		// Detached from the request, whose context may end before a shared load does
+		err := c.redis("set", func() error {
+			return c.l2.Set(context.Background(), key, encodeItem(it), ttl+c.staleTTL).Err()
+		})
+		if err != nil && !errors.Is(err, errCircuitOpen) {
+			log.Warn().Err(err).Str("key", key).Msg("Error writing cache entry")
+		}
+	}
+	return value, nil
+}
+
+// version returns the current version of a namespace. A version Redis has not
+// seen yet starts at the current time, so a version lost to a Redis eviction or
+// restart jumps past every version used before and old entries cannot come back.
+// A local version ahead of Redis, from an invalidation made while Redis was down,
+// is written back to Redis.
+func (c *TieredCache) version(ctx context.Context, namespace string) int64 {
+	now := time.Now()
+	known, ok := c.known(namespace)
+	if ok && (c.l2 == nil || now.Sub(known.checkedAt) < c.versionTTL) {
+		return known.version
+	}
+
+	if c.l2 == nil {
+		return c.remember(namespace, now.UnixNano(), now)
+	}
+	var version int64
+	err := c.redis("version", func() error {
+		var err error
+		version, err = c.raiseVersion(ctx, namespace, known.version, max(known.version, now.UnixNano()), false)
+		return err
+	})
+	if err == nil {
+		return c.remember(namespace, version, now)
+	}
+	if !ok {
+		// Redis is unavailable and this instance has never seen the namespace
+		return c.remember(namespace, now.UnixNano(), now)
+	}
+	return known.version
+}
+
+// maxVersionRetries bounds how often raiseVersion retries when another instance
+// changes the version concurrently.
+const maxVersionRetries = 10
+
+// errVersionContended is returned when the version of a namespace kept changing
+// while raiseVersion tried to update it.
+var errVersionContended = errors.New("cache namespace version is contended")
+
+// raiseVersion sets the Redis version of a namespace to at least floor, plus one when
+// bump is set, and returns it. A namespace without a version starts at seed.
+func (c *TieredCache) raiseVersion(ctx context.Context, namespace string, floor int64, seed int64, bump bool) (int64, error) {
+	key := namespaceKey(namespace)
+	var version int64
+	update := func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Int64()
+		missing := errors.Is(err, redis.Nil)
+		if missing {
+			current = seed
+		} else if err != nil {
+			return err
+		}
+		version = max(current, floor)
+		if bump {
+			version++
+		}
+		if version == current && !missing {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, version, 0)
+			return nil
+		})
+		return err
+	}
+	for attempt := 0; attempt < maxVersionRetries; attempt++ {
+		err := c.l2.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return version, err
+		}
+	}
+	return 0, errVersionContended
+}
+
+// known returns the in-process version of a namespace.
+func (c *TieredCache) known(namespace string) (namespaceVersion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	known, ok := c.versions[namespace]
+	return known, ok
+}
+
+// remember records a version of a namespace checked at now and returns the
+// in-process version, which never goes below a version seen before.
+func (c *TieredCache) remember(namespace string, version int64, now time.Time) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if known, ok := c.versions[namespace]; ok && known.version > version {
+		version = known.version
+	}
+	c.versions[namespace] = namespaceVersion{version: version, checkedAt: now}
+	return version
+}
+
+// bumpLocal moves the in-process versions of namespaces past their current values.
+func (c *TieredCache) bumpLocal(namespaces []string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, namespace := range namespaces {
+		version := now.UnixNano()
+		if known, ok := c.versions[namespace]; ok && known.version >= version {
+			version = known.version + 1
+		}
+		c.versions[namespace] = namespaceVersion{version: version, checkedAt: now}
+	}
+}
+
+// redis runs a Redis call through the circuit breaker.
+func (c *TieredCache) redis(op string, call func() error) error {
+	if !c.breaker.allow() {
+		return errCircuitOpen
+	}
+	err := call()
+	c.breaker.record(err)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		cacheErrors.WithLabelValues(op).Inc()
+	}
+	return err
+}
+
+// itemFormat marks the encoding of items stored in Redis; values in another format are treated as misses.
+const itemFormat byte = 1
+
+// encodeItem prefixes the value with the format and the item's deadlines.
+func encodeItem(it item) []byte {
+	data := make([]byte, 17+len(it.value))
+	data[0] = itemFormat
+	binary.BigEndian.PutUint64(data[1:9], uint64(it.freshUntil.UnixNano()))
+	binary.BigEndian.PutUint64(data[9:17], uint64(it.expiresAt.UnixNano()))
+	copy(data[17:], it.value)
+	return data
+}
+
+// decodeItem reverses encodeItem.
+func decodeItem(data []byte) (item, bool) {
+	if len(data) < 17 || data[0] != itemFormat {
+		return item{}, false
+	}
+	return item{
+		value:      data[17:],
+		freshUntil: time.Unix(0, int64(binary.BigEndian.Uint64(data[1:9]))),
+		expiresAt:  time.Unix(0, int64(binary.BigEndian.Uint64(data[9:17]))),
+	}, true
+}
+
+// entryKey builds the key of an entry at a namespace version.
+func entryKey(entry Entry, version int64) string {
+	return fmt.Sprintf("cache:%s:v%d:%s:%s", entry.Namespace, version, entry.Type, entry.Key)
+}
+
+// namespaceKey builds the Redis key holding the version of a namespace.
+func namespaceKey(namespace string) string {
+	return "cache:ns:" + namespace
+}
@@ -0,0 +1,256 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestTieredCacheFetchCollapsesConcurrentMisses(t *testing.T) {
+	c := NewMemoryCache(Config{})
+	entry := Entry{Namespace: "restaurants", Type: RestaurantList, Key: "page=1"}
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() ([]byte, error) {
+		loads.Add(1)
+		<-release
+		return []byte("value"), nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.Fetch(context.Background(), entry, load)
+			if err != nil {
+				t.Errorf("Fetch() error = %v", err)
+			}
+			results[i] = string(value)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond) // Let every caller join the load
+	close(release)
+	wg.Wait()
+
+	if got := loads.Load(); got != 1 {
+		t.Errorf("load called %d times, want 1", got)
+	}
+	for i, result := range results {
+		if result != "value" {
+			t.Errorf("caller %d got %q, want %q", i, result, "value")
+		}
+	}
+
+	// Served from L1 now
+	if _, err := c.Fetch(context.Background(), entry, load); err != nil || loads.Load() != 1 {
+		t.Errorf("Fetch() after the load: error = %v, loads = %d, want a hit", err, loads.Load())
+	}
+}
+
+func TestTieredCacheVersionNeverGoesBackwards(t *testing.T) {
+	server := newFakeRedis(t)
+	const namespace = "restaurant:1:dishes"
+	ctx := context.Background()
+
+	newCache := func() *TieredCache {
+		client := redis.NewClient(&redis.Options{Addr: server.addr(), MaxRetries: -1})
+		t.Cleanup(func() { client.Close() })
+		c := NewTieredCache(client, Config{MaxEntries: 10}) // A zero VersionTTL reads Redis on every call
+		c.breaker = newBreaker(1, 0)                        // Probe Redis again straight away
+		return c
+	}
+	c := newCache()
+
+	before := c.version(ctx, namespace)
+	if stored := server.get(namespaceKey(namespace)); stored != strconv.FormatInt(before, 10) {
+		t.Fatalf("Redis holds version %q, want %d", stored, before)
+	}
+
+	server.setDown(true)
+	if err := c.Invalidate(ctx, namespace); err == nil {
+		t.Fatal("Invalidate() with Redis down returned no error")
+	}
+	bumped := c.version(ctx, namespace)
+	if bumped <= before {
+		t.Fatalf("version after invalidating with Redis down = %d, want more than %d", bumped, before)
+	}
+
+	server.setDown(false)
+	tests := []struct {
+		name  string
+		cache *TieredCache
+	}{
+		{name: "instance that invalidated", cache: c},
+		{name: "instance that invalidated, read again", cache: c},
+		{name: "other instance", cache: newCache()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cache.version(ctx, namespace); got < bumped {
+				t.Errorf("version() = %d, want at least %d", got, bumped)
+			}
+			if stored, _ := strconv.ParseInt(server.get(namespaceKey(namespace)), 10, 64); stored < bumped {
+				t.Errorf("Redis holds version %d, want at least %d", stored, bumped)
+			}
+		})
+	}
+
+	if err := c.Invalidate(ctx, namespace); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if got := c.version(ctx, namespace); got <= bumped {
+		t.Errorf("version() after invalidating again = %d, want more than %d", got, bumped)
+	}
+}
+
+// fakeRedis serves the subset of the Redis protocol the tiered cache uses for
+// namespace versions. While down it fails every command.
+type fakeRedis struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	values map[string]string
+	down   bool
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	server := &fakeRedis{listener: listener, values: make(map[string]string)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (f *fakeRedis) addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeRedis) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *fakeRedis) get(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[key]
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	var queued [][]string
+	inTx := false
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		f.mu.Lock()
+		down := f.down
+		f.mu.Unlock()
+
+		var reply string
+		switch {
+		case down:
+			reply = "-ERR unavailable\r\n"
+		case name == "MULTI":
+			inTx, queued = true, nil
+			reply = "+OK\r\n"
+		case name == "EXEC":
+			replies := make([]string, 0, len(queued))
+			for _, command := range queued {
+				replies = append(replies, f.exec(command))
+			}
+			inTx = false
+			reply = fmt.Sprintf("*%d\r\n%s", len(replies), strings.Join(replies, ""))
+		case inTx:
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		default:
+			reply = f.exec(args)
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "WATCH", "UNWATCH":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		f.values[args[1]] = args[2]
+		return "+OK\r\n"
+	default:
+		return fmt.Sprintf("-ERR unknown command %q\r\n", args[0])
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("unexpected command header %q", line)
+	}
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected argument header %q", header)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
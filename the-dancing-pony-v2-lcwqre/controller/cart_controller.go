@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// CartTokenHeader carries the token of a guest cart in requests and responses.
+const CartTokenHeader = "X-Cart-Token"
+
+// CartController handles cart requests of signed-in customers and guests. The same
+// handlers serve both; requests that passed the auth middleware act on the customer's
+// cart, the others on the guest cart named by the X-Cart-Token header.
+type CartController struct {
+	CartService service.CartService
+	Validate    *validator.Validate
+}
+
+// NewCartController creates a new instance of CartController.
+func NewCartController(service service.CartService) *CartController {
+	return &CartController{
+		CartService: service,
+		Validate:    validator.New(),
+	}
+}
+
+// Get retrieves the cart priced at the current dish prices.
+func (controller *CartController) Get(ctx *gin.Context) {
+	requestID, owner, restaurantId, ok := extractCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	cart, err := controller.CartService.Get(owner, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error retrieving cart", requestID)
+		return
+	}
+	respondCart(ctx, http.StatusOK, "Cart retrieved successfully", cart)
+}
+
+// AddItem adds a dish to the cart.
+func (controller *CartController) AddItem(ctx *gin.Context) {
+	requestID, owner, restaurantId, ok := extractCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var itemRequest request.CartItemRequest
+	if !helper.ValidateRequest(ctx, &itemRequest, controller.Validate, requestID) {
+		return
+	}
+
+	cart, err := controller.CartService.AddItem(itemRequest, owner, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error adding dish to cart", requestID)
+		return
+	}
+	respondCart(ctx, http.StatusOK, "Dish added to cart", cart)
+}
+
+// UpdateItem changes the quantity or notes of a cart item.
+func (controller *CartController) UpdateItem(ctx *gin.Context) {
+	requestID, owner, restaurantId, ok := extractCartOwner(ctx)
+	if !ok {
+		return
+	}
+	itemId, ok := parseUUIDParam(ctx, "itemId", requestID)
+	if !ok {
+		return
+	}
+
+	var itemRequest request.UpdateCartItemRequest
+	if !helper.ValidateRequest(ctx, &itemRequest, controller.Validate, requestID) {
+		return
+	}
+
+	cart, err := controller.CartService.UpdateItem(itemRequest, itemId, owner, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error updating cart item", requestID)
+		return
+	}
+	respondCart(ctx, http.StatusOK, "Cart item updated", cart)
+}
+
+// RemoveItem removes an item from the cart.
+func (controller *CartController) RemoveItem(ctx *gin.Context) {
+	requestID, owner, restaurantId, ok := extractCartOwner(ctx)
+	if !ok {
+		return
+	}
+	itemId, ok := parseUUIDParam(ctx, "itemId", requestID)
+	if !ok {
+		return
+	}
+
+	cart, err := controller.CartService.RemoveItem(itemId, owner, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error removing cart item", requestID)
+		return
+	}
+	respondCart(ctx, http.StatusOK, "Cart item removed", cart)
+}
+
+// ApplyCoupon enters a coupon code on the cart.
+func (controller *CartController) ApplyCoupon(ctx *gin.Context) {
+	requestID, owner, restaurantId, ok := extractCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	var couponRequest request.CouponRequest
+	if !helper.ValidateRequest(ctx, &couponRequest, controller.Validate, requestID) {
+		return
+	}
+
+	cart, err := controller.CartService.ApplyCoupon(couponRequest, owner, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error applying coupon", requestID)
+		return
+	}
+	respondCart(ctx, http.StatusOK, "Coupon applied", cart)
+}
+
+// RemoveCoupon removes the coupon code entered on the cart.
+func (controller *CartController) RemoveCoupon(ctx *gin.Context) {
+	requestID, owner, restaurantId, ok := extractCartOwner(ctx)
+	if !ok {
+		return
+	}
+
+	cart, err := controller.CartService.RemoveCoupon(owner, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error removing coupon", requestID)
+		return
+	}
+	respondCart(ctx, http.StatusOK, "Coupon removed", cart)
+}
+
+// Merge moves the guest cart named by the X-Cart-Token header into the customer's cart.
+func (controller *CartController) Merge(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	guestToken := ctx.GetHeader(CartTokenHeader)
+	if guestToken == "" {
+		log.Error().
+			Str("request_id", requestID).
+			Msg("Guest cart token is missing")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": CartTokenHeader + " header is required"})
+		return
+	}
+
+	cart, err := controller.CartService.Merge(guestToken, userId, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error merging guest cart", requestID)
+		return
+	}
+	respondCart(ctx, http.StatusOK, "Guest cart merged", cart)
+}
+
+// Checkout turns the customer's cart into an order.
+func (controller *CartController) Checkout(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var checkoutRequest request.CheckoutRequest
+	if !helper.ValidateRequest(ctx, &checkoutRequest, controller.Validate, requestID) {
+		return
+	}
+
+	order, err := controller.CartService.Checkout(checkoutRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondCartError(ctx, err, "Error checking out cart", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Order placed successfully",
+		Status:  "Ok",
+		Data:    order,
+	})
+}
+
+// extractCartOwner identifies the cart owner: the signed-in customer when the auth
+// middleware ran, otherwise the guest holding the X-Cart-Token header, if any.
+func extractCartOwner(ctx *gin.Context) (string, service.CartOwner, string, bool) {
+	if _, signedIn := ctx.Get("user_id"); signedIn {
+		requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+		if err != nil {
+			return "", service.CartOwner{}, "", false
+		}
+		return requestID, service.CartOwner{CustomerID: userId}, restaurantId, true
+	}
+
+	requestID := ctx.GetString("request_id")
+	restaurantId, err := helper.GetRestaurantAsString(ctx)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Msg("Failed to get restaurant")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get restaurant"})
+		return "", service.CartOwner{}, "", false
+	}
+	return requestID, service.CartOwner{GuestToken: ctx.GetHeader(CartTokenHeader)}, restaurantId, true
+}
+
+// respondCart writes a cart response, echoing the guest token in the X-Cart-Token header.
+func respondCart(ctx *gin.Context, status int, message string, cart response.CartResponse) {
+	if cart.GuestToken != "" {
+		ctx.Header(CartTokenHeader, cart.GuestToken)
+	}
+	ctx.JSON(status, response.APIResponse{
+		Message: message,
+		Status:  "Ok",
+		Data:    cart,
+	})
+}
+
+// respondCartError maps cart service errors to HTTP status codes.
+func respondCartError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Cart item not found", err, requestID)
+	case errors.Is(err, service.ErrDishUnavailable), errors.Is(err, service.ErrCartFull), isCouponError(err), isRewardError(err):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	case errors.Is(err, repository.ErrCartEmpty),
+		errors.Is(err, service.ErrCartHasUnavailableItems),
+		errors.Is(err, service.ErrCartPriceChanged),
+		errors.Is(err, repository.ErrPromotionUnavailable),
+		errors.Is(err, repository.ErrRewardUnavailable),
+		errors.Is(err, repository.ErrInsufficientPoints):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/media"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// DishImagesController handles gallery and direct upload requests for dish images.
+type DishImagesController struct {
+	DishImagesService service.DishImagesService
+	Validate          *validator.Validate
+}
+
+// NewDishImagesController creates a new instance of DishImagesController.
+func NewDishImagesController(service service.DishImagesService) *DishImagesController {
+	return &DishImagesController{
+		DishImagesService: service,
+		Validate:          validator.New(),
+	}
+}
+
+// List returns the gallery of a dish in display order.
+func (controller *DishImagesController) List(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+
+	images, err := controller.DishImagesService.List(dishId, restaurantId, userId, requestID)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error retrieving dish images", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dish images retrieved successfully",
+		Status:  "Ok",
+		Data:    images,
+	})
+}
+
+// Upload adds an image sent as the multipart "image" field, with optional "altText".
+func (controller *DishImagesController) Upload(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+
+	altText := ctx.PostForm("altText")
+	if len(altText) > 300 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "altText must be at most 300 characters"})
+		return
+	}
+	file, header, err := ctx.Request.FormFile("image")
+	if err != nil {
+		log.Error().Str("request_id", requestID).Msg("Failed to get image file")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get image file"})
+		return
+	}
+	defer file.Close()
+
+	image, err := controller.DishImagesService.Upload(ctx, *header, altText, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error uploading dish image", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Dish image added",
+		Status:  "Ok",
+		Data:    image,
+	})
+}
+
+// CreateUpload returns a presigned request the client uses to upload an image straight to storage.
+func (controller *DishImagesController) CreateUpload(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+
+	var uploadRequest request.DishImageUploadRequest
+	if !helper.ValidateRequest(ctx, &uploadRequest, controller.Validate, requestID) {
+		return
+	}
+
+	upload, err := controller.DishImagesService.CreateUpload(ctx, uploadRequest, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error creating upload link", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Upload link created",
+		Status:  "Ok",
+		Data:    upload,
+	})
+}
+
+// ConfirmUpload queues a finished direct upload to be attached to the dish gallery.
+// The image is processed in the background; GetUpload reports when it is done.
+func (controller *DishImagesController) ConfirmUpload(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+	uploadId, ok := parseUUIDParam(ctx, "uploadId", requestID)
+	if !ok {
+		return
+	}
+
+	var confirmRequest request.ConfirmDishImageUploadRequest
+	if ctx.Request.ContentLength != 0 && !helper.ValidateRequest(ctx, &confirmRequest, controller.Validate, requestID) {
+		return
+	}
+
+	upload, err := controller.DishImagesService.ConfirmUpload(ctx, confirmRequest, uploadId, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error confirming upload", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, response.APIResponse{
+		Message: "Dish image is being processed",
+		Status:  "Ok",
+		Data:    upload,
+	})
+}
+
+// GetUpload reports the status of a direct upload.
+func (controller *DishImagesController) GetUpload(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+	uploadId, ok := parseUUIDParam(ctx, "uploadId", requestID)
+	if !ok {
+		return
+	}
+
+	upload, err := controller.DishImagesService.FindUpload(uploadId, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error retrieving upload", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Upload retrieved",
+		Status:  "Ok",
+		Data:    upload,
+	})
+}
+
+// Update changes the alt text of a gallery image.
+func (controller *DishImagesController) Update(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+	imageId, ok := parseUUIDParam(ctx, "imageId", requestID)
+	if !ok {
+		return
+	}
+
+	var updateRequest request.UpdateDishImageRequest
+	if !helper.ValidateRequest(ctx, &updateRequest, controller.Validate, requestID) {
+		return
+	}
+
+	image, err := controller.DishImagesService.Update(updateRequest, imageId, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error updating dish image", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dish image updated",
+		Status:  "Ok",
+		Data:    image,
+	})
+}
+
+// SetPrimary makes an image the primary image of its dish.
+func (controller *DishImagesController) SetPrimary(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+	imageId, ok := parseUUIDParam(ctx, "imageId", requestID)
+	if !ok {
+		return
+	}
+
+	image, err := controller.DishImagesService.SetPrimary(imageId, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error setting primary dish image", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Primary dish image updated",
+		Status:  "Ok",
+		Data:    image,
+	})
+}
+
+// Reorder sets the display order of a dish's gallery.
+func (controller *DishImagesController) Reorder(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+
+	var reorderRequest request.ReorderDishImagesRequest
+	if !helper.ValidateRequest(ctx, &reorderRequest, controller.Validate, requestID) {
+		return
+	}
+
+	images, err := controller.DishImagesService.Reorder(reorderRequest, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondDishImageError(ctx, err, "Error reordering dish images", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dish images reordered",
+		Status:  "Ok",
+		Data:    images,
+	})
+}
+
+// Delete removes an image from a dish's gallery.
+func (controller *DishImagesController) Delete(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+	imageId, ok := parseUUIDParam(ctx, "imageId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.DishImagesService.Delete(imageId, dishId, userId, requestID, restaurantId); err != nil {
+		respondDishImageError(ctx, err, "Error deleting dish image", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dish image deleted",
+		Status:  "Ok",
+	})
+}
+
+// respondDishImageError maps gallery and upload errors to HTTP status codes.
+func respondDishImageError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Dish, image or upload not found", err, requestID)
+	case errors.Is(err, repository.ErrImageOrderMismatch):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	case errors.Is(err, repository.ErrUploadAlreadyConfirmed), errors.Is(err, service.ErrUploadNotReceived):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrUploadExpired):
+		helper.LogInformation(ctx, http.StatusGone, err.Error(), err, requestID)
+	case errors.Is(err, media.ErrUnsupportedImageType), errors.Is(err, media.ErrImageTooLarge), errors.Is(err, media.ErrImageDimensions):
+		respondImageError(ctx, err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
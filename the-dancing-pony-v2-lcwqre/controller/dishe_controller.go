@@ -1,17 +1,31 @@
 package controller
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"the-dancing-pony-v2-lcwqre/data/request"
 	"the-dancing-pony-v2-lcwqre/data/response"
 	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/media"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/repository"
 	"the-dancing-pony-v2-lcwqre/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
 )
 
 type DishesController struct {
@@ -66,6 +80,14 @@ func (controller *DishesController) Create(ctx *gin.Context) {
 	createDishesRequest.Description = description
 	createDishesRequest.Price = priceInt
 
+	// New dishes are published straight away unless created as drafts
+	status := ctx.Request.FormValue("status")
+	if status != "" && status != model.DishStatusDraft && status != model.DishStatusPublished {
+		log.Error().Str("request_id", requestID).Msg("Invalid dish status")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, expected draft or published"})
+		return
+	}
+
 	// Parse the multipart form to get the file and other fields
 	file, header, err := ctx.Request.FormFile("image")
 	if err != nil {
@@ -75,11 +97,10 @@ func (controller *DishesController) Create(ctx *gin.Context) {
 	}
 	defer file.Close()
 
-	// Upload image to S3
-	imageURL, err := controller.DishesService.UploadImageToS3(*header, ctx)
+	// Validate the image and upload its renditions to the object store
+	imageURL, err := controller.DishesService.UploadImage(*header, ctx)
 	if err != nil {
-		log.Error().Str("request_id", requestID).Msg("Failed to upload image to S3")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		respondImageError(ctx, err, requestID)
 		return
 	}
 	var dishRequest request.CreateDishRequest
@@ -87,6 +108,7 @@ func (controller *DishesController) Create(ctx *gin.Context) {
 	dishRequest.Description = createDishesRequest.Description
 	dishRequest.Price = createDishesRequest.Price
 	dishRequest.ImageUrl = imageURL
+	dishRequest.Status = status
 
 	createdDish, err := controller.DishesService.Create(dishRequest, userId, requestID, restaurantUUID)
 	if err != nil {
@@ -110,83 +132,78 @@ func (controller *DishesController) Create(ctx *gin.Context) {
 		Msg("Dish created successfully")
 }
 
-// Update updates a dish.
+// Update applies a JSON Merge Patch (RFC 7386) to a dish. The patch is sent as the
+// request body, or as the "patch" field of a multipart form that may also carry a new "image".
 func (controller *DishesController) Update(ctx *gin.Context) {
 	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
 	if err != nil {
 		return
 	}
-
-	var updateDishesRequest request.UpdateDishRequest
-
-	dishId := ctx.Param("dishId")
-	id, err := uuid.Parse(dishId)
-	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("dish_id", dishId).
-			Msg("Invalid dish ID format")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dish ID format"})
+	id, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
 		return
 	}
-
-	// Parse multipart form data with a max memory size of 32MB
-	if err := ctx.Request.ParseMultipartForm(32 << 20); err != nil {
-		log.Printf("Error parsing form data: %s", err)
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
+	versions, ok := helper.IfMatchVersions(ctx, requestID)
+	if !ok {
 		return
 	}
 
-	// Extract form fields
-	name := ctx.Request.FormValue("name")
-	description := ctx.Request.FormValue("description")
-	price := ctx.Request.FormValue("price")
-	if name == "" || description == "" || price == "" {
-		log.Error().Str("request_id", requestID).Msg("Missing required form fields")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing required fields"})
+	var updateDishRequest request.UpdateDishRequest
+	var imageHeader *multipart.FileHeader
+	switch {
+	case helper.IsMergePatch(ctx):
+		if !helper.BindMergePatch(ctx, ctx.Request.Body, &updateDishRequest, requestID) {
+			return
+		}
+	case ctx.ContentType() == "multipart/form-data":
+		if err := ctx.Request.ParseMultipartForm(32 << 20); err != nil {
+			log.Error().Str("request_id", requestID).Err(err).Msg("Error parsing form data")
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
+			return
+		}
+		if patch := ctx.Request.MultipartForm.Value["patch"]; len(patch) > 0 {
+			if !helper.BindMergePatch(ctx, strings.NewReader(patch[0]), &updateDishRequest, requestID) {
+				return
+			}
+		}
+		if files := ctx.Request.MultipartForm.File["image"]; len(files) > 0 {
+			imageHeader = files[0]
+		}
+	default:
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Expected " + request.MergePatchContentType + " or multipart/form-data"})
 		return
 	}
-	// Parse price to integer
-	priceInt, err := strconv.ParseFloat(price, 64)
-	if err != nil {
-		log.Error().Str("request_id", requestID).Msg("Invalid price format")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price format"})
-		return
-	}
-	var updateDisheRequest request.UpdateDishRequest
 
-	updateDisheRequest.Name = name
-	updateDisheRequest.Description = description
-	updateDisheRequest.Price = priceInt
-
-	// Parse the multipart form to get the file and other fields
-	file, header, err := ctx.Request.FormFile("image")
-	if err != nil {
-		log.Error().Str("request_id", requestID).Msg("Failed to get image file")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get image file"})
+	if !helper.RespondPatchValidation(ctx, updateDishRequest.Validate(controller.Validate), requestID) {
 		return
 	}
-	defer file.Close()
 
-	// Upload image to S3
-	imageURL, err := controller.DishesService.UploadImageToS3(*header, ctx)
-	if err != nil {
-		log.Error().Str("request_id", requestID).Msg("Failed to upload image to S3")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
-		return
+	if imageHeader != nil {
+		if updateDishRequest.ImageUrl.Set {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Send either an image file or imageUrl, not both"})
+			return
+		}
+		// Validate the image and upload its renditions to the object store
+		imageKey, err := controller.DishesService.UploadImage(*imageHeader, ctx)
+		if err != nil {
+			respondImageError(ctx, err, requestID)
+			return
+		}
+		updateDishRequest.ImageUrl = request.Optional[string]{Set: true, Value: imageKey}
 	}
-	updateDisheRequest.ID = id
-	updateDisheRequest.ImageUrl = imageURL
+	updateDishRequest.ID = id
+	updateDishRequest.Versions = versions
 
-	updatedDish, err := controller.DishesService.Update(updateDishesRequest, userId, requestID, restaurantId)
+	updatedDish, err := controller.DishesService.Update(updateDishRequest, userId, requestID, restaurantId)
 	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("dish_id", dishId).
-			Msg("Error updating dish")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating dish"})
+		if errors.Is(err, service.ErrDuplicateSKU) {
+			helper.LogInformation(ctx, http.StatusConflict, service.ErrDuplicateSKU.Error(), err, requestID)
+			return
+		}
+		respondWriteError(ctx, err, "Dish", "Error updating dish", requestID)
 		return
 	}
+	helper.SetETag(ctx, updatedDish.Version)
 
 	webResponse := response.APIResponse{
 		Message: "Dish updated successfully",
@@ -217,13 +234,13 @@ func (controller *DishesController) Delete(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dish ID format"})
 		return
 	}
-	err = controller.DishesService.Delete(id, restaurantId, userId, requestID)
+	versions, ok := helper.IfMatchVersions(ctx, requestID)
+	if !ok {
+		return
+	}
+	err = controller.DishesService.Delete(id, versions, restaurantId, userId, requestID)
 	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("dish_id", dishId).
-			Msg("Error deleting dish")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting dish"})
+		respondWriteError(ctx, err, "Dish", "Error deleting dish", requestID)
 		return
 	}
 
@@ -265,6 +282,9 @@ func (controller *DishesController) FindById(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Dish not found"})
 		return
 	}
+	if helper.NotModified(ctx, dish.Version) {
+		return
+	}
 
 	webResponse := response.APIResponse{
 		Message: "Dish found",
@@ -285,21 +305,12 @@ func (controller *DishesController) FindAll(ctx *gin.Context) {
 		Str("request_id", requestID).
 		Msg("Processing Find All Dishes request")
 
-	// Extract pagination parameters
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "10")
-
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-
 	// Fetch dishes with pagination
-	dishListResponse, err := controller.DishesService.FindAll(page, limit, restaurantId, userId, requestID)
+	dishListResponse, err := controller.DishesService.FindAll(ExtractPagination(ctx), restaurantId, userId, requestID)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+		return
+	}
 	if err != nil {
 		log.Error().
 			Str("request_id", requestID).
@@ -307,6 +318,7 @@ func (controller *DishesController) FindAll(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving dishes"})
 		return
 	}
+	dishListResponse.Links = pageLinks(ctx, dishListResponse.Pagination)
 
 	// Construct paginated response
 	webResponse := response.APIResponse{
@@ -381,37 +393,30 @@ func (controller *DishesController) Search(ctx *gin.Context) {
 		return
 	}
 
-	// Extract pagination parameters
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "10")
-
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-
+	pageRequest := ExtractPagination(ctx)
 	log.Info().
 		Str("request_id", requestID).
 		Str("search_term", searchTerm).
-		Int("page", page).
-		Int("limit", limit).
+		Int("page", pageRequest.Page).
+		Int("limit", pageRequest.Limit).
 		Msg("Searching for dishes")
 
-	dishListResponse, err := controller.DishesService.Search(searchTerm, page, limit, restaurantId, userId, requestID)
+	dishListResponse, err := controller.DishesService.Search(searchTerm, pageRequest, restaurantId, userId, requestID)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+		return
+	}
 	if err != nil {
 		log.Error().
 			Str("request_id", requestID).
 			Str("search_term", searchTerm).
-			Int("page", page).
-			Int("limit", limit).
+			Int("page", pageRequest.Page).
+			Int("limit", pageRequest.Limit).
 			Msg("Error searching dishes")
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Error searching dishes"})
 		return
 	}
+	dishListResponse.Links = pageLinks(ctx, dishListResponse.Pagination)
 
 	webResponse := response.APIResponse{
 		Message: "Dishes retrieved successfully",
@@ -423,19 +428,320 @@ func (controller *DishesController) Search(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, webResponse)
 }
 
-// Handles pagination extraction
-func ExtractPagination(ctx *gin.Context) (int, int) {
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "10")
+// ListReviews retrieves the reviews of the restaurant's dishes, optionally filtered by a "status" query parameter.
+func (controller *DishesController) ListReviews(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	reviews, err := controller.DishesService.FindReviews(ctx.Query("status"), ExtractPagination(ctx), restaurantId, userId, requestID)
+	if err != nil {
+		respondReviewError(ctx, err, "Error retrieving reviews", requestID)
+		return
+	}
+	reviews.Links = pageLinks(ctx, reviews.Pagination)
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reviews retrieved successfully",
+		Status:  "Ok",
+		Data:    reviews,
+	})
+}
+
+// ApproveReview approves a pending review, crediting the customer's review points.
+func (controller *DishesController) ApproveReview(ctx *gin.Context) {
+	controller.moderateReview(ctx, model.RatingStatusApproved, "Review approved")
+}
+
+// RejectReview rejects a pending review.
+func (controller *DishesController) RejectReview(ctx *gin.Context) {
+	controller.moderateReview(ctx, model.RatingStatusRejected, "Review rejected")
+}
+
+// moderateReview moves the review named in the path to the given status.
+func (controller *DishesController) moderateReview(ctx *gin.Context, status string, message string) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	ratingId, ok := parseUUIDParam(ctx, "ratingId", requestID)
+	if !ok {
+		return
+	}
+
+	review, err := controller.DishesService.ModerateReview(ratingId, status, restaurantId, userId, requestID)
+	if err != nil {
+		respondReviewError(ctx, err, "Error moderating review", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: message,
+		Status:  "Ok",
+		Data:    review,
+	})
+}
+
+// maxImportBodySize caps the size of a bulk import payload.
+const maxImportBodySize = 10 << 20
+
+// exportFlushEvery is the number of exported rows written between flushes to the client.
+const exportFlushEvery = 100
+
+// Import creates or updates dishes in bulk from a CSV or JSON payload.
+// The payload is either the request body (Content-Type text/csv or application/json)
+// or a multipart "file" field. Pass dryRun=true to validate without writing.
+func (controller *DishesController) Import(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID format"})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBodySize)
+	body, format, err := importPayload(ctx)
+	if err != nil {
+		log.Error().Str("request_id", requestID).Err(err).Msg("Failed to read import payload")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import payload"})
+		return
+	}
+	defer body.Close()
+
+	var dishList request.ListDishRequestList
+	switch format {
+	case "csv":
+		dishList, err = service.ParseDishCSV(body)
+		if err != nil {
+			log.Error().Str("request_id", requestID).Err(err).Msg("Invalid CSV import")
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	default:
+		if err := json.NewDecoder(body).Decode(&dishList); err != nil {
+			log.Error().Str("request_id", requestID).Err(err).Msg("Invalid JSON import")
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+
+	importResponse, err := controller.DishesService.Import(dishList, dryRun, userId, requestID, restaurantUUID)
+	if errors.Is(err, service.ErrInvalidImport) {
+		ctx.JSON(http.StatusUnprocessableEntity, response.APIResponse{
+			Message: "Import contains invalid rows, nothing was imported",
+			Status:  "error",
+			Data:    importResponse,
+		})
+		return
+	}
+	if err != nil {
+		helper.LogInformation(ctx, http.StatusInternalServerError, "Error importing dishes", err, requestID)
+		return
+	}
+
+	message := "Dishes imported successfully"
+	if dryRun {
+		message = "Dry run completed, nothing was imported"
+	}
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: message,
+		Status:  "Ok",
+		Data:    importResponse,
+	})
+}
+
+// Export streams the restaurant's whole catalogue as CSV (default) or JSON, chosen by the "format" query parameter.
+func (controller *DishesController) Export(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	format := strings.ToLower(ctx.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "json" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected csv or json"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="dishes-%s.%s"`, restaurantId, format))
+	ctx.Status(http.StatusOK)
+
+	rows := 0
+	if format == "csv" {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		writer := csv.NewWriter(ctx.Writer)
+		if err := writer.Write(service.DishCSVHeader); err != nil {
+			return
+		}
+		err = controller.DishesService.Export(restaurantId, userId, requestID, func(row response.DishExportRow) error {
+			if err := writer.Write(service.DishCSVRecord(row)); err != nil {
+				return err
+			}
+			if rows++; rows%exportFlushEvery == 0 {
+				writer.Flush()
+				ctx.Writer.Flush()
+			}
+			return writer.Error()
+		})
+		writer.Flush()
+	} else {
+		ctx.Header("Content-Type", "application/json")
+		if _, err := io.WriteString(ctx.Writer, `{"dishes":[`); err != nil {
+			return
+		}
+		err = controller.DishesService.Export(restaurantId, userId, requestID, func(row response.DishExportRow) error {
+			encoded, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			if rows > 0 {
+				encoded = append([]byte(","), encoded...)
+			}
+			if _, err := ctx.Writer.Write(encoded); err != nil {
+				return err
+			}
+			if rows++; rows%exportFlushEvery == 0 {
+				ctx.Writer.Flush()
+			}
+			return nil
+		})
+		if err == nil {
+			_, err = io.WriteString(ctx.Writer, "]}")
+		}
+	}
+
+	if err != nil {
+		// Headers are already sent, so the truncated body is the only signal left to the client.
+		log.Error().
+			Str("request_id", requestID).
+			Int("num_exported", rows).
+			Err(err).
+			Msg("Dish export aborted")
+		ctx.Abort()
+	}
+}
+
+// respondWriteError maps the errors of a conditional update or delete to HTTP status codes.
+func respondWriteError(ctx *gin.Context, err error, resource string, message string, requestID string) {
+	switch {
+	case errors.Is(err, repository.ErrVersionMismatch):
+		helper.LogInformation(ctx, http.StatusPreconditionFailed, resource+" has been modified since it was read", err, requestID)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, resource+" not found", err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
+
+// respondReviewError maps review moderation errors to HTTP status codes.
+func respondReviewError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Review not found", err, requestID)
+	case errors.Is(err, repository.ErrReviewNotPending):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrUnknownReviewStatus), errors.Is(err, service.ErrReviewPageUnsupported):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
 
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page <= 0 {
-		page = 1
+// respondImageError maps image upload errors to HTTP status codes.
+func respondImageError(ctx *gin.Context, err error, requestID string) {
+	switch {
+	case errors.Is(err, media.ErrUnsupportedImageType):
+		helper.LogInformation(ctx, http.StatusUnsupportedMediaType, media.ErrUnsupportedImageType.Error(), err, requestID)
+	case errors.Is(err, media.ErrImageTooLarge):
+		helper.LogInformation(ctx, http.StatusRequestEntityTooLarge, media.ErrImageTooLarge.Error(), err, requestID)
+	case errors.Is(err, media.ErrImageDimensions):
+		helper.LogInformation(ctx, http.StatusUnprocessableEntity, err.Error(), err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, "Failed to upload image", err, requestID)
 	}
-	limit, err := strconv.Atoi(limitStr)
+}
+
+// importPayload returns the import body and its format ("csv" or "json").
+func importPayload(ctx *gin.Context) (io.ReadCloser, string, error) {
+	format := strings.ToLower(ctx.Query("format"))
+	contentType := ctx.ContentType()
+
+	if contentType == "multipart/form-data" {
+		file, header, err := ctx.Request.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+		if format != "csv" {
+			format = "json"
+		}
+		return file, format, nil
+	}
+
+	if format == "" && (contentType == "text/csv" || contentType == "application/csv") {
+		format = "csv"
+	}
+	if format != "csv" {
+		format = "json"
+	}
+	return ctx.Request.Body, format, nil
+}
+
+// ExtractPagination reads the page, limit, cursor and count query parameters.
+// A page number selects offset pagination; without one, pages are addressed by cursor.
+func ExtractPagination(ctx *gin.Context) pagination.Request {
+	var pageRequest pagination.Request
+	if pageStr, ok := ctx.GetQuery("page"); ok {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil || page <= 0 {
+			page = 1
+		}
+		pageRequest.Page = page
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
 	if err != nil || limit <= 0 {
 		limit = 10
 	}
+	pageRequest.Limit = limit
+	pageRequest.Cursor = ctx.Query("cursor")
+	pageRequest.Count, _ = strconv.ParseBool(ctx.Query("count"))
+	return pageRequest
+}
 
-	return page, limit
+// pageLinks builds the links to the pages around the current one from the request URL.
+func pageLinks(ctx *gin.Context, page response.Pagination) response.PageLinks {
+	link := func(set func(query url.Values)) string {
+		query := ctx.Request.URL.Query()
+		query.Del("page")
+		query.Del("cursor")
+		set(query)
+		return ctx.Request.URL.Path + "?" + query.Encode()
+	}
+
+	var links response.PageLinks
+	if page.CurrentPage > 0 {
+		if page.TotalPages != nil && page.CurrentPage < *page.TotalPages {
+			links.Next = link(func(query url.Values) { query.Set("page", strconv.Itoa(page.CurrentPage+1)) })
+		}
+		if page.CurrentPage > 1 {
+			links.Prev = link(func(query url.Values) { query.Set("page", strconv.Itoa(page.CurrentPage-1)) })
+		}
+		return links
+	}
+	if page.NextCursor != "" {
+		links.Next = link(func(query url.Values) { query.Set("cursor", page.NextCursor) })
+	}
+	if page.PrevCursor != "" {
+		links.Prev = link(func(query url.Values) { query.Set("cursor", page.PrevCursor) })
+	}
+	return links
 }
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/realtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/websocket"
+)
+
+// eventsHeartbeat is how often idle streams are pinged so that proxies keep them open.
+const eventsHeartbeat = 25 * time.Second
+
+// EventsController streams realtime order and dish events over Server-Sent Events and
+// WebSockets. Staff receive every order of the restaurant, customers their own orders;
+// both receive dish availability changes.
+type EventsController struct {
+	Hub      realtime.Hub
+	Shutdown context.Context // Done once the server shuts down, ending every stream
+}
+
+// NewEventsController creates a new instance of EventsController. Streams are closed once
+// shutdown is done, so that clients reconnect to another instance instead of holding the
+// server up until its shutdown times out.
+func NewEventsController(hub realtime.Hub, shutdown context.Context) *EventsController {
+	return &EventsController{Hub: hub, Shutdown: shutdown}
+}
+
+// Stream sends events as Server-Sent Events. Reconnecting clients resume after the
+// Last-Event-ID header, which EventSource sends automatically.
+func (controller *EventsController) Stream(ctx *gin.Context) {
+	requestID, subscription, ok := controller.subscribe(ctx)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	// Streams outlive the write timeout of the server
+	http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, open := <-subscription.Events:
+			if !open {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Error().Str("request_id", requestID).Err(err).Msg("Error encoding event")
+				continue
+			}
+			// Events without an ID, such as resets, leave the client's last event ID alone
+			if event.ID != "" {
+				if _, err := fmt.Fprintf(ctx.Writer, "id: %s\n", event.ID); err != nil {
+					return
+				}
+			}
+			if _, err := fmt.Fprintf(ctx.Writer, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-ctx.Request.Context().Done():
+			return
+		case <-controller.Shutdown.Done():
+			return
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// WebSocket sends events as JSON WebSocket messages. Clients resume by passing the ID of
+// the last event they received in the lastEventId query parameter.
+func (controller *EventsController) WebSocket(ctx *gin.Context) {
+	requestID, subscription, ok := controller.subscribe(ctx)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	server := websocket.Server{
+		// Browsers cannot send the JWT in a header, so the origin is not what authenticates them
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			// Clients do not send messages; reading only detects the connection closing
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard []byte
+				for websocket.Message.Receive(conn, &discard) == nil {
+				}
+			}()
+
+			heartbeat := time.NewTicker(eventsHeartbeat)
+			defer heartbeat.Stop()
+			for {
+				select {
+				case event, open := <-subscription.Events:
+					if !open {
+						return
+					}
+					if err := websocket.JSON.Send(conn, event); err != nil {
+						return
+					}
+				case <-heartbeat.C:
+					if err := websocket.JSON.Send(conn, gin.H{"type": "ping"}); err != nil {
+						return
+					}
+				case <-closed:
+					return
+				case <-controller.Shutdown.Done():
+					return
+				}
+			}
+		},
+	}
+	// The connection outlives the read and write timeouts of the server once upgraded
+	responseController := http.NewResponseController(ctx.Writer)
+	responseController.SetReadDeadline(time.Time{})
+	responseController.SetWriteDeadline(time.Time{})
+
+	log.Info().Str("request_id", requestID).Msg("WebSocket event stream opened")
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// subscribe subscribes the caller to the events of their restaurant, resuming after the
+// Last-Event-ID header or lastEventId query parameter when given.
+func (controller *EventsController) subscribe(ctx *gin.Context) (string, *realtime.Subscription, bool) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return "", nil, false
+	}
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("lastEventId")
+	}
+	subscriber := realtime.Subscriber{
+		RestaurantID: restaurantId,
+		UserID:       userId.String(),
+		Staff:        isStaff(ctx),
+	}
+
+	subscription, err := controller.Hub.Subscribe(ctx.Request.Context(), subscriber, lastEventID)
+	if err != nil {
+		helper.LogInformation(ctx, http.StatusServiceUnavailable, "Error subscribing to events", err, requestID)
+		return "", nil, false
+	}
+	return requestID, subscription, true
+}
+
+// isStaff reports whether the authenticated user works for the restaurant or is an admin.
+func isStaff(ctx *gin.Context) bool {
+	permissions, _ := ctx.Get("permissions")
+	permArray, _ := permissions.([]model.Permission)
+	for _, perm := range permArray {
+		if perm.Name == "restaurant" || perm.Name == "admin" {
+			return true
+		}
+	}
+	return false
+}
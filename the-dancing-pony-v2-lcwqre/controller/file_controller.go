@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"the-dancing-pony-v2-lcwqre/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// FilesController serves and accepts objects of the local and memory stores through signed links.
+type FilesController struct {
+	ObjectStore storage.ObjectStore
+}
+
+// NewFilesController creates a new instance of FilesController.
+func NewFilesController(objectStore storage.ObjectStore) *FilesController {
+	return &FilesController{ObjectStore: objectStore}
+}
+
+// Serve streams an object after checking the signature and expiry of its link.
+func (controller *FilesController) Serve(ctx *gin.Context) {
+	fileServer, ok := controller.ObjectStore.(storage.FileServer)
+	if !ok {
+		// Objects of external stores are fetched from the store directly
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	expires, err := strconv.ParseInt(ctx.Query("expires"), 10, 64)
+	if err != nil || !fileServer.VerifySignature(key, expires, ctx.Query("sig")) {
+		log.Warn().Str("key", key).Msg("Invalid or expired file signature")
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired link"})
+		return
+	}
+
+	body, info, err := fileServer.Get(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		log.Error().Str("key", key).Err(err).Msg("Error reading file")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error reading file"})
+		return
+	}
+	defer body.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.Header("Cache-Control", "private, max-age=3600")
+	ctx.DataFromReader(http.StatusOK, info.Size, contentType, body, nil)
+}
+
+// Upload stores the request body under a key after checking the signature, expiry and size limit of its link.
+// It stands in for direct-to-bucket uploads when the store has no upload endpoint of its own.
+func (controller *FilesController) Upload(ctx *gin.Context) {
+	fileServer, ok := controller.ObjectStore.(storage.FileServer)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	expires, expiresErr := strconv.ParseInt(ctx.Query("expires"), 10, 64)
+	maxBytes, maxErr := strconv.ParseInt(ctx.Query("max"), 10, 64)
+	if expiresErr != nil || maxErr != nil || !fileServer.VerifyUploadSignature(key, expires, maxBytes, ctx.Query("sig")) {
+		log.Warn().Str("key", key).Msg("Invalid or expired upload signature")
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired link"})
+		return
+	}
+	if ctx.Request.ContentLength > maxBytes {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File exceeds the maximum upload size"})
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes)
+	if err := fileServer.Put(ctx, key, body, ctx.ContentType()); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File exceeds the maximum upload size"})
+			return
+		}
+		log.Error().Str("key", key).Err(err).Msg("Error storing uploaded file")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error storing file"})
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// FloorPlanController handles staff requests for dining areas, tables and the reservation schedule.
+type FloorPlanController struct {
+	FloorPlanService service.FloorPlanService
+	Validate         *validator.Validate
+}
+
+// NewFloorPlanController creates a new instance of FloorPlanController.
+func NewFloorPlanController(service service.FloorPlanService) *FloorPlanController {
+	return &FloorPlanController{
+		FloorPlanService: service,
+		Validate:         validator.New(),
+	}
+}
+
+// CreateArea creates a dining area.
+func (controller *FloorPlanController) CreateArea(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var areaRequest request.DiningAreaRequest
+	if !helper.ValidateRequest(ctx, &areaRequest, controller.Validate, requestID) {
+		return
+	}
+
+	area, err := controller.FloorPlanService.CreateArea(areaRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondFloorPlanError(ctx, err, "Error creating dining area", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Dining area created successfully",
+		Status:  "Ok",
+		Data:    area,
+	})
+}
+
+// ListAreas retrieves the dining areas of the restaurant.
+func (controller *FloorPlanController) ListAreas(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	areas, err := controller.FloorPlanService.FindAreas(userId, requestID, restaurantId)
+	if err != nil {
+		respondFloorPlanError(ctx, err, "Error retrieving dining areas", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dining areas retrieved successfully",
+		Status:  "Ok",
+		Data:    areas,
+	})
+}
+
+// DeleteArea deletes a dining area; its tables stay, without an area.
+func (controller *FloorPlanController) DeleteArea(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	areaId, ok := parseUUIDParam(ctx, "areaId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.FloorPlanService.DeleteArea(areaId, userId, requestID, restaurantId); err != nil {
+		respondFloorPlanError(ctx, err, "Error deleting dining area", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dining area deleted successfully",
+		Status:  "Ok",
+		Data:    nil,
+	})
+}
+
+// CreateTable creates a table.
+func (controller *FloorPlanController) CreateTable(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var tableRequest request.DiningTableRequest
+	if !helper.ValidateRequest(ctx, &tableRequest, controller.Validate, requestID) {
+		return
+	}
+
+	table, err := controller.FloorPlanService.CreateTable(tableRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondFloorPlanError(ctx, err, "Error creating table", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Table created successfully",
+		Status:  "Ok",
+		Data:    table,
+	})
+}
+
+// ListTables retrieves the tables of the restaurant.
+func (controller *FloorPlanController) ListTables(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	tables, err := controller.FloorPlanService.FindTables(userId, requestID, restaurantId)
+	if err != nil {
+		respondFloorPlanError(ctx, err, "Error retrieving tables", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Tables retrieved successfully",
+		Status:  "Ok",
+		Data:    tables,
+	})
+}
+
+// ReplaceTable replaces a table.
+func (controller *FloorPlanController) ReplaceTable(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	tableId, ok := parseUUIDParam(ctx, "tableId", requestID)
+	if !ok {
+		return
+	}
+
+	var tableRequest request.DiningTableRequest
+	if !helper.ValidateRequest(ctx, &tableRequest, controller.Validate, requestID) {
+		return
+	}
+
+	table, err := controller.FloorPlanService.ReplaceTable(tableRequest, tableId, userId, requestID, restaurantId)
+	if err != nil {
+		respondFloorPlanError(ctx, err, "Error updating table", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Table updated successfully",
+		Status:  "Ok",
+		Data:    table,
+	})
+}
+
+// DeleteTable deletes a table that no upcoming reservation holds.
+func (controller *FloorPlanController) DeleteTable(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	tableId, ok := parseUUIDParam(ctx, "tableId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.FloorPlanService.DeleteTable(tableId, userId, requestID, restaurantId); err != nil {
+		respondFloorPlanError(ctx, err, "Error deleting table", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Table deleted successfully",
+		Status:  "Ok",
+		Data:    nil,
+	})
+}
+
+// Schedule retrieves the restaurant's reservation settings and opening hours.
+func (controller *FloorPlanController) Schedule(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	schedule, err := controller.FloorPlanService.FindSchedule(userId, requestID, restaurantId)
+	if err != nil {
+		respondFloorPlanError(ctx, err, "Error retrieving reservation schedule", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reservation schedule retrieved successfully",
+		Status:  "Ok",
+		Data:    schedule,
+	})
+}
+
+// SaveSchedule replaces the restaurant's reservation settings and opening hours.
+func (controller *FloorPlanController) SaveSchedule(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var scheduleRequest request.ScheduleRequest
+	if !helper.ValidateRequest(ctx, &scheduleRequest, controller.Validate, requestID) {
+		return
+	}
+
+	schedule, err := controller.FloorPlanService.SaveSchedule(scheduleRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondFloorPlanError(ctx, err, "Error saving reservation schedule", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reservation schedule saved successfully",
+		Status:  "Ok",
+		Data:    schedule,
+	})
+}
+
+// respondFloorPlanError maps floor plan service errors to HTTP status codes.
+func respondFloorPlanError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Dining area or table not found", err, requestID)
+	case errors.Is(err, service.ErrDuplicateAreaName),
+		errors.Is(err, service.ErrDuplicateTableName),
+		errors.Is(err, repository.ErrTableHasReservations):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrUnknownDiningArea), errors.Is(err, service.ErrInvalidTableCapacity):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
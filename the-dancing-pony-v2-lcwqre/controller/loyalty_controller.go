@@ -0,0 +1,309 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoyaltyController handles customer and staff requests for loyalty points and rewards.
+type LoyaltyController struct {
+	LoyaltyService service.LoyaltyService
+	Validate       *validator.Validate
+}
+
+// NewLoyaltyController creates a new instance of LoyaltyController.
+func NewLoyaltyController(service service.LoyaltyService) *LoyaltyController {
+	return &LoyaltyController{
+		LoyaltyService: service,
+		Validate:       validator.New(),
+	}
+}
+
+// Account retrieves the signed-in customer's balance and the rewards on offer.
+func (controller *LoyaltyController) Account(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	controller.account(ctx, userId, userId, requestID, restaurantId)
+}
+
+// Ledger retrieves the signed-in customer's points ledger.
+func (controller *LoyaltyController) Ledger(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	controller.ledger(ctx, userId, userId, requestID, restaurantId)
+}
+
+// Rewards retrieves the active rewards of the restaurant.
+func (controller *LoyaltyController) Rewards(ctx *gin.Context) {
+	controller.rewards(ctx, true)
+}
+
+// StaffAccount retrieves a customer's balance.
+func (controller *LoyaltyController) StaffAccount(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	customerId, ok := parseUUIDParam(ctx, "customerId", requestID)
+	if !ok {
+		return
+	}
+	controller.account(ctx, customerId, userId, requestID, restaurantId)
+}
+
+// StaffLedger retrieves a customer's points ledger.
+func (controller *LoyaltyController) StaffLedger(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	customerId, ok := parseUUIDParam(ctx, "customerId", requestID)
+	if !ok {
+		return
+	}
+	controller.ledger(ctx, customerId, userId, requestID, restaurantId)
+}
+
+// Adjust credits or debits a customer's points.
+func (controller *LoyaltyController) Adjust(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	customerId, ok := parseUUIDParam(ctx, "customerId", requestID)
+	if !ok {
+		return
+	}
+
+	var adjustmentRequest request.LoyaltyAdjustmentRequest
+	if !helper.ValidateRequest(ctx, &adjustmentRequest, controller.Validate, requestID) {
+		return
+	}
+
+	entry, err := controller.LoyaltyService.Adjust(adjustmentRequest, customerId, userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error adjusting points", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Points adjusted successfully",
+		Status:  "Ok",
+		Data:    entry,
+	})
+}
+
+// FindProgram retrieves the loyalty configuration of the restaurant.
+func (controller *LoyaltyController) FindProgram(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	program, err := controller.LoyaltyService.FindProgram(userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error retrieving loyalty program", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Loyalty program retrieved successfully",
+		Status:  "Ok",
+		Data:    program,
+	})
+}
+
+// SaveProgram replaces the loyalty configuration of the restaurant.
+func (controller *LoyaltyController) SaveProgram(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var programRequest request.LoyaltyProgramRequest
+	if !helper.ValidateRequest(ctx, &programRequest, controller.Validate, requestID) {
+		return
+	}
+
+	program, err := controller.LoyaltyService.SaveProgram(programRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error saving loyalty program", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Loyalty program saved successfully",
+		Status:  "Ok",
+		Data:    program,
+	})
+}
+
+// StaffRewards retrieves every reward of the restaurant, inactive ones included.
+func (controller *LoyaltyController) StaffRewards(ctx *gin.Context) {
+	controller.rewards(ctx, false)
+}
+
+// CreateReward creates a reward.
+func (controller *LoyaltyController) CreateReward(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var rewardRequest request.LoyaltyRewardRequest
+	if !helper.ValidateRequest(ctx, &rewardRequest, controller.Validate, requestID) {
+		return
+	}
+
+	reward, err := controller.LoyaltyService.CreateReward(rewardRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error creating reward", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Reward created successfully",
+		Status:  "Ok",
+		Data:    reward,
+	})
+}
+
+// ReplaceReward replaces a reward.
+func (controller *LoyaltyController) ReplaceReward(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	rewardId, ok := parseUUIDParam(ctx, "rewardId", requestID)
+	if !ok {
+		return
+	}
+
+	var rewardRequest request.LoyaltyRewardRequest
+	if !helper.ValidateRequest(ctx, &rewardRequest, controller.Validate, requestID) {
+		return
+	}
+
+	reward, err := controller.LoyaltyService.ReplaceReward(rewardRequest, rewardId, userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error updating reward", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reward updated successfully",
+		Status:  "Ok",
+		Data:    reward,
+	})
+}
+
+// DeleteReward deletes a reward.
+func (controller *LoyaltyController) DeleteReward(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	rewardId, ok := parseUUIDParam(ctx, "rewardId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.LoyaltyService.DeleteReward(rewardId, userId, requestID, restaurantId); err != nil {
+		respondLoyaltyError(ctx, err, "Error deleting reward", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reward deleted successfully",
+		Status:  "Ok",
+		Data:    nil,
+	})
+}
+
+// account responds with a customer's balance.
+func (controller *LoyaltyController) account(ctx *gin.Context, customerId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) {
+	account, err := controller.LoyaltyService.FindAccount(customerId, userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error retrieving points", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Points retrieved successfully",
+		Status:  "Ok",
+		Data:    account,
+	})
+}
+
+// ledger responds with a page of a customer's points ledger.
+func (controller *LoyaltyController) ledger(ctx *gin.Context, customerId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) {
+	ledger, err := controller.LoyaltyService.FindLedger(customerId, ExtractPagination(ctx), userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error retrieving points ledger", requestID)
+		return
+	}
+	ledger.Links = pageLinks(ctx, ledger.Pagination)
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Points ledger retrieved successfully",
+		Status:  "Ok",
+		Data:    ledger,
+	})
+}
+
+// rewards responds with the rewards of the restaurant, optionally only the active ones.
+func (controller *LoyaltyController) rewards(ctx *gin.Context, activeOnly bool) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	rewards, err := controller.LoyaltyService.FindRewards(activeOnly, userId, requestID, restaurantId)
+	if err != nil {
+		respondLoyaltyError(ctx, err, "Error retrieving rewards", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Rewards retrieved successfully",
+		Status:  "Ok",
+		Data:    rewards,
+	})
+}
+
+// respondLoyaltyError maps loyalty service errors to HTTP status codes.
+func respondLoyaltyError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Not found", err, requestID)
+	case errors.Is(err, repository.ErrInsufficientPoints):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
+
+// isRewardError reports whether err explains why a loyalty reward cannot be redeemed
+// with an order, which the customer can act on.
+func isRewardError(err error) bool {
+	return errors.Is(err, service.ErrLoyaltyDisabled) ||
+		errors.Is(err, service.ErrUnknownReward)
+}
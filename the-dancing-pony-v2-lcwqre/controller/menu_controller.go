@@ -0,0 +1,266 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// MenuController handles draft, publish and scheduling requests for a restaurant's menu.
+type MenuController struct {
+	MenuService service.MenuService
+	Validate    *validator.Validate
+}
+
+// NewMenuController creates a new instance of MenuController.
+func NewMenuController(service service.MenuService) *MenuController {
+	return &MenuController{
+		MenuService: service,
+		Validate:    validator.New(),
+	}
+}
+
+// DraftChange stores a draft edit to a dish.
+func (controller *MenuController) DraftChange(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+
+	var changeRequest request.DishChangeRequest
+	if !helper.ValidateRequest(ctx, &changeRequest, controller.Validate, requestID) {
+		return
+	}
+
+	change, err := controller.MenuService.DraftChange(changeRequest, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondMenuError(ctx, err, "Error drafting dish change", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Dish change saved",
+		Status:  "Ok",
+		Data:    change,
+	})
+}
+
+// ListChanges lists the draft and scheduled changes of a dish.
+func (controller *MenuController) ListChanges(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+
+	changes, err := controller.MenuService.ListChanges(dishId, restaurantId, userId, requestID)
+	if err != nil {
+		respondMenuError(ctx, err, "Error retrieving dish changes", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dish changes retrieved successfully",
+		Status:  "Ok",
+		Data:    changes,
+	})
+}
+
+// PublishChange applies a draft change now or schedules it.
+func (controller *MenuController) PublishChange(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	changeId, ok := parseUUIDParam(ctx, "changeId", requestID)
+	if !ok {
+		return
+	}
+	publishRequest, ok := controller.bindPublishRequest(ctx, requestID)
+	if !ok {
+		return
+	}
+
+	change, err := controller.MenuService.PublishChange(publishRequest, changeId, userId, requestID, restaurantId)
+	if err != nil {
+		respondMenuError(ctx, err, "Error publishing dish change", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: publishMessage(change),
+		Status:  "Ok",
+		Data:    change,
+	})
+}
+
+// DiscardChange cancels a draft or scheduled change.
+func (controller *MenuController) DiscardChange(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	changeId, ok := parseUUIDParam(ctx, "changeId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.MenuService.DiscardChange(changeId, restaurantId, userId, requestID); err != nil {
+		respondMenuError(ctx, err, "Error discarding dish change", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Dish change discarded",
+		Status:  "Ok",
+	})
+}
+
+// PublishDish makes a draft dish visible to customers now or at a future time.
+func (controller *MenuController) PublishDish(ctx *gin.Context) {
+	controller.changeVisibility(ctx, controller.MenuService.PublishDish)
+}
+
+// UnpublishDish hides a dish from customers now or at a future time.
+func (controller *MenuController) UnpublishDish(ctx *gin.Context) {
+	controller.changeVisibility(ctx, controller.MenuService.UnpublishDish)
+}
+
+// Preview returns the menu as customers will see it at the time given by the "at" query parameter.
+func (controller *MenuController) Preview(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	at := time.Now()
+	if atStr := ctx.Query("at"); atStr != "" {
+		at, err = parseMenuTime(atStr)
+		if err != nil {
+			log.Error().
+				Str("request_id", requestID).
+				Str("at", atStr).
+				Msg("Invalid preview time")
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'at', expected an RFC 3339 time or YYYY-MM-DD date"})
+			return
+		}
+	}
+
+	preview, err := controller.MenuService.Preview(at, restaurantId, userId, requestID)
+	if err != nil {
+		respondMenuError(ctx, err, "Error previewing menu", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Menu preview retrieved successfully",
+		Status:  "Ok",
+		Data:    preview,
+	})
+}
+
+type visibilityFunc func(request.PublishRequest, uuid.UUID, uuid.UUID, string, string) (response.DishChangeResponse, error)
+
+// changeVisibility handles the shared flow of the publish and unpublish endpoints.
+func (controller *MenuController) changeVisibility(ctx *gin.Context, apply visibilityFunc) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	dishId, ok := parseUUIDParam(ctx, "dishId", requestID)
+	if !ok {
+		return
+	}
+	publishRequest, ok := controller.bindPublishRequest(ctx, requestID)
+	if !ok {
+		return
+	}
+
+	change, err := apply(publishRequest, dishId, userId, requestID, restaurantId)
+	if err != nil {
+		respondMenuError(ctx, err, "Error changing dish visibility", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: publishMessage(change),
+		Status:  "Ok",
+		Data:    change,
+	})
+}
+
+// bindPublishRequest reads the optional publish payload; an empty body means publish now.
+func (controller *MenuController) bindPublishRequest(ctx *gin.Context, requestID string) (request.PublishRequest, bool) {
+	var publishRequest request.PublishRequest
+	if ctx.Request.ContentLength == 0 {
+		return publishRequest, true
+	}
+	if !helper.ValidateRequest(ctx, &publishRequest, controller.Validate, requestID) {
+		return publishRequest, false
+	}
+	return publishRequest, true
+}
+
+// publishMessage describes whether a change went live or was scheduled.
+func publishMessage(change response.DishChangeResponse) string {
+	if change.AppliedAt == nil {
+		return "Dish change scheduled"
+	}
+	return "Dish change published"
+}
+
+// parseMenuTime accepts either a full RFC 3339 timestamp or a plain date (start of day, UTC).
+func parseMenuTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// parseUUIDParam parses a UUID path parameter and writes a 400 response when it is malformed.
+func parseUUIDParam(ctx *gin.Context, name string, requestID string) (uuid.UUID, bool) {
+	value := ctx.Param(name)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str(name, value).
+			Msgf("Invalid %s format", name)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " format"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondMenuError maps menu service errors to HTTP status codes.
+func respondMenuError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Dish or change not found", err, requestID)
+	case errors.Is(err, repository.ErrDishChangeNotPending):
+		helper.LogInformation(ctx, http.StatusConflict, "Dish change has already been applied or discarded", err, requestID)
+	case errors.Is(err, service.ErrEmptyDishChange):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// OrdersController handles customer and staff requests for orders.
+type OrdersController struct {
+	OrderService service.OrderService
+	Validate     *validator.Validate
+}
+
+// NewOrdersController creates a new instance of OrdersController.
+func NewOrdersController(service service.OrderService) *OrdersController {
+	return &OrdersController{
+		OrderService: service,
+		Validate:     validator.New(),
+	}
+}
+
+// Place creates an order for the current customer.
+func (controller *OrdersController) Place(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var orderRequest request.PlaceOrderRequest
+	if !helper.ValidateRequest(ctx, &orderRequest, controller.Validate, requestID) {
+		return
+	}
+
+	order, err := controller.OrderService.Place(orderRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondOrderError(ctx, err, "Error placing order", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Order placed successfully",
+		Status:  "Ok",
+		Data:    order,
+	})
+}
+
+// List retrieves the current customer's orders with pagination.
+func (controller *OrdersController) List(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	orders, err := controller.OrderService.FindByCustomer(ExtractPagination(ctx), userId, requestID, restaurantId)
+	if err != nil {
+		respondOrderError(ctx, err, "Error retrieving orders", requestID)
+		return
+	}
+	orders.Links = pageLinks(ctx, orders.Pagination)
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Orders retrieved successfully",
+		Status:  "Ok",
+		Data:    orders,
+	})
+}
+
+// FindById retrieves one of the current customer's orders.
+func (controller *OrdersController) FindById(ctx *gin.Context) {
+	controller.findById(ctx, model.RoleCustomer)
+}
+
+// Cancel cancels one of the current customer's orders. The body with a reason is optional.
+func (controller *OrdersController) Cancel(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	orderId, ok := parseUUIDParam(ctx, "orderId", requestID)
+	if !ok {
+		return
+	}
+
+	var cancelRequest request.CancelOrderRequest
+	if ctx.Request.ContentLength != 0 && !helper.ValidateRequest(ctx, &cancelRequest, controller.Validate, requestID) {
+		return
+	}
+
+	transitionRequest := request.OrderTransitionRequest{
+		Status: model.OrderStatusCancelled,
+		Reason: cancelRequest.Reason,
+	}
+	order, err := controller.OrderService.Transition(transitionRequest, orderId, userId, model.RoleCustomer, requestID, restaurantId)
+	if err != nil {
+		respondOrderError(ctx, err, "Error cancelling order", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Order cancelled",
+		Status:  "Ok",
+		Data:    order,
+	})
+}
+
+// StaffList retrieves the restaurant's orders, optionally filtered by a comma-separated "status" query parameter.
+func (controller *OrdersController) StaffList(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var statuses []string
+	for _, status := range strings.Split(ctx.Query("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+
+	orders, err := controller.OrderService.FindByRestaurant(statuses, ExtractPagination(ctx), userId, requestID, restaurantId)
+	if err != nil {
+		respondOrderError(ctx, err, "Error retrieving orders", requestID)
+		return
+	}
+	orders.Links = pageLinks(ctx, orders.Pagination)
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Orders retrieved successfully",
+		Status:  "Ok",
+		Data:    orders,
+	})
+}
+
+// StaffFindById retrieves any order of the restaurant.
+func (controller *OrdersController) StaffFindById(ctx *gin.Context) {
+	controller.findById(ctx, model.RoleStaff)
+}
+
+// Transition moves an order to the requested status on behalf of staff.
+func (controller *OrdersController) Transition(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	orderId, ok := parseUUIDParam(ctx, "orderId", requestID)
+	if !ok {
+		return
+	}
+
+	var transitionRequest request.OrderTransitionRequest
+	if !helper.ValidateRequest(ctx, &transitionRequest, controller.Validate, requestID) {
+		return
+	}
+
+	order, err := controller.OrderService.Transition(transitionRequest, orderId, userId, model.RoleStaff, requestID, restaurantId)
+	if err != nil {
+		respondOrderError(ctx, err, "Error changing order status", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Order status changed to " + order.Status,
+		Status:  "Ok",
+		Data:    order,
+	})
+}
+
+// findById handles the shared flow of the customer and staff order lookups.
+func (controller *OrdersController) findById(ctx *gin.Context, role string) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	orderId, ok := parseUUIDParam(ctx, "orderId", requestID)
+	if !ok {
+		return
+	}
+
+	order, err := controller.OrderService.FindById(orderId, userId, role, requestID, restaurantId)
+	if err != nil {
+		respondOrderError(ctx, err, "Error retrieving order", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Order retrieved successfully",
+		Status:  "Ok",
+		Data:    order,
+	})
+}
+
+// respondOrderError maps order service errors to HTTP status codes.
+func respondOrderError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Order not found", err, requestID)
+	case errors.Is(err, service.ErrOrderTransitionForbidden):
+		helper.LogInformation(ctx, http.StatusForbidden, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrInvalidOrderTransition):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, repository.ErrOrderStatusChanged):
+		helper.LogInformation(ctx, http.StatusConflict, "Order status changed concurrently, reload and retry", err, requestID)
+	case errors.Is(err, repository.ErrPromotionUnavailable),
+		errors.Is(err, repository.ErrRewardUnavailable),
+		errors.Is(err, repository.ErrInsufficientPoints):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrDishUnavailable), errors.Is(err, service.ErrUnknownOrderStatus), isCouponError(err), isRewardError(err):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/payments"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// maxWebhookBytes caps the size of provider webhook bodies.
+const maxWebhookBytes = 1 << 20
+
+// PaymentsController handles order payments, refunds, provider webhooks and the pages
+// of stand-in providers.
+type PaymentsController struct {
+	PaymentService service.PaymentService
+	Validate       *validator.Validate
+	standIn        http.Handler
+}
+
+// NewPaymentsController creates a new instance of PaymentsController.
+func NewPaymentsController(service service.PaymentService, provider payments.PaymentProvider) *PaymentsController {
+	controller := &PaymentsController{
+		PaymentService: service,
+		Validate:       validator.New(),
+	}
+	if standIn, ok := provider.(payments.StandIn); ok {
+		controller.standIn = standIn.Handler()
+	}
+	return controller
+}
+
+// Pay starts a payment of one of the current customer's orders.
+func (controller *PaymentsController) Pay(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	orderId, ok := parseUUIDParam(ctx, "orderId", requestID)
+	if !ok {
+		return
+	}
+
+	var payRequest request.PayOrderRequest
+	if !helper.ValidateRequest(ctx, &payRequest, controller.Validate, requestID) {
+		return
+	}
+
+	payment, err := controller.PaymentService.Pay(ctx, payRequest, orderId, userId, requestID, restaurantId)
+	if err != nil {
+		respondPaymentError(ctx, err, "Error paying for order", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Payment " + payment.Status,
+		Status:  "Ok",
+		Data:    payment,
+	})
+}
+
+// List retrieves the payments of one of the current customer's orders.
+func (controller *PaymentsController) List(ctx *gin.Context) {
+	controller.list(ctx, model.RoleCustomer)
+}
+
+// StaffList retrieves the payments of any order of the restaurant.
+func (controller *PaymentsController) StaffList(ctx *gin.Context) {
+	controller.list(ctx, model.RoleStaff)
+}
+
+// FindById retrieves a payment of the restaurant.
+func (controller *PaymentsController) FindById(ctx *gin.Context) {
+	requestID, _, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	paymentId, ok := parseUUIDParam(ctx, "paymentId", requestID)
+	if !ok {
+		return
+	}
+
+	payment, err := controller.PaymentService.FindById(paymentId, requestID, restaurantId)
+	if err != nil {
+		respondPaymentError(ctx, err, "Error retrieving payment", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Payment retrieved successfully",
+		Status:  "Ok",
+		Data:    payment,
+	})
+}
+
+// Capture collects an authorised payment.
+func (controller *PaymentsController) Capture(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	paymentId, ok := parseUUIDParam(ctx, "paymentId", requestID)
+	if !ok {
+		return
+	}
+
+	payment, err := controller.PaymentService.Capture(ctx, paymentId, userId, requestID, restaurantId)
+	if err != nil {
+		respondPaymentError(ctx, err, "Error capturing payment", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Payment captured",
+		Status:  "Ok",
+		Data:    payment,
+	})
+}
+
+// Refund returns part or all of a payment. Retries should repeat the Idempotency-Key header.
+func (controller *PaymentsController) Refund(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	paymentId, ok := parseUUIDParam(ctx, "paymentId", requestID)
+	if !ok {
+		return
+	}
+
+	var refundRequest request.RefundRequest
+	if ctx.Request.ContentLength != 0 && !helper.ValidateRequest(ctx, &refundRequest, controller.Validate, requestID) {
+		return
+	}
+	idempotencyKey := ctx.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > 100 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 100 characters"})
+		return
+	}
+
+	payment, err := controller.PaymentService.Refund(ctx, refundRequest, idempotencyKey, paymentId, userId, requestID, restaurantId)
+	if err != nil {
+		respondPaymentError(ctx, err, "Error refunding payment", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Refund requested",
+		Status:  "Ok",
+		Data:    payment,
+	})
+}
+
+// Webhook receives provider webhooks. Failures other than a bad signature answer with
+// an error status so that the provider redelivers the event.
+func (controller *PaymentsController) Webhook(ctx *gin.Context) {
+	requestID := ctx.GetString("request_id")
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxWebhookBytes))
+	if err != nil {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Webhook body is too large"})
+		return
+	}
+
+	if err := controller.PaymentService.HandleWebhook(ctx.Request.Header, body, requestID); err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature"})
+			return
+		}
+		respondPaymentError(ctx, err, "Error processing webhook", requestID)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// StandIn serves the pages of stand-in providers, such as fake 3-D Secure challenges.
+func (controller *PaymentsController) StandIn(ctx *gin.Context) {
+	if controller.standIn == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	controller.standIn.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// list handles the shared flow of the customer and staff payment lists.
+func (controller *PaymentsController) list(ctx *gin.Context, role string) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	orderId, ok := parseUUIDParam(ctx, "orderId", requestID)
+	if !ok {
+		return
+	}
+
+	paymentResponses, err := controller.PaymentService.FindByOrder(orderId, userId, role, requestID, restaurantId)
+	if err != nil {
+		respondPaymentError(ctx, err, "Error retrieving payments", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Payments retrieved successfully",
+		Status:  "Ok",
+		Data:    paymentResponses,
+	})
+}
+
+// respondPaymentError maps payment service errors to HTTP status codes.
+func respondPaymentError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Order or payment not found", err, requestID)
+	case errors.Is(err, service.ErrOrderNotPayable),
+		errors.Is(err, service.ErrPaymentNotCapturable),
+		errors.Is(err, repository.ErrPaymentNotRefundable),
+		errors.Is(err, repository.ErrRefundExceedsPayment),
+		errors.Is(err, repository.ErrPaymentStatusChanged):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, payments.ErrNotCapturable), errors.Is(err, payments.ErrIntentNotFound):
+		helper.LogInformation(ctx, http.StatusBadGateway, "Payment provider refused the request", err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
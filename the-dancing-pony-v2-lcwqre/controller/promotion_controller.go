@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// PromotionsController handles staff requests for promotions and coupons.
+type PromotionsController struct {
+	PromotionService service.PromotionService
+	Validate         *validator.Validate
+}
+
+// NewPromotionsController creates a new instance of PromotionsController.
+func NewPromotionsController(service service.PromotionService) *PromotionsController {
+	return &PromotionsController{
+		PromotionService: service,
+		Validate:         validator.New(),
+	}
+}
+
+// Create creates a promotion, or a coupon when it has a code.
+func (controller *PromotionsController) Create(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var promotionRequest request.PromotionRequest
+	if !helper.ValidateRequest(ctx, &promotionRequest, controller.Validate, requestID) {
+		return
+	}
+
+	promotion, err := controller.PromotionService.Create(promotionRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondPromotionError(ctx, err, "Error creating promotion", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Promotion created successfully",
+		Status:  "Ok",
+		Data:    promotion,
+	})
+}
+
+// List retrieves the promotions of the restaurant in the order they are applied.
+func (controller *PromotionsController) List(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	promotions, err := controller.PromotionService.FindAll(userId, requestID, restaurantId)
+	if err != nil {
+		respondPromotionError(ctx, err, "Error retrieving promotions", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Promotions retrieved successfully",
+		Status:  "Ok",
+		Data:    promotions,
+	})
+}
+
+// FindById retrieves a promotion with the number of its redemptions.
+func (controller *PromotionsController) FindById(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	promotionId, ok := parseUUIDParam(ctx, "promotionId", requestID)
+	if !ok {
+		return
+	}
+
+	promotion, err := controller.PromotionService.FindById(promotionId, userId, requestID, restaurantId)
+	if err != nil {
+		respondPromotionError(ctx, err, "Error retrieving promotion", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Promotion retrieved successfully",
+		Status:  "Ok",
+		Data:    promotion,
+	})
+}
+
+// Replace replaces a promotion.
+func (controller *PromotionsController) Replace(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	promotionId, ok := parseUUIDParam(ctx, "promotionId", requestID)
+	if !ok {
+		return
+	}
+
+	var promotionRequest request.PromotionRequest
+	if !helper.ValidateRequest(ctx, &promotionRequest, controller.Validate, requestID) {
+		return
+	}
+
+	promotion, err := controller.PromotionService.Replace(promotionRequest, promotionId, userId, requestID, restaurantId)
+	if err != nil {
+		respondPromotionError(ctx, err, "Error updating promotion", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Promotion updated successfully",
+		Status:  "Ok",
+		Data:    promotion,
+	})
+}
+
+// Delete deletes a promotion.
+func (controller *PromotionsController) Delete(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	promotionId, ok := parseUUIDParam(ctx, "promotionId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.PromotionService.Delete(promotionId, userId, requestID, restaurantId); err != nil {
+		respondPromotionError(ctx, err, "Error deleting promotion", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Promotion deleted successfully",
+		Status:  "Ok",
+		Data:    nil,
+	})
+}
+
+// respondPromotionError maps promotion service errors to HTTP status codes.
+func respondPromotionError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Promotion not found", err, requestID)
+	case errors.Is(err, service.ErrDuplicateCouponCode):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrInvalidPromotion):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
+
+// isCouponError reports whether err explains why a coupon or promotion does not apply
+// to a cart or order, which the customer can act on.
+func isCouponError(err error) bool {
+	return errors.Is(err, service.ErrUnknownCoupon) ||
+		errors.Is(err, service.ErrPromotionNotRunning) ||
+		errors.Is(err, service.ErrPromotionFirstOrder) ||
+		errors.Is(err, service.ErrPromotionLimitReached) ||
+		errors.Is(err, service.ErrPromotionMinSpend) ||
+		errors.Is(err, service.ErrPromotionNoQualifyingItems) ||
+		errors.Is(err, service.ErrPromotionNotStackable)
+}
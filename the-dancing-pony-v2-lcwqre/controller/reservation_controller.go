@@ -0,0 +1,348 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ReservationsController handles customer and staff requests for reservations and the waitlist.
+type ReservationsController struct {
+	ReservationService service.ReservationService
+	Validate           *validator.Validate
+}
+
+// NewReservationsController creates a new instance of ReservationsController.
+func NewReservationsController(service service.ReservationService) *ReservationsController {
+	return &ReservationsController{
+		ReservationService: service,
+		Validate:           validator.New(),
+	}
+}
+
+// Availability lists the slots of the "date" query parameter (YYYY-MM-DD) and whether a
+// party of "partySize" guests can be seated at each.
+func (controller *ReservationsController) Availability(ctx *gin.Context) {
+	requestID, _, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	partySize, err := strconv.Atoi(ctx.Query("partySize"))
+	if err != nil || partySize < 1 {
+		log.Error().
+			Str("request_id", requestID).
+			Str("partySize", ctx.Query("partySize")).
+			Msg("Invalid party size")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "partySize must be a positive number"})
+		return
+	}
+
+	availability, err := controller.ReservationService.Availability(ctx.Query("date"), partySize, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error retrieving availability", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Availability retrieved successfully",
+		Status:  "Ok",
+		Data:    availability,
+	})
+}
+
+// Book reserves a table for the current customer.
+func (controller *ReservationsController) Book(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var bookRequest request.BookReservationRequest
+	if !helper.ValidateRequest(ctx, &bookRequest, controller.Validate, requestID) {
+		return
+	}
+
+	reservation, err := controller.ReservationService.Book(bookRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error booking reservation", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Reservation requested",
+		Status:  "Ok",
+		Data:    reservation,
+	})
+}
+
+// List retrieves the current customer's reservations with pagination.
+func (controller *ReservationsController) List(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	reservations, err := controller.ReservationService.FindByCustomer(ExtractPagination(ctx), userId, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error retrieving reservations", requestID)
+		return
+	}
+	reservations.Links = pageLinks(ctx, reservations.Pagination)
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reservations retrieved successfully",
+		Status:  "Ok",
+		Data:    reservations,
+	})
+}
+
+// FindById retrieves one of the current customer's reservations.
+func (controller *ReservationsController) FindById(ctx *gin.Context) {
+	controller.findById(ctx, model.RoleCustomer)
+}
+
+// Cancel cancels one of the current customer's reservations. The body with a reason is optional.
+func (controller *ReservationsController) Cancel(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	reservationId, ok := parseUUIDParam(ctx, "reservationId", requestID)
+	if !ok {
+		return
+	}
+
+	var cancelRequest request.CancelReservationRequest
+	if ctx.Request.ContentLength != 0 && !helper.ValidateRequest(ctx, &cancelRequest, controller.Validate, requestID) {
+		return
+	}
+
+	transitionRequest := request.ReservationTransitionRequest{
+		Status: model.ReservationStatusCancelled,
+		Reason: cancelRequest.Reason,
+	}
+	reservation, err := controller.ReservationService.Transition(transitionRequest, reservationId, userId, model.RoleCustomer, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error cancelling reservation", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reservation cancelled",
+		Status:  "Ok",
+		Data:    reservation,
+	})
+}
+
+// StaffList retrieves the reservations of the "date" query parameter (YYYY-MM-DD, today
+// when absent), optionally filtered by a comma-separated "status" query parameter.
+func (controller *ReservationsController) StaffList(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var statuses []string
+	for _, status := range strings.Split(ctx.Query("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+
+	reservations, err := controller.ReservationService.FindByDate(ctx.Query("date"), statuses, userId, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error retrieving reservations", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reservations retrieved successfully",
+		Status:  "Ok",
+		Data:    reservations,
+	})
+}
+
+// StaffFindById retrieves any reservation of the restaurant.
+func (controller *ReservationsController) StaffFindById(ctx *gin.Context) {
+	controller.findById(ctx, model.RoleStaff)
+}
+
+// Transition moves a reservation to the requested status on behalf of staff.
+func (controller *ReservationsController) Transition(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	reservationId, ok := parseUUIDParam(ctx, "reservationId", requestID)
+	if !ok {
+		return
+	}
+
+	var transitionRequest request.ReservationTransitionRequest
+	if !helper.ValidateRequest(ctx, &transitionRequest, controller.Validate, requestID) {
+		return
+	}
+
+	reservation, err := controller.ReservationService.Transition(transitionRequest, reservationId, userId, model.RoleStaff, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error changing reservation status", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reservation status changed to " + reservation.Status,
+		Status:  "Ok",
+		Data:    reservation,
+	})
+}
+
+// JoinWaitlist puts the current customer on the waitlist for a fully booked slot.
+func (controller *ReservationsController) JoinWaitlist(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var waitlistRequest request.WaitlistRequest
+	if !helper.ValidateRequest(ctx, &waitlistRequest, controller.Validate, requestID) {
+		return
+	}
+
+	entry, err := controller.ReservationService.JoinWaitlist(waitlistRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error joining waitlist", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Added to waitlist",
+		Status:  "Ok",
+		Data:    entry,
+	})
+}
+
+// Waitlist retrieves the current customer's waiting entries.
+func (controller *ReservationsController) Waitlist(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	entries, err := controller.ReservationService.FindCustomerWaitlist(userId, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error retrieving waitlist", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Waitlist retrieved successfully",
+		Status:  "Ok",
+		Data:    entries,
+	})
+}
+
+// LeaveWaitlist removes one of the current customer's waiting entries.
+func (controller *ReservationsController) LeaveWaitlist(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	entryId, ok := parseUUIDParam(ctx, "entryId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.ReservationService.LeaveWaitlist(entryId, userId, requestID, restaurantId); err != nil {
+		respondReservationError(ctx, err, "Error leaving waitlist", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Removed from waitlist",
+		Status:  "Ok",
+		Data:    nil,
+	})
+}
+
+// StaffWaitlist retrieves the waiting entries of the "date" query parameter (YYYY-MM-DD, today when absent).
+func (controller *ReservationsController) StaffWaitlist(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	entries, err := controller.ReservationService.FindWaitlist(ctx.Query("date"), userId, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error retrieving waitlist", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Waitlist retrieved successfully",
+		Status:  "Ok",
+		Data:    entries,
+	})
+}
+
+// findById handles the shared flow of the customer and staff reservation lookups.
+func (controller *ReservationsController) findById(ctx *gin.Context, role string) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	reservationId, ok := parseUUIDParam(ctx, "reservationId", requestID)
+	if !ok {
+		return
+	}
+
+	reservation, err := controller.ReservationService.FindById(reservationId, userId, role, requestID, restaurantId)
+	if err != nil {
+		respondReservationError(ctx, err, "Error retrieving reservation", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Reservation retrieved successfully",
+		Status:  "Ok",
+		Data:    reservation,
+	})
+}
+
+// respondReservationError maps reservation service errors to HTTP status codes.
+func respondReservationError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Reservation or waitlist entry not found", err, requestID)
+	case errors.Is(err, service.ErrReservationTransitionForbidden):
+		helper.LogInformation(ctx, http.StatusForbidden, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrInvalidReservationTransition):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, repository.ErrReservationStatusChanged):
+		helper.LogInformation(ctx, http.StatusConflict, "Reservation status changed concurrently, reload and retry", err, requestID)
+	case errors.Is(err, repository.ErrNoTableAvailable):
+		helper.LogInformation(ctx, http.StatusConflict, "No table is available at the requested time, choose another slot or join the waitlist", err, requestID)
+	case errors.Is(err, service.ErrInvalidReservationDate),
+		errors.Is(err, service.ErrPartyTooLarge),
+		errors.Is(err, service.ErrNotBookableSlot),
+		errors.Is(err, service.ErrUnknownReservationStatus):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
@@ -1,11 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
 	"the-dancing-pony-v2-lcwqre/data/request"
 	"the-dancing-pony-v2-lcwqre/data/response"
 	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/pagination"
 	"the-dancing-pony-v2-lcwqre/service"
 
 	"github.com/gin-gonic/gin"
@@ -65,22 +66,13 @@ func (controller *RestaurantsController) Create(ctx *gin.Context) {
 		Msg("Restaurant created successfully")
 }
 
-// Update updates a restaurant.
+// Update applies a JSON Merge Patch (RFC 7386) to a restaurant.
 func (controller *RestaurantsController) Update(ctx *gin.Context) {
 	requestID := ctx.GetString("request_id")
 	log.Info().
 		Str("request_id", requestID).
 		Msg("Update restaurant request received")
 
-	var updateRestaurantRequest request.UpdateRestaurantRequest
-	if !helper.ValidateRequest(ctx, &updateRestaurantRequest, controller.Validate, requestID) {
-		log.Warn().
-			Str("request_id", requestID).
-			Msg("Request validation failed")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed"})
-		return
-	}
-
 	restaurantId := ctx.Param("restaurantId")
 	id, err := uuid.Parse(restaurantId)
 	if err != nil {
@@ -92,16 +84,30 @@ func (controller *RestaurantsController) Update(ctx *gin.Context) {
 		return
 	}
 
+	versions, ok := helper.IfMatchVersions(ctx, requestID)
+	if !ok {
+		return
+	}
+	if !helper.IsMergePatch(ctx) {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Expected " + request.MergePatchContentType})
+		return
+	}
+	var updateRestaurantRequest request.UpdateRestaurantRequest
+	if !helper.BindMergePatch(ctx, ctx.Request.Body, &updateRestaurantRequest, requestID) {
+		return
+	}
+	if !helper.RespondPatchValidation(ctx, updateRestaurantRequest.Validate(controller.Validate), requestID) {
+		return
+	}
+
 	updateRestaurantRequest.ID = id
+	updateRestaurantRequest.Versions = versions
 	updatedRestaurant, err := controller.RestaurantsService.Update(updateRestaurantRequest)
 	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("restaurant_id", restaurantId).
-			Msg("Error updating restaurant")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating restaurant"})
+		respondWriteError(ctx, err, "Restaurant", "Error updating restaurant", requestID)
 		return
 	}
+	helper.SetETag(ctx, updatedRestaurant.Version)
 
 	webResponse := response.APIResponse{
 		Message: "Restaurant updated successfully",
@@ -130,13 +136,13 @@ func (controller *RestaurantsController) Delete(ctx *gin.Context) {
 		return
 	}
 
-	err = controller.RestaurantsService.Delete(id)
+	versions, ok := helper.IfMatchVersions(ctx, requestID)
+	if !ok {
+		return
+	}
+	err = controller.RestaurantsService.Delete(id, versions)
 	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("restaurant_id", restaurantId).
-			Msg("Error deleting restaurant")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting restaurant"})
+		respondWriteError(ctx, err, "Restaurant", "Error deleting restaurant", requestID)
 		return
 	}
 
@@ -176,6 +182,9 @@ func (controller *RestaurantsController) FindById(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
 		return
 	}
+	if helper.NotModified(ctx, restaurant.Version) {
+		return
+	}
 
 	webResponse := response.APIResponse{
 		Message: "Restaurant found",
@@ -193,21 +202,12 @@ func (controller *RestaurantsController) FindAll(ctx *gin.Context) {
 		Str("request_id", requestID).
 		Msg("Processing Find All Restaurants request")
 
-	// Extract pagination parameters
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "10")
-
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-
 	// Fetch restaurants with pagination
-	restaurantListResponse, err := controller.RestaurantsService.FindAll(page, limit)
+	restaurantListResponse, err := controller.RestaurantsService.FindAll(ExtractPagination(ctx))
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+		return
+	}
 	if err != nil {
 		log.Error().
 			Str("request_id", requestID).
@@ -215,6 +215,7 @@ func (controller *RestaurantsController) FindAll(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving restaurants"})
 		return
 	}
+	restaurantListResponse.Links = pageLinks(ctx, restaurantListResponse.Pagination)
 
 	// Construct paginated response
 	webResponse := response.APIResponse{
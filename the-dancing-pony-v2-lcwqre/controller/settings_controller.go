@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net/http"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// SettingsController handles requests for the configuration of the running instance.
+type SettingsController struct {
+	Settings []response.SettingResponse
+}
+
+// NewSettingsController creates a new instance of SettingsController showing the
+// effective settings, secrets redacted.
+func NewSettingsController(settings config.Settings) *SettingsController {
+	effective := settings.Effective()
+	responses := make([]response.SettingResponse, 0, len(effective))
+	for _, setting := range effective {
+		responses = append(responses, response.SettingResponse{
+			Key:    setting.Key,
+			Env:    setting.Env,
+			Value:  setting.Value,
+			Source: setting.Source,
+		})
+	}
+	return &SettingsController{Settings: responses}
+}
+
+// FindAll retrieves the effective settings.
+func (controller *SettingsController) FindAll(ctx *gin.Context) {
+	log.Info().
+		Str("request_id", ctx.GetString("request_id")).
+		Str("user_id", ctx.GetString("user_id")).
+		Msg("Effective settings retrieved")
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Settings retrieved successfully",
+		Status:  "Ok",
+		Data:    controller.Settings,
+	})
+}
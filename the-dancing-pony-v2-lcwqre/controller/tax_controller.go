@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// TaxController handles requests for tax settings, invoices and order receipts.
+type TaxController struct {
+	TaxService service.TaxService
+	Validate   *validator.Validate
+}
+
+// NewTaxController creates a new instance of TaxController.
+func NewTaxController(service service.TaxService) *TaxController {
+	return &TaxController{
+		TaxService: service,
+		Validate:   validator.New(),
+	}
+}
+
+// Receipt downloads the receipt of one of the signed-in customer's orders as PDF
+// (default) or plain text, chosen by the "format" query parameter.
+func (controller *TaxController) Receipt(ctx *gin.Context) {
+	controller.receipt(ctx, model.RoleCustomer)
+}
+
+// StaffReceipt downloads the receipt of any order of the restaurant.
+func (controller *TaxController) StaffReceipt(ctx *gin.Context) {
+	controller.receipt(ctx, model.RoleStaff)
+}
+
+// IssueInvoice numbers a served or collected order that has no invoice yet, such as
+// orders completed before the restaurant configured tax.
+func (controller *TaxController) IssueInvoice(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	orderId, ok := parseUUIDParam(ctx, "orderId", requestID)
+	if !ok {
+		return
+	}
+
+	invoice, err := controller.TaxService.IssueInvoice(orderId, userId, requestID, restaurantId)
+	if err != nil {
+		respondTaxError(ctx, err, "Error issuing invoice", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Invoice issued successfully",
+		Status:  "Ok",
+		Data:    invoice,
+	})
+}
+
+// FindSettings retrieves the tax configuration of the restaurant.
+func (controller *TaxController) FindSettings(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	settings, err := controller.TaxService.FindSettings(userId, requestID, restaurantId)
+	if err != nil {
+		respondTaxError(ctx, err, "Error retrieving tax settings", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Tax settings retrieved successfully",
+		Status:  "Ok",
+		Data:    settings,
+	})
+}
+
+// SaveSettings replaces the tax configuration of the restaurant.
+func (controller *TaxController) SaveSettings(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var settingsRequest request.TaxSettingsRequest
+	if !helper.ValidateRequest(ctx, &settingsRequest, controller.Validate, requestID) {
+		return
+	}
+
+	settings, err := controller.TaxService.SaveSettings(settingsRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondTaxError(ctx, err, "Error saving tax settings", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Tax settings saved successfully",
+		Status:  "Ok",
+		Data:    settings,
+	})
+}
+
+// receipt sends the receipt of an order as seen by the given role as a download.
+func (controller *TaxController) receipt(ctx *gin.Context, role string) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	orderId, ok := parseUUIDParam(ctx, "orderId", requestID)
+	if !ok {
+		return
+	}
+
+	format := strings.ToLower(ctx.Query("format"))
+	file, err := controller.TaxService.Receipt(orderId, format, userId, role, requestID, restaurantId)
+	if err != nil {
+		respondTaxError(ctx, err, "Error rendering receipt", requestID)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.Filename))
+	ctx.Data(http.StatusOK, file.ContentType, file.Body)
+}
+
+// respondTaxError maps tax service errors to HTTP status codes.
+func respondTaxError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Not found", err, requestID)
+	case errors.Is(err, service.ErrOrderNotCompleted):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrInvalidTaxSettings), errors.Is(err, service.ErrUnknownReceiptFormat):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/helper"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// WebhookController handles requests for webhook subscriptions and their deliveries.
+type WebhookController struct {
+	WebhookService service.WebhookService
+	Validate       *validator.Validate
+}
+
+// NewWebhookController creates a new instance of WebhookController.
+func NewWebhookController(service service.WebhookService) *WebhookController {
+	return &WebhookController{
+		WebhookService: service,
+		Validate:       validator.New(),
+	}
+}
+
+// EventTypes lists the event types subscriptions can ask for.
+func (controller *WebhookController) EventTypes(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhook event types retrieved successfully",
+		Status:  "Ok",
+		Data:    controller.WebhookService.EventTypes(),
+	})
+}
+
+// Create creates a subscription. Its signing secret is only returned here and when rotated.
+func (controller *WebhookController) Create(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	var subscriptionRequest request.WebhookSubscriptionRequest
+	if !helper.ValidateRequest(ctx, &subscriptionRequest, controller.Validate, requestID) {
+		return
+	}
+
+	subscription, err := controller.WebhookService.Create(subscriptionRequest, userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error creating webhook", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response.APIResponse{
+		Message: "Webhook created successfully",
+		Status:  "Ok",
+		Data:    subscription,
+	})
+}
+
+// FindAll retrieves the subscriptions of the restaurant.
+func (controller *WebhookController) FindAll(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+
+	subscriptions, err := controller.WebhookService.FindAll(userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error retrieving webhooks", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhooks retrieved successfully",
+		Status:  "Ok",
+		Data:    subscriptions,
+	})
+}
+
+// FindById retrieves a subscription.
+func (controller *WebhookController) FindById(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	webhookId, ok := parseUUIDParam(ctx, "webhookId", requestID)
+	if !ok {
+		return
+	}
+
+	subscription, err := controller.WebhookService.FindById(webhookId, userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error retrieving webhook", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhook retrieved successfully",
+		Status:  "Ok",
+		Data:    subscription,
+	})
+}
+
+// Replace replaces a subscription. Setting it active again re-enables a subscription
+// that was disabled for failing.
+func (controller *WebhookController) Replace(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	webhookId, ok := parseUUIDParam(ctx, "webhookId", requestID)
+	if !ok {
+		return
+	}
+
+	var subscriptionRequest request.WebhookSubscriptionRequest
+	if !helper.ValidateRequest(ctx, &subscriptionRequest, controller.Validate, requestID) {
+		return
+	}
+
+	subscription, err := controller.WebhookService.Replace(subscriptionRequest, webhookId, userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error updating webhook", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhook updated successfully",
+		Status:  "Ok",
+		Data:    subscription,
+	})
+}
+
+// RotateSecret gives a subscription a new signing secret.
+func (controller *WebhookController) RotateSecret(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	webhookId, ok := parseUUIDParam(ctx, "webhookId", requestID)
+	if !ok {
+		return
+	}
+
+	subscription, err := controller.WebhookService.RotateSecret(webhookId, userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error rotating webhook secret", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhook secret rotated successfully",
+		Status:  "Ok",
+		Data:    subscription,
+	})
+}
+
+// Delete deletes a subscription.
+func (controller *WebhookController) Delete(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	webhookId, ok := parseUUIDParam(ctx, "webhookId", requestID)
+	if !ok {
+		return
+	}
+
+	if err := controller.WebhookService.Delete(webhookId, userId, requestID, restaurantId); err != nil {
+		respondWebhookError(ctx, err, "Error deleting webhook", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhook deleted successfully",
+		Status:  "Ok",
+		Data:    nil,
+	})
+}
+
+// Test sends a test event to a subscription and responds with the delivery, whether
+// the receiver accepted it or not.
+func (controller *WebhookController) Test(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	webhookId, ok := parseUUIDParam(ctx, "webhookId", requestID)
+	if !ok {
+		return
+	}
+
+	delivery, err := controller.WebhookService.Test(webhookId, userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error sending test webhook", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Test webhook sent",
+		Status:  "Ok",
+		Data:    delivery,
+	})
+}
+
+// FindDeliveries retrieves a page of a subscription's delivery log, optionally only the
+// deliveries with the status given by the "status" query parameter.
+func (controller *WebhookController) FindDeliveries(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	webhookId, ok := parseUUIDParam(ctx, "webhookId", requestID)
+	if !ok {
+		return
+	}
+
+	deliveries, err := controller.WebhookService.FindDeliveries(webhookId, ctx.Query("status"), ExtractPagination(ctx), userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error retrieving webhook deliveries", requestID)
+		return
+	}
+	deliveries.Links = pageLinks(ctx, deliveries.Pagination)
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhook deliveries retrieved successfully",
+		Status:  "Ok",
+		Data:    deliveries,
+	})
+}
+
+// FindDelivery retrieves a delivery with its payload and attempts.
+func (controller *WebhookController) FindDelivery(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	deliveryId, ok := parseUUIDParam(ctx, "deliveryId", requestID)
+	if !ok {
+		return
+	}
+
+	delivery, err := controller.WebhookService.FindDelivery(deliveryId, userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error retrieving webhook delivery", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.APIResponse{
+		Message: "Webhook delivery retrieved successfully",
+		Status:  "Ok",
+		Data:    delivery,
+	})
+}
+
+// Replay queues a delivery's event to be sent again.
+func (controller *WebhookController) Replay(ctx *gin.Context) {
+	requestID, userId, restaurantId, err := helper.ExtractRequestData(ctx)
+	if err != nil {
+		return
+	}
+	deliveryId, ok := parseUUIDParam(ctx, "deliveryId", requestID)
+	if !ok {
+		return
+	}
+
+	delivery, err := controller.WebhookService.Replay(deliveryId, userId, requestID, restaurantId)
+	if err != nil {
+		respondWebhookError(ctx, err, "Error replaying webhook delivery", requestID)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, response.APIResponse{
+		Message: "Webhook delivery queued for replay",
+		Status:  "Ok",
+		Data:    delivery,
+	})
+}
+
+// respondWebhookError maps webhook service errors to HTTP status codes.
+func respondWebhookError(ctx *gin.Context, err error, message string, requestID string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		helper.LogInformation(ctx, http.StatusNotFound, "Not found", err, requestID)
+	case errors.Is(err, service.ErrUnknownWebhookEvent),
+		errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrUnknownDeliveryStatus):
+		helper.LogInformation(ctx, http.StatusBadRequest, err.Error(), err, requestID)
+	case errors.Is(err, service.ErrWebhookDisabled):
+		helper.LogInformation(ctx, http.StatusConflict, err.Error(), err, requestID)
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		helper.LogInformation(ctx, http.StatusBadRequest, "Invalid cursor", err, requestID)
+	default:
+		helper.LogInformation(ctx, http.StatusInternalServerError, message, err, requestID)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const createAdminUsage = `usage: thedancingpony create-admin -restaurant <id> -email <email> [flags]
+
+Creates a user with the admin permission, who can manage every restaurant. The user
+signs in through the given restaurant. The password is read from the first line of
+standard input unless -password is given.
+
+Flags:
+`
+
+// runCreateAdmin runs the create-admin command.
+func runCreateAdmin(settings config.Settings, args []string) int {
+	flags := newFlagSet("create-admin", createAdminUsage)
+	restaurant := flags.String("restaurant", "", "ID of the restaurant the admin signs in through")
+	email := flags.String("email", "", "email address of the admin")
+	name := flags.String("name", "Administrator", "name of the admin")
+	password := flags.String("password", "", "password of the admin, read from standard input when empty")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	restaurantId, err := uuid.Parse(*restaurant)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error: -restaurant must be a restaurant ID")
+		return 2
+	}
+
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(os.Stderr, "error: reading the password from standard input:", err)
+			return 2
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	registerRequest := request.RegisterRequest{
+		Name:        *name,
+		Email:       *email,
+		Password:    *password,
+		Permissions: []string{"admin"},
+	}
+	if err := validator.New().Struct(registerRequest); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	db, ok := openDatabase(settings)
+	if !ok {
+		return 1
+	}
+	if _, err := repository.NewRestaurantsRepositoryImpl(db).FindById(restaurantId); err != nil {
+		fmt.Fprintf(os.Stderr, "error: restaurant %s: %v\n", restaurantId, err)
+		return 1
+	}
+	if err := service.NewAuthService(repository.NewUserRepository(db), []byte(settings.JWTSecret)).Register(registerRequest, restaurantId); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	fmt.Printf("Created admin %s, signing in through restaurant %s\n", *email, restaurantId)
+	return 0
+}
@@ -0,0 +1,30 @@
+package request
+
+import (
+	"github.com/google/uuid"
+)
+
+// CartItemRequest represents a dish to add to a cart.
+type CartItemRequest struct {
+	DishID   uuid.UUID `json:"dishId" validate:"required"`
+	Quantity int       `json:"quantity" validate:"required,min=1,max=100"`
+	Notes    string    `json:"notes" validate:"max=200"`
+}
+
+// UpdateCartItemRequest represents a change to a cart item. Omitted notes are kept.
+type UpdateCartItemRequest struct {
+	Quantity int     `json:"quantity" validate:"required,min=1,max=100"`
+	Notes    *string `json:"notes" validate:"omitempty,max=200"`
+}
+
+// CheckoutRequest represents a request to turn a cart into an order.
+type CheckoutRequest struct {
+	Fulfilment string     `json:"fulfilment" validate:"required,oneof=dine_in takeaway"`
+	Notes      string     `json:"notes" validate:"max=500"`
+	RewardID   *uuid.UUID `json:"rewardId"` // Loyalty reward to redeem points for
+}
+
+// CouponRequest represents a coupon code entered on a cart.
+type CouponRequest struct {
+	Code string `json:"code" validate:"required,min=1,max=40"`
+}
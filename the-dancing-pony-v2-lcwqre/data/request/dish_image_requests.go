@@ -0,0 +1,26 @@
+package request
+
+import (
+	"github.com/google/uuid"
+)
+
+// DishImageUploadRequest asks for a presigned URL to upload a dish image directly to storage.
+type DishImageUploadRequest struct {
+	ContentType string `json:"contentType" validate:"required,oneof=image/jpeg image/png image/webp"`
+	Size        int64  `json:"size" validate:"required,gt=0"` // Size of the file in bytes
+}
+
+// ConfirmDishImageUploadRequest attaches a finished upload to the dish gallery.
+type ConfirmDishImageUploadRequest struct {
+	AltText string `json:"altText" validate:"max=300"`
+}
+
+// UpdateDishImageRequest represents an edit to a gallery image.
+type UpdateDishImageRequest struct {
+	AltText string `json:"altText" validate:"max=300"`
+}
+
+// ReorderDishImagesRequest lists every image of a dish in its new display order.
+type ReorderDishImagesRequest struct {
+	ImageIds []uuid.UUID `json:"imageIds" validate:"required,min=1"`
+}
@@ -1,6 +1,14 @@
 package request
 
 import (
+	"fmt"
+	"path"
+	"strings"
+
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/media"
+	"the-dancing-pony-v2-lcwqre/storage"
+
 	"github.com/go-playground/validator/v10" // Import validator package for validation
 	"github.com/google/uuid"
 )
@@ -17,6 +25,10 @@ type DishBase struct {
 type CreateDishRequest struct {
 	UserID uuid.UUID `json:"userId"`
 	DishBase
+	Status   string   `json:"status" validate:"omitempty,oneof=draft published"` // Defaults to published
+	SKU      string   `json:"sku" validate:"omitempty,max=64"`                   // External SKU used to upsert on bulk import
+	Category string   `json:"category" validate:"max=50"`                        // Menu section such as "mains"
+	Tags     []string `json:"tags" validate:"max=20,dive,min=1,max=30"`
 }
 
 type CreateDishAPIRequest struct {
@@ -25,34 +37,130 @@ type CreateDishAPIRequest struct {
 	Price       float64 `json:"price" validate:"required,gt=0"`
 }
 
-// UpdateDishRequest represents a request to update a dish.
+// UpdateDishRequest is a JSON Merge Patch of a dish. Absent members are left
+// unchanged and null removes a value; name and price cannot be removed.
 type UpdateDishRequest struct {
-	ID uuid.UUID `json:"id" validate:"required"`
-	DishBase
+	ID          uuid.UUID          `json:"-"`
+	Versions    []int64            `json:"-"` // From If-Match; the update only applies at one of these versions
+	Name        Optional[string]   `json:"name"`
+	Description Optional[string]   `json:"description"`
+	Price       Optional[float64]  `json:"price"`
+	ImageUrl    Optional[string]   `json:"imageUrl"`
+	SKU         Optional[string]   `json:"sku"`
+	Category    Optional[string]   `json:"category"`
+	Tags        Optional[[]string] `json:"tags"`
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (r *UpdateDishRequest) IsEmpty() bool {
+	return !r.Name.Set && !r.Description.Set && !r.Price.Set && !r.ImageUrl.Set && !r.SKU.Set &&
+		!r.Category.Set && !r.Tags.Set
+}
+
+// Validate checks the members present in the patch and returns a message per invalid member.
+func (r *UpdateDishRequest) Validate(validate *validator.Validate) []string {
+	var errs []string
+	if r.Name.Null {
+		errs = append(errs, "Field 'name' cannot be removed")
+	} else if r.Name.Set && validate.Var(r.Name.Value, "min=1,max=200") != nil {
+		errs = append(errs, "Field 'name' must be between 1 and 200 characters")
+	}
+	if r.Description.HasValue() && validate.Var(r.Description.Value, "max=200") != nil {
+		errs = append(errs, "Field 'description' must be at most 200 characters")
+	}
+	if r.Price.Null {
+		errs = append(errs, "Field 'price' cannot be removed")
+	} else if r.Price.Set && r.Price.Value <= 0 {
+		errs = append(errs, "Field 'price' must be greater than 0")
+	}
+	// Stored images are replaced by uploading a file; a patch may only point at an external image
+	if r.ImageUrl.HasValue() && validate.Var(r.ImageUrl.Value, "http_url,max=200") != nil {
+		errs = append(errs, "Field 'imageUrl' must be an http(s) URL of at most 200 characters")
+	}
+	if r.SKU.HasValue() && validate.Var(r.SKU.Value, "min=1,max=64") != nil {
+		errs = append(errs, "Field 'sku' must be between 1 and 64 characters")
+	}
+	if r.Category.HasValue() && validate.Var(r.Category.Value, "max=50") != nil {
+		errs = append(errs, "Field 'category' must be at most 50 characters")
+	}
+	if r.Tags.HasValue() && validate.Var(r.Tags.Value, "max=20,dive,min=1,max=30") != nil {
+		errs = append(errs, "Field 'tags' must hold at most 20 tags of 1 to 30 characters")
+	}
+	return errs
 }
 
 // ListDishRequestList represents a list of dish creation requests.
 type ListDishRequestList struct {
-	Dishes []CreateDishRequest `json:"dishes"`
+	Dishes      []CreateDishRequest     `json:"dishes"`
+	ParseErrors []response.DishRowError `json:"-"` // Rows a CSV import could not parse, reported by Validate
 }
 
+// dishImagePrefix starts the keys of uploaded dish images.
+const dishImagePrefix = "dishes/"
+
 // DeleteDishRequest represents a request to delete a dish.
 type DeleteDishRequest struct {
 	ID uuid.UUID `json:"id" validate:"required"`
 }
 
-// RateDishRequest represents a request to rate a dish.
+// RateDishRequest represents a request to rate a dish, optionally with a written review.
 type RateDishRequest struct {
-	Rating int `json:"rating" validate:"required,gt=0,lt=6"`
+	Rating  int    `json:"rating" validate:"required,gt=0,lt=6"`
+	Comment string `json:"comment" validate:"max=1000"`
 }
 
-// Validate validates the CreateDishRequestList fields.
-func (c *ListDishRequestList) Validate() error {
+// Validate validates the CreateDishRequestList fields and returns the errors of every invalid row,
+// along with the errors found parsing it. A SKU may only appear once per list, and images must be
+// http(s) URLs or the keys of uploaded images, as exported.
+func (c *ListDishRequestList) Validate() []response.DishRowError {
 	validate := validator.New()
-	for _, dish := range c.Dishes {
+	parseErrors := make(map[int][]string)
+	for _, rowError := range c.ParseErrors {
+		parseErrors[rowError.Row] = append(parseErrors[rowError.Row], rowError.Errors...)
+	}
+	var rowErrors []response.DishRowError
+	seenSKUs := make(map[string]int)
+	for i, dish := range c.Dishes {
+		messages := parseErrors[i+1]
 		if err := validate.Struct(dish); err != nil {
-			return err
+			if validationErrors, ok := err.(validator.ValidationErrors); ok {
+				for _, e := range validationErrors {
+					// A price that could not be parsed was reported above
+					if e.Field() == "Price" && len(parseErrors[i+1]) > 0 {
+						continue
+					}
+					messages = append(messages, fmt.Sprintf("Field '%s' failed validation on '%s' tag", e.Field(), e.Tag()))
+				}
+			} else {
+				messages = append(messages, err.Error())
+			}
+		}
+		if dish.ImageUrl != "" && !validImportImage(validate, dish.ImageUrl) {
+			messages = append(messages, "Field 'ImageUrl' must be an http(s) URL or the key of an uploaded image")
 		}
+		if dish.SKU != "" {
+			if firstRow, ok := seenSKUs[dish.SKU]; ok {
+				messages = append(messages, fmt.Sprintf("SKU '%s' is already used by row %d", dish.SKU, firstRow))
+			} else {
+				seenSKUs[dish.SKU] = i + 1
+			}
+		}
+		if len(messages) > 0 {
+			rowErrors = append(rowErrors, response.DishRowError{Row: i + 1, SKU: dish.SKU, Errors: messages})
+		}
+	}
+	return rowErrors
+}
+
+// validImportImage reports whether an imported image is an http(s) URL, or the key of the
+// full rendition of an image uploaded for a dish, which is what exports hold for them.
+func validImportImage(validate *validator.Validate, image string) bool {
+	if storage.IsExternalURL(image) {
+		return validate.Var(image, "http_url") == nil
+	}
+	if !strings.HasPrefix(image, dishImagePrefix) || path.Clean(image) != image {
+		return false
 	}
-	return nil
+	_, ok := media.RenditionKey(image, media.RenditionCard)
+	return ok
 }
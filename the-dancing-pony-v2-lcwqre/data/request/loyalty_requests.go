@@ -0,0 +1,24 @@
+package request
+
+// LoyaltyProgramRequest represents a restaurant's loyalty configuration.
+type LoyaltyProgramRequest struct {
+	Enabled       bool    `json:"enabled"`
+	PointsPerUnit float64 `json:"pointsPerUnit" validate:"min=0,max=1000"` // Points per currency unit of an order's total
+	ReviewPoints  int     `json:"reviewPoints" validate:"min=0,max=100000"`
+	ExpiryDays    int     `json:"expiryDays" validate:"min=0,max=3650"` // 0 keeps points forever
+}
+
+// LoyaltyRewardRequest represents a reward created or replaced by staff.
+type LoyaltyRewardRequest struct {
+	Name        string  `json:"name" validate:"required,min=1,max=100"`
+	Description string  `json:"description" validate:"max=500"`
+	PointsCost  int     `json:"pointsCost" validate:"required,min=1,max=10000000"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"` // Taken off the order total
+	Active      bool    `json:"active"`
+}
+
+// LoyaltyAdjustmentRequest represents a staff correction of a customer's points.
+type LoyaltyAdjustmentRequest struct {
+	Points int    `json:"points" validate:"required,min=-10000000,max=10000000"` // Positive to credit, negative to debit
+	Note   string `json:"note" validate:"required,min=1,max=300"`
+}
@@ -0,0 +1,19 @@
+package request
+
+import (
+	"time"
+)
+
+// DishChangeRequest represents a draft edit to a published dish.
+// Fields left out of the payload are not changed when the draft is applied.
+type DishChangeRequest struct {
+	Name        *string    `json:"name" validate:"omitempty,min=1,max=200"`
+	Description *string    `json:"description" validate:"omitempty,min=1,max=200"`
+	Price       *float64   `json:"price" validate:"omitempty,gt=0"`
+	PublishAt   *time.Time `json:"publishAt"` // Schedules the change straight away when set
+}
+
+// PublishRequest represents a request to publish a dish or change now or at a future time.
+type PublishRequest struct {
+	PublishAt *time.Time `json:"publishAt"` // Publishes immediately when omitted
+}
@@ -0,0 +1,59 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MergePatchContentType is the media type of JSON Merge Patch documents (RFC 7386).
+const MergePatchContentType = "application/merge-patch+json"
+
+// ErrInvalidMergePatch is returned when a merge patch is not a JSON object or names unknown members.
+var ErrInvalidMergePatch = errors.New("invalid merge patch")
+
+// Optional is a member of a JSON Merge Patch document. Set reports whether the
+// member appeared at all, and Null whether it was null, which removes the value.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON records that the member was present before decoding its value.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// HasValue reports whether the member was present with a non-null value.
+func (o Optional[T]) HasValue() bool {
+	return o.Set && !o.Null
+}
+
+// DecodeMergePatch decodes a merge patch document into patch. Only objects are
+// accepted, since replacing a whole resource is what PUT is for, and members the
+// patch type does not declare are rejected rather than silently dropped.
+func DecodeMergePatch(r io.Reader, patch interface{}) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading merge patch: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return fmt.Errorf("%w: document must be a JSON object", ErrInvalidMergePatch)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patch); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMergePatch, err)
+	}
+	return nil
+}
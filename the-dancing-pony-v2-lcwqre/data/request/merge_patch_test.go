@@ -0,0 +1,86 @@
+package request
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestDecodeMergePatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		wantDescription Optional[string]
+		wantTags        Optional[[]string]
+		wantEmpty       bool
+		wantErr         bool
+	}{
+		{name: "absent members are unset", body: `{}`, wantEmpty: true},
+		{name: "null removes", body: `{"description": null}`, wantDescription: Optional[string]{Set: true, Null: true}},
+		{name: "value replaces", body: `{"description": "Second breakfast"}`, wantDescription: Optional[string]{Set: true, Value: "Second breakfast"}},
+		{name: "empty string is a value", body: `{"description": ""}`, wantDescription: Optional[string]{Set: true}},
+		{name: "null list removes", body: `{"tags": null}`, wantTags: Optional[[]string]{Set: true, Null: true}},
+		{name: "empty list is a value", body: `{"tags": []}`, wantTags: Optional[[]string]{Set: true, Value: []string{}}},
+		{name: "surrounding whitespace", body: " \n{\"description\": null}\n", wantDescription: Optional[string]{Set: true, Null: true}},
+		{name: "unknown member", body: `{"colour": "green"}`, wantErr: true},
+		{name: "array document", body: `[{"description": null}]`, wantErr: true},
+		{name: "null document", body: `null`, wantErr: true},
+		{name: "empty body", body: ``, wantErr: true},
+		{name: "wrong type", body: `{"price": "cheap"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch UpdateDishRequest
+			err := DecodeMergePatch(strings.NewReader(tt.body), &patch)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMergePatch) {
+					t.Fatalf("DecodeMergePatch() error = %v, want ErrInvalidMergePatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeMergePatch() error = %v", err)
+			}
+			if patch.Description != tt.wantDescription {
+				t.Errorf("Description = %+v, want %+v", patch.Description, tt.wantDescription)
+			}
+			if patch.Tags.Set != tt.wantTags.Set || patch.Tags.Null != tt.wantTags.Null || !slices.Equal(patch.Tags.Value, tt.wantTags.Value) ||
+				(patch.Tags.Value == nil) != (tt.wantTags.Value == nil) {
+				t.Errorf("Tags = %+v, want %+v", patch.Tags, tt.wantTags)
+			}
+			if patch.IsEmpty() != tt.wantEmpty {
+				t.Errorf("IsEmpty() = %v, want %v", patch.IsEmpty(), tt.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestUpdateDishRequestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "absent required members", body: `{"description": "Lembas"}`},
+		{name: "null name", body: `{"name": null}`, want: []string{"Field 'name' cannot be removed"}},
+		{name: "null price", body: `{"price": null}`, want: []string{"Field 'price' cannot be removed"}},
+		{name: "empty name", body: `{"name": ""}`, want: []string{"Field 'name' must be between 1 and 200 characters"}},
+		{name: "null optional members", body: `{"description": null, "imageUrl": null, "sku": null, "category": null, "tags": null}`},
+		{name: "empty sku", body: `{"sku": ""}`, want: []string{"Field 'sku' must be between 1 and 64 characters"}},
+	}
+	validate := validator.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch UpdateDishRequest
+			if err := DecodeMergePatch(strings.NewReader(tt.body), &patch); err != nil {
+				t.Fatalf("DecodeMergePatch() error = %v", err)
+			}
+			if got := patch.Validate(validate); !slices.Equal(got, tt.want) {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
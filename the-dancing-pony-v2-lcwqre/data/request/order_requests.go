@@ -0,0 +1,32 @@
+package request
+
+import (
+	"github.com/google/uuid"
+)
+
+// PlaceOrderRequest represents a customer's order.
+type PlaceOrderRequest struct {
+	Fulfilment string             `json:"fulfilment" validate:"required,oneof=dine_in takeaway"`
+	Notes      string             `json:"notes" validate:"max=500"`
+	Items      []OrderItemRequest `json:"items" validate:"required,min=1,max=50,dive"`
+	CouponCode string             `json:"couponCode" validate:"max=40"`
+	RewardID   *uuid.UUID         `json:"rewardId"` // Loyalty reward to redeem points for
+}
+
+// OrderItemRequest represents one line of an order.
+type OrderItemRequest struct {
+	DishID   uuid.UUID `json:"dishId" validate:"required"`
+	Quantity int       `json:"quantity" validate:"required,min=1,max=100"`
+	Notes    string    `json:"notes" validate:"max=200"`
+}
+
+// OrderTransitionRequest represents a request to move an order to another status.
+type OrderTransitionRequest struct {
+	Status string `json:"status" validate:"required,oneof=accepted preparing ready served collected cancelled rejected"`
+	Reason string `json:"reason" validate:"max=300"` // Shown to the customer when the order is cancelled or rejected
+}
+
+// CancelOrderRequest represents a customer's request to cancel an order.
+type CancelOrderRequest struct {
+	Reason string `json:"reason" validate:"max=300"`
+}
@@ -0,0 +1,13 @@
+package request
+
+// PayOrderRequest represents a customer's request to pay for an order.
+type PayOrderRequest struct {
+	PaymentMethod string `json:"paymentMethod" validate:"required,max=100"` // Provider token of the customer's payment method
+}
+
+// RefundRequest represents a refund of part or all of a payment. Omitting the amount
+// refunds everything that has not been refunded yet.
+type RefundRequest struct {
+	Amount float64 `json:"amount" validate:"omitempty,gt=0"`
+	Reason string  `json:"reason" validate:"max=300"`
+}
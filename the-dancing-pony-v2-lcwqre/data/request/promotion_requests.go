@@ -0,0 +1,31 @@
+package request
+
+import (
+	"time"
+)
+
+// PromotionRequest represents a promotion created or replaced by staff. Promotions with
+// a code are coupons; the others apply automatically.
+type PromotionRequest struct {
+	Name             string     `json:"name" validate:"required,min=1,max=100"`
+	Code             string     `json:"code" validate:"omitempty,min=3,max=40,alphanum"`
+	Active           bool       `json:"active"`
+	Priority         int        `json:"priority" validate:"min=0,max=1000"`
+	Stackable        bool       `json:"stackable"`
+	StartsAt         *time.Time `json:"startsAt"`
+	EndsAt           *time.Time `json:"endsAt"`
+	Timezone         string     `json:"timezone" validate:"omitempty,timezone"` // Defaults to UTC
+	Weekdays         []int      `json:"weekdays" validate:"max=7,dive,min=0,max=6"`
+	DailyFrom        string     `json:"dailyFrom" validate:"omitempty,datetime=15:04"` // Daily hours, both or neither
+	DailyUntil       string     `json:"dailyUntil" validate:"omitempty,datetime=15:04"`
+	Categories       []string   `json:"categories" validate:"max=20,dive,min=1,max=50"`
+	Tags             []string   `json:"tags" validate:"max=20,dive,min=1,max=30"`
+	MinSpend         float64    `json:"minSpend" validate:"min=0"`
+	FirstOrderOnly   bool       `json:"firstOrderOnly"`
+	PerCustomerLimit int        `json:"perCustomerLimit" validate:"min=0"`
+	UsageLimit       int        `json:"usageLimit" validate:"min=0"`
+	DiscountType     string     `json:"discountType" validate:"required,oneof=percent fixed buy_x_get_y"`
+	Value            float64    `json:"value" validate:"min=0"`               // Percentage for percent discounts, amount for fixed discounts
+	BuyQuantity      int        `json:"buyQuantity" validate:"min=0,max=100"` // For buy_x_get_y discounts
+	GetQuantity      int        `json:"getQuantity" validate:"min=0,max=100"`
+}
@@ -0,0 +1,65 @@
+package request
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookReservationRequest represents a customer's request for a table.
+type BookReservationRequest struct {
+	PartySize int       `json:"partySize" validate:"required,min=1,max=100"`
+	StartsAt  time.Time `json:"startsAt" validate:"required"` // One of the slots returned by the availability endpoint
+	Notes     string    `json:"notes" validate:"max=500"`
+}
+
+// ReservationTransitionRequest represents a request to move a reservation to another status.
+type ReservationTransitionRequest struct {
+	Status string `json:"status" validate:"required,oneof=confirmed seated no_show cancelled"`
+	Reason string `json:"reason" validate:"max=300"` // Shown to the customer when the reservation is cancelled
+}
+
+// CancelReservationRequest represents a customer's request to cancel a reservation.
+type CancelReservationRequest struct {
+	Reason string `json:"reason" validate:"max=300"`
+}
+
+// WaitlistRequest represents a customer's request to wait for a table at a fully booked time.
+type WaitlistRequest struct {
+	PartySize int       `json:"partySize" validate:"required,min=1,max=100"`
+	DesiredAt time.Time `json:"desiredAt" validate:"required"`
+	Notes     string    `json:"notes" validate:"max=500"`
+}
+
+// DiningAreaRequest represents a request to create a dining area.
+type DiningAreaRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// DiningTableRequest represents a request to create or replace a table.
+type DiningTableRequest struct {
+	Name        string     `json:"name" validate:"required,min=1,max=50"`
+	AreaID      *uuid.UUID `json:"areaId"`
+	MinCapacity int        `json:"minCapacity" validate:"omitempty,min=1,max=100"` // Defaults to 1
+	MaxCapacity int        `json:"maxCapacity" validate:"required,min=1,max=100"`
+	Active      *bool      `json:"active"` // Defaults to true
+}
+
+// ScheduleRequest represents a restaurant's reservation settings and weekly opening hours.
+// The opening hours replace the stored ones.
+type ScheduleRequest struct {
+	Timezone        string                `json:"timezone" validate:"required,timezone"`
+	SlotMinutes     int                   `json:"slotMinutes" validate:"required,min=5,max=240"`
+	DurationMinutes int                   `json:"durationMinutes" validate:"required,min=15,max=720"`
+	MaxPartySize    int                   `json:"maxPartySize" validate:"required,min=1,max=100"`
+	MaxAdvanceDays  int                   `json:"maxAdvanceDays" validate:"required,min=1,max=365"`
+	Hours           []OpeningHoursRequest `json:"hours" validate:"max=50,dive"`
+}
+
+// OpeningHoursRequest represents a period in which reservations are seated on a day of the week.
+// A period closing at or before its opening time closes on the next day.
+type OpeningHoursRequest struct {
+	Weekday int    `json:"weekday" validate:"min=0,max=6"` // 0 is Sunday
+	Opens   string `json:"opens" validate:"required,datetime=15:04"`
+	Closes  string `json:"closes" validate:"required,datetime=15:04"`
+}
@@ -1,6 +1,9 @@
 package request
 
 import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
@@ -17,10 +20,45 @@ type CreateRestaurantRequest struct {
 	RestaurantBase
 }
 
-// UpdateRestaurantRequest represents a request to update a restaurant.
+// UpdateRestaurantRequest is a JSON Merge Patch of a restaurant. Absent members
+// are left unchanged and null removes a value; name and location cannot be removed.
 type UpdateRestaurantRequest struct {
-	ID uuid.UUID `json:"id" validate:"required"`
-	RestaurantBase
+	ID          uuid.UUID        `json:"-"`
+	Versions    []int64          `json:"-"` // From If-Match; the update only applies at one of these versions
+	Name        Optional[string] `json:"name"`
+	Description Optional[string] `json:"description"`
+	Location    Optional[string] `json:"location"`
+	ImageUrl    Optional[string] `json:"imageUrl"`
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (r *UpdateRestaurantRequest) IsEmpty() bool {
+	return !r.Name.Set && !r.Description.Set && !r.Location.Set && !r.ImageUrl.Set
+}
+
+// Validate checks the members present in the patch and returns a message per invalid member.
+func (r *UpdateRestaurantRequest) Validate(validate *validator.Validate) []string {
+	var errs []string
+	for _, member := range []struct {
+		name      string
+		value     Optional[string]
+		removable bool
+	}{
+		{"name", r.Name, false},
+		{"description", r.Description, true},
+		{"location", r.Location, false},
+		{"imageUrl", r.ImageUrl, true},
+	} {
+		switch {
+		case member.value.Null && !member.removable:
+			errs = append(errs, fmt.Sprintf("Field '%s' cannot be removed", member.name))
+		case member.value.HasValue() && member.removable && validate.Var(member.value.Value, "max=200") != nil:
+			errs = append(errs, fmt.Sprintf("Field '%s' must be at most 200 characters", member.name))
+		case member.value.HasValue() && !member.removable && validate.Var(member.value.Value, "min=1,max=200") != nil:
+			errs = append(errs, fmt.Sprintf("Field '%s' must be between 1 and 200 characters", member.name))
+		}
+	}
+	return errs
 }
 
 // ListRestaurantRequestList represents a list of restaurant creation requests.
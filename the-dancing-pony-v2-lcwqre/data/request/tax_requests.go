@@ -0,0 +1,18 @@
+package request
+
+// TaxSettingsRequest represents a restaurant's tax configuration and invoice details.
+type TaxSettingsRequest struct {
+	PricesIncludeTax bool             `json:"pricesIncludeTax"` // Whether menu prices include tax or have it added on top
+	Rates            []TaxRateRequest `json:"rates" validate:"required,min=1,max=20,dive"`
+	LegalName        string           `json:"legalName" validate:"max=200"` // Printed on invoices instead of the restaurant name
+	Address          string           `json:"address" validate:"max=500"`
+	TaxNumber        string           `json:"taxNumber" validate:"max=50"`
+	InvoicePrefix    string           `json:"invoicePrefix" validate:"max=20"` // Defaults to "INV-"
+}
+
+// TaxRateRequest represents a named tax rate and the dish categories it applies to.
+type TaxRateRequest struct {
+	Name       string   `json:"name" validate:"required,min=1,max=50"`
+	Rate       float64  `json:"rate" validate:"min=0,max=100"`                  // Percent, with at most two decimals
+	Categories []string `json:"categories" validate:"max=50,dive,min=1,max=50"` // Empty for the default rate
+}
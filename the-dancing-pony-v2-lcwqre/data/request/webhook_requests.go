@@ -0,0 +1,9 @@
+package request
+
+// WebhookSubscriptionRequest represents a webhook subscription created or replaced by staff.
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2000"` // http or https URL events are posted to
+	Description string   `json:"description" validate:"max=200"`
+	EventTypes  []string `json:"eventTypes" validate:"required,min=1,max=20,dive,required,max=50"`
+	Active      *bool    `json:"active"` // Defaults to true; activating a disabled subscription resets its failures
+}
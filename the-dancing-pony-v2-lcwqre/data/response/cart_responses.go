@@ -0,0 +1,35 @@
+package response
+
+import (
+	"github.com/google/uuid"
+)
+
+// CartResponse represents a cart priced at the current dish prices.
+type CartResponse struct {
+	GuestToken       string             `json:"guestToken,omitempty"` // Returned to guests, sent back in the X-Cart-Token header
+	Items            []CartItemResponse `json:"items"`
+	Subtotal         float64            `json:"subtotal"` // Sum of the available items
+	Discounts        []DiscountResponse `json:"discounts"`
+	Discount         float64            `json:"discount"`
+	Total            float64            `json:"total"` // Subtotal less the discounts, plus tax when prices exclude it
+	PricesIncludeTax bool               `json:"pricesIncludeTax"`
+	Tax              float64            `json:"tax"`
+	Taxes            []TaxLineResponse  `json:"taxes"`                 // Tax by rate, empty when the restaurant charges no tax
+	CouponCode       string             `json:"couponCode,omitempty"`  // Coupon entered, applied or not
+	CouponError      string             `json:"couponError,omitempty"` // Why the coupon does not apply to the cart as it is
+	PriceChanged     bool               `json:"priceChanged"`          // Whether any price changed since the cart was last shown
+	CanCheckout      bool               `json:"canCheckout"`
+}
+
+// CartItemResponse represents one dish in a cart.
+type CartItemResponse struct {
+	ID            uuid.UUID `json:"id"`
+	DishID        uuid.UUID `json:"dishId"`
+	Name          string    `json:"name"`
+	UnitPrice     float64   `json:"unitPrice"`
+	PreviousPrice *float64  `json:"previousPrice,omitempty"` // Price last shown, when it has changed since
+	Quantity      int       `json:"quantity"`
+	Notes         string    `json:"notes,omitempty"`
+	Subtotal      float64   `json:"subtotal"`
+	Available     bool      `json:"available"` // False once the dish is unpublished or deleted
+}
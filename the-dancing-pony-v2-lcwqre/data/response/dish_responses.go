@@ -1,6 +1,10 @@
 package response
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Common response structure for success and error messages.
 type APIResponse struct {
@@ -28,19 +32,56 @@ func ErrorResponse(message string) APIResponse {
 
 // DishResponse represents a single dish data in response.
 type DishResponse struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	ImageUrl    string    `json:"imageUrl"`
+	ID          uuid.UUID           `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Price       float64             `json:"price"`
+	ImageUrl    string              `json:"imageUrl"`
+	Images      *DishImages         `json:"images,omitempty"`
+	Gallery     []DishImageResponse `json:"gallery,omitempty"`
+	Category    string              `json:"category,omitempty"`
+	Tags        []string            `json:"tags"`
+	Status      string              `json:"status,omitempty"`
+	Version     int64               `json:"version"`
+}
+
+// DishImages holds the URLs of every rendition of a dish image.
+type DishImages struct {
+	Thumbnail string `json:"thumbnail"`
+	Card      string `json:"card"`
+	Full      string `json:"full"`
+}
+
+// DishImageResponse represents one image of a dish gallery.
+type DishImageResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	AltText   string     `json:"altText"`
+	Position  int        `json:"position"`
+	IsPrimary bool       `json:"isPrimary"`
+	Images    DishImages `json:"images"`
+}
+
+// DishImageUploadResponse describes the request a client makes to upload an image directly to storage.
+type DishImageUploadResponse struct {
+	UploadID  uuid.UUID         `json:"uploadId"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// DishImageUploadStatusResponse reports the processing of a confirmed direct upload.
+type DishImageUploadStatusResponse struct {
+	UploadID uuid.UUID  `json:"uploadId"`
+	Status   string     `json:"status"`            // pending, processing, completed or failed
+	ImageID  *uuid.UUID `json:"imageId,omitempty"` // Gallery image, once completed
+	Error    string     `json:"error,omitempty"`   // Why processing failed
 }
 
 // DishListResponse represents a response containing a list of dishes.
 type DishListResponse struct {
-	Dishes      []DishResponse `json:"dishes"`       // List of dishes for the current page
-	CurrentPage int            `json:"current_page"` // Current page number
-	TotalPages  int            `json:"total_pages"`  // Total number of pages
-	TotalItems  int            `json:"total_items"`  // Total number of items
+	Dishes []DishResponse `json:"dishes"` // List of dishes for the current page
+	Pagination
 }
 
 // SearchDishesResponse represents a response containing search results.
@@ -48,11 +89,78 @@ type SearchDishesResponse struct {
 	Dishes []DishResponse `json:"dishes"`
 }
 
+// DishChangeResponse represents a draft or scheduled change to a dish.
+type DishChangeResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	DishID      uuid.UUID  `json:"dishId"`
+	Kind        string     `json:"kind"`
+	Status      string     `json:"status"`
+	Name        *string    `json:"name,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Price       *float64   `json:"price,omitempty"`
+	PublishAt   *time.Time `json:"publishAt,omitempty"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+}
+
+// MenuPreviewResponse represents the menu customers will see at a given time.
+type MenuPreviewResponse struct {
+	At     time.Time      `json:"at"`
+	Dishes []DishResponse `json:"dishes"`
+}
+
+// DishImportResponse summarises a bulk dish import.
+type DishImportResponse struct {
+	DryRun  bool           `json:"dryRun"`
+	Total   int            `json:"total"`   // Number of rows in the import
+	Created int            `json:"created"` // Rows that create a new dish
+	Updated int            `json:"updated"` // Rows that update an existing dish by SKU
+	Errors  []DishRowError `json:"errors,omitempty"`
+}
+
+// DishRowError describes why a row of a bulk dish import was rejected.
+type DishRowError struct {
+	Row    int      `json:"row"` // 1-based position of the dish in the import
+	SKU    string   `json:"sku,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+// DishExportRow represents a dish in a catalogue export, in the same shape the import accepts.
+type DishExportRow struct {
+	SKU         string   `json:"sku"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       float64  `json:"price"`
+	ImageUrl    string   `json:"imageUrl"`
+	Status      string   `json:"status"`
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+}
+
 // RatingResponse represents a response for a rating action.
 type RatingResponse struct {
-	ID     uuid.UUID `json:"id"`
-	Rating int       `json:"rating"`
-	DishId uuid.UUID `json:"dishId"`
+	ID      uuid.UUID `json:"id"`
+	Rating  int       `json:"rating"`
+	DishId  uuid.UUID `json:"dishId"`
+	Comment string    `json:"comment,omitempty"`
+	Status  string    `json:"status"` // Reviews are pending until staff approve or reject them
+}
+
+// ReviewResponse represents a customer's review of a dish as seen by staff.
+type ReviewResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	DishID      uuid.UUID  `json:"dishId"`
+	CustomerID  uuid.UUID  `json:"customerId"`
+	Rating      int        `json:"rating"`
+	Comment     string     `json:"comment"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ModeratedAt *time.Time `json:"moderatedAt,omitempty"`
+}
+
+// ReviewListResponse represents a page of reviews.
+type ReviewListResponse struct {
+	Reviews []ReviewResponse `json:"reviews"`
+	Pagination
 }
 
 /*// SuccessResponse represents a successful response with data.
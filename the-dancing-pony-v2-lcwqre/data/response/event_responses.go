@@ -0,0 +1,60 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderEvent is the payload of realtime order events.
+type OrderEvent struct {
+	OrderID         uuid.UUID           `json:"orderId"`
+	CustomerID      uuid.UUID           `json:"customerId"`
+	Status          string              `json:"status"`
+	Fulfilment      string              `json:"fulfilment"`
+	Notes           string              `json:"notes,omitempty"`
+	Reason          string              `json:"reason,omitempty"`
+	Total           float64             `json:"total"`
+	Items           []OrderItemResponse `json:"items"`
+	PlacedAt        time.Time           `json:"placedAt"`
+	StatusChangedAt time.Time           `json:"statusChangedAt"`
+}
+
+// DishAvailabilityEvent is the payload of realtime dish availability events.
+type DishAvailabilityEvent struct {
+	DishID    uuid.UUID `json:"dishId"`
+	Available bool      `json:"available"`
+}
+
+// DishEvent is the payload of dish created, updated and deleted events, which only staff
+// receive since the dish may not be published.
+type DishEvent struct {
+	DishID   uuid.UUID `json:"dishId"`
+	Name     string    `json:"name,omitempty"`
+	Price    float64   `json:"price,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Version  int64     `json:"version,omitempty"`
+}
+
+// ReviewEvent is the payload of review events.
+type ReviewEvent struct {
+	ReviewID   uuid.UUID `json:"reviewId"`
+	DishID     uuid.UUID `json:"dishId"`
+	CustomerID uuid.UUID `json:"customerId"`
+	Rating     int       `json:"rating"`
+	Comment    string    `json:"comment,omitempty"`
+	Status     string    `json:"status"`
+}
+
+// ReservationEvent is the payload of realtime reservation events.
+type ReservationEvent struct {
+	ReservationID uuid.UUID `json:"reservationId"`
+	CustomerID    uuid.UUID `json:"customerId"`
+	TableID       uuid.UUID `json:"tableId"`
+	PartySize     int       `json:"partySize"`
+	StartsAt      time.Time `json:"startsAt"`
+	EndsAt        time.Time `json:"endsAt"`
+	Status        string    `json:"status"`
+}
@@ -0,0 +1,61 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoyaltyProgramResponse represents a restaurant's loyalty configuration.
+type LoyaltyProgramResponse struct {
+	Enabled       bool    `json:"enabled"`
+	PointsPerUnit float64 `json:"pointsPerUnit"`
+	ReviewPoints  int     `json:"reviewPoints"`
+	ExpiryDays    int     `json:"expiryDays"`
+}
+
+// LoyaltyRewardResponse represents a reward points can be redeemed for.
+type LoyaltyRewardResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	PointsCost  int       `json:"pointsCost"`
+	Amount      float64   `json:"amount"`
+	Active      bool      `json:"active"`
+}
+
+// LoyaltyLotResponse represents points that expire together.
+type LoyaltyLotResponse struct {
+	Points    int       `json:"points"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// LoyaltyAccountResponse represents a customer's balance as computed from their ledger.
+type LoyaltyAccountResponse struct {
+	CustomerID uuid.UUID               `json:"customerId"`
+	Points     int                     `json:"points"`
+	Expiring   []LoyaltyLotResponse    `json:"expiring"` // Points that will expire, soonest first
+	Totals     map[string]int          `json:"totals"`   // Points by kind of ledger entry; they add up to the balance
+	Program    LoyaltyProgramResponse  `json:"program"`
+	Rewards    []LoyaltyRewardResponse `json:"rewards"` // Active rewards, cheapest first
+}
+
+// LoyaltyEntryResponse represents one line of a points ledger.
+type LoyaltyEntryResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Kind        string     `json:"kind"`
+	Points      int        `json:"points"`
+	OrderID     *uuid.UUID `json:"orderId,omitempty"`
+	RatingID    *uuid.UUID `json:"ratingId,omitempty"`
+	RewardID    *uuid.UUID `json:"rewardId,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	CreatedByID *uuid.UUID `json:"createdById,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// LoyaltyLedgerResponse represents a page of a points ledger.
+type LoyaltyLedgerResponse struct {
+	Entries []LoyaltyEntryResponse `json:"entries"`
+	Pagination
+}
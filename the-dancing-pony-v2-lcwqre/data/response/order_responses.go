@@ -0,0 +1,57 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderResponse represents an order and its items.
+type OrderResponse struct {
+	ID               uuid.UUID            `json:"id"`
+	CustomerID       uuid.UUID            `json:"customerId"`
+	Status           string               `json:"status"`
+	Fulfilment       string               `json:"fulfilment"`
+	Notes            string               `json:"notes,omitempty"`
+	Reason           string               `json:"reason,omitempty"`
+	Subtotal         float64              `json:"subtotal"`
+	Discounts        []DiscountResponse   `json:"discounts"`
+	Discount         float64              `json:"discount"`
+	Total            float64              `json:"total"`
+	PricesIncludeTax bool                 `json:"pricesIncludeTax"`
+	Tax              float64              `json:"tax"`               // Included in the total either way
+	Taxes            []TaxLineResponse    `json:"taxes"`             // Tax by rate, empty for untaxed orders
+	Invoice          *InvoiceResponse     `json:"invoice,omitempty"` // Set once the order is served or collected
+	CouponCode       string               `json:"couponCode,omitempty"`
+	Reward           *OrderRewardResponse `json:"reward,omitempty"`
+	Items            []OrderItemResponse  `json:"items"`
+	PlacedAt         time.Time            `json:"placedAt"`
+	StatusChangedAt  time.Time            `json:"statusChangedAt"`
+	NextStatuses     []string             `json:"nextStatuses"` // Statuses the caller may move the order to
+}
+
+// OrderRewardResponse represents the loyalty reward redeemed with an order.
+type OrderRewardResponse struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Points   int       `json:"points"`
+	Discount float64   `json:"discount"`
+}
+
+// OrderItemResponse represents one line of an order.
+type OrderItemResponse struct {
+	DishID    uuid.UUID `json:"dishId"`
+	Name      string    `json:"name"`
+	UnitPrice float64   `json:"unitPrice"`
+	Quantity  int       `json:"quantity"`
+	Notes     string    `json:"notes,omitempty"`
+	Subtotal  float64   `json:"subtotal"`
+	TaxName   string    `json:"taxName,omitempty"`
+	TaxRate   float64   `json:"taxRate"`
+}
+
+// OrderListResponse represents a page of orders.
+type OrderListResponse struct {
+	Orders []OrderResponse `json:"orders"`
+	Pagination
+}
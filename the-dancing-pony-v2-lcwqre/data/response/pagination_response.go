@@ -0,0 +1,18 @@
+package response
+
+// Pagination describes where a page sits in its list. Offset pages report their
+// page number; cursor pages report the cursors of the pages around them.
+type Pagination struct {
+	CurrentPage int       `json:"current_page,omitempty"` // Current page number, in offset mode
+	TotalPages  *int      `json:"total_pages,omitempty"`  // Total number of pages, when counted
+	TotalItems  *int      `json:"total_items,omitempty"`  // Total number of items, when counted
+	NextCursor  string    `json:"next_cursor,omitempty"`  // Cursor of the next page, in cursor mode
+	PrevCursor  string    `json:"prev_cursor,omitempty"`  // Cursor of the previous page, in cursor mode
+	Links       PageLinks `json:"links"`
+}
+
+// PageLinks holds the URLs of the pages around the current one.
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
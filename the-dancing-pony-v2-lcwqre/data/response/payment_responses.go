@@ -0,0 +1,32 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentResponse represents a payment of an order and its refunds.
+type PaymentResponse struct {
+	ID             uuid.UUID        `json:"id"`
+	OrderID        uuid.UUID        `json:"orderId"`
+	Status         string           `json:"status"`
+	Provider       string           `json:"provider"`
+	Amount         float64          `json:"amount"`
+	AmountRefunded float64          `json:"amountRefunded"`
+	Currency       string           `json:"currency"`
+	CaptureMethod  string           `json:"captureMethod"`
+	NextActionURL  string           `json:"nextActionUrl,omitempty"` // Where to send the customer to complete the payment, e.g. 3-D Secure
+	FailureCode    string           `json:"failureCode,omitempty"`
+	Refunds        []RefundResponse `json:"refunds"`
+	CreatedAt      time.Time        `json:"createdAt"`
+}
+
+// RefundResponse represents a refund of a payment.
+type RefundResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Status    string    `json:"status"`
+	Amount    float64   `json:"amount"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,42 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionResponse represents a promotion as seen by staff.
+type PromotionResponse struct {
+	ID               uuid.UUID  `json:"id"`
+	Name             string     `json:"name"`
+	Code             string     `json:"code,omitempty"` // Empty for automatic promotions
+	Active           bool       `json:"active"`
+	Priority         int        `json:"priority"`
+	Stackable        bool       `json:"stackable"`
+	StartsAt         *time.Time `json:"startsAt,omitempty"`
+	EndsAt           *time.Time `json:"endsAt,omitempty"`
+	Timezone         string     `json:"timezone"`
+	Weekdays         []int      `json:"weekdays"`
+	DailyFrom        string     `json:"dailyFrom,omitempty"`
+	DailyUntil       string     `json:"dailyUntil,omitempty"`
+	Categories       []string   `json:"categories"`
+	Tags             []string   `json:"tags"`
+	MinSpend         float64    `json:"minSpend"`
+	FirstOrderOnly   bool       `json:"firstOrderOnly"`
+	PerCustomerLimit int        `json:"perCustomerLimit"`
+	UsageLimit       int        `json:"usageLimit"`
+	DiscountType     string     `json:"discountType"`
+	Value            float64    `json:"value"`
+	BuyQuantity      int        `json:"buyQuantity,omitempty"`
+	GetQuantity      int        `json:"getQuantity,omitempty"`
+	Redemptions      *int64     `json:"redemptions,omitempty"` // Redemptions that still hold, on single promotion lookups
+}
+
+// DiscountResponse represents a promotion applied to a cart or order.
+type DiscountResponse struct {
+	PromotionID uuid.UUID `json:"promotionId"`
+	Name        string    `json:"name"`
+	Code        string    `json:"code,omitempty"`
+	Amount      float64   `json:"amount"`
+}
@@ -0,0 +1,89 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReservationResponse represents a reservation.
+type ReservationResponse struct {
+	ID              uuid.UUID `json:"id"`
+	CustomerID      uuid.UUID `json:"customerId"`
+	TableID         uuid.UUID `json:"tableId"`
+	PartySize       int       `json:"partySize"`
+	StartsAt        time.Time `json:"startsAt"`
+	EndsAt          time.Time `json:"endsAt"`
+	Status          string    `json:"status"`
+	Notes           string    `json:"notes,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	StatusChangedAt time.Time `json:"statusChangedAt"`
+	NextStatuses    []string  `json:"nextStatuses"` // Statuses the caller may move the reservation to
+}
+
+// ReservationListResponse represents a page of reservations.
+type ReservationListResponse struct {
+	Reservations []ReservationResponse `json:"reservations"`
+	Pagination
+}
+
+// AvailabilityResponse lists the start times of a day at which a party can be seated.
+type AvailabilityResponse struct {
+	Date      string         `json:"date"`
+	Timezone  string         `json:"timezone"`
+	PartySize int            `json:"partySize"`
+	Slots     []SlotResponse `json:"slots"`
+}
+
+// SlotResponse represents a bookable start time.
+type SlotResponse struct {
+	StartsAt   time.Time `json:"startsAt"`
+	EndsAt     time.Time `json:"endsAt"`
+	Available  bool      `json:"available"`
+	FreeTables int       `json:"freeTables"`
+}
+
+// WaitlistEntryResponse represents a waitlist entry.
+type WaitlistEntryResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	CustomerID    uuid.UUID  `json:"customerId"`
+	PartySize     int        `json:"partySize"`
+	DesiredAt     time.Time  `json:"desiredAt"`
+	Status        string     `json:"status"`
+	Notes         string     `json:"notes,omitempty"`
+	ReservationID *uuid.UUID `json:"reservationId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// DiningAreaResponse represents a dining area.
+type DiningAreaResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// DiningTableResponse represents a table.
+type DiningTableResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	AreaID      *uuid.UUID `json:"areaId"`
+	MinCapacity int        `json:"minCapacity"`
+	MaxCapacity int        `json:"maxCapacity"`
+	Active      bool       `json:"active"`
+}
+
+// ScheduleResponse represents a restaurant's reservation settings and weekly opening hours.
+type ScheduleResponse struct {
+	Timezone        string                 `json:"timezone"`
+	SlotMinutes     int                    `json:"slotMinutes"`
+	DurationMinutes int                    `json:"durationMinutes"`
+	MaxPartySize    int                    `json:"maxPartySize"`
+	MaxAdvanceDays  int                    `json:"maxAdvanceDays"`
+	Hours           []OpeningHoursResponse `json:"hours"`
+}
+
+// OpeningHoursResponse represents a period in which reservations are seated.
+type OpeningHoursResponse struct {
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
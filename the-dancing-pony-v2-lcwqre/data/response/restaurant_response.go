@@ -11,14 +11,13 @@ type RestaurantResponse struct {
 	Description string    `json:"description"`
 	Location    string    `json:"location"`
 	ImageUrl    string    `json:"imageUrl"`
+	Version     int64     `json:"version"`
 }
 
 // RestaurantListResponse represents a response containing a list of restaurants.
 type RestaurantListResponse struct {
-	Restaurants []RestaurantResponse `json:"restaurants"`  // List of restaurants for the current page
-	CurrentPage int                  `json:"current_page"` // Current page number
-	TotalPages  int                  `json:"total_pages"`  // Total number of pages
-	TotalItems  int                  `json:"total_items"`  // Total number of items
+	Restaurants []RestaurantResponse `json:"restaurants"` // List of restaurants for the current page
+	Pagination
 }
 
 // SearchRestaurantsResponse represents a response containing search results for restaurants.
@@ -0,0 +1,9 @@
+package response
+
+// SettingResponse represents a setting of the running instance and where it was read from.
+type SettingResponse struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Value  string `json:"value"`  // "[redacted]" for secrets that are set
+	Source string `json:"source"` // "default", "file:<path>", "env:<variable>" or "flag:-<name>"
+}
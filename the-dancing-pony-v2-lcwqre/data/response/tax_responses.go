@@ -0,0 +1,45 @@
+package response
+
+import (
+	"time"
+)
+
+// TaxSettingsResponse represents a restaurant's tax configuration and invoice details.
+type TaxSettingsResponse struct {
+	Configured       bool              `json:"configured"` // False while the restaurant charges no tax
+	PricesIncludeTax bool              `json:"pricesIncludeTax"`
+	Rates            []TaxRateResponse `json:"rates"`
+	LegalName        string            `json:"legalName,omitempty"`
+	Address          string            `json:"address,omitempty"`
+	TaxNumber        string            `json:"taxNumber,omitempty"`
+	InvoicePrefix    string            `json:"invoicePrefix"`
+}
+
+// TaxRateResponse represents a named tax rate and the dish categories it applies to.
+type TaxRateResponse struct {
+	Name       string   `json:"name"`
+	Rate       float64  `json:"rate"`
+	Categories []string `json:"categories"` // Empty for the default rate
+}
+
+// TaxLineResponse represents the tax of an order or cart at one rate.
+type TaxLineResponse struct {
+	Name  string  `json:"name"`
+	Rate  float64 `json:"rate"`
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+	Gross float64 `json:"gross"`
+}
+
+// InvoiceResponse represents the invoice of an order.
+type InvoiceResponse struct {
+	Number   string    `json:"number"`
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+// ReceiptFile is a rendered receipt, sent as a download.
+type ReceiptFile struct {
+	Filename    string
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,57 @@
+package response
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscriptionResponse represents a webhook subscription.
+type WebhookSubscriptionResponse struct {
+	ID                  uuid.UUID  `json:"id"`
+	URL                 string     `json:"url"`
+	Description         string     `json:"description,omitempty"`
+	EventTypes          []string   `json:"eventTypes"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"` // Set when it was disabled for failing
+	DisabledReason      string     `json:"disabledReason,omitempty"`
+	Secret              string     `json:"secret,omitempty"` // Only returned when created or rotated
+	CreatedAt           time.Time  `json:"createdAt"`
+}
+
+// WebhookDeliveryResponse represents a delivery of an event to a subscription.
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID                `json:"id"`
+	SubscriptionID uuid.UUID                `json:"subscriptionId"`
+	EventID        string                   `json:"eventId"`
+	EventType      string                   `json:"eventType"`
+	Status         string                   `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"nextAttemptAt,omitempty"`
+	LastAttemptAt  *time.Time               `json:"lastAttemptAt,omitempty"`
+	ResponseStatus int                      `json:"responseStatus,omitempty"`
+	Error          string                   `json:"error,omitempty"`
+	DeliveredAt    *time.Time               `json:"deliveredAt,omitempty"`
+	ReplayOfID     *uuid.UUID               `json:"replayOfId,omitempty"`
+	CreatedAt      time.Time                `json:"createdAt"`
+	Payload        json.RawMessage          `json:"payload,omitempty"` // Only returned for a single delivery
+	Log            []WebhookAttemptResponse `json:"log,omitempty"`     // Only returned for a single delivery
+}
+
+// WebhookAttemptResponse represents one request made for a delivery.
+type WebhookAttemptResponse struct {
+	Number         int       `json:"number"`
+	ResponseStatus int       `json:"responseStatus,omitempty"`
+	ResponseBody   string    `json:"responseBody,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"durationMs"`
+	AttemptedAt    time.Time `json:"attemptedAt"`
+}
+
+// WebhookDeliveryListResponse represents a page of a subscription's delivery log.
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Pagination
+}
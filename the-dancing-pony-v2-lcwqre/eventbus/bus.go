@@ -0,0 +1,57 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Handler reacts to an event. Events are delivered at least once and in commit order, so
+// handlers must tolerate seeing an event again. Returning an error retries the event, and
+// holds back the later events of the consumer, until the relay gives up on it.
+type Handler func(ctx context.Context, event Event) error
+
+// Consumer is a named handler of some event types. The relay keeps an offset per
+// consumer name, so renaming a consumer replays the retained events to it.
+type Consumer struct {
+	Name    string
+	Types   []string // Empty for every type
+	Handler Handler
+}
+
+// Wants reports whether the consumer handles events of the given type.
+func (c Consumer) Wants(eventType string) bool {
+	return len(c.Types) == 0 || slices.Contains(c.Types, eventType)
+}
+
+// Bus holds the consumers the outbox relay delivers events to.
+type Bus struct {
+	mu        sync.RWMutex
+	consumers []Consumer
+}
+
+// NewBus creates a new instance of Bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds a consumer of the given event types, or of every type when none are
+// given. Names must be unique.
+func (b *Bus) Subscribe(name string, handler Handler, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, consumer := range b.consumers {
+		if consumer.Name == name {
+			panic(fmt.Sprintf("eventbus: consumer %q subscribed twice", name))
+		}
+	}
+	b.consumers = append(b.consumers, Consumer{Name: name, Types: types, Handler: handler})
+}
+
+// Consumers returns the consumers in the order they subscribed.
+func (b *Bus) Consumers() []Consumer {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Clone(b.consumers)
+}
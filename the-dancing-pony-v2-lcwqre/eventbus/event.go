@@ -0,0 +1,86 @@
+// Package eventbus defines the domain events written to the transactional outbox and
+// the consumers the outbox relay hands them to.
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of domain events.
+const (
+	DishCreated     = "dish.created"
+	DishUpdated     = "dish.updated"
+	DishDeleted     = "dish.deleted"
+	RatingAdded     = "rating.added"
+	RatingModerated = "rating.moderated"
+)
+
+// Types of the aggregates events are about.
+const (
+	AggregateDish   = "dish"
+	AggregateRating = "rating"
+)
+
+// Event is a change committed to the database, recorded in the same transaction.
+type Event struct {
+	ID            uuid.UUID       `json:"id"` // Stable across redeliveries, for consumers to drop duplicates
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   uuid.UUID       `json:"aggregateId"`
+	RestaurantID  string          `json:"restaurantId"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+}
+
+// DishPayload is the state of a dish after a DishCreated, DishUpdated or DishDeleted event.
+type DishPayload struct {
+	DishID      uuid.UUID `json:"dishId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       float64   `json:"price"`
+	Image       string    `json:"image,omitempty"`
+	SKU         *string   `json:"sku,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Tags        []string  `json:"tags"`
+	Status      string    `json:"status"`
+	Version     int64     `json:"version"`
+}
+
+// RatingPayload is the state of a rating after a RatingAdded or RatingModerated event.
+type RatingPayload struct {
+	RatingID   uuid.UUID `json:"ratingId"`
+	DishID     uuid.UUID `json:"dishId"`
+	CustomerID uuid.UUID `json:"customerId"`
+	Rating     int       `json:"rating"`
+	Comment    string    `json:"comment,omitempty"`
+	Status     string    `json:"status"`
+}
+
+// New creates an event with a new ID and the JSON encoding of payload.
+func New(eventType string, aggregateType string, aggregateId uuid.UUID, restaurantId string, payload interface{}) (Event, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("error encoding %s event: %w", eventType, err)
+	}
+	return Event{
+		ID:            uuid.New(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateId,
+		RestaurantID:  restaurantId,
+		Payload:       encoded,
+		OccurredAt:    time.Now().UTC(),
+	}, nil
+}
+
+// Decode decodes the payload of the event into v.
+func (e Event) Decode(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("error decoding %s event %s: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
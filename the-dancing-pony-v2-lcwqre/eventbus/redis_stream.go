@@ -0,0 +1,35 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// NewRedisStreamHandler returns a handler appending events to a Redis stream, trimmed to
+// about maxLen entries, for consumers outside this service. Entries carry the event ID,
+// which readers use to drop the duplicates at-least-once delivery can produce.
+func NewRedisStreamHandler(client *redis.Client, stream string, maxLen int64) Handler {
+	return func(ctx context.Context, event Event) error {
+		err := client.XAdd(ctx, &redis.XAddArgs{
+			Stream: stream,
+			MaxLen: maxLen,
+			Approx: true,
+			Values: map[string]interface{}{
+				"id":             event.ID.String(),
+				"type":           event.Type,
+				"aggregate_type": event.AggregateType,
+				"aggregate_id":   event.AggregateID.String(),
+				"restaurant_id":  event.RestaurantID,
+				"payload":        string(event.Payload),
+				"occurred_at":    event.OccurredAt.Format(time.RFC3339Nano),
+			},
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("error appending event %s to redis stream %s: %w", event.ID, stream, err)
+		}
+		return nil
+	}
+}
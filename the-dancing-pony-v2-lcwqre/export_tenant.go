@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+)
+
+const exportTenantUsage = `usage: thedancingpony export-tenant -restaurant <id> [flags]
+
+Writes every row of a restaurant's data, soft-deleted ones included, as one JSON
+object with a list of rows per table. Password hashes and webhook secrets are redacted.
+
+Flags:
+`
+
+// redactedColumns are left out of exports, by table.
+var redactedColumns = map[string][]string{
+	"users":                 {"password"},
+	"webhook_subscriptions": {"secret"},
+}
+
+// tenantExport writes the rows of an export as JSON as they are read.
+type tenantExport struct {
+	w     *bufio.Writer
+	table string
+	rows  int
+}
+
+// BeginTable closes the list of rows of the previous table and opens the next one.
+func (e *tenantExport) BeginTable(table string) error {
+	separator := ","
+	if e.table == "" {
+		separator = ""
+	} else {
+		e.w.WriteString("\n  ]")
+	}
+	name, _ := json.Marshal(table)
+	e.table, e.rows = table, 0
+	_, err := fmt.Fprintf(e.w, "%s\n  %s: [", separator, name)
+	return err
+}
+
+// WriteRow appends a row to the list of the current table.
+func (e *tenantExport) WriteRow(row map[string]interface{}) error {
+	for _, column := range redactedColumns[e.table] {
+		if _, ok := row[column]; ok {
+			row[column] = "[redacted]"
+		}
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if e.rows > 0 {
+		e.w.WriteString(",")
+	}
+	e.rows++
+	e.w.WriteString("\n    ")
+	_, err = e.w.Write(data)
+	return err
+}
+
+// runExportTenant runs the export-tenant command.
+func runExportTenant(settings config.Settings, args []string) int {
+	flags := newFlagSet("export-tenant", exportTenantUsage)
+	restaurant := flags.String("restaurant", "", "ID of the restaurant to export")
+	output := flags.String("o", "", "file to write the export to instead of standard output")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	restaurantId, err := uuid.Parse(*restaurant)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error: -restaurant must be a restaurant ID")
+		return 2
+	}
+
+	db, ok := openDatabase(settings)
+	if !ok {
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := exportTenant(repository.NewMaintenanceRepositoryImpl(db), restaurantId, out); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		if *output != "" {
+			os.Remove(*output)
+		}
+		return 1
+	}
+	return 0
+}
+
+// exportTenant writes the export of a restaurant to out.
+func exportTenant(maintenanceRepository repository.MaintenanceRepository, restaurantId uuid.UUID, out io.Writer) error {
+	export := &tenantExport{w: bufio.NewWriter(out)}
+	header, _ := json.Marshal(map[string]interface{}{
+		"restaurant_id": restaurantId,
+		"exported_at":   time.Now().UTC(),
+	})
+	// Open the header object again to add the tables to it
+	fmt.Fprintf(export.w, "%s,\n\"tables\": {", header[:len(header)-1])
+
+	if err := maintenanceRepository.ExportTenant(restaurantId, export); err != nil {
+		return err
+	}
+	if export.table != "" {
+		export.w.WriteString("\n  ]")
+	}
+	export.w.WriteString("\n}}\n")
+	return export.w.Flush()
+}
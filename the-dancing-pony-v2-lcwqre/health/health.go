@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Paths the liveness and readiness endpoints are served at.
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Statuses of a dependency.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check reports whether a dependency can be used, returning nil when it can.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of the check of a dependency.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"` // Whether the instance is unready while the dependency is down
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness of the instance and the results of its checks.
+type Report struct {
+	Ready    bool     `json:"ready"`
+	Draining bool     `json:"draining"` // Set once the instance is shutting down
+	Checks   []Result `json:"checks"`
+}
+
+type dependency struct {
+	name     string
+	required bool
+	check    Check
+}
+
+// Checker checks the dependencies of the instance for the readiness endpoint. An instance
+// is ready once it is marked as serving, until it starts draining, while every required
+// dependency is up; optional dependencies are reported without affecting readiness.
+type Checker struct {
+	timeout      time.Duration
+	dependencies []dependency
+	serving      atomic.Bool
+	draining     atomic.Bool
+}
+
+// NewChecker creates a new instance of Checker giving each check at most timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers the check of a dependency. It must not be called once requests are served.
+func (c *Checker) Add(name string, required bool, check Check) {
+	c.dependencies = append(c.dependencies, dependency{name: name, required: required, check: check})
+}
+
+// SetServing marks the instance as ready to serve once it has started.
+func (c *Checker) SetServing() {
+	c.serving.Store(true)
+}
+
+// SetDraining marks the instance as shutting down, so that it reports as unready and
+// load balancers stop sending it requests while those in flight finish.
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+// Check runs the checks of the dependencies concurrently and reports the readiness of the
+// instance.
+func (c *Checker) Check(ctx context.Context) Report {
+	results := make([]Result, len(c.dependencies))
+	var wg sync.WaitGroup
+	for i, dep := range c.dependencies {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			started := time.Now()
+			err := dep.check(checkCtx)
+			results[i] = Result{
+				Name:      dep.name,
+				Status:    StatusUp,
+				Required:  dep.required,
+				LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = StatusDown
+				results[i].Error = err.Error()
+			}
+		}(i, dep)
+	}
+	wg.Wait()
+
+	report := Report{
+		Ready:    c.serving.Load() && !c.draining.Load(),
+		Draining: c.draining.Load(),
+		Checks:   results,
+	}
+	for _, result := range results {
+		if result.Required && result.Status == StatusDown {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// LivenessHandler answers 200 while the process serves requests at all. It checks no
+// dependency, so that an outage of one does not get every instance restarted.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusUp})
+	})
+}
+
+// ReadinessHandler answers 200 with the report when the instance is ready, and 503 with
+// it otherwise.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Check(r.Context())
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+// writeJSON writes an uncacheable JSON response.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
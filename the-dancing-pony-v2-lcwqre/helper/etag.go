@@ -0,0 +1,77 @@
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ETag formats a resource version as a strong entity tag.
+func ETag(version int64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// SetETag writes the ETag header for a resource version.
+func SetETag(c *gin.Context, version int64) {
+	c.Header("ETag", ETag(version))
+}
+
+// IfMatchVersions parses the If-Match header into the versions a write may apply to.
+// A missing header or "*" yields no versions, meaning any version. When the header
+// lists no tag that could ever match, a 412 response is written and ok is false.
+func IfMatchVersions(c *gin.Context, requestID string) (versions []int64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		// If-Match uses strong comparison, so weak tags never match
+		if version, valid := parseETag(strings.TrimSpace(tag)); valid {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("if_match", header).
+			Msg("If-Match lists no usable entity tag")
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return nil, false
+	}
+	return versions, true
+}
+
+// NotModified sets the ETag header and reports whether If-None-Match already names
+// this version, in which case a 304 response has been written.
+func NotModified(c *gin.Context, version int64) bool {
+	SetETag(c, version)
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	matched := header == "*"
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Match uses weak comparison
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if v, valid := parseETag(tag); valid && v == version {
+			matched = true
+		}
+	}
+	if matched {
+		c.Status(http.StatusNotModified)
+	}
+	return matched
+}
+
+// parseETag reads the version out of a strong entity tag produced by ETag.
+func parseETag(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	return version, err == nil
+}
@@ -7,12 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
-	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
 )
 
@@ -203,17 +201,3 @@ func GetRestaurantAsString(ctx *gin.Context) (string, error) {
 	// Return the restaurant ID as a string
 	return restaurantIDStr, nil
 }
-
-// GetJWTSecret retrieves the JWT secret from the environment variable.
-func GetJWTSecret() string {
-	if err := godotenv.Load(); err != nil {
-		log.Error().Err(err).Msg("Error loading .env file")
-	}
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		errMsg := "JWT_SECRET environment variable is not set"
-		log.Error().Msg(errMsg)
-		return ""
-	}
-	return secret
-}
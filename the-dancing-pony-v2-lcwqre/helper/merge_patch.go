@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// IsMergePatch reports whether the request body is a JSON Merge Patch. Plain
+// application/json is accepted too, since existing clients send that.
+func IsMergePatch(c *gin.Context) bool {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == request.MergePatchContentType || mediaType == "application/json"
+}
+
+// BindMergePatch decodes a merge patch document and writes a 400 response when it is malformed.
+func BindMergePatch(c *gin.Context, body io.Reader, patch interface{}, requestID string) bool {
+	if err := request.DecodeMergePatch(body, patch); err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Err(err).
+			Msg("Failed to decode merge patch")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// RespondPatchValidation writes a 400 response listing the invalid members of a patch.
+func RespondPatchValidation(c *gin.Context, errs []string, requestID string) bool {
+	if len(errs) == 0 {
+		return true
+	}
+	log.Warn().
+		Str("request_id", requestID).
+		Strs("validation_errors", errs).
+		Msg("Validation errors encountered")
+	c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+	return false
+}
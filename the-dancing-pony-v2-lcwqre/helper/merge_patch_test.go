@@ -0,0 +1,33 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsMergePatch(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{contentType: "application/merge-patch+json", want: true},
+		{contentType: "application/merge-patch+json; charset=utf-8", want: true},
+		{contentType: "application/json", want: true},
+		{contentType: "application/json-patch+json", want: false},
+		{contentType: "text/plain", want: false},
+		{contentType: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", nil)
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			if got := IsMergePatch(c); got != tt.want {
+				t.Errorf("IsMergePatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+)
+
+// Client enqueues jobs.
+type Client struct {
+	store Store
+}
+
+// NewClient creates a new instance of Client.
+func NewClient(store Store) *Client {
+	return &Client{store: store}
+}
+
+// Enqueue stores a job of the given type with the JSON encoding of payload, due now
+// unless delayed. It returns ErrDuplicateJob when a unique job is already queued.
+func (c *Client) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...Option) (Job, error) {
+	job, err := newJob(jobType, payload, opts...)
+	if err != nil {
+		return Job{}, err
+	}
+	if err := c.store.Enqueue(ctx, job); err != nil {
+		return Job{}, err
+	}
+	jobsEnqueued.WithLabelValues(job.Type).Inc()
+	return job, nil
+}
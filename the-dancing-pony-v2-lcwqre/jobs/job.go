@@ -0,0 +1,153 @@
+// Package jobs runs background work outside the request path: jobs are stored in
+// Postgres or Redis, picked up by workers with retries and backoff, and buried as dead
+// once their attempts run out.
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a job. Jobs that succeed are removed.
+const (
+	StatusPending = "pending" // Waiting for RunAt, or for a retry
+	StatusRunning = "running" // Leased by a worker
+	StatusDead    = "dead"    // Failed every attempt, or failed permanently; kept for inspection
+)
+
+// DefaultMaxAttempts is how many times a job is attempted unless enqueued with MaxAttempts.
+const DefaultMaxAttempts = 5
+
+var (
+	// ErrDuplicateJob is returned when a unique job is enqueued while another job with the
+	// same key is pending or running.
+	ErrDuplicateJob = errors.New("a job with this unique key is already queued")
+
+	// ErrLeaseExpired is the failure recorded for a job buried because its lease ran out on
+	// its last attempt, most likely as its worker stopped.
+	ErrLeaseExpired = errors.New("lease expired on the last attempt")
+
+	// ErrNoHandler is returned when a job is enqueued or scheduled for a type no handler was registered for.
+	ErrNoHandler = errors.New("no handler for job type")
+)
+
+// Job is a unit of background work.
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	UniqueKey   string          `json:"uniqueKey,omitempty"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"` // Including the one running
+	MaxAttempts int             `json:"maxAttempts"`
+	RunAt       time.Time       `json:"runAt"` // When the job is due
+	LastError   string          `json:"lastError,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// Option changes how a job is enqueued.
+type Option func(*Job)
+
+// Delay runs the job no earlier than d from now.
+func Delay(d time.Duration) Option {
+	return func(job *Job) {
+		job.RunAt = time.Now().Add(d)
+	}
+}
+
+// At runs the job no earlier than t.
+func At(t time.Time) Option {
+	return func(job *Job) {
+		job.RunAt = t
+	}
+}
+
+// Unique keeps the job from being enqueued while another job with the same key is
+// pending or running; Enqueue returns ErrDuplicateJob instead.
+func Unique(key string) Option {
+	return func(job *Job) {
+		job.UniqueKey = key
+	}
+}
+
+// MaxAttempts sets how many times the job is attempted before it is buried.
+func MaxAttempts(n int) Option {
+	return func(job *Job) {
+		if n > 0 {
+			job.MaxAttempts = n
+		}
+	}
+}
+
+// permanentError marks a failure retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error returned by a handler so the job is buried right away
+// instead of retried.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// isPermanent reports whether a handler error was wrapped with Permanent.
+func isPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// Backoff spaces out the attempts of a failing job.
+type Backoff struct {
+	Base time.Duration // Delay before the second attempt
+	Max  time.Duration // Longest delay
+}
+
+// DefaultBackoff waits 10s before the second attempt, doubling up to an hour.
+var DefaultBackoff = Backoff{Base: 10 * time.Second, Max: time.Hour}
+
+// Delay returns how long to wait after the given failed attempt, counted from 1, with
+// ±20% jitter so jobs that failed together do not retry together.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	jitter := (rand.Float64()*0.4 - 0.2) * float64(delay)
+	return delay + time.Duration(jitter)
+}
+
+// newJob creates a pending job of the given type with the JSON encoding of payload.
+func newJob(jobType string, payload interface{}, opts ...Option) (Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("error encoding %s job: %w", jobType, err)
+	}
+	now := time.Now()
+	job := Job{
+		ID:          uuid.New(),
+		Type:        jobType,
+		Payload:     encoded,
+		Status:      StatusPending,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(&job)
+	}
+	return job, nil
+}
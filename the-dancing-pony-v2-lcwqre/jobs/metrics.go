@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	jobsEnqueued = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobs_enqueued_total",
+			Help: "Number of jobs enqueued by this process, by type.",
+		},
+		[]string{"type"},
+	)
+	jobsProcessed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobs_processed_total",
+			Help: "Number of job attempts run by this process, by type and outcome (succeeded, retried or dead).",
+		},
+		[]string{"type", "outcome"},
+	)
+	jobsWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "jobs_wait_seconds",
+			Help:    "Time from a job coming due to a worker starting it, by type.",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+		},
+		[]string{"type"},
+	)
+	jobsDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "jobs_duration_seconds",
+			Help:    "Time spent running a job attempt, by type.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"type"},
+	)
+	jobsQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "jobs_queue_depth",
+			Help: "Number of queued jobs, by type and status (pending, running or dead).",
+		},
+		[]string{"type", "status"},
+	)
+	jobsOldestPending = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "jobs_oldest_pending_age_seconds",
+			Help: "Time the oldest due pending job has been waiting, by type.",
+		},
+		[]string{"type"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(jobsEnqueued, jobsProcessed, jobsWait, jobsDuration, jobsQueueDepth, jobsOldestPending)
+}
@@ -0,0 +1,251 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// RedisStore keeps jobs in Redis. Each job is a hash holding its JSON encoding, attempts,
+// status and last error; sorted sets per type index the pending jobs by due time, the
+// running ones by lease expiry and the dead ones by when they were buried. Every state
+// change is a Lua script, so workers sharing the store never claim a job twice.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a new instance of RedisStore with its keys under prefix.
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) jobKey(id uuid.UUID) string { return s.prefix + "job:" + id.String() }
+func (s *RedisStore) pendingKey(t string) string { return s.prefix + "pending:" + t }
+func (s *RedisStore) runningKey(t string) string { return s.prefix + "running:" + t }
+func (s *RedisStore) deadKey(t string) string    { return s.prefix + "dead:" + t }
+func (s *RedisStore) uniqueKey(key string) string {
+	if key == "" {
+		return s.prefix + "unique:"
+	}
+	return s.prefix + "unique:" + key
+}
+
+// KEYS: job, pending, unique. ARGV: id, data, runAt, unique key.
+var redisEnqueue = redis.NewScript(`
+if ARGV[4] ~= '' and redis.call('SET', KEYS[3], ARGV[1], 'NX') == false then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'data', ARGV[2], 'attempts', 0, 'status', 'pending', 'error', '')
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`)
+
+// KEYS: pending, running, dead. ARGV: now, limit, lease expiry, job key prefix, unique
+// key prefix, error of jobs buried for an expired lease.
+var redisClaim = redis.NewScript(`
+local limit = tonumber(ARGV[2])
+local claimed = {}
+local function bury(id)
+	local key = ARGV[4] .. id
+	local job = cjson.decode(redis.call('HGET', key, 'data'))
+	if tonumber(redis.call('HGET', key, 'attempts')) < (tonumber(job.maxAttempts) or 0) then
+		return false
+	end
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[3], ARGV[1], id)
+	redis.call('HSET', key, 'status', 'dead', 'error', ARGV[6])
+	if job.uniqueKey and redis.call('GET', ARGV[5] .. job.uniqueKey) == id then
+		redis.call('DEL', ARGV[5] .. job.uniqueKey)
+	end
+	return true
+end
+local function claim(set, id)
+	local key = ARGV[4] .. id
+	redis.call('ZREM', set, id)
+	redis.call('ZADD', KEYS[2], ARGV[3], id)
+	local attempts = redis.call('HINCRBY', key, 'attempts', 1)
+	redis.call('HSET', key, 'status', 'running')
+	local job = redis.call('HMGET', key, 'data', 'error')
+	table.insert(claimed, {job[1], attempts, job[2]})
+end
+for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, limit)) do
+	if not bury(id) then
+		claim(KEYS[2], id)
+	end
+end
+if #claimed < limit then
+	for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, limit - #claimed)) do
+		claim(KEYS[1], id)
+	end
+end
+return claimed
+`)
+
+// KEYS: job, running, target set (pending or dead, unused on completion), unique.
+// ARGV: id, attempts, outcome (complete, retry or bury), score, error.
+var redisFinish = redis.NewScript(`
+if redis.call('ZSCORE', KEYS[2], ARGV[1]) == false or redis.call('HGET', KEYS[1], 'attempts') ~= ARGV[2] then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+if ARGV[3] == 'retry' then
+	redis.call('ZADD', KEYS[3], ARGV[4], ARGV[1])
+	redis.call('HSET', KEYS[1], 'status', 'pending', 'error', ARGV[5])
+	return 1
+end
+if redis.call('GET', KEYS[4]) == ARGV[1] then
+	redis.call('DEL', KEYS[4])
+end
+if ARGV[3] == 'bury' then
+	redis.call('ZADD', KEYS[3], ARGV[4], ARGV[1])
+	redis.call('HSET', KEYS[1], 'status', 'dead', 'error', ARGV[5])
+else
+	redis.call('DEL', KEYS[1])
+end
+return 1
+`)
+
+// KEYS: slot. ARGV: slot.
+var redisClaimSlot = redis.NewScript(`
+local last = redis.call('GET', KEYS[1])
+if last and tonumber(last) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1])
+return 1
+`)
+
+func (s *RedisStore) Enqueue(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("error encoding job %s: %w", job.ID, err)
+	}
+	keys := []string{s.jobKey(job.ID), s.pendingKey(job.Type), s.uniqueKey(job.UniqueKey)}
+	added, err := redisEnqueue.Run(ctx, s.client, keys, job.ID.String(), data, job.RunAt.UnixMilli(), job.UniqueKey).Int()
+	if err != nil {
+		return fmt.Errorf("error enqueuing job %s: %w", job.ID, err)
+	}
+	if added == 0 {
+		return ErrDuplicateJob
+	}
+	return nil
+}
+
+func (s *RedisStore) Claim(ctx context.Context, types []string, limit int, lease time.Duration, now time.Time) ([]Job, error) {
+	var jobs []Job
+	for _, jobType := range types {
+		if len(jobs) >= limit {
+			break
+		}
+		keys := []string{s.pendingKey(jobType), s.runningKey(jobType), s.deadKey(jobType)}
+		result, err := redisClaim.Run(ctx, s.client, keys, now.UnixMilli(), limit-len(jobs), now.Add(lease).UnixMilli(),
+			s.prefix+"job:", s.prefix+"unique:", ErrLeaseExpired.Error()).Slice()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return jobs, fmt.Errorf("error claiming %s jobs: %w", jobType, err)
+		}
+		for _, entry := range result {
+			job, err := decodeRedisJob(entry)
+			if err != nil {
+				return jobs, err
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// decodeRedisJob builds a claimed job from the data, attempts and error returned by the
+// claim script.
+func decodeRedisJob(entry interface{}) (Job, error) {
+	fields, ok := entry.([]interface{})
+	if !ok || len(fields) != 3 {
+		return Job{}, fmt.Errorf("unexpected claimed job %v", entry)
+	}
+	data, _ := fields[0].(string)
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return Job{}, fmt.Errorf("error decoding claimed job: %w", err)
+	}
+	attempts, _ := fields[1].(int64)
+	job.Attempts = int(attempts)
+	job.LastError, _ = fields[2].(string)
+	job.Status = StatusRunning
+	return job, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, job Job) error {
+	return s.finish(ctx, job, "complete", "", 0, "")
+}
+
+func (s *RedisStore) Retry(ctx context.Context, job Job, runAt time.Time, reason string) error {
+	return s.finish(ctx, job, "retry", s.pendingKey(job.Type), runAt.UnixMilli(), reason)
+}
+
+func (s *RedisStore) Bury(ctx context.Context, job Job, reason string) error {
+	return s.finish(ctx, job, "bury", s.deadKey(job.Type), time.Now().UnixMilli(), reason)
+}
+
+// finish records the outcome of an attempt, unless the lease ran out and the job was
+// claimed again, in which case the newer attempt decides.
+func (s *RedisStore) finish(ctx context.Context, job Job, outcome string, target string, score int64, reason string) error {
+	if target == "" {
+		target = s.pendingKey(job.Type)
+	}
+	keys := []string{s.jobKey(job.ID), s.runningKey(job.Type), target, s.uniqueKey(job.UniqueKey)}
+	err := redisFinish.Run(ctx, s.client, keys, job.ID.String(), strconv.Itoa(job.Attempts), outcome, score, truncateError(reason)).Err()
+	if err != nil {
+		return fmt.Errorf("error updating job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) ClaimSlot(ctx context.Context, schedule string, slot time.Time) (bool, error) {
+	claimed, err := redisClaimSlot.Run(ctx, s.client, []string{s.prefix + "slot:" + schedule}, slot.Unix()).Int()
+	if err != nil {
+		return false, fmt.Errorf("error claiming slot of schedule %s: %w", schedule, err)
+	}
+	return claimed == 1, nil
+}
+
+func (s *RedisStore) Stats(ctx context.Context, types []string, now time.Time) ([]Stat, error) {
+	var stats []Stat
+	for _, jobType := range types {
+		pipe := s.client.Pipeline()
+		pending := pipe.ZCard(ctx, s.pendingKey(jobType))
+		running := pipe.ZCard(ctx, s.runningKey(jobType))
+		dead := pipe.ZCard(ctx, s.deadKey(jobType))
+		oldest := pipe.ZRangeByScoreWithScores(ctx, s.pendingKey(jobType), &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(now.UnixMilli(), 10),
+			Count: 1,
+		})
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("error reading %s job stats: %w", jobType, err)
+		}
+
+		stat := Stat{Type: jobType, Status: StatusPending, Count: pending.Val()}
+		if entries := oldest.Val(); len(entries) > 0 {
+			stat.Oldest = time.UnixMilli(int64(entries[0].Score))
+		}
+		stats = append(stats, stat,
+			Stat{Type: jobType, Status: StatusRunning, Count: running.Val()},
+			Stat{Type: jobType, Status: StatusDead, Count: dead.Val()},
+		)
+	}
+	return stats, nil
+}
+
+// truncateError keeps stored failure reasons short.
+func truncateError(reason string) string {
+	if len(reason) > 500 {
+		return reason[:500]
+	}
+	return reason
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"30-10 * * * *",
+		"a * * * *",
+		"@yearly",
+		"@every soon",
+		"@every 500ms",
+	}
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParseSchedule(spec); err == nil {
+				t.Errorf("ParseSchedule(%q) error = nil", spec)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse("2006-01-02 15:04:05", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want string // Empty when the schedule never runs
+	}{
+		{name: "every minute drops seconds", spec: "* * * * *", from: "2024-03-10 10:07:30", want: "2024-03-10 10:08:00"},
+		{name: "strictly after", spec: "0 * * * *", from: "2024-03-10 10:00:00", want: "2024-03-10 11:00:00"},
+		{name: "step", spec: "*/15 * * * *", from: "2024-03-10 10:07:00", want: "2024-03-10 10:15:00"},
+		{name: "step into the next hour", spec: "*/15 * * * *", from: "2024-03-10 10:45:00", want: "2024-03-10 11:00:00"},
+		{name: "step from a value", spec: "5/20 * * * *", from: "2024-03-10 10:26:00", want: "2024-03-10 10:45:00"},
+		{name: "list", spec: "0,30 * * * *", from: "2024-03-10 10:07:00", want: "2024-03-10 10:30:00"},
+		{name: "range with step", spec: "0 9-17/4 * * *", from: "2024-03-10 13:00:00", want: "2024-03-10 17:00:00"},
+		{name: "range with step into the next day", spec: "0 9-17/4 * * *", from: "2024-03-10 17:30:00", want: "2024-03-11 09:00:00"},
+		{name: "sunday as 0", spec: "30 2 * * 0", from: "2024-03-10 03:00:00", want: "2024-03-17 02:30:00"},
+		{name: "sunday as 7", spec: "30 2 * * 7", from: "2024-03-10 03:00:00", want: "2024-03-17 02:30:00"},
+		{name: "weekday range", spec: "0 8 * * 1-5", from: "2024-03-08 09:00:00", want: "2024-03-11 08:00:00"},
+		{name: "day of month only", spec: "0 0 10 * *", from: "2024-09-01 12:00:00", want: "2024-09-10 00:00:00"},
+		{name: "day of week only", spec: "0 0 * * 5", from: "2024-09-01 12:00:00", want: "2024-09-06 00:00:00"},
+		{name: "both days, the weekday first", spec: "0 0 10 * 5", from: "2024-09-01 12:00:00", want: "2024-09-06 00:00:00"},
+		{name: "both days, the day of month first", spec: "0 0 10 * 5", from: "2024-09-06 00:00:00", want: "2024-09-10 00:00:00"},
+		{name: "month", spec: "0 0 1 6 *", from: "2024-09-01 12:00:00", want: "2025-06-01 00:00:00"},
+		{name: "february 29th", spec: "0 0 29 2 *", from: "2024-03-01 00:00:00", want: "2028-02-29 00:00:00"},
+		{name: "february 29th in a leap year", spec: "0 12 29 2 *", from: "2024-02-01 00:00:00", want: "2024-02-29 12:00:00"},
+		{name: "february 30th never runs", spec: "0 0 30 2 *", from: "2024-01-01 00:00:00"},
+		{name: "hourly", spec: "@hourly", from: "2024-03-10 10:07:00", want: "2024-03-10 11:00:00"},
+		{name: "weekly", spec: "@weekly", from: "2024-03-10 10:07:00", want: "2024-03-17 00:00:00"},
+		{name: "monthly", spec: "@monthly", from: "2024-12-15 10:07:00", want: "2025-01-01 00:00:00"},
+		{name: "every interval since the epoch", spec: "@every 90m", from: "2024-03-10 10:07:00", want: "2024-03-10 10:30:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) error = %v", tt.spec, err)
+			}
+			var want time.Time
+			if tt.want != "" {
+				want = at(tt.want)
+			}
+			if got := schedule.Next(at(tt.from)); !got.Equal(want) {
+				t.Errorf("Next(%s) = %v, want %v", tt.from, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps jobs for workers to claim. Several workers, in several processes, may use
+// one store at once.
+type Store interface {
+	// Enqueue stores a pending job, or returns ErrDuplicateJob when the job is unique and
+	// a job with its key is pending or running.
+	Enqueue(ctx context.Context, job Job) error
+
+	// Claim leases up to limit jobs of the given types that are due, counting an attempt
+	// for each. Running jobs whose lease ran out, e.g. because their worker stopped, are
+	// claimed again, or buried with ErrLeaseExpired when that was their last attempt.
+	Claim(ctx context.Context, types []string, limit int, lease time.Duration, now time.Time) ([]Job, error)
+
+	// Complete removes a job that succeeded.
+	Complete(ctx context.Context, job Job) error
+
+	// Retry makes a failed job pending again, due at runAt.
+	Retry(ctx context.Context, job Job, runAt time.Time, reason string) error
+
+	// Bury moves a job that failed for good to the dead jobs.
+	Bury(ctx context.Context, job Job, reason string) error
+
+	// ClaimSlot records that the run of a schedule due at slot was enqueued, and reports
+	// false when another worker already did.
+	ClaimSlot(ctx context.Context, schedule string, slot time.Time) (bool, error)
+
+	// Stats counts the jobs of the given types by status, with the oldest due pending job.
+	Stats(ctx context.Context, types []string, now time.Time) ([]Stat, error)
+}
+
+// Stat describes the jobs of a type with a status.
+type Stat struct {
+	Type   string
+	Status string
+	Count  int64
+	Oldest time.Time // RunAt of the oldest due job, for pending jobs; zero when none is due
+}
@@ -0,0 +1,334 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HandlerFunc runs a job. Returning an error retries the job with backoff, unless the
+// error is wrapped with Permanent or the job is on its last attempt, which buries it.
+type HandlerFunc func(ctx context.Context, job Job) error
+
+// Config tunes a worker.
+type Config struct {
+	Concurrency   int           // Jobs run at once
+	PollInterval  time.Duration // How often to look for due jobs
+	Lease         time.Duration // How long a job may run before another worker may claim it
+	StatsInterval time.Duration // How often to refresh the queue depth metrics
+	Backoff       Backoff
+}
+
+// DefaultConfig runs 4 jobs at once, polling every second.
+var DefaultConfig = Config{
+	Concurrency:   4,
+	PollInterval:  time.Second,
+	Lease:         5 * time.Minute,
+	StatsInterval: 15 * time.Second,
+	Backoff:       DefaultBackoff,
+}
+
+// Worker claims jobs from a store and runs them with the handler registered for their
+// type. It also enqueues the runs of recurring schedules.
+type Worker struct {
+	store     Store
+	client    *Client
+	config    Config
+	handlers  map[string]HandlerFunc
+	schedules []*schedule
+}
+
+// schedule is a recurring job registered with Schedule.
+type schedule struct {
+	name     string
+	schedule Schedule
+	jobType  string
+	payload  interface{}
+	next     time.Time
+}
+
+// NewWorker creates a new instance of Worker. Zero fields of config take their value
+// from DefaultConfig.
+func NewWorker(store Store, config Config) *Worker {
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultConfig.Concurrency
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultConfig.PollInterval
+	}
+	if config.Lease <= 0 {
+		config.Lease = DefaultConfig.Lease
+	}
+	if config.StatsInterval <= 0 {
+		config.StatsInterval = DefaultConfig.StatsInterval
+	}
+	if config.Backoff.Base <= 0 {
+		config.Backoff = DefaultConfig.Backoff
+	}
+	return &Worker{
+		store:    store,
+		client:   NewClient(store),
+		config:   config,
+		handlers: make(map[string]HandlerFunc),
+	}
+}
+
+// Handle registers the handler of a job type. It panics when the type already has one.
+func (w *Worker) Handle(jobType string, handler HandlerFunc) {
+	if _, ok := w.handlers[jobType]; ok {
+		panic(fmt.Sprintf("jobs: handler for %q registered twice", jobType))
+	}
+	w.handlers[jobType] = handler
+}
+
+// Register registers a handler that receives the payload of the job decoded as T. A
+// payload that cannot be decoded buries the job.
+func Register[T any](w *Worker, jobType string, handler func(ctx context.Context, job Job, payload T) error) {
+	w.Handle(jobType, func(ctx context.Context, job Job) error {
+		var payload T
+		if len(job.Payload) > 0 {
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return Permanent(fmt.Errorf("error decoding %s job: %w", jobType, err))
+			}
+		}
+		return handler(ctx, job, payload)
+	})
+}
+
+// Schedule enqueues a job of the given type with payload on every run of spec (see
+// ParseSchedule). When several workers share a store, each run is enqueued once.
+func (w *Worker) Schedule(name string, spec string, jobType string, payload interface{}) error {
+	if _, ok := w.handlers[jobType]; !ok {
+		return fmt.Errorf("%w %q", ErrNoHandler, jobType)
+	}
+	parsed, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	w.schedules = append(w.schedules, &schedule{
+		name:     name,
+		schedule: parsed,
+		jobType:  jobType,
+		payload:  payload,
+	})
+	return nil
+}
+
+// Types returns the job types the worker has handlers for.
+func (w *Worker) Types() []string {
+	types := make([]string, 0, len(w.handlers))
+	for jobType := range w.handlers {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Run claims and runs jobs until the context is cancelled, then waits for the jobs
+// already running to finish.
+func (w *Worker) Run(ctx context.Context) {
+	types := w.Types()
+	now := time.Now()
+	for _, s := range w.schedules {
+		s.next = s.schedule.Next(now)
+	}
+
+	poll := time.NewTicker(w.config.PollInterval)
+	defer poll.Stop()
+	stats := time.NewTicker(w.config.StatsInterval)
+	defer stats.Stop()
+
+	log.Info().
+		Strs("types", types).
+		Int("concurrency", w.config.Concurrency).
+		Int("schedules", len(w.schedules)).
+		Msg("Job worker started")
+
+	slots := make(chan struct{}, w.config.Concurrency)
+	var running sync.WaitGroup
+	w.refreshStats(ctx, types)
+	for {
+		select {
+		case <-ctx.Done():
+			running.Wait()
+			log.Info().Msg("Job worker stopped")
+			return
+		case <-stats.C:
+			w.refreshStats(ctx, types)
+		case <-poll.C:
+			w.enqueueSchedules(ctx, time.Now())
+			w.claim(ctx, types, slots, &running)
+		}
+	}
+}
+
+// enqueueSchedules enqueues the runs of the schedules that came due.
+func (w *Worker) enqueueSchedules(ctx context.Context, now time.Time) {
+	for _, s := range w.schedules {
+		if now.Before(s.next) {
+			continue
+		}
+		slot := s.next
+		s.next = s.schedule.Next(now)
+
+		claimed, err := w.store.ClaimSlot(ctx, s.name, slot)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("schedule", s.name).
+				Msg("Error claiming scheduled job run")
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		// A run still queued from an earlier slot makes this one redundant
+		_, err = w.client.Enqueue(ctx, s.jobType, s.payload, Unique("schedule:"+s.name))
+		if err != nil && !errors.Is(err, ErrDuplicateJob) {
+			log.Error().
+				Err(err).
+				Str("schedule", s.name).
+				Msg("Error enqueuing scheduled job")
+		}
+	}
+}
+
+// claim leases as many due jobs as there are free slots and starts them, repeating
+// while full batches come back.
+func (w *Worker) claim(ctx context.Context, types []string, slots chan struct{}, running *sync.WaitGroup) {
+	for ctx.Err() == nil {
+		free := cap(slots) - len(slots)
+		if free == 0 {
+			return
+		}
+		jobs, err := w.store.Claim(ctx, types, free, w.config.Lease, time.Now())
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().
+					Err(err).
+					Msg("Error claiming jobs")
+			}
+			return
+		}
+		for _, job := range jobs {
+			slots <- struct{}{}
+			running.Add(1)
+			go func(job Job) {
+				defer func() {
+					<-slots
+					running.Done()
+				}()
+				w.process(job)
+			}(job)
+		}
+		if len(jobs) < free {
+			return
+		}
+	}
+}
+
+// process runs a claimed job and records the outcome. Jobs are not cancelled when the
+// worker stops, only when they outlive their lease.
+func (w *Worker) process(job Job) {
+	started := time.Now()
+	if started.After(job.RunAt) {
+		jobsWait.WithLabelValues(job.Type).Observe(started.Sub(job.RunAt).Seconds())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.Lease)
+	defer cancel()
+	err := w.call(ctx, job)
+	jobsDuration.WithLabelValues(job.Type).Observe(time.Since(started).Seconds())
+
+	logger := log.With().
+		Str("job_id", job.ID.String()).
+		Str("job_type", job.Type).
+		Int("attempt", job.Attempts).
+		Logger()
+
+	storeCtx, storeCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer storeCancel()
+	switch {
+	case err == nil:
+		jobsProcessed.WithLabelValues(job.Type, "succeeded").Inc()
+		if err := w.store.Complete(storeCtx, job); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Error completing job")
+		}
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		jobsProcessed.WithLabelValues(job.Type, "dead").Inc()
+		logger.Error().
+			Err(err).
+			Msg("Job failed, moving it to the dead jobs")
+		if err := w.store.Bury(storeCtx, job, err.Error()); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Error burying job")
+		}
+	default:
+		jobsProcessed.WithLabelValues(job.Type, "retried").Inc()
+		runAt := time.Now().Add(w.config.Backoff.Delay(job.Attempts))
+		logger.Warn().
+			Err(err).
+			Time("retry_at", runAt).
+			Msg("Job failed, retrying")
+		if err := w.store.Retry(storeCtx, job, runAt, err.Error()); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Error rescheduling job")
+		}
+	}
+}
+
+// call runs the handler of a job, turning a panic into a permanent failure.
+func (w *Worker) call(ctx context.Context, job Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("%w %q", ErrNoHandler, job.Type))
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Error().
+				Str("job_id", job.ID.String()).
+				Str("job_type", job.Type).
+				Bytes("stack", debug.Stack()).
+				Msg("Job panicked")
+			err = Permanent(fmt.Errorf("job panicked: %v", recovered))
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// refreshStats updates the queue depth metrics.
+func (w *Worker) refreshStats(ctx context.Context, types []string) {
+	now := time.Now()
+	stats, err := w.store.Stats(ctx, types, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().
+				Err(err).
+				Msg("Error reading job queue stats")
+		}
+		return
+	}
+	for _, jobType := range types {
+		for _, status := range []string{StatusPending, StatusRunning, StatusDead} {
+			jobsQueueDepth.WithLabelValues(jobType, status).Set(0)
+		}
+		jobsOldestPending.WithLabelValues(jobType).Set(0)
+	}
+	for _, stat := range stats {
+		jobsQueueDepth.WithLabelValues(stat.Type, stat.Status).Set(float64(stat.Count))
+		if stat.Status == StatusPending && !stat.Oldest.IsZero() && now.After(stat.Oldest) {
+			jobsOldestPending.WithLabelValues(stat.Type).Set(now.Sub(stat.Oldest).Seconds())
+		}
+	}
+}
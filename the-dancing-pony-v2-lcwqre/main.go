@@ -1,86 +1,140 @@
 package main
 
 import (
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-
-	"github.com/joho/godotenv"
-
-	"github.com/go-playground/validator/v10"
-	"github.com/rs/zerolog/log"
+	"text/tabwriter"
 
 	config "the-dancing-pony-v2-lcwqre/Config"
-	utils "the-dancing-pony-v2-lcwqre/Utils"
-	cache "the-dancing-pony-v2-lcwqre/caching"
-	"the-dancing-pony-v2-lcwqre/controller"
-	"the-dancing-pony-v2-lcwqre/repository"
-	"the-dancing-pony-v2-lcwqre/router"
-)
 
-func main() {
-	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		log.Error().Err(err).Msg("Error loading .env file")
-	}
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
 
-	// Retrieve environment variables
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port
-	}
+// command is a subcommand of the binary. Commands return the exit code of the process:
+// 0 on success, 1 when the command failed and 2 on invalid arguments.
+type command struct {
+	name    string
+	summary string
+	run     func(settings config.Settings, args []string) int
+}
 
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
+var commands = []command{
+	{"serve", "serve the API (default)", runServe},
+	{"worker", "run background jobs without serving the API", runWorker},
+	{"migrate", "apply or roll back database migrations", runMigrate},
+	{"seed", "create a demo restaurant with dishes and users", runSeed},
+	{"create-admin", "create a user with the admin permission", runCreateAdmin},
+	{"rotate-keys", "replace webhook secrets or generate new signing keys", runRotateKeys},
+	{"reindex-search", "rebuild the search indexes and drop cached search results", runReindexSearch},
+	{"purge-deleted", "permanently delete rows soft-deleted long ago", runPurgeDeleted},
+	{"export-tenant", "write every row of a restaurant as JSON", runExportTenant},
+	{"replay-outbox", "list or replay outbox events consumers gave up on", runReplayOutbox},
+}
 
-	redisAddr := os.Getenv("REDIS_ADDR")
-	redisPassword := os.Getenv("REDIS_PASSWORD")
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	log.Info().Msgf("Starting server on port %s", port)
+// run loads the settings from the flags before the command and the other sources, and
+// dispatches the remaining arguments to their command, serving the API when there are none.
+func run(args []string) int {
+	settings, args, err := config.LoadSettings(args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		printUsage(os.Stdout)
+		return 0
+	case errors.Is(err, config.ErrInvalidFlags):
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		printUsage(os.Stderr)
+		return 2
+	}
 
-	// Initialize database
-	db, err := config.SetupDatabase(dbUser, dbPassword, dbName)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to set up database")
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
-	log.Info().Msgf("Database succesfully set up")
-	// Initialize Redis client
-	if err := cache.InitRedisClient(redisAddr, redisPassword); err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize Redis client")
+	if name == "help" || name == "-h" || name == "--help" {
+		if len(args) > 0 && args[0] == "settings" {
+			config.PrintSettings(os.Stdout)
+			return 0
+		}
+		printUsage(os.Stdout)
+		return 0
 	}
-	log.Info().Msgf("Redis client initialized")
-
-	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
-	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	region := os.Getenv("AWS_REGION")
-	// Initialize the S3 uploader
-	uploader, err := utils.NewS3Uploader(accessKey, secretKey, region)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize S3 uploader:")
 
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		level, _ := zerolog.ParseLevel(settings.LogLevel)
+		zerolog.SetGlobalLevel(level)
+		return cmd.run(settings, args)
 	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
 
-	// Create validator instance
-	validate := validator.New()
-
-	// Initialize services
-	dishService, authService, resturantService := config.InitializeServices(db, validate, uploader)
-
-	// Initialize controllers
-	dishController := controller.NewDishesController(dishService)
-	authController := controller.NewAuthController(authService)
-	restaurantController := controller.NewRestaurantsController(resturantService)
+// printUsage lists the commands.
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: thedancingpony [settings flags] [command] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(table, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	table.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run thedancingpony <command> -h for the arguments of a command.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Settings are read from their defaults, the YAML or TOML file given by -config or")
+	fmt.Fprintln(w, "CONFIG_FILE, environment variables and settings flags, each overriding the previous.")
+	fmt.Fprintln(w, "Run thedancingpony help settings for their keys, variables, flags and defaults.")
+}
 
-	// Setup router
-	routes := router.NewRouter(dishController, authController, restaurantController, repository.NewUserRepository(db))
+// newFlagSet returns the flag set of a command, printing usage before its flags on -h
+// or invalid flags.
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
 
-	// Start server
-	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: routes,
+// parseFlags parses the arguments of a command, which takes no positional arguments.
+// It returns false with the exit code when the command should not run.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, false
+		}
+		return 2, false
 	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected argument %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2, false
+	}
+	return 0, true
+}
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatal().Err(err).Msg("Server failed")
+// openDatabase connects to the database of a command, which must be migrated.
+func openDatabase(settings config.Settings) (*gorm.DB, bool) {
+	db, err := config.SetupDatabase(settings.Database, false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return nil, false
 	}
+	return db, true
 }
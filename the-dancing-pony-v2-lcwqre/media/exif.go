@@ -0,0 +1,68 @@
+package media
+
+import (
+	"encoding/binary"
+)
+
+// jpegOrientation returns the EXIF orientation tag of a JPEG, or 1 when there is none.
+// Only the APP1 segment and the first IFD are read; everything else is ignored.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return 1
+		}
+		marker := data[offset+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Start of scan or end of image: no metadata follows
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[offset+2:]))
+		end := offset + 2 + size
+		if size < 2 || end > len(data) {
+			return 1
+		}
+		segment := data[offset+4 : end]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		offset = end
+	}
+	return 1
+}
+
+// tiffOrientation reads the orientation tag (0x0112) from the first IFD of a TIFF header.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,306 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupportedImageType is returned for uploads that are not JPEG, PNG or WebP by content.
+	ErrUnsupportedImageType = errors.New("unsupported image type, expected JPEG, PNG or WebP")
+
+	// ErrImageTooLarge is returned when an upload exceeds the byte limit.
+	ErrImageTooLarge = errors.New("image exceeds the maximum upload size")
+
+	// ErrImageDimensions is returned when an image is too small or exceeds the pixel limits.
+	ErrImageDimensions = errors.New("image dimensions are out of range")
+)
+
+// Rendition names, in the order they are produced.
+const (
+	RenditionThumbnail = "thumbnail"
+	RenditionCard      = "card"
+	RenditionFull      = "full"
+)
+
+// RenditionSpec describes one re-encoded size of an uploaded image.
+type RenditionSpec struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool // Crop to fill the box instead of fitting inside it
+}
+
+// DefaultRenditions are the sizes produced for every dish image.
+var DefaultRenditions = []RenditionSpec{
+	{Name: RenditionThumbnail, Width: 256, Height: 256, Crop: true},
+	{Name: RenditionCard, Width: 800, Height: 600},
+	{Name: RenditionFull, Width: 1920, Height: 1920},
+}
+
+// Rendition is an encoded image ready to be stored.
+type Rendition struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// ProcessedImage is the result of validating and re-encoding an upload.
+type ProcessedImage struct {
+	Hash       string // Hex SHA-256 of the original upload, used for content-addressed keys
+	Ext        string // Extension shared by all renditions
+	Renditions []Rendition
+}
+
+// Key returns the object key of a rendition under the given prefix.
+func (p ProcessedImage) Key(prefix string, rendition string) string {
+	return ImageKey(prefix, p.Hash, rendition, p.Ext)
+}
+
+// ImageKey builds the content-addressed key of a rendition, e.g. dishes/<hash>/card.jpg.
+func ImageKey(prefix string, hash string, rendition string, ext string) string {
+	return path.Join(prefix, hash, rendition+ext)
+}
+
+// RenditionKey derives the key of another rendition from the key of the full rendition.
+// It reports false for keys that were not produced by the image processor.
+func RenditionKey(fullKey string, rendition string) (string, bool) {
+	dir, file := path.Split(fullKey)
+	ext := path.Ext(file)
+	if strings.TrimSuffix(file, ext) != RenditionFull {
+		return "", false
+	}
+	return dir + rendition + ext, true
+}
+
+// ImageProcessor validates uploaded images and re-encodes them into renditions.
+// Re-encoding drops all metadata, including EXIF, after applying the EXIF orientation.
+type ImageProcessor struct {
+	MaxBytes     int64
+	MaxDimension int // Maximum width or height of the original, in pixels
+	MinDimension int // Minimum width and height of the original, in pixels
+	JPEGQuality  int
+	Renditions   []RenditionSpec
+}
+
+// NewImageProcessor creates a new instance of ImageProcessor with the default renditions.
+func NewImageProcessor(maxBytes int64, maxDimension int) *ImageProcessor {
+	return &ImageProcessor{
+		MaxBytes:     maxBytes,
+		MaxDimension: maxDimension,
+		MinDimension: 64,
+		JPEGQuality:  85,
+		Renditions:   DefaultRenditions,
+	}
+}
+
+// Hash reads an upload, enforcing the size limit, and returns its bytes and content hash.
+func (p *ImageProcessor) Hash(r io.Reader) ([]byte, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, p.MaxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > p.MaxBytes {
+		return nil, "", ErrImageTooLarge
+	}
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
+
+// Process validates an upload by content and produces its renditions.
+func (p *ImageProcessor) Process(data []byte, hash string) (ProcessedImage, error) {
+	contentType := http.DetectContentType(data)
+	var decode func(io.Reader) (image.Image, error)
+	var decodeConfig func(io.Reader) (image.Config, error)
+	switch contentType {
+	case "image/jpeg":
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+	case "image/png":
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case "image/webp":
+		decode, decodeConfig = webp.Decode, webp.DecodeConfig
+	default:
+		return ProcessedImage{}, ErrUnsupportedImageType
+	}
+
+	// Check the header before decoding so oversized images are rejected without allocating them
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, fmt.Errorf("%w: %v", ErrUnsupportedImageType, err)
+	}
+	if config.Width > p.MaxDimension || config.Height > p.MaxDimension ||
+		config.Width < p.MinDimension || config.Height < p.MinDimension {
+		return ProcessedImage{}, fmt.Errorf("%w: %dx%d, allowed %d to %d pixels per side",
+			ErrImageDimensions, config.Width, config.Height, p.MinDimension, p.MaxDimension)
+	}
+
+	src, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, fmt.Errorf("%w: %v", ErrUnsupportedImageType, err)
+	}
+	if contentType == "image/jpeg" {
+		src = applyOrientation(src, jpegOrientation(data))
+	}
+
+	// Opaque images become JPEG, images with transparency stay PNG
+	opaque := isOpaque(src)
+	processed := ProcessedImage{Hash: hash, Ext: ".png"}
+	if opaque {
+		processed.Ext = ".jpg"
+	}
+
+	for _, spec := range p.Renditions {
+		resized := resize(src, spec)
+		var buf bytes.Buffer
+		rendition := Rendition{
+			Name:   spec.Name,
+			Ext:    processed.Ext,
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+		}
+		if opaque {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: p.JPEGQuality})
+			rendition.ContentType = "image/jpeg"
+		} else {
+			err = png.Encode(&buf, resized)
+			rendition.ContentType = "image/png"
+		}
+		if err != nil {
+			return ProcessedImage{}, fmt.Errorf("failed to encode %s rendition: %w", spec.Name, err)
+		}
+		rendition.Data = buf.Bytes()
+		processed.Renditions = append(processed.Renditions, rendition)
+	}
+	return processed, nil
+}
+
+// resize scales src to the rendition box without upscaling, cropping the centre when requested.
+func resize(src image.Image, spec RenditionSpec) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	var scale float64
+	if spec.Crop {
+		scale = maxFloat(float64(spec.Width)/float64(srcW), float64(spec.Height)/float64(srcH))
+	} else {
+		scale = minFloat(float64(spec.Width)/float64(srcW), float64(spec.Height)/float64(srcH))
+	}
+	if scale > 1 {
+		scale = 1
+	}
+
+	srcRect := bounds
+	dstW, dstH := int(float64(srcW)*scale+0.5), int(float64(srcH)*scale+0.5)
+	if spec.Crop {
+		// Take the largest centred region with the box's aspect ratio
+		cropW := minInt(srcW, int(float64(spec.Width)/scale+0.5))
+		cropH := minInt(srcH, int(float64(spec.Height)/scale+0.5))
+		x0 := bounds.Min.X + (srcW-cropW)/2
+		y0 := bounds.Min.Y + (srcH-cropH)/2
+		srcRect = image.Rect(x0, y0, x0+cropW, y0+cropH)
+		dstW, dstH = minInt(spec.Width, cropW), minInt(spec.Height, cropH)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, maxInt(dstW, 1), maxInt(dstH, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Src, nil)
+	return dst
+}
+
+// isOpaque reports whether every pixel of the image is fully opaque.
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// applyOrientation rotates and flips an image according to its EXIF orientation (1-8).
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, color.RGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)))
+		}
+	}
+	return dst
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"strings"
 
-	"the-dancing-pony-v2-lcwqre/helper"
 	"the-dancing-pony-v2-lcwqre/repository"
 
 	"github.com/dgrijalva/jwt-go"
@@ -12,10 +11,8 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-var jwtSecret = []byte(helper.GetJWTSecret())
-
-// AuthMiddleware validates JWT tokens and ensures the user is authenticated.
-func AuthMiddleware(userRepo repository.UserRepository) gin.HandlerFunc {
+// AuthMiddleware validates JWT tokens signed with jwtSecret and ensures the user is authenticated.
+func AuthMiddleware(userRepo repository.UserRepository, jwtSecret []byte) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract the restaurantId from the URL
 		restaurantIDStr := c.Param("restaurantId")
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// RequireIfMatch rejects writes without an If-Match header with 428 Precondition Required,
+// so clients cannot overwrite changes they have not seen. When required is false the
+// header stays optional and is still honoured by the handlers.
+func RequireIfMatch(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && c.GetHeader("If-Match") == "" {
+			log.Warn().
+				Str("request_id", c.GetString("request_id")).
+				Str("path", c.FullPath()).
+				Msg("Write rejected without If-Match")
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the resource ETag is required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
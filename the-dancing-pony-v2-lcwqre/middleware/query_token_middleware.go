@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// TokenFromQuery lets clients that cannot set headers, such as browser EventSource and
+// WebSocket clients, pass their JWT in the given query parameter. The token is moved to
+// the Authorization header for AuthMiddleware; a header already present wins. Either
+// way the parameter is removed from the URL, so the token is not logged with it, e.g. in
+// the request dumped on a panic.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if query.Has(param) {
+			if token := query.Get(param); token != "" && c.GetHeader("Authorization") == "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+			query.Del(param)
+			c.Request.URL.RawQuery = query.Encode()
+			c.Request.RequestURI = c.Request.URL.RequestURI()
+		}
+		c.Next()
+	}
+}
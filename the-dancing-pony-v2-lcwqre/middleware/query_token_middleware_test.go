@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTokenFromQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		wantAuth      string
+		wantURI       string
+	}{
+		{name: "token moved to the header", target: "/events?access_token=abc&lastEventId=7", wantAuth: "Bearer abc", wantURI: "/events?lastEventId=7"},
+		{name: "header wins", target: "/events?access_token=abc", authorization: "Bearer xyz", wantAuth: "Bearer xyz", wantURI: "/events"},
+		{name: "empty token removed", target: "/events?access_token=", wantURI: "/events"},
+		{name: "no token", target: "/events?lastEventId=7", wantURI: "/events?lastEventId=7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAuth, gotURI, gotURL, gotLastEventID string
+			router := gin.New()
+			router.GET("/events", TokenFromQuery("access_token"), func(c *gin.Context) {
+				gotAuth = c.GetHeader("Authorization")
+				gotURI, gotURL = c.Request.RequestURI, c.Request.URL.String()
+				gotLastEventID = c.Query("lastEventId")
+			})
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotAuth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", gotAuth, tt.wantAuth)
+			}
+			if gotURI != tt.wantURI || gotURL != tt.wantURI {
+				t.Errorf("RequestURI = %q and URL = %q, want %q", gotURI, gotURL, tt.wantURI)
+			}
+			if want := req.URL.Query().Get("lastEventId"); gotLastEventID != want {
+				t.Errorf("lastEventId = %q, want %q", gotLastEventID, want)
+			}
+		})
+	}
+}
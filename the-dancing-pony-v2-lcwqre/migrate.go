@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/migrations"
+)
+
+const migrateUsage = `usage: thedancingpony migrate [command]
+
+Commands:
+  up              apply every pending migration (default)
+  down [steps]    roll back the last steps migrations (default 1)
+  to <version>    migrate up or down to version; 0 rolls back everything
+  status          list the migrations and whether they are applied
+`
+
+// runMigrate runs the migrate command with its arguments and returns the exit code:
+// 0 on success, 1 when migrating failed and 2 on invalid arguments.
+func runMigrate(settings config.Settings, args []string) int {
+	db, err := config.OpenDatabase(settings.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	migrator, err := migrations.NewMigrator(sqlDB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	ctx := context.Background()
+
+	var count int
+	switch command {
+	case "up":
+		if len(args) != 0 {
+			break
+		}
+		count, err = migrator.Up(ctx)
+		return migrateResult(count, "applied", err)
+	case "down":
+		steps := 1
+		if len(args) == 1 {
+			steps, err = strconv.Atoi(args[0])
+			if err != nil || steps <= 0 {
+				break
+			}
+		} else if len(args) > 1 {
+			break
+		}
+		count, err = migrator.Down(ctx, steps)
+		return migrateResult(count, "rolled back", err)
+	case "to":
+		if len(args) != 1 {
+			break
+		}
+		version, parseErr := strconv.ParseInt(args[0], 10, 64)
+		if parseErr != nil || version < 0 {
+			break
+		}
+		count, err = migrator.To(ctx, version)
+		return migrateResult(count, "applied or rolled back", err)
+	case "status":
+		if len(args) != 0 {
+			break
+		}
+		return printMigrationStatus(ctx, migrator)
+	}
+
+	fmt.Fprint(os.Stderr, migrateUsage)
+	return 2
+}
+
+// migrateResult reports the outcome of a migration command and returns its exit code.
+func migrateResult(count int, done string, err error) int {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	fmt.Printf("%d migrations %s\n", count, done)
+	return 0
+}
+
+// printMigrationStatus prints a table of the migrations and returns the exit code.
+func printMigrationStatus(ctx context.Context, migrator *migrations.Migrator) int {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		switch {
+		case status.Unknown:
+			state += " (unknown to this release)"
+		case status.Modified:
+			state += " (modified since)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	w.Flush()
+	return 0
+}
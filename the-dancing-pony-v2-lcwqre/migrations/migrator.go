@@ -0,0 +1,326 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// lockKey is the Postgres advisory lock held while migrating, so replicas starting at
+// once, or a deploy job racing an operator, apply each migration once.
+const lockKey int64 = 0x64616e63696e67 // "dancing"
+
+var (
+	// ErrSchemaOutdated is returned by Check when migrations are pending.
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+
+	// ErrUnknownVersion is returned when migrating to, or rolling back, a version this
+	// binary has no migration for.
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+// Status describes a migration and whether it is applied.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool // Applied from an up script that has changed since
+	Unknown   bool // Applied by a newer binary, which has a migration this one lacks
+}
+
+// Migrator applies the migrations to a database, recording the applied versions in the
+// schema_migrations table. Each migration runs in its own transaction.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a new instance of Migrator with the embedded migrations.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest migration, or 0 when there are none.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration and returns how many it applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the given number of most recent migrations and returns how many it
+// rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(applied) - 1; i >= 0 && count < steps; i-- {
+			if err := m.rollback(ctx, conn, applied[i].version); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// To migrates up or down until version is the newest applied migration; 0 rolls back
+// every migration. It returns how many migrations it applied or rolled back.
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	if _, ok := m.find(version); !ok && version != 0 {
+		return 0, fmt.Errorf("%w %d", ErrUnknownVersion, version)
+	}
+
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		isApplied := make(map[int64]bool, len(applied))
+		for _, row := range applied {
+			isApplied[row.version] = true
+		}
+
+		for i := len(applied) - 1; i >= 0 && applied[i].version > version; i-- {
+			if err := m.rollback(ctx, conn, applied[i].version); err != nil {
+				return err
+			}
+			count++
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version || isApplied[migration.Version] {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status lists the known migrations, and those applied by a newer binary, in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
+	}
+	defer conn.Close()
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]appliedVersion, len(applied))
+	for _, row := range applied {
+		byVersion[row.version] = row
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := byVersion[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = row.appliedAt
+			status.Modified = row.checksum != migration.Checksum()
+			delete(byVersion, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		if _, ok := byVersion[row.version]; ok {
+			statuses = append(statuses, Status{Version: row.version, Name: row.name, Applied: true, AppliedAt: row.appliedAt, Unknown: true})
+		}
+	}
+	return statuses, nil
+}
+
+// Check returns ErrSchemaOutdated when a known migration is not applied. A schema newer
+// than this binary, as during a rolling deploy, is logged but accepted.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []int64
+	for _, status := range statuses {
+		switch {
+		case !status.Applied:
+			pending = append(pending, status.Version)
+		case status.Unknown:
+			log.Warn().
+				Int64("version", status.Version).
+				Str("name", status.Name).
+				Msg("Database has a migration this binary does not know, it was probably applied by a newer release")
+		case status.Modified:
+			log.Warn().
+				Int64("version", status.Version).
+				Str("name", status.Name).
+				Msg("Migration has changed since it was applied")
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: migrations %v are pending, run the migrate command", ErrSchemaOutdated, pending)
+	}
+	return nil
+}
+
+// locked runs fn on a connection holding the migration lock, waiting for the lock when
+// another process is migrating.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error connecting to the database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("error acquiring the migration lock: %w", err)
+	}
+	defer func() {
+		// The lock belongs to the session, so it must be released on the same connection
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			log.Error().Err(err).Msg("Error releasing the migration lock")
+		}
+	}()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// apply runs the up script of a migration and records it, in one transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	started := time.Now()
+	err := inTransaction(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, now())",
+			migration.Version, migration.Name, migration.Checksum())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error applying migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	log.Info().
+		Int64("version", migration.Version).
+		Str("name", migration.Name).
+		Dur("duration", time.Since(started)).
+		Msg("Migration applied")
+	return nil
+}
+
+// rollback runs the down script of an applied migration and forgets it, in one transaction.
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, version int64) error {
+	migration, ok := m.find(version)
+	if !ok {
+		return fmt.Errorf("%w %d: it was applied by a newer release, roll it back with that release", ErrUnknownVersion, version)
+	}
+	started := time.Now()
+	err := inTransaction(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error rolling back migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	log.Info().
+		Int64("version", migration.Version).
+		Str("name", migration.Name).
+		Dur("duration", time.Since(started)).
+		Msg("Migration rolled back")
+	return nil
+}
+
+// find returns the known migration with the given version.
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// appliedVersion is a row of schema_migrations.
+type appliedVersion struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// ensureTable creates the schema_migrations table on first use.
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name varchar(200) NOT NULL,
+	checksum varchar(64) NOT NULL,
+	applied_at timestamptz NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions lists the applied migrations in version order.
+func appliedVersions(ctx context.Context, conn *sql.Conn) ([]appliedVersion, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []appliedVersion
+	for rows.Next() {
+		var row appliedVersion
+		if err := rows.Scan(&row.version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+		}
+		applied = append(applied, row)
+	}
+	return applied, rows.Err()
+}
+
+// inTransaction runs fn in a transaction on conn, committing when it succeeds.
+func inTransaction(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
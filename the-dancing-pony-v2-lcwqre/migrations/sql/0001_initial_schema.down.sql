@@ -0,0 +1,38 @@
+-- Drops every table of the initial schema. All data is lost.
+
+DROP TABLE IF EXISTS "outbox_offsets";
+DROP TABLE IF EXISTS "outbox_events";
+DROP TABLE IF EXISTS "webhook_attempts";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_subscriptions";
+DROP TABLE IF EXISTS "invoices";
+DROP TABLE IF EXISTS "invoice_sequences";
+DROP TABLE IF EXISTS "tax_settings";
+DROP TABLE IF EXISTS "loyalty_entries";
+DROP TABLE IF EXISTS "loyalty_accounts";
+DROP TABLE IF EXISTS "loyalty_rewards";
+DROP TABLE IF EXISTS "loyalty_programs";
+DROP TABLE IF EXISTS "promotion_redemptions";
+DROP TABLE IF EXISTS "promotions";
+DROP TABLE IF EXISTS "waitlist_entries";
+DROP TABLE IF EXISTS "reservations";
+DROP TABLE IF EXISTS "opening_hours";
+DROP TABLE IF EXISTS "reservation_settings";
+DROP TABLE IF EXISTS "dining_tables";
+DROP TABLE IF EXISTS "dining_areas";
+DROP TABLE IF EXISTS "payment_events";
+DROP TABLE IF EXISTS "refunds";
+DROP TABLE IF EXISTS "payments";
+DROP TABLE IF EXISTS "cart_items";
+DROP TABLE IF EXISTS "carts";
+DROP TABLE IF EXISTS "order_items";
+DROP TABLE IF EXISTS "orders";
+DROP TABLE IF EXISTS "dish_image_uploads";
+DROP TABLE IF EXISTS "dish_images";
+DROP TABLE IF EXISTS "dish_changes";
+DROP TABLE IF EXISTS "ratings";
+DROP TABLE IF EXISTS "dishes";
+DROP TABLE IF EXISTS "user_permissions";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "restaurants";
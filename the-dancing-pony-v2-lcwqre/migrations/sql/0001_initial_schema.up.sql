@@ -0,0 +1,661 @@
+-- Schema as created by AutoMigrate before versioned migrations. Every statement is
+-- guarded with IF NOT EXISTS, so databases AutoMigrate already set up adopt it as is.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS "restaurants" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text NOT NULL,
+    "description" text,
+    "location" text,
+    "image_url" text,
+    "version" bigint NOT NULL DEFAULT 1,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_restaurants_deleted_at" ON "restaurants" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text NOT NULL,
+    "email" text,
+    "password" text NOT NULL,
+    "restaurant_id" uuid,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_users_restaurant_id" ON "users" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "permissions" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_permissions_deleted_at" ON "permissions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "user_permissions" (
+    "user_id" uuid,
+    "permission_id" uuid,
+    PRIMARY KEY ("user_id","permission_id"),
+    CONSTRAINT "fk_user_permissions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_user_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "dishes" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "description" text,
+    "price" decimal,
+    "image" text,
+    "category" varchar(50) NOT NULL DEFAULT '',
+    "tags" jsonb NOT NULL DEFAULT '[]',
+    "status" varchar(20) NOT NULL DEFAULT 'published',
+    "published_at" timestamptz,
+    "created_by_id" uuid,
+    "last_updated_by_id" uuid,
+    "restaurant_id" uuid NOT NULL,
+    "sku" varchar(64),
+    "version" bigint NOT NULL DEFAULT 1,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_dishes_last_updated_by" FOREIGN KEY ("last_updated_by_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_dishes_restaurant" FOREIGN KEY ("restaurant_id") REFERENCES "restaurants"("id"),
+    CONSTRAINT "fk_users_dishes" FOREIGN KEY ("created_by_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_dishes_category" ON "dishes" ("category");
+CREATE INDEX IF NOT EXISTS "idx_dishes_deleted_at" ON "dishes" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_dishes_restaurant_id" ON "dishes" ("restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_dishes_status" ON "dishes" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_dishes_restaurant_sku" ON "dishes" ("restaurant_id","sku");
+
+CREATE TABLE IF NOT EXISTS "ratings" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "dish_id" uuid,
+    "user_id" uuid,
+    "rating" bigint,
+    "comment" varchar(1000) NOT NULL DEFAULT '',
+    "status" varchar(20) NOT NULL DEFAULT 'approved',
+    "moderated_at" timestamptz,
+    "moderated_by_id" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_ratings" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_dishes_ratings" FOREIGN KEY ("dish_id") REFERENCES "dishes"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ratings_deleted_at" ON "ratings" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_ratings_status" ON "ratings" ("status");
+
+CREATE TABLE IF NOT EXISTS "dish_changes" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "dish_id" uuid NOT NULL,
+    "restaurant_id" text NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "name" text,
+    "description" text,
+    "price" decimal,
+    "publish_at" timestamptz,
+    "applied_at" timestamptz,
+    "created_by_id" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_dish_changes_dish" FOREIGN KEY ("dish_id") REFERENCES "dishes"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_dish_changes_deleted_at" ON "dish_changes" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_dish_changes_dish_id" ON "dish_changes" ("dish_id");
+CREATE INDEX IF NOT EXISTS "idx_dish_changes_publish_at" ON "dish_changes" ("publish_at");
+CREATE INDEX IF NOT EXISTS "idx_dish_changes_restaurant_id" ON "dish_changes" ("restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_dish_changes_status" ON "dish_changes" ("status");
+
+CREATE TABLE IF NOT EXISTS "dish_images" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "dish_id" uuid NOT NULL,
+    "restaurant_id" text NOT NULL,
+    "key" text NOT NULL,
+    "alt_text" varchar(300),
+    "position" bigint NOT NULL DEFAULT 0,
+    "is_primary" boolean NOT NULL DEFAULT false,
+    "created_by_id" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_dishes_images" FOREIGN KEY ("dish_id") REFERENCES "dishes"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_dish_images_deleted_at" ON "dish_images" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_dish_images_dish_id" ON "dish_images" ("dish_id");
+CREATE INDEX IF NOT EXISTS "idx_dish_images_restaurant_id" ON "dish_images" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "dish_image_uploads" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "dish_id" text NOT NULL,
+    "restaurant_id" text NOT NULL,
+    "key" text NOT NULL,
+    "content_type" varchar(50) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "confirmed_at" timestamptz,
+    "created_by_id" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_dish_image_uploads_deleted_at" ON "dish_image_uploads" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_dish_image_uploads_dish_id" ON "dish_image_uploads" ("dish_id");
+CREATE INDEX IF NOT EXISTS "idx_dish_image_uploads_expires_at" ON "dish_image_uploads" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_dish_image_uploads_restaurant_id" ON "dish_image_uploads" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "customer_id" text NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "fulfilment" varchar(20) NOT NULL,
+    "notes" varchar(500),
+    "discount" decimal NOT NULL DEFAULT 0,
+    "total" decimal NOT NULL,
+    "prices_include_tax" boolean NOT NULL DEFAULT false,
+    "tax" decimal NOT NULL DEFAULT 0,
+    "tax_lines" jsonb NOT NULL DEFAULT '[]',
+    "coupon_code" varchar(40),
+    "reward_id" text,
+    "reward_name" varchar(100),
+    "reward_points" bigint NOT NULL DEFAULT 0,
+    "reward_discount" decimal NOT NULL DEFAULT 0,
+    "reason" varchar(300),
+    "status_changed_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_orders_customer_id" ON "orders" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_deleted_at" ON "orders" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_orders_restaurant_id" ON "orders" ("restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_status" ON "orders" ("status");
+
+CREATE TABLE IF NOT EXISTS "order_items" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "order_id" uuid NOT NULL,
+    "dish_id" text NOT NULL,
+    "name" text NOT NULL,
+    "unit_price" decimal NOT NULL,
+    "quantity" bigint NOT NULL,
+    "notes" varchar(200),
+    "tax_name" varchar(50) NOT NULL DEFAULT '',
+    "tax_rate" decimal NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_items" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_order_items_deleted_at" ON "order_items" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_order_items_dish_id" ON "order_items" ("dish_id");
+CREATE INDEX IF NOT EXISTS "idx_order_items_order_id" ON "order_items" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "carts" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "customer_id" text,
+    "guest_token" varchar(64),
+    "coupon_code" varchar(40),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_carts_deleted_at" ON "carts" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_carts_guest_token" ON "carts" ("guest_token");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_carts_restaurant_customer" ON "carts" ("restaurant_id","customer_id");
+
+CREATE TABLE IF NOT EXISTS "cart_items" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "cart_id" uuid NOT NULL,
+    "dish_id" text NOT NULL,
+    "name" text NOT NULL,
+    "unit_price" decimal NOT NULL,
+    "quantity" bigint NOT NULL,
+    "notes" varchar(200),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_carts_items" FOREIGN KEY ("cart_id") REFERENCES "carts"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_cart_items_deleted_at" ON "cart_items" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_cart_items_cart_dish" ON "cart_items" ("cart_id","dish_id");
+
+CREATE TABLE IF NOT EXISTS "payments" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "order_id" text NOT NULL,
+    "customer_id" text NOT NULL,
+    "provider" varchar(30) NOT NULL,
+    "provider_intent_id" varchar(100),
+    "status" varchar(20) NOT NULL,
+    "amount" bigint NOT NULL,
+    "amount_refunded" bigint NOT NULL DEFAULT 0,
+    "currency" varchar(3) NOT NULL,
+    "capture_method" varchar(20) NOT NULL,
+    "next_action_url" varchar(500),
+    "failure_code" varchar(100),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_payments_customer_id" ON "payments" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_payments_deleted_at" ON "payments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_payments_order_id" ON "payments" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_payments_provider_intent_id" ON "payments" ("provider_intent_id");
+CREATE INDEX IF NOT EXISTS "idx_payments_restaurant_id" ON "payments" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "refunds" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "payment_id" uuid NOT NULL,
+    "provider_refund_id" varchar(100),
+    "status" varchar(20) NOT NULL,
+    "amount" bigint NOT NULL,
+    "reason" varchar(300),
+    "idempotency_key" varchar(100),
+    "requested_by_id" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_payments_refunds" FOREIGN KEY ("payment_id") REFERENCES "payments"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_refunds_deleted_at" ON "refunds" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_refunds_provider_refund_id" ON "refunds" ("provider_refund_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refunds_payment_key" ON "refunds" ("payment_id","idempotency_key");
+
+CREATE TABLE IF NOT EXISTS "payment_events" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "provider" varchar(30) NOT NULL,
+    "event_id" varchar(100) NOT NULL,
+    "type" varchar(50) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_payment_events_deleted_at" ON "payment_events" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_payment_events_provider_event" ON "payment_events" ("provider","event_id");
+
+CREATE TABLE IF NOT EXISTS "dining_areas" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "name" varchar(100) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_dining_areas_deleted_at" ON "dining_areas" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_dining_areas_restaurant_name" ON "dining_areas" ("restaurant_id","name") WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS "dining_tables" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "area_id" text,
+    "name" varchar(50) NOT NULL,
+    "min_capacity" bigint NOT NULL DEFAULT 1,
+    "max_capacity" bigint NOT NULL,
+    "active" boolean NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_dining_tables_area_id" ON "dining_tables" ("area_id");
+CREATE INDEX IF NOT EXISTS "idx_dining_tables_deleted_at" ON "dining_tables" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_dining_tables_restaurant_name" ON "dining_tables" ("restaurant_id","name") WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS "reservation_settings" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "timezone" varchar(64) NOT NULL,
+    "slot_minutes" bigint NOT NULL,
+    "duration_minutes" bigint NOT NULL,
+    "max_party_size" bigint NOT NULL,
+    "max_advance_days" bigint NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_reservation_settings_deleted_at" ON "reservation_settings" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reservation_settings_restaurant_id" ON "reservation_settings" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "opening_hours" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "weekday" bigint NOT NULL,
+    "opens" varchar(5) NOT NULL,
+    "closes" varchar(5) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_opening_hours_deleted_at" ON "opening_hours" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_opening_hours_restaurant_id" ON "opening_hours" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "reservations" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "customer_id" text NOT NULL,
+    "table_id" text NOT NULL,
+    "party_size" bigint NOT NULL,
+    "starts_at" timestamptz NOT NULL,
+    "ends_at" timestamptz NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "notes" varchar(500),
+    "reason" varchar(300),
+    "status_changed_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_reservations_customer_id" ON "reservations" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_reservations_deleted_at" ON "reservations" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_reservations_restaurant_id" ON "reservations" ("restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_reservations_starts_at" ON "reservations" ("starts_at");
+CREATE INDEX IF NOT EXISTS "idx_reservations_status" ON "reservations" ("status");
+CREATE INDEX IF NOT EXISTS "idx_reservations_table_time" ON "reservations" ("table_id","starts_at");
+
+CREATE TABLE IF NOT EXISTS "waitlist_entries" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "customer_id" text NOT NULL,
+    "party_size" bigint NOT NULL,
+    "desired_at" timestamptz NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "notes" varchar(500),
+    "reservation_id" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_waitlist_entries_customer_id" ON "waitlist_entries" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_waitlist_entries_deleted_at" ON "waitlist_entries" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_waitlist_entries_desired_at" ON "waitlist_entries" ("desired_at");
+CREATE INDEX IF NOT EXISTS "idx_waitlist_entries_restaurant_id" ON "waitlist_entries" ("restaurant_id");
+CREATE INDEX IF NOT EXISTS "idx_waitlist_entries_status" ON "waitlist_entries" ("status");
+
+CREATE TABLE IF NOT EXISTS "promotions" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "code" varchar(40),
+    "active" boolean NOT NULL,
+    "priority" bigint NOT NULL,
+    "stackable" boolean NOT NULL,
+    "starts_at" timestamptz,
+    "ends_at" timestamptz,
+    "timezone" varchar(64) NOT NULL,
+    "weekdays" jsonb NOT NULL,
+    "daily_from" varchar(5) NOT NULL,
+    "daily_until" varchar(5) NOT NULL,
+    "categories" jsonb NOT NULL,
+    "tags" jsonb NOT NULL,
+    "min_spend" decimal NOT NULL,
+    "first_order_only" boolean NOT NULL,
+    "per_customer_limit" bigint NOT NULL,
+    "usage_limit" bigint NOT NULL,
+    "discount_type" varchar(20) NOT NULL,
+    "value" decimal NOT NULL,
+    "buy_quantity" bigint NOT NULL,
+    "get_quantity" bigint NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_promotions_deleted_at" ON "promotions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_promotions_restaurant_id" ON "promotions" ("restaurant_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_promotions_restaurant_code" ON "promotions" ("restaurant_id","code") WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS "promotion_redemptions" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "promotion_id" text NOT NULL,
+    "restaurant_id" text NOT NULL,
+    "customer_id" text NOT NULL,
+    "order_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "code" varchar(40),
+    "amount" decimal NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_redemptions" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_promotion_redemptions_customer_id" ON "promotion_redemptions" ("customer_id");
+CREATE INDEX IF NOT EXISTS "idx_promotion_redemptions_deleted_at" ON "promotion_redemptions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_promotion_redemptions_order_id" ON "promotion_redemptions" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_promotion_redemptions_promotion_id" ON "promotion_redemptions" ("promotion_id");
+
+CREATE TABLE IF NOT EXISTS "loyalty_programs" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "enabled" boolean NOT NULL,
+    "points_per_unit" decimal NOT NULL,
+    "review_points" bigint NOT NULL,
+    "expiry_days" bigint NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loyalty_programs_deleted_at" ON "loyalty_programs" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_loyalty_programs_restaurant_id" ON "loyalty_programs" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "loyalty_rewards" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "description" varchar(500),
+    "points_cost" bigint NOT NULL,
+    "amount" decimal NOT NULL,
+    "active" boolean NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loyalty_rewards_deleted_at" ON "loyalty_rewards" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_loyalty_rewards_restaurant_id" ON "loyalty_rewards" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "loyalty_accounts" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "customer_id" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loyalty_accounts_deleted_at" ON "loyalty_accounts" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_loyalty_accounts_customer" ON "loyalty_accounts" ("restaurant_id","customer_id");
+
+CREATE TABLE IF NOT EXISTS "loyalty_entries" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "customer_id" text NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "points" bigint NOT NULL,
+    "order_id" text,
+    "rating_id" text,
+    "reward_id" text,
+    "source_entry_id" text,
+    "expires_at" timestamptz,
+    "note" varchar(300),
+    "created_by_id" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_loyalty_entries_customer" ON "loyalty_entries" ("restaurant_id","customer_id");
+CREATE INDEX IF NOT EXISTS "idx_loyalty_entries_deleted_at" ON "loyalty_entries" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_loyalty_entries_order" ON "loyalty_entries" ("kind","order_id") WHERE order_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_loyalty_entries_rating" ON "loyalty_entries" ("kind","rating_id") WHERE rating_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_loyalty_entries_source_entry_id" ON "loyalty_entries" ("source_entry_id");
+
+CREATE TABLE IF NOT EXISTS "tax_settings" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "prices_include_tax" boolean NOT NULL,
+    "rates" jsonb NOT NULL,
+    "legal_name" varchar(200) NOT NULL,
+    "address" varchar(500) NOT NULL,
+    "tax_number" varchar(50) NOT NULL,
+    "invoice_prefix" varchar(20) NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tax_settings_deleted_at" ON "tax_settings" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tax_settings_restaurant_id" ON "tax_settings" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "invoice_sequences" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "last" bigint NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invoice_sequences_deleted_at" ON "invoice_sequences" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoice_sequences_restaurant_id" ON "invoice_sequences" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "invoices" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "sequence" bigint NOT NULL,
+    "number" varchar(40) NOT NULL,
+    "order_id" uuid NOT NULL,
+    "issued_at" timestamptz NOT NULL,
+    "seller_name" varchar(200) NOT NULL,
+    "seller_address" varchar(500) NOT NULL,
+    "tax_number" varchar(50) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_invoice" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invoices_deleted_at" ON "invoices" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_order_id" ON "invoices" ("order_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_restaurant_sequence" ON "invoices" ("restaurant_id","sequence");
+
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "url" varchar(2000) NOT NULL,
+    "description" varchar(200),
+    "event_types" jsonb NOT NULL DEFAULT '[]',
+    "secret" varchar(100) NOT NULL,
+    "active" boolean NOT NULL,
+    "consecutive_failures" bigint NOT NULL DEFAULT 0,
+    "disabled_at" timestamptz,
+    "disabled_reason" varchar(300),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_subscriptions_deleted_at" ON "webhook_subscriptions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_subscriptions_restaurant_id" ON "webhook_subscriptions" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "restaurant_id" text NOT NULL,
+    "subscription_id" uuid NOT NULL,
+    "event_id" varchar(64) NOT NULL,
+    "event_type" varchar(50) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "locked_until" timestamptz,
+    "last_attempt_at" timestamptz,
+    "response_status" bigint NOT NULL DEFAULT 0,
+    "error" varchar(500),
+    "delivered_at" timestamptz,
+    "replay_of_id" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_subscription" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_deleted_at" ON "webhook_deliveries" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_due" ON "webhook_deliveries" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_event_id" ON "webhook_deliveries" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_subscription_id" ON "webhook_deliveries" ("subscription_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_attempts" (
+    "id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "delivery_id" uuid NOT NULL,
+    "number" bigint NOT NULL,
+    "response_status" bigint NOT NULL,
+    "response_body" varchar(1000),
+    "error" varchar(500),
+    "duration_ms" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_log" FOREIGN KEY ("delivery_id") REFERENCES "webhook_deliveries"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_attempts_deleted_at" ON "webhook_attempts" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_attempts_delivery_id" ON "webhook_attempts" ("delivery_id");
+
+CREATE TABLE IF NOT EXISTS "outbox_events" (
+    "sequence" bigserial,
+    "transaction_id" bigint NOT NULL DEFAULT (pg_current_xact_id()::text::bigint),
+    "id" uuid NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "aggregate_type" varchar(50) NOT NULL,
+    "aggregate_id" uuid NOT NULL,
+    "restaurant_id" varchar(36) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "occurred_at" timestamptz NOT NULL,
+    PRIMARY KEY ("sequence")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_occurred_at" ON "outbox_events" ("occurred_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_position" ON "outbox_events" ("transaction_id","sequence");
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_type" ON "outbox_events" ("type");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_outbox_events_id" ON "outbox_events" ("id");
+
+CREATE TABLE IF NOT EXISTS "outbox_offsets" (
+    "consumer" varchar(100),
+    "transaction_id" bigint NOT NULL DEFAULT 0,
+    "sequence" bigint NOT NULL DEFAULT 0,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "last_error" varchar(500),
+    "updated_at" timestamptz,
+    PRIMARY KEY ("consumer")
+);
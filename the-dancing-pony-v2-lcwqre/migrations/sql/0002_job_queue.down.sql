@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "idx_dish_image_uploads_status";
+ALTER TABLE "dish_image_uploads"
+    DROP COLUMN IF EXISTS "status",
+    DROP COLUMN IF EXISTS "alt_text",
+    DROP COLUMN IF EXISTS "image_id",
+    DROP COLUMN IF EXISTS "error";
+
+DROP TABLE IF EXISTS "job_schedules";
+DROP TABLE IF EXISTS "jobs";
@@ -0,0 +1,36 @@
+-- Background job queue, and the processing status of confirmed direct image uploads.
+
+CREATE TABLE IF NOT EXISTS "jobs" (
+    "id" uuid,
+    "type" varchar(100) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "unique_key" varchar(200),
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "max_attempts" bigint NOT NULL,
+    "run_at" timestamptz NOT NULL,
+    "lease_expires_at" timestamptz,
+    "last_error" varchar(500),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_jobs_due" ON "jobs" ("type","status","run_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_jobs_unique_key" ON "jobs" ("unique_key") WHERE status <> 'dead';
+
+CREATE TABLE IF NOT EXISTS "job_schedules" (
+    "name" varchar(100),
+    "last_slot" timestamptz NOT NULL,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("name")
+);
+
+ALTER TABLE "dish_image_uploads"
+    ADD COLUMN IF NOT EXISTS "status" varchar(20) NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS "alt_text" varchar(300),
+    ADD COLUMN IF NOT EXISTS "image_id" text,
+    ADD COLUMN IF NOT EXISTS "error" varchar(300);
+CREATE INDEX IF NOT EXISTS "idx_dish_image_uploads_status" ON "dish_image_uploads" ("status");
+
+-- Uploads confirmed before processing moved to a job were processed on confirmation
+UPDATE "dish_image_uploads" SET "status" = 'completed' WHERE "confirmed_at" IS NOT NULL AND "status" = 'pending';
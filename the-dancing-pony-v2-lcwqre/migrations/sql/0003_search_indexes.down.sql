@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_restaurants_name_trgm";
+DROP INDEX IF EXISTS "idx_dishes_name_trgm";
@@ -0,0 +1,6 @@
+-- Trigram indexes for the name searches of dishes and restaurants, which match with ILIKE '%term%'.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS "idx_dishes_name_trgm" ON "dishes" USING gin ("name" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "idx_restaurants_name_trgm" ON "restaurants" USING gin ("name" gin_trgm_ops);
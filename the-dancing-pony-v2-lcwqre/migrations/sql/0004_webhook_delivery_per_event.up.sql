@@ -0,0 +1,15 @@
+-- At most one delivery of an event per webhook subscription, so an event relayed again
+-- does not queue it twice. Replays are further deliveries of the same event.
+
+CREATE TEMPORARY TABLE "duplicate_webhook_deliveries" ON COMMIT DROP AS
+SELECT "id" FROM (
+    SELECT "id", row_number() OVER (PARTITION BY "subscription_id", "event_id" ORDER BY "created_at", "id") AS "position"
+    FROM "webhook_deliveries"
+    WHERE "replay_of_id" IS NULL
+) AS "deliveries"
+WHERE "position" > 1;
+
+DELETE FROM "webhook_attempts" WHERE "delivery_id" IN (SELECT "id" FROM "duplicate_webhook_deliveries");
+DELETE FROM "webhook_deliveries" WHERE "id" IN (SELECT "id" FROM "duplicate_webhook_deliveries");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_webhook_deliveries_subscription_event" ON "webhook_deliveries" ("subscription_id","event_id") WHERE replay_of_id IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "outbox_dead_letters";
+ALTER TABLE "outbox_offsets" DROP COLUMN IF EXISTS "leased_until";
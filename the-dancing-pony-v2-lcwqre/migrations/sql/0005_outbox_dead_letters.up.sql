@@ -0,0 +1,24 @@
+-- Events a consumer gave up on, kept until they are replayed, and the lease of the
+-- replica relaying a consumer, which no longer holds a row lock while handlers run.
+
+ALTER TABLE "outbox_offsets" ADD COLUMN IF NOT EXISTS "leased_until" timestamptz;
+
+CREATE TABLE IF NOT EXISTS "outbox_dead_letters" (
+    "id" uuid,
+    "consumer" varchar(100) NOT NULL,
+    "event_id" uuid NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "aggregate_type" varchar(50) NOT NULL,
+    "aggregate_id" uuid NOT NULL,
+    "restaurant_id" varchar(36) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "occurred_at" timestamptz NOT NULL,
+    "attempts" bigint NOT NULL,
+    "last_error" varchar(500),
+    "replay_requested_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_outbox_dead_letters_consumer_event" ON "outbox_dead_letters" ("consumer","event_id");
+CREATE INDEX IF NOT EXISTS "idx_outbox_dead_letters_replay_requested_at" ON "outbox_dead_letters" ("replay_requested_at");
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Cart is a customer's or guest's basket at a restaurant before checkout. Prices are
+// recalculated from the dishes whenever the cart is read; items only remember the
+// last price shown so that changes can be flagged.
+type Cart struct {
+	Model
+	RestaurantID uuid.UUID  `gorm:"not null;uniqueIndex:idx_carts_restaurant_customer" json:"restaurant_id"`
+	CustomerID   *uuid.UUID `gorm:"uniqueIndex:idx_carts_restaurant_customer" json:"customer_id"` // Nil for guest carts
+	GuestToken   *string    `gorm:"type:varchar(64);uniqueIndex" json:"-"`                        // Identifies a guest cart, nil for customer carts
+	CouponCode   *string    `gorm:"type:varchar(40)" json:"coupon_code"`                          // Coupon entered by the owner, checked again at checkout
+	Items        []CartItem `gorm:"foreignKey:CartID"`
+}
+
+// CartItem is one dish in a cart.
+type CartItem struct {
+	Model
+	CartID    uuid.UUID `gorm:"not null;uniqueIndex:idx_cart_items_cart_dish" json:"cart_id"`
+	DishID    uuid.UUID `gorm:"not null;uniqueIndex:idx_cart_items_cart_dish" json:"dish_id"`
+	Name      string    `gorm:"not null" json:"name"`       // Dish name when last seen, kept for dishes that disappear
+	UnitPrice float64   `gorm:"not null" json:"unit_price"` // Dish price when the cart was last shown
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	Notes     string    `gorm:"type:varchar(200)" json:"notes"`
+}
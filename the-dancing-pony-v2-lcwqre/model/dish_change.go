@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of pending dish changes.
+const (
+	DishChangeKindUpdate    = "update"
+	DishChangeKindPublish   = "publish"
+	DishChangeKindUnpublish = "unpublish"
+)
+
+// Lifecycle states of a pending dish change.
+const (
+	DishChangeStatusDraft     = "draft"
+	DishChangeStatusScheduled = "scheduled"
+	DishChangeStatusApplied   = "applied"
+	DishChangeStatusCancelled = "cancelled"
+)
+
+// DishChange holds an edit to a dish that is not yet visible to customers.
+// A change stays in draft until it is published immediately or scheduled
+// for a future time, at which point a scheduled background job applies it.
+type DishChange struct {
+	Model
+	DishID       uuid.UUID  `gorm:"index;not null" json:"dish_id"`
+	Dish         Dish       `gorm:"foreignKey:DishID;references:ID"`
+	RestaurantID uuid.UUID  `gorm:"index;not null" json:"restaurant_id"`
+	Kind         string     `gorm:"type:varchar(20);not null" json:"kind"`
+	Status       string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Name         *string    `json:"name"`
+	Description  *string    `json:"description"`
+	Price        *float64   `json:"price"`
+	PublishAt    *time.Time `gorm:"index" json:"publish_at"`
+	AppliedAt    *time.Time `json:"applied_at"`
+	CreatedById  uuid.UUID  `json:"created_by_id"`
+}
+
+// ApplyTo applies the change to an in-memory copy of the dish.
+func (c *DishChange) ApplyTo(dish *Dish, at time.Time) {
+	switch c.Kind {
+	case DishChangeKindPublish:
+		dish.Status = DishStatusPublished
+		if dish.PublishedAt == nil {
+			dish.PublishedAt = &at
+		}
+	case DishChangeKindUnpublish:
+		dish.Status = DishStatusDraft
+	default:
+		if c.Name != nil {
+			dish.Name = *c.Name
+		}
+		if c.Description != nil {
+			dish.Description = *c.Description
+		}
+		if c.Price != nil {
+			dish.Price = *c.Price
+		}
+	}
+}
+
+// Fields returns the dish columns written when the change is applied.
+func (c *DishChange) Fields(at time.Time) map[string]interface{} {
+	fields := map[string]interface{}{
+		"LastUpdatedByID": c.CreatedById,
+	}
+	switch c.Kind {
+	case DishChangeKindPublish:
+		fields["Status"] = DishStatusPublished
+		fields["PublishedAt"] = gorm.Expr("COALESCE(published_at, ?)", at)
+	case DishChangeKindUnpublish:
+		fields["Status"] = DishStatusDraft
+	default:
+		if c.Name != nil {
+			fields["Name"] = *c.Name
+		}
+		if c.Description != nil {
+			fields["Description"] = *c.Description
+		}
+		if c.Price != nil {
+			fields["Price"] = *c.Price
+		}
+	}
+	return fields
+}
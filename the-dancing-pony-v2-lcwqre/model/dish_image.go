@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DishImage is one image in a dish's gallery. Images are shown in Position
+// order, and the primary image is mirrored to Dish.Image so list endpoints
+// do not need to load the gallery.
+type DishImage struct {
+	Model
+	DishID       uuid.UUID `gorm:"index;not null" json:"dish_id"`
+	RestaurantID uuid.UUID `gorm:"index;not null" json:"restaurant_id"`
+	Key          string    `gorm:"not null" json:"key"` // Object store key of the full rendition
+	AltText      string    `gorm:"type:varchar(300)" json:"alt_text"`
+	Position     int       `gorm:"not null;default:0" json:"position"`
+	IsPrimary    bool      `gorm:"not null;default:false" json:"is_primary"`
+	CreatedById  uuid.UUID `json:"created_by_id"`
+}
+
+// Dish image upload status values. A confirmed upload is processed by a background
+// job, which completes it or marks it failed.
+const (
+	DishImageUploadStatusPending    = "pending"
+	DishImageUploadStatusProcessing = "processing"
+	DishImageUploadStatusCompleted  = "completed"
+	DishImageUploadStatusFailed     = "failed"
+)
+
+// DishImageUpload tracks a presigned upload the client sends straight to the
+// object store. The upload is attached to the dish once the client confirms it.
+type DishImageUpload struct {
+	Model
+	DishID       uuid.UUID  `gorm:"index;not null" json:"dish_id"`
+	RestaurantID uuid.UUID  `gorm:"index;not null" json:"restaurant_id"`
+	Key          string     `gorm:"not null" json:"key"` // Object store key the client uploads the raw file to
+	ContentType  string     `gorm:"type:varchar(50);not null" json:"content_type"`
+	Status       string     `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	AltText      string     `gorm:"type:varchar(300)" json:"alt_text"` // Given on confirmation
+	ImageID      *uuid.UUID `json:"image_id"`                          // Gallery image created from the upload
+	Error        string     `gorm:"type:varchar(300)" json:"error"`    // Why processing failed
+	ExpiresAt    time.Time  `gorm:"index;not null" json:"expires_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	CreatedById  uuid.UUID  `json:"created_by_id"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job is a queued background job. Jobs that succeed are deleted; those that failed for
+// good stay with status dead.
+type Job struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Type           string     `gorm:"type:varchar(100);not null;index:idx_jobs_due,priority:1" json:"type"`
+	Payload        string     `gorm:"type:jsonb;not null" json:"payload"`
+	UniqueKey      *string    `gorm:"type:varchar(200);uniqueIndex:idx_jobs_unique_key,where:status <> 'dead'" json:"unique_key"` // At most one queued job per key
+	Status         string     `gorm:"type:varchar(20);not null;default:pending;index:idx_jobs_due,priority:2" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts    int        `gorm:"not null" json:"max_attempts"`
+	RunAt          time.Time  `gorm:"not null;index:idx_jobs_due,priority:3" json:"run_at"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"` // When a running job may be claimed again
+	LastError      string     `gorm:"type:varchar(500)" json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// JobSchedule records the last run of a recurring job that was enqueued, so replicas
+// enqueue each run once.
+type JobSchedule struct {
+	Name      string    `gorm:"type:varchar(100);primaryKey" json:"name"`
+	LastSlot  time.Time `gorm:"not null" json:"last_slot"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
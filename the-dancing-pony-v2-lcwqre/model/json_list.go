@@ -0,0 +1,40 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONList is a list stored in a single jsonb column, such as the tags of a dish.
+// A nil list is stored as an empty array so that columns can be NOT NULL.
+type JSONList[T any] []T
+
+// Value encodes the list as a JSON array.
+func (l JSONList[T]) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]T(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan decodes a JSON array read from the database.
+func (l *JSONList[T]) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a JSON list", value)
+	}
+	return json.Unmarshal(data, (*[]T)(l))
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of loyalty ledger entries. Earned, refunded and positive adjusted points are
+// credits; redeemed, expired and negative adjusted points are debits.
+const (
+	LoyaltyEntryEarnOrder  = "earn_order"  // Points for a served or collected order
+	LoyaltyEntryEarnReview = "earn_review" // Points for an approved review
+	LoyaltyEntryRedeem     = "redeem"      // Points spent on a reward at checkout
+	LoyaltyEntryRefund     = "refund"      // Points of a redemption returned when its order is cancelled or rejected
+	LoyaltyEntryExpire     = "expire"      // What was left of a credit when it expired
+	LoyaltyEntryAdjust     = "adjust"      // Correction made by staff
+)
+
+// LoyaltyProgram is the loyalty configuration of a restaurant. Restaurants without one
+// have no loyalty program.
+type LoyaltyProgram struct {
+	Model
+	RestaurantID  uuid.UUID `gorm:"not null;uniqueIndex" json:"restaurant_id"`
+	Enabled       bool      `gorm:"not null" json:"enabled"`
+	PointsPerUnit float64   `gorm:"not null" json:"points_per_unit"` // Points per currency unit of an order's total, rounded down
+	ReviewPoints  int       `gorm:"not null" json:"review_points"`   // Points for the first approved review of each dish
+	ExpiryDays    int       `gorm:"not null" json:"expiry_days"`     // Days credits stay valid, 0 for never
+}
+
+// LoyaltyReward is a discount customers can redeem points for at checkout.
+type LoyaltyReward struct {
+	Model
+	RestaurantID uuid.UUID `gorm:"not null;index" json:"restaurant_id"`
+	Name         string    `gorm:"type:varchar(100);not null" json:"name"`
+	Description  string    `gorm:"type:varchar(500)" json:"description"`
+	PointsCost   int       `gorm:"not null" json:"points_cost"`
+	Amount       float64   `gorm:"not null" json:"amount"` // Taken off the order total after promotions
+	Active       bool      `gorm:"not null" json:"active"`
+}
+
+// LoyaltyAccount is locked while entries are appended to a customer's ledger so that
+// concurrent checkouts cannot spend the same points. It holds no balance: balances are
+// always computed from the ledger.
+type LoyaltyAccount struct {
+	Model
+	RestaurantID uuid.UUID `gorm:"not null;uniqueIndex:idx_loyalty_accounts_customer" json:"restaurant_id"`
+	CustomerID   uuid.UUID `gorm:"not null;uniqueIndex:idx_loyalty_accounts_customer" json:"customer_id"`
+}
+
+// LoyaltyEntry is one line of a customer's append-only points ledger. Entries are
+// never updated or deleted; mistakes are corrected with adjustments.
+type LoyaltyEntry struct {
+	Model
+	RestaurantID  uuid.UUID  `gorm:"not null;index:idx_loyalty_entries_customer" json:"restaurant_id"`
+	CustomerID    uuid.UUID  `gorm:"not null;index:idx_loyalty_entries_customer" json:"customer_id"`
+	Kind          string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_loyalty_entries_order,where:order_id IS NOT NULL;uniqueIndex:idx_loyalty_entries_rating,where:rating_id IS NOT NULL" json:"kind"`
+	Points        int        `gorm:"not null" json:"points"`                                                              // Positive for credits, negative for debits
+	OrderID       *uuid.UUID `gorm:"uniqueIndex:idx_loyalty_entries_order,where:order_id IS NOT NULL" json:"order_id"`    // Order earning, redeeming or refunding the points
+	RatingID      *uuid.UUID `gorm:"uniqueIndex:idx_loyalty_entries_rating,where:rating_id IS NOT NULL" json:"rating_id"` // Review earning the points
+	RewardID      *uuid.UUID `json:"reward_id"`
+	SourceEntryID *uuid.UUID `gorm:"uniqueIndex" json:"source_entry_id"` // Credit an expire entry lapses
+	ExpiresAt     *time.Time `json:"expires_at"`                         // When what is left of a credit expires, nil for never
+	Note          string     `gorm:"type:varchar(300)" json:"note"`
+	CreatedByID   *uuid.UUID `json:"created_by_id"` // Staff member who made an adjustment
+}
+
+// LoyaltyLot is what is left of a credit after the debits drawn from it. Lots are
+// computed from the ledger and never stored.
+type LoyaltyLot struct {
+	EntryID   uuid.UUID
+	Points    int
+	ExpiresAt *time.Time
+}
+
+// LoyaltyBalance is a customer's balance as computed from their ledger.
+type LoyaltyBalance struct {
+	Points int
+	Lots   []LoyaltyLot   // Credits with points left, soonest to expire first
+	Totals map[string]int // Sum of the points of each kind of entry
+}
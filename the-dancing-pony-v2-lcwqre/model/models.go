@@ -1,39 +1,81 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Roles a caller acts in on the orders, reservations, payments and receipts of a
+// restaurant, which decide the status changes they may make and what they see.
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff"
+)
+
+// Model holds the columns every table shares. It replaces gorm.Model, whose uint ID does
+// not match the uuid primary keys of the schema.
+type Model struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// Dish status values. Only published dishes are visible on customer routes.
+const (
+	DishStatusDraft     = "draft"
+	DishStatusPublished = "published"
+)
+
 type Dish struct {
-	gorm.Model
-	Name            string     `json:"name"`
-	Description     string     `json:"description"`
-	Price           float64    `json:"price"`
-	Image           string     `json:"image"`
-	CreatedById     uuid.UUID  `json:"created_by_id"`                            // Foreign key for the user who created the dish
-	CreatedBy       User       `gorm:"foreignKey:CreatedById;references:ID"`     // Belongs to User
-	LastUpdatedByID *uuid.UUID `json:"last_updated_by_id"`                       // Foreign key for the user who last updated the dish
-	LastUpdatedBy   User       `gorm:"foreignKey:LastUpdatedByID;references:ID"` // Belongs to User
-	Ratings         []Rating   `gorm:"foreignKey:DishID"`                        // One-to-many relationship with ratings
-	RestaurantID    uuid.UUID  `gorm:"index;not null"`                           // Foreign key for the restaurant
-	Restaurant      Restaurant `gorm:"foreignKey:RestaurantID"`
+	Model
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	Price           float64          `json:"price"`
+	Image           string           `json:"image"`
+	Category        string           `gorm:"type:varchar(50);not null;default:'';index" json:"category"` // Menu section such as "mains", used by promotions
+	Tags            JSONList[string] `gorm:"type:jsonb;not null;default:'[]'" json:"tags"`               // Free-form labels such as "vegan", used by promotions
+	Status          string           `gorm:"type:varchar(20);not null;default:'published';index" json:"status"`
+	PublishedAt     *time.Time       `json:"published_at"`
+	CreatedById     uuid.UUID        `json:"created_by_id"`                                        // Foreign key for the user who created the dish
+	CreatedBy       User             `gorm:"foreignKey:CreatedById;references:ID"`                 // Belongs to User
+	LastUpdatedByID *uuid.UUID       `json:"last_updated_by_id"`                                   // Foreign key for the user who last updated the dish
+	LastUpdatedBy   User             `gorm:"foreignKey:LastUpdatedByID;references:ID"`             // Belongs to User
+	Ratings         []Rating         `gorm:"foreignKey:DishID"`                                    // One-to-many relationship with ratings
+	RestaurantID    uuid.UUID        `gorm:"index;not null;uniqueIndex:idx_dishes_restaurant_sku"` // Foreign key for the restaurant
+	Restaurant      Restaurant       `gorm:"foreignKey:RestaurantID"`
+	SKU             *string          `gorm:"type:varchar(64);uniqueIndex:idx_dishes_restaurant_sku" json:"sku"` // External stock keeping unit, unique per restaurant
+	Images          []DishImage      `gorm:"foreignKey:DishID"`                                                 // Image gallery, ordered by position
+	Version         int64            `gorm:"not null;default:1" json:"version"`                                 // Bumped on every update, exposed as the ETag
 }
 
-// Rating model
+// Moderation states of a rating. Ratings left before moderation existed count as approved.
+const (
+	RatingStatusPending  = "pending"
+	RatingStatusApproved = "approved"
+	RatingStatusRejected = "rejected"
+)
+
+// Rating model; a rating with its comment is a customer's review of a dish
 type Rating struct {
-	gorm.Model
-	DishID uuid.UUID `json:"dish_id"`
-	Dish   Dish      `gorm:"foreignKey:DishID;references:ID"` // Belongs to Dish
-	UserID uuid.UUID `json:"user_id"`
-	User   User      `gorm:"foreignKey:UserID;references:ID"` // Belongs to User
-	Rating int       `json:"rating"`
+	Model
+	DishID        uuid.UUID  `json:"dish_id"`
+	Dish          Dish       `gorm:"foreignKey:DishID;references:ID"` // Belongs to Dish
+	UserID        uuid.UUID  `json:"user_id"`
+	User          User       `gorm:"foreignKey:UserID;references:ID"` // Belongs to User
+	Rating        int        `json:"rating"`
+	Comment       string     `gorm:"type:varchar(1000);not null;default:''" json:"comment"`
+	Status        string     `gorm:"type:varchar(20);not null;default:'approved';index" json:"status"`
+	ModeratedAt   *time.Time `json:"moderated_at"`
+	ModeratedByID *uuid.UUID `json:"moderated_by_id"` // Staff member who approved or rejected the review
 }
 
 // User represents a user entity in the system.
 type User struct {
-	gorm.Model
+	Model
 	Name         string       `gorm:"not null" json:"name"`
 	Email        string       `gorm:"not null,uniqueIndex" json:"email"`
 	Password     string       `gorm:"not null" json:"-"`
@@ -46,17 +88,38 @@ type User struct {
 
 // Permission represents a permission assigned to a user.
 type Permission struct {
-	gorm.Model
+	Model
 	Name string `json:"name" validate:"required,unique"`
 }
 
 // Restaurant represents a restaurant entity.
 type Restaurant struct {
-	gorm.Model
+	Model
 	Name        string `gorm:"not null"`
 	Description string `json:"description" validate:"required,min=1,max=200"`
 	Location    string `json:"location" validate:"required,min=1,max=200"`
 	ImageUrl    string `json:"imageUrl" validate:"required,min=1,max=200"`
+	Version     int64  `gorm:"not null;default:1" json:"version"` // Bumped on every update, exposed as the ETag
+}
+
+// BeforeUpdate bumps the dish version so every write changes its ETag.
+// Dishes are always updated with column maps; struct updates would need the version set explicitly.
+func (d *Dish) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx)
+	return nil
+}
+
+// BeforeUpdate bumps the restaurant version so every write changes its ETag.
+func (r *Restaurant) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx)
+	return nil
+}
+
+// bumpVersion increments the version column of a map update in the same statement.
+func bumpVersion(tx *gorm.DB) {
+	if _, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		tx.Statement.SetColumn("Version", gorm.Expr("version + 1"))
+	}
 }
 
 // SetPassword hashes the password for the User
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lifecycle states of an order.
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusAccepted  = "accepted"
+	OrderStatusPreparing = "preparing"
+	OrderStatusReady     = "ready"
+	OrderStatusServed    = "served"    // Final state of dine-in orders
+	OrderStatusCollected = "collected" // Final state of takeaway orders
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRejected  = "rejected"
+)
+
+// How an order is handed to the customer.
+const (
+	OrderFulfilmentDineIn   = "dine_in"
+	OrderFulfilmentTakeaway = "takeaway"
+)
+
+// Order is a customer's order at a restaurant. Items snapshot the dish name
+// and price at order time, so later menu edits do not change placed orders, and the
+// promotions applied are recorded as redemptions. A loyalty reward redeemed with the
+// order and the tax charged are snapshotted the same way.
+type Order struct {
+	Model
+	RestaurantID     uuid.UUID             `gorm:"index;not null" json:"restaurant_id"`
+	CustomerID       uuid.UUID             `gorm:"index;not null" json:"customer_id"`
+	Status           string                `gorm:"type:varchar(20);not null;index" json:"status"`
+	Fulfilment       string                `gorm:"type:varchar(20);not null" json:"fulfilment"`
+	Notes            string                `gorm:"type:varchar(500)" json:"notes"`
+	Discount         float64               `gorm:"not null;default:0" json:"discount"` // Sum of the promotions applied
+	Total            float64               `gorm:"not null" json:"total"`              // Sum of the items less the discount and the reward discount, plus tax when prices exclude it
+	PricesIncludeTax bool                  `gorm:"not null;default:false" json:"prices_include_tax"`
+	Tax              float64               `gorm:"not null;default:0" json:"tax"`
+	TaxLines         JSONList[TaxLine]     `gorm:"type:jsonb;not null;default:'[]'" json:"tax_lines"` // Tax by rate, empty for untaxed orders
+	CouponCode       string                `gorm:"type:varchar(40)" json:"coupon_code"`
+	RewardID         *uuid.UUID            `json:"reward_id"`                                 // Loyalty reward redeemed with the order
+	RewardName       string                `gorm:"type:varchar(100)" json:"reward_name"`      // Reward name when the order was placed
+	RewardPoints     int                   `gorm:"not null;default:0" json:"reward_points"`   // Points spent on the reward
+	RewardDiscount   float64               `gorm:"not null;default:0" json:"reward_discount"` // Taken off after the promotions
+	Reason           string                `gorm:"type:varchar(300)" json:"reason"`           // Why the order was cancelled or rejected
+	StatusChangedAt  time.Time             `gorm:"not null" json:"status_changed_at"`
+	Items            []OrderItem           `gorm:"foreignKey:OrderID"`
+	Redemptions      []PromotionRedemption `gorm:"foreignKey:OrderID"`
+	Invoice          *Invoice              `gorm:"foreignKey:OrderID"`
+}
+
+// OrderItem is one line of an order.
+type OrderItem struct {
+	Model
+	OrderID   uuid.UUID `gorm:"index;not null" json:"order_id"`
+	DishID    uuid.UUID `gorm:"index;not null" json:"dish_id"`
+	Name      string    `gorm:"not null" json:"name"`       // Dish name when the order was placed
+	UnitPrice float64   `gorm:"not null" json:"unit_price"` // Dish price when the order was placed
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	Notes     string    `gorm:"type:varchar(200)" json:"notes"`
+	TaxName   string    `gorm:"type:varchar(50);not null;default:''" json:"tax_name"` // Tax rate the line was taxed at
+	TaxRate   float64   `gorm:"not null;default:0" json:"tax_rate"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the change it
+// describes. Events are relayed in (TransactionID, Sequence) order: once a transaction
+// ID is below the oldest one still running, no event can commit before it any more.
+type OutboxEvent struct {
+	Sequence      int64     `gorm:"primaryKey;autoIncrement;index:idx_outbox_events_position,priority:2" json:"sequence"`
+	TransactionID int64     `gorm:"not null;default:(pg_current_xact_id()::text::bigint);index:idx_outbox_events_position,priority:1" json:"transaction_id"` // Writing transaction, set by the database
+	ID            uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"id"`
+	Type          string    `gorm:"type:varchar(50);not null;index" json:"type"`
+	AggregateType string    `gorm:"type:varchar(50);not null" json:"aggregate_type"`
+	AggregateID   uuid.UUID `gorm:"type:uuid;not null" json:"aggregate_id"`
+	RestaurantID  string    `gorm:"type:varchar(36);not null" json:"restaurant_id"`
+	Payload       string    `gorm:"type:jsonb;not null" json:"payload"`
+	OccurredAt    time.Time `gorm:"not null;index" json:"occurred_at"`
+}
+
+// OutboxOffset is the position of a consumer in the outbox: the last event it handled
+// or gave up on.
+type OutboxOffset struct {
+	Consumer      string     `gorm:"type:varchar(100);primaryKey" json:"consumer"`
+	TransactionID int64      `gorm:"not null;default:0" json:"transaction_id"`
+	Sequence      int64      `gorm:"not null;default:0" json:"sequence"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"` // Failed attempts at the next event
+	LastError     string     `gorm:"type:varchar(500)" json:"last_error"`
+	LeasedUntil   *time.Time `json:"leased_until"` // Lease of the replica relaying the consumer
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// OutboxDeadLetter is an event a consumer gave up on once its attempts ran out. It keeps
+// a copy of the event, which may be pruned from the outbox, until the consumer handles
+// it on a replay.
+type OutboxDeadLetter struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Consumer          string     `gorm:"type:varchar(100);not null;uniqueIndex:idx_outbox_dead_letters_consumer_event,priority:1" json:"consumer"`
+	EventID           uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_outbox_dead_letters_consumer_event,priority:2" json:"event_id"`
+	Type              string     `gorm:"type:varchar(50);not null" json:"type"`
+	AggregateType     string     `gorm:"type:varchar(50);not null" json:"aggregate_type"`
+	AggregateID       uuid.UUID  `gorm:"type:uuid;not null" json:"aggregate_id"`
+	RestaurantID      string     `gorm:"type:varchar(36);not null" json:"restaurant_id"`
+	Payload           string     `gorm:"type:jsonb;not null" json:"payload"`
+	OccurredAt        time.Time  `gorm:"not null" json:"occurred_at"`
+	Attempts          int        `gorm:"not null" json:"attempts"`
+	LastError         string     `gorm:"type:varchar(500)" json:"last_error"`
+	ReplayRequestedAt *time.Time `gorm:"index" json:"replay_requested_at"` // Set until the relay hands the event to the consumer again
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Lifecycle states of a payment.
+const (
+	PaymentStatusPending           = "pending"         // Stored, provider intent not created yet
+	PaymentStatusRequiresAction    = "requires_action" // Waiting for the customer, e.g. 3-D Secure
+	PaymentStatusProcessing        = "processing"
+	PaymentStatusAuthorized        = "authorized" // Funds held, waiting for capture
+	PaymentStatusSucceeded         = "succeeded"
+	PaymentStatusFailed            = "failed"
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+	PaymentStatusRefunded          = "refunded"
+)
+
+// Lifecycle states of a refund.
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+// Payment is an attempt to pay for an order through a payment provider.
+// Amounts are in minor units of the currency.
+type Payment struct {
+	Model
+	RestaurantID     uuid.UUID `gorm:"index;not null" json:"restaurant_id"`
+	OrderID          uuid.UUID `gorm:"index;not null" json:"order_id"`
+	CustomerID       uuid.UUID `gorm:"index;not null" json:"customer_id"`
+	Provider         string    `gorm:"type:varchar(30);not null" json:"provider"`
+	ProviderIntentID string    `gorm:"type:varchar(100);index" json:"provider_intent_id"`
+	Status           string    `gorm:"type:varchar(20);not null" json:"status"`
+	Amount           int64     `gorm:"not null" json:"amount"`
+	AmountRefunded   int64     `gorm:"not null;default:0" json:"amount_refunded"`
+	Currency         string    `gorm:"type:varchar(3);not null" json:"currency"`
+	CaptureMethod    string    `gorm:"type:varchar(20);not null" json:"capture_method"`
+	NextActionURL    string    `gorm:"type:varchar(500)" json:"next_action_url"`
+	FailureCode      string    `gorm:"type:varchar(100)" json:"failure_code"`
+	Refunds          []Refund  `gorm:"foreignKey:PaymentID"`
+}
+
+// Refund returns part or all of a succeeded payment.
+type Refund struct {
+	Model
+	PaymentID        uuid.UUID `gorm:"not null;uniqueIndex:idx_refunds_payment_key" json:"payment_id"`
+	ProviderRefundID string    `gorm:"type:varchar(100);index" json:"provider_refund_id"`
+	Status           string    `gorm:"type:varchar(20);not null" json:"status"`
+	Amount           int64     `gorm:"not null" json:"amount"`
+	Reason           string    `gorm:"type:varchar(300)" json:"reason"`
+	IdempotencyKey   *string   `gorm:"type:varchar(100);uniqueIndex:idx_refunds_payment_key" json:"-"` // From the Idempotency-Key header, if sent
+	RequestedByID    uuid.UUID `json:"requested_by_id"`
+}
+
+// PaymentEvent records a processed provider webhook so that redeliveries are ignored.
+type PaymentEvent struct {
+	Model
+	Provider string `gorm:"type:varchar(30);not null;uniqueIndex:idx_payment_events_provider_event"`
+	EventID  string `gorm:"type:varchar(100);not null;uniqueIndex:idx_payment_events_provider_event"`
+	Type     string `gorm:"type:varchar(50);not null"`
+}
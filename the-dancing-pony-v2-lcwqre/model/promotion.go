@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How a promotion discounts the items it applies to.
+const (
+	PromotionDiscountPercent  = "percent"     // Value percent off each qualifying item
+	PromotionDiscountFixed    = "fixed"       // Value off the qualifying items together
+	PromotionDiscountBuyXGetY = "buy_x_get_y" // Of every BuyQuantity+GetQuantity qualifying items, the GetQuantity cheapest are free
+)
+
+// Promotion is a discount rule of a restaurant. Promotions without a code apply
+// automatically to every cart and order meeting their conditions; promotions with
+// a code are coupons that only apply once the customer enters the code.
+type Promotion struct {
+	Model
+	RestaurantID uuid.UUID `gorm:"not null;index;uniqueIndex:idx_promotions_restaurant_code,where:deleted_at IS NULL" json:"restaurant_id"`
+	Name         string    `gorm:"type:varchar(100);not null" json:"name"`
+	Code         *string   `gorm:"type:varchar(40);uniqueIndex:idx_promotions_restaurant_code,where:deleted_at IS NULL" json:"code"` // Upper-case coupon code, nil for automatic promotions
+	Active       bool      `gorm:"not null" json:"active"`
+	Priority     int       `gorm:"not null" json:"priority"`  // Higher priorities are applied first
+	Stackable    bool      `gorm:"not null" json:"stackable"` // Whether the promotion combines with other stackable promotions
+
+	// Conditions
+	StartsAt         *time.Time       `json:"starts_at"`
+	EndsAt           *time.Time       `json:"ends_at"`
+	Timezone         string           `gorm:"type:varchar(64);not null" json:"timezone"`  // IANA name, weekdays and daily hours are in this zone
+	Weekdays         JSONList[int]    `gorm:"type:jsonb;not null" json:"weekdays"`        // 0 is Sunday, as in time.Weekday; empty means every day
+	DailyFrom        string           `gorm:"type:varchar(5);not null" json:"daily_from"` // HH:MM, empty for all day
+	DailyUntil       string           `gorm:"type:varchar(5);not null" json:"daily_until"`
+	Categories       JSONList[string] `gorm:"type:jsonb;not null" json:"categories"` // Dish categories the promotion applies to
+	Tags             JSONList[string] `gorm:"type:jsonb;not null" json:"tags"`       // Dish tags the promotion applies to; with no categories and tags it applies to every dish
+	MinSpend         float64          `gorm:"not null" json:"min_spend"`             // Minimum order subtotal before discounts
+	FirstOrderOnly   bool             `gorm:"not null" json:"first_order_only"`
+	PerCustomerLimit int              `gorm:"not null" json:"per_customer_limit"` // Redemptions per customer, 0 for unlimited
+	UsageLimit       int              `gorm:"not null" json:"usage_limit"`        // Redemptions overall, 0 for unlimited; 1 makes a single-use coupon
+
+	// Action
+	DiscountType string  `gorm:"type:varchar(20);not null" json:"discount_type"`
+	Value        float64 `gorm:"not null" json:"value"` // Percentage or amount, depending on the discount type
+	BuyQuantity  int     `gorm:"not null" json:"buy_quantity"`
+	GetQuantity  int     `gorm:"not null" json:"get_quantity"`
+}
+
+// PromotionRedemption records a promotion applied to an order. Redemptions of cancelled
+// and rejected orders do not count towards the promotion's limits.
+type PromotionRedemption struct {
+	Model
+	PromotionID  uuid.UUID `gorm:"not null;index" json:"promotion_id"`
+	RestaurantID uuid.UUID `gorm:"not null" json:"restaurant_id"`
+	CustomerID   uuid.UUID `gorm:"not null;index" json:"customer_id"`
+	OrderID      uuid.UUID `gorm:"not null;index" json:"order_id"`
+	Name         string    `gorm:"type:varchar(100);not null" json:"name"` // Promotion name when the order was placed
+	Code         string    `gorm:"type:varchar(40)" json:"code"`
+	Amount       float64   `gorm:"not null" json:"amount"`
+}
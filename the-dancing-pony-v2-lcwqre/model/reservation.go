@@ -0,0 +1,104 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lifecycle states of a reservation.
+const (
+	ReservationStatusRequested = "requested"
+	ReservationStatusConfirmed = "confirmed"
+	ReservationStatusSeated    = "seated"
+	ReservationStatusNoShow    = "no_show"
+	ReservationStatusCancelled = "cancelled"
+)
+
+// ReservationHoldingStatuses are the statuses in which a reservation holds its table.
+var ReservationHoldingStatuses = []string{ReservationStatusRequested, ReservationStatusConfirmed, ReservationStatusSeated}
+
+// Lifecycle states of a waitlist entry.
+const (
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusBooked    = "booked" // A table freed up and a reservation was made for the entry
+	WaitlistStatusCancelled = "cancelled"
+)
+
+// DiningArea groups the tables of a restaurant, such as the terrace or the bar.
+type DiningArea struct {
+	Model
+	RestaurantID uuid.UUID `gorm:"not null;uniqueIndex:idx_dining_areas_restaurant_name,where:deleted_at IS NULL" json:"restaurant_id"`
+	Name         string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_dining_areas_restaurant_name,where:deleted_at IS NULL" json:"name"`
+}
+
+// DiningTable is a table that can be reserved by parties of MinCapacity to MaxCapacity guests.
+type DiningTable struct {
+	Model
+	RestaurantID uuid.UUID  `gorm:"not null;uniqueIndex:idx_dining_tables_restaurant_name,where:deleted_at IS NULL" json:"restaurant_id"`
+	AreaID       *uuid.UUID `gorm:"index" json:"area_id"`
+	Name         string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_dining_tables_restaurant_name,where:deleted_at IS NULL" json:"name"`
+	MinCapacity  int        `gorm:"not null;default:1" json:"min_capacity"`
+	MaxCapacity  int        `gorm:"not null" json:"max_capacity"`
+	Active       bool       `gorm:"not null" json:"active"` // Inactive tables are not offered for reservations
+}
+
+// ReservationSettings holds how a restaurant takes reservations. Restaurants without
+// settings use the defaults below.
+type ReservationSettings struct {
+	Model
+	RestaurantID    uuid.UUID `gorm:"not null;uniqueIndex" json:"restaurant_id"`
+	Timezone        string    `gorm:"type:varchar(64);not null" json:"timezone"` // IANA name, opening hours are in this zone
+	SlotMinutes     int       `gorm:"not null" json:"slot_minutes"`              // Interval between bookable start times
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`          // How long a reservation holds its table
+	MaxPartySize    int       `gorm:"not null" json:"max_party_size"`
+	MaxAdvanceDays  int       `gorm:"not null" json:"max_advance_days"` // How far ahead reservations are taken
+}
+
+// Default reservation settings.
+const (
+	DefaultReservationTimezone = "UTC"
+	DefaultSlotMinutes         = 15
+	DefaultDurationMinutes     = 90
+	DefaultMaxPartySize        = 12
+	DefaultMaxAdvanceDays      = 60
+)
+
+// OpeningHours is a period in which a restaurant seats reservations on a day of the week.
+// A period closing at or before its opening time closes on the next day.
+type OpeningHours struct {
+	Model
+	RestaurantID uuid.UUID `gorm:"index;not null" json:"restaurant_id"`
+	Weekday      int       `gorm:"not null" json:"weekday"`                // 0 is Sunday, as in time.Weekday
+	Opens        string    `gorm:"type:varchar(5);not null" json:"opens"`  // HH:MM in the restaurant's timezone
+	Closes       string    `gorm:"type:varchar(5);not null" json:"closes"` // HH:MM in the restaurant's timezone
+}
+
+// Reservation is a table held for a customer's party from StartsAt to EndsAt.
+// Overlapping reservations of a table are prevented by locking the table while booking.
+type Reservation struct {
+	Model
+	RestaurantID    uuid.UUID `gorm:"index;not null" json:"restaurant_id"`
+	CustomerID      uuid.UUID `gorm:"index;not null" json:"customer_id"`
+	TableID         uuid.UUID `gorm:"not null;index:idx_reservations_table_time" json:"table_id"`
+	PartySize       int       `gorm:"not null" json:"party_size"`
+	StartsAt        time.Time `gorm:"not null;index;index:idx_reservations_table_time" json:"starts_at"`
+	EndsAt          time.Time `gorm:"not null" json:"ends_at"`
+	Status          string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	Notes           string    `gorm:"type:varchar(500)" json:"notes"`
+	Reason          string    `gorm:"type:varchar(300)" json:"reason"` // Why the reservation was cancelled
+	StatusChangedAt time.Time `gorm:"not null" json:"status_changed_at"`
+}
+
+// WaitlistEntry is a customer waiting for a table at a time that was fully booked.
+// When a table frees up, the entry is booked into a reservation automatically.
+type WaitlistEntry struct {
+	Model
+	RestaurantID  uuid.UUID  `gorm:"index;not null" json:"restaurant_id"`
+	CustomerID    uuid.UUID  `gorm:"index;not null" json:"customer_id"`
+	PartySize     int        `gorm:"not null" json:"party_size"`
+	DesiredAt     time.Time  `gorm:"not null;index" json:"desired_at"`
+	Status        string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Notes         string     `gorm:"type:varchar(500)" json:"notes"`
+	ReservationID *uuid.UUID `json:"reservation_id"` // Set once booked
+}
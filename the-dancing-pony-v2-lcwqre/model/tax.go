@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaxRate is a named tax rate and the dish categories it applies to. The rate without
+// categories is the default rate, applied to dishes of every other category.
+type TaxRate struct {
+	Name       string   `json:"name"`
+	Rate       float64  `json:"rate"` // Percent, with at most two decimals
+	Categories []string `json:"categories"`
+}
+
+// TaxSettings is the tax configuration of a restaurant and the seller details printed
+// on its invoices. Restaurants without settings charge no tax.
+type TaxSettings struct {
+	Model
+	RestaurantID     uuid.UUID         `gorm:"not null;uniqueIndex" json:"restaurant_id"`
+	PricesIncludeTax bool              `gorm:"not null" json:"prices_include_tax"` // Whether menu prices include tax or have it added on top
+	Rates            JSONList[TaxRate] `gorm:"type:jsonb;not null" json:"rates"`
+	LegalName        string            `gorm:"type:varchar(200);not null" json:"legal_name"` // Printed instead of the restaurant name when set
+	Address          string            `gorm:"type:varchar(500);not null" json:"address"`
+	TaxNumber        string            `gorm:"type:varchar(50);not null" json:"tax_number"` // VAT registration number
+	InvoicePrefix    string            `gorm:"type:varchar(20);not null" json:"invoice_prefix"`
+}
+
+// TaxLine is the tax of an order at one rate.
+type TaxLine struct {
+	Name  string  `json:"name"`
+	Rate  float64 `json:"rate"`
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+	Gross float64 `json:"gross"`
+}
+
+// InvoiceSequence holds the last invoice number of a restaurant. It is locked while an
+// invoice is issued, so numbers are sequential and a rolled back invoice leaves no gap.
+type InvoiceSequence struct {
+	Model
+	RestaurantID uuid.UUID `gorm:"not null;uniqueIndex" json:"restaurant_id"`
+	Last         int64     `gorm:"not null" json:"last"`
+}
+
+// Invoice numbers an order once it was served or collected. The seller details are
+// snapshotted so reprints match the original.
+type Invoice struct {
+	Model
+	RestaurantID  uuid.UUID `gorm:"not null;uniqueIndex:idx_invoices_restaurant_sequence" json:"restaurant_id"`
+	Sequence      int64     `gorm:"not null;uniqueIndex:idx_invoices_restaurant_sequence" json:"sequence"`
+	Number        string    `gorm:"type:varchar(40);not null" json:"number"` // Invoice prefix and zero-padded sequence
+	OrderID       uuid.UUID `gorm:"not null;uniqueIndex" json:"order_id"`
+	IssuedAt      time.Time `gorm:"not null" json:"issued_at"`
+	SellerName    string    `gorm:"type:varchar(200);not null" json:"seller_name"`
+	SellerAddress string    `gorm:"type:varchar(500);not null" json:"seller_address"`
+	TaxNumber     string    `gorm:"type:varchar(50);not null" json:"tax_number"`
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a webhook delivery.
+const (
+	WebhookDeliveryPending   = "pending"   // Waiting for its first attempt or a retry
+	WebhookDeliverySucceeded = "succeeded" // The receiver answered with a 2xx status
+	WebhookDeliveryFailed    = "failed"    // Every attempt failed, or the subscription was disabled or deleted
+)
+
+// WebhookEventTest is the type of the event sent by a test delivery. It cannot be
+// subscribed to, and its attempts do not count towards the failures of a subscription.
+const WebhookEventTest = "webhook.test"
+
+// WebhookSubscription asks for a restaurant's events of the given types to be posted to a URL.
+type WebhookSubscription struct {
+	Model
+	RestaurantID        uuid.UUID        `gorm:"not null;index" json:"restaurant_id"`
+	URL                 string           `gorm:"type:varchar(2000);not null" json:"url"`
+	Description         string           `gorm:"type:varchar(200)" json:"description"`
+	EventTypes          JSONList[string] `gorm:"type:jsonb;not null;default:'[]'" json:"event_types"`
+	Secret              string           `gorm:"type:varchar(100);not null" json:"-"` // Key deliveries are signed with
+	Active              bool             `gorm:"not null" json:"active"`
+	ConsecutiveFailures int              `gorm:"not null;default:0" json:"consecutive_failures"` // Failed attempts since the last success
+	DisabledAt          *time.Time       `json:"disabled_at"`                                    // When the subscription was disabled for failing
+	DisabledReason      string           `gorm:"type:varchar(300)" json:"disabled_reason"`
+}
+
+// WebhookDelivery is one event to post to one subscription, with the outcome of its
+// last attempt. Replays are new deliveries of the same event.
+type WebhookDelivery struct {
+	Model
+	RestaurantID   uuid.UUID            `gorm:"not null" json:"restaurant_id"`
+	SubscriptionID uuid.UUID            `gorm:"not null;index;uniqueIndex:idx_webhook_deliveries_subscription_event,priority:1,where:replay_of_id IS NULL" json:"subscription_id"`
+	Subscription   *WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+	EventID        string               `gorm:"type:varchar(64);not null;index;uniqueIndex:idx_webhook_deliveries_subscription_event,priority:2,where:replay_of_id IS NULL" json:"event_id"` // Sent as Webhook-Id so receivers can drop duplicates; one delivery per subscription besides replays
+	EventType      string               `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        string               `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string               `gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due" json:"status"`
+	Attempts       int                  `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time           `gorm:"index:idx_webhook_deliveries_due" json:"next_attempt_at"` // Nil once the delivery succeeded or failed
+	LockedUntil    *time.Time           `json:"locked_until"`                                            // Lease of the dispatcher sending it
+	LastAttemptAt  *time.Time           `json:"last_attempt_at"`
+	ResponseStatus int                  `gorm:"not null;default:0" json:"response_status"` // Of the last attempt, 0 when no response was received
+	Error          string               `gorm:"type:varchar(500)" json:"error"`            // Of the last attempt
+	DeliveredAt    *time.Time           `json:"delivered_at"`
+	ReplayOfID     *uuid.UUID           `json:"replay_of_id"` // Delivery this one replays
+	Log            []WebhookAttempt     `gorm:"foreignKey:DeliveryID" json:"-"`
+}
+
+// WebhookAttempt records one HTTP request made for a delivery.
+type WebhookAttempt struct {
+	Model
+	DeliveryID     uuid.UUID `gorm:"not null;index" json:"delivery_id"`
+	Number         int       `gorm:"not null" json:"number"`
+	ResponseStatus int       `gorm:"not null" json:"response_status"`
+	ResponseBody   string    `gorm:"type:varchar(1000)" json:"response_body"` // Start of the body, for debugging
+	Error          string    `gorm:"type:varchar(500)" json:"error"`
+	DurationMs     int64     `gorm:"not null" json:"duration_ms"`
+}
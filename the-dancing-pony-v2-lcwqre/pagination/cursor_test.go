@@ -0,0 +1,102 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorSignerDecode(t *testing.T) {
+	signer := NewCursorSigner("test-signing-key")
+	cursor := Cursor{
+		Key:   Key{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), ID: uuid.MustParse("6f1c2a3e-7d4b-4c8e-9a0f-1b2c3d4e5f60")},
+		Scope: "dishes:restaurant-1",
+	}
+	token := signer.Encode(cursor)
+	encoded, signature, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2024-05-01T12:30:00Z","i":"6f1c2a3e-7d4b-4c8e-9a0f-1b2c3d4e5f60","s":"dishes:restaurant-2"}`))
+
+	tests := []struct {
+		name    string
+		signer  *CursorSigner
+		token   string
+		scope   string
+		wantErr bool
+	}{
+		{name: "round trip", signer: signer, token: token, scope: cursor.Scope},
+		{name: "other scope", signer: signer, token: token, scope: "dishes:restaurant-2", wantErr: true},
+		{name: "other key", signer: NewCursorSigner("another-key"), token: token, scope: cursor.Scope, wantErr: true},
+		{name: "tampered payload", signer: signer, token: forged + "." + signature, scope: "dishes:restaurant-2", wantErr: true},
+		{name: "tampered signature", signer: signer, token: encoded + "." + strings.Repeat("A", len(signature)), scope: cursor.Scope, wantErr: true},
+		{name: "missing signature", signer: signer, token: encoded, scope: cursor.Scope, wantErr: true},
+		{name: "empty", signer: signer, token: "", scope: cursor.Scope, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.signer.Decode(tt.token, tt.scope)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("Decode() error = %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !got.CreatedAt.Equal(cursor.CreatedAt) || got.ID != cursor.ID || got.Backward != cursor.Backward || got.Scope != cursor.Scope {
+				t.Errorf("Decode() = %+v, want %+v", got, cursor)
+			}
+		})
+	}
+}
+
+func TestCursorSignerPage(t *testing.T) {
+	signer := NewCursorSigner("test-signing-key")
+	key := Key{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), ID: uuid.MustParse("6f1c2a3e-7d4b-4c8e-9a0f-1b2c3d4e5f60")}
+	const scope = "restaurants"
+
+	tests := []struct {
+		name       string
+		request    Request
+		want       Page
+		wantAfter  bool
+		wantBefore bool
+		wantErr    bool
+	}{
+		{name: "default limit", request: Request{}, want: Page{Limit: DefaultLimit}},
+		{name: "limit capped", request: Request{Limit: MaxLimit + 1}, want: Page{Limit: MaxLimit}},
+		{name: "offset mode counts", request: Request{Page: 3, Limit: 20}, want: Page{Number: 3, Limit: 20, Count: true}},
+		{name: "next cursor", request: Request{Limit: 5, Cursor: signer.Encode(Cursor{Key: key, Scope: scope})}, want: Page{Limit: 5}, wantAfter: true},
+		{name: "prev cursor", request: Request{Limit: 5, Cursor: signer.Encode(Cursor{Key: key, Backward: true, Scope: scope})}, want: Page{Limit: 5}, wantBefore: true},
+		{name: "cursor for other list", request: Request{Cursor: signer.Encode(Cursor{Key: key, Scope: "dishes"})}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signer.Page(tt.request, scope)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("Page() error = %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Page() error = %v", err)
+			}
+			if got.Number != tt.want.Number || got.Limit != tt.want.Limit || got.Count != tt.want.Count {
+				t.Errorf("Page() = %+v, want %+v", got, tt.want)
+			}
+			if (got.After != nil) != tt.wantAfter || (got.Before != nil) != tt.wantBefore {
+				t.Fatalf("Page() after = %v, before = %v", got.After, got.Before)
+			}
+			for _, k := range []*Key{got.After, got.Before} {
+				if k != nil && (!k.CreatedAt.Equal(key.CreatedAt) || k.ID != key.ID) {
+					t.Errorf("Page() key = %+v, want %+v", *k, key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package pagination
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100 // Larger limits are lowered to this
+)
+
+// Request holds the pagination query parameters of a list request.
+type Request struct {
+	Page   int    // Page number; selects offset pagination when set
+	Limit  int    // Rows per page
+	Cursor string // Token from a previous page's next or prev cursor
+	Count  bool   // Whether to count the matching rows; offset pagination always counts
+}
+
+// Page tells a repository which rows to return.
+type Page struct {
+	Number int // Page number in offset mode, zero in keyset mode
+	Limit  int
+	After  *Key // Return the rows after this key
+	Before *Key // Return the rows before this key, when paging backwards
+	Count  bool
+}
+
+// Result describes the rows a repository returned for a page.
+type Result struct {
+	HasNext bool
+	HasPrev bool
+	Total   int // Number of matching rows, -1 when not counted
+}
+
+// Page validates a request for the list identified by scope.
+func (s *CursorSigner) Page(request Request, scope string) (Page, error) {
+	page := Page{Limit: request.Limit, Count: request.Count}
+	if page.Limit <= 0 {
+		page.Limit = DefaultLimit
+	}
+	if page.Limit > MaxLimit {
+		page.Limit = MaxLimit
+	}
+
+	if request.Page > 0 {
+		page.Number = request.Page
+		page.Count = true
+		return page, nil
+	}
+	if request.Cursor == "" {
+		return page, nil
+	}
+	cursor, err := s.Decode(request.Cursor, scope)
+	if err != nil {
+		return Page{}, err
+	}
+	if cursor.Backward {
+		page.Before = &cursor.Key
+	} else {
+		page.After = &cursor.Key
+	}
+	return page, nil
+}
+
+// Offset returns the number of rows an offset mode page skips.
+func (p Page) Offset() int {
+	if p.Number <= 1 {
+		return 0
+	}
+	return (p.Number - 1) * p.Limit
+}
+
+// Cursors returns the tokens of the pages around a keyset mode page, given the keys of its rows.
+// A token is empty when there is no page on that side.
+func (s *CursorSigner) Cursors(scope string, page Page, result Result, keys []Key) (next, prev string) {
+	if page.Number > 0 || len(keys) == 0 {
+		return "", ""
+	}
+	if result.HasNext {
+		next = s.Encode(Cursor{Key: keys[len(keys)-1], Scope: scope})
+	}
+	if result.HasPrev {
+		prev = s.Encode(Cursor{Key: keys[0], Backward: true, Scope: scope})
+	}
+	return next, prev
+}
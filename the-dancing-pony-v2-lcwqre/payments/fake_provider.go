@@ -0,0 +1,287 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Payment method tokens understood by the fake provider, each simulating one outcome.
+const (
+	FakeCardSuccess = "fake_card_success" // Succeeds, or is authorised with manual capture
+	FakeCardDecline = "fake_card_decline" // Is declined with card_declined
+	FakeCard3DS     = "fake_card_3ds"     // Requires a 3-D Secure challenge on the stand-in page
+	FakeCardDelayed = "fake_card_delayed" // Processes, then succeeds in a webhook after the webhook delay
+)
+
+// WebhookPath is the API path payment providers deliver webhooks to.
+const WebhookPath = "/api/payments/webhook"
+
+// fakeSignatureHeader carries the fake provider's webhook signature as "t=<unix>,v1=<hex hmac>".
+const fakeSignatureHeader = "Fake-Signature"
+
+// webhookTolerance is how old a webhook signature may be before it is rejected.
+const webhookTolerance = 5 * time.Minute
+
+// FakeProvider is an in-memory payment provider for tests and local development. It
+// delivers signed webhooks to the API like a real provider and serves 3-D Secure
+// challenges through its stand-in handler.
+type FakeProvider struct {
+	mu      sync.Mutex
+	intents map[string]*fakeIntent
+	refunds map[string]Refund
+	keys    map[string]string // Idempotency key to intent or refund ID
+
+	secret       []byte
+	publicURL    string
+	webhookDelay time.Duration
+	client       *http.Client
+}
+
+type fakeIntent struct {
+	Intent
+	captureMethod string
+	refunded      int64
+}
+
+// NewFakeProvider creates a new instance of FakeProvider.
+func NewFakeProvider(cfg Config) *FakeProvider {
+	return &FakeProvider{
+		intents:      make(map[string]*fakeIntent),
+		refunds:      make(map[string]Refund),
+		keys:         make(map[string]string),
+		secret:       []byte(cfg.WebhookSecret),
+		publicURL:    strings.TrimSuffix(cfg.PublicURL, "/"),
+		webhookDelay: cfg.WebhookDelay,
+		client:       &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Name identifies the provider in stored payments.
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// CreateIntent starts a payment whose outcome depends on the payment method token.
+func (p *FakeProvider) CreateIntent(ctx context.Context, req IntentRequest) (Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if id, ok := p.keys[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return p.intents[id].Intent, nil
+	}
+
+	intent := &fakeIntent{
+		Intent:        Intent{ID: "fake_pi_" + uuid.NewString(), Reference: req.Reference, Amount: req.Amount},
+		captureMethod: req.CaptureMethod,
+	}
+	switch req.PaymentMethod {
+	case FakeCardSuccess:
+		intent.authorize()
+	case FakeCardDecline:
+		intent.Status = IntentFailed
+		intent.FailureCode = "card_declined"
+	case FakeCard3DS:
+		intent.Status = IntentRequiresAction
+		intent.NextActionURL = p.publicURL + StandInRoutePrefix + "fake/intents/" + intent.ID + "/challenge"
+	case FakeCardDelayed:
+		intent.Status = IntentProcessing
+	default:
+		intent.Status = IntentFailed
+		intent.FailureCode = "invalid_payment_method"
+	}
+	p.intents[intent.ID] = intent
+	if req.IdempotencyKey != "" {
+		p.keys[req.IdempotencyKey] = intent.ID
+	}
+
+	if req.PaymentMethod == FakeCardDelayed {
+		time.AfterFunc(p.webhookDelay, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			intent.authorize()
+			p.sendIntentEvent(intent)
+		})
+	} else {
+		p.sendIntentEvent(intent)
+	}
+	return intent.Intent, nil
+}
+
+// Capture collects the given amount of an authorised intent; zero captures the full amount.
+func (p *FakeProvider) Capture(ctx context.Context, intentId string, amount int64) (Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	intent, ok := p.intents[intentId]
+	if !ok {
+		return Intent{}, ErrIntentNotFound
+	}
+	if intent.Status == IntentSucceeded {
+		return intent.Intent, nil
+	}
+	if intent.Status != IntentAuthorized || amount > intent.Amount {
+		return Intent{}, ErrNotCapturable
+	}
+	if amount == 0 {
+		amount = intent.Amount
+	}
+	intent.Status = IntentSucceeded
+	intent.AmountCaptured = amount
+	p.sendIntentEvent(intent)
+	return intent.Intent, nil
+}
+
+// Refund returns part or all of a succeeded payment.
+func (p *FakeProvider) Refund(ctx context.Context, req RefundRequest) (Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if id, ok := p.keys[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return p.refunds[id], nil
+	}
+	intent, ok := p.intents[req.IntentID]
+	if !ok {
+		return Refund{}, ErrIntentNotFound
+	}
+
+	refund := Refund{ID: "fake_re_" + uuid.NewString(), Amount: req.Amount, Status: RefundSucceeded}
+	if intent.Status != IntentSucceeded || intent.refunded+req.Amount > intent.AmountCaptured {
+		refund.Status = RefundFailed
+	} else {
+		intent.refunded += req.Amount
+	}
+	p.refunds[refund.ID] = refund
+	if req.IdempotencyKey != "" {
+		p.keys[req.IdempotencyKey] = refund.ID
+	}
+	p.send(Event{Type: EventRefundUpdated, Refund: &refund})
+	return refund, nil
+}
+
+// VerifyWebhook checks the Fake-Signature header and decodes the event.
+func (p *FakeProvider) VerifyWebhook(header http.Header, body []byte) (Event, error) {
+	var timestamp int64
+	var signature string
+	for _, part := range strings.Split(header.Get(fakeSignatureHeader), ",") {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == 0 || time.Since(time.Unix(timestamp, 0)) > webhookTolerance ||
+		!hmac.Equal([]byte(p.sign(timestamp, body)), []byte(signature)) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return event, nil
+}
+
+// completeChallenge resolves a 3-D Secure challenge.
+func (p *FakeProvider) completeChallenge(intentId string, passed bool) (Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	intent, ok := p.intents[intentId]
+	if !ok {
+		return Intent{}, ErrIntentNotFound
+	}
+	if intent.Status != IntentRequiresAction {
+		return intent.Intent, nil
+	}
+	intent.NextActionURL = ""
+	if passed {
+		intent.authorize()
+	} else {
+		intent.Status = IntentFailed
+		intent.FailureCode = "authentication_failed"
+	}
+	p.sendIntentEvent(intent)
+	return intent.Intent, nil
+}
+
+// authorize moves an intent on after a successful authorisation, capturing it unless
+// capture is manual.
+func (intent *fakeIntent) authorize() {
+	if intent.captureMethod == CaptureManual {
+		intent.Status = IntentAuthorized
+		return
+	}
+	intent.Status = IntentSucceeded
+	intent.AmountCaptured = intent.Amount
+}
+
+// sendIntentEvent delivers the intent's current state. Callers hold p.mu.
+func (p *FakeProvider) sendIntentEvent(intent *fakeIntent) {
+	snapshot := intent.Intent
+	p.send(Event{Type: EventIntentUpdated, Intent: &snapshot})
+}
+
+// send delivers an event to the webhook endpoint in the background, retrying a few times.
+func (p *FakeProvider) send(event Event) {
+	event.ID = "fake_evt_" + uuid.NewString()
+	event.CreatedAt = time.Now().UTC()
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Msg("Error encoding fake payment webhook")
+		return
+	}
+
+	go func() {
+		for attempt := 1; attempt <= 3; attempt++ {
+			err := p.post(body)
+			if err == nil {
+				return
+			}
+			log.Warn().
+				Str("event_id", event.ID).
+				Int("attempt", attempt).
+				Err(err).
+				Msg("Fake payment webhook delivery failed")
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}()
+}
+
+// post signs and posts one webhook.
+func (p *FakeProvider) post(body []byte) error {
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequest(http.MethodPost, p.publicURL+WebhookPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(fakeSignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, p.sign(timestamp, body)))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sign returns the hex HMAC-SHA256 of the timestamp and body.
+func (p *FakeProvider) sign(timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package payments
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+var challengePage = template.Must(template.New("challenge").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Fake 3-D Secure</title></head>
+<body>
+<h1>Fake 3-D Secure challenge</h1>
+{{if eq .Status "requires_action"}}
+<p>Payment {{.ID}} of {{.Amount}} minor units needs authentication.</p>
+<form method="post"><button name="result" value="pass">Authenticate</button> <button name="result" value="fail">Fail</button></form>
+{{else}}
+<p>Payment {{.ID}} is {{.Status}}. You can close this page.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// Handler serves the fake provider's 3-D Secure challenge pages under StandInRoutePrefix.
+// Submitting the form resolves the challenge and sends the resulting webhook.
+func (p *FakeProvider) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+StandInRoutePrefix+"fake/intents/{id}/challenge", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		intent, ok := p.intents[r.PathValue("id")]
+		var snapshot Intent
+		if ok {
+			snapshot = intent.Intent
+		}
+		p.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		renderChallenge(w, snapshot)
+	})
+	mux.HandleFunc("POST "+StandInRoutePrefix+"fake/intents/{id}/challenge", func(w http.ResponseWriter, r *http.Request) {
+		intent, err := p.completeChallenge(r.PathValue("id"), r.FormValue("result") == "pass")
+		if errors.Is(err, ErrIntentNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		renderChallenge(w, intent)
+	})
+	return mux
+}
+
+// renderChallenge writes the challenge page for an intent.
+func renderChallenge(w http.ResponseWriter, intent Intent) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := challengePage.Execute(w, intent); err != nil {
+		log.Error().Str("intent_id", intent.ID).Err(err).Msg("Error rendering fake 3-D Secure page")
+	}
+}
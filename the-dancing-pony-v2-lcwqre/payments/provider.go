@@ -0,0 +1,139 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned when a webhook's signature does not verify.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrIntentNotFound is returned when the provider does not know a payment intent.
+	ErrIntentNotFound = errors.New("payment intent not found")
+
+	// ErrNotCapturable is returned when capturing an intent that is not authorised.
+	ErrNotCapturable = errors.New("payment intent cannot be captured")
+)
+
+// Intent statuses reported by providers.
+const (
+	IntentRequiresAction = "requires_action" // Waiting for the customer, e.g. a 3-D Secure challenge
+	IntentProcessing     = "processing"      // Outcome follows in a webhook
+	IntentAuthorized     = "authorized"      // Funds held, waiting for capture
+	IntentSucceeded      = "succeeded"
+	IntentFailed         = "failed"
+)
+
+// Refund statuses reported by providers.
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+// Webhook event types.
+const (
+	EventIntentUpdated = "intent.updated"
+	EventRefundUpdated = "refund.updated"
+)
+
+// Capture methods of an intent.
+const (
+	CaptureAutomatic = "automatic"
+	CaptureManual    = "manual"
+)
+
+// PaymentProvider is a payment service provider (PSP). Amounts are in minor units of the currency.
+type PaymentProvider interface {
+	// Name identifies the provider in stored payments.
+	Name() string
+
+	// CreateIntent starts a payment. Retrying with the same idempotency key returns the same intent.
+	CreateIntent(ctx context.Context, req IntentRequest) (Intent, error)
+
+	// Capture collects the given amount of an authorised intent; zero captures the full amount.
+	Capture(ctx context.Context, intentId string, amount int64) (Intent, error)
+
+	// Refund returns part or all of a succeeded payment. Retrying with the same idempotency key
+	// returns the same refund.
+	Refund(ctx context.Context, req RefundRequest) (Refund, error)
+
+	// VerifyWebhook checks a webhook's signature and decodes its event.
+	VerifyWebhook(header http.Header, body []byte) (Event, error)
+}
+
+// StandIn is implemented by providers that serve their own customer-facing pages, such
+// as 3-D Secure challenges, from the API under StandInRoutePrefix.
+type StandIn interface {
+	Handler() http.Handler
+}
+
+// StandInRoutePrefix is the path under which stand-in providers serve their pages.
+const StandInRoutePrefix = "/payments/provider/"
+
+// IntentRequest describes a payment to start.
+type IntentRequest struct {
+	Amount         int64
+	Currency       string
+	PaymentMethod  string // Provider token of the customer's payment method
+	CaptureMethod  string // CaptureAutomatic or CaptureManual
+	Reference      string // Our payment ID, echoed back in webhooks
+	IdempotencyKey string
+}
+
+// Intent is a provider's view of a payment.
+type Intent struct {
+	ID             string `json:"id"`
+	Reference      string `json:"reference"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	AmountCaptured int64  `json:"amountCaptured"`
+	NextActionURL  string `json:"nextActionUrl,omitempty"` // Where to send the customer when Status is IntentRequiresAction
+	FailureCode    string `json:"failureCode,omitempty"`
+}
+
+// RefundRequest describes a refund of an intent.
+type RefundRequest struct {
+	IntentID       string
+	Amount         int64
+	IdempotencyKey string
+}
+
+// Refund is a provider's view of a refund.
+type Refund struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Amount int64  `json:"amount"`
+}
+
+// Event is a verified webhook event. Intent events carry the intent, refund events the refund.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Intent    *Intent   `json:"intent,omitempty"`
+	Refund    *Refund   `json:"refund,omitempty"`
+}
+
+// Config selects and configures the payment provider.
+type Config struct {
+	Driver        string // "fake" is the only built-in driver
+	WebhookSecret string // HMAC key of webhook signatures
+	PublicURL     string // Base URL of the API, used for stand-in pages and webhook delivery
+	WebhookDelay  time.Duration
+}
+
+// NewProvider creates the payment provider selected by cfg.Driver.
+func NewProvider(cfg Config) (PaymentProvider, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", "fake":
+		return NewFakeProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.Driver)
+	}
+}
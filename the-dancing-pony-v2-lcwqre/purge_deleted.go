@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/repository"
+)
+
+const purgeDeletedUsage = `usage: thedancingpony purge-deleted [flags]
+
+Permanently deletes the rows soft-deleted longer ago than -older-than. Rows other rows
+still reference, such as dishes with ratings, are kept.
+
+Flags:
+`
+
+// runPurgeDeleted runs the purge-deleted command.
+func runPurgeDeleted(settings config.Settings, args []string) int {
+	flags := newFlagSet("purge-deleted", purgeDeletedUsage)
+	olderThan := flags.Duration("older-than", 30*24*time.Hour, "how long rows stay soft-deleted before they are purged")
+	batchSize := flags.Int("batch-size", 1000, "rows deleted per statement")
+	dryRun := flags.Bool("dry-run", false, "count the rows that would be purged without deleting them")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if *olderThan < 0 || *batchSize <= 0 {
+		fmt.Fprintln(os.Stderr, "error: -older-than must not be negative and -batch-size must be positive")
+		return 2
+	}
+
+	db, ok := openDatabase(settings)
+	if !ok {
+		return 1
+	}
+	results, err := repository.NewMaintenanceRepositoryImpl(db).PurgeDeleted(time.Now().Add(-*olderThan), *batchSize, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	purgedHeader := "PURGED"
+	if *dryRun {
+		purgedHeader = "WOULD PURGE"
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "TABLE\t%s\tKEPT (REFERENCED)\n", purgedHeader)
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", result.Table, result.Purged, result.Kept)
+	}
+	w.Flush()
+	return 0
+}
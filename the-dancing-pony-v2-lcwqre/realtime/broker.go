@@ -0,0 +1,162 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped.
+// Dropped clients reconnect with Last-Event-ID and catch up from the history.
+const subscriberBuffer = 64
+
+// broker fans events out to the subscribers connected to this process.
+type broker struct {
+	mu   sync.Mutex
+	subs map[string]map[*subscription]struct{} // By restaurant
+}
+
+type subscription struct {
+	subscriber Subscriber
+	live       chan Event
+	closed     bool
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[string]map[*subscription]struct{})}
+}
+
+// add registers a subscriber; its live channel receives events from now on.
+func (b *broker) add(subscriber Subscriber) *subscription {
+	sub := &subscription{subscriber: subscriber, live: make(chan Event, subscriberBuffer)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[subscriber.RestaurantID] == nil {
+		b.subs[subscriber.RestaurantID] = make(map[*subscription]struct{})
+	}
+	b.subs[subscriber.RestaurantID][sub] = struct{}{}
+	realtimeSubscribers.Inc()
+	return sub
+}
+
+// remove unregisters a subscriber and closes its live channel.
+func (b *broker) remove(sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(sub)
+}
+
+func (b *broker) removeLocked(sub *subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.live)
+	delete(b.subs[sub.subscriber.RestaurantID], sub)
+	if len(b.subs[sub.subscriber.RestaurantID]) == 0 {
+		delete(b.subs, sub.subscriber.RestaurantID)
+	}
+	realtimeSubscribers.Dec()
+}
+
+// deliver passes an event to every subscriber that may see it. Subscribers whose
+// buffer is full are dropped rather than slowing everyone else down.
+func (b *broker) deliver(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs[event.RestaurantID] {
+		if !sub.subscriber.receives(event) {
+			continue
+		}
+		select {
+		case sub.live <- event:
+		default:
+			realtimeDropped.Inc()
+			b.removeLocked(sub)
+		}
+	}
+	realtimeEvents.WithLabelValues(event.Type).Inc()
+}
+
+// Subscription is a stream of events for one subscriber.
+type Subscription struct {
+	// Events yields replayed events, then live ones. It is closed when the subscription
+	// ends, including when the subscriber fell too far behind.
+	Events <-chan Event
+	cancel context.CancelFunc
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.cancel()
+}
+
+// stream starts a subscription that yields the replayed events followed by the live
+// ones, skipping live events that were already replayed. The live subscription must
+// be registered before the history is read so that no event falls in between.
+func (b *broker) stream(ctx context.Context, sub *subscription, replay []Event) *Subscription {
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan Event)
+	replayed := make(map[string]struct{}, len(replay))
+	for _, event := range replay {
+		replayed[event.ID] = struct{}{}
+	}
+
+	go func() {
+		defer close(out)
+		defer b.remove(sub)
+		send := func(event Event) bool {
+			select {
+			case out <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, event := range replay {
+			if !send(event) {
+				return
+			}
+		}
+		for {
+			select {
+			case event, ok := <-sub.live:
+				if !ok {
+					return
+				}
+				if _, seen := replayed[event.ID]; seen {
+					continue
+				}
+				if !send(event) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return &Subscription{Events: out, cancel: cancel}
+}
+
+// replayAfter returns the subscriber's events in history after lastEventID, oldest
+// first. When the ID is no longer in history, a reset event is returned instead. The
+// reset has no ID: a client resuming from it would only be reset again.
+func replayAfter(history []Event, subscriber Subscriber, lastEventID string) []Event {
+	if lastEventID == "" {
+		return nil
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ID != lastEventID {
+			continue
+		}
+		var replay []Event
+		for _, event := range history[i+1:] {
+			if subscriber.receives(event) {
+				replay = append(replay, event)
+			}
+		}
+		return replay
+	}
+	reset, _ := NewEvent(EventReset, subscriber.RestaurantID, AudienceAll, "", nil)
+	reset.ID, reset.Data = "", nil
+	return []Event{reset}
+}
@@ -0,0 +1,52 @@
+package realtime
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReplayAfter(t *testing.T) {
+	const restaurant = "restaurant-1"
+	history := []Event{
+		{ID: "1", RestaurantID: restaurant, Audience: AudienceAll},
+		{ID: "2", RestaurantID: restaurant, Audience: AudienceStaff},
+		{ID: "3", RestaurantID: restaurant, Audience: AudienceStaff, CustomerID: "customer-1"},
+		{ID: "4", RestaurantID: restaurant, Audience: AudienceAll},
+	}
+	staff := Subscriber{RestaurantID: restaurant, UserID: "staff-1", Staff: true}
+	customer := Subscriber{RestaurantID: restaurant, UserID: "customer-1"}
+	tests := []struct {
+		name        string
+		subscriber  Subscriber
+		lastEventID string
+		want        []string // IDs of the replayed events
+		wantReset   bool
+	}{
+		{name: "new subscriber", subscriber: staff},
+		{name: "staff", subscriber: staff, lastEventID: "1", want: []string{"2", "3", "4"}},
+		{name: "customer", subscriber: customer, lastEventID: "1", want: []string{"3", "4"}},
+		{name: "up to date", subscriber: staff, lastEventID: "4"},
+		{name: "unknown event", subscriber: staff, lastEventID: "0", wantReset: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay := replayAfter(history, tt.subscriber, tt.lastEventID)
+			if tt.wantReset {
+				if len(replay) != 1 || replay[0].Type != EventReset {
+					t.Fatalf("replayAfter() = %v, want a reset", replay)
+				}
+				if replay[0].ID != "" {
+					t.Errorf("reset ID = %q, want none so clients do not resume from it", replay[0].ID)
+				}
+				return
+			}
+			var got []string
+			for _, event := range replay {
+				got = append(got, event.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("replayAfter() IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types pushed to clients.
+const (
+	EventOrderPlaced      = "order.placed"
+	EventOrderUpdated     = "order.updated"
+	EventDishAvailability = "dish.availability"
+	EventDishCreated      = "dish.created"
+	EventDishUpdated      = "dish.updated"
+	EventDishDeleted      = "dish.deleted"
+
+	EventReviewCreated   = "review.created"
+	EventReviewModerated = "review.moderated"
+
+	EventReservationCreated = "reservation.created"
+	EventReservationUpdated = "reservation.updated"
+
+	// EventReset tells a reconnecting client that the events it missed are no longer
+	// available and it should reload its state.
+	EventReset = "stream.reset"
+)
+
+// Audiences of an event within its restaurant.
+const (
+	AudienceAll   = "all"   // Customers and staff
+	AudienceStaff = "staff" // Staff only, plus the customer named in the event, if any
+)
+
+// Event is a change pushed to the subscribers of a restaurant.
+type Event struct {
+	ID           string          `json:"id,omitempty"` // Empty for resets, which clients cannot resume from
+	Type         string          `json:"type"`
+	RestaurantID string          `json:"restaurantId"`
+	Audience     string          `json:"-"`
+	CustomerID   string          `json:"-"` // Customer who also receives a staff event, e.g. the owner of an order
+	Data         json.RawMessage `json:"data,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// wireEvent is an Event including its routing fields, as passed between replicas.
+type wireEvent struct {
+	Event
+	Audience   string `json:"audience"`
+	CustomerID string `json:"customerId,omitempty"`
+}
+
+// NewEvent creates an event with a new ID and the JSON encoding of data.
+func NewEvent(eventType string, restaurantId string, audience string, customerId string, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("error encoding %s event: %w", eventType, err)
+	}
+	return Event{
+		ID:           uuid.NewString(),
+		Type:         eventType,
+		RestaurantID: restaurantId,
+		Audience:     audience,
+		CustomerID:   customerId,
+		Data:         encoded,
+		CreatedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// Subscriber describes who is listening, which decides the events they receive.
+type Subscriber struct {
+	RestaurantID string
+	UserID       string
+	Staff        bool
+}
+
+// receives reports whether the subscriber may see the event.
+func (s Subscriber) receives(event Event) bool {
+	if event.RestaurantID != s.RestaurantID {
+		return false
+	}
+	switch {
+	case event.Audience == AudienceAll, s.Staff && event.Audience == AudienceStaff:
+		return true
+	default:
+		return event.CustomerID != "" && event.CustomerID == s.UserID
+	}
+}
+
+// Publisher publishes events to the subscribers of their restaurant.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Hub fans events out to subscribers, across replicas for the Redis driver.
+type Hub interface {
+	Publisher
+
+	// Subscribe streams the subscriber's events. When lastEventID is set, the events
+	// published after it are replayed first, or EventReset is sent when they are no
+	// longer kept. The subscription ends when ctx is done or it is closed.
+	Subscribe(ctx context.Context, subscriber Subscriber, lastEventID string) (*Subscription, error)
+}
+
+// Config selects and configures the hub driver.
+type Config struct {
+	Driver        string // "memory" or "redis"
+	RedisAddr     string
+	RedisPassword string
+	HistorySize   int // Events kept per restaurant for Last-Event-ID replay
+}
+
+// NewHub creates the hub selected by cfg.Driver.
+func NewHub(ctx context.Context, cfg Config) (Hub, error) {
+	if cfg.HistorySize <= 0 {
+		cfg.HistorySize = 500
+	}
+	switch strings.ToLower(cfg.Driver) {
+	case "", "memory":
+		return NewMemoryHub(cfg.HistorySize), nil
+	case "redis":
+		return NewRedisHub(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown realtime driver %q", cfg.Driver)
+	}
+}
+
+// NopPublisher discards events. It is used where no hub is configured.
+type NopPublisher struct{}
+
+// Publish discards the event.
+func (NopPublisher) Publish(ctx context.Context, event Event) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryHub delivers events within a single process. It suits single-node deployments.
+type MemoryHub struct {
+	broker      *broker
+	mu          sync.Mutex
+	history     map[string][]Event // By restaurant, oldest first
+	historySize int
+}
+
+// NewMemoryHub creates a new instance of MemoryHub.
+func NewMemoryHub(historySize int) *MemoryHub {
+	return &MemoryHub{
+		broker:      newBroker(),
+		history:     make(map[string][]Event),
+		historySize: historySize,
+	}
+}
+
+// Publish records the event in the restaurant's history and delivers it.
+func (h *MemoryHub) Publish(ctx context.Context, event Event) error {
+	h.mu.Lock()
+	history := append(h.history[event.RestaurantID], event)
+	if len(history) > h.historySize {
+		history = history[len(history)-h.historySize:]
+	}
+	h.history[event.RestaurantID] = history
+	h.mu.Unlock()
+
+	h.broker.deliver(event)
+	return nil
+}
+
+// Subscribe streams the subscriber's events, replaying those after lastEventID.
+func (h *MemoryHub) Subscribe(ctx context.Context, subscriber Subscriber, lastEventID string) (*Subscription, error) {
+	sub := h.broker.add(subscriber)
+	h.mu.Lock()
+	replay := replayAfter(h.history[subscriber.RestaurantID], subscriber, lastEventID)
+	h.mu.Unlock()
+	return h.broker.stream(ctx, sub, replay), nil
+}
@@ -0,0 +1,31 @@
+package realtime
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	realtimeSubscribers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "realtime_subscribers",
+			Help: "Number of SSE and WebSocket subscribers connected to this process.",
+		},
+	)
+	realtimeEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "realtime_events_total",
+			Help: "Number of events fanned out by this process, by type.",
+		},
+		[]string{"type"},
+	)
+	realtimeDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "realtime_dropped_subscribers_total",
+			Help: "Number of subscribers disconnected for falling behind.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(realtimeSubscribers, realtimeEvents, realtimeDropped)
+}
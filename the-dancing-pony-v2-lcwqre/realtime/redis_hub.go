@@ -0,0 +1,121 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// redisChannelPrefix prefixes the pub/sub channel of each restaurant.
+	redisChannelPrefix = "realtime:events:"
+
+	// redisHistoryPrefix prefixes the list holding each restaurant's recent events, newest first.
+	redisHistoryPrefix = "realtime:history:"
+
+	// redisHistoryTTL expires the history of restaurants without recent events.
+	redisHistoryTTL = 24 * time.Hour
+)
+
+// RedisHub delivers events across replicas through Redis pub/sub. Each replica holds a
+// single pattern subscription and fans events out to its own subscribers.
+type RedisHub struct {
+	broker      *broker
+	client      *redis.Client
+	historySize int
+}
+
+// NewRedisHub creates a new instance of RedisHub and starts receiving events. An
+// unreachable Redis is logged; the client keeps reconnecting in the background.
+func NewRedisHub(ctx context.Context, cfg Config) (*RedisHub, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        cfg.RedisAddr,
+		Password:    cfg.RedisPassword,
+		DialTimeout: time.Second,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Warn().Err(err).Str("addr", cfg.RedisAddr).Msg("Redis is unreachable, realtime events will resume once it is back")
+	}
+
+	hub := &RedisHub{broker: newBroker(), client: client, historySize: cfg.HistorySize}
+	go hub.receive(context.Background())
+	return hub, nil
+}
+
+// Publish stores the event in the restaurant's history and broadcasts it to every replica.
+func (h *RedisHub) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(wireEvent{Event: event, Audience: event.Audience, CustomerID: event.CustomerID})
+	if err != nil {
+		return fmt.Errorf("error encoding event: %w", err)
+	}
+	historyKey := redisHistoryPrefix + event.RestaurantID
+	_, err = h.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, historyKey, payload)
+		pipe.LTrim(ctx, historyKey, 0, int64(h.historySize-1))
+		pipe.Expire(ctx, historyKey, redisHistoryTTL)
+		pipe.Publish(ctx, redisChannelPrefix+event.RestaurantID, payload)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error publishing event: %w", err)
+	}
+	return nil
+}
+
+// Subscribe streams the subscriber's events, replaying those after lastEventID.
+func (h *RedisHub) Subscribe(ctx context.Context, subscriber Subscriber, lastEventID string) (*Subscription, error) {
+	sub := h.broker.add(subscriber)
+	var replay []Event
+	if lastEventID != "" {
+		history, err := h.history(ctx, subscriber.RestaurantID)
+		if err != nil {
+			h.broker.remove(sub)
+			return nil, err
+		}
+		replay = replayAfter(history, subscriber, lastEventID)
+	}
+	return h.broker.stream(ctx, sub, replay), nil
+}
+
+// history reads a restaurant's recent events, oldest first.
+func (h *RedisHub) history(ctx context.Context, restaurantId string) ([]Event, error) {
+	payloads, err := h.client.LRange(ctx, redisHistoryPrefix+restaurantId, 0, int64(h.historySize-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error reading event history: %w", err)
+	}
+	events := make([]Event, 0, len(payloads))
+	for i := len(payloads) - 1; i >= 0; i-- {
+		if event, ok := decodeEvent(payloads[i]); ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// receive delivers the events broadcast by every replica to this replica's subscribers.
+func (h *RedisHub) receive(ctx context.Context) {
+	pubsub := h.client.PSubscribe(ctx, redisChannelPrefix+"*")
+	defer pubsub.Close()
+	for message := range pubsub.Channel() {
+		if event, ok := decodeEvent(message.Payload); ok {
+			h.broker.deliver(event)
+		}
+	}
+}
+
+// decodeEvent decodes an event as passed between replicas.
+func decodeEvent(payload string) (Event, bool) {
+	var wire wireEvent
+	if err := json.Unmarshal([]byte(payload), &wire); err != nil {
+		log.Warn().Err(err).Msg("Discarding malformed realtime event")
+		return Event{}, false
+	}
+	event := wire.Event
+	event.Audience = wire.Audience
+	event.CustomerID = wire.CustomerID
+	return event, true
+}
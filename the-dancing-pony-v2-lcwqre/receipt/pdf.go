@@ -0,0 +1,98 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Page geometry of PDF receipts, in points: A4 paper with the receipt set in 10 point
+// Courier, one of the standard fonts every PDF reader has, so nothing is embedded.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 56
+	fontSize   = 10
+	leading    = 13
+)
+
+// linesPerPage is the number of receipt lines that fit on a page.
+const linesPerPage = (pageHeight - 2*margin) / leading
+
+// PDF renders a receipt as a PDF document, starting a new page when it runs longer than
+// one. Text is encoded with WinAnsiEncoding; characters it cannot represent print as "?".
+func PDF(r Receipt) []byte {
+	lines := r.lines()
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects 1 to 3 are the catalog, the page tree and the font; each page then takes
+	// two objects, the page and its content stream.
+	var objects []string
+	kids := make([]byte, 0, len(pages)*8)
+	for i := range pages {
+		kids = fmt.Appendf(kids, "%d 0 R ", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content bytes.Buffer
+		// The ' operator moves down a line before showing each line of text.
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, pageHeight-margin)
+		for _, line := range page {
+			content.WriteString("(")
+			content.Write(pdfString(line))
+			content.WriteString(") '\n")
+		}
+		content.WriteString("ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.Bytes()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// winAnsi maps the characters of WinAnsiEncoding outside Latin-1 that receipts are likely
+// to contain, mostly typographic punctuation from dish names.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// pdfString encodes a line as the body of a PDF literal string in WinAnsiEncoding.
+func pdfString(line string) []byte {
+	encoded := make([]byte, 0, len(line))
+	for _, r := range line {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			encoded = append(encoded, '\\', byte(r))
+		case winAnsi[r] != 0:
+			encoded = append(encoded, winAnsi[r])
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
@@ -0,0 +1,209 @@
+// Package receipt renders order receipts and tax invoices as plain text and PDF. Both
+// formats share one fixed-width layout, so a printed PDF reads exactly like the text
+// receipt a till printer would produce.
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Width is the number of characters per line of a receipt.
+const Width = 48
+
+// labelWidth is the width of the label column of details; it leaves room for a UUID.
+const labelWidth = 12
+
+// Receipt is the content of a receipt, formatted by Text and PDF.
+type Receipt struct {
+	Title       string   // Such as "RECEIPT" or "TAX INVOICE"
+	Header      []string // Seller name, address and tax number, centred
+	Details     []Detail // Invoice number, order, dates and so on
+	Currency    string
+	Items       []Item
+	Subtotal    float64
+	Adjustments []Adjustment // Discounts, shown as negative amounts
+	Total       float64
+	Taxes       []Tax
+	Notes       []string // Printed at the bottom, wrapped
+}
+
+// Detail is a labelled value printed under the header. Labels longer than 11 characters
+// run into their value.
+type Detail struct {
+	Label string
+	Value string
+}
+
+// Item is a line of the order.
+type Item struct {
+	Name      string
+	Quantity  int
+	UnitPrice float64
+	Amount    float64
+	TaxCode   string // Code of the Tax the item was taxed at, empty for untaxed orders
+}
+
+// Adjustment is an amount added to or taken off the subtotal.
+type Adjustment struct {
+	Label  string
+	Amount float64
+}
+
+// Tax is the tax charged at one rate.
+type Tax struct {
+	Code  string
+	Name  string
+	Rate  float64 // Percent
+	Net   float64
+	Tax   float64
+	Gross float64
+}
+
+// lines lays a receipt out as lines of at most Width characters.
+func (r Receipt) lines() []string {
+	rule := strings.Repeat("-", Width)
+	var lines []string
+	for _, header := range r.Header {
+		for _, line := range wrap(header, Width) {
+			lines = append(lines, centre(line))
+		}
+	}
+	if r.Title != "" {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, centre(r.Title))
+	}
+	lines = append(lines, rule)
+
+	if len(r.Details) > 0 {
+		for _, detail := range r.Details {
+			for i, value := range wrap(detail.Value, Width-labelWidth) {
+				label := ""
+				if i == 0 {
+					label = detail.Label
+				}
+				lines = append(lines, pad(label, labelWidth)+value)
+			}
+		}
+		lines = append(lines, rule)
+	}
+
+	for _, item := range r.Items {
+		code := item.TaxCode
+		if code != "" {
+			code = " " + code
+		}
+		amount := money(item.Amount) + code
+		name := wrap(fmt.Sprintf("%d x %s", item.Quantity, item.Name), Width-len(amount)-2)
+		for i, line := range name {
+			if i == 0 {
+				lines = append(lines, columns(line, amount))
+			} else {
+				lines = append(lines, "    "+line)
+			}
+		}
+		if item.Quantity > 1 {
+			lines = append(lines, "    @ "+money(item.UnitPrice))
+		}
+	}
+	lines = append(lines, rule)
+
+	lines = append(lines, columns("Subtotal", money(r.Subtotal)))
+	for _, adjustment := range r.Adjustments {
+		lines = append(lines, columns(truncate(adjustment.Label, Width-14), money(adjustment.Amount)))
+	}
+	lines = append(lines, columns(strings.TrimSpace("TOTAL "+r.Currency), money(r.Total)))
+
+	if len(r.Taxes) > 0 {
+		lines = append(lines, rule)
+		lines = append(lines, fmt.Sprintf("%-5s%7s%12s%12s%12s", "Code", "Rate", "Net", "Tax", "Gross"))
+		for _, tax := range r.Taxes {
+			lines = append(lines, fmt.Sprintf("%-5s%7s%12s%12s%12s", tax.Code, percent(tax.Rate), money(tax.Net), money(tax.Tax), money(tax.Gross)))
+		}
+		for _, tax := range r.Taxes {
+			lines = append(lines, truncate(tax.Code+" = "+tax.Name, Width))
+		}
+	}
+
+	if len(r.Notes) > 0 {
+		lines = append(lines, rule)
+		for _, note := range r.Notes {
+			lines = append(lines, wrap(note, Width)...)
+		}
+	}
+	return lines
+}
+
+// money formats an amount with two decimals.
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// percent formats a rate without trailing zeros, such as "20%" or "5.5%".
+func percent(rate float64) string {
+	s := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", rate), "0"), ".")
+	return s + "%"
+}
+
+// columns puts left at the start of a line and right at its end.
+func columns(left string, right string) string {
+	gap := Width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if gap < 1 {
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// centre centres a line.
+func centre(line string) string {
+	return strings.Repeat(" ", (Width-utf8.RuneCountInString(line))/2) + line
+}
+
+// pad pads s with spaces to n characters.
+func pad(s string, n int) string {
+	if count := utf8.RuneCountInString(s); count < n {
+		return s + strings.Repeat(" ", n-count)
+	}
+	return s
+}
+
+// truncate cuts s to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// wrap breaks text into lines of at most n characters, at spaces where it can.
+func wrap(text string, n int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > n {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:n]))
+			word = string(runes[n:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= n:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,8 @@
+package receipt
+
+import "strings"
+
+// Text renders a receipt as UTF-8 plain text.
+func Text(r Receipt) []byte {
+	return []byte(strings.Join(r.lines(), "\n") + "\n")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	cache "the-dancing-pony-v2-lcwqre/caching"
+	"the-dancing-pony-v2-lcwqre/repository"
+)
+
+const reindexSearchUsage = `usage: thedancingpony reindex-search
+
+Rebuilds the indexes of the dish and restaurant searches without blocking writes,
+then drops the cached search results so they are computed again.
+`
+
+// runReindexSearch runs the reindex-search command.
+func runReindexSearch(settings config.Settings, args []string) int {
+	if code, ok := parseFlags(newFlagSet("reindex-search", reindexSearchUsage), args); !ok {
+		return code
+	}
+
+	db, ok := openDatabase(settings)
+	if !ok {
+		return 1
+	}
+	maintenanceRepository := repository.NewMaintenanceRepositoryImpl(db)
+	if err := maintenanceRepository.ReindexSearch(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	// Dish searches are cached with the other dish entries of their restaurant
+	restaurantIds, err := maintenanceRepository.FindRestaurantIds()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	namespaces := []string{cache.RestaurantsNamespace}
+	for _, restaurantId := range restaurantIds {
+		namespaces = append(namespaces, cache.RestaurantDishesNamespace(restaurantId.String()))
+	}
+	appCache, err := newCache(settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if err := appCache.Invalidate(context.Background(), namespaces...); err != nil {
+		fmt.Fprintln(os.Stderr, "error: dropping cached search results:", err)
+		return 1
+	}
+
+	fmt.Printf("Search indexes rebuilt, cached results of %d restaurants dropped\n", len(restaurantIds))
+	return 0
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+)
+
+const replayOutboxUsage = `usage: thedancingpony replay-outbox [flags]
+
+Lists the outbox events consumers gave up on after their attempts ran out, or marks them
+to be handed to their consumer again by the outbox relay of a running server or worker.
+Without -consumer and -event every dead letter is replayed.
+
+Flags:
+`
+
+// runReplayOutbox runs the replay-outbox command.
+func runReplayOutbox(settings config.Settings, args []string) int {
+	flags := newFlagSet("replay-outbox", replayOutboxUsage)
+	list := flags.Bool("list", false, "list the dead letters instead of replaying them")
+	consumer := flags.String("consumer", "", "only the dead letters of this consumer")
+	event := flags.String("event", "", "only the dead letter of this event ID")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	var eventId uuid.UUID
+	if *event != "" {
+		var err error
+		if eventId, err = uuid.Parse(*event); err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid event ID %q\n", *event)
+			return 2
+		}
+	}
+
+	db, ok := openDatabase(settings)
+	if !ok {
+		return 1
+	}
+	outboxRepository := repository.NewOutboxRepositoryImpl(db)
+
+	if *list {
+		deadLetters, err := outboxRepository.FindDeadLetters(*consumer)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CONSUMER\tEVENT\tTYPE\tATTEMPTS\tREPLAY REQUESTED\tLAST ERROR")
+		for _, deadLetter := range deadLetters {
+			if eventId != uuid.Nil && deadLetter.EventID != eventId {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%t\t%s\n", deadLetter.Consumer, deadLetter.EventID, deadLetter.Type,
+				deadLetter.Attempts, deadLetter.ReplayRequestedAt != nil, deadLetter.LastError)
+		}
+		w.Flush()
+		return 0
+	}
+
+	requested, err := outboxRepository.RequestReplay(*consumer, eventId)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	fmt.Printf("%d dead letters marked for replay\n", requested)
+	return 0
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// PlaceFunc builds the order for a locked cart's items from the published dishes among them.
+// Returning an error aborts the checkout and leaves the cart untouched.
+type PlaceFunc func(cart model.Cart, items []model.CartItem, dishes []model.Dish) (model.Order, error)
+
+// CartsRepository defines the data operations for customer and guest carts.
+type CartsRepository interface {
+	// FindByCustomer retrieves a customer's cart with its items. A customer without
+	// a cart gets an empty cart whose ID is uuid.Nil.
+	FindByCustomer(customerId uuid.UUID, restaurantId string) (model.Cart, error)
+
+	// FindByGuestToken retrieves a guest cart with its items, or an empty cart whose ID is uuid.Nil.
+	FindByGuestToken(guestToken string, restaurantId string) (model.Cart, error)
+
+	// FindOrCreate retrieves the cart of the customer or guest named in cart, creating it when missing.
+	FindOrCreate(cart model.Cart) (model.Cart, error)
+
+	// AddItem adds a dish to a cart, or raises its quantity up to maxQuantity when already there.
+	AddItem(item model.CartItem, maxQuantity int) error
+
+	// UpdateItem writes the given fields to an item of a cart.
+	UpdateItem(cartId uuid.UUID, itemId uuid.UUID, fields map[string]interface{}) error
+
+	// RemoveItem removes an item from a cart.
+	RemoveItem(cartId uuid.UUID, itemId uuid.UUID) error
+
+	// UpdatePrices records the prices last shown for the given items.
+	UpdatePrices(prices map[uuid.UUID]float64) error
+
+	// SetCoupon records the coupon code entered for a cart; nil removes it.
+	SetCoupon(cartId uuid.UUID, code *string) error
+
+	// Merge moves the items of a guest cart into a customer cart and deletes the guest cart.
+	Merge(guestCartId uuid.UUID, customerCartId uuid.UUID, maxQuantity int) error
+
+	// Checkout locks a cart, builds its order with place, stores the order and empties
+	// the cart, coupon included, in one transaction.
+	Checkout(cartId uuid.UUID, restaurantId string, place PlaceFunc) (model.Order, error)
+}
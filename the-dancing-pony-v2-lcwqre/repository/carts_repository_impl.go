@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCartEmpty is returned when checking out a cart without items.
+var ErrCartEmpty = errors.New("cart is empty")
+
+// CartsRepositoryImpl implements CartsRepository interface.
+type CartsRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewCartsRepositoryImpl creates a new instance of CartsRepositoryImpl.
+func NewCartsRepositoryImpl(db *gorm.DB) CartsRepository {
+	return &CartsRepositoryImpl{Db: db}
+}
+
+// FindByCustomer retrieves a customer's cart with its items.
+func (repo *CartsRepositoryImpl) FindByCustomer(customerId uuid.UUID, restaurantId string) (model.Cart, error) {
+	return repo.findCart(repo.Db.Where("customer_id = ? AND restaurant_id = ?", customerId, restaurantId), restaurantId)
+}
+
+// FindByGuestToken retrieves a guest cart with its items.
+func (repo *CartsRepositoryImpl) FindByGuestToken(guestToken string, restaurantId string) (model.Cart, error) {
+	return repo.findCart(repo.Db.Where("guest_token = ? AND restaurant_id = ?", guestToken, restaurantId), restaurantId)
+}
+
+// FindOrCreate retrieves the cart of the customer or guest named in cart, creating it when missing.
+// Concurrent first requests of a customer end up with the same cart.
+func (repo *CartsRepositoryImpl) FindOrCreate(cart model.Cart) (model.Cart, error) {
+	restaurantId := cart.RestaurantID.String()
+	cart.ID = uuid.New()
+	result := repo.Db.Clauses(clause.OnConflict{DoNothing: true}).Omit("Items").Create(&cart)
+	if result.Error != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(result.Error).
+			Msg("Error creating cart")
+		return model.Cart{}, fmt.Errorf("error creating cart: %w", result.Error)
+	}
+	if cart.CustomerID != nil {
+		return repo.FindByCustomer(*cart.CustomerID, restaurantId)
+	}
+	return repo.FindByGuestToken(*cart.GuestToken, restaurantId)
+}
+
+// AddItem adds a dish to a cart, or raises its quantity up to maxQuantity when already there.
+func (repo *CartsRepositoryImpl) AddItem(item model.CartItem, maxQuantity int) error {
+	item.ID = uuid.New()
+	result := repo.Db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "cart_id"}, {Name: "dish_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   gorm.Expr("LEAST(cart_items.quantity + EXCLUDED.quantity, ?)", maxQuantity),
+			"name":       gorm.Expr("EXCLUDED.name"),
+			"unit_price": gorm.Expr("EXCLUDED.unit_price"),
+			"notes":      gorm.Expr("CASE WHEN EXCLUDED.notes = '' THEN cart_items.notes ELSE EXCLUDED.notes END"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&item)
+	if result.Error != nil {
+		log.Error().
+			Str("cart_id", item.CartID.String()).
+			Str("dish_id", item.DishID.String()).
+			Err(result.Error).
+			Msg("Error adding cart item")
+		return fmt.Errorf("error adding cart item: %w", result.Error)
+	}
+	return nil
+}
+
+// UpdateItem writes the given fields to an item of a cart.
+func (repo *CartsRepositoryImpl) UpdateItem(cartId uuid.UUID, itemId uuid.UUID, fields map[string]interface{}) error {
+	result := repo.Db.Model(&model.CartItem{}).
+		Where("id = ? AND cart_id = ?", itemId, cartId).
+		Updates(fields)
+	if result.Error != nil {
+		log.Error().
+			Str("cart_item_id", itemId.String()).
+			Err(result.Error).
+			Msg("Error updating cart item")
+		return fmt.Errorf("error updating cart item: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cart item with ID %s not found: %w", itemId, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// RemoveItem removes an item from a cart.
+func (repo *CartsRepositoryImpl) RemoveItem(cartId uuid.UUID, itemId uuid.UUID) error {
+	result := repo.Db.Unscoped().
+		Where("id = ? AND cart_id = ?", itemId, cartId).
+		Delete(&model.CartItem{})
+	if result.Error != nil {
+		log.Error().
+			Str("cart_item_id", itemId.String()).
+			Err(result.Error).
+			Msg("Error removing cart item")
+		return fmt.Errorf("error removing cart item: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cart item with ID %s not found: %w", itemId, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// UpdatePrices records the prices last shown for the given items.
+func (repo *CartsRepositoryImpl) UpdatePrices(prices map[uuid.UUID]float64) error {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		for itemId, price := range prices {
+			if err := tx.Model(&model.CartItem{}).Where("id = ?", itemId).Update("UnitPrice", price).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Int("items", len(prices)).
+			Err(err).
+			Msg("Error updating cart prices")
+		return fmt.Errorf("error updating cart prices: %w", err)
+	}
+	return nil
+}
+
+// SetCoupon records the coupon code entered for a cart; nil removes it.
+func (repo *CartsRepositoryImpl) SetCoupon(cartId uuid.UUID, code *string) error {
+	result := repo.Db.Model(&model.Cart{}).Where("id = ?", cartId).Update("CouponCode", code)
+	if result.Error != nil {
+		log.Error().
+			Str("cart_id", cartId.String()).
+			Err(result.Error).
+			Msg("Error setting cart coupon")
+		return fmt.Errorf("error setting cart coupon: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cart with ID %s not found: %w", cartId, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// Merge moves the items of a guest cart into a customer cart and deletes the guest cart.
+// Dishes in both carts add up their quantities and keep the guest notes when set. The
+// guest's coupon moves too unless the customer already entered one.
+func (repo *CartsRepositoryImpl) Merge(guestCartId uuid.UUID, customerCartId uuid.UUID, maxQuantity int) error {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var guestItems []model.CartItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("cart_id = ?", guestCartId).Find(&guestItems).Error; err != nil {
+			return err
+		}
+		txRepo := &CartsRepositoryImpl{Db: tx}
+		for _, item := range guestItems {
+			item.CartID = customerCartId
+			if err := txRepo.AddItem(item, maxQuantity); err != nil {
+				return err
+			}
+		}
+		var guestCart model.Cart
+		if err := tx.Where("id = ?", guestCartId).First(&guestCart).Error; err != nil {
+			return err
+		}
+		if guestCart.CouponCode != nil {
+			if err := tx.Model(&model.Cart{}).Where("id = ? AND coupon_code IS NULL", customerCartId).
+				Update("CouponCode", guestCart.CouponCode).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("cart_id = ?", guestCartId).Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", guestCartId).Delete(&model.Cart{}).Error
+	})
+	if err != nil {
+		log.Error().
+			Str("guest_cart_id", guestCartId.String()).
+			Str("cart_id", customerCartId.String()).
+			Err(err).
+			Msg("Error merging guest cart")
+		return fmt.Errorf("error merging guest cart: %w", err)
+	}
+	log.Info().
+		Str("guest_cart_id", guestCartId.String()).
+		Str("cart_id", customerCartId.String()).
+		Msg("Guest cart merged successfully")
+	return nil
+}
+
+// Checkout locks a cart, builds its order with place, stores the order and empties
+// the cart, coupon included, in one transaction.
+func (repo *CartsRepositoryImpl) Checkout(cartId uuid.UUID, restaurantId string, place PlaceFunc) (model.Order, error) {
+	var created model.Order
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var cart model.Cart
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ?", cartId, restaurantId).
+			First(&cart)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var items []model.CartItem
+		if err := tx.Where("cart_id = ?", cartId).Order("created_at ASC").Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return ErrCartEmpty
+		}
+
+		dishIds := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			dishIds = append(dishIds, item.DishID)
+		}
+		var dishes []model.Dish
+		if err := tx.Where("id IN ? AND restaurant_id = ? AND deleted_at IS NULL AND status = ?", dishIds, restaurantId, model.DishStatusPublished).
+			Find(&dishes).Error; err != nil {
+			return err
+		}
+
+		order, err := place(cart, items, dishes)
+		if err != nil {
+			return err
+		}
+		if created, err = createOrder(tx, order); err != nil {
+			return err
+		}
+		if err := tx.Model(&cart).Update("CouponCode", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("cart_id = ?", cartId).Delete(&model.CartItem{}).Error
+	})
+	if err != nil {
+		log.Error().
+			Str("cart_id", cartId.String()).
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error checking out cart")
+		return model.Order{}, err
+	}
+	log.Info().
+		Str("cart_id", cartId.String()).
+		Str("order_id", created.ID.String()).
+		Msg("Cart checked out successfully")
+	return created, nil
+}
+
+// findCart retrieves the cart matched by query with its items, or an empty cart without an ID.
+func (repo *CartsRepositoryImpl) findCart(query *gorm.DB, restaurantId string) (model.Cart, error) {
+	var cart model.Cart
+	result := query.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).First(&cart)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return model.Cart{}, nil
+		}
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(result.Error).
+			Msg("Error finding cart")
+		return model.Cart{}, fmt.Errorf("error finding cart: %w", result.Error)
+	}
+	return cart, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// DishChangesRepository defines the data operations for draft and scheduled dish changes.
+type DishChangesRepository interface {
+	// Create stores a new pending change.
+	Create(change model.DishChange) (model.DishChange, error)
+
+	// FindById retrieves a change by its ID for a specific restaurant.
+	FindById(changeId uuid.UUID, restaurantId string) (model.DishChange, error)
+
+	// FindByDish retrieves the draft and scheduled changes of a dish.
+	FindByDish(dishId uuid.UUID, restaurantId string) ([]model.DishChange, error)
+
+	// FindScheduledUntil retrieves the scheduled changes of a restaurant due on or before the given time.
+	FindScheduledUntil(restaurantId string, at time.Time) ([]model.DishChange, error)
+
+	// Schedule moves a change into the scheduled state for the given time.
+	Schedule(changeId uuid.UUID, restaurantId string, publishAt time.Time) (model.DishChange, error)
+
+	// Cancel discards a change that has not been applied yet.
+	Cancel(changeId uuid.UUID, restaurantId string) error
+
+	// Apply writes a change to its dish and marks it applied in one transaction.
+	Apply(changeId uuid.UUID, restaurantId string, at time.Time) (model.DishChange, error)
+
+	// ApplyDue applies up to limit scheduled changes that are due, across all restaurants.
+	ApplyDue(now time.Time, limit int) ([]model.DishChange, error)
+}
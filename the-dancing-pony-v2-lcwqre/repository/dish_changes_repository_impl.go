@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDishChangeNotPending is returned when a change has already been applied or cancelled.
+var ErrDishChangeNotPending = errors.New("dish change is no longer pending")
+
+// DishChangesRepositoryImpl implements DishChangesRepository interface.
+type DishChangesRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewDishChangesRepositoryImpl creates a new instance of DishChangesRepositoryImpl.
+func NewDishChangesRepositoryImpl(db *gorm.DB) DishChangesRepository {
+	return &DishChangesRepositoryImpl{Db: db}
+}
+
+// Create stores a new pending change.
+func (repo *DishChangesRepositoryImpl) Create(change model.DishChange) (model.DishChange, error) {
+	change.ID = uuid.New()
+	result := repo.Db.Create(&change)
+	if result.Error != nil {
+		log.Error().
+			Str("dish_id", change.DishID.String()).
+			Err(result.Error).
+			Msg("Error creating dish change")
+		return model.DishChange{}, result.Error
+	}
+	log.Info().
+		Str("change_id", change.ID.String()).
+		Str("dish_id", change.DishID.String()).
+		Str("status", change.Status).
+		Msg("Dish change created successfully")
+	return change, nil
+}
+
+// FindById retrieves a change by its ID for a specific restaurant.
+func (repo *DishChangesRepositoryImpl) FindById(changeId uuid.UUID, restaurantId string) (model.DishChange, error) {
+	var change model.DishChange
+	result := repo.Db.Where("id = ? AND restaurant_id = ?", changeId, restaurantId).First(&change)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			log.Warn().
+				Str("change_id", changeId.String()).
+				Str("restaurant_id", restaurantId).
+				Msg("Dish change not found")
+			return model.DishChange{}, fmt.Errorf("dish change with ID %s not found for restaurant %s: %w", changeId, restaurantId, result.Error)
+		}
+		log.Error().
+			Str("change_id", changeId.String()).
+			Err(result.Error).
+			Msg("Error finding dish change")
+		return model.DishChange{}, fmt.Errorf("error finding dish change: %w", result.Error)
+	}
+	return change, nil
+}
+
+// FindByDish retrieves the draft and scheduled changes of a dish.
+func (repo *DishChangesRepositoryImpl) FindByDish(dishId uuid.UUID, restaurantId string) ([]model.DishChange, error) {
+	var changes []model.DishChange
+	result := repo.Db.Where("dish_id = ? AND restaurant_id = ? AND status IN ?", dishId, restaurantId,
+		[]string{model.DishChangeStatusDraft, model.DishChangeStatusScheduled}).
+		Order("created_at ASC").
+		Find(&changes)
+	if result.Error != nil {
+		log.Error().
+			Str("dish_id", dishId.String()).
+			Err(result.Error).
+			Msg("Error finding dish changes")
+		return nil, fmt.Errorf("error finding dish changes: %w", result.Error)
+	}
+	return changes, nil
+}
+
+// FindScheduledUntil retrieves the scheduled changes of a restaurant due on or before the given time.
+func (repo *DishChangesRepositoryImpl) FindScheduledUntil(restaurantId string, at time.Time) ([]model.DishChange, error) {
+	var changes []model.DishChange
+	result := repo.Db.Where("restaurant_id = ? AND status = ? AND publish_at <= ?", restaurantId, model.DishChangeStatusScheduled, at).
+		Order("publish_at ASC, created_at ASC").
+		Find(&changes)
+	if result.Error != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(result.Error).
+			Msg("Error finding scheduled dish changes")
+		return nil, fmt.Errorf("error finding scheduled dish changes: %w", result.Error)
+	}
+	return changes, nil
+}
+
+// Schedule moves a change into the scheduled state for the given time.
+func (repo *DishChangesRepositoryImpl) Schedule(changeId uuid.UUID, restaurantId string, publishAt time.Time) (model.DishChange, error) {
+	result := repo.Db.Model(&model.DishChange{}).
+		Where("id = ? AND restaurant_id = ? AND status IN ?", changeId, restaurantId,
+			[]string{model.DishChangeStatusDraft, model.DishChangeStatusScheduled}).
+		Updates(map[string]interface{}{
+			"Status":    model.DishChangeStatusScheduled,
+			"PublishAt": publishAt,
+		})
+	if result.Error != nil {
+		log.Error().
+			Str("change_id", changeId.String()).
+			Err(result.Error).
+			Msg("Error scheduling dish change")
+		return model.DishChange{}, fmt.Errorf("error scheduling dish change: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return model.DishChange{}, ErrDishChangeNotPending
+	}
+	return repo.FindById(changeId, restaurantId)
+}
+
+// Cancel discards a change that has not been applied yet.
+func (repo *DishChangesRepositoryImpl) Cancel(changeId uuid.UUID, restaurantId string) error {
+	result := repo.Db.Model(&model.DishChange{}).
+		Where("id = ? AND restaurant_id = ? AND status IN ?", changeId, restaurantId,
+			[]string{model.DishChangeStatusDraft, model.DishChangeStatusScheduled}).
+		Update("Status", model.DishChangeStatusCancelled)
+	if result.Error != nil {
+		log.Error().
+			Str("change_id", changeId.String()).
+			Err(result.Error).
+			Msg("Error cancelling dish change")
+		return fmt.Errorf("error cancelling dish change: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDishChangeNotPending
+	}
+	log.Info().
+		Str("change_id", changeId.String()).
+		Msg("Dish change cancelled successfully")
+	return nil
+}
+
+// Apply writes a change to its dish and marks it applied in one transaction.
+func (repo *DishChangesRepositoryImpl) Apply(changeId uuid.UUID, restaurantId string, at time.Time) (model.DishChange, error) {
+	var applied model.DishChange
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var change model.DishChange
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ?", changeId, restaurantId).
+			First(&change)
+		if result.Error != nil {
+			return result.Error
+		}
+		if change.Status != model.DishChangeStatusDraft && change.Status != model.DishChangeStatusScheduled {
+			return ErrDishChangeNotPending
+		}
+		if err := applyChange(tx, &change, at); err != nil {
+			return err
+		}
+		applied = change
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Str("change_id", changeId.String()).
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error applying dish change")
+		return model.DishChange{}, err
+	}
+	log.Info().
+		Str("change_id", changeId.String()).
+		Str("dish_id", applied.DishID.String()).
+		Msg("Dish change applied successfully")
+	return applied, nil
+}
+
+// ApplyDue applies up to limit scheduled changes that are due, across all restaurants.
+// Rows are locked with SKIP LOCKED so several replicas can run the scheduler at once.
+func (repo *DishChangesRepositoryImpl) ApplyDue(now time.Time, limit int) ([]model.DishChange, error) {
+	var applied []model.DishChange
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var due []model.DishChange
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND publish_at <= ?", model.DishChangeStatusScheduled, now).
+			Order("publish_at ASC, created_at ASC").
+			Limit(limit).
+			Find(&due)
+		if result.Error != nil {
+			return result.Error
+		}
+		for i := range due {
+			if err := applyChange(tx, &due[i], *due[i].PublishAt); err != nil {
+				return err
+			}
+		}
+		applied = due
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error applying due dish changes")
+		return nil, fmt.Errorf("error applying due dish changes: %w", err)
+	}
+	return applied, nil
+}
+
+// applyChange writes a locked change to its dish and marks it applied.
+func applyChange(tx *gorm.DB, change *model.DishChange, at time.Time) error {
+	result := tx.Model(&model.Dish{}).
+		Where("id = ? AND restaurant_id = ? AND deleted_at IS NULL", change.DishID, change.RestaurantID).
+		Updates(change.Fields(at))
+	if result.Error != nil {
+		return fmt.Errorf("error updating dish: %w", result.Error)
+	}
+
+	now := time.Now()
+	status := model.DishChangeStatusApplied
+	if result.RowsAffected == 0 {
+		// The dish was deleted after the change was drafted.
+		status = model.DishChangeStatusCancelled
+	}
+	if err := tx.Model(change).Updates(map[string]interface{}{
+		"Status":    status,
+		"AppliedAt": now,
+	}).Error; err != nil {
+		return fmt.Errorf("error marking dish change applied: %w", err)
+	}
+	change.Status = status
+	change.AppliedAt = &now
+	return nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// DishImagesRepository defines the data operations for dish image galleries and presigned uploads.
+type DishImagesRepository interface {
+	// FindByDish retrieves the gallery of a dish ordered by position.
+	FindByDish(dishId uuid.UUID, restaurantId string) ([]model.DishImage, error)
+
+	// Add appends an image to the end of a dish's gallery. When uploadId is set the
+	// processing upload is marked completed in the same transaction.
+	Add(image model.DishImage, uploadId *uuid.UUID) (model.DishImage, error)
+
+	// UpdateAltText changes the alt text of a gallery image.
+	UpdateAltText(imageId uuid.UUID, dishId uuid.UUID, restaurantId string, altText string) (model.DishImage, error)
+
+	// SetPrimary makes an image the primary image of its dish.
+	SetPrimary(imageId uuid.UUID, dishId uuid.UUID, restaurantId string) (model.DishImage, error)
+
+	// Reorder sets the gallery order; imageIds must list every image of the dish exactly once.
+	Reorder(dishId uuid.UUID, restaurantId string, imageIds []uuid.UUID) ([]model.DishImage, error)
+
+	// Delete removes an image from a gallery, promoting the next image if it was primary.
+	Delete(imageId uuid.UUID, dishId uuid.UUID, restaurantId string) error
+
+	// CreateUpload stores a pending presigned upload.
+	CreateUpload(upload model.DishImageUpload) (model.DishImageUpload, error)
+
+	// FindUpload retrieves an upload of a dish.
+	FindUpload(uploadId uuid.UUID, dishId uuid.UUID, restaurantId string) (model.DishImageUpload, error)
+
+	// StartUpload marks a pending upload confirmed and processing, with the alt text of
+	// the image it becomes.
+	StartUpload(uploadId uuid.UUID, dishId uuid.UUID, restaurantId string, altText string) error
+
+	// FailUpload marks a processing upload failed with the reason.
+	FailUpload(uploadId uuid.UUID, reason string) error
+
+	// FindExpiredUploads retrieves up to limit uploads that were never confirmed and
+	// expired before the given time.
+	FindExpiredUploads(before time.Time, limit int) ([]model.DishImageUpload, error)
+
+	// DeleteUploads deletes the given uploads.
+	DeleteUploads(uploadIds []uuid.UUID) error
+}
@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrImageOrderMismatch is returned when a reorder request does not list every image of the dish exactly once.
+	ErrImageOrderMismatch = errors.New("image order must list every image of the dish exactly once")
+
+	// ErrUploadAlreadyConfirmed is returned when a presigned upload is confirmed twice.
+	ErrUploadAlreadyConfirmed = errors.New("upload has already been confirmed")
+)
+
+// DishImagesRepositoryImpl implements DishImagesRepository interface.
+type DishImagesRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewDishImagesRepositoryImpl creates a new instance of DishImagesRepositoryImpl.
+func NewDishImagesRepositoryImpl(db *gorm.DB) DishImagesRepository {
+	return &DishImagesRepositoryImpl{Db: db}
+}
+
+// FindByDish retrieves the gallery of a dish ordered by position.
+func (repo *DishImagesRepositoryImpl) FindByDish(dishId uuid.UUID, restaurantId string) ([]model.DishImage, error) {
+	var images []model.DishImage
+	result := repo.Db.Where("dish_id = ? AND restaurant_id = ?", dishId, restaurantId).
+		Order("position ASC").
+		Find(&images)
+	if result.Error != nil {
+		log.Error().
+			Str("dish_id", dishId.String()).
+			Err(result.Error).
+			Msg("Error finding dish images")
+		return nil, fmt.Errorf("error finding dish images: %w", result.Error)
+	}
+	return images, nil
+}
+
+// Add appends an image to the end of a dish's gallery.
+// The dish row is locked so concurrent uploads get distinct positions.
+func (repo *DishImagesRepositoryImpl) Add(image model.DishImage, uploadId *uuid.UUID) (model.DishImage, error) {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		dish, err := lockDish(tx, image.DishID, image.RestaurantID.String())
+		if err != nil {
+			return err
+		}
+
+		image.ID = uuid.New()
+		if uploadId != nil {
+			result := tx.Model(&model.DishImageUpload{}).
+				Where("id = ? AND dish_id = ? AND status = ?", *uploadId, image.DishID, model.DishImageUploadStatusProcessing).
+				Updates(map[string]interface{}{
+					"status":   model.DishImageUploadStatusCompleted,
+					"image_id": image.ID,
+				})
+			if result.Error != nil {
+				return fmt.Errorf("error completing upload: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return ErrUploadAlreadyConfirmed
+			}
+		}
+
+		var existing []model.DishImage
+		if err := tx.Where("dish_id = ?", image.DishID).Order("position ASC").Find(&existing).Error; err != nil {
+			return fmt.Errorf("error finding dish images: %w", err)
+		}
+		if len(existing) == 0 && dish.Image != "" && dish.Image != image.Key {
+			// Carry the image set before galleries existed over as the first, primary entry
+			legacy := model.DishImage{
+				DishID:       dish.ID,
+				RestaurantID: dish.RestaurantID,
+				Key:          dish.Image,
+				IsPrimary:    true,
+				CreatedById:  dish.CreatedById,
+			}
+			legacy.ID = uuid.New()
+			if err := tx.Create(&legacy).Error; err != nil {
+				return fmt.Errorf("error migrating legacy dish image: %w", err)
+			}
+			existing = append(existing, legacy)
+		}
+
+		image.Position = len(existing)
+		image.IsPrimary = len(existing) == 0
+		if err := tx.Create(&image).Error; err != nil {
+			return fmt.Errorf("error creating dish image: %w", err)
+		}
+		if image.IsPrimary {
+			return setDishImage(tx, image.DishID, image.Key)
+		}
+		return touchDish(tx, image.DishID)
+	})
+	if err != nil {
+		log.Error().
+			Str("dish_id", image.DishID.String()).
+			Err(err).
+			Msg("Error adding dish image")
+		return model.DishImage{}, err
+	}
+	log.Info().
+		Str("image_id", image.ID.String()).
+		Str("dish_id", image.DishID.String()).
+		Msg("Dish image added successfully")
+	return image, nil
+}
+
+// UpdateAltText changes the alt text of a gallery image.
+func (repo *DishImagesRepositoryImpl) UpdateAltText(imageId uuid.UUID, dishId uuid.UUID, restaurantId string, altText string) (model.DishImage, error) {
+	var image model.DishImage
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.DishImage{}).
+			Where("id = ? AND dish_id = ? AND restaurant_id = ?", imageId, dishId, restaurantId).
+			Update("AltText", altText)
+		if result.Error != nil {
+			return fmt.Errorf("error updating dish image: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("dish image with ID %s not found: %w", imageId, gorm.ErrRecordNotFound)
+		}
+		if err := touchDish(tx, dishId); err != nil {
+			return err
+		}
+		var err error
+		image, err = repo.findImage(tx, imageId, dishId, restaurantId)
+		return err
+	})
+	if err != nil {
+		log.Error().
+			Str("image_id", imageId.String()).
+			Err(err).
+			Msg("Error updating dish image alt text")
+		return model.DishImage{}, err
+	}
+	return image, nil
+}
+
+// SetPrimary makes an image the primary image of its dish.
+func (repo *DishImagesRepositoryImpl) SetPrimary(imageId uuid.UUID, dishId uuid.UUID, restaurantId string) (model.DishImage, error) {
+	var image model.DishImage
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockDish(tx, dishId, restaurantId); err != nil {
+			return err
+		}
+		var err error
+		image, err = repo.findImage(tx, imageId, dishId, restaurantId)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.DishImage{}).
+			Where("dish_id = ? AND id <> ?", dishId, imageId).
+			Update("IsPrimary", false).Error; err != nil {
+			return fmt.Errorf("error clearing primary image: %w", err)
+		}
+		if err := tx.Model(&image).Update("IsPrimary", true).Error; err != nil {
+			return fmt.Errorf("error setting primary image: %w", err)
+		}
+		image.IsPrimary = true
+		return setDishImage(tx, dishId, image.Key)
+	})
+	if err != nil {
+		log.Error().
+			Str("image_id", imageId.String()).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error setting primary dish image")
+		return model.DishImage{}, err
+	}
+	return image, nil
+}
+
+// Reorder sets the gallery order; imageIds must list every image of the dish exactly once.
+func (repo *DishImagesRepositoryImpl) Reorder(dishId uuid.UUID, restaurantId string, imageIds []uuid.UUID) ([]model.DishImage, error) {
+	var images []model.DishImage
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockDish(tx, dishId, restaurantId); err != nil {
+			return err
+		}
+		if err := tx.Where("dish_id = ?", dishId).Find(&images).Error; err != nil {
+			return fmt.Errorf("error finding dish images: %w", err)
+		}
+
+		byId := make(map[uuid.UUID]*model.DishImage, len(images))
+		for i := range images {
+			byId[images[i].ID] = &images[i]
+		}
+		if len(imageIds) != len(images) {
+			return ErrImageOrderMismatch
+		}
+		ordered := make([]model.DishImage, 0, len(images))
+		for position, id := range imageIds {
+			image, ok := byId[id]
+			if !ok {
+				return ErrImageOrderMismatch
+			}
+			delete(byId, id)
+			if err := tx.Model(image).Update("Position", position).Error; err != nil {
+				return fmt.Errorf("error reordering dish images: %w", err)
+			}
+			image.Position = position
+			ordered = append(ordered, *image)
+		}
+		images = ordered
+		return touchDish(tx, dishId)
+	})
+	if err != nil {
+		log.Error().
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error reordering dish images")
+		return nil, err
+	}
+	return images, nil
+}
+
+// Delete removes an image from a gallery, promoting the next image if it was primary.
+// Stored objects are kept because content-addressed keys may be shared by other dishes.
+func (repo *DishImagesRepositoryImpl) Delete(imageId uuid.UUID, dishId uuid.UUID, restaurantId string) error {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockDish(tx, dishId, restaurantId); err != nil {
+			return err
+		}
+		image, err := repo.findImage(tx, imageId, dishId, restaurantId)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&image).Error; err != nil {
+			return fmt.Errorf("error deleting dish image: %w", err)
+		}
+
+		if !image.IsPrimary {
+			if _, err := compactImages(tx, dishId); err != nil {
+				return err
+			}
+			return touchDish(tx, dishId)
+		}
+		return promoteNextImage(tx, dishId)
+	})
+	if err != nil {
+		log.Error().
+			Str("image_id", imageId.String()).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error deleting dish image")
+		return err
+	}
+	log.Info().
+		Str("image_id", imageId.String()).
+		Str("dish_id", dishId.String()).
+		Msg("Dish image deleted successfully")
+	return nil
+}
+
+// CreateUpload stores a pending presigned upload.
+func (repo *DishImagesRepositoryImpl) CreateUpload(upload model.DishImageUpload) (model.DishImageUpload, error) {
+	upload.ID = uuid.New()
+	if err := repo.Db.Create(&upload).Error; err != nil {
+		log.Error().
+			Str("dish_id", upload.DishID.String()).
+			Err(err).
+			Msg("Error creating dish image upload")
+		return model.DishImageUpload{}, fmt.Errorf("error creating dish image upload: %w", err)
+	}
+	return upload, nil
+}
+
+// FindUpload retrieves an upload of a dish.
+func (repo *DishImagesRepositoryImpl) FindUpload(uploadId uuid.UUID, dishId uuid.UUID, restaurantId string) (model.DishImageUpload, error) {
+	var upload model.DishImageUpload
+	result := repo.Db.Where("id = ? AND dish_id = ? AND restaurant_id = ?", uploadId, dishId, restaurantId).First(&upload)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			log.Warn().
+				Str("upload_id", uploadId.String()).
+				Str("dish_id", dishId.String()).
+				Msg("Dish image upload not found")
+			return model.DishImageUpload{}, fmt.Errorf("upload with ID %s not found: %w", uploadId, result.Error)
+		}
+		return model.DishImageUpload{}, fmt.Errorf("error finding dish image upload: %w", result.Error)
+	}
+	return upload, nil
+}
+
+// StartUpload marks a pending upload confirmed and processing. Only one confirmation
+// of an upload can move it out of pending.
+func (repo *DishImagesRepositoryImpl) StartUpload(uploadId uuid.UUID, dishId uuid.UUID, restaurantId string, altText string) error {
+	result := repo.Db.Model(&model.DishImageUpload{}).
+		Where("id = ? AND dish_id = ? AND restaurant_id = ? AND status = ? AND confirmed_at IS NULL", uploadId, dishId, restaurantId, model.DishImageUploadStatusPending).
+		Updates(map[string]interface{}{
+			"status":       model.DishImageUploadStatusProcessing,
+			"alt_text":     altText,
+			"confirmed_at": time.Now(),
+		})
+	if result.Error != nil {
+		log.Error().
+			Str("upload_id", uploadId.String()).
+			Err(result.Error).
+			Msg("Error confirming dish image upload")
+		return fmt.Errorf("error confirming dish image upload: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadAlreadyConfirmed
+	}
+	return nil
+}
+
+// FailUpload marks a processing upload failed with the reason.
+func (repo *DishImagesRepositoryImpl) FailUpload(uploadId uuid.UUID, reason string) error {
+	result := repo.Db.Model(&model.DishImageUpload{}).
+		Where("id = ? AND status = ?", uploadId, model.DishImageUploadStatusProcessing).
+		Updates(map[string]interface{}{
+			"status": model.DishImageUploadStatusFailed,
+			"error":  truncateText(reason, 300),
+		})
+	if result.Error != nil {
+		log.Error().
+			Str("upload_id", uploadId.String()).
+			Err(result.Error).
+			Msg("Error failing dish image upload")
+		return fmt.Errorf("error failing dish image upload: %w", result.Error)
+	}
+	return nil
+}
+
+// FindExpiredUploads retrieves up to limit uploads that were never confirmed and
+// expired before the given time, oldest first.
+func (repo *DishImagesRepositoryImpl) FindExpiredUploads(before time.Time, limit int) ([]model.DishImageUpload, error) {
+	var uploads []model.DishImageUpload
+	result := repo.Db.Where("status = ? AND confirmed_at IS NULL AND expires_at < ?", model.DishImageUploadStatusPending, before).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&uploads)
+	if result.Error != nil {
+		return nil, fmt.Errorf("error finding expired dish image uploads: %w", result.Error)
+	}
+	return uploads, nil
+}
+
+// DeleteUploads deletes the given uploads.
+func (repo *DishImagesRepositoryImpl) DeleteUploads(uploadIds []uuid.UUID) error {
+	if len(uploadIds) == 0 {
+		return nil
+	}
+	if err := repo.Db.Where("id IN ?", uploadIds).Delete(&model.DishImageUpload{}).Error; err != nil {
+		return fmt.Errorf("error deleting dish image uploads: %w", err)
+	}
+	return nil
+}
+
+// findImage retrieves a gallery image scoped to its dish and restaurant.
+func (repo *DishImagesRepositoryImpl) findImage(db *gorm.DB, imageId uuid.UUID, dishId uuid.UUID, restaurantId string) (model.DishImage, error) {
+	var image model.DishImage
+	result := db.Where("id = ? AND dish_id = ? AND restaurant_id = ?", imageId, dishId, restaurantId).First(&image)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return model.DishImage{}, fmt.Errorf("dish image with ID %s not found: %w", imageId, result.Error)
+		}
+		return model.DishImage{}, fmt.Errorf("error finding dish image: %w", result.Error)
+	}
+	return image, nil
+}
+
+// compactImages closes the gaps left in the positions of a dish's gallery by deleted
+// images and returns the remaining images in order.
+func compactImages(tx *gorm.DB, dishId uuid.UUID) ([]model.DishImage, error) {
+	var remaining []model.DishImage
+	if err := tx.Where("dish_id = ?", dishId).Order("position ASC").Find(&remaining).Error; err != nil {
+		return nil, fmt.Errorf("error finding dish images: %w", err)
+	}
+	for position := range remaining {
+		if remaining[position].Position != position {
+			if err := tx.Model(&remaining[position]).Update("Position", position).Error; err != nil {
+				return nil, fmt.Errorf("error reordering dish images: %w", err)
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// promoteNextImage makes the first remaining gallery image primary once the primary
+// image was deleted, or clears the dish image when the gallery is empty.
+func promoteNextImage(tx *gorm.DB, dishId uuid.UUID) error {
+	remaining, err := compactImages(tx, dishId)
+	if err != nil {
+		return err
+	}
+	if len(remaining) == 0 {
+		return setDishImage(tx, dishId, "")
+	}
+	if err := tx.Model(&remaining[0]).Update("IsPrimary", true).Error; err != nil {
+		return fmt.Errorf("error promoting primary image: %w", err)
+	}
+	return setDishImage(tx, dishId, remaining[0].Key)
+}
+
+// lockDish locks a dish row for the rest of the transaction.
+func lockDish(tx *gorm.DB, dishId uuid.UUID, restaurantId string) (model.Dish, error) {
+	var dish model.Dish
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND restaurant_id = ?", dishId, restaurantId).
+		First(&dish)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return model.Dish{}, fmt.Errorf("dish with ID %s not found for restaurant %s: %w", dishId, restaurantId, result.Error)
+		}
+		return model.Dish{}, fmt.Errorf("error finding dish: %w", result.Error)
+	}
+	return dish, nil
+}
+
+// touchDish records a gallery change on the dish, which bumps its version and so its ETag.
+func touchDish(tx *gorm.DB, dishId uuid.UUID) error {
+	if err := tx.Model(&model.Dish{}).Where("id = ?", dishId).Update("UpdatedAt", time.Now()).Error; err != nil {
+		return fmt.Errorf("error updating dish: %w", err)
+	}
+	return nil
+}
+
+// setDishImage mirrors the primary gallery image to the dish.
+func setDishImage(tx *gorm.DB, dishId uuid.UUID, key string) error {
+	if err := tx.Model(&model.Dish{}).Where("id = ?", dishId).Update("Image", key).Error; err != nil {
+		return fmt.Errorf("error updating dish image: %w", err)
+	}
+	return nil
+}
@@ -1,14 +1,25 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"the-dancing-pony-v2-lcwqre/eventbus"
 	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrReviewNotPending is returned when a review that was already approved or rejected is moderated again.
+var ErrReviewNotPending = errors.New("review is no longer pending")
+
+// importBatchSize is the number of new dishes inserted per statement during a bulk import.
+const importBatchSize = 100
+
 // DishesRepositoryImpl implements DishesRepository interface.
 type DishesRepositoryImpl struct {
 	Db *gorm.DB
@@ -19,17 +30,34 @@ func NewDishesRepositoryImpl(db *gorm.DB) DishesRepository {
 	return &DishesRepositoryImpl{Db: db}
 }
 
-// Delete removes a dish from the database.
-func (repo *DishesRepositoryImpl) Delete(dishId uuid.UUID, restaurantId string) error {
-	var dish model.Dish
-	result := repo.Db.Where("id = ? AND restaurant_id = ? AND deleted_at IS NULL", dishId, restaurantId).Delete(&dish)
-	if result.Error != nil {
+// Delete removes a dish from the database and records a DishDeleted event.
+func (repo *DishesRepositoryImpl) Delete(dishId uuid.UUID, restaurantId string, versions []int64) error {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var dish model.Dish
+		query := tx.Where("id = ? AND restaurant_id = ? AND deleted_at IS NULL", dishId, restaurantId)
+		result := whereVersion(query, versions).Delete(&dish)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if len(versions) > 0 {
+				err := missOrConflict(tx, &model.Dish{}, "id = ? AND restaurant_id = ?", dishId, restaurantId)
+				return fmt.Errorf("error deleting dish %s: %w", dishId, err)
+			}
+			return nil
+		}
+		if err := tx.Unscoped().Where("id = ?", dishId).First(&dish).Error; err != nil {
+			return fmt.Errorf("error retrieving deleted dish: %w", err)
+		}
+		return appendDishEvent(tx, eventbus.DishDeleted, dish)
+	})
+	if err != nil {
 		log.Error().
 			Str("dish_id", dishId.String()).
 			Str("restaurant_id", restaurantId).
-			Err(result.Error).
+			Err(err).
 			Msg("Error deleting dish")
-		return result.Error
+		return err
 	}
 	log.Info().
 		Str("dish_id", dishId.String()).
@@ -38,43 +66,23 @@ func (repo *DishesRepositoryImpl) Delete(dishId uuid.UUID, restaurantId string)
 	return nil
 }
 
-// FindAll retrieves all dishes with pagination that are not soft-deleted for a specific restaurant.
-func (repo *DishesRepositoryImpl) FindAll(page, limit int, restaurantId string) ([]model.Dish, int, error) {
-	var dishes []model.Dish
-	var total int64
-
-	offset := (page - 1) * limit
-
-	// Fetch total count of dishes for the specified restaurant
-	countResult := repo.Db.Model(&model.Dish{}).
-		Where("restaurant_id = ? AND deleted_at IS NULL", restaurantId).
-		Count(&total)
-	if countResult.Error != nil {
+// FindAll retrieves a page of the published dishes of a restaurant.
+func (repo *DishesRepositoryImpl) FindAll(page pagination.Page, restaurantId string) ([]model.Dish, pagination.Result, error) {
+	query := repo.Db.Model(&model.Dish{}).
+		Where("restaurant_id = ? AND deleted_at IS NULL AND status = ?", restaurantId, model.DishStatusPublished)
+	dishes, result, err := findPage[model.Dish](query, page)
+	if err != nil {
 		log.Error().
 			Str("restaurant_id", restaurantId).
-			Err(countResult.Error).
-			Msg("Error counting total dishes")
-		return nil, 0, fmt.Errorf("error counting dishes: %w", countResult.Error)
-	}
-
-	// Fetch dishes with pagination for the specified restaurant
-	result := repo.Db.Where("restaurant_id = ? AND deleted_at IS NULL", restaurantId).
-		Limit(limit).
-		Offset(offset).
-		Find(&dishes)
-
-	if result.Error != nil {
-		log.Error().
-			Str("restaurant_id", restaurantId).
-			Err(result.Error).
+			Err(err).
 			Msg("Error finding dishes")
-		return nil, 0, fmt.Errorf("error finding dishes: %w", result.Error)
+		return nil, result, fmt.Errorf("error finding dishes: %w", err)
 	}
 
 	log.Info().
 		Str("restaurant_id", restaurantId).
-		Msgf("Retrieved dishes for page %d with limit %d successfully", page, limit)
-	return dishes, int(total), nil
+		Msgf("Retrieved %d dishes with limit %d successfully", len(dishes), page.Limit)
+	return dishes, result, nil
 }
 
 // FindById retrieves a dish by its ID for a specific restaurant and ensures it is not soft-deleted.
@@ -87,7 +95,7 @@ func (repo *DishesRepositoryImpl) FindById(dishId uuid.UUID, restaurantId string
 				Str("dish_id", dishId.String()).
 				Str("restaurant_id", restaurantId).
 				Msg("Dish not found")
-			return model.Dish{}, fmt.Errorf("dish with ID %s not found for restaurant %s", dishId, restaurantId)
+			return model.Dish{}, fmt.Errorf("dish with ID %s not found for restaurant %s: %w", dishId, restaurantId, result.Error)
 		}
 		log.Error().
 			Str("dish_id", dishId.String()).
@@ -103,85 +111,291 @@ func (repo *DishesRepositoryImpl) FindById(dishId uuid.UUID, restaurantId string
 	return dish, nil
 }
 
-// Create adds a new dish to the database.
-func (repo *DishesRepositoryImpl) Create(dish model.Dish, userId uuid.UUID) (model.Dish, error) {
-	dish.ID = uuid.New()
-	dish.CreatedById = userId
-	result := repo.Db.Create(&dish)
+// FindPublishedById retrieves a published dish by its ID for a specific restaurant.
+func (repo *DishesRepositoryImpl) FindPublishedById(dishId uuid.UUID, restaurantId string) (model.Dish, error) {
+	var dish model.Dish
+	result := repo.Db.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Where("id = ? AND restaurant_id = ? AND deleted_at IS NULL AND status = ?", dishId, restaurantId, model.DishStatusPublished).First(&dish)
 	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			log.Warn().
+				Str("dish_id", dishId.String()).
+				Str("restaurant_id", restaurantId).
+				Msg("Published dish not found")
+			return model.Dish{}, fmt.Errorf("dish with ID %s not found for restaurant %s: %w", dishId, restaurantId, result.Error)
+		}
 		log.Error().
-			Str("dish_id", dish.ID.String()).
+			Str("dish_id", dishId.String()).
+			Str("restaurant_id", restaurantId).
 			Err(result.Error).
-			Msg("Error creating dish")
-		return model.Dish{}, result.Error
+			Msg("Error finding published dish")
+		return model.Dish{}, fmt.Errorf("error finding dish: %w", result.Error)
 	}
 	return dish, nil
 }
 
-// Update modifies an existing dish in the database.
-func (repo *DishesRepositoryImpl) Update(dish model.Dish, userId uuid.UUID, restaurantId string) (model.Dish, error) {
-	updateFields := map[string]interface{}{
-		"LastUpdatedByID": userId,
+// FindPublishedByIds retrieves the published dishes of a restaurant among the given IDs.
+// IDs that do not match a published dish are left out of the result.
+func (repo *DishesRepositoryImpl) FindPublishedByIds(dishIds []uuid.UUID, restaurantId string) ([]model.Dish, error) {
+	var dishes []model.Dish
+	result := repo.Db.Where("id IN ? AND restaurant_id = ? AND deleted_at IS NULL AND status = ?", dishIds, restaurantId, model.DishStatusPublished).
+		Find(&dishes)
+	if result.Error != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(result.Error).
+			Msg("Error finding published dishes")
+		return nil, fmt.Errorf("error finding dishes: %w", result.Error)
 	}
+	return dishes, nil
+}
 
-	if dish.Name != "" {
-		updateFields["Name"] = dish.Name
-	}
-	if dish.Description != "" {
-		updateFields["Description"] = dish.Description
-	}
-	if dish.Price != 0 {
-		updateFields["Price"] = dish.Price
+// FindAllForPreview retrieves every non-deleted dish of a restaurant regardless of status.
+func (repo *DishesRepositoryImpl) FindAllForPreview(restaurantId string) ([]model.Dish, error) {
+	var dishes []model.Dish
+	result := repo.Db.Where("restaurant_id = ? AND deleted_at IS NULL", restaurantId).
+		Order("name ASC").
+		Find(&dishes)
+	if result.Error != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(result.Error).
+			Msg("Error finding dishes for preview")
+		return nil, fmt.Errorf("error finding dishes: %w", result.Error)
 	}
-	if dish.Image != "" {
-		updateFields["Image"] = dish.Image
+	return dishes, nil
+}
+
+// FindIdsBySKU maps the given SKUs to the IDs of the restaurant's dishes, including soft-deleted ones.
+func (repo *DishesRepositoryImpl) FindIdsBySKU(restaurantId string, skus []string) (map[string]uuid.UUID, error) {
+	ids := make(map[string]uuid.UUID)
+	if len(skus) == 0 {
+		return ids, nil
 	}
 
-	// Update the dish if it exists for the specified restaurant
-	result := repo.Db.Model(&model.Dish{}).Where("id = ? AND restaurant_id = ?", dish.ID, restaurantId).Updates(updateFields)
+	var dishes []model.Dish
+	result := repo.Db.Unscoped().
+		Select("id", "sku").
+		Where("restaurant_id = ? AND sku IN ?", restaurantId, skus).
+		Find(&dishes)
 	if result.Error != nil {
 		log.Error().
-			Str("dish_id", dish.ID.String()).
 			Str("restaurant_id", restaurantId).
 			Err(result.Error).
-			Msg("Error updating dish")
-		return model.Dish{}, fmt.Errorf("error updating dish: %w", result.Error)
+			Msg("Error finding dishes by SKU")
+		return nil, fmt.Errorf("error finding dishes by SKU: %w", result.Error)
 	}
-	if result.RowsAffected == 0 {
-		log.Warn().
-			Str("dish_id", dish.ID.String()).
+	for _, dish := range dishes {
+		ids[*dish.SKU] = dish.ID
+	}
+	return ids, nil
+}
+
+// Import creates the dishes without an ID and updates the ones with an ID, all in one transaction,
+// and records a DishCreated or DishUpdated event for each. Updating a soft-deleted dish restores it.
+func (repo *DishesRepositoryImpl) Import(dishes []model.Dish, userId uuid.UUID) error {
+	now := time.Now()
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var toCreate []model.Dish
+		var updatedIds []uuid.UUID
+		for _, dish := range dishes {
+			if dish.ID == uuid.Nil {
+				dish.ID = uuid.New()
+				dish.CreatedById = userId
+				toCreate = append(toCreate, dish)
+				continue
+			}
+
+			updateFields := map[string]interface{}{
+				"Name":            dish.Name,
+				"Description":     dish.Description,
+				"Price":           dish.Price,
+				"Image":           dish.Image,
+				"Category":        dish.Category,
+				"Tags":            dish.Tags,
+				"LastUpdatedByID": userId,
+				"DeletedAt":       nil,
+			}
+			if dish.Status != "" {
+				updateFields["Status"] = dish.Status
+			}
+			if dish.Status == model.DishStatusPublished {
+				updateFields["PublishedAt"] = gorm.Expr("COALESCE(published_at, ?)", now)
+			}
+			result := tx.Unscoped().Model(&model.Dish{}).
+				Where("id = ? AND restaurant_id = ?", dish.ID, dish.RestaurantID).
+				Updates(updateFields)
+			if result.Error != nil {
+				return fmt.Errorf("error updating dish with SKU %s: %w", *dish.SKU, result.Error)
+			}
+			updatedIds = append(updatedIds, dish.ID)
+		}
+
+		if len(toCreate) > 0 {
+			if err := tx.Omit(clause.Associations).CreateInBatches(toCreate, importBatchSize).Error; err != nil {
+				return fmt.Errorf("error creating dishes: %w", err)
+			}
+		}
+
+		var updated []model.Dish
+		if len(updatedIds) > 0 {
+			if err := tx.Where("id IN ?", updatedIds).Find(&updated).Error; err != nil {
+				return fmt.Errorf("error retrieving imported dishes: %w", err)
+			}
+		}
+		events := make([]eventbus.Event, 0, len(toCreate)+len(updated))
+		for _, batch := range []struct {
+			eventType string
+			dishes    []model.Dish
+		}{{eventbus.DishCreated, toCreate}, {eventbus.DishUpdated, updated}} {
+			for _, dish := range batch.dishes {
+				event, err := dishEvent(batch.eventType, dish)
+				if err != nil {
+					return err
+				}
+				events = append(events, event)
+			}
+		}
+		return appendOutbox(tx, events...)
+	})
+	if err != nil {
+		log.Error().
+			Int("num_dishes", len(dishes)).
+			Err(err).
+			Msg("Error importing dishes, transaction rolled back")
+		return err
+	}
+
+	log.Info().
+		Int("num_dishes", len(dishes)).
+		Msg("Dishes imported successfully")
+	return nil
+}
+
+// StreamAll calls fn for every non-deleted dish of a restaurant without loading the whole catalogue into memory.
+func (repo *DishesRepositoryImpl) StreamAll(restaurantId string, fn func(model.Dish) error) error {
+	rows, err := repo.Db.Model(&model.Dish{}).
+		Where("restaurant_id = ? AND deleted_at IS NULL", restaurantId).
+		Order("name ASC, id ASC").
+		Rows()
+	if err != nil {
+		log.Error().
 			Str("restaurant_id", restaurantId).
-			Msg("No rows affected, update might have failed")
-		return model.Dish{}, fmt.Errorf("no rows affected, update might have failed")
+			Err(err).
+			Msg("Error streaming dishes")
+		return fmt.Errorf("error streaming dishes: %w", err)
 	}
+	defer rows.Close()
 
-	var updatedDish model.Dish
-	if err := repo.Db.Where("id = ? AND restaurant_id = ?", dish.ID, restaurantId).First(&updatedDish).Error; err != nil {
+	for rows.Next() {
+		var dish model.Dish
+		if err := repo.Db.ScanRows(rows, &dish); err != nil {
+			return fmt.Errorf("error scanning dish: %w", err)
+		}
+		if err := fn(dish); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Create adds a new dish to the database and records a DishCreated event.
+func (repo *DishesRepositoryImpl) Create(dish model.Dish, userId uuid.UUID) (model.Dish, error) {
+	dish.ID = uuid.New()
+	dish.CreatedById = userId
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&dish).Error; err != nil {
+			return err
+		}
+		return appendDishEvent(tx, eventbus.DishCreated, dish)
+	})
+	if err != nil {
 		log.Error().
 			Str("dish_id", dish.ID.String()).
+			Err(err).
+			Msg("Error creating dish")
+		return model.Dish{}, err
+	}
+	return dish, nil
+}
+
+// Update writes the given fields to a dish. Only the keys present in fields are
+// changed, so zero values such as an empty description are stored as given.
+// A new Image replaces the primary gallery image, which Dish.Image mirrors; removing it
+// promotes the next gallery image.
+// When versions is not empty the dish must be at one of them, or ErrVersionMismatch is returned.
+// A DishUpdated event is recorded with the change.
+func (repo *DishesRepositoryImpl) Update(dishId uuid.UUID, fields map[string]interface{}, versions []int64, userId uuid.UUID, restaurantId string) (model.Dish, error) {
+	updateFields := map[string]interface{}{
+		"LastUpdatedByID": userId,
+	}
+	for field, value := range fields {
+		updateFields[field] = value
+	}
+
+	var updatedDish model.Dish
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&model.Dish{}).Where("id = ? AND restaurant_id = ?", dishId, restaurantId)
+		result := whereVersion(query, versions).Updates(updateFields)
+		if result.Error != nil {
+			return fmt.Errorf("error updating dish: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			err := missOrConflict(tx, &model.Dish{}, "id = ? AND restaurant_id = ?", dishId, restaurantId)
+			return fmt.Errorf("error updating dish %s for restaurant %s: %w", dishId, restaurantId, err)
+		}
+
+		if image, ok := fields["Image"].(string); ok {
+			primary := tx.Where("dish_id = ? AND is_primary = ?", dishId, true)
+			if image == "" {
+				if err := primary.Delete(&model.DishImage{}).Error; err != nil {
+					return fmt.Errorf("error removing primary dish image: %w", err)
+				}
+				if err := promoteNextImage(tx, dishId); err != nil {
+					return err
+				}
+			} else if err := primary.Model(&model.DishImage{}).Update("Key", image).Error; err != nil {
+				return fmt.Errorf("error replacing primary dish image: %w", err)
+			}
+		}
+
+		if err := tx.Where("id = ? AND restaurant_id = ?", dishId, restaurantId).First(&updatedDish).Error; err != nil {
+			return fmt.Errorf("error retrieving updated dish: %w", err)
+		}
+		return appendDishEvent(tx, eventbus.DishUpdated, updatedDish)
+	})
+	if err != nil {
+		log.Error().
+			Str("dish_id", dishId.String()).
 			Str("restaurant_id", restaurantId).
 			Err(err).
-			Msg("Error retrieving updated dish")
-		return model.Dish{}, fmt.Errorf("error retrieving updated dish: %w", err)
+			Msg("Error updating dish")
+		return model.Dish{}, err
 	}
 
 	log.Info().
-		Str("dish_id", dish.ID.String()).
+		Str("dish_id", dishId.String()).
 		Str("restaurant_id", restaurantId).
 		Msg("Dish updated successfully")
 	return updatedDish, nil
 }
 
-// RateDish adds a new rating to the database.
-func (repo *DishesRepositoryImpl) RateDish(rating model.Rating) (model.Rating, error) {
+// RateDish adds a new rating to the database and records a RatingAdded event.
+func (repo *DishesRepositoryImpl) RateDish(rating model.Rating, restaurantId string) (model.Rating, error) {
 	rating.ID = uuid.New()
-	result := repo.Db.Create(&rating)
-	if result.Error != nil {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rating).Error; err != nil {
+			return err
+		}
+		return appendRatingEvent(tx, eventbus.RatingAdded, rating, restaurantId)
+	})
+	if err != nil {
 		log.Error().
 			Str("rating_id", rating.ID.String()).
-			Err(result.Error).
+			Err(err).
 			Msg("Error creating rating")
-		return model.Rating{}, result.Error
+		return model.Rating{}, err
 	}
 	log.Info().
 		Str("rating_id", rating.ID.String()).
@@ -189,49 +403,99 @@ func (repo *DishesRepositoryImpl) RateDish(rating model.Rating) (model.Rating, e
 	return rating, nil
 }
 
-// Search finds dishes based on a search term in the dish name with pagination, filtering by restaurant ID.
-func (repo *DishesRepositoryImpl) Search(searchTerm string, page, limit int, restaurantId string) ([]model.Dish, int, error) {
-	var dishes []model.Dish
-	var total int64
-
-	// Prepare the search query
-	query := "%" + searchTerm + "%"
+// FindReviews retrieves a page of the reviews of a restaurant's dishes, optionally only those with the given status.
+func (repo *DishesRepositoryImpl) FindReviews(restaurantId string, status string, page pagination.Page) ([]model.Rating, pagination.Result, error) {
+	query := repo.Db.Model(&model.Rating{}).
+		Where("dish_id IN (?)", repo.Db.Model(&model.Dish{}).Select("id").Where("restaurant_id = ?", restaurantId))
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	reviews, result, err := findPage[model.Rating](query, page)
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding reviews")
+		return nil, result, fmt.Errorf("error finding reviews: %w", err)
+	}
+	return reviews, result, nil
+}
 
-	// Calculate the offset for pagination
-	offset := (page - 1) * limit
+// ModerateReview approves or rejects a pending review of one of the restaurant's dishes.
+// Approval credits the customer's review points in the same transaction, which also
+// records a RatingModerated event.
+func (repo *DishesRepositoryImpl) ModerateReview(ratingId uuid.UUID, restaurantId string, status string, userId uuid.UUID) (model.Rating, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return model.Rating{}, fmt.Errorf("invalid restaurant ID %q: %w", restaurantId, err)
+	}
+	var moderated model.Rating
+	err = repo.Db.Transaction(func(tx *gorm.DB) error {
+		var rating model.Rating
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND dish_id IN (?)", ratingId, tx.Model(&model.Dish{}).Select("id").Where("restaurant_id = ?", restaurantId)).
+			First(&rating)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("review with ID %s not found for restaurant %s: %w", ratingId, restaurantId, result.Error)
+			}
+			return result.Error
+		}
+		if rating.Status != model.RatingStatusPending {
+			return ErrReviewNotPending
+		}
 
-	// Fetch the total count of matching dishes for pagination
-	countResult := repo.Db.Model(&model.Dish{}).
-		Where("deleted_at IS NULL AND name ILIKE ? AND restaurant_id = ?", query, restaurantId).
-		Count(&total)
-	if countResult.Error != nil {
+		now := time.Now()
+		if err := tx.Model(&rating).Updates(map[string]interface{}{
+			"Status":        status,
+			"ModeratedAt":   now,
+			"ModeratedByID": userId,
+		}).Error; err != nil {
+			return err
+		}
+		rating.Status, rating.ModeratedAt, rating.ModeratedByID = status, &now, &userId
+		if status == model.RatingStatusApproved {
+			if err := creditReview(tx, rating, restaurantUUID); err != nil {
+				return err
+			}
+		}
+		moderated = rating
+		return appendRatingEvent(tx, eventbus.RatingModerated, rating, restaurantId)
+	})
+	if err != nil {
 		log.Error().
-			Str("search_term", searchTerm).
+			Str("rating_id", ratingId.String()).
 			Str("restaurant_id", restaurantId).
-			Err(countResult.Error).
-			Msg("Error counting total dishes for search")
-		return nil, 0, fmt.Errorf("error counting dishes: %w", countResult.Error)
+			Err(err).
+			Msg("Error moderating review")
+		return model.Rating{}, err
 	}
+	log.Info().
+		Str("rating_id", ratingId.String()).
+		Str("status", status).
+		Msg("Review moderated successfully")
+	return moderated, nil
+}
 
-	// Fetch the paginated list of dishes
-	result := repo.Db.Where("deleted_at IS NULL AND name ILIKE ? AND restaurant_id = ?", query, restaurantId).
-		Limit(limit).
-		Offset(offset).
-		Find(&dishes)
-	if result.Error != nil {
+// Search retrieves a page of the published dishes of a restaurant whose name contains the search term.
+func (repo *DishesRepositoryImpl) Search(searchTerm string, page pagination.Page, restaurantId string) ([]model.Dish, pagination.Result, error) {
+	// Prepare the search query
+	query := "%" + searchTerm + "%"
+
+	dishes, result, err := findPage[model.Dish](repo.Db.Model(&model.Dish{}).
+		Where("deleted_at IS NULL AND name ILIKE ? AND restaurant_id = ? AND status = ?", query, restaurantId, model.DishStatusPublished), page)
+	if err != nil {
 		log.Error().
 			Str("search_term", searchTerm).
 			Str("restaurant_id", restaurantId).
-			Err(result.Error).
+			Err(err).
 			Msg("Error searching dishes with pagination")
-		return nil, 0, fmt.Errorf("error searching dishes: %w", result.Error)
+		return nil, result, fmt.Errorf("error searching dishes: %w", err)
 	}
 
 	log.Info().
 		Str("search_term", searchTerm).
-		Str("restaurant_id", restaurantId).
 		Int("num_dishes_found", len(dishes)).
 		Msg("Dishes searched successfully with pagination")
-
-	return dishes, int(total), nil
+	return dishes, result, nil
 }
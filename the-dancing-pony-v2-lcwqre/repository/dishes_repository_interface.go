@@ -2,17 +2,26 @@ package repository
 
 import (
 	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
 
 	"github.com/google/uuid"
 )
 
 type DishesRepository interface {
 	Create(dish model.Dish, userId uuid.UUID) (model model.Dish, err error)
-	Update(dish model.Dish, userId uuid.UUID, restaurantId string) (model model.Dish, err error)
-	Delete(dishId uuid.UUID, restaurantId string) (err error)
+	Update(dishId uuid.UUID, fields map[string]interface{}, versions []int64, userId uuid.UUID, restaurantId string) (model model.Dish, err error)
+	Delete(dishId uuid.UUID, restaurantId string, versions []int64) (err error)
 	FindById(dish_id uuid.UUID, restaurantId string) (dish model.Dish, err error)
-	FindAll(page, limit int, restaurantId string) (returnDishes []model.Dish, count int, err error)
+	FindAll(page pagination.Page, restaurantId string) (returnDishes []model.Dish, result pagination.Result, err error)
+	FindPublishedById(dishId uuid.UUID, restaurantId string) (dish model.Dish, err error)
+	FindPublishedByIds(dishIds []uuid.UUID, restaurantId string) (dishes []model.Dish, err error)
+	FindAllForPreview(restaurantId string) (dishes []model.Dish, err error)
+	FindIdsBySKU(restaurantId string, skus []string) (ids map[string]uuid.UUID, err error)
+	Import(dishes []model.Dish, userId uuid.UUID) (err error)
+	StreamAll(restaurantId string, fn func(model.Dish) error) (err error)
 
-	RateDish(dish model.Rating) (model model.Rating, err error)
-	Search(searchTerm string, page int, limit int, restaurantId string) ([]model.Dish, int, error)
+	RateDish(dish model.Rating, restaurantId string) (model model.Rating, err error)
+	FindReviews(restaurantId string, status string, page pagination.Page) (reviews []model.Rating, result pagination.Result, err error)
+	ModerateReview(ratingId uuid.UUID, restaurantId string, status string, userId uuid.UUID) (review model.Rating, err error)
+	Search(searchTerm string, page pagination.Page, restaurantId string) ([]model.Dish, pagination.Result, error)
 }
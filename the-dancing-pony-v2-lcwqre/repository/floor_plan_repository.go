@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// FloorPlanRepository defines the data operations for a restaurant's dining areas,
+// tables and reservation schedule.
+type FloorPlanRepository interface {
+	// CreateArea stores a new dining area.
+	CreateArea(area model.DiningArea) (model.DiningArea, error)
+
+	// FindAreas retrieves the dining areas of a restaurant ordered by name.
+	FindAreas(restaurantId string) ([]model.DiningArea, error)
+
+	// DeleteArea deletes a dining area and detaches its tables from it.
+	DeleteArea(areaId uuid.UUID, restaurantId string) error
+
+	// CreateTable stores a new table.
+	CreateTable(table model.DiningTable) (model.DiningTable, error)
+
+	// FindTables retrieves the tables of a restaurant ordered by name.
+	FindTables(restaurantId string) ([]model.DiningTable, error)
+
+	// FindTable retrieves a table of a restaurant.
+	FindTable(tableId uuid.UUID, restaurantId string) (model.DiningTable, error)
+
+	// UpdateTable writes the given fields to a table.
+	UpdateTable(tableId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.DiningTable, error)
+
+	// DeleteTable deletes a table. It fails with ErrTableHasReservations while the table
+	// is held by a reservation ending after now.
+	DeleteTable(tableId uuid.UUID, restaurantId string, now time.Time) error
+
+	// FindSchedule retrieves a restaurant's reservation settings and opening hours. A
+	// restaurant without settings gets empty settings whose ID is uuid.Nil.
+	FindSchedule(restaurantId string) (model.ReservationSettings, []model.OpeningHours, error)
+
+	// SaveSchedule replaces a restaurant's reservation settings and opening hours.
+	SaveSchedule(settings model.ReservationSettings, hours []model.OpeningHours) error
+}
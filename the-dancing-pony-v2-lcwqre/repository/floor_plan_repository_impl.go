@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTableHasReservations is returned when deleting a table that upcoming reservations hold.
+var ErrTableHasReservations = errors.New("table has upcoming reservations")
+
+// FloorPlanRepositoryImpl implements FloorPlanRepository interface.
+type FloorPlanRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewFloorPlanRepositoryImpl creates a new instance of FloorPlanRepositoryImpl.
+func NewFloorPlanRepositoryImpl(db *gorm.DB) FloorPlanRepository {
+	return &FloorPlanRepositoryImpl{Db: db}
+}
+
+// CreateArea stores a new dining area.
+func (repo *FloorPlanRepositoryImpl) CreateArea(area model.DiningArea) (model.DiningArea, error) {
+	area.ID = uuid.New()
+	if result := repo.Db.Create(&area); result.Error != nil {
+		log.Error().
+			Str("restaurant_id", area.RestaurantID.String()).
+			Err(result.Error).
+			Msg("Error creating dining area")
+		return model.DiningArea{}, fmt.Errorf("error creating dining area: %w", result.Error)
+	}
+	return area, nil
+}
+
+// FindAreas retrieves the dining areas of a restaurant ordered by name.
+func (repo *FloorPlanRepositoryImpl) FindAreas(restaurantId string) ([]model.DiningArea, error) {
+	var areas []model.DiningArea
+	if err := repo.Db.Where("restaurant_id = ?", restaurantId).Order("name ASC").Find(&areas).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding dining areas")
+		return nil, fmt.Errorf("error finding dining areas: %w", err)
+	}
+	return areas, nil
+}
+
+// DeleteArea deletes a dining area and detaches its tables from it.
+func (repo *FloorPlanRepositoryImpl) DeleteArea(areaId uuid.UUID, restaurantId string) error {
+	return repo.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND restaurant_id = ?", areaId, restaurantId).Delete(&model.DiningArea{})
+		if result.Error != nil {
+			log.Error().
+				Str("area_id", areaId.String()).
+				Err(result.Error).
+				Msg("Error deleting dining area")
+			return fmt.Errorf("error deleting dining area: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("dining area with ID %s not found for restaurant %s: %w", areaId, restaurantId, gorm.ErrRecordNotFound)
+		}
+		if err := tx.Model(&model.DiningTable{}).Where("area_id = ?", areaId).Update("area_id", nil).Error; err != nil {
+			return fmt.Errorf("error detaching tables from dining area: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateTable stores a new table.
+func (repo *FloorPlanRepositoryImpl) CreateTable(table model.DiningTable) (model.DiningTable, error) {
+	table.ID = uuid.New()
+	if result := repo.Db.Create(&table); result.Error != nil {
+		log.Error().
+			Str("restaurant_id", table.RestaurantID.String()).
+			Err(result.Error).
+			Msg("Error creating table")
+		return model.DiningTable{}, fmt.Errorf("error creating table: %w", result.Error)
+	}
+	return table, nil
+}
+
+// FindTables retrieves the tables of a restaurant ordered by name.
+func (repo *FloorPlanRepositoryImpl) FindTables(restaurantId string) ([]model.DiningTable, error) {
+	var tables []model.DiningTable
+	if err := repo.Db.Where("restaurant_id = ?", restaurantId).Order("name ASC").Find(&tables).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding tables")
+		return nil, fmt.Errorf("error finding tables: %w", err)
+	}
+	return tables, nil
+}
+
+// FindTable retrieves a table of a restaurant.
+func (repo *FloorPlanRepositoryImpl) FindTable(tableId uuid.UUID, restaurantId string) (model.DiningTable, error) {
+	return findTable(repo.Db, tableId, restaurantId)
+}
+
+// UpdateTable writes the given fields to a table.
+func (repo *FloorPlanRepositoryImpl) UpdateTable(tableId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.DiningTable, error) {
+	result := repo.Db.Model(&model.DiningTable{}).Where("id = ? AND restaurant_id = ?", tableId, restaurantId).Updates(fields)
+	if result.Error != nil {
+		log.Error().
+			Str("table_id", tableId.String()).
+			Err(result.Error).
+			Msg("Error updating table")
+		return model.DiningTable{}, fmt.Errorf("error updating table: %w", result.Error)
+	}
+	return repo.FindTable(tableId, restaurantId)
+}
+
+// DeleteTable deletes a table unless a reservation ending after now holds it. The table
+// is locked so that no reservation can be booked for it in between.
+func (repo *FloorPlanRepositoryImpl) DeleteTable(tableId uuid.UUID, restaurantId string, now time.Time) error {
+	return repo.Db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findTable(tx.Clauses(clause.Locking{Strength: "UPDATE"}), tableId, restaurantId); err != nil {
+			return err
+		}
+		var upcoming int64
+		if err := tx.Model(&model.Reservation{}).
+			Where("table_id = ? AND status IN ? AND ends_at > ?", tableId, model.ReservationHoldingStatuses, now).
+			Count(&upcoming).Error; err != nil {
+			return fmt.Errorf("error counting table reservations: %w", err)
+		}
+		if upcoming > 0 {
+			return ErrTableHasReservations
+		}
+		if err := tx.Delete(&model.DiningTable{}, "id = ?", tableId).Error; err != nil {
+			log.Error().
+				Str("table_id", tableId.String()).
+				Err(err).
+				Msg("Error deleting table")
+			return fmt.Errorf("error deleting table: %w", err)
+		}
+		return nil
+	})
+}
+
+// FindSchedule retrieves a restaurant's reservation settings and opening hours.
+func (repo *FloorPlanRepositoryImpl) FindSchedule(restaurantId string) (model.ReservationSettings, []model.OpeningHours, error) {
+	var settings model.ReservationSettings
+	if err := repo.Db.Where("restaurant_id = ?", restaurantId).Limit(1).Find(&settings).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding reservation settings")
+		return model.ReservationSettings{}, nil, fmt.Errorf("error finding reservation settings: %w", err)
+	}
+	var hours []model.OpeningHours
+	if err := repo.Db.Where("restaurant_id = ?", restaurantId).Order("weekday ASC, opens ASC").Find(&hours).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding opening hours")
+		return model.ReservationSettings{}, nil, fmt.Errorf("error finding opening hours: %w", err)
+	}
+	return settings, hours, nil
+}
+
+// SaveSchedule replaces a restaurant's reservation settings and opening hours in one transaction.
+func (repo *FloorPlanRepositoryImpl) SaveSchedule(settings model.ReservationSettings, hours []model.OpeningHours) error {
+	settings.ID = uuid.New()
+	for i := range hours {
+		hours[i].ID = uuid.New()
+		hours[i].RestaurantID = settings.RestaurantID
+	}
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "restaurant_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"timezone", "slot_minutes", "duration_minutes", "max_party_size", "max_advance_days", "updated_at"}),
+		}).Create(&settings).Error; err != nil {
+			return fmt.Errorf("error saving reservation settings: %w", err)
+		}
+		if err := tx.Unscoped().Where("restaurant_id = ?", settings.RestaurantID).Delete(&model.OpeningHours{}).Error; err != nil {
+			return fmt.Errorf("error replacing opening hours: %w", err)
+		}
+		if len(hours) > 0 {
+			if err := tx.Create(&hours).Error; err != nil {
+				return fmt.Errorf("error replacing opening hours: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", settings.RestaurantID.String()).
+			Err(err).
+			Msg("Error saving reservation schedule")
+		return err
+	}
+	return nil
+}
+
+// findTable retrieves a table of a restaurant with db, which may be a locking transaction.
+func findTable(db *gorm.DB, tableId uuid.UUID, restaurantId string) (model.DiningTable, error) {
+	var table model.DiningTable
+	result := db.Where("id = ? AND restaurant_id = ?", tableId, restaurantId).First(&table)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return model.DiningTable{}, fmt.Errorf("table with ID %s not found for restaurant %s: %w", tableId, restaurantId, result.Error)
+		}
+		log.Error().
+			Str("table_id", tableId.String()).
+			Err(result.Error).
+			Msg("Error finding table")
+		return model.DiningTable{}, fmt.Errorf("error finding table: %w", result.Error)
+	}
+	return table, nil
+}
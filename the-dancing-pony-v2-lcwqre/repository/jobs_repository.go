@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"the-dancing-pony-v2-lcwqre/jobs"
+)
+
+// JobsRepository stores background jobs in the database, where workers claim them with
+// SKIP LOCKED.
+type JobsRepository interface {
+	jobs.Store
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/jobs"
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobsRepositoryImpl implements JobsRepository interface.
+type JobsRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewJobsRepositoryImpl creates a new instance of JobsRepositoryImpl.
+func NewJobsRepositoryImpl(db *gorm.DB) JobsRepository {
+	return &JobsRepositoryImpl{Db: db}
+}
+
+// Enqueue inserts a pending job. The unique index on queued jobs' keys turns a
+// duplicate into a no-op insert.
+func (repo *JobsRepositoryImpl) Enqueue(ctx context.Context, job jobs.Job) error {
+	row := model.Job{
+		ID:          job.ID,
+		Type:        job.Type,
+		Payload:     string(job.Payload),
+		Status:      jobs.StatusPending,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		CreatedAt:   job.CreatedAt,
+	}
+	if job.UniqueKey != "" {
+		row.UniqueKey = &job.UniqueKey
+	}
+	result := repo.Db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if result.Error != nil {
+		return fmt.Errorf("error enqueuing job %s: %w", job.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return jobs.ErrDuplicateJob
+	}
+	return nil
+}
+
+// Claim locks the due jobs, skipping those other workers are claiming, and leases them.
+// Jobs whose lease ran out on their last attempt are buried instead.
+func (repo *JobsRepositoryImpl) Claim(ctx context.Context, types []string, limit int, lease time.Duration, now time.Time) ([]jobs.Job, error) {
+	var rows []model.Job
+	err := repo.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Job{}).
+			Where("type IN ? AND status = ? AND lease_expires_at <= ? AND attempts >= max_attempts", types, jobs.StatusRunning, now).
+			Updates(map[string]interface{}{
+				"status":           jobs.StatusDead,
+				"lease_expires_at": nil,
+				"last_error":       jobs.ErrLeaseExpired.Error(),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type IN ?", types).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND lease_expires_at <= ?)", jobs.StatusPending, now, jobs.StatusRunning, now).
+			Order("run_at ASC").Limit(limit).Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+
+		ids := make([]interface{}, len(rows))
+		for i := range rows {
+			ids[i] = rows[i].ID
+		}
+		leaseExpiresAt := now.Add(lease)
+		return tx.Model(&model.Job{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":           jobs.StatusRunning,
+			"attempts":         gorm.Expr("attempts + 1"),
+			"lease_expires_at": leaseExpiresAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error claiming jobs: %w", err)
+	}
+
+	claimed := make([]jobs.Job, len(rows))
+	for i, row := range rows {
+		row.Attempts++
+		row.Status = jobs.StatusRunning
+		claimed[i] = toJob(row)
+	}
+	return claimed, nil
+}
+
+// Complete deletes a job that succeeded.
+func (repo *JobsRepositoryImpl) Complete(ctx context.Context, job jobs.Job) error {
+	err := repo.leased(ctx, job).Delete(&model.Job{}).Error
+	if err != nil {
+		return fmt.Errorf("error completing job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// Retry makes a failed job pending again.
+func (repo *JobsRepositoryImpl) Retry(ctx context.Context, job jobs.Job, runAt time.Time, reason string) error {
+	err := repo.leased(ctx, job).Model(&model.Job{}).Updates(map[string]interface{}{
+		"status":           jobs.StatusPending,
+		"run_at":           runAt,
+		"lease_expires_at": nil,
+		"last_error":       truncateText(reason, 500),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("error rescheduling job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// Bury marks a job dead, which also frees its unique key.
+func (repo *JobsRepositoryImpl) Bury(ctx context.Context, job jobs.Job, reason string) error {
+	err := repo.leased(ctx, job).Model(&model.Job{}).Updates(map[string]interface{}{
+		"status":           jobs.StatusDead,
+		"lease_expires_at": nil,
+		"last_error":       truncateText(reason, 500),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("error burying job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// leased matches a job only while the attempt that claimed it holds it, so a worker that
+// outlived its lease does not overwrite the outcome of the attempt that took over.
+func (repo *JobsRepositoryImpl) leased(ctx context.Context, job jobs.Job) *gorm.DB {
+	return repo.Db.WithContext(ctx).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, jobs.StatusRunning, job.Attempts)
+}
+
+// ClaimSlot moves the last slot of a schedule forward; the row lock makes replicas
+// claiming the same slot wait for each other, and all but the first find it taken.
+func (repo *JobsRepositoryImpl) ClaimSlot(ctx context.Context, schedule string, slot time.Time) (bool, error) {
+	result := repo.Db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"last_slot": slot, "updated_at": time.Now()}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "job_schedules.last_slot < ?", Vars: []interface{}{slot}}}},
+	}).Create(&model.JobSchedule{Name: schedule, LastSlot: slot})
+	if result.Error != nil {
+		return false, fmt.Errorf("error claiming slot of schedule %s: %w", schedule, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Stats counts the jobs by type and status.
+func (repo *JobsRepositoryImpl) Stats(ctx context.Context, types []string, now time.Time) ([]jobs.Stat, error) {
+	var rows []struct {
+		Type   string
+		Status string
+		Count  int64
+		Oldest *time.Time
+	}
+	err := repo.Db.WithContext(ctx).Model(&model.Job{}).
+		Select("type, status, COUNT(*) AS count, MIN(run_at) FILTER (WHERE run_at <= ?) AS oldest", now).
+		Where("type IN ?", types).
+		Group("type, status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error reading job stats: %w", err)
+	}
+
+	stats := make([]jobs.Stat, len(rows))
+	for i, row := range rows {
+		stats[i] = jobs.Stat{Type: row.Type, Status: row.Status, Count: row.Count}
+		if row.Status == jobs.StatusPending && row.Oldest != nil {
+			stats[i].Oldest = *row.Oldest
+		}
+	}
+	return stats, nil
+}
+
+// toJob maps a job row to the jobs package's view of it.
+func toJob(row model.Job) jobs.Job {
+	job := jobs.Job{
+		ID:          row.ID,
+		Type:        row.Type,
+		Payload:     json.RawMessage(row.Payload),
+		Status:      row.Status,
+		Attempts:    row.Attempts,
+		MaxAttempts: row.MaxAttempts,
+		RunAt:       row.RunAt,
+		LastError:   row.LastError,
+		CreatedAt:   row.CreatedAt,
+	}
+	if row.UniqueKey != nil {
+		job.UniqueKey = *row.UniqueKey
+	}
+	return job
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// LoyaltyRepository defines the data operations for loyalty programs, rewards and points ledgers.
+type LoyaltyRepository interface {
+	// FindProgram retrieves the loyalty program of a restaurant, or a disabled program
+	// whose ID is uuid.Nil when the restaurant has none.
+	FindProgram(restaurantId string) (model.LoyaltyProgram, error)
+
+	// SaveProgram creates or replaces the loyalty program of a restaurant.
+	SaveProgram(program model.LoyaltyProgram) (model.LoyaltyProgram, error)
+
+	// CreateReward stores a new reward.
+	CreateReward(reward model.LoyaltyReward) (model.LoyaltyReward, error)
+
+	// FindReward retrieves a reward of a restaurant.
+	FindReward(rewardId uuid.UUID, restaurantId string) (model.LoyaltyReward, error)
+
+	// FindRewards retrieves the rewards of a restaurant, cheapest first, optionally only the active ones.
+	FindRewards(restaurantId string, activeOnly bool) ([]model.LoyaltyReward, error)
+
+	// UpdateReward writes the given fields to a reward.
+	UpdateReward(rewardId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.LoyaltyReward, error)
+
+	// DeleteReward deletes a reward. Orders that redeemed it keep its name and discount.
+	DeleteReward(rewardId uuid.UUID, restaurantId string) error
+
+	// Balance computes a customer's balance from their ledger at the given time, first
+	// recording the expiry of the credits that lapsed.
+	Balance(customerId uuid.UUID, restaurantId string, at time.Time) (model.LoyaltyBalance, error)
+
+	// FindEntries retrieves a page of a customer's ledger, oldest entries first.
+	FindEntries(customerId uuid.UUID, restaurantId string, page pagination.Page) ([]model.LoyaltyEntry, pagination.Result, error)
+
+	// Adjust appends a staff adjustment to the ledger of a customer of the restaurant. It
+	// fails with ErrInsufficientPoints when a negative adjustment exceeds the balance.
+	Adjust(entry model.LoyaltyEntry) (model.LoyaltyEntry, error)
+}
@@ -0,0 +1,514 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInsufficientPoints is returned when a customer spends more points than their balance.
+	ErrInsufficientPoints = errors.New("not enough loyalty points")
+
+	// ErrRewardUnavailable is returned when an order redeems a reward that was disabled,
+	// deleted or repriced since the order was priced, or whose program was disabled.
+	ErrRewardUnavailable = errors.New("reward is no longer available")
+)
+
+// LoyaltyRepositoryImpl implements LoyaltyRepository interface.
+type LoyaltyRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewLoyaltyRepositoryImpl creates a new instance of LoyaltyRepositoryImpl.
+func NewLoyaltyRepositoryImpl(db *gorm.DB) LoyaltyRepository {
+	return &LoyaltyRepositoryImpl{Db: db}
+}
+
+// FindProgram retrieves the loyalty program of a restaurant, or a disabled program when it has none.
+func (repo *LoyaltyRepositoryImpl) FindProgram(restaurantId string) (model.LoyaltyProgram, error) {
+	program, err := findLoyaltyProgram(repo.Db, restaurantId)
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding loyalty program")
+		return model.LoyaltyProgram{}, fmt.Errorf("error finding loyalty program: %w", err)
+	}
+	return program, nil
+}
+
+// SaveProgram creates or replaces the loyalty program of a restaurant.
+func (repo *LoyaltyRepositoryImpl) SaveProgram(program model.LoyaltyProgram) (model.LoyaltyProgram, error) {
+	program.ID = uuid.New()
+	err := repo.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "restaurant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "points_per_unit", "review_points", "expiry_days", "updated_at"}),
+	}).Create(&program).Error
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", program.RestaurantID.String()).
+			Err(err).
+			Msg("Error saving loyalty program")
+		return model.LoyaltyProgram{}, fmt.Errorf("error saving loyalty program: %w", err)
+	}
+	return repo.FindProgram(program.RestaurantID.String())
+}
+
+// CreateReward stores a new reward.
+func (repo *LoyaltyRepositoryImpl) CreateReward(reward model.LoyaltyReward) (model.LoyaltyReward, error) {
+	reward.ID = uuid.New()
+	if result := repo.Db.Create(&reward); result.Error != nil {
+		log.Error().
+			Str("restaurant_id", reward.RestaurantID.String()).
+			Err(result.Error).
+			Msg("Error creating reward")
+		return model.LoyaltyReward{}, fmt.Errorf("error creating reward: %w", result.Error)
+	}
+	return reward, nil
+}
+
+// FindReward retrieves a reward of a restaurant.
+func (repo *LoyaltyRepositoryImpl) FindReward(rewardId uuid.UUID, restaurantId string) (model.LoyaltyReward, error) {
+	var reward model.LoyaltyReward
+	result := repo.Db.Where("id = ? AND restaurant_id = ?", rewardId, restaurantId).First(&reward)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return model.LoyaltyReward{}, fmt.Errorf("reward with ID %s not found for restaurant %s: %w", rewardId, restaurantId, result.Error)
+		}
+		log.Error().
+			Str("reward_id", rewardId.String()).
+			Err(result.Error).
+			Msg("Error finding reward")
+		return model.LoyaltyReward{}, fmt.Errorf("error finding reward: %w", result.Error)
+	}
+	return reward, nil
+}
+
+// FindRewards retrieves the rewards of a restaurant, cheapest first, optionally only the active ones.
+func (repo *LoyaltyRepositoryImpl) FindRewards(restaurantId string, activeOnly bool) ([]model.LoyaltyReward, error) {
+	query := repo.Db.Where("restaurant_id = ?", restaurantId)
+	if activeOnly {
+		query = query.Where("active")
+	}
+	var rewards []model.LoyaltyReward
+	if err := query.Order("points_cost ASC, created_at ASC, id ASC").Find(&rewards).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding rewards")
+		return nil, fmt.Errorf("error finding rewards: %w", err)
+	}
+	return rewards, nil
+}
+
+// UpdateReward writes the given fields to a reward.
+func (repo *LoyaltyRepositoryImpl) UpdateReward(rewardId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.LoyaltyReward, error) {
+	result := repo.Db.Model(&model.LoyaltyReward{}).Where("id = ? AND restaurant_id = ?", rewardId, restaurantId).Updates(fields)
+	if result.Error != nil {
+		log.Error().
+			Str("reward_id", rewardId.String()).
+			Err(result.Error).
+			Msg("Error updating reward")
+		return model.LoyaltyReward{}, fmt.Errorf("error updating reward: %w", result.Error)
+	}
+	return repo.FindReward(rewardId, restaurantId)
+}
+
+// DeleteReward deletes a reward. Orders that redeemed it keep its name and discount.
+func (repo *LoyaltyRepositoryImpl) DeleteReward(rewardId uuid.UUID, restaurantId string) error {
+	result := repo.Db.Where("id = ? AND restaurant_id = ?", rewardId, restaurantId).Delete(&model.LoyaltyReward{})
+	if result.Error != nil {
+		log.Error().
+			Str("reward_id", rewardId.String()).
+			Err(result.Error).
+			Msg("Error deleting reward")
+		return fmt.Errorf("error deleting reward: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("reward with ID %s not found for restaurant %s: %w", rewardId, restaurantId, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// Balance computes a customer's balance from their ledger. Reading a balance with lapsed
+// credits records their expiry first, so the ledger always explains the balance shown.
+func (repo *LoyaltyRepositoryImpl) Balance(customerId uuid.UUID, restaurantId string, at time.Time) (model.LoyaltyBalance, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return model.LoyaltyBalance{}, fmt.Errorf("invalid restaurant ID %q: %w", restaurantId, err)
+	}
+	balance, err := loadLoyaltyBalance(repo.Db, restaurantUUID, customerId)
+	if err == nil && len(lapsedLoyaltyLots(balance, at)) > 0 {
+		err = repo.Db.Transaction(func(tx *gorm.DB) error {
+			if err := lockLoyaltyAccount(tx, restaurantUUID, customerId); err != nil {
+				return err
+			}
+			var err error
+			balance, err = settleLoyalty(tx, restaurantUUID, customerId, at)
+			return err
+		})
+	}
+	if err != nil {
+		log.Error().
+			Str("customer_id", customerId.String()).
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error computing loyalty balance")
+		return model.LoyaltyBalance{}, fmt.Errorf("error computing loyalty balance: %w", err)
+	}
+	return balance, nil
+}
+
+// FindEntries retrieves a page of a customer's ledger, oldest entries first.
+func (repo *LoyaltyRepositoryImpl) FindEntries(customerId uuid.UUID, restaurantId string, page pagination.Page) ([]model.LoyaltyEntry, pagination.Result, error) {
+	query := repo.Db.Model(&model.LoyaltyEntry{}).Where("customer_id = ? AND restaurant_id = ?", customerId, restaurantId)
+	entries, result, err := findPage[model.LoyaltyEntry](query, page)
+	if err != nil {
+		log.Error().
+			Str("customer_id", customerId.String()).
+			Err(err).
+			Msg("Error finding loyalty entries")
+		return nil, result, fmt.Errorf("error finding loyalty entries: %w", err)
+	}
+	return entries, result, nil
+}
+
+// Adjust appends a staff adjustment to the ledger of a customer of the restaurant.
+func (repo *LoyaltyRepositoryImpl) Adjust(entry model.LoyaltyEntry) (model.LoyaltyEntry, error) {
+	entry.Kind = model.LoyaltyEntryAdjust
+	var created model.LoyaltyEntry
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var customers int64
+		if err := tx.Model(&model.User{}).Where("id = ? AND restaurant_id = ?", entry.CustomerID, entry.RestaurantID).Count(&customers).Error; err != nil {
+			return fmt.Errorf("error finding customer: %w", err)
+		}
+		if customers == 0 {
+			return fmt.Errorf("customer with ID %s not found for restaurant %s: %w", entry.CustomerID, entry.RestaurantID, gorm.ErrRecordNotFound)
+		}
+		var err error
+		created, err = appendLoyaltyEntry(tx, entry, time.Now())
+		return err
+	})
+	if err != nil {
+		log.Warn().
+			Str("customer_id", entry.CustomerID.String()).
+			Int("points", entry.Points).
+			Err(err).
+			Msg("Loyalty adjustment failed")
+		return model.LoyaltyEntry{}, err
+	}
+	log.Info().
+		Str("customer_id", entry.CustomerID.String()).
+		Int("points", entry.Points).
+		Msg("Loyalty points adjusted")
+	return created, nil
+}
+
+// findLoyaltyProgram retrieves the loyalty program of a restaurant with db, or a disabled
+// program when it has none.
+func findLoyaltyProgram(db *gorm.DB, restaurantId string) (model.LoyaltyProgram, error) {
+	var program model.LoyaltyProgram
+	err := db.Where("restaurant_id = ?", restaurantId).Limit(1).Find(&program).Error
+	return program, err
+}
+
+// loyaltyExpiry returns when points credited at the given time expire under a program.
+func loyaltyExpiry(program model.LoyaltyProgram, at time.Time) *time.Time {
+	if program.ExpiryDays <= 0 {
+		return nil
+	}
+	expiresAt := at.AddDate(0, 0, program.ExpiryDays)
+	return &expiresAt
+}
+
+// lockLoyaltyAccount creates a customer's loyalty account when missing and locks it until
+// the transaction of db ends. Every write to a ledger happens under this lock.
+func lockLoyaltyAccount(db *gorm.DB, restaurantId uuid.UUID, customerId uuid.UUID) error {
+	account := model.LoyaltyAccount{RestaurantID: restaurantId, CustomerID: customerId}
+	account.ID = uuid.New()
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "restaurant_id"}, {Name: "customer_id"}},
+		DoNothing: true,
+	}).Create(&account).Error; err != nil {
+		return fmt.Errorf("error creating loyalty account: %w", err)
+	}
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("restaurant_id = ? AND customer_id = ?", restaurantId, customerId).
+		First(&model.LoyaltyAccount{}).Error; err != nil {
+		return fmt.Errorf("error locking loyalty account: %w", err)
+	}
+	return nil
+}
+
+// appendLoyaltyEntry appends an entry to a customer's ledger with db, which must be a
+// transaction, after recording the expiry of the credits that lapsed. Credits get the
+// expiry of the restaurant's program unless one is set; debits fail with
+// ErrInsufficientPoints when they exceed the balance.
+func appendLoyaltyEntry(db *gorm.DB, entry model.LoyaltyEntry, at time.Time) (model.LoyaltyEntry, error) {
+	if err := lockLoyaltyAccount(db, entry.RestaurantID, entry.CustomerID); err != nil {
+		return model.LoyaltyEntry{}, err
+	}
+	balance, err := settleLoyalty(db, entry.RestaurantID, entry.CustomerID, at)
+	if err != nil {
+		return model.LoyaltyEntry{}, err
+	}
+	if entry.Points < 0 && balance.Points+entry.Points < 0 {
+		return model.LoyaltyEntry{}, fmt.Errorf("%w: %d needed, %d available", ErrInsufficientPoints, -entry.Points, balance.Points)
+	}
+	if entry.Points > 0 && entry.ExpiresAt == nil {
+		program, err := findLoyaltyProgram(db, entry.RestaurantID.String())
+		if err != nil {
+			return model.LoyaltyEntry{}, fmt.Errorf("error finding loyalty program: %w", err)
+		}
+		entry.ExpiresAt = loyaltyExpiry(program, at)
+	}
+
+	entry.ID = uuid.New()
+	if err := db.Create(&entry).Error; err != nil {
+		return model.LoyaltyEntry{}, fmt.Errorf("error creating loyalty entry: %w", err)
+	}
+	return entry, nil
+}
+
+// settleLoyalty records the expiry of a customer's lapsed credits with db, which must hold
+// the lock on their account, and returns the balance that is left.
+func settleLoyalty(db *gorm.DB, restaurantId uuid.UUID, customerId uuid.UUID, at time.Time) (model.LoyaltyBalance, error) {
+	balance, err := loadLoyaltyBalance(db, restaurantId, customerId)
+	if err != nil {
+		return model.LoyaltyBalance{}, err
+	}
+	lapsed := lapsedLoyaltyLots(balance, at)
+	for _, lot := range lapsed {
+		expiry := model.LoyaltyEntry{
+			RestaurantID:  restaurantId,
+			CustomerID:    customerId,
+			Kind:          model.LoyaltyEntryExpire,
+			Points:        -lot.Points,
+			SourceEntryID: &lot.EntryID,
+		}
+		expiry.ID = uuid.New()
+		if err := db.Create(&expiry).Error; err != nil {
+			return model.LoyaltyBalance{}, fmt.Errorf("error recording loyalty expiry: %w", err)
+		}
+		balance.Points -= lot.Points
+		balance.Totals[model.LoyaltyEntryExpire] -= lot.Points
+	}
+	if len(lapsed) > 0 {
+		balance.Lots = slices.DeleteFunc(balance.Lots, func(lot model.LoyaltyLot) bool {
+			return lot.ExpiresAt != nil && !lot.ExpiresAt.After(at)
+		})
+	}
+	return balance, nil
+}
+
+// loadLoyaltyBalance computes a customer's balance from their whole ledger.
+func loadLoyaltyBalance(db *gorm.DB, restaurantId uuid.UUID, customerId uuid.UUID) (model.LoyaltyBalance, error) {
+	var entries []model.LoyaltyEntry
+	if err := db.Where("restaurant_id = ? AND customer_id = ?", restaurantId, customerId).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error; err != nil {
+		return model.LoyaltyBalance{}, fmt.Errorf("error finding loyalty entries: %w", err)
+	}
+	return loyaltyBalance(entries), nil
+}
+
+// loyaltyBalance replays a ledger in order. Every credit opens a lot; an expire entry
+// empties the lot it names, and every other debit draws from the lots still valid at its
+// time, soonest to expire first and oldest first among equals, so customers always spend
+// the points they would otherwise lose first.
+func loyaltyBalance(entries []model.LoyaltyEntry) model.LoyaltyBalance {
+	balance := model.LoyaltyBalance{Totals: map[string]int{}}
+	var lots []model.LoyaltyLot
+	for _, entry := range entries {
+		balance.Points += entry.Points
+		balance.Totals[entry.Kind] += entry.Points
+		switch {
+		case entry.Points > 0:
+			lots = append(lots, model.LoyaltyLot{EntryID: entry.ID, Points: entry.Points, ExpiresAt: entry.ExpiresAt})
+		case entry.SourceEntryID != nil:
+			for i := range lots {
+				if lots[i].EntryID == *entry.SourceEntryID {
+					lots[i].Points += entry.Points
+				}
+			}
+		case entry.Points < 0:
+			drawLoyaltyLots(lots, -entry.Points, entry.CreatedAt)
+		}
+	}
+
+	lots = slices.DeleteFunc(lots, func(lot model.LoyaltyLot) bool { return lot.Points <= 0 })
+	slices.SortStableFunc(lots, func(a, b model.LoyaltyLot) int { return compareLoyaltyExpiry(a.ExpiresAt, b.ExpiresAt) })
+	balance.Lots = lots
+	return balance
+}
+
+// drawLoyaltyLots takes points from the lots valid at the given time, soonest to expire first.
+func drawLoyaltyLots(lots []model.LoyaltyLot, points int, at time.Time) {
+	valid := make([]int, 0, len(lots))
+	for i, lot := range lots {
+		if lot.Points > 0 && (lot.ExpiresAt == nil || lot.ExpiresAt.After(at)) {
+			valid = append(valid, i)
+		}
+	}
+	slices.SortStableFunc(valid, func(a, b int) int { return compareLoyaltyExpiry(lots[a].ExpiresAt, lots[b].ExpiresAt) })
+	for _, i := range valid {
+		if points == 0 {
+			return
+		}
+		taken := min(points, lots[i].Points)
+		lots[i].Points -= taken
+		points -= taken
+	}
+}
+
+// compareLoyaltyExpiry orders expiry times soonest first, with credits that never expire last.
+func compareLoyaltyExpiry(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// lapsedLoyaltyLots returns the lots of a balance that expired by the given time.
+func lapsedLoyaltyLots(balance model.LoyaltyBalance, at time.Time) []model.LoyaltyLot {
+	var lapsed []model.LoyaltyLot
+	for _, lot := range balance.Lots {
+		if lot.ExpiresAt != nil && !lot.ExpiresAt.After(at) {
+			lapsed = append(lapsed, lot)
+		}
+	}
+	return lapsed
+}
+
+// redeemReward spends the points of the reward a new order redeems with db, which must be
+// a transaction. It fails with ErrRewardUnavailable when the reward changed since the
+// order was priced and with ErrInsufficientPoints when the customer cannot afford it.
+func redeemReward(db *gorm.DB, order model.Order) error {
+	if order.RewardID == nil {
+		return nil
+	}
+	program, err := findLoyaltyProgram(db, order.RestaurantID.String())
+	if err != nil {
+		return fmt.Errorf("error finding loyalty program: %w", err)
+	}
+	var reward model.LoyaltyReward
+	result := db.Where("id = ? AND restaurant_id = ?", *order.RewardID, order.RestaurantID).Limit(1).Find(&reward)
+	if result.Error != nil {
+		return fmt.Errorf("error finding reward: %w", result.Error)
+	}
+	if !program.Enabled || result.RowsAffected == 0 || !reward.Active || reward.PointsCost != order.RewardPoints {
+		return fmt.Errorf("%w: %s", ErrRewardUnavailable, order.RewardName)
+	}
+
+	_, err = appendLoyaltyEntry(db, model.LoyaltyEntry{
+		RestaurantID: order.RestaurantID,
+		CustomerID:   order.CustomerID,
+		Kind:         model.LoyaltyEntryRedeem,
+		Points:       -order.RewardPoints,
+		OrderID:      &order.ID,
+		RewardID:     order.RewardID,
+		Note:         order.RewardName,
+	}, time.Now())
+	return err
+}
+
+// settleOrderLoyalty credits the points of an order that was just served or collected, or
+// returns the points it redeemed when it was just cancelled or rejected, with db, which
+// must be a transaction. An order never earns or is refunded twice.
+func settleOrderLoyalty(db *gorm.DB, order model.Order) error {
+	entry := model.LoyaltyEntry{
+		RestaurantID: order.RestaurantID,
+		CustomerID:   order.CustomerID,
+		OrderID:      &order.ID,
+	}
+	switch order.Status {
+	case model.OrderStatusServed, model.OrderStatusCollected:
+		program, err := findLoyaltyProgram(db, order.RestaurantID.String())
+		if err != nil {
+			return fmt.Errorf("error finding loyalty program: %w", err)
+		}
+		if !program.Enabled {
+			return nil
+		}
+		// Totals are in cents, the small term keeps products such as 10.1 * 10 from rounding down a point
+		entry.Kind = model.LoyaltyEntryEarnOrder
+		entry.Points = int(math.Floor(order.Total*program.PointsPerUnit + 1e-6))
+	case model.OrderStatusCancelled, model.OrderStatusRejected:
+		entry.Kind = model.LoyaltyEntryRefund
+		entry.Points = order.RewardPoints
+		entry.RewardID = order.RewardID
+		entry.Note = order.RewardName
+	}
+	if entry.Points <= 0 {
+		return nil
+	}
+
+	var existing int64
+	if err := db.Model(&model.LoyaltyEntry{}).Where("kind = ? AND order_id = ?", entry.Kind, order.ID).Count(&existing).Error; err != nil {
+		return fmt.Errorf("error finding loyalty entries: %w", err)
+	}
+	if existing > 0 {
+		return nil
+	}
+	if _, err := appendLoyaltyEntry(db, entry, time.Now()); err != nil {
+		return err
+	}
+	log.Info().
+		Str("order_id", order.ID.String()).
+		Str("kind", entry.Kind).
+		Int("points", entry.Points).
+		Msg("Order loyalty points recorded")
+	return nil
+}
+
+// creditReview credits the review points of a review that was just approved with db, which
+// must be a transaction. Only the first approved review of each dish by a customer earns points.
+func creditReview(db *gorm.DB, rating model.Rating, restaurantId uuid.UUID) error {
+	program, err := findLoyaltyProgram(db, restaurantId.String())
+	if err != nil {
+		return fmt.Errorf("error finding loyalty program: %w", err)
+	}
+	if !program.Enabled || program.ReviewPoints <= 0 {
+		return nil
+	}
+	if err := lockLoyaltyAccount(db, restaurantId, rating.UserID); err != nil {
+		return err
+	}
+
+	var earned int64
+	if err := db.Model(&model.LoyaltyEntry{}).
+		Joins("JOIN ratings ON ratings.id = loyalty_entries.rating_id").
+		Where("loyalty_entries.kind = ? AND loyalty_entries.restaurant_id = ? AND loyalty_entries.customer_id = ? AND ratings.dish_id = ?",
+			model.LoyaltyEntryEarnReview, restaurantId, rating.UserID, rating.DishID).
+		Count(&earned).Error; err != nil {
+		return fmt.Errorf("error finding loyalty entries: %w", err)
+	}
+	if earned > 0 {
+		return nil
+	}
+
+	_, err = appendLoyaltyEntry(db, model.LoyaltyEntry{
+		RestaurantID: restaurantId,
+		CustomerID:   rating.UserID,
+		Kind:         model.LoyaltyEntryEarnReview,
+		Points:       program.ReviewPoints,
+		RatingID:     &rating.ID,
+	}, time.Now())
+	return err
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PurgeResult counts the soft-deleted rows of a table a purge deleted, and those it
+// kept because other rows still reference them.
+type PurgeResult struct {
+	Table  string
+	Purged int64
+	Kept   int64
+}
+
+// TenantWriter receives the rows of a restaurant's data, table by table.
+type TenantWriter interface {
+	BeginTable(table string) error
+	WriteRow(row map[string]interface{}) error
+}
+
+// MaintenanceRepository defines the data operations of the operations commands, which
+// work across tables and restaurants.
+type MaintenanceRepository interface {
+	// FindRestaurantIds retrieves the IDs of every restaurant.
+	FindRestaurantIds() ([]uuid.UUID, error)
+
+	// PurgeDeleted permanently deletes the rows soft-deleted before the given time, in
+	// batches of batchSize, except those other rows still reference through a foreign key.
+	// With dryRun set nothing is deleted and the rows that would be are counted instead.
+	PurgeDeleted(before time.Time, batchSize int, dryRun bool) ([]PurgeResult, error)
+
+	// ExportTenant writes every row belonging to a restaurant, soft-deleted ones included,
+	// from a single consistent snapshot. It returns gorm.ErrRecordNotFound when there is
+	// no such restaurant, not even soft-deleted.
+	ExportTenant(restaurantId uuid.UUID, writer TenantWriter) error
+
+	// ReindexSearch rebuilds the indexes of the dish and restaurant searches and refreshes
+	// the planner statistics of their tables.
+	ReindexSearch() error
+}
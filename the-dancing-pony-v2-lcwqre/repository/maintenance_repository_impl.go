@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// searchIndexes serve the ILIKE name searches of dishes and restaurants.
+var searchIndexes = []string{"idx_dishes_name_trgm", "idx_restaurants_name_trgm"}
+
+// searchTables are the tables the search indexes are built on.
+var searchTables = []string{"dishes", "restaurants"}
+
+// tenantTable is a table holding restaurant data, with the condition selecting the rows
+// of the restaurant given as @restaurant.
+type tenantTable struct {
+	name  string
+	where string
+}
+
+// tenantChildTables hold restaurant data without a restaurant_id column; their rows
+// belong to the restaurant of the row they reference.
+var tenantChildTables = []tenantTable{
+	{"user_permissions", "user_id IN (SELECT id FROM users WHERE restaurant_id = @restaurant)"},
+	{"ratings", "dish_id IN (SELECT id FROM dishes WHERE restaurant_id = @restaurant)"},
+	{"order_items", "order_id IN (SELECT id FROM orders WHERE restaurant_id = @restaurant)"},
+	{"cart_items", "cart_id IN (SELECT id FROM carts WHERE restaurant_id = @restaurant)"},
+	{"refunds", "payment_id IN (SELECT id FROM payments WHERE restaurant_id = @restaurant)"},
+	{"webhook_attempts", "delivery_id IN (SELECT id FROM webhook_deliveries WHERE restaurant_id = @restaurant)"},
+}
+
+// foreignKey is a single column foreign key referencing a table.
+type foreignKey struct {
+	Table        string // Referencing table
+	Column       string // Referencing column
+	ParentColumn string // Referenced column
+}
+
+// MaintenanceRepositoryImpl implements MaintenanceRepository interface.
+type MaintenanceRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewMaintenanceRepositoryImpl creates a new instance of MaintenanceRepositoryImpl.
+func NewMaintenanceRepositoryImpl(db *gorm.DB) MaintenanceRepository {
+	return &MaintenanceRepositoryImpl{Db: db}
+}
+
+// FindRestaurantIds retrieves the IDs of every restaurant, oldest first.
+func (repo *MaintenanceRepositoryImpl) FindRestaurantIds() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := repo.Db.Model(&model.Restaurant{}).Order("created_at ASC").Pluck("id", &ids).Error; err != nil {
+		log.Error().Err(err).Msg("Error finding restaurant IDs")
+		return nil, fmt.Errorf("error finding restaurant IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// PurgeDeleted purges every table with a deleted_at column. Tables are purged again
+// while rows are deleted, since purging a table can release the rows it referenced.
+func (repo *MaintenanceRepositoryImpl) PurgeDeleted(before time.Time, batchSize int, dryRun bool) ([]PurgeResult, error) {
+	tables, err := tablesWithColumn(repo.Db, "deleted_at")
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make(map[string]string, len(tables))
+	for _, table := range tables {
+		references, err := referencesTo(repo.Db, table)
+		if err != nil {
+			return nil, err
+		}
+		condition := "deleted_at < @before"
+		for _, reference := range references {
+			condition += fmt.Sprintf(" AND NOT EXISTS (SELECT 1 FROM %s r WHERE r.%s = %s.%s)",
+				quoteIdent(reference.Table), quoteIdent(reference.Column), quoteIdent(table), quoteIdent(reference.ParentColumn))
+		}
+		conditions[table] = condition
+	}
+	args := sql.Named("before", before)
+
+	purged := make(map[string]int64, len(tables))
+	for pass := 0; pass < len(tables); pass++ {
+		deleted := false
+		for _, table := range tables {
+			if dryRun {
+				var count int64
+				query := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s", quoteIdent(table), conditions[table])
+				if err := repo.Db.Raw(query, args).Scan(&count).Error; err != nil {
+					return nil, fmt.Errorf("error counting purgeable rows of %s: %w", table, err)
+				}
+				purged[table] = count
+				continue
+			}
+
+			query := fmt.Sprintf("DELETE FROM %s WHERE ctid = ANY(ARRAY(SELECT ctid FROM %s WHERE %s LIMIT %d))",
+				quoteIdent(table), quoteIdent(table), conditions[table], batchSize)
+			for {
+				result := repo.Db.Exec(query, args)
+				if result.Error != nil {
+					log.Error().
+						Str("table", table).
+						Err(result.Error).
+						Msg("Error purging soft-deleted rows")
+					return nil, fmt.Errorf("error purging soft-deleted rows of %s: %w", table, result.Error)
+				}
+				purged[table] += result.RowsAffected
+				deleted = deleted || result.RowsAffected > 0
+				if result.RowsAffected < int64(batchSize) {
+					break
+				}
+			}
+		}
+		if !deleted {
+			break
+		}
+	}
+
+	var results []PurgeResult
+	for _, table := range tables {
+		var remaining int64
+		query := fmt.Sprintf("SELECT count(*) FROM %s WHERE deleted_at < @before", quoteIdent(table))
+		if err := repo.Db.Raw(query, args).Scan(&remaining).Error; err != nil {
+			return nil, fmt.Errorf("error counting soft-deleted rows of %s: %w", table, err)
+		}
+		result := PurgeResult{Table: table, Purged: purged[table], Kept: remaining}
+		if dryRun {
+			result.Kept -= result.Purged
+		}
+		if result.Purged > 0 || result.Kept > 0 {
+			results = append(results, result)
+		}
+	}
+
+	log.Info().
+		Time("before", before).
+		Bool("dry_run", dryRun).
+		Int("tables", len(results)).
+		Msg("Soft-deleted rows purged successfully")
+	return results, nil
+}
+
+// ExportTenant writes the restaurant, the rows of every table with a restaurant_id
+// column, and the rows of the child tables, in a read-only repeatable read transaction.
+func (repo *MaintenanceRepositoryImpl) ExportTenant(restaurantId uuid.UUID, writer TenantWriter) error {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&model.Restaurant{}).Where("id = ?", restaurantId).Count(&count).Error; err != nil {
+			return fmt.Errorf("error finding restaurant: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("restaurant %s: %w", restaurantId, gorm.ErrRecordNotFound)
+		}
+
+		tables := []tenantTable{{name: "restaurants", where: "id = @restaurant"}}
+		names, err := tablesWithColumn(tx, "restaurant_id")
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			tables = append(tables, tenantTable{name: name, where: "restaurant_id = @restaurant"})
+		}
+		tables = append(tables, tenantChildTables...)
+
+		for _, table := range tables {
+			if err := writer.BeginTable(table.name); err != nil {
+				return err
+			}
+			query := fmt.Sprintf("SELECT * FROM %s WHERE %s", quoteIdent(table.name), table.where)
+			rows, err := tx.Raw(query, sql.Named("restaurant", restaurantId.String())).Rows()
+			if err != nil {
+				return fmt.Errorf("error reading %s: %w", table.name, err)
+			}
+			if err := writeRows(rows, writer); err != nil {
+				return fmt.Errorf("error exporting %s: %w", table.name, err)
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId.String()).
+			Err(err).
+			Msg("Error exporting restaurant data")
+		return err
+	}
+	return nil
+}
+
+// ReindexSearch rebuilds the search indexes without blocking writes to their tables.
+func (repo *MaintenanceRepositoryImpl) ReindexSearch() error {
+	for _, index := range searchIndexes {
+		if err := repo.Db.Exec("REINDEX INDEX CONCURRENTLY " + quoteIdent(index)).Error; err != nil {
+			log.Error().
+				Str("index", index).
+				Err(err).
+				Msg("Error rebuilding search index")
+			return fmt.Errorf("error rebuilding search index %s: %w", index, err)
+		}
+		log.Info().Str("index", index).Msg("Search index rebuilt successfully")
+	}
+
+	quoted := make([]string, len(searchTables))
+	for i, table := range searchTables {
+		quoted[i] = quoteIdent(table)
+	}
+	if err := repo.Db.Exec("ANALYZE " + strings.Join(quoted, ", ")).Error; err != nil {
+		return fmt.Errorf("error analyzing search tables: %w", err)
+	}
+	return nil
+}
+
+// writeRows hands each row to the writer as a map of column names to values. JSON
+// columns are kept as raw JSON and other byte values are turned into strings.
+func writeRows(rows *sql.Rows, writer TenantWriter) error {
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			value := values[i]
+			if data, ok := value.([]byte); ok {
+				switch column.DatabaseTypeName() {
+				case "JSON", "JSONB":
+					value = json.RawMessage(data)
+				default:
+					value = string(data)
+				}
+			}
+			row[column.Name()] = value
+		}
+		if err := writer.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// tablesWithColumn lists the tables of the current schema that have the given column.
+func tablesWithColumn(db *gorm.DB, column string) ([]string, error) {
+	var tables []string
+	err := db.Raw(`SELECT c.table_name FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE' AND c.column_name = ?
+		ORDER BY c.table_name`, column).Scan(&tables).Error
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables with a %s column: %w", column, err)
+	}
+	return tables, nil
+}
+
+// referencesTo lists the single column foreign keys referencing a table of the current schema.
+func referencesTo(db *gorm.DB, table string) ([]foreignKey, error) {
+	var references []foreignKey
+	err := db.Raw(`SELECT child.relname AS "table", child_column.attname AS "column", parent_column.attname AS parent_column
+		FROM pg_constraint con
+		JOIN pg_class child ON child.oid = con.conrelid
+		JOIN pg_class parent ON parent.oid = con.confrelid
+		JOIN pg_attribute child_column ON child_column.attrelid = con.conrelid AND child_column.attnum = con.conkey[1]
+		JOIN pg_attribute parent_column ON parent_column.attrelid = con.confrelid AND parent_column.attnum = con.confkey[1]
+		WHERE con.contype = 'f' AND cardinality(con.conkey) = 1
+		AND parent.relname = ? AND parent.relnamespace = current_schema()::regnamespace`, table).Scan(&references).Error
+	if err != nil {
+		return nil, fmt.Errorf("error listing references to %s: %w", table, err)
+	}
+	return references, nil
+}
+
+// quoteIdent quotes a table, column or index name for use in SQL.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// OrdersRepository defines the data operations for customer orders.
+type OrdersRepository interface {
+	// Create stores a new order with its items.
+	Create(order model.Order) (model.Order, error)
+
+	// FindById retrieves an order with its items for a specific restaurant.
+	FindById(orderId uuid.UUID, restaurantId string) (model.Order, error)
+
+	// FindCustomerOrder retrieves an order with its items only if it belongs to the customer.
+	FindCustomerOrder(orderId uuid.UUID, customerId uuid.UUID, restaurantId string) (model.Order, error)
+
+	// FindByCustomer retrieves a page of a customer's orders at a restaurant.
+	FindByCustomer(customerId uuid.UUID, restaurantId string, page pagination.Page) ([]model.Order, pagination.Result, error)
+
+	// FindByRestaurant retrieves a page of a restaurant's orders, optionally only those in the given statuses.
+	FindByRestaurant(restaurantId string, statuses []string, page pagination.Page) ([]model.Order, pagination.Result, error)
+
+	// Transition moves an order from one status to another, writing the given fields with it.
+	// It fails with ErrOrderStatusChanged when the order is no longer in the from status.
+	Transition(orderId uuid.UUID, restaurantId string, from string, fields map[string]interface{}) (model.Order, error)
+}
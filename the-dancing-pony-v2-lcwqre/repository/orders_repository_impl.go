@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// ErrOrderStatusChanged is returned when an order changed status while it was being updated.
+var ErrOrderStatusChanged = errors.New("order status has changed")
+
+// OrdersRepositoryImpl implements OrdersRepository interface.
+type OrdersRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewOrdersRepositoryImpl creates a new instance of OrdersRepositoryImpl.
+func NewOrdersRepositoryImpl(db *gorm.DB) OrdersRepository {
+	return &OrdersRepositoryImpl{Db: db}
+}
+
+// Create stores a new order with its items and redemptions in one transaction.
+func (repo *OrdersRepositoryImpl) Create(order model.Order) (model.Order, error) {
+	var created model.Order
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, err = createOrder(tx, order)
+		return err
+	})
+	return created, err
+}
+
+// FindById retrieves an order with its items for a specific restaurant.
+func (repo *OrdersRepositoryImpl) FindById(orderId uuid.UUID, restaurantId string) (model.Order, error) {
+	return repo.findOrder(repo.Db.Where("id = ? AND restaurant_id = ?", orderId, restaurantId), orderId, restaurantId)
+}
+
+// FindCustomerOrder retrieves an order with its items only if it belongs to the customer.
+// Other customers' orders are reported as not found rather than forbidden.
+func (repo *OrdersRepositoryImpl) FindCustomerOrder(orderId uuid.UUID, customerId uuid.UUID, restaurantId string) (model.Order, error) {
+	return repo.findOrder(repo.Db.Where("id = ? AND customer_id = ? AND restaurant_id = ?", orderId, customerId, restaurantId), orderId, restaurantId)
+}
+
+// FindByCustomer retrieves a page of a customer's orders at a restaurant.
+func (repo *OrdersRepositoryImpl) FindByCustomer(customerId uuid.UUID, restaurantId string, page pagination.Page) ([]model.Order, pagination.Result, error) {
+	query := repo.Db.Model(&model.Order{}).Where("customer_id = ? AND restaurant_id = ?", customerId, restaurantId)
+	return repo.findPage(query, page)
+}
+
+// FindByRestaurant retrieves a page of a restaurant's orders, optionally only those in the given statuses.
+func (repo *OrdersRepositoryImpl) FindByRestaurant(restaurantId string, statuses []string, page pagination.Page) ([]model.Order, pagination.Result, error) {
+	query := repo.Db.Model(&model.Order{}).Where("restaurant_id = ?", restaurantId)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	return repo.findPage(query, page)
+}
+
+// Transition moves an order from one status to another, writing the given fields with it.
+// The loyalty points the order earns, or the points it redeemed and gets back, are
+// recorded in the same transaction, as is the invoice of an order served or collected.
+func (repo *OrdersRepositoryImpl) Transition(orderId uuid.UUID, restaurantId string, from string, fields map[string]interface{}) (model.Order, error) {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Order{}).
+			Where("id = ? AND restaurant_id = ? AND status = ?", orderId, restaurantId, from).
+			Updates(fields)
+		if result.Error != nil {
+			return fmt.Errorf("error updating order status: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderStatusChanged
+		}
+		var order model.Order
+		if err := tx.Where("id = ?", orderId).First(&order).Error; err != nil {
+			return fmt.Errorf("error finding order: %w", err)
+		}
+		if err := settleOrderLoyalty(tx, order); err != nil {
+			return err
+		}
+		if order.Status == model.OrderStatusServed || order.Status == model.OrderStatusCollected {
+			if _, err := issueInvoice(tx, order); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrOrderStatusChanged) {
+		if _, err := repo.FindById(orderId, restaurantId); err != nil {
+			return model.Order{}, err
+		}
+		return model.Order{}, ErrOrderStatusChanged
+	}
+	if err != nil {
+		log.Error().
+			Str("order_id", orderId.String()).
+			Err(err).
+			Msg("Error updating order status")
+		return model.Order{}, err
+	}
+	log.Info().
+		Str("order_id", orderId.String()).
+		Str("from", from).
+		Interface("status", fields["Status"]).
+		Msg("Order status updated successfully")
+	return repo.FindById(orderId, restaurantId)
+}
+
+// findOrder retrieves the order matched by query with its items.
+func (repo *OrdersRepositoryImpl) findOrder(query *gorm.DB, orderId uuid.UUID, restaurantId string) (model.Order, error) {
+	var order model.Order
+	result := query.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Redemptions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, id ASC")
+	}).Preload("Invoice").First(&order)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			log.Warn().
+				Str("order_id", orderId.String()).
+				Str("restaurant_id", restaurantId).
+				Msg("Order not found")
+			return model.Order{}, fmt.Errorf("order with ID %s not found for restaurant %s: %w", orderId, restaurantId, result.Error)
+		}
+		log.Error().
+			Str("order_id", orderId.String()).
+			Err(result.Error).
+			Msg("Error finding order")
+		return model.Order{}, fmt.Errorf("error finding order: %w", result.Error)
+	}
+	return order, nil
+}
+
+// findPage retrieves a page of orders and loads their items, redemptions and invoices in further queries.
+func (repo *OrdersRepositoryImpl) findPage(query *gorm.DB, page pagination.Page) ([]model.Order, pagination.Result, error) {
+	orders, result, err := findPage[model.Order](query, page)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error finding orders")
+		return nil, result, fmt.Errorf("error finding orders: %w", err)
+	}
+	if len(orders) == 0 {
+		return orders, result, nil
+	}
+
+	ids := make([]uuid.UUID, len(orders))
+	positions := make(map[uuid.UUID]int, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+		positions[order.ID] = i
+	}
+	var items []model.OrderItem
+	if err := repo.Db.Where("order_id IN ?", ids).Order("created_at ASC").Find(&items).Error; err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error finding order items")
+		return nil, result, fmt.Errorf("error finding order items: %w", err)
+	}
+	for _, item := range items {
+		i := positions[item.OrderID]
+		orders[i].Items = append(orders[i].Items, item)
+	}
+
+	var redemptions []model.PromotionRedemption
+	if err := repo.Db.Where("order_id IN ?", ids).Order("created_at ASC, id ASC").Find(&redemptions).Error; err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error finding order redemptions")
+		return nil, result, fmt.Errorf("error finding order redemptions: %w", err)
+	}
+	for _, redemption := range redemptions {
+		i := positions[redemption.OrderID]
+		orders[i].Redemptions = append(orders[i].Redemptions, redemption)
+	}
+
+	var invoices []model.Invoice
+	if err := repo.Db.Where("order_id IN ?", ids).Find(&invoices).Error; err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error finding order invoices")
+		return nil, result, fmt.Errorf("error finding order invoices: %w", err)
+	}
+	for _, invoice := range invoices {
+		orders[positions[invoice.OrderID]].Invoice = &invoice
+	}
+	return orders, result, nil
+}
+
+// createOrder assigns IDs to an order, its items and its redemptions and stores them
+// with db, which must be a transaction, spending the points of the reward it redeems.
+// It fails with ErrPromotionUnavailable when a redeemed promotion is no longer available
+// and with ErrRewardUnavailable or ErrInsufficientPoints when the reward cannot be redeemed.
+func createOrder(db *gorm.DB, order model.Order) (model.Order, error) {
+	order.ID = uuid.New()
+	for i := range order.Items {
+		order.Items[i].ID = uuid.New()
+		order.Items[i].OrderID = order.ID
+	}
+	for i := range order.Redemptions {
+		order.Redemptions[i].ID = uuid.New()
+		order.Redemptions[i].OrderID = order.ID
+		order.Redemptions[i].RestaurantID = order.RestaurantID
+		order.Redemptions[i].CustomerID = order.CustomerID
+	}
+	if err := redeemPromotions(db, order); err != nil {
+		log.Warn().
+			Str("restaurant_id", order.RestaurantID.String()).
+			Str("customer_id", order.CustomerID.String()).
+			Err(err).
+			Msg("Order redeems an unavailable promotion")
+		return model.Order{}, err
+	}
+	if err := redeemReward(db, order); err != nil {
+		log.Warn().
+			Str("restaurant_id", order.RestaurantID.String()).
+			Str("customer_id", order.CustomerID.String()).
+			Err(err).
+			Msg("Order reward could not be redeemed")
+		return model.Order{}, err
+	}
+	if result := db.Create(&order); result.Error != nil {
+		log.Error().
+			Str("restaurant_id", order.RestaurantID.String()).
+			Str("customer_id", order.CustomerID.String()).
+			Err(result.Error).
+			Msg("Error creating order")
+		return model.Order{}, fmt.Errorf("error creating order: %w", result.Error)
+	}
+	log.Info().
+		Str("order_id", order.ID.String()).
+		Int("items", len(order.Items)).
+		Msg("Order created successfully")
+	return order, nil
+}
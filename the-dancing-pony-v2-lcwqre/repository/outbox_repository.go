@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/eventbus"
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// OutboxRepository defines the data operations for relaying the transactional outbox.
+// Events are written by the other repositories, in the transactions of their changes.
+type OutboxRepository interface {
+	// Consume hands up to limit committed events of the given types (every type when
+	// empty) that follow the consumer's offset to handle, in commit order, and moves the
+	// offset past those handled. An event handle fails on is retried by later calls, and
+	// recorded as a dead letter once it failed maxAttempts times. It returns how many
+	// events the offset moved past; a consumer another replica is relaying is left alone
+	// and 0 is returned.
+	Consume(consumer string, types []string, limit int, maxAttempts int, handle func(eventbus.Event) error) (int, error)
+
+	// FindDeadLetters retrieves the dead letters of a consumer, or of every consumer when
+	// consumer is empty.
+	FindDeadLetters(consumer string) ([]model.OutboxDeadLetter, error)
+
+	// RequestReplay marks dead letters to be handed to their consumer again, all of them
+	// when consumer and eventId are empty, and returns how many it marked.
+	RequestReplay(consumer string, eventId uuid.UUID) (int64, error)
+
+	// Replay hands up to limit dead letters of a consumer marked for replay to handle,
+	// deletes those handled and returns how many it deleted.
+	Replay(consumer string, limit int, handle func(eventbus.Event) error) (int, error)
+
+	// Prune deletes the events older than before that every given consumer has moved
+	// past, and returns how many it deleted.
+	Prune(consumers []string, before time.Time) (int64, error)
+}
@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/eventbus"
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// committedBelow matches the events of transactions older than every transaction still
+// running. Later commits always get higher transaction IDs, so consumers can move past
+// these without skipping an event that commits afterwards.
+const committedBelow = "transaction_id < pg_snapshot_xmin(pg_current_snapshot())::text::bigint"
+
+// OutboxRepositoryImpl implements OutboxRepository interface.
+type OutboxRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewOutboxRepositoryImpl creates a new instance of OutboxRepositoryImpl.
+func NewOutboxRepositoryImpl(db *gorm.DB) OutboxRepository {
+	return &OutboxRepositoryImpl{Db: db}
+}
+
+// outboxLease is how long a replica may relay a consumer before another one takes
+// over, should it stop without releasing the consumer.
+const outboxLease = 5 * time.Minute
+
+// errOutboxOffsetMoved is returned when another replica moved a consumer's offset while
+// this one handled a batch, after the lease of this one expired.
+var errOutboxOffsetMoved = errors.New("outbox offset was moved by another replica")
+
+// Consume relays events to a consumer. A lease on its offset makes each consumer relayed
+// by one replica at a time. The events are read and handled outside any transaction, so
+// a slow handler does not hold back pg_snapshot_xmin and with it every other consumer;
+// the offset is then moved in a short transaction, only if it is still where the batch
+// started.
+func (repo *OutboxRepositoryImpl) Consume(consumer string, types []string, limit int, maxAttempts int, handle func(eventbus.Event) error) (int, error) {
+	if err := repo.Db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.OutboxOffset{Consumer: consumer}).Error; err != nil {
+		return 0, fmt.Errorf("error creating outbox offset of %s: %w", consumer, err)
+	}
+
+	now := time.Now()
+	leased := repo.Db.Model(&model.OutboxOffset{}).
+		Where("consumer = ? AND (leased_until IS NULL OR leased_until < ?)", consumer, now).
+		Update("LeasedUntil", now.Add(outboxLease))
+	if leased.Error != nil {
+		return 0, fmt.Errorf("error leasing outbox offset of %s: %w", consumer, leased.Error)
+	}
+	if leased.RowsAffected == 0 {
+		return 0, nil
+	}
+
+	consumed, err := repo.consume(consumer, types, limit, maxAttempts, handle)
+	if err != nil {
+		log.Error().
+			Str("consumer", consumer).
+			Err(err).
+			Msg("Error relaying outbox events")
+		if releaseErr := repo.Db.Model(&model.OutboxOffset{}).Where("consumer = ?", consumer).Update("LeasedUntil", nil).Error; releaseErr != nil {
+			log.Error().
+				Str("consumer", consumer).
+				Err(releaseErr).
+				Msg("Error releasing outbox offset")
+		}
+		return 0, fmt.Errorf("error relaying outbox events to %s: %w", consumer, err)
+	}
+	return consumed, nil
+}
+
+// consume handles a batch of events for a consumer whose offset this replica leased,
+// then moves the offset and releases the lease. Events the consumer gives up on are
+// recorded as dead letters in the same transaction.
+func (repo *OutboxRepositoryImpl) consume(consumer string, types []string, limit int, maxAttempts int, handle func(eventbus.Event) error) (int, error) {
+	var offset model.OutboxOffset
+	if err := repo.Db.Where("consumer = ?", consumer).First(&offset).Error; err != nil {
+		return 0, err
+	}
+	start := offset
+
+	query := repo.Db.Where("(transaction_id, sequence) > (?, ?)", offset.TransactionID, offset.Sequence).Where(committedBelow)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+	var rows []model.OutboxEvent
+	if err := query.Order("transaction_id ASC, sequence ASC").Limit(limit).Find(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	consumed := 0
+	var deadLetters []model.OutboxDeadLetter
+	for _, row := range rows {
+		if err := handle(toOutboxEvent(row)); err != nil {
+			offset.Attempts++
+			offset.LastError = truncateText(err.Error(), 500)
+			if offset.Attempts < maxAttempts {
+				log.Warn().
+					Str("consumer", consumer).
+					Str("event_id", row.ID.String()).
+					Int("attempts", offset.Attempts).
+					Err(err).
+					Msg("Error handling outbox event, will retry")
+				break
+			}
+			log.Error().
+				Str("consumer", consumer).
+				Str("event_id", row.ID.String()).
+				Str("type", row.Type).
+				Int("attempts", offset.Attempts).
+				Err(err).
+				Msg("Giving up on outbox event, recording it as a dead letter")
+			deadLetters = append(deadLetters, toOutboxDeadLetter(consumer, row, offset.Attempts, offset.LastError))
+		}
+		offset.TransactionID, offset.Sequence, offset.Attempts = row.TransactionID, row.Sequence, 0
+		consumed++
+	}
+
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		if len(deadLetters) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&deadLetters).Error; err != nil {
+				return fmt.Errorf("error recording outbox dead letters: %w", err)
+			}
+		}
+		result := tx.Model(&model.OutboxOffset{}).
+			Where("consumer = ? AND transaction_id = ? AND sequence = ?", consumer, start.TransactionID, start.Sequence).
+			Updates(map[string]interface{}{
+				"TransactionID": offset.TransactionID,
+				"Sequence":      offset.Sequence,
+				"Attempts":      offset.Attempts,
+				"LastError":     offset.LastError,
+				"LeasedUntil":   nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOutboxOffsetMoved
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return consumed, nil
+}
+
+// FindDeadLetters retrieves the dead letters of a consumer, or of every consumer when
+// consumer is empty, oldest first.
+func (repo *OutboxRepositoryImpl) FindDeadLetters(consumer string) ([]model.OutboxDeadLetter, error) {
+	query := repo.Db.Order("created_at ASC, id ASC")
+	if consumer != "" {
+		query = query.Where("consumer = ?", consumer)
+	}
+	var deadLetters []model.OutboxDeadLetter
+	if err := query.Find(&deadLetters).Error; err != nil {
+		return nil, fmt.Errorf("error finding outbox dead letters: %w", err)
+	}
+	return deadLetters, nil
+}
+
+// RequestReplay marks dead letters to be handed to their consumer again and returns how
+// many it marked. An empty consumer or event ID matches every dead letter.
+func (repo *OutboxRepositoryImpl) RequestReplay(consumer string, eventId uuid.UUID) (int64, error) {
+	query := repo.Db.Model(&model.OutboxDeadLetter{}).Where("replay_requested_at IS NULL")
+	if consumer != "" {
+		query = query.Where("consumer = ?", consumer)
+	}
+	if eventId != uuid.Nil {
+		query = query.Where("event_id = ?", eventId)
+	}
+	result := query.Update("ReplayRequestedAt", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("error requesting outbox dead letter replay: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Replay hands up to limit dead letters of a consumer marked for replay to handle.
+// Each dead letter is claimed first, so replicas replay it once; those handled are
+// deleted, the others keep their error and wait for another replay request.
+func (repo *OutboxRepositoryImpl) Replay(consumer string, limit int, handle func(eventbus.Event) error) (int, error) {
+	var deadLetters []model.OutboxDeadLetter
+	if err := repo.Db.Where("consumer = ? AND replay_requested_at IS NOT NULL", consumer).
+		Order("created_at ASC, id ASC").Limit(limit).Find(&deadLetters).Error; err != nil {
+		return 0, fmt.Errorf("error finding outbox dead letters to replay: %w", err)
+	}
+
+	replayed := 0
+	for _, deadLetter := range deadLetters {
+		claimed := repo.Db.Model(&model.OutboxDeadLetter{}).
+			Where("id = ? AND replay_requested_at IS NOT NULL", deadLetter.ID).
+			Update("ReplayRequestedAt", nil)
+		if claimed.Error != nil {
+			return replayed, fmt.Errorf("error claiming outbox dead letter: %w", claimed.Error)
+		}
+		if claimed.RowsAffected == 0 {
+			continue
+		}
+
+		if err := handle(deadLetterEvent(deadLetter)); err != nil {
+			log.Warn().
+				Str("consumer", consumer).
+				Str("event_id", deadLetter.EventID.String()).
+				Err(err).
+				Msg("Error replaying outbox dead letter")
+			if err := repo.Db.Model(&deadLetter).Updates(map[string]interface{}{
+				"Attempts":  gorm.Expr("attempts + 1"),
+				"LastError": truncateText(err.Error(), 500),
+			}).Error; err != nil {
+				return replayed, fmt.Errorf("error recording outbox dead letter replay: %w", err)
+			}
+			continue
+		}
+		if err := repo.Db.Delete(&deadLetter).Error; err != nil {
+			return replayed, fmt.Errorf("error deleting replayed outbox dead letter: %w", err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// Prune deletes relayed events up to the offset of the consumer that is furthest behind.
+func (repo *OutboxRepositoryImpl) Prune(consumers []string, before time.Time) (int64, error) {
+	if len(consumers) == 0 {
+		return 0, nil
+	}
+	var offsets []model.OutboxOffset
+	if err := repo.Db.Where("consumer IN ?", consumers).Order("transaction_id ASC, sequence ASC").Find(&offsets).Error; err != nil {
+		return 0, fmt.Errorf("error finding outbox offsets: %w", err)
+	}
+	if len(offsets) < len(consumers) {
+		// A consumer that never ran still needs every event
+		return 0, nil
+	}
+
+	oldest := offsets[0]
+	result := repo.Db.Where("(transaction_id, sequence) <= (?, ?) AND occurred_at < ?", oldest.TransactionID, oldest.Sequence, before).
+		Delete(&model.OutboxEvent{})
+	if result.Error != nil {
+		log.Error().
+			Err(result.Error).
+			Msg("Error pruning outbox events")
+		return 0, fmt.Errorf("error pruning outbox events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// appendOutbox writes events to the outbox in the transaction of the change they describe.
+func appendOutbox(db *gorm.DB, events ...eventbus.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([]model.OutboxEvent, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, model.OutboxEvent{
+			ID:            event.ID,
+			Type:          event.Type,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			RestaurantID:  event.RestaurantID,
+			Payload:       string(event.Payload),
+			OccurredAt:    event.OccurredAt,
+		})
+	}
+	if err := db.CreateInBatches(rows, importBatchSize).Error; err != nil {
+		return fmt.Errorf("error writing outbox events: %w", err)
+	}
+	return nil
+}
+
+// appendDishEvent writes an event carrying the state of a dish to the outbox.
+func appendDishEvent(db *gorm.DB, eventType string, dish model.Dish) error {
+	event, err := dishEvent(eventType, dish)
+	if err != nil {
+		return err
+	}
+	return appendOutbox(db, event)
+}
+
+// dishEvent creates an event carrying the state of a dish.
+func dishEvent(eventType string, dish model.Dish) (eventbus.Event, error) {
+	tags := dish.Tags
+	if tags == nil {
+		tags = model.JSONList[string]{}
+	}
+	return eventbus.New(eventType, eventbus.AggregateDish, dish.ID, dish.RestaurantID.String(), eventbus.DishPayload{
+		DishID:      dish.ID,
+		Name:        dish.Name,
+		Description: dish.Description,
+		Price:       dish.Price,
+		Image:       dish.Image,
+		SKU:         dish.SKU,
+		Category:    dish.Category,
+		Tags:        tags,
+		Status:      dish.Status,
+		Version:     dish.Version,
+	})
+}
+
+// appendRatingEvent writes an event carrying the state of a rating to the outbox.
+func appendRatingEvent(db *gorm.DB, eventType string, rating model.Rating, restaurantId string) error {
+	event, err := eventbus.New(eventType, eventbus.AggregateRating, rating.ID, restaurantId, eventbus.RatingPayload{
+		RatingID:   rating.ID,
+		DishID:     rating.DishID,
+		CustomerID: rating.UserID,
+		Rating:     rating.Rating,
+		Comment:    rating.Comment,
+		Status:     rating.Status,
+	})
+	if err != nil {
+		return err
+	}
+	return appendOutbox(db, event)
+}
+
+// toOutboxDeadLetter records an event a consumer gave up on.
+func toOutboxDeadLetter(consumer string, row model.OutboxEvent, attempts int, lastError string) model.OutboxDeadLetter {
+	return model.OutboxDeadLetter{
+		ID:            uuid.New(),
+		Consumer:      consumer,
+		EventID:       row.ID,
+		Type:          row.Type,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		RestaurantID:  row.RestaurantID,
+		Payload:       row.Payload,
+		OccurredAt:    row.OccurredAt,
+		Attempts:      attempts,
+		LastError:     lastError,
+	}
+}
+
+// deadLetterEvent converts a dead letter back into its event.
+func deadLetterEvent(deadLetter model.OutboxDeadLetter) eventbus.Event {
+	return eventbus.Event{
+		ID:            deadLetter.EventID,
+		Type:          deadLetter.Type,
+		AggregateType: deadLetter.AggregateType,
+		AggregateID:   deadLetter.AggregateID,
+		RestaurantID:  deadLetter.RestaurantID,
+		Payload:       json.RawMessage(deadLetter.Payload),
+		OccurredAt:    deadLetter.OccurredAt,
+	}
+}
+
+// toOutboxEvent converts an outbox row back into its event.
+func toOutboxEvent(row model.OutboxEvent) eventbus.Event {
+	return eventbus.Event{
+		ID:            row.ID,
+		Type:          row.Type,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		RestaurantID:  row.RestaurantID,
+		Payload:       json.RawMessage(row.Payload),
+		OccurredAt:    row.OccurredAt,
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"slices"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// findPage runs a list query for one page, ordered by the pagination key.
+// One extra row is fetched to tell whether more rows follow the page.
+func findPage[T any](query *gorm.DB, page pagination.Page) ([]T, pagination.Result, error) {
+	query = query.Session(&gorm.Session{})
+	result := pagination.Result{Total: -1}
+	if page.Count {
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return nil, result, err
+		}
+		result.Total = int(total)
+	}
+
+	switch {
+	case page.Before != nil:
+		query = query.Where(afterKey("<", *page.Before)).Order(keyOrder(true))
+	case page.After != nil:
+		query = query.Where(afterKey(">", *page.After)).Order(keyOrder(false))
+	default:
+		query = query.Order(keyOrder(false)).Offset(page.Offset())
+	}
+
+	var rows []T
+	if err := query.Limit(page.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, result, err
+	}
+	more := len(rows) > page.Limit
+	if more {
+		rows = rows[:page.Limit]
+	}
+
+	if page.Before != nil {
+		slices.Reverse(rows)
+		result.HasPrev, result.HasNext = more, true
+	} else {
+		result.HasNext = more
+		result.HasPrev = page.After != nil || page.Offset() > 0
+	}
+	return rows, result, nil
+}
+
+// The pagination key columns, qualified with the table of the query so lists that join
+// other tables stay unambiguous.
+var (
+	createdAtColumn = clause.Column{Table: clause.CurrentTable, Name: "created_at"}
+	idColumn        = clause.Column{Table: clause.CurrentTable, Name: "id"}
+)
+
+// afterKey matches the rows on one side of a key: op is > for the rows after it and <
+// for those before it.
+func afterKey(op string, key pagination.Key) clause.Expr {
+	return clause.Expr{
+		SQL:  "(?, ?) " + op + " (?, ?)",
+		Vars: []interface{}{createdAtColumn, idColumn, key.CreatedAt, key.ID},
+	}
+}
+
+// keyOrder orders rows by the pagination key.
+func keyOrder(desc bool) clause.OrderBy {
+	return clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: createdAtColumn, Desc: desc},
+		{Column: idColumn, Desc: desc},
+	}}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// PaymentsRepository defines the data operations for payments, refunds and provider events.
+type PaymentsRepository interface {
+	// FindOrCreateForOrder returns the order's payment that has not failed, or stores the given one.
+	// The order row is locked so concurrent requests cannot start two payments.
+	FindOrCreateForOrder(payment model.Payment) (model.Payment, error)
+
+	// FindById retrieves a payment with its refunds for a specific restaurant.
+	FindById(paymentId uuid.UUID, restaurantId string) (model.Payment, error)
+
+	// FindByOrder retrieves the payments of an order with their refunds, newest first.
+	FindByOrder(orderId uuid.UUID, restaurantId string) ([]model.Payment, error)
+
+	// FindByIntent retrieves the payment of a provider intent. The reference is the payment ID
+	// sent with the intent and matches payments whose intent ID is not stored yet.
+	FindByIntent(provider string, intentId string, reference uuid.UUID) (model.Payment, error)
+
+	// Transition moves a payment from one status to another, writing the given fields with it.
+	// It fails with ErrPaymentStatusChanged when the payment is no longer in the from status.
+	Transition(paymentId uuid.UUID, from string, fields map[string]interface{}) (model.Payment, error)
+
+	// ReserveRefund stores a pending refund if the payment has enough left to refund. A zero
+	// amount refunds everything left. A refund with the same idempotency key is returned as is.
+	ReserveRefund(refund model.Refund, restaurantId string) (model.Refund, error)
+
+	// SetProviderRefundID stores the provider's ID of a refund.
+	SetProviderRefundID(refundId uuid.UUID, providerRefundId string) error
+
+	// CompleteRefund records the final status of a pending refund and updates the refunded
+	// amount of its payment. Completing a refund twice has no further effect.
+	CompleteRefund(refundId uuid.UUID, status string) (model.Payment, error)
+
+	// FindRefundByProviderId retrieves a refund by the provider's refund ID.
+	FindRefundByProviderId(providerRefundId string) (model.Refund, error)
+
+	// HasEvent reports whether a provider event has already been processed.
+	HasEvent(provider string, eventId string) (bool, error)
+
+	// RecordEvent marks a provider event as processed.
+	RecordEvent(event model.PaymentEvent) error
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPaymentStatusChanged is returned when a payment changed status while it was being updated.
+	ErrPaymentStatusChanged = errors.New("payment status has changed")
+
+	// ErrPaymentNotRefundable is returned when refunding a payment that has not succeeded.
+	ErrPaymentNotRefundable = errors.New("payment cannot be refunded")
+
+	// ErrRefundExceedsPayment is returned when a refund is larger than what is left to refund.
+	ErrRefundExceedsPayment = errors.New("refund exceeds the amount left to refund")
+)
+
+// PaymentsRepositoryImpl implements PaymentsRepository interface.
+type PaymentsRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewPaymentsRepositoryImpl creates a new instance of PaymentsRepositoryImpl.
+func NewPaymentsRepositoryImpl(db *gorm.DB) PaymentsRepository {
+	return &PaymentsRepositoryImpl{Db: db}
+}
+
+// FindOrCreateForOrder returns the order's payment that has not failed, or stores the given one.
+func (repo *PaymentsRepositoryImpl) FindOrCreateForOrder(payment model.Payment) (model.Payment, error) {
+	var stored model.Payment
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ?", payment.OrderID, payment.RestaurantID).
+			First(&order)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Where("order_id = ? AND status <> ?", payment.OrderID, model.PaymentStatusFailed).
+			Order("created_at DESC").
+			Limit(1).
+			Find(&stored)
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+
+		payment.ID = uuid.New()
+		if err := tx.Omit("Refunds").Create(&payment).Error; err != nil {
+			return err
+		}
+		stored = payment
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Str("order_id", payment.OrderID.String()).
+			Err(err).
+			Msg("Error creating payment")
+		return model.Payment{}, fmt.Errorf("error creating payment: %w", err)
+	}
+	return stored, nil
+}
+
+// FindById retrieves a payment with its refunds for a specific restaurant.
+func (repo *PaymentsRepositoryImpl) FindById(paymentId uuid.UUID, restaurantId string) (model.Payment, error) {
+	var payment model.Payment
+	result := repo.withRefunds(repo.Db).
+		Where("id = ? AND restaurant_id = ?", paymentId, restaurantId).
+		First(&payment)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			log.Warn().
+				Str("payment_id", paymentId.String()).
+				Str("restaurant_id", restaurantId).
+				Msg("Payment not found")
+			return model.Payment{}, fmt.Errorf("payment with ID %s not found for restaurant %s: %w", paymentId, restaurantId, result.Error)
+		}
+		log.Error().
+			Str("payment_id", paymentId.String()).
+			Err(result.Error).
+			Msg("Error finding payment")
+		return model.Payment{}, fmt.Errorf("error finding payment: %w", result.Error)
+	}
+	return payment, nil
+}
+
+// FindByOrder retrieves the payments of an order with their refunds, newest first.
+func (repo *PaymentsRepositoryImpl) FindByOrder(orderId uuid.UUID, restaurantId string) ([]model.Payment, error) {
+	var payments []model.Payment
+	result := repo.withRefunds(repo.Db).
+		Where("order_id = ? AND restaurant_id = ?", orderId, restaurantId).
+		Order("created_at DESC").
+		Find(&payments)
+	if result.Error != nil {
+		log.Error().
+			Str("order_id", orderId.String()).
+			Err(result.Error).
+			Msg("Error finding order payments")
+		return nil, fmt.Errorf("error finding payments: %w", result.Error)
+	}
+	return payments, nil
+}
+
+// FindByIntent retrieves the payment of a provider intent.
+func (repo *PaymentsRepositoryImpl) FindByIntent(provider string, intentId string, reference uuid.UUID) (model.Payment, error) {
+	var payment model.Payment
+	result := repo.withRefunds(repo.Db).
+		Where("provider = ? AND (provider_intent_id = ? OR (id = ? AND provider_intent_id = ''))", provider, intentId, reference).
+		First(&payment)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return model.Payment{}, fmt.Errorf("payment for intent %s not found: %w", intentId, result.Error)
+		}
+		log.Error().
+			Str("intent_id", intentId).
+			Err(result.Error).
+			Msg("Error finding payment by intent")
+		return model.Payment{}, fmt.Errorf("error finding payment: %w", result.Error)
+	}
+	return payment, nil
+}
+
+// Transition moves a payment from one status to another, writing the given fields with it.
+func (repo *PaymentsRepositoryImpl) Transition(paymentId uuid.UUID, from string, fields map[string]interface{}) (model.Payment, error) {
+	result := repo.Db.Model(&model.Payment{}).
+		Where("id = ? AND status = ?", paymentId, from).
+		Updates(fields)
+	if result.Error != nil {
+		log.Error().
+			Str("payment_id", paymentId.String()).
+			Err(result.Error).
+			Msg("Error updating payment status")
+		return model.Payment{}, fmt.Errorf("error updating payment status: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return model.Payment{}, ErrPaymentStatusChanged
+	}
+	log.Info().
+		Str("payment_id", paymentId.String()).
+		Str("from", from).
+		Interface("status", fields["Status"]).
+		Msg("Payment status updated successfully")
+
+	var payment model.Payment
+	if err := repo.withRefunds(repo.Db).Where("id = ?", paymentId).First(&payment).Error; err != nil {
+		return model.Payment{}, fmt.Errorf("error finding payment: %w", err)
+	}
+	return payment, nil
+}
+
+// ReserveRefund stores a pending refund if the payment has enough left to refund.
+func (repo *PaymentsRepositoryImpl) ReserveRefund(refund model.Refund, restaurantId string) (model.Refund, error) {
+	var stored model.Refund
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var payment model.Payment
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ?", refund.PaymentID, restaurantId).
+			First(&payment)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if refund.IdempotencyKey != nil {
+			result = tx.Where("payment_id = ? AND idempotency_key = ?", refund.PaymentID, *refund.IdempotencyKey).
+				Limit(1).
+				Find(&stored)
+			if result.Error != nil || result.RowsAffected > 0 {
+				return result.Error
+			}
+		}
+
+		if payment.Status != model.PaymentStatusSucceeded && payment.Status != model.PaymentStatusPartiallyRefunded {
+			return ErrPaymentNotRefundable
+		}
+		var reserved int64
+		if err := tx.Model(&model.Refund{}).
+			Where("payment_id = ? AND status <> ?", refund.PaymentID, model.RefundStatusFailed).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&reserved).Error; err != nil {
+			return err
+		}
+		left := payment.Amount - reserved
+		if refund.Amount == 0 {
+			refund.Amount = left
+		}
+		if refund.Amount <= 0 || refund.Amount > left {
+			return ErrRefundExceedsPayment
+		}
+
+		refund.ID = uuid.New()
+		refund.Status = model.RefundStatusPending
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+		stored = refund
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Str("payment_id", refund.PaymentID.String()).
+			Err(err).
+			Msg("Error reserving refund")
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrPaymentNotRefundable) || errors.Is(err, ErrRefundExceedsPayment) {
+			return model.Refund{}, err
+		}
+		return model.Refund{}, fmt.Errorf("error reserving refund: %w", err)
+	}
+	return stored, nil
+}
+
+// SetProviderRefundID stores the provider's ID of a refund.
+func (repo *PaymentsRepositoryImpl) SetProviderRefundID(refundId uuid.UUID, providerRefundId string) error {
+	result := repo.Db.Model(&model.Refund{}).
+		Where("id = ?", refundId).
+		Update("ProviderRefundID", providerRefundId)
+	if result.Error != nil {
+		log.Error().
+			Str("refund_id", refundId.String()).
+			Err(result.Error).
+			Msg("Error storing provider refund ID")
+		return fmt.Errorf("error updating refund: %w", result.Error)
+	}
+	return nil
+}
+
+// CompleteRefund records the final status of a pending refund and updates the refunded amount of its payment.
+func (repo *PaymentsRepositoryImpl) CompleteRefund(refundId uuid.UUID, status string) (model.Payment, error) {
+	var paymentId uuid.UUID
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var refund model.Refund
+		if err := tx.Where("id = ?", refundId).First(&refund).Error; err != nil {
+			return err
+		}
+		paymentId = refund.PaymentID
+
+		var payment model.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", refund.PaymentID).First(&payment).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&model.Refund{}).
+			Where("id = ? AND status = ?", refundId, model.RefundStatusPending).
+			Update("Status", status)
+		if result.Error != nil || result.RowsAffected == 0 || status != model.RefundStatusSucceeded {
+			return result.Error
+		}
+
+		refunded := payment.AmountRefunded + refund.Amount
+		paymentStatus := model.PaymentStatusPartiallyRefunded
+		if refunded >= payment.Amount {
+			paymentStatus = model.PaymentStatusRefunded
+		}
+		return tx.Model(&model.Payment{}).
+			Where("id = ?", payment.ID).
+			Updates(map[string]interface{}{
+				"AmountRefunded": refunded,
+				"Status":         paymentStatus,
+			}).Error
+	})
+	if err != nil {
+		log.Error().
+			Str("refund_id", refundId.String()).
+			Err(err).
+			Msg("Error completing refund")
+		return model.Payment{}, fmt.Errorf("error completing refund: %w", err)
+	}
+
+	var payment model.Payment
+	if err := repo.withRefunds(repo.Db).Where("id = ?", paymentId).First(&payment).Error; err != nil {
+		return model.Payment{}, fmt.Errorf("error finding payment: %w", err)
+	}
+	return payment, nil
+}
+
+// FindRefundByProviderId retrieves a refund by the provider's refund ID.
+func (repo *PaymentsRepositoryImpl) FindRefundByProviderId(providerRefundId string) (model.Refund, error) {
+	var refund model.Refund
+	result := repo.Db.Where("provider_refund_id = ?", providerRefundId).First(&refund)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return model.Refund{}, fmt.Errorf("refund %s not found: %w", providerRefundId, result.Error)
+		}
+		return model.Refund{}, fmt.Errorf("error finding refund: %w", result.Error)
+	}
+	return refund, nil
+}
+
+// HasEvent reports whether a provider event has already been processed.
+func (repo *PaymentsRepositoryImpl) HasEvent(provider string, eventId string) (bool, error) {
+	var count int64
+	result := repo.Db.Model(&model.PaymentEvent{}).
+		Where("provider = ? AND event_id = ?", provider, eventId).
+		Count(&count)
+	if result.Error != nil {
+		return false, fmt.Errorf("error finding payment event: %w", result.Error)
+	}
+	return count > 0, nil
+}
+
+// RecordEvent marks a provider event as processed.
+func (repo *PaymentsRepositoryImpl) RecordEvent(event model.PaymentEvent) error {
+	event.ID = uuid.New()
+	result := repo.Db.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+	if result.Error != nil {
+		log.Error().
+			Str("event_id", event.EventID).
+			Err(result.Error).
+			Msg("Error recording payment event")
+		return fmt.Errorf("error recording payment event: %w", result.Error)
+	}
+	return nil
+}
+
+// withRefunds preloads the refunds of payments, oldest first.
+func (repo *PaymentsRepositoryImpl) withRefunds(db *gorm.DB) *gorm.DB {
+	return db.Preload("Refunds", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	})
+}
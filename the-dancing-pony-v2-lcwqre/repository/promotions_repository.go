@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// PromotionsRepository defines the data operations for promotions and their redemptions.
+type PromotionsRepository interface {
+	// Create stores a new promotion.
+	Create(promotion model.Promotion) (model.Promotion, error)
+
+	// FindById retrieves a promotion of a restaurant.
+	FindById(promotionId uuid.UUID, restaurantId string) (model.Promotion, error)
+
+	// FindByCode retrieves the promotion of a restaurant with the given coupon code, or an
+	// empty promotion whose ID is uuid.Nil when no promotion has that code.
+	FindByCode(code string, restaurantId string) (model.Promotion, error)
+
+	// FindAll retrieves every promotion of a restaurant, highest priority first.
+	FindAll(restaurantId string) ([]model.Promotion, error)
+
+	// FindApplicable retrieves the active promotions of a restaurant running at the given
+	// time: the automatic ones and, when code is not empty, the coupon with that code.
+	FindApplicable(restaurantId string, code string, at time.Time) ([]model.Promotion, error)
+
+	// Update writes the given fields to a promotion.
+	Update(promotionId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.Promotion, error)
+
+	// Delete deletes a promotion. Its redemptions are kept for the orders that used it.
+	Delete(promotionId uuid.UUID, restaurantId string) error
+
+	// CountRedemptions counts the redemptions of a promotion that still hold.
+	CountRedemptions(promotionId uuid.UUID) (int64, error)
+
+	// CountCustomerRedemptions counts a customer's redemptions that still hold, by promotion.
+	CountCustomerRedemptions(customerId uuid.UUID, restaurantId string) (map[uuid.UUID]int64, error)
+
+	// CountCustomerOrders counts a customer's orders at a restaurant that were not cancelled or rejected.
+	CountCustomerOrders(customerId uuid.UUID, restaurantId string) (int64, error)
+}
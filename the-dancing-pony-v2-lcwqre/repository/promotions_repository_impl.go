@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPromotionUnavailable is returned when an order redeems a promotion that was disabled,
+// deleted or used up since the order was priced.
+var ErrPromotionUnavailable = errors.New("promotion is no longer available")
+
+// releasedOrderStatuses are the order statuses whose redemptions no longer count.
+var releasedOrderStatuses = []string{model.OrderStatusCancelled, model.OrderStatusRejected}
+
+// PromotionsRepositoryImpl implements PromotionsRepository interface.
+type PromotionsRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewPromotionsRepositoryImpl creates a new instance of PromotionsRepositoryImpl.
+func NewPromotionsRepositoryImpl(db *gorm.DB) PromotionsRepository {
+	return &PromotionsRepositoryImpl{Db: db}
+}
+
+// Create stores a new promotion.
+func (repo *PromotionsRepositoryImpl) Create(promotion model.Promotion) (model.Promotion, error) {
+	promotion.ID = uuid.New()
+	if result := repo.Db.Create(&promotion); result.Error != nil {
+		log.Error().
+			Str("restaurant_id", promotion.RestaurantID.String()).
+			Err(result.Error).
+			Msg("Error creating promotion")
+		return model.Promotion{}, fmt.Errorf("error creating promotion: %w", result.Error)
+	}
+	return promotion, nil
+}
+
+// FindById retrieves a promotion of a restaurant.
+func (repo *PromotionsRepositoryImpl) FindById(promotionId uuid.UUID, restaurantId string) (model.Promotion, error) {
+	var promotion model.Promotion
+	result := repo.Db.Where("id = ? AND restaurant_id = ?", promotionId, restaurantId).First(&promotion)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return model.Promotion{}, fmt.Errorf("promotion with ID %s not found for restaurant %s: %w", promotionId, restaurantId, result.Error)
+		}
+		log.Error().
+			Str("promotion_id", promotionId.String()).
+			Err(result.Error).
+			Msg("Error finding promotion")
+		return model.Promotion{}, fmt.Errorf("error finding promotion: %w", result.Error)
+	}
+	return promotion, nil
+}
+
+// FindByCode retrieves the promotion of a restaurant with the given coupon code, or an empty promotion.
+func (repo *PromotionsRepositoryImpl) FindByCode(code string, restaurantId string) (model.Promotion, error) {
+	var promotion model.Promotion
+	result := repo.Db.Where("code = ? AND restaurant_id = ?", code, restaurantId).First(&promotion)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return model.Promotion{}, nil
+		}
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(result.Error).
+			Msg("Error finding coupon")
+		return model.Promotion{}, fmt.Errorf("error finding coupon: %w", result.Error)
+	}
+	return promotion, nil
+}
+
+// FindAll retrieves every promotion of a restaurant, highest priority first.
+func (repo *PromotionsRepositoryImpl) FindAll(restaurantId string) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	if err := repo.Db.Where("restaurant_id = ?", restaurantId).Order("priority DESC, created_at ASC, id ASC").Find(&promotions).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding promotions")
+		return nil, fmt.Errorf("error finding promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// FindApplicable retrieves the active promotions of a restaurant running at the given time.
+// Weekdays, daily hours and the other conditions are left to the caller.
+func (repo *PromotionsRepositoryImpl) FindApplicable(restaurantId string, code string, at time.Time) ([]model.Promotion, error) {
+	query := repo.Db.Where("restaurant_id = ? AND active AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", restaurantId, at, at)
+	if code != "" {
+		query = query.Where("code IS NULL OR code = ?", code)
+	} else {
+		query = query.Where("code IS NULL")
+	}
+
+	var promotions []model.Promotion
+	if err := query.Order("priority DESC, created_at ASC, id ASC").Find(&promotions).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding applicable promotions")
+		return nil, fmt.Errorf("error finding applicable promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// Update writes the given fields to a promotion.
+func (repo *PromotionsRepositoryImpl) Update(promotionId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.Promotion, error) {
+	result := repo.Db.Model(&model.Promotion{}).Where("id = ? AND restaurant_id = ?", promotionId, restaurantId).Updates(fields)
+	if result.Error != nil {
+		log.Error().
+			Str("promotion_id", promotionId.String()).
+			Err(result.Error).
+			Msg("Error updating promotion")
+		return model.Promotion{}, fmt.Errorf("error updating promotion: %w", result.Error)
+	}
+	return repo.FindById(promotionId, restaurantId)
+}
+
+// Delete deletes a promotion. Its redemptions are kept for the orders that used it.
+func (repo *PromotionsRepositoryImpl) Delete(promotionId uuid.UUID, restaurantId string) error {
+	result := repo.Db.Where("id = ? AND restaurant_id = ?", promotionId, restaurantId).Delete(&model.Promotion{})
+	if result.Error != nil {
+		log.Error().
+			Str("promotion_id", promotionId.String()).
+			Err(result.Error).
+			Msg("Error deleting promotion")
+		return fmt.Errorf("error deleting promotion: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("promotion with ID %s not found for restaurant %s: %w", promotionId, restaurantId, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// CountRedemptions counts the redemptions of a promotion that still hold.
+func (repo *PromotionsRepositoryImpl) CountRedemptions(promotionId uuid.UUID) (int64, error) {
+	count, err := countRedemptions(repo.Db, promotionId, uuid.Nil)
+	if err != nil {
+		log.Error().
+			Str("promotion_id", promotionId.String()).
+			Err(err).
+			Msg("Error counting promotion redemptions")
+		return 0, fmt.Errorf("error counting promotion redemptions: %w", err)
+	}
+	return count, nil
+}
+
+// CountCustomerRedemptions counts a customer's redemptions that still hold, by promotion.
+func (repo *PromotionsRepositoryImpl) CountCustomerRedemptions(customerId uuid.UUID, restaurantId string) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		PromotionID uuid.UUID
+		Count       int64
+	}
+	err := heldRedemptions(repo.Db).
+		Select("promotion_redemptions.promotion_id, COUNT(*) AS count").
+		Where("promotion_redemptions.customer_id = ? AND promotion_redemptions.restaurant_id = ?", customerId, restaurantId).
+		Group("promotion_redemptions.promotion_id").
+		Scan(&rows).Error
+	if err != nil {
+		log.Error().
+			Str("customer_id", customerId.String()).
+			Err(err).
+			Msg("Error counting customer redemptions")
+		return nil, fmt.Errorf("error counting customer redemptions: %w", err)
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.PromotionID] = row.Count
+	}
+	return counts, nil
+}
+
+// CountCustomerOrders counts a customer's orders at a restaurant that were not cancelled or rejected.
+func (repo *PromotionsRepositoryImpl) CountCustomerOrders(customerId uuid.UUID, restaurantId string) (int64, error) {
+	count, err := countCustomerOrders(repo.Db, customerId, restaurantId)
+	if err != nil {
+		log.Error().
+			Str("customer_id", customerId.String()).
+			Err(err).
+			Msg("Error counting customer orders")
+		return 0, fmt.Errorf("error counting customer orders: %w", err)
+	}
+	return count, nil
+}
+
+// redeemPromotions checks the redemptions of a new order against the limits of their
+// promotions with db, which must be a transaction. Each promotion is locked until the
+// transaction ends, so concurrent checkouts redeeming it are counted one after another
+// and a limited promotion can never be redeemed more often than allowed.
+func redeemPromotions(db *gorm.DB, order model.Order) error {
+	redemptions := slices.Clone(order.Redemptions)
+	// Lock in a fixed order so that orders redeeming the same promotions cannot deadlock
+	slices.SortFunc(redemptions, func(a, b model.PromotionRedemption) int {
+		return slices.Compare(a.PromotionID[:], b.PromotionID[:])
+	})
+
+	for _, redemption := range redemptions {
+		var promotion model.Promotion
+		result := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ?", redemption.PromotionID, order.RestaurantID).
+			First(&promotion)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %s", ErrPromotionUnavailable, redemption.Name)
+		}
+		if result.Error != nil {
+			return fmt.Errorf("error locking promotion: %w", result.Error)
+		}
+		if !promotion.Active {
+			return fmt.Errorf("%w: %s", ErrPromotionUnavailable, promotion.Name)
+		}
+
+		if promotion.UsageLimit > 0 {
+			count, err := countRedemptions(db, promotion.ID, uuid.Nil)
+			if err != nil {
+				return fmt.Errorf("error counting promotion redemptions: %w", err)
+			}
+			if count >= int64(promotion.UsageLimit) {
+				return fmt.Errorf("%w: %s has been used up", ErrPromotionUnavailable, promotion.Name)
+			}
+		}
+		if promotion.PerCustomerLimit > 0 {
+			count, err := countRedemptions(db, promotion.ID, order.CustomerID)
+			if err != nil {
+				return fmt.Errorf("error counting promotion redemptions: %w", err)
+			}
+			if count >= int64(promotion.PerCustomerLimit) {
+				return fmt.Errorf("%w: %s was already used the maximum number of times", ErrPromotionUnavailable, promotion.Name)
+			}
+		}
+		if promotion.FirstOrderOnly {
+			count, err := countCustomerOrders(db, order.CustomerID, order.RestaurantID.String())
+			if err != nil {
+				return fmt.Errorf("error counting customer orders: %w", err)
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: %s is only for a first order", ErrPromotionUnavailable, promotion.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// heldRedemptions starts a query on the redemptions whose order was not cancelled or rejected.
+func heldRedemptions(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.PromotionRedemption{}).
+		Joins("JOIN orders ON orders.id = promotion_redemptions.order_id").
+		Where("orders.status NOT IN ?", releasedOrderStatuses)
+}
+
+// countRedemptions counts the held redemptions of a promotion, only the customer's unless customerId is uuid.Nil.
+func countRedemptions(db *gorm.DB, promotionId uuid.UUID, customerId uuid.UUID) (int64, error) {
+	query := heldRedemptions(db).Where("promotion_redemptions.promotion_id = ?", promotionId)
+	if customerId != uuid.Nil {
+		query = query.Where("promotion_redemptions.customer_id = ?", customerId)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// countCustomerOrders counts a customer's orders at a restaurant that were not cancelled or rejected.
+func countCustomerOrders(db *gorm.DB, customerId uuid.UUID, restaurantId string) (int64, error) {
+	var count int64
+	err := db.Model(&model.Order{}).
+		Where("customer_id = ? AND restaurant_id = ? AND status NOT IN ?", customerId, restaurantId, releasedOrderStatuses).
+		Count(&count).Error
+	return count, err
+}
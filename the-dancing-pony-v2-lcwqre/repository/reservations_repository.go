@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// ReservationsRepository defines the data operations for table reservations and the waitlist.
+type ReservationsRepository interface {
+	// Book stores a reservation at the first of the given tables that is free for its time,
+	// trying them in order. It fails with ErrNoTableAvailable when none is free.
+	Book(reservation model.Reservation, tableIds []uuid.UUID) (model.Reservation, error)
+
+	// FindById retrieves a reservation of a restaurant.
+	FindById(reservationId uuid.UUID, restaurantId string) (model.Reservation, error)
+
+	// FindCustomerReservation retrieves a reservation only if it belongs to the customer.
+	FindCustomerReservation(reservationId uuid.UUID, customerId uuid.UUID, restaurantId string) (model.Reservation, error)
+
+	// FindByCustomer retrieves a page of a customer's reservations at a restaurant.
+	FindByCustomer(customerId uuid.UUID, restaurantId string, page pagination.Page) ([]model.Reservation, pagination.Result, error)
+
+	// FindOverlapping retrieves a restaurant's reservations overlapping from to to ordered
+	// by start time, optionally only those in the given statuses.
+	FindOverlapping(restaurantId string, from time.Time, to time.Time, statuses []string) ([]model.Reservation, error)
+
+	// Transition moves a reservation from one status to another, writing the given fields with it.
+	// It fails with ErrReservationStatusChanged when the reservation is no longer in the from status.
+	Transition(reservationId uuid.UUID, restaurantId string, from string, fields map[string]interface{}) (model.Reservation, error)
+
+	// CreateWaitlistEntry stores a new waitlist entry.
+	CreateWaitlistEntry(entry model.WaitlistEntry) (model.WaitlistEntry, error)
+
+	// FindWaitlistByCustomer retrieves a customer's waiting entries at a restaurant.
+	FindWaitlistByCustomer(customerId uuid.UUID, restaurantId string) ([]model.WaitlistEntry, error)
+
+	// FindWaitlist retrieves a restaurant's waiting entries desired from from to to, oldest first.
+	FindWaitlist(restaurantId string, from time.Time, to time.Time) ([]model.WaitlistEntry, error)
+
+	// CancelWaitlistEntry cancels a customer's waiting entry.
+	CancelWaitlistEntry(entryId uuid.UUID, customerId uuid.UUID, restaurantId string) error
+
+	// BookWaitlistEntry books a reservation for a waiting entry as Book does and marks the
+	// entry booked, in one transaction. It fails with ErrWaitlistEntryTaken when the entry
+	// is no longer waiting.
+	BookWaitlistEntry(entryId uuid.UUID, reservation model.Reservation, tableIds []uuid.UUID) (model.Reservation, error)
+}
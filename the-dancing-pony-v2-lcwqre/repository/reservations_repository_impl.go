@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNoTableAvailable is returned when every suitable table is taken at the requested time.
+	ErrNoTableAvailable = errors.New("no table is available at the requested time")
+
+	// ErrReservationStatusChanged is returned when a reservation changed status while it was being updated.
+	ErrReservationStatusChanged = errors.New("reservation status has changed")
+
+	// ErrWaitlistEntryTaken is returned when a waitlist entry was booked or cancelled in the meantime.
+	ErrWaitlistEntryTaken = errors.New("waitlist entry is no longer waiting")
+)
+
+// ReservationsRepositoryImpl implements ReservationsRepository interface.
+type ReservationsRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewReservationsRepositoryImpl creates a new instance of ReservationsRepositoryImpl.
+func NewReservationsRepositoryImpl(db *gorm.DB) ReservationsRepository {
+	return &ReservationsRepositoryImpl{Db: db}
+}
+
+// Book stores a reservation at the first of the given tables that is free for its time.
+func (repo *ReservationsRepositoryImpl) Book(reservation model.Reservation, tableIds []uuid.UUID) (model.Reservation, error) {
+	var booked model.Reservation
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		booked, err = bookReservation(tx, reservation, tableIds)
+		return err
+	})
+	return booked, err
+}
+
+// FindById retrieves a reservation of a restaurant.
+func (repo *ReservationsRepositoryImpl) FindById(reservationId uuid.UUID, restaurantId string) (model.Reservation, error) {
+	return repo.findReservation(repo.Db.Where("id = ? AND restaurant_id = ?", reservationId, restaurantId), reservationId, restaurantId)
+}
+
+// FindCustomerReservation retrieves a reservation only if it belongs to the customer.
+// Other customers' reservations are reported as not found rather than forbidden.
+func (repo *ReservationsRepositoryImpl) FindCustomerReservation(reservationId uuid.UUID, customerId uuid.UUID, restaurantId string) (model.Reservation, error) {
+	return repo.findReservation(repo.Db.Where("id = ? AND customer_id = ? AND restaurant_id = ?", reservationId, customerId, restaurantId), reservationId, restaurantId)
+}
+
+// FindByCustomer retrieves a page of a customer's reservations at a restaurant.
+func (repo *ReservationsRepositoryImpl) FindByCustomer(customerId uuid.UUID, restaurantId string, page pagination.Page) ([]model.Reservation, pagination.Result, error) {
+	query := repo.Db.Model(&model.Reservation{}).Where("customer_id = ? AND restaurant_id = ?", customerId, restaurantId)
+	reservations, result, err := findPage[model.Reservation](query, page)
+	if err != nil {
+		log.Error().
+			Str("customer_id", customerId.String()).
+			Err(err).
+			Msg("Error finding reservations")
+		return nil, result, fmt.Errorf("error finding reservations: %w", err)
+	}
+	return reservations, result, nil
+}
+
+// FindOverlapping retrieves a restaurant's reservations overlapping from to to ordered by start time.
+func (repo *ReservationsRepositoryImpl) FindOverlapping(restaurantId string, from time.Time, to time.Time, statuses []string) ([]model.Reservation, error) {
+	query := repo.Db.Where("restaurant_id = ? AND starts_at < ? AND ends_at > ?", restaurantId, to, from)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	var reservations []model.Reservation
+	if err := query.Order("starts_at ASC, id ASC").Find(&reservations).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding reservations")
+		return nil, fmt.Errorf("error finding reservations: %w", err)
+	}
+	return reservations, nil
+}
+
+// Transition moves a reservation from one status to another, writing the given fields with it.
+func (repo *ReservationsRepositoryImpl) Transition(reservationId uuid.UUID, restaurantId string, from string, fields map[string]interface{}) (model.Reservation, error) {
+	result := repo.Db.Model(&model.Reservation{}).
+		Where("id = ? AND restaurant_id = ? AND status = ?", reservationId, restaurantId, from).
+		Updates(fields)
+	if result.Error != nil {
+		log.Error().
+			Str("reservation_id", reservationId.String()).
+			Err(result.Error).
+			Msg("Error updating reservation status")
+		return model.Reservation{}, fmt.Errorf("error updating reservation status: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := repo.FindById(reservationId, restaurantId); err != nil {
+			return model.Reservation{}, err
+		}
+		return model.Reservation{}, ErrReservationStatusChanged
+	}
+	log.Info().
+		Str("reservation_id", reservationId.String()).
+		Str("from", from).
+		Interface("status", fields["Status"]).
+		Msg("Reservation status updated successfully")
+	return repo.FindById(reservationId, restaurantId)
+}
+
+// CreateWaitlistEntry stores a new waitlist entry.
+func (repo *ReservationsRepositoryImpl) CreateWaitlistEntry(entry model.WaitlistEntry) (model.WaitlistEntry, error) {
+	entry.ID = uuid.New()
+	if result := repo.Db.Create(&entry); result.Error != nil {
+		log.Error().
+			Str("customer_id", entry.CustomerID.String()).
+			Err(result.Error).
+			Msg("Error creating waitlist entry")
+		return model.WaitlistEntry{}, fmt.Errorf("error creating waitlist entry: %w", result.Error)
+	}
+	return entry, nil
+}
+
+// FindWaitlistByCustomer retrieves a customer's waiting entries at a restaurant.
+func (repo *ReservationsRepositoryImpl) FindWaitlistByCustomer(customerId uuid.UUID, restaurantId string) ([]model.WaitlistEntry, error) {
+	var entries []model.WaitlistEntry
+	err := repo.Db.Where("customer_id = ? AND restaurant_id = ? AND status = ?", customerId, restaurantId, model.WaitlistStatusWaiting).
+		Order("desired_at ASC").
+		Find(&entries).Error
+	if err != nil {
+		log.Error().
+			Str("customer_id", customerId.String()).
+			Err(err).
+			Msg("Error finding waitlist entries")
+		return nil, fmt.Errorf("error finding waitlist entries: %w", err)
+	}
+	return entries, nil
+}
+
+// FindWaitlist retrieves a restaurant's waiting entries desired from from to to, oldest first.
+func (repo *ReservationsRepositoryImpl) FindWaitlist(restaurantId string, from time.Time, to time.Time) ([]model.WaitlistEntry, error) {
+	var entries []model.WaitlistEntry
+	err := repo.Db.Where("restaurant_id = ? AND status = ? AND desired_at >= ? AND desired_at < ?", restaurantId, model.WaitlistStatusWaiting, from, to).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding waitlist entries")
+		return nil, fmt.Errorf("error finding waitlist entries: %w", err)
+	}
+	return entries, nil
+}
+
+// CancelWaitlistEntry cancels a customer's waiting entry.
+func (repo *ReservationsRepositoryImpl) CancelWaitlistEntry(entryId uuid.UUID, customerId uuid.UUID, restaurantId string) error {
+	result := repo.Db.Model(&model.WaitlistEntry{}).
+		Where("id = ? AND customer_id = ? AND restaurant_id = ? AND status = ?", entryId, customerId, restaurantId, model.WaitlistStatusWaiting).
+		Update("status", model.WaitlistStatusCancelled)
+	if result.Error != nil {
+		log.Error().
+			Str("entry_id", entryId.String()).
+			Err(result.Error).
+			Msg("Error cancelling waitlist entry")
+		return fmt.Errorf("error cancelling waitlist entry: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("waiting entry with ID %s not found for restaurant %s: %w", entryId, restaurantId, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// BookWaitlistEntry books a reservation for a waiting entry and marks the entry booked.
+// The entry is locked first so that it is booked at most once.
+func (repo *ReservationsRepositoryImpl) BookWaitlistEntry(entryId uuid.UUID, reservation model.Reservation, tableIds []uuid.UUID) (model.Reservation, error) {
+	var booked model.Reservation
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var entry model.WaitlistEntry
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", entryId, model.WaitlistStatusWaiting).
+			Limit(1).
+			Find(&entry)
+		if result.Error != nil {
+			return fmt.Errorf("error finding waitlist entry: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrWaitlistEntryTaken
+		}
+
+		var err error
+		if booked, err = bookReservation(tx, reservation, tableIds); err != nil {
+			return err
+		}
+		return tx.Model(&entry).Updates(map[string]interface{}{
+			"Status":        model.WaitlistStatusBooked,
+			"ReservationID": booked.ID,
+		}).Error
+	})
+	return booked, err
+}
+
+// findReservation retrieves the reservation matched by query.
+func (repo *ReservationsRepositoryImpl) findReservation(query *gorm.DB, reservationId uuid.UUID, restaurantId string) (model.Reservation, error) {
+	var reservation model.Reservation
+	result := query.First(&reservation)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			log.Warn().
+				Str("reservation_id", reservationId.String()).
+				Str("restaurant_id", restaurantId).
+				Msg("Reservation not found")
+			return model.Reservation{}, fmt.Errorf("reservation with ID %s not found for restaurant %s: %w", reservationId, restaurantId, result.Error)
+		}
+		log.Error().
+			Str("reservation_id", reservationId.String()).
+			Err(result.Error).
+			Msg("Error finding reservation")
+		return model.Reservation{}, fmt.Errorf("error finding reservation: %w", result.Error)
+	}
+	return reservation, nil
+}
+
+// bookReservation stores a reservation at the first of the given tables that is free for
+// its time, within the transaction tx. Each table is locked before its reservations are
+// checked, so concurrent bookings of the same table are serialised and cannot overlap.
+// Callers pass tables in a consistent order, which keeps concurrent bookings from deadlocking.
+func bookReservation(tx *gorm.DB, reservation model.Reservation, tableIds []uuid.UUID) (model.Reservation, error) {
+	restaurantId := reservation.RestaurantID.String()
+	for _, tableId := range tableIds {
+		var table model.DiningTable
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND restaurant_id = ? AND active = ?", tableId, restaurantId, true).
+			Limit(1).
+			Find(&table)
+		if result.Error != nil {
+			return model.Reservation{}, fmt.Errorf("error locking table: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue // Deleted or deactivated since the caller listed it
+		}
+
+		var overlapping int64
+		if err := tx.Model(&model.Reservation{}).
+			Where("table_id = ? AND status IN ? AND starts_at < ? AND ends_at > ?", tableId, model.ReservationHoldingStatuses, reservation.EndsAt, reservation.StartsAt).
+			Count(&overlapping).Error; err != nil {
+			return model.Reservation{}, fmt.Errorf("error checking table reservations: %w", err)
+		}
+		if overlapping > 0 {
+			continue
+		}
+
+		reservation.ID = uuid.New()
+		reservation.TableID = tableId
+		if err := tx.Create(&reservation).Error; err != nil {
+			log.Error().
+				Str("restaurant_id", restaurantId).
+				Str("table_id", tableId.String()).
+				Err(err).
+				Msg("Error creating reservation")
+			return model.Reservation{}, fmt.Errorf("error creating reservation: %w", err)
+		}
+		log.Info().
+			Str("reservation_id", reservation.ID.String()).
+			Str("table_id", tableId.String()).
+			Msg("Reservation booked successfully")
+		return reservation, nil
+	}
+	return model.Reservation{}, ErrNoTableAvailable
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
 
 	"github.com/google/uuid"
 )
@@ -11,18 +12,18 @@ type RestaurantsRepository interface {
 	// Create adds a new restaurant to the database.
 	Create(restaurant model.Restaurant) (model.Restaurant, error)
 
-	// Update modifies an existing restaurant in the database.
-	Update(restaurant model.Restaurant) (model.Restaurant, error)
+	// Update writes the given fields to an existing restaurant, optionally only at one of the given versions.
+	Update(restaurantId uuid.UUID, fields map[string]interface{}, versions []int64) (model.Restaurant, error)
 
-	// Delete removes a restaurant from the database.
-	Delete(restaurantId uuid.UUID) error
+	// Delete removes a restaurant from the database, optionally only at one of the given versions.
+	Delete(restaurantId uuid.UUID, versions []int64) error
 
-	// FindAll retrieves all restaurants with pagination.
-	FindAll(page, limit int) ([]model.Restaurant, int, error)
+	// FindAll retrieves a page of restaurants.
+	FindAll(page pagination.Page) ([]model.Restaurant, pagination.Result, error)
 
 	// FindById retrieves a restaurant by its ID.
 	FindById(restaurantId uuid.UUID) (model.Restaurant, error)
 
-	// Search finds restaurants based on a search term in the restaurant name with pagination.
-	Search(searchTerm string, page, limit int) ([]model.Restaurant, int, error)
+	// Search finds a page of restaurants based on a search term in the restaurant name.
+	Search(searchTerm string, page pagination.Page) ([]model.Restaurant, pagination.Result, error)
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"fmt"
 	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -20,9 +21,10 @@ func NewRestaurantsRepositoryImpl(db *gorm.DB) RestaurantsRepository {
 }
 
 // Delete removes a restaurant from the database.
-func (repo *RestaurantsRepositoryImpl) Delete(restaurantId uuid.UUID) error {
+func (repo *RestaurantsRepositoryImpl) Delete(restaurantId uuid.UUID, versions []int64) error {
 	var restaurant model.Restaurant
-	result := repo.Db.Where("id = ? AND deleted_at IS NULL", restaurantId).Delete(&restaurant)
+	query := repo.Db.Where("id = ? AND deleted_at IS NULL", restaurantId)
+	result := whereVersion(query, versions).Delete(&restaurant)
 	if result.Error != nil {
 		log.Error().
 			Str("restaurant_id", restaurantId.String()).
@@ -30,44 +32,29 @@ func (repo *RestaurantsRepositoryImpl) Delete(restaurantId uuid.UUID) error {
 			Msg("Error deleting restaurant")
 		return result.Error
 	}
+	if result.RowsAffected == 0 && len(versions) > 0 {
+		err := missOrConflict(repo.Db, &model.Restaurant{}, "id = ?", restaurantId)
+		return fmt.Errorf("error deleting restaurant %s: %w", restaurantId, err)
+	}
 	log.Info().
 		Str("restaurant_id", restaurantId.String()).
 		Msg("Restaurant deleted successfully")
 	return nil
 }
 
-// FindAll retrieves all restaurants with pagination that are not soft-deleted.
-func (repo *RestaurantsRepositoryImpl) FindAll(page, limit int) ([]model.Restaurant, int, error) {
-	var restaurants []model.Restaurant
-	var total int64
-
-	offset := (page - 1) * limit
-
-	// Fetch total count of restaurants
-	countResult := repo.Db.Model(&model.Restaurant{}).Where("deleted_at IS NULL").Count(&total)
-	if countResult.Error != nil {
+// FindAll retrieves a page of the restaurants that are not soft-deleted.
+func (repo *RestaurantsRepositoryImpl) FindAll(page pagination.Page) ([]model.Restaurant, pagination.Result, error) {
+	restaurants, result, err := findPage[model.Restaurant](repo.Db.Model(&model.Restaurant{}).Where("deleted_at IS NULL"), page)
+	if err != nil {
 		log.Error().
-			Err(countResult.Error).
-			Msg("Error counting total restaurants")
-		return nil, 0, fmt.Errorf("error counting restaurants: %w", countResult.Error)
-	}
-
-	// Fetch restaurants with pagination
-	result := repo.Db.Where("deleted_at IS NULL").
-		Limit(limit).
-		Offset(offset).
-		Find(&restaurants)
-
-	if result.Error != nil {
-		log.Error().
-			Err(result.Error).
+			Err(err).
 			Msg("Error finding restaurants")
-		return nil, 0, fmt.Errorf("error finding restaurants: %w", result.Error)
+		return nil, result, fmt.Errorf("error finding restaurants: %w", err)
 	}
 
 	log.Info().
-		Msgf("Retrieved restaurants for page %d with limit %d successfully", page, limit)
-	return restaurants, int(total), nil
+		Msgf("Retrieved %d restaurants with limit %d successfully", len(restaurants), page.Limit)
+	return restaurants, result, nil
 }
 
 // FindById retrieves a restaurant by its ID.
@@ -110,91 +97,60 @@ func (repo *RestaurantsRepositoryImpl) Create(restaurant model.Restaurant) (mode
 	return restaurant, nil
 }
 
-// Update modifies an existing restaurant in the database.
-func (repo *RestaurantsRepositoryImpl) Update(restaurant model.Restaurant) (model.Restaurant, error) {
-	updateFields := make(map[string]interface{})
-
-	if restaurant.Name != "" {
-		updateFields["Name"] = restaurant.Name
-	}
-	if restaurant.Description != "" {
-		updateFields["Description"] = restaurant.Description
-	}
-	if restaurant.Location != "" {
-		updateFields["Location"] = restaurant.Location
-	}
-	if restaurant.ImageUrl != "" {
-		updateFields["ImageUrl"] = restaurant.ImageUrl
-	}
-
-	result := repo.Db.Model(&model.Restaurant{}).Where("id = ?", restaurant.ID).Updates(updateFields)
+// Update writes the given fields to a restaurant. Only the keys present in
+// fields are changed, so zero values are stored as given. When versions is not
+// empty the restaurant must be at one of them, or ErrVersionMismatch is returned.
+func (repo *RestaurantsRepositoryImpl) Update(restaurantId uuid.UUID, fields map[string]interface{}, versions []int64) (model.Restaurant, error) {
+	query := repo.Db.Model(&model.Restaurant{}).Where("id = ?", restaurantId)
+	result := whereVersion(query, versions).Updates(fields)
 	if result.Error != nil {
 		log.Error().
-			Str("restaurant_id", restaurant.ID.String()).
+			Str("restaurant_id", restaurantId.String()).
 			Err(result.Error).
 			Msg("Error updating restaurant")
 		return model.Restaurant{}, fmt.Errorf("error updating restaurant: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
+		err := missOrConflict(repo.Db, &model.Restaurant{}, "id = ?", restaurantId)
 		log.Warn().
-			Str("restaurant_id", restaurant.ID.String()).
-			Msg("No rows affected, update might have failed")
-		return model.Restaurant{}, fmt.Errorf("no rows affected, update might have failed")
+			Str("restaurant_id", restaurantId.String()).
+			Err(err).
+			Msg("Restaurant not updated")
+		return model.Restaurant{}, fmt.Errorf("error updating restaurant %s: %w", restaurantId, err)
 	}
 
 	var updatedRestaurant model.Restaurant
-	if err := repo.Db.First(&updatedRestaurant, restaurant.ID).Error; err != nil {
+	if err := repo.Db.Where("id = ?", restaurantId).First(&updatedRestaurant).Error; err != nil {
 		log.Error().
-			Str("restaurant_id", restaurant.ID.String()).
+			Str("restaurant_id", restaurantId.String()).
 			Err(err).
 			Msg("Error retrieving updated restaurant")
 		return model.Restaurant{}, fmt.Errorf("error retrieving updated restaurant: %w", err)
 	}
 
 	log.Info().
-		Str("restaurant_id", restaurant.ID.String()).
+		Str("restaurant_id", restaurantId.String()).
 		Msg("Restaurant updated successfully")
 	return updatedRestaurant, nil
 }
 
-// Search finds restaurants based on a search term in the restaurant name with pagination.
-func (repo *RestaurantsRepositoryImpl) Search(searchTerm string, page, limit int) ([]model.Restaurant, int, error) {
-	var restaurants []model.Restaurant
-	var total int64
-
+// Search retrieves a page of the restaurants whose name contains the search term.
+func (repo *RestaurantsRepositoryImpl) Search(searchTerm string, page pagination.Page) ([]model.Restaurant, pagination.Result, error) {
 	// Prepare the search query
 	query := "%" + searchTerm + "%"
 
-	// Calculate the offset for pagination
-	offset := (page - 1) * limit
-
-	// Fetch the total count of matching restaurants for pagination
-	countResult := repo.Db.Model(&model.Restaurant{}).
-		Where("deleted_at IS NULL AND name ILIKE ?", query).
-		Count(&total)
-	if countResult.Error != nil {
-		log.Error().
-			Err(countResult.Error).
-			Msg("Error counting total restaurants for search")
-		return nil, 0, fmt.Errorf("error counting restaurants: %w", countResult.Error)
-	}
-
-	// Fetch the paginated list of restaurants
-	result := repo.Db.Where("deleted_at IS NULL AND name ILIKE ?", query).
-		Limit(limit).
-		Offset(offset).
-		Find(&restaurants)
-	if result.Error != nil {
+	restaurants, result, err := findPage[model.Restaurant](repo.Db.Model(&model.Restaurant{}).
+		Where("deleted_at IS NULL AND name ILIKE ?", query), page)
+	if err != nil {
 		log.Error().
-			Err(result.Error).
+			Err(err).
 			Msg("Error searching restaurants with pagination")
-		return nil, 0, fmt.Errorf("error searching restaurants: %w", result.Error)
+		return nil, result, fmt.Errorf("error searching restaurants: %w", err)
 	}
 
 	log.Info().
 		Str("search_term", searchTerm).
 		Int("num_restaurants_found", len(restaurants)).
 		Msg("Restaurants searched successfully with pagination")
-
-	return restaurants, int(total), nil
+	return restaurants, result, nil
 }
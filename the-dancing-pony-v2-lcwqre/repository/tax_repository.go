@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// TaxRepository defines the data operations for tax settings and invoices.
+type TaxRepository interface {
+	// FindSettings retrieves the tax settings of a restaurant, or settings whose ID is
+	// uuid.Nil when the restaurant has none and charges no tax.
+	FindSettings(restaurantId string) (model.TaxSettings, error)
+
+	// SaveSettings creates or replaces the tax settings of a restaurant.
+	SaveSettings(settings model.TaxSettings) (model.TaxSettings, error)
+
+	// FindInvoice retrieves the invoice of an order, or an invoice whose ID is uuid.Nil
+	// when none was issued yet.
+	FindInvoice(orderId uuid.UUID, restaurantId string) (model.Invoice, error)
+
+	// IssueInvoice numbers an order with the next invoice number of its restaurant. An
+	// order that already has an invoice keeps it.
+	IssueInvoice(order model.Order) (model.Invoice, error)
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultInvoicePrefix prefixes the invoice numbers of restaurants that did not choose a prefix.
+const defaultInvoicePrefix = "INV-"
+
+// TaxRepositoryImpl implements TaxRepository interface.
+type TaxRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewTaxRepositoryImpl creates a new instance of TaxRepositoryImpl.
+func NewTaxRepositoryImpl(db *gorm.DB) TaxRepository {
+	return &TaxRepositoryImpl{Db: db}
+}
+
+// FindSettings retrieves the tax settings of a restaurant, or empty settings when it has none.
+func (repo *TaxRepositoryImpl) FindSettings(restaurantId string) (model.TaxSettings, error) {
+	settings, err := findTaxSettings(repo.Db, restaurantId)
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding tax settings")
+		return model.TaxSettings{}, fmt.Errorf("error finding tax settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SaveSettings creates or replaces the tax settings of a restaurant.
+func (repo *TaxRepositoryImpl) SaveSettings(settings model.TaxSettings) (model.TaxSettings, error) {
+	settings.ID = uuid.New()
+	err := repo.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "restaurant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"prices_include_tax", "rates", "legal_name", "address", "tax_number", "invoice_prefix", "updated_at"}),
+	}).Create(&settings).Error
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", settings.RestaurantID.String()).
+			Err(err).
+			Msg("Error saving tax settings")
+		return model.TaxSettings{}, fmt.Errorf("error saving tax settings: %w", err)
+	}
+	return repo.FindSettings(settings.RestaurantID.String())
+}
+
+// FindInvoice retrieves the invoice of an order, or an empty invoice when none was issued.
+func (repo *TaxRepositoryImpl) FindInvoice(orderId uuid.UUID, restaurantId string) (model.Invoice, error) {
+	var invoice model.Invoice
+	if err := repo.Db.Where("order_id = ? AND restaurant_id = ?", orderId, restaurantId).Limit(1).Find(&invoice).Error; err != nil {
+		log.Error().
+			Str("order_id", orderId.String()).
+			Err(err).
+			Msg("Error finding invoice")
+		return model.Invoice{}, fmt.Errorf("error finding invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+// IssueInvoice numbers an order in a transaction; an order that already has an invoice keeps it.
+func (repo *TaxRepositoryImpl) IssueInvoice(order model.Order) (model.Invoice, error) {
+	var invoice model.Invoice
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invoice, err = issueInvoice(tx, order)
+		return err
+	})
+	if err != nil {
+		log.Error().
+			Str("order_id", order.ID.String()).
+			Err(err).
+			Msg("Error issuing invoice")
+		return model.Invoice{}, err
+	}
+	return invoice, nil
+}
+
+// findTaxSettings retrieves the tax settings of a restaurant with db, or empty settings
+// when it has none.
+func findTaxSettings(db *gorm.DB, restaurantId string) (model.TaxSettings, error) {
+	var settings model.TaxSettings
+	if err := db.Where("restaurant_id = ?", restaurantId).Limit(1).Find(&settings).Error; err != nil {
+		return model.TaxSettings{}, err
+	}
+	return settings, nil
+}
+
+// issueInvoice numbers an order with db, which must be a transaction. The restaurant's
+// invoice sequence stays locked until the transaction ends, so concurrent invoices are
+// numbered one after the other and a rolled back invoice gives its number back. An
+// order invoiced concurrently keeps the invoice that was committed first. The
+// seller details are taken from the tax settings, falling back to the restaurant's name
+// and location.
+func issueInvoice(db *gorm.DB, order model.Order) (model.Invoice, error) {
+	var invoice model.Invoice
+	if err := db.Where("order_id = ?", order.ID).Limit(1).Find(&invoice).Error; err != nil {
+		return model.Invoice{}, fmt.Errorf("error finding invoice: %w", err)
+	}
+	if invoice.ID != uuid.Nil {
+		return invoice, nil
+	}
+
+	settings, err := findTaxSettings(db, order.RestaurantID.String())
+	if err != nil {
+		return model.Invoice{}, fmt.Errorf("error finding tax settings: %w", err)
+	}
+	var restaurant model.Restaurant
+	if err := db.Where("id = ?", order.RestaurantID).First(&restaurant).Error; err != nil {
+		return model.Invoice{}, fmt.Errorf("error finding restaurant: %w", err)
+	}
+
+	sequence := model.InvoiceSequence{RestaurantID: order.RestaurantID}
+	sequence.ID = uuid.New()
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "restaurant_id"}},
+		DoNothing: true,
+	}).Create(&sequence).Error; err != nil {
+		return model.Invoice{}, fmt.Errorf("error creating invoice sequence: %w", err)
+	}
+	// The sequence is locked into a fresh struct: the ID set above is only the
+	// stored one for the restaurant's first invoice.
+	var current model.InvoiceSequence
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("restaurant_id = ?", order.RestaurantID).
+		First(&current).Error; err != nil {
+		return model.Invoice{}, fmt.Errorf("error locking invoice sequence: %w", err)
+	}
+	next := current.Last + 1
+
+	prefix := settings.InvoicePrefix
+	if settings.ID == uuid.Nil {
+		prefix = defaultInvoicePrefix
+	}
+	invoice = model.Invoice{
+		RestaurantID:  order.RestaurantID,
+		Sequence:      next,
+		Number:        fmt.Sprintf("%s%06d", prefix, next),
+		OrderID:       order.ID,
+		IssuedAt:      time.Now(),
+		SellerName:    settings.LegalName,
+		SellerAddress: settings.Address,
+		TaxNumber:     settings.TaxNumber,
+	}
+	if invoice.SellerName == "" {
+		invoice.SellerName = restaurant.Name
+	}
+	if invoice.SellerAddress == "" {
+		invoice.SellerAddress = restaurant.Location
+	}
+	invoice.ID = uuid.New()
+	// An invoice issued for the order by a concurrent call, committed after the lookup
+	// above, wins: it is returned and the number goes back to the sequence.
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "order_id"}},
+		DoNothing: true,
+	}).Create(&invoice)
+	if result.Error != nil {
+		return model.Invoice{}, fmt.Errorf("error creating invoice: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var issued model.Invoice
+		if err := db.Where("order_id = ?", order.ID).First(&issued).Error; err != nil {
+			return model.Invoice{}, fmt.Errorf("error finding invoice: %w", err)
+		}
+		return issued, nil
+	}
+	if err := db.Model(&model.InvoiceSequence{}).Where("id = ?", current.ID).Update("last", next).Error; err != nil {
+		return model.Invoice{}, fmt.Errorf("error advancing invoice sequence: %w", err)
+	}
+	log.Info().
+		Str("order_id", order.ID.String()).
+		Str("invoice", invoice.Number).
+		Msg("Invoice issued successfully")
+	return invoice, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/migrations"
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testDB connects to the Postgres database in TEST_DATABASE_DSN and migrates it, or
+// skips the test when the variable is not set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("getting the test database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	migrator, err := migrations.NewMigrator(sqlDB)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrating the test database: %v", err)
+	}
+	return db
+}
+
+func TestTaxRepositoryIssueInvoiceReusesSequence(t *testing.T) {
+	db := testDB(t)
+	repo := NewTaxRepositoryImpl(db)
+
+	restaurant := model.Restaurant{Name: "The Prancing Pony", Location: "Bree"}
+	restaurant.ID = uuid.New()
+	if err := db.Create(&restaurant).Error; err != nil {
+		t.Fatalf("creating restaurant: %v", err)
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		order := model.Order{
+			RestaurantID:    restaurant.ID,
+			CustomerID:      uuid.New(),
+			Status:          model.OrderStatusServed,
+			Fulfilment:      model.OrderFulfilmentDineIn,
+			Total:           10,
+			StatusChangedAt: time.Now(),
+		}
+		order.ID = uuid.New()
+		if err := db.Create(&order).Error; err != nil {
+			t.Fatalf("creating order %d: %v", i, err)
+		}
+
+		invoice, err := repo.IssueInvoice(order)
+		if err != nil {
+			t.Fatalf("IssueInvoice() for order %d error = %v", i, err)
+		}
+		if invoice.Sequence != i {
+			t.Errorf("IssueInvoice() for order %d sequence = %d, want %d", i, invoice.Sequence, i)
+		}
+		if want := fmt.Sprintf("%s%06d", defaultInvoicePrefix, i); invoice.Number != want {
+			t.Errorf("IssueInvoice() for order %d number = %q, want %q", i, invoice.Number, want)
+		}
+
+		again, err := repo.IssueInvoice(order)
+		if err != nil {
+			t.Fatalf("IssueInvoice() again for order %d error = %v", i, err)
+		}
+		if again.ID != invoice.ID {
+			t.Errorf("IssueInvoice() again for order %d issued %s, want the existing %s", i, again.ID, invoice.ID)
+		}
+	}
+}
+
+func TestTaxRepositoryIssueInvoiceConcurrently(t *testing.T) {
+	db := testDB(t)
+	repo := NewTaxRepositoryImpl(db)
+
+	restaurant := model.Restaurant{Name: "The Green Dragon", Location: "Bywater"}
+	restaurant.ID = uuid.New()
+	if err := db.Create(&restaurant).Error; err != nil {
+		t.Fatalf("creating restaurant: %v", err)
+	}
+	order := model.Order{
+		RestaurantID:    restaurant.ID,
+		CustomerID:      uuid.New(),
+		Status:          model.OrderStatusCollected,
+		Fulfilment:      model.OrderFulfilmentTakeaway,
+		Total:           10,
+		StatusChangedAt: time.Now(),
+	}
+	order.ID = uuid.New()
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("creating order: %v", err)
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	invoices := make([]model.Invoice, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			invoices[i], errs[i] = repo.IssueInvoice(order)
+		}()
+	}
+	wg.Wait()
+
+	for i := range callers {
+		if errs[i] != nil {
+			t.Fatalf("IssueInvoice() call %d error = %v", i, errs[i])
+		}
+		if invoices[i].ID != invoices[0].ID {
+			t.Errorf("IssueInvoice() call %d issued %s, want %s like call 0", i, invoices[i].ID, invoices[0].ID)
+		}
+	}
+	var sequence model.InvoiceSequence
+	if err := db.Where("restaurant_id = ?", restaurant.ID).First(&sequence).Error; err != nil {
+		t.Fatalf("finding invoice sequence: %v", err)
+	}
+	if sequence.Last != 1 {
+		t.Errorf("invoice sequence is at %d after one invoice, want 1", sequence.Last)
+	}
+}
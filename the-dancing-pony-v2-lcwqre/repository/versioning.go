@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionMismatch is returned when a conditional write names a version the row is no longer at.
+var ErrVersionMismatch = errors.New("resource has been modified since it was read")
+
+// whereVersion limits a write to the given versions; no versions means any version.
+func whereVersion(db *gorm.DB, versions []int64) *gorm.DB {
+	if len(versions) == 0 {
+		return db
+	}
+	return db.Where("version IN ?", versions)
+}
+
+// missOrConflict explains why a conditional write touched no rows: the row is
+// either gone or at another version.
+func missOrConflict(db *gorm.DB, model interface{}, query string, args ...interface{}) error {
+	var count int64
+	if err := db.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionMismatch
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// WebhooksRepository defines the data operations for webhook subscriptions and their delivery log.
+type WebhooksRepository interface {
+	// CreateSubscription stores a new subscription.
+	CreateSubscription(subscription model.WebhookSubscription) (model.WebhookSubscription, error)
+
+	// FindSubscription retrieves a subscription of a restaurant.
+	FindSubscription(subscriptionId uuid.UUID, restaurantId string) (model.WebhookSubscription, error)
+
+	// FindSubscriptions retrieves the subscriptions of a restaurant, oldest first.
+	FindSubscriptions(restaurantId string) ([]model.WebhookSubscription, error)
+
+	// UpdateSubscription writes the given fields to a subscription. Deactivating it fails
+	// its pending deliveries.
+	UpdateSubscription(subscriptionId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.WebhookSubscription, error)
+
+	// DeleteSubscription deletes a subscription and fails its pending deliveries.
+	DeleteSubscription(subscriptionId uuid.UUID, restaurantId string) error
+
+	// Enqueue creates a pending delivery of an event for every active subscription of the
+	// restaurant to its type, due now, and returns how many it created. Subscriptions that
+	// already have a delivery of the event, other than a replay, are skipped.
+	Enqueue(restaurantId string, eventId string, eventType string, payload string, at time.Time) (int, error)
+
+	// CreateDelivery stores a new delivery.
+	CreateDelivery(delivery model.WebhookDelivery) (model.WebhookDelivery, error)
+
+	// FindDelivery retrieves a delivery of a restaurant with its attempts.
+	FindDelivery(deliveryId uuid.UUID, restaurantId string) (model.WebhookDelivery, error)
+
+	// FindDeliveries retrieves a page of the deliveries of a subscription, optionally
+	// only those with the given status.
+	FindDeliveries(subscriptionId uuid.UUID, restaurantId string, status string, page pagination.Page) ([]model.WebhookDelivery, pagination.Result, error)
+
+	// ClaimDue leases up to limit pending deliveries of active subscriptions that are due,
+	// across all restaurants, with their subscriptions. Leased deliveries are not claimed
+	// again until the lease ends, so several replicas can dispatch at once.
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+
+	// RecordAttempt stores an attempt and the resulting state of its delivery, given in
+	// delivery, and releases the lease. Failed attempts count towards disabling the
+	// subscription after disableAfter consecutive failures; successes reset the count.
+	// Attempts of test deliveries do neither. It returns the subscription as updated.
+	RecordAttempt(delivery model.WebhookDelivery, attempt model.WebhookAttempt, disableAfter int) (model.WebhookSubscription, error)
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhooksRepositoryImpl implements WebhooksRepository interface.
+type WebhooksRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewWebhooksRepositoryImpl creates a new instance of WebhooksRepositoryImpl.
+func NewWebhooksRepositoryImpl(db *gorm.DB) WebhooksRepository {
+	return &WebhooksRepositoryImpl{Db: db}
+}
+
+// CreateSubscription stores a new subscription.
+func (repo *WebhooksRepositoryImpl) CreateSubscription(subscription model.WebhookSubscription) (model.WebhookSubscription, error) {
+	subscription.ID = uuid.New()
+	if err := repo.Db.Create(&subscription).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", subscription.RestaurantID.String()).
+			Err(err).
+			Msg("Error creating webhook subscription")
+		return model.WebhookSubscription{}, fmt.Errorf("error creating webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// FindSubscription retrieves a subscription of a restaurant.
+func (repo *WebhooksRepositoryImpl) FindSubscription(subscriptionId uuid.UUID, restaurantId string) (model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	if err := repo.Db.Where("id = ? AND restaurant_id = ?", subscriptionId, restaurantId).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return model.WebhookSubscription{}, fmt.Errorf("webhook subscription with ID %s not found: %w", subscriptionId, err)
+		}
+		log.Error().
+			Str("subscription_id", subscriptionId.String()).
+			Err(err).
+			Msg("Error finding webhook subscription")
+		return model.WebhookSubscription{}, fmt.Errorf("error finding webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// FindSubscriptions retrieves the subscriptions of a restaurant, oldest first.
+func (repo *WebhooksRepositoryImpl) FindSubscriptions(restaurantId string) ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	if err := repo.Db.Where("restaurant_id = ?", restaurantId).Order("created_at ASC, id ASC").Find(&subscriptions).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error finding webhook subscriptions")
+		return nil, fmt.Errorf("error finding webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription writes the given fields to a subscription, failing its pending
+// deliveries when it is deactivated.
+func (repo *WebhooksRepositoryImpl) UpdateSubscription(subscriptionId uuid.UUID, restaurantId string, fields map[string]interface{}) (model.WebhookSubscription, error) {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.WebhookSubscription{}).
+			Where("id = ? AND restaurant_id = ?", subscriptionId, restaurantId).
+			Updates(fields)
+		if result.Error != nil {
+			return fmt.Errorf("error updating webhook subscription: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("webhook subscription with ID %s not found: %w", subscriptionId, gorm.ErrRecordNotFound)
+		}
+		if active, ok := fields["Active"].(bool); ok && !active {
+			return failPendingDeliveries(tx, subscriptionId, "subscription was deactivated")
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Str("subscription_id", subscriptionId.String()).
+			Err(err).
+			Msg("Error updating webhook subscription")
+		return model.WebhookSubscription{}, err
+	}
+	return repo.FindSubscription(subscriptionId, restaurantId)
+}
+
+// DeleteSubscription deletes a subscription and fails its pending deliveries. The
+// delivery log is kept.
+func (repo *WebhooksRepositoryImpl) DeleteSubscription(subscriptionId uuid.UUID, restaurantId string) error {
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND restaurant_id = ?", subscriptionId, restaurantId).Delete(&model.WebhookSubscription{})
+		if result.Error != nil {
+			return fmt.Errorf("error deleting webhook subscription: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("webhook subscription with ID %s not found: %w", subscriptionId, gorm.ErrRecordNotFound)
+		}
+		return failPendingDeliveries(tx, subscriptionId, "subscription was deleted")
+	})
+	if err != nil {
+		log.Error().
+			Str("subscription_id", subscriptionId.String()).
+			Err(err).
+			Msg("Error deleting webhook subscription")
+		return err
+	}
+	return nil
+}
+
+// Enqueue creates a pending delivery of an event for every active subscription to its type
+// that has none yet.
+func (repo *WebhooksRepositoryImpl) Enqueue(restaurantId string, eventId string, eventType string, payload string, at time.Time) (int, error) {
+	eventTypes, err := json.Marshal([]string{eventType})
+	if err != nil {
+		return 0, err
+	}
+	var subscriptions []model.WebhookSubscription
+	if err := repo.Db.Where("restaurant_id = ? AND active AND event_types @> ?", restaurantId, string(eventTypes)).Find(&subscriptions).Error; err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Str("type", eventType).
+			Err(err).
+			Msg("Error finding webhook subscriptions")
+		return 0, fmt.Errorf("error finding webhook subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return 0, nil
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		delivery := model.WebhookDelivery{
+			RestaurantID:   subscription.RestaurantID,
+			SubscriptionID: subscription.ID,
+			EventID:        eventId,
+			EventType:      eventType,
+			Payload:        payload,
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  &at,
+		}
+		delivery.ID = uuid.New()
+		deliveries = append(deliveries, delivery)
+	}
+	// An event relayed again after a failure keeps the deliveries queued the first time
+	result := repo.Db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "replay_of_id IS NULL"}}},
+		DoNothing:   true,
+	}).Create(&deliveries)
+	if result.Error != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Str("type", eventType).
+			Err(result.Error).
+			Msg("Error enqueuing webhook deliveries")
+		return 0, fmt.Errorf("error enqueuing webhook deliveries: %w", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// CreateDelivery stores a new delivery.
+func (repo *WebhooksRepositoryImpl) CreateDelivery(delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	delivery.ID = uuid.New()
+	if err := repo.Db.Omit(clause.Associations).Create(&delivery).Error; err != nil {
+		log.Error().
+			Str("subscription_id", delivery.SubscriptionID.String()).
+			Err(err).
+			Msg("Error creating webhook delivery")
+		return model.WebhookDelivery{}, fmt.Errorf("error creating webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// FindDelivery retrieves a delivery of a restaurant with its attempts, oldest first.
+func (repo *WebhooksRepositoryImpl) FindDelivery(deliveryId uuid.UUID, restaurantId string) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := repo.Db.Preload("Log", func(db *gorm.DB) *gorm.DB {
+		return db.Order("number ASC")
+	}).Where("id = ? AND restaurant_id = ?", deliveryId, restaurantId).First(&delivery).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return model.WebhookDelivery{}, fmt.Errorf("webhook delivery with ID %s not found: %w", deliveryId, err)
+		}
+		log.Error().
+			Str("delivery_id", deliveryId.String()).
+			Err(err).
+			Msg("Error finding webhook delivery")
+		return model.WebhookDelivery{}, fmt.Errorf("error finding webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// FindDeliveries retrieves a page of the deliveries of a subscription.
+func (repo *WebhooksRepositoryImpl) FindDeliveries(subscriptionId uuid.UUID, restaurantId string, status string, page pagination.Page) ([]model.WebhookDelivery, pagination.Result, error) {
+	query := repo.Db.Model(&model.WebhookDelivery{}).Where("subscription_id = ? AND restaurant_id = ?", subscriptionId, restaurantId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	deliveries, result, err := findPage[model.WebhookDelivery](query, page)
+	if err != nil {
+		log.Error().
+			Str("subscription_id", subscriptionId.String()).
+			Err(err).
+			Msg("Error finding webhook deliveries")
+		return nil, result, fmt.Errorf("error finding webhook deliveries: %w", err)
+	}
+	return deliveries, result, nil
+}
+
+// ClaimDue leases due deliveries. Rows are locked with SKIP LOCKED while the lease is
+// written, and the lease keeps other replicas away while the requests are made.
+func (repo *WebhooksRepositoryImpl) ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	var claimed []model.WebhookDelivery
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		var due []model.WebhookDelivery
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)", model.WebhookDeliveryPending, now, now).
+			Where("subscription_id IN (?)", tx.Model(&model.WebhookSubscription{}).Select("id").Where("active")).
+			Order("next_attempt_at ASC, created_at ASC").
+			Limit(limit).
+			Find(&due)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(due))
+		for i := range due {
+			ids[i] = due[i].ID
+		}
+		lockedUntil := now.Add(lease)
+		if err := tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).Update("locked_until", lockedUntil).Error; err != nil {
+			return err
+		}
+		if err := tx.Preload("Subscription").Where("id IN ?", ids).Order("next_attempt_at ASC, created_at ASC").Find(&claimed).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error claiming due webhook deliveries")
+		return nil, fmt.Errorf("error claiming due webhook deliveries: %w", err)
+	}
+	return claimed, nil
+}
+
+// RecordAttempt stores an attempt and the state of its delivery, and updates the
+// failure count of the subscription, disabling it once it reaches disableAfter. Test
+// deliveries leave the count alone.
+func (repo *WebhooksRepositoryImpl) RecordAttempt(delivery model.WebhookDelivery, attempt model.WebhookAttempt, disableAfter int) (model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	err := repo.Db.Transaction(func(tx *gorm.DB) error {
+		attempt.ID = uuid.New()
+		attempt.DeliveryID = delivery.ID
+		if err := tx.Create(&attempt).Error; err != nil {
+			return fmt.Errorf("error recording webhook attempt: %w", err)
+		}
+		if err := tx.Model(&model.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+			"Status":         delivery.Status,
+			"Attempts":       delivery.Attempts,
+			"NextAttemptAt":  delivery.NextAttemptAt,
+			"LockedUntil":    nil,
+			"LastAttemptAt":  delivery.LastAttemptAt,
+			"ResponseStatus": delivery.ResponseStatus,
+			"Error":          delivery.Error,
+			"DeliveredAt":    delivery.DeliveredAt,
+		}).Error; err != nil {
+			return fmt.Errorf("error updating webhook delivery: %w", err)
+		}
+
+		if delivery.EventType == model.WebhookEventTest {
+			if err := tx.Unscoped().Where("id = ?", delivery.SubscriptionID).First(&subscription).Error; err != nil {
+				return fmt.Errorf("error finding webhook subscription: %w", err)
+			}
+			return nil
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Unscoped().
+			Where("id = ?", delivery.SubscriptionID).First(&subscription).Error; err != nil {
+			return fmt.Errorf("error locking webhook subscription: %w", err)
+		}
+		fields := map[string]interface{}{"ConsecutiveFailures": 0}
+		if delivery.Status != model.WebhookDeliverySucceeded {
+			fields["ConsecutiveFailures"] = subscription.ConsecutiveFailures + 1
+		}
+		disable := subscription.Active && disableAfter > 0 && fields["ConsecutiveFailures"].(int) >= disableAfter
+		if disable {
+			now := time.Now()
+			fields["Active"] = false
+			fields["DisabledAt"] = &now
+			fields["DisabledReason"] = fmt.Sprintf("disabled after %d consecutive failed deliveries; last error: %s", fields["ConsecutiveFailures"], truncateText(delivery.Error, 200))
+		}
+		if err := tx.Model(&subscription).Updates(fields).Error; err != nil {
+			return fmt.Errorf("error updating webhook subscription: %w", err)
+		}
+		if disable {
+			return failPendingDeliveries(tx, subscription.ID, "subscription was disabled after repeated failures")
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().
+			Str("delivery_id", delivery.ID.String()).
+			Err(err).
+			Msg("Error recording webhook attempt")
+		return model.WebhookSubscription{}, err
+	}
+	return subscription, nil
+}
+
+// failPendingDeliveries gives up on the pending deliveries of a subscription with db,
+// which must be a transaction.
+func failPendingDeliveries(db *gorm.DB, subscriptionId uuid.UUID, reason string) error {
+	err := db.Model(&model.WebhookDelivery{}).
+		Where("subscription_id = ? AND status = ?", subscriptionId, model.WebhookDeliveryPending).
+		Updates(map[string]interface{}{
+			"Status":        model.WebhookDeliveryFailed,
+			"NextAttemptAt": nil,
+			"LockedUntil":   nil,
+			"Error":         reason,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("error failing pending webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// truncateText cuts s to at most n characters.
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
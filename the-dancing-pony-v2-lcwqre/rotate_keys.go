@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/webhooks"
+
+	"github.com/google/uuid"
+)
+
+const rotateKeysUsage = `usage: thedancingpony rotate-keys <target> [flags]
+
+Targets:
+  webhooks    give webhook subscriptions new signing secrets and print them; deliveries
+              are signed with the new secrets from now on, so receivers must be given them
+  env         print new random values of the signing keys kept in the configuration;
+              tokens, file links and cursors signed with the old values stop verifying
+              once the new values are deployed
+
+Flags of webhooks:
+`
+
+// signingKeyVariables are the environment variables of the signing keys owned by this
+// API. The payment webhook secret is not one: it is issued by the payment provider.
+var signingKeyVariables = []string{"JWT_SECRET", "STORAGE_SIGNING_KEY", "CURSOR_SIGNING_KEY"}
+
+// runRotateKeys runs the rotate-keys command.
+func runRotateKeys(settings config.Settings, args []string) int {
+	flags := newFlagSet("rotate-keys", rotateKeysUsage)
+	restaurant := flags.String("restaurant", "", "only rotate the secrets of this restaurant's subscriptions")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if code, ok := parseFlags(flags, args); !ok {
+			return code
+		}
+		flags.Usage()
+		return 2
+	}
+	target, args := args[0], args[1:]
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	switch target {
+	case "webhooks":
+		return rotateWebhookSecrets(settings, *restaurant)
+	case "env":
+		if *restaurant != "" {
+			break
+		}
+		for _, name := range signingKeyVariables {
+			key := make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return 1
+			}
+			fmt.Printf("%s=%s\n", name, hex.EncodeToString(key))
+		}
+		return 0
+	}
+	flags.Usage()
+	return 2
+}
+
+// rotateWebhookSecrets gives the subscriptions of a restaurant, or of every restaurant
+// when restaurant is empty, new secrets and prints them.
+func rotateWebhookSecrets(settings config.Settings, restaurant string) int {
+	var restaurantIds []uuid.UUID
+	if restaurant != "" {
+		restaurantId, err := uuid.Parse(restaurant)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error: -restaurant must be a restaurant ID")
+			return 2
+		}
+		restaurantIds = append(restaurantIds, restaurantId)
+	}
+
+	db, ok := openDatabase(settings)
+	if !ok {
+		return 1
+	}
+	if restaurant == "" {
+		var err error
+		if restaurantIds, err = repository.NewMaintenanceRepositoryImpl(db).FindRestaurantIds(); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+	}
+
+	webhooksRepository := repository.NewWebhooksRepositoryImpl(db)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "RESTAURANT\tSUBSCRIPTION\tURL\tSECRET")
+	for _, restaurantId := range restaurantIds {
+		subscriptions, err := webhooksRepository.FindSubscriptions(restaurantId.String())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		for _, subscription := range subscriptions {
+			secret, err := webhooks.NewSecret()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return 1
+			}
+			_, err = webhooksRepository.UpdateSubscription(subscription.ID, restaurantId.String(), map[string]interface{}{"Secret": secret})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: rotating the secret of subscription %s: %v\n", subscription.ID, err)
+				return 1
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", restaurantId, subscription.ID, subscription.URL, secret)
+		}
+	}
+	return 0
+}
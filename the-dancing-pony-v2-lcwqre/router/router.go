@@ -5,8 +5,12 @@ import (
 	"time"
 
 	"the-dancing-pony-v2-lcwqre/controller"
+	"the-dancing-pony-v2-lcwqre/health"
 	"the-dancing-pony-v2-lcwqre/middleware"
+	"the-dancing-pony-v2-lcwqre/payments"
 	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/storage"
+	"the-dancing-pony-v2-lcwqre/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -16,7 +20,25 @@ func NewRouter(
 	dishController *controller.DishesController,
 	authController *controller.AuthController,
 	resturantController *controller.RestaurantsController,
+	menuController *controller.MenuController,
+	dishImagesController *controller.DishImagesController,
+	filesController *controller.FilesController,
+	ordersController *controller.OrdersController,
+	cartController *controller.CartController,
+	paymentsController *controller.PaymentsController,
+	eventsController *controller.EventsController,
+	reservationsController *controller.ReservationsController,
+	floorPlanController *controller.FloorPlanController,
+	promotionsController *controller.PromotionsController,
+	loyaltyController *controller.LoyaltyController,
+	taxController *controller.TaxController,
+	webhookController *controller.WebhookController,
+	settingsController *controller.SettingsController,
+	webhookReceiver http.Handler,
+	healthChecker *health.Checker,
 	userRepo repository.UserRepository,
+	jwtSecret []byte,
+	requireIfMatch bool,
 ) *gin.Engine {
 	router := gin.New()
 
@@ -32,9 +54,29 @@ func NewRouter(
 	// Initialize user based rate limiter
 	iPrateLimiter := middleware.NewIPRateLimiter(10*time.Second, 5)
 
+	// Conditional writes guard against lost updates between concurrent editors
+	ifMatch := middleware.RequireIfMatch(requireIfMatch)
+
+	// Liveness and readiness endpoints for the orchestrator and load balancers
+	router.GET(health.LivenessPath, gin.WrapH(healthChecker.LivenessHandler()))
+	router.GET(health.ReadinessPath, gin.WrapH(healthChecker.ReadinessHandler()))
+
 	// Prometheus metrics endpoint
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
+	// Signed links to objects of the local and memory stores
+	router.GET(storage.FilesRoutePrefix+"*key", filesController.Serve)
+	router.PUT(storage.FilesRoutePrefix+"*key", filesController.Upload)
+
+	// Payment provider webhooks and stand-in provider pages
+	router.POST(payments.WebhookPath, middleware.RequestUniqueId(), paymentsController.Webhook)
+	router.Any(payments.StandInRoutePrefix+"*path", paymentsController.StandIn)
+
+	// Local receiver for trying webhook subscriptions out, when enabled
+	if webhookReceiver != nil {
+		router.Any(webhooks.ReceiverRoutePrefix+"*path", gin.WrapH(webhookReceiver))
+	}
+
 	// Welcome route
 	router.GET("/", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, "Welcome home")
@@ -55,7 +97,7 @@ func NewRouter(
 
 	// Customer and admin routes
 	dishesRouter := apiRouter.Group("/restaurants/:restaurantId/dishes")
-	dishesRouter.Use(middleware.AuthMiddleware(userRepo), middleware.PermissionMiddleware("customer", "admin", "restaurant"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	dishesRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("customer", "admin", "restaurant"), middleware.MultiTenantRouting(), rateLimiter.Limit())
 	{
 		dishesRouter.GET("", dishController.FindAll)
 		dishesRouter.GET("/:dishId", dishController.FindById)
@@ -65,19 +107,214 @@ func NewRouter(
 
 	// Admin-only routes
 	adminDishesRouter := apiRouter.Group("/restaurants/:restaurantId/dishes/admin")
-	adminDishesRouter.Use(middleware.AuthMiddleware(userRepo), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	adminDishesRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
 	{
 		adminDishesRouter.POST("/", dishController.Create)
-		adminDishesRouter.PATCH("/:dishId", dishController.Update)
-		adminDishesRouter.DELETE("/:dishId", dishController.Delete)
+		adminDishesRouter.POST("/import", dishController.Import)
+		adminDishesRouter.GET("/export", dishController.Export)
+		adminDishesRouter.PATCH("/:dishId", ifMatch, dishController.Update)
+		adminDishesRouter.DELETE("/:dishId", ifMatch, dishController.Delete)
+
+		// Review moderation routes
+		adminDishesRouter.GET("/reviews", dishController.ListReviews)
+		adminDishesRouter.POST("/reviews/:ratingId/approve", dishController.ApproveReview)
+		adminDishesRouter.POST("/reviews/:ratingId/reject", dishController.RejectReview)
+
+		// Draft, publish and scheduling routes
+		adminDishesRouter.GET("/preview", menuController.Preview)
+		adminDishesRouter.GET("/:dishId/changes", menuController.ListChanges)
+		adminDishesRouter.POST("/:dishId/changes", menuController.DraftChange)
+		adminDishesRouter.POST("/:dishId/publish", menuController.PublishDish)
+		adminDishesRouter.POST("/:dishId/unpublish", menuController.UnpublishDish)
+		adminDishesRouter.POST("/changes/:changeId/publish", menuController.PublishChange)
+		adminDishesRouter.DELETE("/changes/:changeId", menuController.DiscardChange)
+
+		// Image gallery and direct upload routes
+		adminDishesRouter.GET("/:dishId/images", dishImagesController.List)
+		adminDishesRouter.POST("/:dishId/images", dishImagesController.Upload)
+		adminDishesRouter.PUT("/:dishId/images/order", dishImagesController.Reorder)
+		adminDishesRouter.POST("/:dishId/images/uploads", dishImagesController.CreateUpload)
+		adminDishesRouter.GET("/:dishId/images/uploads/:uploadId", dishImagesController.GetUpload)
+		adminDishesRouter.POST("/:dishId/images/uploads/:uploadId/confirm", dishImagesController.ConfirmUpload)
+		adminDishesRouter.PATCH("/:dishId/images/:imageId", dishImagesController.Update)
+		adminDishesRouter.DELETE("/:dishId/images/:imageId", dishImagesController.Delete)
+		adminDishesRouter.POST("/:dishId/images/:imageId/primary", dishImagesController.SetPrimary)
+	}
+
+	// Customer order routes; customers only see their own orders
+	ordersRouter := apiRouter.Group("/restaurants/:restaurantId/orders")
+	ordersRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("customer", "admin", "restaurant"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		ordersRouter.POST("", ordersController.Place)
+		ordersRouter.GET("", ordersController.List)
+		ordersRouter.GET("/:orderId", ordersController.FindById)
+		ordersRouter.POST("/:orderId/cancel", ordersController.Cancel)
+		ordersRouter.GET("/:orderId/payments", paymentsController.List)
+		ordersRouter.POST("/:orderId/payments", paymentsController.Pay)
+		ordersRouter.GET("/:orderId/receipt", taxController.Receipt)
+	}
+
+	// Cart routes of signed-in customers
+	cartRouter := apiRouter.Group("/restaurants/:restaurantId/cart")
+	cartRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("customer", "admin", "restaurant"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		cartRouter.GET("", cartController.Get)
+		cartRouter.POST("/items", cartController.AddItem)
+		cartRouter.PATCH("/items/:itemId", cartController.UpdateItem)
+		cartRouter.DELETE("/items/:itemId", cartController.RemoveItem)
+		cartRouter.PUT("/coupon", cartController.ApplyCoupon)
+		cartRouter.DELETE("/coupon", cartController.RemoveCoupon)
+		cartRouter.POST("/merge", cartController.Merge)
+		cartRouter.POST("/checkout", cartController.Checkout)
+	}
+
+	// Guest cart routes, identified by the X-Cart-Token header instead of a login
+	guestCartRouter := apiRouter.Group("/restaurants/:restaurantId/guest-cart")
+	guestCartRouter.Use(middleware.MultiTenantRouting(), iPrateLimiter.Limit())
+	{
+		guestCartRouter.GET("", cartController.Get)
+		guestCartRouter.POST("/items", cartController.AddItem)
+		guestCartRouter.PATCH("/items/:itemId", cartController.UpdateItem)
+		guestCartRouter.DELETE("/items/:itemId", cartController.RemoveItem)
+		guestCartRouter.PUT("/coupon", cartController.ApplyCoupon)
+		guestCartRouter.DELETE("/coupon", cartController.RemoveCoupon)
+	}
+
+	// Staff order routes
+	adminOrdersRouter := apiRouter.Group("/restaurants/:restaurantId/orders/admin")
+	adminOrdersRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		adminOrdersRouter.GET("", ordersController.StaffList)
+		adminOrdersRouter.GET("/:orderId", ordersController.StaffFindById)
+		adminOrdersRouter.POST("/:orderId/transitions", ordersController.Transition)
+		adminOrdersRouter.GET("/:orderId/payments", paymentsController.StaffList)
+		adminOrdersRouter.GET("/:orderId/receipt", taxController.StaffReceipt)
+		adminOrdersRouter.POST("/:orderId/invoice", taxController.IssueInvoice)
+	}
+
+	// Staff payment routes
+	adminPaymentsRouter := apiRouter.Group("/restaurants/:restaurantId/payments/admin")
+	adminPaymentsRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		adminPaymentsRouter.GET("/:paymentId", paymentsController.FindById)
+		adminPaymentsRouter.POST("/:paymentId/capture", paymentsController.Capture)
+		adminPaymentsRouter.POST("/:paymentId/refunds", paymentsController.Refund)
+	}
+
+	// Customer reservation routes; customers only see their own reservations and waitlist entries
+	reservationsRouter := apiRouter.Group("/restaurants/:restaurantId/reservations")
+	reservationsRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("customer", "admin", "restaurant"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		reservationsRouter.GET("/availability", reservationsController.Availability)
+		reservationsRouter.POST("", reservationsController.Book)
+		reservationsRouter.GET("", reservationsController.List)
+		reservationsRouter.GET("/waitlist", reservationsController.Waitlist)
+		reservationsRouter.POST("/waitlist", reservationsController.JoinWaitlist)
+		reservationsRouter.DELETE("/waitlist/:entryId", reservationsController.LeaveWaitlist)
+		reservationsRouter.GET("/:reservationId", reservationsController.FindById)
+		reservationsRouter.POST("/:reservationId/cancel", reservationsController.Cancel)
+	}
+
+	// Staff reservation, floor plan and schedule routes
+	adminReservationsRouter := apiRouter.Group("/restaurants/:restaurantId/reservations/admin")
+	adminReservationsRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		adminReservationsRouter.GET("", reservationsController.StaffList)
+		adminReservationsRouter.GET("/waitlist", reservationsController.StaffWaitlist)
+		adminReservationsRouter.GET("/areas", floorPlanController.ListAreas)
+		adminReservationsRouter.POST("/areas", floorPlanController.CreateArea)
+		adminReservationsRouter.DELETE("/areas/:areaId", floorPlanController.DeleteArea)
+		adminReservationsRouter.GET("/tables", floorPlanController.ListTables)
+		adminReservationsRouter.POST("/tables", floorPlanController.CreateTable)
+		adminReservationsRouter.PUT("/tables/:tableId", floorPlanController.ReplaceTable)
+		adminReservationsRouter.DELETE("/tables/:tableId", floorPlanController.DeleteTable)
+		adminReservationsRouter.GET("/schedule", floorPlanController.Schedule)
+		adminReservationsRouter.PUT("/schedule", floorPlanController.SaveSchedule)
+		adminReservationsRouter.GET("/:reservationId", reservationsController.StaffFindById)
+		adminReservationsRouter.POST("/:reservationId/transitions", reservationsController.Transition)
+	}
+
+	// Staff promotion and coupon routes
+	adminPromotionsRouter := apiRouter.Group("/restaurants/:restaurantId/promotions/admin")
+	adminPromotionsRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		adminPromotionsRouter.GET("", promotionsController.List)
+		adminPromotionsRouter.POST("", promotionsController.Create)
+		adminPromotionsRouter.GET("/:promotionId", promotionsController.FindById)
+		adminPromotionsRouter.PUT("/:promotionId", promotionsController.Replace)
+		adminPromotionsRouter.DELETE("/:promotionId", promotionsController.Delete)
+	}
+
+	// Customer loyalty routes; customers only see their own points
+	loyaltyRouter := apiRouter.Group("/restaurants/:restaurantId/loyalty")
+	loyaltyRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("customer", "admin", "restaurant"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		loyaltyRouter.GET("", loyaltyController.Account)
+		loyaltyRouter.GET("/ledger", loyaltyController.Ledger)
+		loyaltyRouter.GET("/rewards", loyaltyController.Rewards)
+	}
+
+	// Staff loyalty program, reward and ledger routes
+	adminLoyaltyRouter := apiRouter.Group("/restaurants/:restaurantId/loyalty/admin")
+	adminLoyaltyRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		adminLoyaltyRouter.GET("/program", loyaltyController.FindProgram)
+		adminLoyaltyRouter.PUT("/program", loyaltyController.SaveProgram)
+		adminLoyaltyRouter.GET("/rewards", loyaltyController.StaffRewards)
+		adminLoyaltyRouter.POST("/rewards", loyaltyController.CreateReward)
+		adminLoyaltyRouter.PUT("/rewards/:rewardId", loyaltyController.ReplaceReward)
+		adminLoyaltyRouter.DELETE("/rewards/:rewardId", loyaltyController.DeleteReward)
+		adminLoyaltyRouter.GET("/customers/:customerId", loyaltyController.StaffAccount)
+		adminLoyaltyRouter.GET("/customers/:customerId/ledger", loyaltyController.StaffLedger)
+		adminLoyaltyRouter.POST("/customers/:customerId/adjustments", loyaltyController.Adjust)
+	}
+
+	// Staff tax configuration routes
+	adminTaxRouter := apiRouter.Group("/restaurants/:restaurantId/tax/admin")
+	adminTaxRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		adminTaxRouter.GET("/settings", taxController.FindSettings)
+		adminTaxRouter.PUT("/settings", taxController.SaveSettings)
+	}
+
+	// Staff webhook subscription and delivery log routes
+	adminWebhooksRouter := apiRouter.Group("/restaurants/:restaurantId/webhooks/admin")
+	adminWebhooksRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("restaurant", "admin"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		adminWebhooksRouter.GET("", webhookController.FindAll)
+		adminWebhooksRouter.POST("", webhookController.Create)
+		adminWebhooksRouter.GET("/event-types", webhookController.EventTypes)
+		adminWebhooksRouter.GET("/deliveries/:deliveryId", webhookController.FindDelivery)
+		adminWebhooksRouter.POST("/deliveries/:deliveryId/replay", webhookController.Replay)
+		adminWebhooksRouter.GET("/:webhookId", webhookController.FindById)
+		adminWebhooksRouter.PUT("/:webhookId", webhookController.Replace)
+		adminWebhooksRouter.DELETE("/:webhookId", webhookController.Delete)
+		adminWebhooksRouter.POST("/:webhookId/rotate-secret", webhookController.RotateSecret)
+		adminWebhooksRouter.POST("/:webhookId/test", webhookController.Test)
+		adminWebhooksRouter.GET("/:webhookId/deliveries", webhookController.FindDeliveries)
+	}
+
+	// Realtime order and dish events; browsers pass their token in the access_token query parameter
+	eventsRouter := apiRouter.Group("/restaurants/:restaurantId/events")
+	eventsRouter.Use(middleware.TokenFromQuery("access_token"), middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("customer", "admin", "restaurant"), middleware.MultiTenantRouting(), rateLimiter.Limit())
+	{
+		eventsRouter.GET("", eventsController.Stream)
+		eventsRouter.GET("/ws", eventsController.WebSocket)
+	}
+
+	// Instance administration routes, for users with the admin permission
+	adminRouter := apiRouter.Group("/admin")
+	adminRouter.Use(middleware.AuthMiddleware(userRepo, jwtSecret), middleware.PermissionMiddleware("admin"), rateLimiter.Limit())
+	{
+		adminRouter.GET("/config", settingsController.FindAll)
 	}
 
 	restaurantRouter := apiRouter.Group("/restaurants")
 	restaurantRouter.POST("/", resturantController.Create)
 	restaurantRouter.GET("/:restaurantId", resturantController.FindById)
 	restaurantRouter.GET("", resturantController.FindAll)
-	restaurantRouter.PATCH("/:restaurantId", resturantController.Update)
-	restaurantRouter.DELETE("/:restaurantId", resturantController.Delete)
+	restaurantRouter.PATCH("/:restaurantId", ifMatch, resturantController.Update)
+	restaurantRouter.DELETE("/:restaurantId", ifMatch, resturantController.Delete)
 
 	return router
 }
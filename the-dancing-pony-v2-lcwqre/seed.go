@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/service"
+)
+
+const seedUsage = `usage: thedancingpony seed [flags]
+
+Creates a demo restaurant with a menu and three users, an admin, a member of staff
+and a customer, who all sign in with the given password. Nothing is created when a
+restaurant with the demo name already exists.
+
+Flags:
+`
+
+// seedUser is a demo user and the permission they are given.
+type seedUser struct {
+	name       string
+	email      string
+	permission string
+}
+
+var seedUsers = []seedUser{
+	{"Barliman Butterbur", "admin@dancingpony.test", "admin"},
+	{"Nob", "staff@dancingpony.test", "restaurant"},
+	{"Frodo Underhill", "customer@dancingpony.test", "customer"},
+}
+
+var seedDishes = []model.Dish{
+	{Name: "Mushroom Soup", Description: "Field mushrooms, cream and thyme, with bread", Price: 6.5, Category: "starters", Tags: model.JSONList[string]{"vegetarian"}},
+	{Name: "Honey Bread", Description: "Warm loaf with butter and honey", Price: 3.5, Category: "starters", Tags: model.JSONList[string]{"vegetarian"}},
+	{Name: "Roast Chicken", Description: "Half a chicken with roast potatoes and gravy", Price: 14.5, Category: "mains", Tags: model.JSONList[string]{}},
+	{Name: "Rabbit Stew", Description: "Slow cooked with carrots and herbs", Price: 13, Category: "mains", Tags: model.JSONList[string]{}},
+	{Name: "Seed Cake", Description: "Caraway seed cake with cream", Price: 4, Category: "desserts", Tags: model.JSONList[string]{"vegetarian"}},
+	{Name: "Pint of Ale", Description: "Brewed in Bree", Price: 4.5, Category: "drinks", Tags: model.JSONList[string]{"vegan"}},
+}
+
+// runSeed runs the seed command.
+func runSeed(settings config.Settings, args []string) int {
+	flags := newFlagSet("seed", seedUsage)
+	name := flags.String("name", "The Dancing Pony", "name of the demo restaurant")
+	password := flags.String("password", "dancingpony", "password of the demo users")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if *name == "" || *password == "" {
+		fmt.Fprintln(os.Stderr, "error: -name and -password must not be empty")
+		return 2
+	}
+
+	db, ok := openDatabase(settings)
+	if !ok {
+		return 1
+	}
+	restaurantRepository := repository.NewRestaurantsRepositoryImpl(db)
+	dishRepository := repository.NewDishesRepositoryImpl(db)
+	userRepository := repository.NewUserRepository(db)
+	authService := service.NewAuthService(userRepository, []byte(settings.JWTSecret))
+
+	existing, _, err := restaurantRepository.Search(*name, pagination.Page{Limit: pagination.MaxLimit})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	for _, restaurant := range existing {
+		if restaurant.Name == *name {
+			fmt.Printf("Demo restaurant already exists: %s\n", restaurant.ID)
+			return 0
+		}
+	}
+
+	restaurant, err := restaurantRepository.Create(model.Restaurant{
+		Name:        *name,
+		Description: "An inn at the crossroads, serving hearty food and good ale",
+		Location:    "Bree",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	var admin *model.User
+	for _, user := range seedUsers {
+		err := authService.Register(request.RegisterRequest{
+			Name:        user.name,
+			Email:       user.email,
+			Password:    *password,
+			Permissions: []string{user.permission},
+		}, restaurant.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: creating %s: %v\n", user.email, err)
+			return 1
+		}
+		if user.permission == "admin" {
+			if admin, err = userRepository.FindByEmail(user.email, restaurant.ID); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return 1
+			}
+		}
+	}
+
+	now := time.Now()
+	for i, dish := range seedDishes {
+		sku := fmt.Sprintf("DEMO-%03d", i+1)
+		dish.SKU = &sku
+		dish.RestaurantID = restaurant.ID
+		dish.Status = model.DishStatusPublished
+		dish.PublishedAt = &now
+		if _, err := dishRepository.Create(dish, admin.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "error: creating %s: %v\n", dish.Name, err)
+			return 1
+		}
+	}
+
+	fmt.Printf("Created %s (%s) with %d dishes and these users:\n\n", restaurant.Name, restaurant.ID, len(seedDishes))
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "EMAIL\tPERMISSION\tPASSWORD")
+	for _, user := range seedUsers {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", user.email, user.permission, *password)
+	}
+	w.Flush()
+	return 0
+}
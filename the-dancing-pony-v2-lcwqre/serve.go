@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+
+	config "the-dancing-pony-v2-lcwqre/Config"
+	cache "the-dancing-pony-v2-lcwqre/caching"
+	"the-dancing-pony-v2-lcwqre/controller"
+	"the-dancing-pony-v2-lcwqre/eventbus"
+	"the-dancing-pony-v2-lcwqre/health"
+	"the-dancing-pony-v2-lcwqre/jobs"
+	"the-dancing-pony-v2-lcwqre/media"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/payments"
+	"the-dancing-pony-v2-lcwqre/realtime"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/router"
+	"the-dancing-pony-v2-lcwqre/service"
+	"the-dancing-pony-v2-lcwqre/storage"
+	"the-dancing-pony-v2-lcwqre/webhooks"
+)
+
+const serveUsage = `usage: thedancingpony serve
+
+Serves the API, and runs background jobs too unless SERVER_RUNS_WORKER=false. On
+SIGTERM or an interrupt, /readyz fails for SERVER_DRAIN_DELAY before the requests in
+flight are given SERVER_SHUTDOWN_TIMEOUT to finish.
+`
+
+const workerUsage = `usage: thedancingpony worker
+
+Runs background jobs and webhook deliveries without serving the API, until it is
+interrupted. Only metrics, /healthz and /readyz are served, on WORKER_METRICS_ADDR.
+`
+
+// runServe runs the serve command.
+func runServe(settings config.Settings, args []string) int {
+	if code, ok := parseFlags(newFlagSet("serve", serveUsage), args); !ok {
+		return code
+	}
+	return serve(settings, false)
+}
+
+// runWorker runs the worker command.
+func runWorker(settings config.Settings, args []string) int {
+	if code, ok := parseFlags(newFlagSet("worker", workerUsage), args); !ok {
+		return code
+	}
+	return serve(settings, true)
+}
+
+// newCache connects the response cache; Redis is optional at startup.
+func newCache(settings config.Settings) (cache.Cache, error) {
+	return cache.NewCache(context.Background(), cache.Config{
+		Driver:        settings.Cache.Driver,
+		RedisAddr:     settings.Redis.Addr,
+		RedisPassword: settings.Redis.Password,
+		TTLs:          settings.Cache.TTLs(),
+		StaleTTL:      settings.Cache.StaleTTL,
+		VersionTTL:    settings.Cache.VersionTTL,
+		MaxEntries:    settings.Cache.MaxEntries,
+	})
+}
+
+// serve runs the API server, or only the background work in worker mode, and returns
+// the exit code once it stops.
+func serve(settings config.Settings, workerMode bool) int {
+	if workerMode {
+		log.Info().Msg("Starting worker")
+	} else {
+		log.Info().Msgf("Starting server on port %d", settings.Port)
+	}
+
+	// Initialize database; the schema must be migrated unless MIGRATE_ON_START is set
+	db, err := config.SetupDatabase(settings.Database, settings.Database.MigrateOnStart)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to set up database")
+		return 1
+	}
+	log.Info().Msgf("Database succesfully set up")
+
+	// Initialize the response cache
+	appCache, err := newCache(settings)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize cache")
+		return 1
+	}
+	log.Info().Msgf("Cache initialized")
+
+	// Sign access tokens; a random secret signs users out on every restart. Settings
+	// only leave secrets and signing keys empty with DEV_RANDOM_KEYS.
+	jwtSecret := []byte(settings.JWTSecret)
+	if len(jwtSecret) == 0 {
+		jwtSecret = []byte(uuid.New().String())
+		log.Warn().Msg("JWT_SECRET is not set and DEV_RANDOM_KEYS is on, access tokens will not survive a restart")
+	}
+
+	// Initialize the object store for dish images
+	signingKey := settings.Storage.SigningKey
+	if signingKey == "" {
+		signingKey = uuid.New().String()
+		log.Warn().Msg("STORAGE_SIGNING_KEY is not set and DEV_RANDOM_KEYS is on, signed file links will not survive a restart")
+	}
+	objectStore, err := storage.NewObjectStore(context.Background(), storage.Config{
+		Driver:           settings.Storage.Driver,
+		LocalDir:         settings.Storage.LocalDir,
+		PublicURL:        settings.PublicURL,
+		SigningKey:       signingKey,
+		URLTTL:           settings.Storage.URLTTL,
+		S3Bucket:         settings.Storage.S3Bucket,
+		S3Region:         settings.Storage.S3Region,
+		S3Endpoint:       settings.Storage.S3Endpoint,
+		S3AccessKey:      settings.Storage.S3AccessKey,
+		S3SecretKey:      settings.Storage.S3SecretKey,
+		S3ForcePathStyle: settings.Storage.S3ForcePathStyle,
+		S3PublicURL:      settings.Storage.S3PublicURL,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize object store")
+		return 1
+	}
+
+	// Configure image validation limits
+	imageProcessor := media.NewImageProcessor(settings.Images.MaxBytes, settings.Images.MaxDimension)
+
+	// Sign pagination cursors so clients cannot forge positions
+	cursorSigningKey := settings.CursorSigningKey
+	if cursorSigningKey == "" {
+		cursorSigningKey = uuid.New().String()
+		log.Warn().Msg("CURSOR_SIGNING_KEY is not set and DEV_RANDOM_KEYS is on, pagination cursors will not survive a restart")
+	}
+	cursorSigner := pagination.NewCursorSigner(cursorSigningKey)
+
+	// Initialize the payment provider; the fake provider delivers its webhooks back to this API
+	paymentWebhookSecret := settings.Payments.WebhookSecret
+	if paymentWebhookSecret == "" {
+		paymentWebhookSecret = uuid.New().String()
+		log.Warn().Msg("PAYMENT_WEBHOOK_SECRET is not set and DEV_RANDOM_KEYS is on, using a random secret")
+	}
+	paymentProvider, err := payments.NewProvider(payments.Config{
+		Driver:        settings.Payments.Provider,
+		WebhookSecret: paymentWebhookSecret,
+		PublicURL:     settings.PublicURL,
+		WebhookDelay:  settings.Payments.FakeWebhookDelay,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize payment provider")
+		return 1
+	}
+
+	// Initialize the realtime hub; the Redis driver shares events between replicas
+	realtimeHub, err := realtime.NewHub(context.Background(), realtime.Config{
+		Driver:        settings.Realtime.Driver,
+		RedisAddr:     settings.Redis.Addr,
+		RedisPassword: settings.Redis.Password,
+		HistorySize:   settings.Realtime.HistorySize,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize realtime hub")
+		return 1
+	}
+
+	// Configure outbound webhooks; the local test receiver is only mounted when enabled
+	webhookSender := webhooks.NewSender(webhooks.Config{
+		Timeout:              settings.Webhooks.Timeout,
+		AllowPrivateNetworks: settings.Webhooks.AllowPrivateNetworks,
+	})
+	var webhookReceiver http.Handler
+	if settings.Webhooks.TestReceiver {
+		webhookReceiver = webhooks.NewReceiver()
+		log.Info().Msgf("Webhook test receiver available at %s%s<inbox>", settings.PublicURL, webhooks.ReceiverRoutePrefix)
+	}
+
+	// Domain events written to the outbox are relayed to the consumers of this bus; the
+	// Redis stream consumer shares them with other systems when a stream is configured
+	bus := eventbus.NewBus()
+	if settings.Outbox.RedisStream != "" {
+		streamClient, err := cache.NewRedisClient(context.Background(), settings.Redis.Addr, settings.Redis.Password)
+		if err != nil {
+			log.Warn().Err(err).Msg("Redis is unreachable, outbox events will be appended to the stream once it is back")
+		}
+		bus.Subscribe(service.ConsumerRedisStream, eventbus.NewRedisStreamHandler(streamClient, settings.Outbox.RedisStream, settings.Outbox.RedisStreamMaxLen))
+	}
+
+	// Queue background jobs in Postgres, or in Redis when JOBS_DRIVER=redis
+	var jobStore jobs.Store = repository.NewJobsRepositoryImpl(db)
+	if settings.Jobs.Driver == "redis" {
+		jobsRedis, err := cache.NewRedisClient(context.Background(), settings.Redis.Addr, settings.Redis.Password)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to connect to the Redis job queue")
+			return 1
+		}
+		jobStore = jobs.NewRedisStore(jobsRedis, "jobs:")
+	}
+	jobClient := jobs.NewClient(jobStore)
+
+	// Check the dependencies for readiness. Redis is only required when realtime events,
+	// jobs or the outbox stream depend on it; the cache does without it
+	healthChecker := health.NewChecker(2 * time.Second)
+	healthChecker.Add("database", true, func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	if settings.Redis.Addr != "" {
+		// Reachability is reported by the check rather than at startup
+		healthRedis, _ := cache.NewRedisClient(context.Background(), settings.Redis.Addr, settings.Redis.Password)
+		redisRequired := settings.Realtime.Driver == "redis" || settings.Jobs.Driver == "redis" || settings.Outbox.RedisStream != ""
+		healthChecker.Add("redis", redisRequired, func(ctx context.Context) error {
+			return healthRedis.Ping(ctx).Err()
+		})
+	}
+	healthChecker.Add("object_store", true, objectStore.Ping)
+
+	// Create validator instance
+	validate := validator.New()
+
+	// Initialize services
+	dishService, authService, resturantService, menuService, dishImagesService, orderService, cartService, paymentService, reservationService, floorPlanService, promotionService, loyaltyService, taxService, webhookService := config.InitializeServices(db, validate, objectStore, imageProcessor, appCache, cursorSigner, paymentProvider, settings.Payments.Currency, settings.Payments.CaptureMethod, realtimeHub, bus, webhookSender, settings.Webhooks.Policy(), jobClient, jwtSecret)
+
+	// Run background jobs, including scheduled menu changes
+	worker := jobs.NewWorker(jobStore, jobs.Config{
+		Concurrency:  settings.Jobs.Concurrency,
+		PollInterval: settings.Jobs.PollInterval,
+	})
+	if err := service.RegisterJobs(worker, dishImagesService, menuService, settings.Jobs.MenuSchedulerInterval); err != nil {
+		log.Error().Err(err).Msg("Failed to register background jobs")
+		return 1
+	}
+
+	// Relay outbox events to the consumers of the bus in the background
+	outboxRelay := service.NewOutboxRelay(repository.NewOutboxRepositoryImpl(db), bus, settings.Outbox.RelayInterval, settings.Outbox.Retention)
+
+	// Send webhook deliveries and their retries in the background
+	webhookDispatcher := service.NewWebhookDispatcher(webhookService, settings.Webhooks.DispatchInterval)
+
+	// runBackground runs the job worker and webhook dispatcher until the context is
+	// cancelled, and returns once the jobs already running are done
+	runBackground := func(ctx context.Context) {
+		go webhookDispatcher.Run(ctx)
+		worker.Run(ctx)
+	}
+
+	if workerMode {
+		// The worker serves only its metrics and health
+		mux := http.NewServeMux()
+		mux.Handle(health.LivenessPath, healthChecker.LivenessHandler())
+		mux.Handle(health.ReadinessPath, healthChecker.ReadinessHandler())
+		mux.Handle("/", promhttp.Handler())
+		go func() {
+			metricsServer := &http.Server{Addr: settings.Jobs.WorkerMetricsAddr, Handler: mux, ReadHeaderTimeout: settings.Server.ReadHeaderTimeout}
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error().Err(err).Msg("Worker metrics server failed")
+			}
+		}()
+		healthChecker.SetServing()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		runBackground(ctx)
+		return 0
+	}
+
+	// Background work stops once the server has drained, after the jobs running finish;
+	// the outbox relay stays with the server, whose realtime subscribers it feeds
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	backgroundDone := make(chan struct{})
+	go outboxRelay.Run(backgroundCtx)
+
+	// The server runs background work too, unless separate workers are deployed
+	go func() {
+		defer close(backgroundDone)
+		if settings.Jobs.ServerRunsWorker {
+			runBackground(backgroundCtx)
+		}
+	}()
+	stopBackgroundWork := func() {
+		stopBackground()
+		<-backgroundDone
+	}
+
+	// Event streams end when the server shuts down, instead of holding the drain up
+	streamsCtx, closeStreams := context.WithCancel(context.Background())
+
+	// Initialize controllers
+	dishController := controller.NewDishesController(dishService)
+	authController := controller.NewAuthController(authService)
+	restaurantController := controller.NewRestaurantsController(resturantService)
+	menuController := controller.NewMenuController(menuService)
+	dishImagesController := controller.NewDishImagesController(dishImagesService)
+	filesController := controller.NewFilesController(objectStore)
+	ordersController := controller.NewOrdersController(orderService)
+	cartController := controller.NewCartController(cartService)
+	paymentsController := controller.NewPaymentsController(paymentService, paymentProvider)
+	eventsController := controller.NewEventsController(realtimeHub, streamsCtx)
+	reservationsController := controller.NewReservationsController(reservationService)
+	floorPlanController := controller.NewFloorPlanController(floorPlanService)
+	promotionsController := controller.NewPromotionsController(promotionService)
+	loyaltyController := controller.NewLoyaltyController(loyaltyService)
+	taxController := controller.NewTaxController(taxService)
+	webhookController := controller.NewWebhookController(webhookService)
+	settingsController := controller.NewSettingsController(settings)
+
+	// Setup router
+	routes := router.NewRouter(dishController, authController, restaurantController, menuController, dishImagesController, filesController, ordersController, cartController, paymentsController, eventsController, reservationsController, floorPlanController, promotionsController, loyaltyController, taxController, webhookController, settingsController, webhookReceiver, healthChecker, repository.NewUserRepository(db), jwtSecret, settings.RequireIfMatch)
+
+	// Start server
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", settings.Port),
+		Handler:           routes,
+		ReadHeaderTimeout: settings.Server.ReadHeaderTimeout,
+		ReadTimeout:       settings.Server.ReadTimeout,
+		WriteTimeout:      settings.Server.WriteTimeout,
+		IdleTimeout:       settings.Server.IdleTimeout,
+	}
+	server.RegisterOnShutdown(closeStreams)
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to listen")
+		stopBackgroundWork()
+		return 1
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+	healthChecker.SetServing()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-serverErr:
+		log.Error().Err(err).Msg("Server failed")
+		stopBackgroundWork()
+		return 1
+	case <-ctx.Done():
+	}
+	stop() // A second signal stops the process right away
+
+	// Fail readiness first, so that load balancers stop routing here before draining
+	log.Info().Dur("drain_delay", settings.Server.DrainDelay).Msg("Shutting down, reporting as unready")
+	healthChecker.SetDraining()
+	time.Sleep(settings.Server.DrainDelay)
+
+	log.Info().Dur("timeout", settings.Server.ShutdownTimeout).Msg("Draining connections")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), settings.Server.ShutdownTimeout)
+	defer cancel()
+	code := 0
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Requests were still in flight when the shutdown timed out")
+		server.Close()
+		code = 1
+	}
+	stopBackgroundWork()
+	log.Info().Msg("Server stopped")
+	return code
+}
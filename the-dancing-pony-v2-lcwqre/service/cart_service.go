@@ -0,0 +1,47 @@
+package service
+
+import (
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/google/uuid"
+)
+
+// CartOwner identifies whose cart an operation applies to: a signed-in customer,
+// or a guest holding the token of their cart.
+type CartOwner struct {
+	CustomerID uuid.UUID
+	GuestToken string
+}
+
+// IsGuest reports whether the owner is a guest.
+func (owner CartOwner) IsGuest() bool {
+	return owner.CustomerID == uuid.Nil
+}
+
+// CartService defines the operations on customer and guest carts.
+type CartService interface {
+	// Get retrieves a cart priced at the current dish prices.
+	Get(owner CartOwner, requestId string, restaurantId string) (response.CartResponse, error)
+
+	// AddItem adds a published dish to a cart, creating the cart when needed.
+	AddItem(itemRequest request.CartItemRequest, owner CartOwner, requestId string, restaurantId string) (response.CartResponse, error)
+
+	// UpdateItem changes the quantity or notes of a cart item.
+	UpdateItem(itemRequest request.UpdateCartItemRequest, itemId uuid.UUID, owner CartOwner, requestId string, restaurantId string) (response.CartResponse, error)
+
+	// RemoveItem removes an item from a cart.
+	RemoveItem(itemId uuid.UUID, owner CartOwner, requestId string, restaurantId string) (response.CartResponse, error)
+
+	// ApplyCoupon enters a coupon code on a cart, creating the cart when needed.
+	ApplyCoupon(couponRequest request.CouponRequest, owner CartOwner, requestId string, restaurantId string) (response.CartResponse, error)
+
+	// RemoveCoupon removes the coupon code entered on a cart.
+	RemoveCoupon(owner CartOwner, requestId string, restaurantId string) (response.CartResponse, error)
+
+	// Merge moves a guest cart into the customer's cart once they sign in.
+	Merge(guestToken string, userId uuid.UUID, requestId string, restaurantId string) (response.CartResponse, error)
+
+	// Checkout turns the customer's cart into an order at the prices last shown.
+	Checkout(checkoutRequest request.CheckoutRequest, userId uuid.UUID, requestId string, restaurantId string) (response.OrderResponse, error)
+}
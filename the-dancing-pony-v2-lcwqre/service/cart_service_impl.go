@@ -0,0 +1,473 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/realtime"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxCartItems caps the number of different dishes in a cart, matching the order limit.
+	maxCartItems = 50
+
+	// maxCartItemQuantity caps the quantity of a single dish in a cart.
+	maxCartItemQuantity = 100
+)
+
+var (
+	// ErrCartFull is returned when adding a dish to a cart that already holds maxCartItems dishes.
+	ErrCartFull = fmt.Errorf("cart cannot hold more than %d dishes", maxCartItems)
+
+	// ErrCartHasUnavailableItems is returned when checking out a cart with dishes that are no longer on the menu.
+	ErrCartHasUnavailableItems = errors.New("cart contains dishes that are no longer available")
+
+	// ErrCartPriceChanged is returned when checking out a cart whose prices changed since it was last shown.
+	ErrCartPriceChanged = errors.New("prices in the cart have changed, review the cart before checking out")
+)
+
+// CartServiceImpl provides the implementation for cart-related operations.
+type CartServiceImpl struct {
+	CartsRepository      repository.CartsRepository
+	DishesRepository     repository.DishesRepository
+	PromotionsRepository repository.PromotionsRepository
+	LoyaltyRepository    repository.LoyaltyRepository
+	TaxRepository        repository.TaxRepository
+	Events               realtime.Publisher
+}
+
+// NewCartServiceImpl creates a new instance of CartServiceImpl.
+func NewCartServiceImpl(cartsRepository repository.CartsRepository, dishesRepository repository.DishesRepository, promotionsRepository repository.PromotionsRepository, loyaltyRepository repository.LoyaltyRepository, taxRepository repository.TaxRepository, events realtime.Publisher) CartService {
+	return &CartServiceImpl{
+		CartsRepository:      cartsRepository,
+		DishesRepository:     dishesRepository,
+		PromotionsRepository: promotionsRepository,
+		LoyaltyRepository:    loyaltyRepository,
+		TaxRepository:        taxRepository,
+		Events:               events,
+	}
+}
+
+// Get retrieves a cart priced at the current dish prices. Owners without a cart get an empty one.
+func (s *CartServiceImpl) Get(owner CartOwner, requestID string, restaurantId string) (response.CartResponse, error) {
+	cart, err := s.findCart(owner, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.priceCart(cart, restaurantId)
+}
+
+// AddItem adds a published dish to a cart, creating the cart when needed. Guests without
+// a valid token get a new cart and token.
+func (s *CartServiceImpl) AddItem(itemRequest request.CartItemRequest, owner CartOwner, requestID string, restaurantId string) (response.CartResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("dish_id", itemRequest.DishID.String()).
+		Bool("guest", owner.IsGuest()).
+		Msg("Adding dish to cart")
+
+	dishes, err := s.DishesRepository.FindPublishedByIds([]uuid.UUID{itemRequest.DishID}, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if len(dishes) == 0 {
+		return response.CartResponse{}, fmt.Errorf("%w: %s", ErrDishUnavailable, itemRequest.DishID)
+	}
+	dish := dishes[0]
+
+	cart, err := s.findCart(owner, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if cart.ID == uuid.Nil {
+		if cart, err = s.createCart(owner, restaurantId); err != nil {
+			return response.CartResponse{}, err
+		}
+	}
+	if len(cart.Items) >= maxCartItems && !cartHasDish(cart, dish.ID) {
+		return response.CartResponse{}, ErrCartFull
+	}
+
+	err = s.CartsRepository.AddItem(model.CartItem{
+		CartID:    cart.ID,
+		DishID:    dish.ID,
+		Name:      dish.Name,
+		UnitPrice: dish.Price,
+		Quantity:  itemRequest.Quantity,
+		Notes:     itemRequest.Notes,
+	}, maxCartItemQuantity)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.reload(cart, restaurantId)
+}
+
+// UpdateItem changes the quantity or notes of a cart item.
+func (s *CartServiceImpl) UpdateItem(itemRequest request.UpdateCartItemRequest, itemId uuid.UUID, owner CartOwner, requestID string, restaurantId string) (response.CartResponse, error) {
+	cart, err := s.findCart(owner, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+
+	fields := map[string]interface{}{"Quantity": itemRequest.Quantity}
+	if itemRequest.Notes != nil {
+		fields["Notes"] = *itemRequest.Notes
+	}
+	if err := s.CartsRepository.UpdateItem(cart.ID, itemId, fields); err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.reload(cart, restaurantId)
+}
+
+// RemoveItem removes an item from a cart.
+func (s *CartServiceImpl) RemoveItem(itemId uuid.UUID, owner CartOwner, requestID string, restaurantId string) (response.CartResponse, error) {
+	cart, err := s.findCart(owner, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if err := s.CartsRepository.RemoveItem(cart.ID, itemId); err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.reload(cart, restaurantId)
+}
+
+// ApplyCoupon enters a coupon code on a cart, creating the cart when needed. Codes that do
+// not exist, are not running or that the owner can no longer use are refused; conditions
+// on the items, such as a minimum spend, are reported on the cart until they are met.
+func (s *CartServiceImpl) ApplyCoupon(couponRequest request.CouponRequest, owner CartOwner, requestID string, restaurantId string) (response.CartResponse, error) {
+	code := normalizeCouponCode(couponRequest.Code)
+	log.Info().
+		Str("request_id", requestID).
+		Str("coupon", code).
+		Bool("guest", owner.IsGuest()).
+		Msg("Applying coupon to cart")
+
+	promotion, err := s.PromotionsRepository.FindByCode(code, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if promotion.ID == uuid.Nil {
+		return response.CartResponse{}, fmt.Errorf("%w: %s", ErrUnknownCoupon, code)
+	}
+	customer := promotionCustomer{Coupon: code}
+	if err := loadPromotionCustomer(s.PromotionsRepository, []model.Promotion{promotion}, &customer, owner.CustomerID, restaurantId); err != nil {
+		return response.CartResponse{}, err
+	}
+	if err := checkPromotionEligibility(promotion, customer, time.Now()); err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("coupon", code).
+			Err(err).
+			Msg("Coupon refused")
+		return response.CartResponse{}, err
+	}
+
+	cart, err := s.findCart(owner, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if cart.ID == uuid.Nil {
+		if cart, err = s.createCart(owner, restaurantId); err != nil {
+			return response.CartResponse{}, err
+		}
+	}
+	if err := s.CartsRepository.SetCoupon(cart.ID, &code); err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.reload(cart, restaurantId)
+}
+
+// RemoveCoupon removes the coupon code entered on a cart.
+func (s *CartServiceImpl) RemoveCoupon(owner CartOwner, requestID string, restaurantId string) (response.CartResponse, error) {
+	cart, err := s.findCart(owner, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if cart.ID == uuid.Nil || cart.CouponCode == nil {
+		return s.priceCart(cart, restaurantId)
+	}
+	if err := s.CartsRepository.SetCoupon(cart.ID, nil); err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.reload(cart, restaurantId)
+}
+
+// Merge moves a guest cart into the customer's cart once they sign in. An unknown
+// token leaves the customer's cart as it is.
+func (s *CartServiceImpl) Merge(guestToken string, userId uuid.UUID, requestID string, restaurantId string) (response.CartResponse, error) {
+	customer := CartOwner{CustomerID: userId}
+	guestCart, err := s.CartsRepository.FindByGuestToken(guestToken, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if guestCart.ID == uuid.Nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Msg("No guest cart to merge")
+		return s.Get(customer, requestID, restaurantId)
+	}
+
+	cart, err := s.createCart(customer, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if err := s.CartsRepository.Merge(guestCart.ID, cart.ID, maxCartItemQuantity); err != nil {
+		return response.CartResponse{}, err
+	}
+
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Int("items", len(guestCart.Items)).
+		Msg("Guest cart merged")
+	return s.reload(cart, restaurantId)
+}
+
+// Checkout turns the customer's cart into an order in one transaction. It refuses carts
+// with unavailable dishes, with prices that changed since the cart was last shown, or
+// with a coupon that does not apply; promotions are priced again at checkout time. The
+// loyalty reward chosen at checkout is redeemed after the promotions, and tax is worked
+// out last.
+func (s *CartServiceImpl) Checkout(checkoutRequest request.CheckoutRequest, userId uuid.UUID, requestID string, restaurantId string) (response.OrderResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Msg("Checking out cart")
+
+	cart, err := s.CartsRepository.FindByCustomer(userId, restaurantId)
+	if err != nil {
+		return response.OrderResponse{}, err
+	}
+	if cart.ID == uuid.Nil {
+		return response.OrderResponse{}, repository.ErrCartEmpty
+	}
+
+	order, err := s.CartsRepository.Checkout(cart.ID, restaurantId, func(cart model.Cart, items []model.CartItem, dishes []model.Dish) (model.Order, error) {
+		prices := make(map[uuid.UUID]float64, len(dishes))
+		for _, dish := range dishes {
+			prices[dish.ID] = dish.Price
+		}
+		lines := make([]request.OrderItemRequest, 0, len(items))
+		for _, item := range items {
+			price, ok := prices[item.DishID]
+			if !ok {
+				return model.Order{}, ErrCartHasUnavailableItems
+			}
+			if price != item.UnitPrice {
+				return model.Order{}, ErrCartPriceChanged
+			}
+			lines = append(lines, request.OrderItemRequest{DishID: item.DishID, Quantity: item.Quantity, Notes: item.Notes})
+		}
+		order, err := newOrder(checkoutRequest.Fulfilment, checkoutRequest.Notes, lines, dishes, userId, restaurantId)
+		if err != nil {
+			return model.Order{}, err
+		}
+		var coupon string
+		if cart.CouponCode != nil {
+			coupon = *cart.CouponCode
+		}
+		if err := applyOrderPromotions(s.PromotionsRepository, &order, dishes, coupon, restaurantId); err != nil {
+			return model.Order{}, err
+		}
+		if err := applyOrderReward(s.LoyaltyRepository, &order, checkoutRequest.RewardID, restaurantId); err != nil {
+			return model.Order{}, err
+		}
+		if err := applyOrderTax(s.TaxRepository, &order, dishes, restaurantId); err != nil {
+			return model.Order{}, err
+		}
+		return order, nil
+	})
+	if err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Checkout failed")
+		return response.OrderResponse{}, err
+	}
+
+	log.Info().
+		Str("request_id", requestID).
+		Str("order_id", order.ID.String()).
+		Float64("total", order.Total).
+		Msg("Cart checked out")
+	orderResponse := toOrderResponse(order, model.RoleCustomer)
+	publishOrderEvent(s.Events, realtime.EventOrderPlaced, orderResponse, restaurantId)
+	return orderResponse, nil
+}
+
+// findCart retrieves the owner's cart, or an empty cart when they have none.
+func (s *CartServiceImpl) findCart(owner CartOwner, restaurantId string) (model.Cart, error) {
+	if !owner.IsGuest() {
+		return s.CartsRepository.FindByCustomer(owner.CustomerID, restaurantId)
+	}
+	if owner.GuestToken == "" {
+		return model.Cart{}, nil
+	}
+	return s.CartsRepository.FindByGuestToken(owner.GuestToken, restaurantId)
+}
+
+// createCart retrieves or creates the owner's cart. Guests always get a fresh token so
+// that tokens are never chosen by the client.
+func (s *CartServiceImpl) createCart(owner CartOwner, restaurantId string) (model.Cart, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return model.Cart{}, fmt.Errorf("invalid restaurant ID %q: %w", restaurantId, err)
+	}
+	cart := model.Cart{RestaurantID: restaurantUUID}
+	if owner.IsGuest() {
+		token, err := newGuestToken()
+		if err != nil {
+			return model.Cart{}, err
+		}
+		cart.GuestToken = &token
+	} else {
+		cart.CustomerID = &owner.CustomerID
+	}
+	return s.CartsRepository.FindOrCreate(cart)
+}
+
+// reload retrieves a cart again after a change and prices it.
+func (s *CartServiceImpl) reload(cart model.Cart, restaurantId string) (response.CartResponse, error) {
+	var err error
+	if cart.CustomerID != nil {
+		cart, err = s.CartsRepository.FindByCustomer(*cart.CustomerID, restaurantId)
+	} else if cart.GuestToken != nil {
+		cart, err = s.CartsRepository.FindByGuestToken(*cart.GuestToken, restaurantId)
+	}
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	return s.priceCart(cart, restaurantId)
+}
+
+// priceCart prices a cart at the current dish prices and records them as shown, so
+// that checkout only fails on changes the customer has not seen.
+func (s *CartServiceImpl) priceCart(cart model.Cart, restaurantId string) (response.CartResponse, error) {
+	cartResponse := response.CartResponse{Items: []response.CartItemResponse{}, Discounts: []response.DiscountResponse{}, Taxes: []response.TaxLineResponse{}}
+	if cart.GuestToken != nil {
+		cartResponse.GuestToken = *cart.GuestToken
+	}
+	if cart.CouponCode != nil {
+		cartResponse.CouponCode = *cart.CouponCode
+	}
+	if len(cart.Items) == 0 {
+		return cartResponse, nil
+	}
+
+	dishIds := make([]uuid.UUID, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		dishIds = append(dishIds, item.DishID)
+	}
+	dishes, err := s.DishesRepository.FindPublishedByIds(dishIds, restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	menu := make(map[uuid.UUID]model.Dish, len(dishes))
+	for _, dish := range dishes {
+		menu[dish.ID] = dish
+	}
+
+	changed := make(map[uuid.UUID]float64)
+	var lines []promotionLine
+	var taxable []taxableLine
+	cartResponse.CanCheckout = true
+	for _, item := range cart.Items {
+		itemResponse := response.CartItemResponse{
+			ID:        item.ID,
+			DishID:    item.DishID,
+			Name:      item.Name,
+			UnitPrice: item.UnitPrice,
+			Quantity:  item.Quantity,
+			Notes:     item.Notes,
+		}
+		if dish, ok := menu[item.DishID]; ok {
+			itemResponse.Available = true
+			itemResponse.Name = dish.Name
+			itemResponse.UnitPrice = dish.Price
+			itemResponse.Subtotal = roundMoney(dish.Price * float64(item.Quantity))
+			if dish.Price != item.UnitPrice {
+				previous := item.UnitPrice
+				itemResponse.PreviousPrice = &previous
+				cartResponse.PriceChanged = true
+				changed[item.ID] = dish.Price
+			}
+			lines = append(lines, promotionLine{
+				DishID:    dish.ID,
+				Category:  dish.Category,
+				Tags:      dish.Tags,
+				UnitPrice: dish.Price,
+				Quantity:  item.Quantity,
+			})
+			taxable = append(taxable, taxableLine{Category: dish.Category, Amount: toMinorUnits(dish.Price * float64(item.Quantity))})
+		} else {
+			cartResponse.CanCheckout = false
+		}
+		cartResponse.Items = append(cartResponse.Items, itemResponse)
+	}
+
+	var customerId uuid.UUID
+	if cart.CustomerID != nil {
+		customerId = *cart.CustomerID
+	}
+	pricing, err := priceWithPromotions(s.PromotionsRepository, lines, customerId, cartResponse.CouponCode, restaurantId, time.Now())
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	cartResponse.Subtotal = pricing.Subtotal
+	cartResponse.Discount = pricing.Discount
+	cartResponse.Total = pricing.Total
+	cartResponse.Discounts = toDiscountResponses(toRedemptions(pricing.Applied))
+	if pricing.CouponErr != nil {
+		cartResponse.CouponError = pricing.CouponErr.Error()
+	}
+
+	settings, err := s.TaxRepository.FindSettings(restaurantId)
+	if err != nil {
+		return response.CartResponse{}, err
+	}
+	if settings.ID != uuid.Nil {
+		computation := computeTax(settings, taxable, toMinorUnits(pricing.Discount))
+		cartResponse.PricesIncludeTax = settings.PricesIncludeTax
+		cartResponse.Tax = fromMinorUnits(computation.Tax)
+		cartResponse.Taxes = toTaxLineResponses(computation.Lines)
+		cartResponse.Total = fromMinorUnits(computation.Gross)
+	}
+
+	if len(changed) > 0 {
+		if err := s.CartsRepository.UpdatePrices(changed); err != nil {
+			return response.CartResponse{}, err
+		}
+	}
+	return cartResponse, nil
+}
+
+// cartHasDish reports whether a cart already holds the dish.
+func cartHasDish(cart model.Cart, dishId uuid.UUID) bool {
+	for _, item := range cart.Items {
+		if item.DishID == dishId {
+			return true
+		}
+	}
+	return false
+}
+
+// newGuestToken returns a random token identifying a guest cart.
+func newGuestToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating guest cart token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
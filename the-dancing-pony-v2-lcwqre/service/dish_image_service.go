@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"mime/multipart"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/google/uuid"
+)
+
+// DishImagesService defines the operations on dish image galleries.
+type DishImagesService interface {
+	// List retrieves the gallery of a dish in display order.
+	List(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestId string) ([]response.DishImageResponse, error)
+
+	// Upload validates an image sent through the API and appends it to the gallery.
+	Upload(ctx context.Context, file multipart.FileHeader, altText string, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishImageResponse, error)
+
+	// CreateUpload returns a short-lived presigned request for uploading an image directly to storage.
+	CreateUpload(ctx context.Context, uploadRequest request.DishImageUploadRequest, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishImageUploadResponse, error)
+
+	// ConfirmUpload queues a finished direct upload to be processed and appended to the gallery.
+	ConfirmUpload(ctx context.Context, confirmRequest request.ConfirmDishImageUploadRequest, uploadId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishImageUploadStatusResponse, error)
+
+	// FindUpload reports the status of a direct upload.
+	FindUpload(uploadId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishImageUploadStatusResponse, error)
+
+	// ProcessUpload validates a confirmed upload and appends it to the gallery, in the
+	// background. Errors worth retrying are returned, unless lastAttempt is set, in which
+	// case the upload is marked failed like on any other error.
+	ProcessUpload(ctx context.Context, uploadId uuid.UUID, dishId uuid.UUID, requestId string, restaurantId string, lastAttempt bool) error
+
+	// PurgeExpiredUploads deletes the uploads that expired before the given time without
+	// being confirmed, along with any file sent for them, and returns how many it deleted.
+	PurgeExpiredUploads(ctx context.Context, before time.Time) (int, error)
+
+	// Update changes the alt text of a gallery image.
+	Update(updateRequest request.UpdateDishImageRequest, imageId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishImageResponse, error)
+
+	// SetPrimary makes an image the primary image of its dish.
+	SetPrimary(imageId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishImageResponse, error)
+
+	// Reorder sets the display order of a dish's gallery.
+	Reorder(reorderRequest request.ReorderDishImagesRequest, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) ([]response.DishImageResponse, error)
+
+	// Delete removes an image from a dish's gallery.
+	Delete(imageId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) error
+}
@@ -0,0 +1,499 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+
+	cache "the-dancing-pony-v2-lcwqre/caching"
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/jobs"
+	"the-dancing-pony-v2-lcwqre/media"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/storage"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUploadExpired is returned when a presigned upload is confirmed after its link expired.
+	ErrUploadExpired = errors.New("upload link has expired")
+
+	// ErrUploadNotReceived is returned when an upload is confirmed before the file reached storage.
+	ErrUploadNotReceived = errors.New("uploaded file has not been received")
+)
+
+const (
+	// dishImageUploadTTL is how long a presigned upload link stays valid.
+	dishImageUploadTTL = 15 * time.Minute
+
+	// dishUploadPrefix is the object store prefix for raw direct uploads awaiting confirmation.
+	// Unconfirmed objects under it are removed by JobPurgeDishImageUploads.
+	dishUploadPrefix = "uploads"
+
+	// purgeUploadsBatchSize is how many expired uploads are purged per query.
+	purgeUploadsBatchSize = 100
+)
+
+// DishImagesServiceImpl provides the implementation for dish gallery operations.
+type DishImagesServiceImpl struct {
+	DishesRepository     repository.DishesRepository
+	DishImagesRepository repository.DishImagesRepository
+	ObjectStore          storage.ObjectStore
+	ImageProcessor       *media.ImageProcessor
+	Cache                cache.Cache
+	Jobs                 *jobs.Client
+}
+
+// NewDishImagesServiceImpl creates a new instance of DishImagesServiceImpl.
+func NewDishImagesServiceImpl(dishesRepository repository.DishesRepository, dishImagesRepository repository.DishImagesRepository, objectStore storage.ObjectStore, imageProcessor *media.ImageProcessor, dishCache cache.Cache, jobClient *jobs.Client) DishImagesService {
+	return &DishImagesServiceImpl{
+		DishesRepository:     dishesRepository,
+		DishImagesRepository: dishImagesRepository,
+		ObjectStore:          objectStore,
+		ImageProcessor:       imageProcessor,
+		Cache:                dishCache,
+		Jobs:                 jobClient,
+	}
+}
+
+// List retrieves the gallery of a dish in display order.
+func (s *DishImagesServiceImpl) List(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestID string) ([]response.DishImageResponse, error) {
+	if _, err := s.DishesRepository.FindById(dishId, restaurantId); err != nil {
+		return nil, err
+	}
+	images, err := s.DishImagesRepository.FindByDish(dishId, restaurantId)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error listing dish images")
+		return nil, err
+	}
+	return toDishImageResponses(images, s.ObjectStore), nil
+}
+
+// Upload validates an image sent through the API and appends it to the gallery.
+func (s *DishImagesServiceImpl) Upload(ctx context.Context, fileHeader multipart.FileHeader, altText string, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishImageResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("dish_id", dishId.String()).
+		Msg("Uploading dish image")
+
+	dish, err := s.DishesRepository.FindById(dishId, restaurantId)
+	if err != nil {
+		return response.DishImageResponse{}, err
+	}
+	if fileHeader.Size > s.ImageProcessor.MaxBytes {
+		return response.DishImageResponse{}, media.ErrImageTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.DishImageResponse{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	key, err := storeDishImage(ctx, s.ObjectStore, s.ImageProcessor, file)
+	if err != nil {
+		return response.DishImageResponse{}, err
+	}
+	return s.addImage(dish.ID, dish.RestaurantID, key, altText, nil, userId, requestID)
+}
+
+// CreateUpload returns a short-lived presigned request for uploading an image directly to storage.
+func (s *DishImagesServiceImpl) CreateUpload(ctx context.Context, uploadRequest request.DishImageUploadRequest, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishImageUploadResponse, error) {
+	if uploadRequest.Size > s.ImageProcessor.MaxBytes {
+		return response.DishImageUploadResponse{}, media.ErrImageTooLarge
+	}
+	dish, err := s.DishesRepository.FindById(dishId, restaurantId)
+	if err != nil {
+		return response.DishImageUploadResponse{}, err
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", dishUploadPrefix, dish.RestaurantID, uuid.New())
+	presigned, err := s.ObjectStore.PresignUpload(ctx, key, uploadRequest.ContentType, s.ImageProcessor.MaxBytes, dishImageUploadTTL)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error presigning dish image upload")
+		return response.DishImageUploadResponse{}, err
+	}
+
+	upload, err := s.DishImagesRepository.CreateUpload(model.DishImageUpload{
+		DishID:       dish.ID,
+		RestaurantID: dish.RestaurantID,
+		Key:          key,
+		ContentType:  uploadRequest.ContentType,
+		Status:       model.DishImageUploadStatusPending,
+		ExpiresAt:    presigned.ExpiresAt,
+		CreatedById:  userId,
+	})
+	if err != nil {
+		return response.DishImageUploadResponse{}, err
+	}
+
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("upload_id", upload.ID.String()).
+		Msg("Dish image upload link created")
+	return response.DishImageUploadResponse{
+		UploadID:  upload.ID,
+		URL:       presigned.URL,
+		Method:    presigned.Method,
+		Headers:   presigned.Headers,
+		ExpiresAt: presigned.ExpiresAt,
+	}, nil
+}
+
+// ConfirmUpload queues a finished direct upload to be processed and appended to the
+// gallery. Renditions are made by a background job; FindUpload reports its progress.
+func (s *DishImagesServiceImpl) ConfirmUpload(ctx context.Context, confirmRequest request.ConfirmDishImageUploadRequest, uploadId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishImageUploadStatusResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("upload_id", uploadId.String()).
+		Msg("Confirming dish image upload")
+
+	upload, err := s.DishImagesRepository.FindUpload(uploadId, dishId, restaurantId)
+	if err != nil {
+		return response.DishImageUploadStatusResponse{}, err
+	}
+	if upload.Status != model.DishImageUploadStatusPending || upload.ConfirmedAt != nil {
+		return response.DishImageUploadStatusResponse{}, repository.ErrUploadAlreadyConfirmed
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return response.DishImageUploadStatusResponse{}, ErrUploadExpired
+	}
+	if _, err := s.ObjectStore.Stat(ctx, upload.Key); errors.Is(err, storage.ErrObjectNotFound) {
+		return response.DishImageUploadStatusResponse{}, ErrUploadNotReceived
+	} else if err != nil {
+		return response.DishImageUploadStatusResponse{}, err
+	}
+
+	if err := s.DishImagesRepository.StartUpload(upload.ID, dishId, restaurantId, confirmRequest.AltText); err != nil {
+		return response.DishImageUploadStatusResponse{}, err
+	}
+	_, err = s.Jobs.Enqueue(ctx, JobProcessDishImageUpload, processDishImageUploadJob{
+		UploadID:     upload.ID,
+		DishID:       dishId,
+		RestaurantID: restaurantId,
+		RequestID:    requestID,
+	}, jobs.Unique(JobProcessDishImageUpload+":"+upload.ID.String()))
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("upload_id", uploadId.String()).
+			Err(err).
+			Msg("Error queuing dish image upload")
+		if failErr := s.DishImagesRepository.FailUpload(upload.ID, "upload could not be queued for processing"); failErr != nil {
+			log.Error().
+				Str("request_id", requestID).
+				Str("upload_id", uploadId.String()).
+				Err(failErr).
+				Msg("Error failing dish image upload")
+		}
+		return response.DishImageUploadStatusResponse{}, err
+	}
+
+	return response.DishImageUploadStatusResponse{
+		UploadID: upload.ID,
+		Status:   model.DishImageUploadStatusProcessing,
+	}, nil
+}
+
+// FindUpload reports the status of a direct upload.
+func (s *DishImagesServiceImpl) FindUpload(uploadId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishImageUploadStatusResponse, error) {
+	upload, err := s.DishImagesRepository.FindUpload(uploadId, dishId, restaurantId)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("upload_id", uploadId.String()).
+			Err(err).
+			Msg("Error finding dish image upload")
+		return response.DishImageUploadStatusResponse{}, err
+	}
+	return response.DishImageUploadStatusResponse{
+		UploadID: upload.ID,
+		Status:   upload.Status,
+		ImageID:  upload.ImageID,
+		Error:    upload.Error,
+	}, nil
+}
+
+// ProcessUpload validates a confirmed upload and appends it to the gallery. The raw
+// upload is run through the same checks and renditions as API uploads, then removed.
+// An upload no longer processing was handled by an earlier attempt and is skipped.
+func (s *DishImagesServiceImpl) ProcessUpload(ctx context.Context, uploadId uuid.UUID, dishId uuid.UUID, requestID string, restaurantId string, lastAttempt bool) error {
+	upload, err := s.DishImagesRepository.FindUpload(uploadId, dishId, restaurantId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if upload.Status != model.DishImageUploadStatusProcessing {
+		return nil
+	}
+
+	key, err := s.processUpload(ctx, upload)
+	if err == nil {
+		_, err = s.addImage(upload.DishID, upload.RestaurantID, key, upload.AltText, &upload.ID, upload.CreatedById, requestID)
+	}
+	switch {
+	case err == nil, errors.Is(err, repository.ErrUploadAlreadyConfirmed):
+		log.Info().
+			Str("request_id", requestID).
+			Str("upload_id", uploadId.String()).
+			Msg("Dish image upload processed")
+	case isUploadRejected(err) || lastAttempt:
+		log.Warn().
+			Str("request_id", requestID).
+			Str("upload_id", uploadId.String()).
+			Err(err).
+			Msg("Dish image upload failed")
+		if err := s.DishImagesRepository.FailUpload(upload.ID, uploadFailureReason(err)); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	if err := s.ObjectStore.Delete(ctx, upload.Key); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("key", upload.Key).
+			Err(err).
+			Msg("Failed to remove raw upload")
+	}
+	return nil
+}
+
+// processUpload stores the renditions of the raw file of an upload and returns the key
+// of the full rendition.
+func (s *DishImagesServiceImpl) processUpload(ctx context.Context, upload model.DishImageUpload) (string, error) {
+	if _, err := s.DishesRepository.FindById(upload.DishID, upload.RestaurantID.String()); err != nil {
+		return "", err
+	}
+	body, _, err := s.ObjectStore.Get(ctx, upload.Key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return "", ErrUploadNotReceived
+	}
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	return storeDishImage(ctx, s.ObjectStore, s.ImageProcessor, body)
+}
+
+// isUploadRejected reports whether an upload failed in a way retrying cannot fix.
+func isUploadRejected(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) ||
+		errors.Is(err, ErrUploadNotReceived) ||
+		errors.Is(err, media.ErrUnsupportedImageType) ||
+		errors.Is(err, media.ErrImageTooLarge) ||
+		errors.Is(err, media.ErrImageDimensions)
+}
+
+// uploadFailureReason is the reason a failed upload reports to the client, which is
+// not told about internal errors.
+func uploadFailureReason(err error) string {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "dish no longer exists"
+	case isUploadRejected(err):
+		return err.Error()
+	default:
+		return "image could not be processed"
+	}
+}
+
+// PurgeExpiredUploads deletes the uploads that expired before the given time without
+// being confirmed, in batches, with any file sent for them.
+func (s *DishImagesServiceImpl) PurgeExpiredUploads(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	for {
+		uploads, err := s.DishImagesRepository.FindExpiredUploads(before, purgeUploadsBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		ids := make([]uuid.UUID, 0, len(uploads))
+		for _, upload := range uploads {
+			if err := s.ObjectStore.Delete(ctx, upload.Key); err != nil && !errors.Is(err, storage.ErrObjectNotFound) {
+				return purged, fmt.Errorf("failed to remove raw upload %s: %w", upload.Key, err)
+			}
+			ids = append(ids, upload.ID)
+		}
+		if err := s.DishImagesRepository.DeleteUploads(ids); err != nil {
+			return purged, err
+		}
+		purged += len(ids)
+		if len(uploads) < purgeUploadsBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Update changes the alt text of a gallery image.
+func (s *DishImagesServiceImpl) Update(updateRequest request.UpdateDishImageRequest, imageId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishImageResponse, error) {
+	image, err := s.DishImagesRepository.UpdateAltText(imageId, dishId, restaurantId, updateRequest.AltText)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("image_id", imageId.String()).
+			Err(err).
+			Msg("Error updating dish image")
+		return response.DishImageResponse{}, err
+	}
+	invalidateDishCache(s.Cache, restaurantId)
+	return toDishImageResponse(image, s.ObjectStore), nil
+}
+
+// SetPrimary makes an image the primary image of its dish.
+func (s *DishImagesServiceImpl) SetPrimary(imageId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishImageResponse, error) {
+	image, err := s.DishImagesRepository.SetPrimary(imageId, dishId, restaurantId)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("image_id", imageId.String()).
+			Err(err).
+			Msg("Error setting primary dish image")
+		return response.DishImageResponse{}, err
+	}
+	invalidateDishCache(s.Cache, restaurantId)
+	log.Info().
+		Str("request_id", requestID).
+		Str("image_id", imageId.String()).
+		Msg("Primary dish image updated")
+	return toDishImageResponse(image, s.ObjectStore), nil
+}
+
+// Reorder sets the display order of a dish's gallery.
+func (s *DishImagesServiceImpl) Reorder(reorderRequest request.ReorderDishImagesRequest, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) ([]response.DishImageResponse, error) {
+	images, err := s.DishImagesRepository.Reorder(dishId, restaurantId, reorderRequest.ImageIds)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error reordering dish images")
+		return nil, err
+	}
+	invalidateDishCache(s.Cache, restaurantId)
+	return toDishImageResponses(images, s.ObjectStore), nil
+}
+
+// Delete removes an image from a dish's gallery.
+func (s *DishImagesServiceImpl) Delete(imageId uuid.UUID, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) error {
+	if err := s.DishImagesRepository.Delete(imageId, dishId, restaurantId); err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("image_id", imageId.String()).
+			Err(err).
+			Msg("Error deleting dish image")
+		return err
+	}
+	invalidateDishCache(s.Cache, restaurantId)
+	return nil
+}
+
+// addImage appends a stored image to the gallery of a dish.
+func (s *DishImagesServiceImpl) addImage(dishId uuid.UUID, restaurantId uuid.UUID, key string, altText string, uploadId *uuid.UUID, userId uuid.UUID, requestID string) (response.DishImageResponse, error) {
+	image, err := s.DishImagesRepository.Add(model.DishImage{
+		DishID:       dishId,
+		RestaurantID: restaurantId,
+		Key:          key,
+		AltText:      altText,
+		CreatedById:  userId,
+	}, uploadId)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error adding dish image")
+		return response.DishImageResponse{}, err
+	}
+	invalidateDishCache(s.Cache, restaurantId.String())
+	return toDishImageResponse(image, s.ObjectStore), nil
+}
+
+// storeDishImage validates an image, stores its renditions and returns the key of the full rendition.
+// Keys are derived from the content hash, so storing the same file twice stores it once.
+func storeDishImage(ctx context.Context, objectStore storage.ObjectStore, processor *media.ImageProcessor, r io.Reader) (string, error) {
+	data, hash, err := processor.Hash(r)
+	if err != nil {
+		return "", err
+	}
+
+	// The full rendition is written last, so its presence means the upload is complete
+	for _, ext := range []string{".jpg", ".png"} {
+		key := media.ImageKey(dishImagePrefix, hash, media.RenditionFull, ext)
+		if _, err := objectStore.Stat(ctx, key); err == nil {
+			log.Info().Str("key", key).Msg("Image already stored, skipping upload")
+			return key, nil
+		} else if !errors.Is(err, storage.ErrObjectNotFound) {
+			return "", err
+		}
+	}
+
+	processed, err := processor.Process(data, hash)
+	if err != nil {
+		return "", err
+	}
+	for _, rendition := range processed.Renditions {
+		key := processed.Key(dishImagePrefix, rendition.Name)
+		if err := objectStore.Put(ctx, key, bytes.NewReader(rendition.Data), rendition.ContentType); err != nil {
+			return "", fmt.Errorf("failed to store %s rendition: %w", rendition.Name, err)
+		}
+	}
+	return processed.Key(dishImagePrefix, media.RenditionFull), nil
+}
+
+// toDishImageResponses converts a gallery to its response form.
+func toDishImageResponses(images []model.DishImage, objectStore storage.ObjectStore) []response.DishImageResponse {
+	if len(images) == 0 {
+		return nil
+	}
+	imageResponses := make([]response.DishImageResponse, 0, len(images))
+	for _, image := range images {
+		imageResponses = append(imageResponses, toDishImageResponse(image, objectStore))
+	}
+	return imageResponses
+}
+
+// toDishImageResponse converts a gallery image to its response form.
+func toDishImageResponse(image model.DishImage, objectStore storage.ObjectStore) response.DishImageResponse {
+	renditions := imageRenditions(objectStore, image.Key)
+	if renditions == nil {
+		// Images stored before renditions existed are served at their original size
+		url := imageURL(objectStore, image.Key)
+		renditions = &response.DishImages{Thumbnail: url, Card: url, Full: url}
+	}
+	return response.DishImageResponse{
+		ID:        image.ID,
+		AltText:   image.AltText,
+		Position:  image.Position,
+		IsPrimary: image.IsPrimary,
+		Images:    *renditions,
+	}
+}
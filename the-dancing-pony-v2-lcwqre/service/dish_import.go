@@ -0,0 +1,254 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxImportRows bounds the number of dishes accepted by a single bulk import.
+const MaxImportRows = 5000
+
+// ErrInvalidImport is returned when at least one row of a bulk import is invalid.
+// Nothing is written in that case.
+var ErrInvalidImport = errors.New("dish import contains invalid rows")
+
+// DishCSVHeader lists the columns of the dish CSV format, used for both import and export.
+// Tags are separated by "|" within their column.
+var DishCSVHeader = []string{"sku", "name", "description", "price", "imageUrl", "status", "category", "tags"}
+
+// Import validates a list of dishes and creates or updates them, upserting by SKU.
+// Either every row is written or none is; with dryRun nothing is written at all.
+func (t *DishesServiceImpl) Import(dishList request.ListDishRequestList, dryRun bool, userId uuid.UUID, requestID string, restaurantId uuid.UUID) (response.DishImportResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Int("num_rows", len(dishList.Dishes)).
+		Bool("dry_run", dryRun).
+		Msg("Starting dish import")
+
+	importResponse := response.DishImportResponse{
+		DryRun: dryRun,
+		Total:  len(dishList.Dishes),
+	}
+	if len(dishList.Dishes) > MaxImportRows {
+		importResponse.Errors = []response.DishRowError{{
+			Row:    MaxImportRows + 1,
+			Errors: []string{fmt.Sprintf("Import is limited to %d rows", MaxImportRows)},
+		}}
+		return importResponse, ErrInvalidImport
+	}
+
+	if rowErrors := dishList.Validate(); len(rowErrors) > 0 {
+		log.Warn().
+			Str("request_id", requestID).
+			Int("num_invalid_rows", len(rowErrors)).
+			Msg("Dish import rejected")
+		importResponse.Errors = rowErrors
+		return importResponse, ErrInvalidImport
+	}
+
+	var skus []string
+	for _, dish := range dishList.Dishes {
+		if dish.SKU != "" {
+			skus = append(skus, dish.SKU)
+		}
+	}
+	existing, err := t.DishesRepository.FindIdsBySKU(restaurantId.String(), skus)
+	if err != nil {
+		return response.DishImportResponse{}, err
+	}
+
+	now := time.Now()
+	dishes := make([]model.Dish, 0, len(dishList.Dishes))
+	for _, dishRequest := range dishList.Dishes {
+		dish := model.Dish{
+			Name:         dishRequest.Name,
+			Description:  dishRequest.Description,
+			Price:        dishRequest.Price,
+			Image:        dishRequest.ImageUrl,
+			Category:     normalizeLabel(dishRequest.Category),
+			Tags:         normalizeTags(dishRequest.Tags),
+			Status:       dishRequest.Status,
+			RestaurantID: restaurantId,
+		}
+		if dishRequest.SKU != "" {
+			sku := dishRequest.SKU
+			dish.SKU = &sku
+		}
+		if id, ok := existing[dishRequest.SKU]; ok && dishRequest.SKU != "" {
+			dish.ID = id
+			importResponse.Updated++
+		} else {
+			if dish.Status == "" {
+				dish.Status = model.DishStatusPublished
+			}
+			if dish.Status == model.DishStatusPublished {
+				dish.PublishedAt = &now
+			}
+			importResponse.Created++
+		}
+		dishes = append(dishes, dish)
+	}
+
+	if dryRun {
+		log.Info().
+			Str("request_id", requestID).
+			Int("created", importResponse.Created).
+			Int("updated", importResponse.Updated).
+			Msg("Dish import dry run completed")
+		return importResponse, nil
+	}
+
+	if err := t.DishesRepository.Import(dishes, userId); err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error importing dishes")
+		return response.DishImportResponse{}, err
+	}
+
+	invalidateDishCache(t.Cache, restaurantId.String())
+	log.Info().
+		Str("request_id", requestID).
+		Int("created", importResponse.Created).
+		Int("updated", importResponse.Updated).
+		Msg("Dish import completed")
+	return importResponse, nil
+}
+
+// Export streams every dish of a restaurant, drafts included, to fn.
+func (t *DishesServiceImpl) Export(restaurantId string, userId uuid.UUID, requestID string, fn func(response.DishExportRow) error) error {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Msg("Starting dish export")
+
+	count := 0
+	err := t.DishesRepository.StreamAll(restaurantId, func(dish model.Dish) error {
+		count++
+		return fn(toDishExportRow(dish))
+	})
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Int("num_exported", count).
+			Err(err).
+			Msg("Error exporting dishes")
+		return err
+	}
+
+	log.Info().
+		Str("request_id", requestID).
+		Int("num_exported", count).
+		Msg("Dish export completed")
+	return nil
+}
+
+// ParseDishCSV reads a dish CSV with a header row into an import list.
+// Columns are matched by name, case-insensitively, and may appear in any order.
+// Prices that cannot be parsed are left at zero and recorded in the list's ParseErrors,
+// which its Validate reports with the other errors of the rows.
+func ParseDishCSV(r io.Reader) (request.ListDishRequestList, error) {
+	var dishList request.ListDishRequestList
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return dishList, fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "description", "price", "imageurl"} {
+		if _, ok := columns[required]; !ok {
+			return dishList, fmt.Errorf("CSV header is missing the '%s' column", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return dishList, fmt.Errorf("error reading CSV row %d: %w", row, err)
+		}
+		if row > MaxImportRows {
+			return dishList, fmt.Errorf("import is limited to %d rows", MaxImportRows)
+		}
+
+		dish := request.CreateDishRequest{SKU: field(record, "sku"), Status: field(record, "status")}
+		dish.Name = field(record, "name")
+		dish.Description = field(record, "description")
+		dish.ImageUrl = field(record, "imageUrl")
+		dish.Category = field(record, "category")
+		if tags := field(record, "tags"); tags != "" {
+			dish.Tags = strings.Split(tags, "|")
+		}
+		if price := field(record, "price"); price != "" {
+			dish.Price, err = strconv.ParseFloat(price, 64)
+			if err != nil {
+				dishList.ParseErrors = append(dishList.ParseErrors, response.DishRowError{
+					Row:    row,
+					SKU:    dish.SKU,
+					Errors: []string{fmt.Sprintf("Field 'Price' has invalid number '%s'", price)},
+				})
+			}
+		}
+		dishList.Dishes = append(dishList.Dishes, dish)
+	}
+	return dishList, nil
+}
+
+// DishCSVRecord formats an export row as a CSV record in DishCSVHeader order.
+func DishCSVRecord(row response.DishExportRow) []string {
+	return []string{
+		row.SKU,
+		row.Name,
+		row.Description,
+		strconv.FormatFloat(row.Price, 'f', -1, 64),
+		row.ImageUrl,
+		row.Status,
+		row.Category,
+		strings.Join(row.Tags, "|"),
+	}
+}
+
+// Helper function to convert model.Dish to response.DishExportRow
+func toDishExportRow(dish model.Dish) response.DishExportRow {
+	row := response.DishExportRow{
+		Name:        dish.Name,
+		Description: dish.Description,
+		Price:       dish.Price,
+		ImageUrl:    dish.Image,
+		Status:      dish.Status,
+		Category:    dish.Category,
+		Tags:        dishTags(dish.Tags),
+	}
+	if dish.SKU != nil {
+		row.SKU = *dish.SKU
+	}
+	return row
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDishCSVValidate(t *testing.T) {
+	const header = "sku,name,description,price,imageUrl\n"
+	const image = "https://images.example.com/lembas.jpg"
+	tests := []struct {
+		name string
+		rows string
+		want map[int][]string // Messages expected per row, matched by substring
+	}{
+		{
+			name: "valid",
+			rows: "L1,Lembas,Waybread,4.5," + image + "\n" +
+				"L2,Lembas,Waybread,4.5,dishes/0a1b2c/full.jpg\n",
+		},
+		{
+			name: "parse and validation errors together",
+			rows: "L1,Lembas,Waybread,four," + image + "\n" +
+				"L2,,Waybread,4.5," + image + "\n" +
+				"L3,Lembas,,cheap," + image + "\n",
+			want: map[int][]string{
+				1: {"invalid number 'four'"},
+				2: {"'Name' failed validation on 'required'"},
+				3: {"invalid number 'cheap'", "'Description' failed validation on 'required'"},
+			},
+		},
+		{
+			name: "duplicate SKU",
+			rows: "L1,Lembas,Waybread,4.5," + image + "\n" + "L1,Lembas,Waybread,4.5," + image + "\n",
+			want: map[int][]string{2: {"SKU 'L1' is already used by row 1"}},
+		},
+		{
+			name: "invalid images",
+			rows: "L1,Lembas,Waybread,4.5,ftp://images.example.com/lembas.jpg\n" +
+				"L2,Lembas,Waybread,4.5,https://\n" +
+				"L3,Lembas,Waybread,4.5,restaurants/secret.jpg\n" +
+				"L4,Lembas,Waybread,4.5,dishes/../restaurants/full.jpg\n" +
+				"L5,Lembas,Waybread,4.5,dishes/0a1b2c/card.jpg\n",
+			want: map[int][]string{
+				1: {"'ImageUrl' must be an http(s) URL"},
+				2: {"'ImageUrl' must be an http(s) URL"},
+				3: {"'ImageUrl' must be an http(s) URL"},
+				4: {"'ImageUrl' must be an http(s) URL"},
+				5: {"'ImageUrl' must be an http(s) URL"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dishList, err := ParseDishCSV(strings.NewReader(header + tt.rows))
+			if err != nil {
+				t.Fatalf("ParseDishCSV() error = %v", err)
+			}
+			rowErrors := dishList.Validate()
+			if len(rowErrors) != len(tt.want) {
+				t.Fatalf("Validate() = %+v, want errors on %d rows", rowErrors, len(tt.want))
+			}
+			for _, rowError := range rowErrors {
+				want, ok := tt.want[rowError.Row]
+				if !ok {
+					t.Errorf("unexpected errors on row %d: %q", rowError.Row, rowError.Errors)
+					continue
+				}
+				if len(rowError.Errors) != len(want) {
+					t.Errorf("row %d errors = %q, want %d", rowError.Row, rowError.Errors, len(want))
+					continue
+				}
+				for i, message := range want {
+					if !strings.Contains(rowError.Errors[i], message) {
+						t.Errorf("row %d error %d = %q, want it to contain %q", rowError.Row, i, rowError.Errors[i], message)
+					}
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"mime/multipart"
 	"the-dancing-pony-v2-lcwqre/data/request"
 	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/pagination"
 
 	"github.com/google/uuid"
 )
@@ -12,11 +13,16 @@ import (
 type DishesService interface {
 	Create(dish request.CreateDishRequest, userId uuid.UUID, requestId string, restaurantId uuid.UUID) (response.DishResponse, error)
 	Update(dish request.UpdateDishRequest, userId uuid.UUID, requestId string, restaurantId string) (response.DishResponse, error)
-	Delete(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestId string) error
+	Delete(dishId uuid.UUID, versions []int64, restaurantId string, userId uuid.UUID, requestId string) error
 	FindById(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestId string) (response.DishResponse, error)
-	FindAll(page int, limit int, restaurantId string, userId uuid.UUID, requestId string) (response.DishListResponse, error)
+	FindAll(page pagination.Request, restaurantId string, userId uuid.UUID, requestId string) (response.DishListResponse, error)
 
 	RateDish(dish request.RateDishRequest, userId uuid.UUID, dishId uuid.UUID, requestId string, restaurantId string) (response.RatingResponse, error)
-	Search(searchTerm string, page int, limit int, restaurantId string, userId uuid.UUID, requestId string) (response.DishListResponse, error)
-	UploadImageToS3(file multipart.FileHeader, ctx context.Context) (string, error)
+	FindReviews(status string, page pagination.Request, restaurantId string, userId uuid.UUID, requestId string) (response.ReviewListResponse, error)
+	ModerateReview(ratingId uuid.UUID, status string, restaurantId string, userId uuid.UUID, requestId string) (response.ReviewResponse, error)
+	Search(searchTerm string, page pagination.Request, restaurantId string, userId uuid.UUID, requestId string) (response.DishListResponse, error)
+	UploadImage(file multipart.FileHeader, ctx context.Context) (string, error)
+
+	Import(dishList request.ListDishRequestList, dryRun bool, userId uuid.UUID, requestId string, restaurantId uuid.UUID) (response.DishImportResponse, error)
+	Export(restaurantId string, userId uuid.UUID, requestId string, fn func(response.DishExportRow) error) error
 }
@@ -2,17 +2,21 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"mime/multipart"
+	"slices"
+	"strings"
 	"time"
 
-	utils "the-dancing-pony-v2-lcwqre/Utils"
 	cache "the-dancing-pony-v2-lcwqre/caching"
 	"the-dancing-pony-v2-lcwqre/data/request"
 	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/media"
 	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
 	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/storage"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -23,25 +27,53 @@ import (
 type DishesServiceImpl struct {
 	DishesRepository repository.DishesRepository
 	Validate         *validator.Validate
-	S3Uploader       *utils.S3Uploader
+	ObjectStore      storage.ObjectStore
+	ImageProcessor   *media.ImageProcessor
+	Cache            cache.Cache
+	Cursors          *pagination.CursorSigner
 }
 
+var (
+	// ErrDuplicateSKU is returned when an update gives a dish the SKU of another dish of the restaurant.
+	ErrDuplicateSKU = errors.New("another dish of the restaurant already uses this SKU")
+
+	// ErrUnknownReviewStatus is returned when reviews are filtered by a status that does not exist.
+	ErrUnknownReviewStatus = errors.New("unknown review status")
+
+	// ErrReviewPageUnsupported is returned when reviews are requested by page number rather than by cursor.
+	ErrReviewPageUnsupported = errors.New("reviews cannot be paged by page number, use cursor")
+)
+
+// dishImagePrefix is the object store prefix under which dish images are kept.
+const dishImagePrefix = "dishes"
+
 // NewDishesServiceImpl creates a new instance of DishesServiceImpl.
-func NewDishesServiceImpl(dishesRepository repository.DishesRepository, validate *validator.Validate, uploader *utils.S3Uploader) DishesService {
+func NewDishesServiceImpl(dishesRepository repository.DishesRepository, validate *validator.Validate, objectStore storage.ObjectStore, imageProcessor *media.ImageProcessor, dishCache cache.Cache, cursors *pagination.CursorSigner) DishesService {
 	return &DishesServiceImpl{
 		DishesRepository: dishesRepository,
 		Validate:         validate,
-		S3Uploader:       uploader,
+		ObjectStore:      objectStore,
+		ImageProcessor:   imageProcessor,
+		Cache:            dishCache,
+		Cursors:          cursors,
 	}
 }
 
-// UploadImageToS3 uploads an image to S3 storage.
-func (s *DishesServiceImpl) UploadImageToS3(fileHeader multipart.FileHeader, ctx context.Context) (string, error) {
-	location, err := s.S3Uploader.UploadImage(fileHeader, ctx)
+// UploadImage validates an uploaded image, stores its renditions and returns the key of the full rendition.
+// Keys are derived from the content hash, so uploading the same file twice stores it once.
+func (s *DishesServiceImpl) UploadImage(fileHeader multipart.FileHeader, ctx context.Context) (string, error) {
+	if fileHeader.Size > s.ImageProcessor.MaxBytes {
+		return "", media.ErrImageTooLarge
+	}
+
+	// Open the uploaded file
+	file, err := fileHeader.Open()
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to open file: %w", err)
 	}
-	return location, nil
+	defer file.Close()
+
+	return storeDishImage(ctx, s.ObjectStore, s.ImageProcessor, file)
 }
 
 // Create adds a new dish to the repository and returns the created dish.
@@ -56,8 +88,18 @@ func (t *DishesServiceImpl) Create(dishRequest request.CreateDishRequest, userId
 		Description:  dishRequest.Description,
 		Price:        dishRequest.Price,
 		Image:        dishRequest.ImageUrl,
+		Category:     normalizeLabel(dishRequest.Category),
+		Tags:         normalizeTags(dishRequest.Tags),
+		Status:       dishRequest.Status,
 		RestaurantID: restaurantId,
 	}
+	if dishModel.Status == "" {
+		dishModel.Status = model.DishStatusPublished
+	}
+	if dishModel.Status == model.DishStatusPublished {
+		now := time.Now()
+		dishModel.PublishedAt = &now
+	}
 
 	createdDish, err := t.DishesRepository.Create(dishModel, userId)
 	if err != nil {
@@ -69,9 +111,8 @@ func (t *DishesServiceImpl) Create(dishRequest request.CreateDishRequest, userId
 		return response.DishResponse{}, err
 	}
 
-	dishResponse := toDishResponse(createdDish)
-	cacheKey := fmt.Sprintf("all_dishes_page_*_limit_*_restaurant_%s", restaurantId.String())
-	cache.RedisClient.Del(context.Background(), cacheKey)
+	dishResponse := toDishResponse(createdDish, t.ObjectStore)
+	invalidateDishCache(t.Cache, restaurantId.String())
 	log.Info().
 		Str("request_id", requestID).
 		Str("user_id", userId.String()).
@@ -80,13 +121,13 @@ func (t *DishesServiceImpl) Create(dishRequest request.CreateDishRequest, userId
 }
 
 // Delete removes a dish by ID.
-func (t *DishesServiceImpl) Delete(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestID string) error {
+func (t *DishesServiceImpl) Delete(dishId uuid.UUID, versions []int64, restaurantId string, userId uuid.UUID, requestID string) error {
 	log.Info().
 		Str("request_id", requestID).
 		Str("user_id", userId.String()).
 		Msg("Starting dish deletion")
 
-	if err := t.DishesRepository.Delete(dishId, restaurantId); err != nil {
+	if err := t.DishesRepository.Delete(dishId, restaurantId, versions); err != nil {
 		log.Error().
 			Str("request_id", requestID).
 			Str("user_id", userId.String()).
@@ -95,9 +136,7 @@ func (t *DishesServiceImpl) Delete(dishId uuid.UUID, restaurantId string, userId
 			Msg("Error deleting dish")
 		return err
 	}
-	cacheKey := fmt.Sprintf("dish_%s_restaurant_%s", dishId.String(), restaurantId)
-	cache.RedisClient.Del(context.Background(), cacheKey)
-	cache.RedisClient.Del(context.Background(), fmt.Sprintf("all_dishes_page_*_limit_*_restaurant_%s", restaurantId))
+	invalidateDishCache(t.Cache, restaurantId)
 
 	log.Info().
 		Str("request_id", requestID).
@@ -107,135 +146,94 @@ func (t *DishesServiceImpl) Delete(dishId uuid.UUID, restaurantId string, userId
 	return nil
 }
 
-// FindAll retrieves dishes with pagination, using cache if available.
-func (t *DishesServiceImpl) FindAll(page, limit int, restaurantId string, userId uuid.UUID, requestID string) (response.DishListResponse, error) {
+// FindAll retrieves a page of dishes, using cache if available.
+func (t *DishesServiceImpl) FindAll(pageRequest pagination.Request, restaurantId string, userId uuid.UUID, requestID string) (response.DishListResponse, error) {
 	log.Info().
 		Str("request_id", requestID).
 		Str("user_id", userId.String()).
 		Msg("Retrieving dishes with pagination")
 
-	cacheKey := fmt.Sprintf("all_dishes_page_%d_limit_%d_restaurant_%s", page, limit, restaurantId)
+	scope := "dishes:" + restaurantId
+	page, err := t.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.DishListResponse{}, err
+	}
 
-	cachedData, err := cache.RedisClient.Get(context.Background(), cacheKey).Result()
-	if err == nil {
-		var dishResponses []response.DishResponse
-		if err := json.Unmarshal([]byte(cachedData), &dishResponses); err != nil {
+	entry := cache.Entry{
+		Namespace: cache.RestaurantDishesNamespace(restaurantId),
+		Type:      cache.DishList,
+		Key:       pageCacheKey(page, pageRequest.Cursor, ""),
+	}
+	return cache.FetchJSON(context.Background(), t.Cache, entry, func() (response.DishListResponse, error) {
+		dishes, result, err := t.DishesRepository.FindAll(page, restaurantId)
+		if err != nil {
 			log.Error().
 				Str("request_id", requestID).
 				Str("user_id", userId.String()).
 				Err(err).
-				Msg("Error unmarshalling cached dishes")
+				Msg("Error retrieving dishes from repository")
 			return response.DishListResponse{}, err
 		}
-		log.Info().
-			Str("request_id", requestID).
-			Str("user_id", userId.String()).
-			Msg("Dishes retrieved from cache")
-		return response.DishListResponse{Dishes: dishResponses}, nil
-	}
-
-	dishes, total, err := t.DishesRepository.FindAll(page, limit, restaurantId)
-	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("user_id", userId.String()).
-			Err(err).
-			Msg("Error retrieving dishes from repository")
-		return response.DishListResponse{}, err
-	}
-
-	var dishResponses []response.DishResponse
-	for _, dish := range dishes {
-		dishResponses = append(dishResponses, toDishResponse(dish))
-	}
-
-	dishesJSON, err := json.Marshal(dishResponses)
-	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("user_id", userId.String()).
-			Err(err).
-			Msg("Error marshalling dishes for cache")
-		return response.DishListResponse{}, err
-	}
-
-	cache.RedisClient.Set(context.Background(), cacheKey, dishesJSON, 10*time.Minute)
-	log.Info().
-		Str("request_id", requestID).
-		Str("user_id", userId.String()).
-		Msg("Dishes cached successfully")
-
-	totalPages := calculateTotalPages(total, limit)
-
-	return response.DishListResponse{
-		Dishes:      dishResponses,
-		CurrentPage: page,
-		TotalPages:  totalPages,
-		TotalItems:  total,
-	}, nil
+		return t.toDishListResponse(dishes, scope, page, result), nil
+	})
 }
 
-// FindById retrieves a dish by its ID.
+// FindById retrieves a published dish by its ID.
 func (s *DishesServiceImpl) FindById(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestID string) (response.DishResponse, error) {
 	log.Info().
 		Str("request_id", requestID).
 		Str("user_id", userId.String()).
 		Msg("Retrieving dish by ID")
 
-	cacheKey := fmt.Sprintf("dish_%s_restaurant_%s", dishId.String(), restaurantId)
-
-	cachedData, err := cache.RedisClient.Get(context.Background(), cacheKey).Result()
-	if err == nil {
-		var dishResponse response.DishResponse
-		if err := json.Unmarshal([]byte(cachedData), &dishResponse); err == nil {
-			log.Info().
+	entry := cache.Entry{
+		Namespace: cache.RestaurantDishesNamespace(restaurantId),
+		Type:      cache.DishDetail,
+		Key:       dishId.String(),
+	}
+	return cache.FetchJSON(context.Background(), s.Cache, entry, func() (response.DishResponse, error) {
+		dish, err := s.DishesRepository.FindPublishedById(dishId, restaurantId)
+		if err != nil {
+			log.Error().
 				Str("request_id", requestID).
 				Str("user_id", userId.String()).
-				Msg("Dish retrieved from cache")
-			return dishResponse, nil
+				Err(err).
+				Msg("Error finding dish by ID")
+			return response.DishResponse{}, err
 		}
-	}
-
-	dish, err := s.FindDishById(dishId, restaurantId)
-	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("user_id", userId.String()).
-			Err(err).
-			Msg("Error finding dish by ID")
-		return response.DishResponse{}, err
-	}
-
-	dishResponse := toDishResponse(dish)
-	dishJSON, _ := json.Marshal(dishResponse)
-	cache.RedisClient.Set(context.Background(), cacheKey, dishJSON, 10*time.Minute)
-
-	log.Info().
-		Str("request_id", requestID).
-		Str("user_id", userId.String()).
-		Msg("Dish retrieved and cached successfully")
-	return dishResponse, nil
+		return toDishResponse(dish, s.ObjectStore), nil
+	})
 }
 
-// Update modifies an existing dish.
+// Update applies a merge patch to a dish. Members absent from the patch are left unchanged.
 func (s *DishesServiceImpl) Update(dishUpdateRequest request.UpdateDishRequest, userId uuid.UUID, requestID string, restaurantId string) (response.DishResponse, error) {
 	log.Info().
 		Str("request_id", requestID).
 		Str("user_id", userId.String()).
+		Str("dish_id", dishUpdateRequest.ID.String()).
 		Msg("Starting dish update")
 
-	dish, err := s.FindDishById(dishUpdateRequest.ID, restaurantId)
-	if err != nil {
-		log.Error().
-			Str("request_id", requestID).
-			Str("user_id", userId.String()).
-			Str("dish_id", dishUpdateRequest.ID.String()).
-			Err(err).
-			Msg("Error finding dish for update")
-		return response.DishResponse{}, err
+	if dishUpdateRequest.IsEmpty() {
+		dish, err := s.FindDishById(dishUpdateRequest.ID, restaurantId)
+		if err != nil {
+			return response.DishResponse{}, err
+		}
+		if !versionMatches(dish.Version, dishUpdateRequest.Versions) {
+			return response.DishResponse{}, repository.ErrVersionMismatch
+		}
+		return toDishResponse(dish, s.ObjectStore), nil
+	}
+
+	if dishUpdateRequest.SKU.HasValue() {
+		existing, err := s.DishesRepository.FindIdsBySKU(restaurantId, []string{dishUpdateRequest.SKU.Value})
+		if err != nil {
+			return response.DishResponse{}, err
+		}
+		if id, ok := existing[dishUpdateRequest.SKU.Value]; ok && id != dishUpdateRequest.ID {
+			return response.DishResponse{}, ErrDuplicateSKU
+		}
 	}
 
-	updatedDish, err := s.DishesRepository.Update(dish, userId, restaurantId)
+	updatedDish, err := s.DishesRepository.Update(dishUpdateRequest.ID, dishPatchFields(dishUpdateRequest), dishUpdateRequest.Versions, userId, restaurantId)
 	if err != nil {
 		log.Error().
 			Str("request_id", requestID).
@@ -246,19 +244,50 @@ func (s *DishesServiceImpl) Update(dishUpdateRequest request.UpdateDishRequest,
 		return response.DishResponse{}, fmt.Errorf("error updating dish: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("dish_%s_restaurant_%s", dishUpdateRequest.ID.String(), restaurantId)
-	cache.RedisClient.Del(context.Background(), cacheKey)
-	cache.RedisClient.Del(context.Background(), fmt.Sprintf("all_dishes_page_*_limit_*_restaurant_%s", restaurantId))
+	invalidateDishCache(s.Cache, restaurantId)
+	dishResponse := toDishResponse(updatedDish, s.ObjectStore)
 
 	log.Info().
 		Str("request_id", requestID).
 		Str("user_id", userId.String()).
 		Str("dish_id", dishUpdateRequest.ID.String()).
 		Msg("Dish updated successfully")
-	return toDishResponse(updatedDish), nil
+	return dishResponse, nil
 }
 
-// RateDish adds a rating to a dish.
+// dishPatchFields maps the members present in a dish patch to the columns they change.
+// A removed description, image or category is stored as an empty string, removed tags
+// as an empty list and a removed SKU as NULL.
+func dishPatchFields(patch request.UpdateDishRequest) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if patch.Name.Set {
+		fields["Name"] = patch.Name.Value
+	}
+	if patch.Description.Set {
+		fields["Description"] = patch.Description.Value
+	}
+	if patch.Price.Set {
+		fields["Price"] = patch.Price.Value
+	}
+	if patch.ImageUrl.Set {
+		fields["Image"] = patch.ImageUrl.Value
+	}
+	if patch.SKU.Null {
+		fields["SKU"] = nil
+	} else if patch.SKU.Set {
+		fields["SKU"] = patch.SKU.Value
+	}
+	if patch.Category.Set {
+		fields["Category"] = normalizeLabel(patch.Category.Value)
+	}
+	if patch.Tags.Set {
+		fields["Tags"] = normalizeTags(patch.Tags.Value)
+	}
+	return fields
+}
+
+// RateDish adds a rating to a dish. The rating is a review pending moderation; it earns
+// loyalty points once staff approve it.
 func (s *DishesServiceImpl) RateDish(ratingRequest request.RateDishRequest, userId uuid.UUID, dishId uuid.UUID, requestID string, restaurantId string) (response.RatingResponse, error) {
 	log.Info().
 		Str("request_id", requestID).
@@ -266,12 +295,14 @@ func (s *DishesServiceImpl) RateDish(ratingRequest request.RateDishRequest, user
 		Msg("Starting dish rating")
 
 	dishRating := model.Rating{
-		UserID: userId,
-		Rating: ratingRequest.Rating,
-		DishID: dishId,
+		UserID:  userId,
+		Rating:  ratingRequest.Rating,
+		DishID:  dishId,
+		Comment: strings.TrimSpace(ratingRequest.Comment),
+		Status:  model.RatingStatusPending,
 	}
 
-	rating, err := s.DishesRepository.RateDish(dishRating)
+	rating, err := s.DishesRepository.RateDish(dishRating, restaurantId)
 	if err != nil {
 		log.Error().
 			Str("request_id", requestID).
@@ -282,20 +313,88 @@ func (s *DishesServiceImpl) RateDish(ratingRequest request.RateDishRequest, user
 		return response.RatingResponse{}, err
 	}
 
-	cache.RedisClient.Del(context.Background(), fmt.Sprintf("dish_%s_restaurant_%s", dishId.String(), restaurantId))
-	cache.RedisClient.Del(context.Background(), fmt.Sprintf("all_dishes_page_*_limit_*_restaurant_%s", restaurantId))
-
+	// Ratings are not part of the cached dish responses, so the cache is left alone
 	log.Info().
 		Str("request_id", requestID).
 		Str("user_id", userId.String()).
 		Msg("Dish rated successfully")
 	return response.RatingResponse{
-		ID:     rating.ID,
-		Rating: rating.Rating,
-		DishId: rating.ID,
+		ID:      rating.ID,
+		Rating:  rating.Rating,
+		DishId:  rating.DishID,
+		Comment: rating.Comment,
+		Status:  rating.Status,
+	}, nil
+}
+
+// FindReviews retrieves a page of the reviews of the restaurant's dishes, optionally only those with the given status.
+func (s *DishesServiceImpl) FindReviews(status string, pageRequest pagination.Request, restaurantId string, userId uuid.UUID, requestId string) (response.ReviewListResponse, error) {
+	if status != "" && status != model.RatingStatusPending && status != model.RatingStatusApproved && status != model.RatingStatusRejected {
+		return response.ReviewListResponse{}, fmt.Errorf("%w: %q", ErrUnknownReviewStatus, status)
+	}
+	// Reviews have been paged by cursor since they were added, so there are no
+	// offset clients to keep working.
+	if pageRequest.Page != 0 {
+		return response.ReviewListResponse{}, ErrReviewPageUnsupported
+	}
+	scope := "reviews:" + restaurantId + ":status:" + status
+	page, err := s.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.ReviewListResponse{}, err
+	}
+
+	reviews, result, err := s.DishesRepository.FindReviews(restaurantId, status, page)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestId).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving reviews")
+		return response.ReviewListResponse{}, err
+	}
+	reviewResponses := make([]response.ReviewResponse, 0, len(reviews))
+	keys := make([]pagination.Key, 0, len(reviews))
+	for _, review := range reviews {
+		reviewResponses = append(reviewResponses, toReviewResponse(review))
+		keys = append(keys, pagination.Key{CreatedAt: review.CreatedAt, ID: review.ID})
+	}
+	return response.ReviewListResponse{
+		Reviews:    reviewResponses,
+		Pagination: pageInfo(s.Cursors, scope, page, result, keys),
 	}, nil
 }
 
+// ModerateReview approves or rejects a pending review. Approving it credits the
+// customer's review points when the restaurant's loyalty program awards them.
+func (s *DishesServiceImpl) ModerateReview(ratingId uuid.UUID, status string, restaurantId string, userId uuid.UUID, requestId string) (response.ReviewResponse, error) {
+	log.Info().
+		Str("request_id", requestId).
+		Str("user_id", userId.String()).
+		Str("rating_id", ratingId.String()).
+		Str("status", status).
+		Msg("Moderating review")
+
+	review, err := s.DishesRepository.ModerateReview(ratingId, restaurantId, status, userId)
+	if err != nil {
+		return response.ReviewResponse{}, err
+	}
+	return toReviewResponse(review), nil
+}
+
+// toReviewResponse converts a rating to its review response.
+func toReviewResponse(rating model.Rating) response.ReviewResponse {
+	return response.ReviewResponse{
+		ID:          rating.ID,
+		DishID:      rating.DishID,
+		CustomerID:  rating.UserID,
+		Rating:      rating.Rating,
+		Comment:     rating.Comment,
+		Status:      rating.Status,
+		CreatedAt:   rating.CreatedAt,
+		ModeratedAt: rating.ModeratedAt,
+	}
+}
+
 // FindDishById retrieves a dish by its ID from the repository.
 func (s *DishesServiceImpl) FindDishById(dishId uuid.UUID, restaurantId string) (model.Dish, error) {
 	dish, err := s.DishesRepository.FindById(dishId, restaurantId)
@@ -309,62 +408,57 @@ func (s *DishesServiceImpl) FindDishById(dishId uuid.UUID, restaurantId string)
 	return dish, nil
 }
 
-// Search searches for dishes matching the search term with pagination and caching.
-func (s *DishesServiceImpl) Search(searchTerm string, page, limit int, restaurantId string, userId uuid.UUID, requestId string) (response.DishListResponse, error) {
-	// Create a cache key based on search term and pagination parameters
-	cacheKey := fmt.Sprintf("search_%s_page_%d_limit_%d,restaurant_%s", searchTerm, page, limit, restaurantId)
+// Search searches for a page of dishes matching the search term, using cache if available.
+func (s *DishesServiceImpl) Search(searchTerm string, pageRequest pagination.Request, restaurantId string, userId uuid.UUID, requestId string) (response.DishListResponse, error) {
+	scope := "dishes:" + restaurantId + ":search:" + searchTerm
+	page, err := s.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.DishListResponse{}, err
+	}
 
-	// Attempt to retrieve cached data
-	cachedData, err := cache.RedisClient.Get(context.Background(), cacheKey).Result()
-	if err == nil {
-		var cachedResponse response.DishListResponse
-		if err := json.Unmarshal([]byte(cachedData), &cachedResponse); err != nil {
+	entry := cache.Entry{
+		Namespace: cache.RestaurantDishesNamespace(restaurantId),
+		Type:      cache.DishSearch,
+		Key:       pageCacheKey(page, pageRequest.Cursor, searchTerm),
+	}
+	return cache.FetchJSON(context.Background(), s.Cache, entry, func() (response.DishListResponse, error) {
+		// Fetch paginated search results from the repository
+		dishes, result, err := s.DishesRepository.Search(searchTerm, page, restaurantId)
+		if err != nil {
 			log.Error().
 				Err(err).
-				Msg("Error unmarshalling cached search results")
+				Msg("Error retrieving search results from repository")
 			return response.DishListResponse{}, err
 		}
-		log.Info().
-			Msg("Search results retrieved from cache")
-		return cachedResponse, nil
-	}
-
-	// Fetch paginated search results from the repository
-	dishes, total, err := s.DishesRepository.Search(searchTerm, page, limit, restaurantId)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("Error retrieving search results from repository")
-		return response.DishListResponse{}, err
-	}
+		return s.toDishListResponse(dishes, scope, page, result), nil
+	})
+}
 
-	// Convert model.Dish to response.DishResponse
-	var dishResponses []response.DishResponse
+// toDishListResponse converts a page of dishes to its response.
+func (s *DishesServiceImpl) toDishListResponse(dishes []model.Dish, scope string, page pagination.Page, result pagination.Result) response.DishListResponse {
+	dishResponses := make([]response.DishResponse, 0, len(dishes))
+	keys := make([]pagination.Key, 0, len(dishes))
 	for _, dish := range dishes {
-		dishResponses = append(dishResponses, toDishResponse(dish))
+		dishResponses = append(dishResponses, toDishResponse(dish, s.ObjectStore))
+		keys = append(keys, pagination.Key{CreatedAt: dish.CreatedAt, ID: dish.ID})
 	}
-
-	// Construct response with pagination details
-	searchResponse := response.DishListResponse{
-		Dishes:      dishResponses,
-		CurrentPage: page,
-		TotalPages:  calculateTotalPages(total, limit), // Calculate total pages based on total items
-		TotalItems:  total,
+	return response.DishListResponse{
+		Dishes:     dishResponses,
+		Pagination: pageInfo(s.Cursors, scope, page, result, keys),
 	}
+}
 
-	// Cache the results
-	dishesJSON, err := json.Marshal(searchResponse)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("Error marshalling search results for cache")
-		return response.DishListResponse{}, err
+// versionMatches reports whether version is one of the expected versions; no expected versions matches any.
+func versionMatches(version int64, expected []int64) bool {
+	if len(expected) == 0 {
+		return true
 	}
-	cache.RedisClient.Set(context.Background(), cacheKey, dishesJSON, 10*time.Minute) // Adjust cache duration as needed
-	log.Info().
-		Msg("Search results cached successfully")
-
-	return searchResponse, nil
+	for _, v := range expected {
+		if v == version {
+			return true
+		}
+	}
+	return false
 }
 
 // calculateTotalPages calculates the total number of pages based on total items and items per page.
@@ -376,12 +470,89 @@ func calculateTotalPages(totalItems, limit int) int {
 }
 
 // Helper function to convert model.Dish to response.DishResponse
-func toDishResponse(dish model.Dish) response.DishResponse {
+func toDishResponse(dish model.Dish, images storage.ObjectStore) response.DishResponse {
 	return response.DishResponse{
 		ID:          dish.ID,
 		Name:        dish.Name,
 		Description: dish.Description,
 		Price:       dish.Price,
-		ImageUrl:    dish.Image,
+		ImageUrl:    imageURL(images, dish.Image),
+		Images:      imageRenditions(images, dish.Image),
+		Gallery:     toDishImageResponses(dish.Images, images),
+		Category:    dish.Category,
+		Tags:        dishTags(dish.Tags),
+		Status:      dish.Status,
+		Version:     dish.Version,
+	}
+}
+
+// normalizeLabel lower-cases a category or tag so that promotions match it regardless of case.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// normalizeTags normalizes a list of tags and drops duplicates, keeping their order.
+func normalizeTags(tags []string) model.JSONList[string] {
+	normalized := make(model.JSONList[string], 0, len(tags))
+	for _, tag := range tags {
+		if tag = normalizeLabel(tag); tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// dishTags returns the tags of a dish, never nil, so responses always carry a list.
+func dishTags(tags model.JSONList[string]) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// imageRenditions resolves the URLs of every rendition of a processed image.
+// Images uploaded before renditions existed have none.
+func imageRenditions(images storage.ObjectStore, fullKey string) *response.DishImages {
+	if fullKey == "" || storage.IsExternalURL(fullKey) {
+		return nil
+	}
+	thumbnailKey, ok := media.RenditionKey(fullKey, media.RenditionThumbnail)
+	if !ok {
+		return nil
+	}
+	cardKey, _ := media.RenditionKey(fullKey, media.RenditionCard)
+	return &response.DishImages{
+		Thumbnail: imageURL(images, thumbnailKey),
+		Card:      imageURL(images, cardKey),
+		Full:      imageURL(images, fullKey),
+	}
+}
+
+// imageURL resolves a stored image key to a URL clients can fetch.
+// Full URLs stored before the object store existed are returned unchanged.
+func imageURL(images storage.ObjectStore, ref string) string {
+	if ref == "" || storage.IsExternalURL(ref) {
+		return ref
+	}
+	url, err := images.URL(context.Background(), ref)
+	if err != nil {
+		log.Error().
+			Str("image", ref).
+			Err(err).
+			Msg("Error resolving image URL")
+		return ""
+	}
+	return url
+}
+
+// invalidateDishCache drops every cached dish detail, list and search entry of a restaurant.
+// A failure is logged; the entries then expire with their TTL, or go when the dish-cache
+// outbox consumer handles the change.
+func invalidateDishCache(dishCache cache.Cache, restaurantId string) {
+	if err := dishCache.Invalidate(context.Background(), cache.RestaurantDishesNamespace(restaurantId)); err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Err(err).
+			Msg("Error invalidating dish cache")
 	}
 }
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/realtime"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// publishEvent pushes an event to the realtime subscribers of a restaurant.
+// A failure is logged; clients catch up on their next reload.
+func publishEvent(events realtime.Publisher, eventType string, restaurantId string, audience string, customerId string, data interface{}) {
+	event, err := realtime.NewEvent(eventType, restaurantId, audience, customerId, data)
+	if err == nil {
+		err = events.Publish(context.Background(), event)
+	}
+	if err != nil {
+		log.Error().
+			Str("restaurant_id", restaurantId).
+			Str("type", eventType).
+			Err(err).
+			Msg("Error publishing realtime event")
+	}
+}
+
+// publishOrderEvent tells staff and the ordering customer about a new or changed order.
+func publishOrderEvent(events realtime.Publisher, eventType string, order response.OrderResponse, restaurantId string) {
+	publishEvent(events, eventType, restaurantId, realtime.AudienceStaff, order.CustomerID.String(), response.OrderEvent{
+		OrderID:         order.ID,
+		CustomerID:      order.CustomerID,
+		Status:          order.Status,
+		Fulfilment:      order.Fulfilment,
+		Notes:           order.Notes,
+		Reason:          order.Reason,
+		Total:           order.Total,
+		Items:           order.Items,
+		PlacedAt:        order.PlacedAt,
+		StatusChangedAt: order.StatusChangedAt,
+	})
+}
+
+// publishReservationEvent tells staff and the customer about a new or changed reservation.
+func publishReservationEvent(events realtime.Publisher, eventType string, reservation model.Reservation) {
+	publishEvent(events, eventType, reservation.RestaurantID.String(), realtime.AudienceStaff, reservation.CustomerID.String(), response.ReservationEvent{
+		ReservationID: reservation.ID,
+		CustomerID:    reservation.CustomerID,
+		TableID:       reservation.TableID,
+		PartySize:     reservation.PartySize,
+		StartsAt:      reservation.StartsAt,
+		EndsAt:        reservation.EndsAt,
+		Status:        reservation.Status,
+	})
+}
+
+// publishDishAvailability tells everyone in the restaurant that a dish was added to or
+// removed from the menu.
+func publishDishAvailability(events realtime.Publisher, dishId uuid.UUID, available bool, restaurantId string) {
+	publishEvent(events, realtime.EventDishAvailability, restaurantId, realtime.AudienceAll, "", response.DishAvailabilityEvent{
+		DishID:    dishId,
+		Available: available,
+	})
+}
+
+// publishDishChange publishes what an applied change did: publish and unpublish changes
+// change the availability of the dish, update changes update it.
+func publishDishChange(events realtime.Publisher, change model.DishChange) {
+	switch change.Kind {
+	case model.DishChangeKindPublish:
+		publishDishAvailability(events, change.DishID, true, change.RestaurantID.String())
+	case model.DishChangeKindUnpublish:
+		publishDishAvailability(events, change.DishID, false, change.RestaurantID.String())
+	case model.DishChangeKindUpdate:
+		publishDishEvent(events, realtime.EventDishUpdated, response.DishResponse{ID: change.DishID}, change.RestaurantID.String())
+	}
+}
+
+// publishDishEvent tells staff that a dish was created, updated or deleted.
+func publishDishEvent(events realtime.Publisher, eventType string, dish response.DishResponse, restaurantId string) {
+	publishEvent(events, eventType, restaurantId, realtime.AudienceStaff, "", response.DishEvent{
+		DishID:   dish.ID,
+		Name:     dish.Name,
+		Price:    dish.Price,
+		Category: dish.Category,
+		Tags:     dish.Tags,
+		Status:   dish.Status,
+		Version:  dish.Version,
+	})
+}
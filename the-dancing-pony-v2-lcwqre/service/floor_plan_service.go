@@ -0,0 +1,39 @@
+package service
+
+import (
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/google/uuid"
+)
+
+// FloorPlanService defines the staff operations on a restaurant's dining areas, tables
+// and reservation schedule.
+type FloorPlanService interface {
+	// CreateArea creates a dining area.
+	CreateArea(areaRequest request.DiningAreaRequest, userId uuid.UUID, requestId string, restaurantId string) (response.DiningAreaResponse, error)
+
+	// FindAreas retrieves the dining areas of a restaurant.
+	FindAreas(userId uuid.UUID, requestId string, restaurantId string) ([]response.DiningAreaResponse, error)
+
+	// DeleteArea deletes a dining area; its tables stay, without an area.
+	DeleteArea(areaId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) error
+
+	// CreateTable creates a table.
+	CreateTable(tableRequest request.DiningTableRequest, userId uuid.UUID, requestId string, restaurantId string) (response.DiningTableResponse, error)
+
+	// FindTables retrieves the tables of a restaurant.
+	FindTables(userId uuid.UUID, requestId string, restaurantId string) ([]response.DiningTableResponse, error)
+
+	// ReplaceTable replaces the name, area, capacity and availability of a table.
+	ReplaceTable(tableRequest request.DiningTableRequest, tableId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DiningTableResponse, error)
+
+	// DeleteTable deletes a table that no upcoming reservation holds.
+	DeleteTable(tableId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) error
+
+	// FindSchedule retrieves a restaurant's reservation settings and opening hours.
+	FindSchedule(userId uuid.UUID, requestId string, restaurantId string) (response.ScheduleResponse, error)
+
+	// SaveSchedule replaces a restaurant's reservation settings and opening hours.
+	SaveSchedule(scheduleRequest request.ScheduleRequest, userId uuid.UUID, requestId string, restaurantId string) (response.ScheduleResponse, error)
+}
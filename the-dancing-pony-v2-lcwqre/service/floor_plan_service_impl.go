@@ -0,0 +1,300 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrDuplicateAreaName is returned when a dining area is given the name of another area of the restaurant.
+	ErrDuplicateAreaName = errors.New("another dining area of the restaurant already uses this name")
+
+	// ErrDuplicateTableName is returned when a table is given the name of another table of the restaurant.
+	ErrDuplicateTableName = errors.New("another table of the restaurant already uses this name")
+
+	// ErrUnknownDiningArea is returned when a table names a dining area the restaurant does not have.
+	ErrUnknownDiningArea = errors.New("unknown dining area")
+
+	// ErrInvalidTableCapacity is returned when a table's minimum capacity exceeds its maximum.
+	ErrInvalidTableCapacity = errors.New("minimum capacity cannot exceed maximum capacity")
+)
+
+// FloorPlanServiceImpl provides the implementation for floor plan and schedule operations.
+type FloorPlanServiceImpl struct {
+	FloorPlanRepository repository.FloorPlanRepository
+}
+
+// NewFloorPlanServiceImpl creates a new instance of FloorPlanServiceImpl.
+func NewFloorPlanServiceImpl(floorPlanRepository repository.FloorPlanRepository) FloorPlanService {
+	return &FloorPlanServiceImpl{FloorPlanRepository: floorPlanRepository}
+}
+
+// CreateArea creates a dining area with a name unique within the restaurant.
+func (s *FloorPlanServiceImpl) CreateArea(areaRequest request.DiningAreaRequest, userId uuid.UUID, requestID string, restaurantId string) (response.DiningAreaResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.DiningAreaResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	areas, err := s.FloorPlanRepository.FindAreas(restaurantId)
+	if err != nil {
+		return response.DiningAreaResponse{}, err
+	}
+	for _, area := range areas {
+		if strings.EqualFold(area.Name, areaRequest.Name) {
+			return response.DiningAreaResponse{}, ErrDuplicateAreaName
+		}
+	}
+
+	area, err := s.FloorPlanRepository.CreateArea(model.DiningArea{RestaurantID: restaurantUUID, Name: areaRequest.Name})
+	if err != nil {
+		return response.DiningAreaResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("area_id", area.ID.String()).
+		Msg("Dining area created successfully")
+	return toDiningAreaResponse(area), nil
+}
+
+// FindAreas retrieves the dining areas of a restaurant.
+func (s *FloorPlanServiceImpl) FindAreas(userId uuid.UUID, requestID string, restaurantId string) ([]response.DiningAreaResponse, error) {
+	areas, err := s.FloorPlanRepository.FindAreas(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	areaResponses := make([]response.DiningAreaResponse, 0, len(areas))
+	for _, area := range areas {
+		areaResponses = append(areaResponses, toDiningAreaResponse(area))
+	}
+	return areaResponses, nil
+}
+
+// DeleteArea deletes a dining area; its tables stay, without an area.
+func (s *FloorPlanServiceImpl) DeleteArea(areaId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) error {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("area_id", areaId.String()).
+		Msg("Deleting dining area")
+	return s.FloorPlanRepository.DeleteArea(areaId, restaurantId)
+}
+
+// CreateTable creates a table with a name unique within the restaurant.
+func (s *FloorPlanServiceImpl) CreateTable(tableRequest request.DiningTableRequest, userId uuid.UUID, requestID string, restaurantId string) (response.DiningTableResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.DiningTableResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	table := newDiningTable(tableRequest)
+	table.RestaurantID = restaurantUUID
+	if err := s.checkTable(table, uuid.Nil, restaurantId); err != nil {
+		return response.DiningTableResponse{}, err
+	}
+
+	created, err := s.FloorPlanRepository.CreateTable(table)
+	if err != nil {
+		return response.DiningTableResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("table_id", created.ID.String()).
+		Msg("Table created successfully")
+	return toDiningTableResponse(created), nil
+}
+
+// FindTables retrieves the tables of a restaurant.
+func (s *FloorPlanServiceImpl) FindTables(userId uuid.UUID, requestID string, restaurantId string) ([]response.DiningTableResponse, error) {
+	tables, err := s.FloorPlanRepository.FindTables(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	tableResponses := make([]response.DiningTableResponse, 0, len(tables))
+	for _, table := range tables {
+		tableResponses = append(tableResponses, toDiningTableResponse(table))
+	}
+	return tableResponses, nil
+}
+
+// ReplaceTable replaces the name, area, capacity and availability of a table. Existing
+// reservations keep the table even when it no longer fits their party.
+func (s *FloorPlanServiceImpl) ReplaceTable(tableRequest request.DiningTableRequest, tableId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DiningTableResponse, error) {
+	if _, err := s.FloorPlanRepository.FindTable(tableId, restaurantId); err != nil {
+		return response.DiningTableResponse{}, err
+	}
+	table := newDiningTable(tableRequest)
+	if err := s.checkTable(table, tableId, restaurantId); err != nil {
+		return response.DiningTableResponse{}, err
+	}
+
+	updated, err := s.FloorPlanRepository.UpdateTable(tableId, restaurantId, map[string]interface{}{
+		"Name":        table.Name,
+		"AreaID":      table.AreaID,
+		"MinCapacity": table.MinCapacity,
+		"MaxCapacity": table.MaxCapacity,
+		"Active":      table.Active,
+	})
+	if err != nil {
+		return response.DiningTableResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("table_id", tableId.String()).
+		Msg("Table updated successfully")
+	return toDiningTableResponse(updated), nil
+}
+
+// DeleteTable deletes a table that no upcoming reservation holds.
+func (s *FloorPlanServiceImpl) DeleteTable(tableId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) error {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("table_id", tableId.String()).
+		Msg("Deleting table")
+	return s.FloorPlanRepository.DeleteTable(tableId, restaurantId, time.Now())
+}
+
+// FindSchedule retrieves a restaurant's reservation settings and opening hours, with
+// the defaults for restaurants that never saved them.
+func (s *FloorPlanServiceImpl) FindSchedule(userId uuid.UUID, requestID string, restaurantId string) (response.ScheduleResponse, error) {
+	settings, hours, err := s.FloorPlanRepository.FindSchedule(restaurantId)
+	if err != nil {
+		return response.ScheduleResponse{}, err
+	}
+	schedule, err := newReservationSchedule(settings, hours)
+	if err != nil {
+		return response.ScheduleResponse{}, err
+	}
+	return toScheduleResponse(schedule.settings, hours), nil
+}
+
+// SaveSchedule replaces a restaurant's reservation settings and opening hours. Existing
+// reservations are kept even when they fall outside the new hours.
+func (s *FloorPlanServiceImpl) SaveSchedule(scheduleRequest request.ScheduleRequest, userId uuid.UUID, requestID string, restaurantId string) (response.ScheduleResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.ScheduleResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	settings := model.ReservationSettings{
+		RestaurantID:    restaurantUUID,
+		Timezone:        scheduleRequest.Timezone,
+		SlotMinutes:     scheduleRequest.SlotMinutes,
+		DurationMinutes: scheduleRequest.DurationMinutes,
+		MaxPartySize:    scheduleRequest.MaxPartySize,
+		MaxAdvanceDays:  scheduleRequest.MaxAdvanceDays,
+	}
+	hours := make([]model.OpeningHours, 0, len(scheduleRequest.Hours))
+	for _, period := range scheduleRequest.Hours {
+		hours = append(hours, model.OpeningHours{
+			RestaurantID: restaurantUUID,
+			Weekday:      period.Weekday,
+			Opens:        period.Opens,
+			Closes:       period.Closes,
+		})
+	}
+
+	if err := s.FloorPlanRepository.SaveSchedule(settings, hours); err != nil {
+		return response.ScheduleResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Int("periods", len(hours)).
+		Msg("Reservation schedule saved successfully")
+	return toScheduleResponse(settings, hours), nil
+}
+
+// checkTable ensures a table's capacity is consistent, its area exists and its name is
+// not used by another table of the restaurant than tableId.
+func (s *FloorPlanServiceImpl) checkTable(table model.DiningTable, tableId uuid.UUID, restaurantId string) error {
+	if table.MinCapacity > table.MaxCapacity {
+		return ErrInvalidTableCapacity
+	}
+	if table.AreaID != nil {
+		areas, err := s.FloorPlanRepository.FindAreas(restaurantId)
+		if err != nil {
+			return err
+		}
+		known := false
+		for _, area := range areas {
+			known = known || area.ID == *table.AreaID
+		}
+		if !known {
+			return ErrUnknownDiningArea
+		}
+	}
+	tables, err := s.FloorPlanRepository.FindTables(restaurantId)
+	if err != nil {
+		return err
+	}
+	for _, other := range tables {
+		if other.ID != tableId && strings.EqualFold(other.Name, table.Name) {
+			return ErrDuplicateTableName
+		}
+	}
+	return nil
+}
+
+// newDiningTable builds a table from a request, applying its defaults.
+func newDiningTable(tableRequest request.DiningTableRequest) model.DiningTable {
+	table := model.DiningTable{
+		Name:        tableRequest.Name,
+		AreaID:      tableRequest.AreaID,
+		MinCapacity: tableRequest.MinCapacity,
+		MaxCapacity: tableRequest.MaxCapacity,
+		Active:      tableRequest.Active == nil || *tableRequest.Active,
+	}
+	if table.MinCapacity == 0 {
+		table.MinCapacity = 1
+	}
+	return table
+}
+
+// toDiningAreaResponse converts a dining area to its response.
+func toDiningAreaResponse(area model.DiningArea) response.DiningAreaResponse {
+	return response.DiningAreaResponse{ID: area.ID, Name: area.Name}
+}
+
+// toDiningTableResponse converts a table to its response.
+func toDiningTableResponse(table model.DiningTable) response.DiningTableResponse {
+	return response.DiningTableResponse{
+		ID:          table.ID,
+		Name:        table.Name,
+		AreaID:      table.AreaID,
+		MinCapacity: table.MinCapacity,
+		MaxCapacity: table.MaxCapacity,
+		Active:      table.Active,
+	}
+}
+
+// toScheduleResponse converts reservation settings and opening hours to their response.
+func toScheduleResponse(settings model.ReservationSettings, hours []model.OpeningHours) response.ScheduleResponse {
+	hourResponses := make([]response.OpeningHoursResponse, 0, len(hours))
+	for _, period := range hours {
+		hourResponses = append(hourResponses, response.OpeningHoursResponse{
+			Weekday: period.Weekday,
+			Opens:   period.Opens,
+			Closes:  period.Closes,
+		})
+	}
+	return response.ScheduleResponse{
+		Timezone:        settings.Timezone,
+		SlotMinutes:     settings.SlotMinutes,
+		DurationMinutes: settings.DurationMinutes,
+		MaxPartySize:    settings.MaxPartySize,
+		MaxAdvanceDays:  settings.MaxAdvanceDays,
+		Hours:           hourResponses,
+	}
+}
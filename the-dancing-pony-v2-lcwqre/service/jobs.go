@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/jobs"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Background job types.
+const (
+	JobProcessDishImageUpload = "dish_images.process_upload"
+	JobPurgeDishImageUploads  = "dish_images.purge_expired_uploads"
+	JobApplyMenuChanges       = "menu.apply_due_changes"
+)
+
+// processDishImageUploadJob is the payload of JobProcessDishImageUpload.
+type processDishImageUploadJob struct {
+	UploadID     uuid.UUID `json:"uploadId"`
+	DishID       uuid.UUID `json:"dishId"`
+	RestaurantID string    `json:"restaurantId"`
+	RequestID    string    `json:"requestId"` // Request that confirmed the upload, for tracing
+}
+
+// RegisterJobs registers the handlers of the background jobs with a worker, and the
+// schedules of the recurring ones. Scheduled dish changes are applied every
+// menuInterval.
+func RegisterJobs(worker *jobs.Worker, dishImagesService DishImagesService, menuService MenuService, menuInterval time.Duration) error {
+	jobs.Register(worker, JobProcessDishImageUpload, func(ctx context.Context, job jobs.Job, payload processDishImageUploadJob) error {
+		lastAttempt := job.Attempts >= job.MaxAttempts
+		return dishImagesService.ProcessUpload(ctx, payload.UploadID, payload.DishID, payload.RequestID, payload.RestaurantID, lastAttempt)
+	})
+
+	jobs.Register(worker, JobPurgeDishImageUploads, func(ctx context.Context, job jobs.Job, payload struct{}) error {
+		purged, err := dishImagesService.PurgeExpiredUploads(ctx, time.Now())
+		if purged > 0 {
+			log.Info().
+				Int("purged", purged).
+				Msg("Expired dish image uploads purged")
+		}
+		return err
+	})
+
+	jobs.Register(worker, JobApplyMenuChanges, func(ctx context.Context, job jobs.Job, payload struct{}) error {
+		applied, err := menuService.ApplyDueChanges(time.Now())
+		if applied > 0 {
+			log.Info().
+				Int("applied", applied).
+				Msg("Scheduled dish changes applied")
+		}
+		return err
+	})
+
+	if err := worker.Schedule(JobApplyMenuChanges, "@every "+menuInterval.String(), JobApplyMenuChanges, nil); err != nil {
+		return err
+	}
+	return worker.Schedule(JobPurgeDishImageUploads, "@hourly", JobPurgeDishImageUploads, nil)
+}
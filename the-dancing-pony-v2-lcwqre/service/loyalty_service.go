@@ -0,0 +1,40 @@
+package service
+
+import (
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// LoyaltyService defines the operations on a restaurant's loyalty program, its rewards
+// and the points ledgers of its customers.
+type LoyaltyService interface {
+	// FindProgram retrieves the loyalty configuration of a restaurant.
+	FindProgram(userId uuid.UUID, requestId string, restaurantId string) (response.LoyaltyProgramResponse, error)
+
+	// SaveProgram replaces the loyalty configuration of a restaurant.
+	SaveProgram(programRequest request.LoyaltyProgramRequest, userId uuid.UUID, requestId string, restaurantId string) (response.LoyaltyProgramResponse, error)
+
+	// CreateReward creates a reward.
+	CreateReward(rewardRequest request.LoyaltyRewardRequest, userId uuid.UUID, requestId string, restaurantId string) (response.LoyaltyRewardResponse, error)
+
+	// FindRewards retrieves the rewards of a restaurant, cheapest first, optionally only the active ones.
+	FindRewards(activeOnly bool, userId uuid.UUID, requestId string, restaurantId string) ([]response.LoyaltyRewardResponse, error)
+
+	// ReplaceReward replaces a reward; orders that redeemed it keep their discount.
+	ReplaceReward(rewardRequest request.LoyaltyRewardRequest, rewardId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.LoyaltyRewardResponse, error)
+
+	// DeleteReward deletes a reward; orders that redeemed it keep their discount.
+	DeleteReward(rewardId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) error
+
+	// FindAccount computes a customer's balance from their ledger.
+	FindAccount(customerId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.LoyaltyAccountResponse, error)
+
+	// FindLedger retrieves a page of a customer's ledger, oldest entries first.
+	FindLedger(customerId uuid.UUID, pageRequest pagination.Request, userId uuid.UUID, requestId string, restaurantId string) (response.LoyaltyLedgerResponse, error)
+
+	// Adjust credits or debits a customer's points with a note explaining why.
+	Adjust(adjustmentRequest request.LoyaltyAdjustmentRequest, customerId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.LoyaltyEntryResponse, error)
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrLoyaltyDisabled is returned when a reward is redeemed at a restaurant without an enabled loyalty program.
+	ErrLoyaltyDisabled = errors.New("the restaurant has no loyalty program")
+
+	// ErrUnknownReward is returned when an order redeems a reward that does not exist or is not active.
+	ErrUnknownReward = errors.New("reward is not available")
+)
+
+// LoyaltyServiceImpl provides the implementation for loyalty operations.
+type LoyaltyServiceImpl struct {
+	LoyaltyRepository repository.LoyaltyRepository
+	Cursors           *pagination.CursorSigner
+}
+
+// NewLoyaltyServiceImpl creates a new instance of LoyaltyServiceImpl.
+func NewLoyaltyServiceImpl(loyaltyRepository repository.LoyaltyRepository, cursors *pagination.CursorSigner) LoyaltyService {
+	return &LoyaltyServiceImpl{
+		LoyaltyRepository: loyaltyRepository,
+		Cursors:           cursors,
+	}
+}
+
+// FindProgram retrieves the loyalty configuration of a restaurant.
+func (s *LoyaltyServiceImpl) FindProgram(userId uuid.UUID, requestID string, restaurantId string) (response.LoyaltyProgramResponse, error) {
+	program, err := s.LoyaltyRepository.FindProgram(restaurantId)
+	if err != nil {
+		return response.LoyaltyProgramResponse{}, err
+	}
+	return toLoyaltyProgramResponse(program), nil
+}
+
+// SaveProgram replaces the loyalty configuration of a restaurant. A new expiry only
+// applies to points credited from now on.
+func (s *LoyaltyServiceImpl) SaveProgram(programRequest request.LoyaltyProgramRequest, userId uuid.UUID, requestID string, restaurantId string) (response.LoyaltyProgramResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.LoyaltyProgramResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	program, err := s.LoyaltyRepository.SaveProgram(model.LoyaltyProgram{
+		RestaurantID:  restaurantUUID,
+		Enabled:       programRequest.Enabled,
+		PointsPerUnit: programRequest.PointsPerUnit,
+		ReviewPoints:  programRequest.ReviewPoints,
+		ExpiryDays:    programRequest.ExpiryDays,
+	})
+	if err != nil {
+		return response.LoyaltyProgramResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Bool("enabled", program.Enabled).
+		Msg("Loyalty program saved successfully")
+	return toLoyaltyProgramResponse(program), nil
+}
+
+// CreateReward creates a reward.
+func (s *LoyaltyServiceImpl) CreateReward(rewardRequest request.LoyaltyRewardRequest, userId uuid.UUID, requestID string, restaurantId string) (response.LoyaltyRewardResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.LoyaltyRewardResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	reward, err := s.LoyaltyRepository.CreateReward(model.LoyaltyReward{
+		RestaurantID: restaurantUUID,
+		Name:         rewardRequest.Name,
+		Description:  rewardRequest.Description,
+		PointsCost:   rewardRequest.PointsCost,
+		Amount:       roundMoney(rewardRequest.Amount),
+		Active:       rewardRequest.Active,
+	})
+	if err != nil {
+		return response.LoyaltyRewardResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("reward_id", reward.ID.String()).
+		Msg("Reward created successfully")
+	return toLoyaltyRewardResponse(reward), nil
+}
+
+// FindRewards retrieves the rewards of a restaurant, cheapest first, optionally only the active ones.
+func (s *LoyaltyServiceImpl) FindRewards(activeOnly bool, userId uuid.UUID, requestID string, restaurantId string) ([]response.LoyaltyRewardResponse, error) {
+	rewards, err := s.LoyaltyRepository.FindRewards(restaurantId, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	return toLoyaltyRewardResponses(rewards), nil
+}
+
+// ReplaceReward replaces a reward. Orders priced with the old cost are refused at
+// checkout rather than charged a different number of points.
+func (s *LoyaltyServiceImpl) ReplaceReward(rewardRequest request.LoyaltyRewardRequest, rewardId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.LoyaltyRewardResponse, error) {
+	if _, err := s.LoyaltyRepository.FindReward(rewardId, restaurantId); err != nil {
+		return response.LoyaltyRewardResponse{}, err
+	}
+	reward, err := s.LoyaltyRepository.UpdateReward(rewardId, restaurantId, map[string]interface{}{
+		"Name":        rewardRequest.Name,
+		"Description": rewardRequest.Description,
+		"PointsCost":  rewardRequest.PointsCost,
+		"Amount":      roundMoney(rewardRequest.Amount),
+		"Active":      rewardRequest.Active,
+	})
+	if err != nil {
+		return response.LoyaltyRewardResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("reward_id", rewardId.String()).
+		Msg("Reward updated successfully")
+	return toLoyaltyRewardResponse(reward), nil
+}
+
+// DeleteReward deletes a reward; orders that redeemed it keep their discount.
+func (s *LoyaltyServiceImpl) DeleteReward(rewardId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) error {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("reward_id", rewardId.String()).
+		Msg("Deleting reward")
+	return s.LoyaltyRepository.DeleteReward(rewardId, restaurantId)
+}
+
+// FindAccount computes a customer's balance from their ledger, with the restaurant's
+// program and the rewards on offer.
+func (s *LoyaltyServiceImpl) FindAccount(customerId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.LoyaltyAccountResponse, error) {
+	balance, err := s.LoyaltyRepository.Balance(customerId, restaurantId, time.Now())
+	if err != nil {
+		return response.LoyaltyAccountResponse{}, err
+	}
+	program, err := s.LoyaltyRepository.FindProgram(restaurantId)
+	if err != nil {
+		return response.LoyaltyAccountResponse{}, err
+	}
+	var rewards []model.LoyaltyReward
+	if program.Enabled {
+		if rewards, err = s.LoyaltyRepository.FindRewards(restaurantId, true); err != nil {
+			return response.LoyaltyAccountResponse{}, err
+		}
+	}
+
+	accountResponse := response.LoyaltyAccountResponse{
+		CustomerID: customerId,
+		Points:     balance.Points,
+		Expiring:   []response.LoyaltyLotResponse{},
+		Totals:     balance.Totals,
+		Program:    toLoyaltyProgramResponse(program),
+		Rewards:    toLoyaltyRewardResponses(rewards),
+	}
+	for _, lot := range balance.Lots {
+		if lot.ExpiresAt != nil {
+			accountResponse.Expiring = append(accountResponse.Expiring, response.LoyaltyLotResponse{Points: lot.Points, ExpiresAt: *lot.ExpiresAt})
+		}
+	}
+	return accountResponse, nil
+}
+
+// FindLedger retrieves a page of a customer's ledger, oldest entries first.
+func (s *LoyaltyServiceImpl) FindLedger(customerId uuid.UUID, pageRequest pagination.Request, userId uuid.UUID, requestID string, restaurantId string) (response.LoyaltyLedgerResponse, error) {
+	scope := "loyalty:" + restaurantId + ":customer:" + customerId.String()
+	page, err := s.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.LoyaltyLedgerResponse{}, err
+	}
+
+	entries, result, err := s.LoyaltyRepository.FindEntries(customerId, restaurantId, page)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving loyalty ledger")
+		return response.LoyaltyLedgerResponse{}, err
+	}
+	entryResponses := make([]response.LoyaltyEntryResponse, 0, len(entries))
+	keys := make([]pagination.Key, 0, len(entries))
+	for _, entry := range entries {
+		entryResponses = append(entryResponses, toLoyaltyEntryResponse(entry))
+		keys = append(keys, pagination.Key{CreatedAt: entry.CreatedAt, ID: entry.ID})
+	}
+	return response.LoyaltyLedgerResponse{
+		Entries:    entryResponses,
+		Pagination: pageInfo(s.Cursors, scope, page, result, keys),
+	}, nil
+}
+
+// Adjust credits or debits a customer's points with a note explaining why. Credited
+// points expire like earned ones.
+func (s *LoyaltyServiceImpl) Adjust(adjustmentRequest request.LoyaltyAdjustmentRequest, customerId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.LoyaltyEntryResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.LoyaltyEntryResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("customer_id", customerId.String()).
+		Int("points", adjustmentRequest.Points).
+		Msg("Adjusting loyalty points")
+
+	entry, err := s.LoyaltyRepository.Adjust(model.LoyaltyEntry{
+		RestaurantID: restaurantUUID,
+		CustomerID:   customerId,
+		Points:       adjustmentRequest.Points,
+		Note:         adjustmentRequest.Note,
+		CreatedByID:  &userId,
+	})
+	if err != nil {
+		return response.LoyaltyEntryResponse{}, err
+	}
+	return toLoyaltyEntryResponse(entry), nil
+}
+
+// applyOrderReward redeems a loyalty reward on a new order once its promotions are
+// applied, taking the reward amount off what is left of the total. The points are
+// spent when the order is stored.
+func applyOrderReward(loyalty repository.LoyaltyRepository, order *model.Order, rewardId *uuid.UUID, restaurantId string) error {
+	if rewardId == nil {
+		return nil
+	}
+	program, err := loyalty.FindProgram(restaurantId)
+	if err != nil {
+		return err
+	}
+	if !program.Enabled {
+		return ErrLoyaltyDisabled
+	}
+	rewards, err := loyalty.FindRewards(restaurantId, true)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(rewards, func(reward model.LoyaltyReward) bool { return reward.ID == *rewardId })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownReward, rewardId)
+	}
+	reward := rewards[i]
+
+	balance, err := loyalty.Balance(order.CustomerID, restaurantId, time.Now())
+	if err != nil {
+		return err
+	}
+	if balance.Points < reward.PointsCost {
+		return fmt.Errorf("%w: %d needed, %d available", repository.ErrInsufficientPoints, reward.PointsCost, balance.Points)
+	}
+
+	order.RewardID = &reward.ID
+	order.RewardName = reward.Name
+	order.RewardPoints = reward.PointsCost
+	order.RewardDiscount = roundMoney(min(reward.Amount, order.Total))
+	order.Total = roundMoney(order.Total - order.RewardDiscount)
+	return nil
+}
+
+// toLoyaltyProgramResponse converts a loyalty program to its response.
+func toLoyaltyProgramResponse(program model.LoyaltyProgram) response.LoyaltyProgramResponse {
+	return response.LoyaltyProgramResponse{
+		Enabled:       program.Enabled,
+		PointsPerUnit: program.PointsPerUnit,
+		ReviewPoints:  program.ReviewPoints,
+		ExpiryDays:    program.ExpiryDays,
+	}
+}
+
+// toLoyaltyRewardResponse converts a reward to its response.
+func toLoyaltyRewardResponse(reward model.LoyaltyReward) response.LoyaltyRewardResponse {
+	return response.LoyaltyRewardResponse{
+		ID:          reward.ID,
+		Name:        reward.Name,
+		Description: reward.Description,
+		PointsCost:  reward.PointsCost,
+		Amount:      reward.Amount,
+		Active:      reward.Active,
+	}
+}
+
+// toLoyaltyRewardResponses converts rewards to their responses.
+func toLoyaltyRewardResponses(rewards []model.LoyaltyReward) []response.LoyaltyRewardResponse {
+	rewardResponses := make([]response.LoyaltyRewardResponse, 0, len(rewards))
+	for _, reward := range rewards {
+		rewardResponses = append(rewardResponses, toLoyaltyRewardResponse(reward))
+	}
+	return rewardResponses
+}
+
+// toLoyaltyEntryResponse converts a ledger entry to its response.
+func toLoyaltyEntryResponse(entry model.LoyaltyEntry) response.LoyaltyEntryResponse {
+	return response.LoyaltyEntryResponse{
+		ID:          entry.ID,
+		Kind:        entry.Kind,
+		Points:      entry.Points,
+		OrderID:     entry.OrderID,
+		RatingID:    entry.RatingID,
+		RewardID:    entry.RewardID,
+		ExpiresAt:   entry.ExpiresAt,
+		Note:        entry.Note,
+		CreatedByID: entry.CreatedByID,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/google/uuid"
+)
+
+// MenuService defines the draft, publish and scheduling operations on a restaurant's menu.
+type MenuService interface {
+	// DraftChange stores an edit to a dish without making it visible to customers.
+	DraftChange(changeRequest request.DishChangeRequest, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishChangeResponse, error)
+
+	// ListChanges retrieves the draft and scheduled changes of a dish.
+	ListChanges(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestId string) ([]response.DishChangeResponse, error)
+
+	// PublishChange applies a draft change now, or schedules it for a future time.
+	PublishChange(publishRequest request.PublishRequest, changeId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishChangeResponse, error)
+
+	// DiscardChange cancels a draft or scheduled change.
+	DiscardChange(changeId uuid.UUID, restaurantId string, userId uuid.UUID, requestId string) error
+
+	// PublishDish makes a draft dish visible to customers now or at a future time.
+	PublishDish(publishRequest request.PublishRequest, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishChangeResponse, error)
+
+	// UnpublishDish hides a dish from customers now or at a future time.
+	UnpublishDish(publishRequest request.PublishRequest, dishId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.DishChangeResponse, error)
+
+	// Preview returns the menu customers will see at the given time.
+	Preview(at time.Time, restaurantId string, userId uuid.UUID, requestId string) (response.MenuPreviewResponse, error)
+
+	// ApplyDueChanges applies every scheduled change due at the given time and returns how many were applied.
+	ApplyDueChanges(now time.Time) (int, error)
+}
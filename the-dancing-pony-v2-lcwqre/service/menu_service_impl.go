@@ -0,0 +1,273 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	cache "the-dancing-pony-v2-lcwqre/caching"
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/realtime"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/storage"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrEmptyDishChange is returned when a draft change does not set any field.
+var ErrEmptyDishChange = errors.New("dish change must set at least one field")
+
+// applyDueBatchSize bounds how many scheduled changes are applied per transaction.
+const applyDueBatchSize = 100
+
+// MenuServiceImpl provides the implementation for menu draft and scheduling operations.
+type MenuServiceImpl struct {
+	DishesRepository      repository.DishesRepository
+	DishChangesRepository repository.DishChangesRepository
+	ObjectStore           storage.ObjectStore
+	Validate              *validator.Validate
+	Cache                 cache.Cache
+	Events                realtime.Publisher
+}
+
+// NewMenuServiceImpl creates a new instance of MenuServiceImpl.
+func NewMenuServiceImpl(dishesRepository repository.DishesRepository, dishChangesRepository repository.DishChangesRepository, objectStore storage.ObjectStore, validate *validator.Validate, dishCache cache.Cache, events realtime.Publisher) MenuService {
+	return &MenuServiceImpl{
+		DishesRepository:      dishesRepository,
+		DishChangesRepository: dishChangesRepository,
+		ObjectStore:           objectStore,
+		Validate:              validate,
+		Cache:                 dishCache,
+		Events:                events,
+	}
+}
+
+// DraftChange stores an edit to a dish without making it visible to customers.
+func (s *MenuServiceImpl) DraftChange(changeRequest request.DishChangeRequest, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishChangeResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("dish_id", dishId.String()).
+		Msg("Drafting dish change")
+
+	if changeRequest.Name == nil && changeRequest.Description == nil && changeRequest.Price == nil {
+		return response.DishChangeResponse{}, ErrEmptyDishChange
+	}
+
+	restaurantUUID, err := s.findDishRestaurant(dishId, restaurantId)
+	if err != nil {
+		return response.DishChangeResponse{}, err
+	}
+
+	change := model.DishChange{
+		DishID:       dishId,
+		RestaurantID: restaurantUUID,
+		Kind:         model.DishChangeKindUpdate,
+		Status:       model.DishChangeStatusDraft,
+		Name:         changeRequest.Name,
+		Description:  changeRequest.Description,
+		Price:        changeRequest.Price,
+		CreatedById:  userId,
+	}
+	if changeRequest.PublishAt != nil {
+		change.Status = model.DishChangeStatusScheduled
+		change.PublishAt = changeRequest.PublishAt
+	}
+
+	created, err := s.DishChangesRepository.Create(change)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error drafting dish change")
+		return response.DishChangeResponse{}, err
+	}
+	return toDishChangeResponse(created), nil
+}
+
+// ListChanges retrieves the draft and scheduled changes of a dish.
+func (s *MenuServiceImpl) ListChanges(dishId uuid.UUID, restaurantId string, userId uuid.UUID, requestID string) ([]response.DishChangeResponse, error) {
+	changes, err := s.DishChangesRepository.FindByDish(dishId, restaurantId)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("dish_id", dishId.String()).
+			Err(err).
+			Msg("Error listing dish changes")
+		return nil, err
+	}
+
+	changeResponses := make([]response.DishChangeResponse, 0, len(changes))
+	for _, change := range changes {
+		changeResponses = append(changeResponses, toDishChangeResponse(change))
+	}
+	return changeResponses, nil
+}
+
+// PublishChange applies a draft change now, or schedules it for a future time.
+func (s *MenuServiceImpl) PublishChange(publishRequest request.PublishRequest, changeId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishChangeResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("change_id", changeId.String()).
+		Msg("Publishing dish change")
+
+	return s.publish(publishRequest, changeId, restaurantId)
+}
+
+// DiscardChange cancels a draft or scheduled change.
+func (s *MenuServiceImpl) DiscardChange(changeId uuid.UUID, restaurantId string, userId uuid.UUID, requestID string) error {
+	if err := s.DishChangesRepository.Cancel(changeId, restaurantId); err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("change_id", changeId.String()).
+			Err(err).
+			Msg("Error discarding dish change")
+		return err
+	}
+	return nil
+}
+
+// PublishDish makes a draft dish visible to customers now or at a future time.
+func (s *MenuServiceImpl) PublishDish(publishRequest request.PublishRequest, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishChangeResponse, error) {
+	return s.changeVisibility(model.DishChangeKindPublish, publishRequest, dishId, userId, requestID, restaurantId)
+}
+
+// UnpublishDish hides a dish from customers now or at a future time.
+func (s *MenuServiceImpl) UnpublishDish(publishRequest request.PublishRequest, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishChangeResponse, error) {
+	return s.changeVisibility(model.DishChangeKindUnpublish, publishRequest, dishId, userId, requestID, restaurantId)
+}
+
+// Preview returns the menu customers will see at the given time.
+// Scheduled changes due by then are replayed in order over the current dishes.
+func (s *MenuServiceImpl) Preview(at time.Time, restaurantId string, userId uuid.UUID, requestID string) (response.MenuPreviewResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Time("at", at).
+		Msg("Previewing menu")
+
+	dishes, err := s.DishesRepository.FindAllForPreview(restaurantId)
+	if err != nil {
+		return response.MenuPreviewResponse{}, err
+	}
+	changes, err := s.DishChangesRepository.FindScheduledUntil(restaurantId, at)
+	if err != nil {
+		return response.MenuPreviewResponse{}, err
+	}
+
+	byId := make(map[uuid.UUID]*model.Dish, len(dishes))
+	for i := range dishes {
+		byId[dishes[i].ID] = &dishes[i]
+	}
+	for i := range changes {
+		if dish, ok := byId[changes[i].DishID]; ok {
+			changes[i].ApplyTo(dish, *changes[i].PublishAt)
+		}
+	}
+
+	dishResponses := []response.DishResponse{}
+	for _, dish := range dishes {
+		if dish.Status == model.DishStatusPublished {
+			dishResponses = append(dishResponses, toDishResponse(dish, s.ObjectStore))
+		}
+	}
+	return response.MenuPreviewResponse{At: at, Dishes: dishResponses}, nil
+}
+
+// ApplyDueChanges applies every scheduled change due at the given time and returns how many were applied.
+func (s *MenuServiceImpl) ApplyDueChanges(now time.Time) (int, error) {
+	total := 0
+	for {
+		applied, err := s.DishChangesRepository.ApplyDue(now, applyDueBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, change := range applied {
+			invalidateDishCache(s.Cache, change.RestaurantID.String())
+			publishDishChange(s.Events, change)
+		}
+		total += len(applied)
+		if len(applied) < applyDueBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// changeVisibility records a publish or unpublish change and applies or schedules it.
+func (s *MenuServiceImpl) changeVisibility(kind string, publishRequest request.PublishRequest, dishId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.DishChangeResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("dish_id", dishId.String()).
+		Str("kind", kind).
+		Msg("Changing dish visibility")
+
+	restaurantUUID, err := s.findDishRestaurant(dishId, restaurantId)
+	if err != nil {
+		return response.DishChangeResponse{}, err
+	}
+
+	change, err := s.DishChangesRepository.Create(model.DishChange{
+		DishID:       dishId,
+		RestaurantID: restaurantUUID,
+		Kind:         kind,
+		Status:       model.DishChangeStatusDraft,
+		CreatedById:  userId,
+	})
+	if err != nil {
+		return response.DishChangeResponse{}, err
+	}
+	return s.publish(publishRequest, change.ID, restaurantId)
+}
+
+// publish applies a change immediately when no future time is given, otherwise schedules it.
+func (s *MenuServiceImpl) publish(publishRequest request.PublishRequest, changeId uuid.UUID, restaurantId string) (response.DishChangeResponse, error) {
+	now := time.Now()
+	if publishRequest.PublishAt != nil && publishRequest.PublishAt.After(now) {
+		scheduled, err := s.DishChangesRepository.Schedule(changeId, restaurantId, *publishRequest.PublishAt)
+		if err != nil {
+			return response.DishChangeResponse{}, err
+		}
+		return toDishChangeResponse(scheduled), nil
+	}
+
+	applied, err := s.DishChangesRepository.Apply(changeId, restaurantId, now)
+	if err != nil {
+		return response.DishChangeResponse{}, err
+	}
+	invalidateDishCache(s.Cache, restaurantId)
+	publishDishChange(s.Events, applied)
+	return toDishChangeResponse(applied), nil
+}
+
+// findDishRestaurant ensures the dish exists for the restaurant and returns the restaurant UUID.
+func (s *MenuServiceImpl) findDishRestaurant(dishId uuid.UUID, restaurantId string) (uuid.UUID, error) {
+	dish, err := s.DishesRepository.FindById(dishId, restaurantId)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error finding dish: %w", err)
+	}
+	return dish.RestaurantID, nil
+}
+
+// Helper function to convert model.DishChange to response.DishChangeResponse
+func toDishChangeResponse(change model.DishChange) response.DishChangeResponse {
+	return response.DishChangeResponse{
+		ID:          change.ID,
+		DishID:      change.DishID,
+		Kind:        change.Kind,
+		Status:      change.Status,
+		Name:        change.Name,
+		Description: change.Description,
+		Price:       change.Price,
+		PublishAt:   change.PublishAt,
+		AppliedAt:   change.AppliedAt,
+	}
+}
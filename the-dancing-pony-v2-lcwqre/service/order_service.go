@@ -0,0 +1,28 @@
+package service
+
+import (
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// OrderService defines the operations on customer orders. Customers only see
+// and act on their own orders; staff see and act on every order of the restaurant.
+type OrderService interface {
+	// Place creates an order for a customer from published dishes.
+	Place(orderRequest request.PlaceOrderRequest, userId uuid.UUID, requestId string, restaurantId string) (response.OrderResponse, error)
+
+	// FindById retrieves an order as seen by the given role.
+	FindById(orderId uuid.UUID, userId uuid.UUID, role string, requestId string, restaurantId string) (response.OrderResponse, error)
+
+	// FindByCustomer retrieves a page of a customer's orders.
+	FindByCustomer(page pagination.Request, userId uuid.UUID, requestId string, restaurantId string) (response.OrderListResponse, error)
+
+	// FindByRestaurant retrieves a page of a restaurant's orders, optionally only those in the given statuses.
+	FindByRestaurant(statuses []string, page pagination.Request, userId uuid.UUID, requestId string, restaurantId string) (response.OrderListResponse, error)
+
+	// Transition moves an order to another status if the state machine allows it for the given role.
+	Transition(transitionRequest request.OrderTransitionRequest, orderId uuid.UUID, userId uuid.UUID, role string, requestId string, restaurantId string) (response.OrderResponse, error)
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/realtime"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrDishUnavailable is returned when an order names a dish that is not on the published menu.
+	ErrDishUnavailable = errors.New("dish is not available to order")
+
+	// ErrUnknownOrderStatus is returned when an order list is filtered by a status that does not exist.
+	ErrUnknownOrderStatus = errors.New("unknown order status")
+)
+
+// orderStatuses lists every order status.
+var orderStatuses = []string{
+	model.OrderStatusPlaced, model.OrderStatusAccepted, model.OrderStatusPreparing, model.OrderStatusReady,
+	model.OrderStatusServed, model.OrderStatusCollected, model.OrderStatusCancelled, model.OrderStatusRejected,
+}
+
+// OrderServiceImpl provides the implementation for order-related operations.
+type OrderServiceImpl struct {
+	OrdersRepository     repository.OrdersRepository
+	DishesRepository     repository.DishesRepository
+	PromotionsRepository repository.PromotionsRepository
+	LoyaltyRepository    repository.LoyaltyRepository
+	TaxRepository        repository.TaxRepository
+	Cursors              *pagination.CursorSigner
+	Events               realtime.Publisher
+}
+
+// NewOrderServiceImpl creates a new instance of OrderServiceImpl.
+func NewOrderServiceImpl(ordersRepository repository.OrdersRepository, dishesRepository repository.DishesRepository, promotionsRepository repository.PromotionsRepository, loyaltyRepository repository.LoyaltyRepository, taxRepository repository.TaxRepository, cursors *pagination.CursorSigner, events realtime.Publisher) OrderService {
+	return &OrderServiceImpl{
+		OrdersRepository:     ordersRepository,
+		DishesRepository:     dishesRepository,
+		PromotionsRepository: promotionsRepository,
+		LoyaltyRepository:    loyaltyRepository,
+		TaxRepository:        taxRepository,
+		Cursors:              cursors,
+		Events:               events,
+	}
+}
+
+// Place creates an order for a customer, snapshotting the name and price of each dish
+// and applying the promotions that are running, along with the coupon if one was entered,
+// then the loyalty reward the customer redeems, and finally tax.
+func (s *OrderServiceImpl) Place(orderRequest request.PlaceOrderRequest, userId uuid.UUID, requestID string, restaurantId string) (response.OrderResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Int("items", len(orderRequest.Items)).
+		Msg("Placing order")
+
+	dishIds := make([]uuid.UUID, 0, len(orderRequest.Items))
+	for _, item := range orderRequest.Items {
+		if !slices.Contains(dishIds, item.DishID) {
+			dishIds = append(dishIds, item.DishID)
+		}
+	}
+	dishes, err := s.DishesRepository.FindPublishedByIds(dishIds, restaurantId)
+	if err != nil {
+		return response.OrderResponse{}, err
+	}
+	order, err := newOrder(orderRequest.Fulfilment, orderRequest.Notes, orderRequest.Items, dishes, userId, restaurantId)
+	if err != nil {
+		return response.OrderResponse{}, err
+	}
+	if err := applyOrderPromotions(s.PromotionsRepository, &order, dishes, orderRequest.CouponCode, restaurantId); err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Order promotions could not be applied")
+		return response.OrderResponse{}, err
+	}
+	if err := applyOrderReward(s.LoyaltyRepository, &order, orderRequest.RewardID, restaurantId); err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Order reward could not be redeemed")
+		return response.OrderResponse{}, err
+	}
+	if err := applyOrderTax(s.TaxRepository, &order, dishes, restaurantId); err != nil {
+		return response.OrderResponse{}, err
+	}
+
+	created, err := s.OrdersRepository.Create(order)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error placing order")
+		return response.OrderResponse{}, err
+	}
+
+	log.Info().
+		Str("request_id", requestID).
+		Str("order_id", created.ID.String()).
+		Float64("total", created.Total).
+		Msg("Order placed successfully")
+	orderResponse := toOrderResponse(created, model.RoleCustomer)
+	publishOrderEvent(s.Events, realtime.EventOrderPlaced, orderResponse, restaurantId)
+	return orderResponse, nil
+}
+
+// FindById retrieves an order as seen by the given role.
+func (s *OrderServiceImpl) FindById(orderId uuid.UUID, userId uuid.UUID, role string, requestID string, restaurantId string) (response.OrderResponse, error) {
+	order, err := s.findOrder(orderId, userId, role, restaurantId)
+	if err != nil {
+		return response.OrderResponse{}, err
+	}
+	return toOrderResponse(order, role), nil
+}
+
+// FindByCustomer retrieves a page of a customer's orders.
+func (s *OrderServiceImpl) FindByCustomer(pageRequest pagination.Request, userId uuid.UUID, requestID string, restaurantId string) (response.OrderListResponse, error) {
+	scope := "orders:" + restaurantId + ":customer:" + userId.String()
+	page, err := s.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.OrderListResponse{}, err
+	}
+
+	orders, result, err := s.OrdersRepository.FindByCustomer(userId, restaurantId, page)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving customer orders")
+		return response.OrderListResponse{}, err
+	}
+	return s.toOrderListResponse(orders, model.RoleCustomer, scope, page, result), nil
+}
+
+// FindByRestaurant retrieves a page of a restaurant's orders, optionally only those in the given statuses.
+func (s *OrderServiceImpl) FindByRestaurant(statuses []string, pageRequest pagination.Request, userId uuid.UUID, requestID string, restaurantId string) (response.OrderListResponse, error) {
+	for _, status := range statuses {
+		if !slices.Contains(orderStatuses, status) {
+			return response.OrderListResponse{}, fmt.Errorf("%w: %q", ErrUnknownOrderStatus, status)
+		}
+	}
+	statuses = slices.Clone(statuses)
+	slices.Sort(statuses)
+
+	scope := "orders:" + restaurantId + ":status:" + strings.Join(statuses, ",")
+	page, err := s.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.OrderListResponse{}, err
+	}
+
+	orders, result, err := s.OrdersRepository.FindByRestaurant(restaurantId, statuses, page)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving restaurant orders")
+		return response.OrderListResponse{}, err
+	}
+	return s.toOrderListResponse(orders, model.RoleStaff, scope, page, result), nil
+}
+
+// Transition moves an order to another status if the state machine allows it for the given role.
+func (s *OrderServiceImpl) Transition(transitionRequest request.OrderTransitionRequest, orderId uuid.UUID, userId uuid.UUID, role string, requestID string, restaurantId string) (response.OrderResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("order_id", orderId.String()).
+		Str("status", transitionRequest.Status).
+		Msg("Changing order status")
+
+	order, err := s.findOrder(orderId, userId, role, restaurantId)
+	if err != nil {
+		return response.OrderResponse{}, err
+	}
+	if err := checkOrderTransition(order, transitionRequest.Status, role); err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("order_id", orderId.String()).
+			Err(err).
+			Msg("Order status change refused")
+		return response.OrderResponse{}, err
+	}
+
+	fields := map[string]interface{}{
+		"Status":          transitionRequest.Status,
+		"StatusChangedAt": time.Now(),
+	}
+	if transitionRequest.Status == model.OrderStatusCancelled || transitionRequest.Status == model.OrderStatusRejected {
+		fields["Reason"] = transitionRequest.Reason
+	}
+
+	updated, err := s.OrdersRepository.Transition(orderId, restaurantId, order.Status, fields)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("order_id", orderId.String()).
+			Err(err).
+			Msg("Error changing order status")
+		return response.OrderResponse{}, err
+	}
+	orderResponse := toOrderResponse(updated, role)
+	publishOrderEvent(s.Events, realtime.EventOrderUpdated, orderResponse, restaurantId)
+	return orderResponse, nil
+}
+
+// newOrder builds a placed order from its lines, snapshotting the name and price of each dish.
+// Every line must name one of the given published dishes.
+func newOrder(fulfilment string, notes string, lines []request.OrderItemRequest, dishes []model.Dish, customerId uuid.UUID, restaurantId string) (model.Order, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return model.Order{}, fmt.Errorf("invalid restaurant ID %q: %w", restaurantId, err)
+	}
+	menu := make(map[uuid.UUID]model.Dish, len(dishes))
+	for _, dish := range dishes {
+		menu[dish.ID] = dish
+	}
+
+	order := model.Order{
+		RestaurantID:    restaurantUUID,
+		CustomerID:      customerId,
+		Status:          model.OrderStatusPlaced,
+		Fulfilment:      fulfilment,
+		Notes:           notes,
+		StatusChangedAt: time.Now(),
+	}
+	for _, line := range lines {
+		dish, ok := menu[line.DishID]
+		if !ok {
+			return model.Order{}, fmt.Errorf("%w: %s", ErrDishUnavailable, line.DishID)
+		}
+		order.Items = append(order.Items, model.OrderItem{
+			DishID:    dish.ID,
+			Name:      dish.Name,
+			UnitPrice: dish.Price,
+			Quantity:  line.Quantity,
+			Notes:     line.Notes,
+		})
+		order.Total += dish.Price * float64(line.Quantity)
+	}
+	order.Total = roundMoney(order.Total)
+	return order, nil
+}
+
+// applyOrderPromotions prices a new order with the promotions running now and records them
+// as redemptions. An order is never placed without the coupon the customer entered, so a
+// coupon that does not apply fails the order.
+func applyOrderPromotions(promotions repository.PromotionsRepository, order *model.Order, dishes []model.Dish, coupon string, restaurantId string) error {
+	pricing, err := priceWithPromotions(promotions, promotionLines(order.Items, dishes), order.CustomerID, coupon, restaurantId, time.Now())
+	if err != nil {
+		return err
+	}
+	if pricing.CouponErr != nil {
+		return pricing.CouponErr
+	}
+	order.CouponCode = normalizeCouponCode(coupon)
+	order.Discount = pricing.Discount
+	order.Total = pricing.Total
+	order.Redemptions = toRedemptions(pricing.Applied)
+	return nil
+}
+
+// findOrder retrieves an order; customers can only retrieve their own.
+func (s *OrderServiceImpl) findOrder(orderId uuid.UUID, userId uuid.UUID, role string, restaurantId string) (model.Order, error) {
+	if role == model.RoleCustomer {
+		return s.OrdersRepository.FindCustomerOrder(orderId, userId, restaurantId)
+	}
+	return s.OrdersRepository.FindById(orderId, restaurantId)
+}
+
+// toOrderListResponse converts a page of orders to its response.
+func (s *OrderServiceImpl) toOrderListResponse(orders []model.Order, role string, scope string, page pagination.Page, result pagination.Result) response.OrderListResponse {
+	orderResponses := make([]response.OrderResponse, 0, len(orders))
+	keys := make([]pagination.Key, 0, len(orders))
+	for _, order := range orders {
+		orderResponses = append(orderResponses, toOrderResponse(order, role))
+		keys = append(keys, pagination.Key{CreatedAt: order.CreatedAt, ID: order.ID})
+	}
+	return response.OrderListResponse{
+		Orders:     orderResponses,
+		Pagination: pageInfo(s.Cursors, scope, page, result, keys),
+	}
+}
+
+// toOrderResponse converts an order to its response as seen by the given role.
+func toOrderResponse(order model.Order, role string) response.OrderResponse {
+	var subtotal float64
+	items := make([]response.OrderItemResponse, 0, len(order.Items))
+	for _, item := range order.Items {
+		itemResponse := response.OrderItemResponse{
+			DishID:    item.DishID,
+			Name:      item.Name,
+			UnitPrice: item.UnitPrice,
+			Quantity:  item.Quantity,
+			Notes:     item.Notes,
+			Subtotal:  roundMoney(item.UnitPrice * float64(item.Quantity)),
+			TaxName:   item.TaxName,
+			TaxRate:   item.TaxRate,
+		}
+		subtotal += itemResponse.Subtotal
+		items = append(items, itemResponse)
+	}
+
+	orderResponse := response.OrderResponse{
+		ID:               order.ID,
+		CustomerID:       order.CustomerID,
+		Status:           order.Status,
+		Fulfilment:       order.Fulfilment,
+		Notes:            order.Notes,
+		Reason:           order.Reason,
+		Subtotal:         roundMoney(subtotal),
+		Discounts:        toDiscountResponses(order.Redemptions),
+		Discount:         order.Discount,
+		Total:            order.Total,
+		PricesIncludeTax: order.PricesIncludeTax,
+		Tax:              order.Tax,
+		Taxes:            toTaxLineResponses(order.TaxLines),
+		CouponCode:       order.CouponCode,
+		Items:            items,
+		PlacedAt:         order.CreatedAt,
+		StatusChangedAt:  order.StatusChangedAt,
+		NextStatuses:     nextOrderStatuses(order, role),
+	}
+	if order.RewardID != nil {
+		orderResponse.Reward = &response.OrderRewardResponse{
+			ID:       *order.RewardID,
+			Name:     order.RewardName,
+			Points:   order.RewardPoints,
+			Discount: order.RewardDiscount,
+		}
+	}
+	if order.Invoice != nil {
+		orderResponse.Invoice = &response.InvoiceResponse{Number: order.Invoice.Number, IssuedAt: order.Invoice.IssuedAt}
+	}
+	return orderResponse
+}
+
+// roundMoney rounds an amount to whole cents.
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+
+	"the-dancing-pony-v2-lcwqre/model"
+)
+
+var (
+	// ErrInvalidOrderTransition is returned when an order cannot move from its status to the requested one.
+	ErrInvalidOrderTransition = errors.New("order cannot move to the requested status")
+
+	// ErrOrderTransitionForbidden is returned when the caller's role may not make a valid transition.
+	ErrOrderTransitionForbidden = errors.New("not allowed to move the order to the requested status")
+)
+
+// orderFulfilment limits a transition to orders with the given fulfilment.
+func orderFulfilment(fulfilment string) func(model.Order) bool {
+	return func(order model.Order) bool { return order.Fulfilment == fulfilment }
+}
+
+// orderStates is the order state machine.
+var orderStates = stateMachine[model.Order]{
+	noun:   "order",
+	status: func(order model.Order) string { return order.Status },
+	transitions: map[string][]transition[model.Order]{
+		model.OrderStatusPlaced: {
+			{to: model.OrderStatusAccepted, roles: []string{model.RoleStaff}},
+			{to: model.OrderStatusRejected, roles: []string{model.RoleStaff}},
+			{to: model.OrderStatusCancelled, roles: []string{model.RoleCustomer, model.RoleStaff}},
+		},
+		model.OrderStatusAccepted: {
+			{to: model.OrderStatusPreparing, roles: []string{model.RoleStaff}},
+			{to: model.OrderStatusCancelled, roles: []string{model.RoleStaff}},
+		},
+		model.OrderStatusPreparing: {
+			{to: model.OrderStatusReady, roles: []string{model.RoleStaff}},
+		},
+		model.OrderStatusReady: {
+			{to: model.OrderStatusServed, roles: []string{model.RoleStaff}, when: orderFulfilment(model.OrderFulfilmentDineIn)},
+			{to: model.OrderStatusCollected, roles: []string{model.RoleStaff}, when: orderFulfilment(model.OrderFulfilmentTakeaway)},
+		},
+	},
+	invalid:   ErrInvalidOrderTransition,
+	forbidden: ErrOrderTransitionForbidden,
+}
+
+// checkOrderTransition reports whether role may move the order to the given status.
+func checkOrderTransition(order model.Order, to string, role string) error {
+	return orderStates.check(order, to, role)
+}
+
+// nextOrderStatuses lists the statuses role may move the order to.
+func nextOrderStatuses(order model.Order, role string) []string {
+	return orderStates.next(order, role)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"the-dancing-pony-v2-lcwqre/model"
+)
+
+func TestCheckOrderTransition(t *testing.T) {
+	const (
+		staff    = model.RoleStaff
+		customer = model.RoleCustomer
+		dineIn   = model.OrderFulfilmentDineIn
+		takeaway = model.OrderFulfilmentTakeaway
+	)
+	tests := []struct {
+		from       string
+		fulfilment string
+		to         string
+		role       string
+		wantErr    error
+	}{
+		{from: model.OrderStatusPlaced, to: model.OrderStatusAccepted, role: staff},
+		{from: model.OrderStatusPlaced, to: model.OrderStatusRejected, role: staff},
+		{from: model.OrderStatusPlaced, to: model.OrderStatusCancelled, role: staff},
+		{from: model.OrderStatusPlaced, to: model.OrderStatusCancelled, role: customer},
+		{from: model.OrderStatusPlaced, to: model.OrderStatusAccepted, role: customer, wantErr: ErrOrderTransitionForbidden},
+		{from: model.OrderStatusPlaced, to: model.OrderStatusPreparing, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusAccepted, to: model.OrderStatusPreparing, role: staff},
+		{from: model.OrderStatusAccepted, to: model.OrderStatusCancelled, role: staff},
+		{from: model.OrderStatusAccepted, to: model.OrderStatusCancelled, role: customer, wantErr: ErrOrderTransitionForbidden},
+		{from: model.OrderStatusAccepted, to: model.OrderStatusRejected, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusPreparing, to: model.OrderStatusReady, role: staff},
+		{from: model.OrderStatusPreparing, to: model.OrderStatusCancelled, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusReady, fulfilment: dineIn, to: model.OrderStatusServed, role: staff},
+		{from: model.OrderStatusReady, fulfilment: dineIn, to: model.OrderStatusCollected, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusReady, fulfilment: takeaway, to: model.OrderStatusCollected, role: staff},
+		{from: model.OrderStatusReady, fulfilment: takeaway, to: model.OrderStatusServed, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusServed, fulfilment: dineIn, to: model.OrderStatusCancelled, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusCollected, fulfilment: takeaway, to: model.OrderStatusReady, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusCancelled, to: model.OrderStatusPlaced, role: staff, wantErr: ErrInvalidOrderTransition},
+		{from: model.OrderStatusRejected, to: model.OrderStatusAccepted, role: staff, wantErr: ErrInvalidOrderTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.from+" "+tt.fulfilment+" to "+tt.to, func(t *testing.T) {
+			order := model.Order{Status: tt.from, Fulfilment: tt.fulfilment}
+			err := checkOrderTransition(order, tt.to, tt.role)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("checkOrderTransition() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkOrderTransition() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextOrderStatuses(t *testing.T) {
+	tests := []struct {
+		status     string
+		fulfilment string
+		role       string
+		want       []string
+	}{
+		{status: model.OrderStatusPlaced, role: model.RoleStaff, want: []string{model.OrderStatusAccepted, model.OrderStatusRejected, model.OrderStatusCancelled}},
+		{status: model.OrderStatusPlaced, role: model.RoleCustomer, want: []string{model.OrderStatusCancelled}},
+		{status: model.OrderStatusAccepted, role: model.RoleCustomer, want: []string{}},
+		{status: model.OrderStatusReady, fulfilment: model.OrderFulfilmentDineIn, role: model.RoleStaff, want: []string{model.OrderStatusServed}},
+		{status: model.OrderStatusReady, fulfilment: model.OrderFulfilmentTakeaway, role: model.RoleStaff, want: []string{model.OrderStatusCollected}},
+		{status: model.OrderStatusServed, fulfilment: model.OrderFulfilmentDineIn, role: model.RoleStaff, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.status+" "+tt.fulfilment, func(t *testing.T) {
+			order := model.Order{Status: tt.status, Fulfilment: tt.fulfilment}
+			if got := nextOrderStatuses(order, tt.role); !slices.Equal(got, tt.want) {
+				t.Errorf("nextOrderStatuses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
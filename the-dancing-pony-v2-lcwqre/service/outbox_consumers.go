@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+
+	cache "the-dancing-pony-v2-lcwqre/caching"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/eventbus"
+	"the-dancing-pony-v2-lcwqre/realtime"
+
+	"github.com/google/uuid"
+)
+
+// Names of the outbox consumers, which key their offsets.
+const (
+	ConsumerDishCache   = "dish-cache"
+	ConsumerRealtime    = "realtime"
+	ConsumerRedisStream = "redis-stream"
+)
+
+// SubscribeOutboxConsumers subscribes the consumers reacting to dish and rating changes:
+// cache invalidation, and the realtime events that also feed webhooks.
+func SubscribeOutboxConsumers(bus *eventbus.Bus, dishCache cache.Cache, events realtime.Publisher) {
+	bus.Subscribe(ConsumerDishCache, dishCacheConsumer(dishCache), eventbus.DishCreated, eventbus.DishUpdated, eventbus.DishDeleted)
+	bus.Subscribe(ConsumerRealtime, realtimeConsumer(events), eventbus.DishCreated, eventbus.DishUpdated, eventbus.DishDeleted, eventbus.RatingAdded, eventbus.RatingModerated)
+}
+
+// dishCacheConsumer drops the cached dishes of the restaurant of a changed dish. The
+// service also invalidates right after the write so its caller reads its own change;
+// this makes sure the entries go even when that failed or the process stopped.
+func dishCacheConsumer(dishCache cache.Cache) eventbus.Handler {
+	return func(ctx context.Context, event eventbus.Event) error {
+		return dishCache.Invalidate(ctx, cache.RestaurantDishesNamespace(event.RestaurantID))
+	}
+}
+
+// realtimeConsumer publishes the realtime events of dish and rating changes.
+func realtimeConsumer(events realtime.Publisher) eventbus.Handler {
+	return func(ctx context.Context, event eventbus.Event) error {
+		switch event.Type {
+		case eventbus.DishCreated, eventbus.DishUpdated, eventbus.DishDeleted:
+			var dish eventbus.DishPayload
+			if err := event.Decode(&dish); err != nil {
+				return err
+			}
+			if event.Type == eventbus.DishDeleted {
+				err := relayToRealtime(ctx, events, event, realtime.EventDishAvailability, realtime.AudienceAll, "", response.DishAvailabilityEvent{
+					DishID:    dish.DishID,
+					Available: false,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return relayToRealtime(ctx, events, event, realtimeDishEventTypes[event.Type], realtime.AudienceStaff, "", response.DishEvent{
+				DishID:   dish.DishID,
+				Name:     dish.Name,
+				Price:    dish.Price,
+				Category: dish.Category,
+				Tags:     dish.Tags,
+				Status:   dish.Status,
+				Version:  dish.Version,
+			})
+		case eventbus.RatingAdded, eventbus.RatingModerated:
+			var rating eventbus.RatingPayload
+			if err := event.Decode(&rating); err != nil {
+				return err
+			}
+			eventType := realtime.EventReviewCreated
+			if event.Type == eventbus.RatingModerated {
+				eventType = realtime.EventReviewModerated
+			}
+			return relayToRealtime(ctx, events, event, eventType, realtime.AudienceStaff, rating.CustomerID.String(), response.ReviewEvent{
+				ReviewID:   rating.RatingID,
+				DishID:     rating.DishID,
+				CustomerID: rating.CustomerID,
+				Rating:     rating.Rating,
+				Comment:    rating.Comment,
+				Status:     rating.Status,
+			})
+		}
+		return nil
+	}
+}
+
+// realtimeDishEventTypes maps dish domain events to their realtime events.
+var realtimeDishEventTypes = map[string]string{
+	eventbus.DishCreated: realtime.EventDishCreated,
+	eventbus.DishUpdated: realtime.EventDishUpdated,
+	eventbus.DishDeleted: realtime.EventDishDeleted,
+}
+
+// relayToRealtime publishes a realtime event for a domain event. Its ID is derived from
+// the domain event, so a redelivered event keeps the IDs subscribers and webhook
+// receivers already saw.
+func relayToRealtime(ctx context.Context, events realtime.Publisher, source eventbus.Event, eventType string, audience string, customerId string, data interface{}) error {
+	event, err := realtime.NewEvent(eventType, source.RestaurantID, audience, customerId, data)
+	if err != nil {
+		return err
+	}
+	event.ID = uuid.NewSHA1(source.ID, []byte(eventType)).String()
+	event.CreatedAt = source.OccurredAt
+	return events.Publish(ctx, event)
+}
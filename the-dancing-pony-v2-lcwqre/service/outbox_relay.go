@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/eventbus"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	outboxBatchSize     = 100       // Events handed to a consumer per transaction
+	outboxMaxAttempts   = 10        // Attempts at an event before it becomes a dead letter
+	outboxPruneInterval = time.Hour // How often relayed events are pruned
+)
+
+// OutboxRelay periodically hands the events committed to the outbox to the consumers of
+// the bus, each from its own offset, and prunes the events every consumer has passed.
+type OutboxRelay struct {
+	OutboxRepository repository.OutboxRepository
+	Bus              *eventbus.Bus
+	Interval         time.Duration
+	Retention        time.Duration // How long relayed events are kept, e.g. for new consumers
+	lastPrune        time.Time
+}
+
+// NewOutboxRelay creates a new instance of OutboxRelay.
+func NewOutboxRelay(outboxRepository repository.OutboxRepository, bus *eventbus.Bus, interval time.Duration, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		OutboxRepository: outboxRepository,
+		Bus:              bus,
+		Interval:         interval,
+		Retention:        retention,
+	}
+}
+
+// Run relays events every interval until the context is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	log.Info().
+		Dur("interval", r.Interval).
+		Int("consumers", len(r.Bus.Consumers())).
+		Msg("Outbox relay started")
+
+	for {
+		r.tick(ctx)
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick catches every consumer up with the outbox and prunes it when due.
+func (r *OutboxRelay) tick(ctx context.Context) {
+	consumers := r.Bus.Consumers()
+	for _, consumer := range consumers {
+		r.relay(ctx, consumer)
+		r.replay(ctx, consumer)
+	}
+
+	if time.Since(r.lastPrune) < outboxPruneInterval {
+		return
+	}
+	names := make([]string, 0, len(consumers))
+	for _, consumer := range consumers {
+		names = append(names, consumer.Name)
+	}
+	pruned, err := r.OutboxRepository.Prune(names, time.Now().Add(-r.Retention))
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error pruning outbox")
+		return
+	}
+	r.lastPrune = time.Now()
+	if pruned > 0 {
+		log.Info().
+			Int64("pruned", pruned).
+			Msg("Outbox events pruned")
+	}
+}
+
+// relay hands a consumer batches of events until it has caught up.
+func (r *OutboxRelay) relay(ctx context.Context, consumer eventbus.Consumer) {
+	for ctx.Err() == nil {
+		consumed, err := r.OutboxRepository.Consume(consumer.Name, consumer.Types, outboxBatchSize, outboxMaxAttempts, func(event eventbus.Event) error {
+			return consumer.Handler(ctx, event)
+		})
+		if err != nil {
+			log.Error().
+				Str("consumer", consumer.Name).
+				Err(err).
+				Msg("Error relaying outbox events")
+			return
+		}
+		if consumed < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// replay hands a consumer the dead letters an operator asked to replay.
+func (r *OutboxRelay) replay(ctx context.Context, consumer eventbus.Consumer) {
+	replayed, err := r.OutboxRepository.Replay(consumer.Name, outboxBatchSize, func(event eventbus.Event) error {
+		return consumer.Handler(ctx, event)
+	})
+	if err != nil {
+		log.Error().
+			Str("consumer", consumer.Name).
+			Err(err).
+			Msg("Error replaying outbox dead letters")
+		return
+	}
+	if replayed > 0 {
+		log.Info().
+			Str("consumer", consumer.Name).
+			Int("replayed", replayed).
+			Msg("Outbox dead letters replayed")
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/pagination"
+)
+
+// pageInfo describes a page of a list for its response, given the keys of its rows.
+func pageInfo(cursors *pagination.CursorSigner, scope string, page pagination.Page, result pagination.Result, keys []pagination.Key) response.Pagination {
+	info := response.Pagination{CurrentPage: page.Number}
+	if result.Total >= 0 {
+		totalItems := result.Total
+		totalPages := calculateTotalPages(totalItems, page.Limit)
+		info.TotalItems, info.TotalPages = &totalItems, &totalPages
+	}
+	info.NextCursor, info.PrevCursor = cursors.Cursors(scope, page, result, keys)
+	return info
+}
+
+// pageCacheKey identifies a page of a list in the cache.
+func pageCacheKey(page pagination.Page, cursor string, searchTerm string) string {
+	values := url.Values{
+		"limit": {strconv.Itoa(page.Limit)},
+		"count": {strconv.FormatBool(page.Count)},
+	}
+	if page.Number > 0 {
+		values.Set("page", strconv.Itoa(page.Number))
+	} else if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+	if searchTerm != "" {
+		values.Set("q", searchTerm)
+	}
+	return values.Encode()
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/google/uuid"
+)
+
+// PaymentService defines the operations on order payments and refunds.
+type PaymentService interface {
+	// Pay starts a payment of the customer's order. While a payment of the order is in
+	// flight or has succeeded, that payment is returned instead of starting another.
+	Pay(ctx context.Context, payRequest request.PayOrderRequest, orderId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.PaymentResponse, error)
+
+	// FindByOrder retrieves the payments of an order as seen by the given role.
+	FindByOrder(orderId uuid.UUID, userId uuid.UUID, role string, requestId string, restaurantId string) ([]response.PaymentResponse, error)
+
+	// FindById retrieves a payment of the restaurant.
+	FindById(paymentId uuid.UUID, requestId string, restaurantId string) (response.PaymentResponse, error)
+
+	// Capture collects an authorised payment.
+	Capture(ctx context.Context, paymentId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.PaymentResponse, error)
+
+	// Refund returns part or all of a succeeded payment. Requests with the same idempotency
+	// key refund only once.
+	Refund(ctx context.Context, refundRequest request.RefundRequest, idempotencyKey string, paymentId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.PaymentResponse, error)
+
+	// HandleWebhook verifies and applies a provider webhook. Redelivered events are ignored.
+	HandleWebhook(header http.Header, body []byte, requestId string) error
+}
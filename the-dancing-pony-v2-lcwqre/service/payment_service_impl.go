@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/payments"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrOrderNotPayable is returned when paying for a cancelled, rejected or free order.
+	ErrOrderNotPayable = errors.New("order cannot be paid")
+
+	// ErrPaymentNotCapturable is returned when capturing a payment that is not authorised.
+	ErrPaymentNotCapturable = errors.New("payment is not authorised for capture")
+)
+
+// paymentRetries bounds how often a payment update is retried after a concurrent change.
+const paymentRetries = 3
+
+// PaymentServiceImpl provides the implementation for payment-related operations.
+type PaymentServiceImpl struct {
+	PaymentsRepository repository.PaymentsRepository
+	OrdersRepository   repository.OrdersRepository
+	Provider           payments.PaymentProvider
+	Currency           string
+	CaptureMethod      string
+}
+
+// NewPaymentServiceImpl creates a new instance of PaymentServiceImpl.
+func NewPaymentServiceImpl(paymentsRepository repository.PaymentsRepository, ordersRepository repository.OrdersRepository, provider payments.PaymentProvider, currency string, captureMethod string) PaymentService {
+	return &PaymentServiceImpl{
+		PaymentsRepository: paymentsRepository,
+		OrdersRepository:   ordersRepository,
+		Provider:           provider,
+		Currency:           currency,
+		CaptureMethod:      captureMethod,
+	}
+}
+
+// Pay starts a payment of the customer's order.
+func (s *PaymentServiceImpl) Pay(ctx context.Context, payRequest request.PayOrderRequest, orderId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.PaymentResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("order_id", orderId.String()).
+		Msg("Paying for order")
+
+	order, err := s.OrdersRepository.FindCustomerOrder(orderId, userId, restaurantId)
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	amount := toMinorUnits(order.Total)
+	if order.Status == model.OrderStatusCancelled || order.Status == model.OrderStatusRejected || amount <= 0 {
+		return response.PaymentResponse{}, fmt.Errorf("%w: order is %s", ErrOrderNotPayable, order.Status)
+	}
+
+	payment, err := s.PaymentsRepository.FindOrCreateForOrder(model.Payment{
+		RestaurantID:  order.RestaurantID,
+		OrderID:       order.ID,
+		CustomerID:    userId,
+		Provider:      s.Provider.Name(),
+		Status:        model.PaymentStatusPending,
+		Amount:        amount,
+		Currency:      s.Currency,
+		CaptureMethod: s.CaptureMethod,
+	})
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	if payment.Status != model.PaymentStatusPending {
+		log.Info().
+			Str("request_id", requestID).
+			Str("payment_id", payment.ID.String()).
+			Str("status", payment.Status).
+			Msg("Order already has a payment")
+		return toPaymentResponse(payment), nil
+	}
+
+	// The payment ID is the idempotency key, so retrying a payment stuck in pending
+	// returns the intent the provider may already have created
+	intent, err := s.Provider.CreateIntent(ctx, payments.IntentRequest{
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		PaymentMethod:  payRequest.PaymentMethod,
+		CaptureMethod:  payment.CaptureMethod,
+		Reference:      payment.ID.String(),
+		IdempotencyKey: payment.ID.String(),
+	})
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("payment_id", payment.ID.String()).
+			Err(err).
+			Msg("Error creating payment intent")
+		return response.PaymentResponse{}, fmt.Errorf("error creating payment intent: %w", err)
+	}
+
+	payment, err = s.applyIntent(payment, intent)
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("payment_id", payment.ID.String()).
+		Str("status", payment.Status).
+		Msg("Payment started")
+	return toPaymentResponse(payment), nil
+}
+
+// FindByOrder retrieves the payments of an order; customers only those of their own orders.
+func (s *PaymentServiceImpl) FindByOrder(orderId uuid.UUID, userId uuid.UUID, role string, requestID string, restaurantId string) ([]response.PaymentResponse, error) {
+	if role == model.RoleCustomer {
+		if _, err := s.OrdersRepository.FindCustomerOrder(orderId, userId, restaurantId); err != nil {
+			return nil, err
+		}
+	}
+	stored, err := s.PaymentsRepository.FindByOrder(orderId, restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	paymentResponses := make([]response.PaymentResponse, 0, len(stored))
+	for _, payment := range stored {
+		paymentResponses = append(paymentResponses, toPaymentResponse(payment))
+	}
+	return paymentResponses, nil
+}
+
+// FindById retrieves a payment of the restaurant.
+func (s *PaymentServiceImpl) FindById(paymentId uuid.UUID, requestID string, restaurantId string) (response.PaymentResponse, error) {
+	payment, err := s.PaymentsRepository.FindById(paymentId, restaurantId)
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	return toPaymentResponse(payment), nil
+}
+
+// Capture collects an authorised payment in full.
+func (s *PaymentServiceImpl) Capture(ctx context.Context, paymentId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.PaymentResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("payment_id", paymentId.String()).
+		Msg("Capturing payment")
+
+	payment, err := s.PaymentsRepository.FindById(paymentId, restaurantId)
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	if payment.Status == model.PaymentStatusSucceeded {
+		return toPaymentResponse(payment), nil
+	}
+	if payment.Status != model.PaymentStatusAuthorized {
+		return response.PaymentResponse{}, fmt.Errorf("%w: payment is %s", ErrPaymentNotCapturable, payment.Status)
+	}
+
+	intent, err := s.Provider.Capture(ctx, payment.ProviderIntentID, 0)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("payment_id", paymentId.String()).
+			Err(err).
+			Msg("Error capturing payment")
+		return response.PaymentResponse{}, fmt.Errorf("error capturing payment: %w", err)
+	}
+	payment, err = s.applyIntent(payment, intent)
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	return toPaymentResponse(payment), nil
+}
+
+// Refund returns part or all of a succeeded payment. The amount is reserved before the
+// provider is called, so concurrent refunds cannot exceed the payment. A refund the
+// provider has not settled yet stays pending until its webhook arrives.
+func (s *PaymentServiceImpl) Refund(ctx context.Context, refundRequest request.RefundRequest, idempotencyKey string, paymentId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.PaymentResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("payment_id", paymentId.String()).
+		Float64("amount", refundRequest.Amount).
+		Msg("Refunding payment")
+
+	payment, err := s.PaymentsRepository.FindById(paymentId, restaurantId)
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+
+	refund := model.Refund{
+		PaymentID:     paymentId,
+		Amount:        toMinorUnits(refundRequest.Amount),
+		Reason:        refundRequest.Reason,
+		RequestedByID: userId,
+	}
+	if idempotencyKey != "" {
+		refund.IdempotencyKey = &idempotencyKey
+	}
+	refund, err = s.PaymentsRepository.ReserveRefund(refund, restaurantId)
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	if refund.Status != model.RefundStatusPending {
+		return s.FindById(paymentId, requestID, restaurantId)
+	}
+
+	providerRefund, err := s.Provider.Refund(ctx, payments.RefundRequest{
+		IntentID:       payment.ProviderIntentID,
+		Amount:         refund.Amount,
+		IdempotencyKey: refund.ID.String(),
+	})
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("refund_id", refund.ID.String()).
+			Err(err).
+			Msg("Error refunding payment")
+		return response.PaymentResponse{}, fmt.Errorf("error refunding payment: %w", err)
+	}
+	if err := s.PaymentsRepository.SetProviderRefundID(refund.ID, providerRefund.ID); err != nil {
+		return response.PaymentResponse{}, err
+	}
+	if providerRefund.Status == payments.RefundPending {
+		return s.FindById(paymentId, requestID, restaurantId)
+	}
+
+	payment, err = s.PaymentsRepository.CompleteRefund(refund.ID, refundStatusFor(providerRefund.Status))
+	if err != nil {
+		return response.PaymentResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("refund_id", refund.ID.String()).
+		Str("status", providerRefund.Status).
+		Msg("Refund completed")
+	return toPaymentResponse(payment), nil
+}
+
+// HandleWebhook verifies and applies a provider webhook.
+func (s *PaymentServiceImpl) HandleWebhook(header http.Header, body []byte, requestID string) error {
+	event, err := s.Provider.VerifyWebhook(header, body)
+	if err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Err(err).
+			Msg("Rejected payment webhook")
+		return err
+	}
+	seen, err := s.PaymentsRepository.HasEvent(s.Provider.Name(), event.ID)
+	if err != nil || seen {
+		return err
+	}
+
+	switch {
+	case event.Type == payments.EventIntentUpdated && event.Intent != nil:
+		reference, _ := uuid.Parse(event.Intent.Reference)
+		payment, err := s.PaymentsRepository.FindByIntent(s.Provider.Name(), event.Intent.ID, reference)
+		if err != nil {
+			return err
+		}
+		if _, err := s.applyIntent(payment, *event.Intent); err != nil {
+			return err
+		}
+	case event.Type == payments.EventRefundUpdated && event.Refund != nil:
+		if event.Refund.Status == payments.RefundPending {
+			break
+		}
+		refund, err := s.PaymentsRepository.FindRefundByProviderId(event.Refund.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := s.PaymentsRepository.CompleteRefund(refund.ID, refundStatusFor(event.Refund.Status)); err != nil {
+			return err
+		}
+	default:
+		log.Info().
+			Str("request_id", requestID).
+			Str("event_id", event.ID).
+			Str("type", event.Type).
+			Msg("Ignoring payment webhook")
+	}
+
+	log.Info().
+		Str("request_id", requestID).
+		Str("event_id", event.ID).
+		Str("type", event.Type).
+		Msg("Payment webhook processed")
+	return s.PaymentsRepository.RecordEvent(model.PaymentEvent{
+		Provider: s.Provider.Name(),
+		EventID:  event.ID,
+		Type:     event.Type,
+	})
+}
+
+// applyIntent moves a payment to the status of its provider intent. Updates that repeat
+// the current status or would move the payment backwards leave it unchanged, so the
+// same intent can be applied from the API response and from any number of webhooks.
+func (s *PaymentServiceImpl) applyIntent(payment model.Payment, intent payments.Intent) (model.Payment, error) {
+	to, ok := paymentStatusFor(intent.Status)
+	if !ok {
+		log.Warn().
+			Str("payment_id", payment.ID.String()).
+			Str("intent_status", intent.Status).
+			Msg("Unknown payment intent status")
+		return payment, nil
+	}
+
+	for attempt := 0; attempt < paymentRetries; attempt++ {
+		if payment.Status == to || !canMovePayment(payment.Status, to) {
+			return payment, nil
+		}
+		updated, err := s.PaymentsRepository.Transition(payment.ID, payment.Status, map[string]interface{}{
+			"Status":           to,
+			"ProviderIntentID": intent.ID,
+			"NextActionURL":    intent.NextActionURL,
+			"FailureCode":      intent.FailureCode,
+		})
+		if !errors.Is(err, repository.ErrPaymentStatusChanged) {
+			return updated, err
+		}
+		if payment, err = s.PaymentsRepository.FindById(payment.ID, payment.RestaurantID.String()); err != nil {
+			return model.Payment{}, err
+		}
+	}
+	return model.Payment{}, repository.ErrPaymentStatusChanged
+}
+
+// refundStatusFor maps a final provider refund status to a refund status.
+func refundStatusFor(providerStatus string) string {
+	if providerStatus == payments.RefundSucceeded {
+		return model.RefundStatusSucceeded
+	}
+	return model.RefundStatusFailed
+}
+
+// toPaymentResponse converts a payment to its response.
+func toPaymentResponse(payment model.Payment) response.PaymentResponse {
+	refunds := make([]response.RefundResponse, 0, len(payment.Refunds))
+	for _, refund := range payment.Refunds {
+		refunds = append(refunds, response.RefundResponse{
+			ID:        refund.ID,
+			Status:    refund.Status,
+			Amount:    fromMinorUnits(refund.Amount),
+			Reason:    refund.Reason,
+			CreatedAt: refund.CreatedAt,
+		})
+	}
+	return response.PaymentResponse{
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		Status:         payment.Status,
+		Provider:       payment.Provider,
+		Amount:         fromMinorUnits(payment.Amount),
+		AmountRefunded: fromMinorUnits(payment.AmountRefunded),
+		Currency:       payment.Currency,
+		CaptureMethod:  payment.CaptureMethod,
+		NextActionURL:  payment.NextActionURL,
+		FailureCode:    payment.FailureCode,
+		Refunds:        refunds,
+		CreatedAt:      payment.CreatedAt,
+	}
+}
+
+// toMinorUnits converts an amount in currency units to cents.
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// fromMinorUnits converts an amount in cents to currency units.
+func fromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"slices"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/payments"
+)
+
+// paymentTransitions is the payment state machine: the statuses each status can move to.
+// Provider updates that would move a payment backwards, such as a late processing event
+// after success, are ignored. Refund statuses are driven by completed refunds.
+var paymentTransitions = map[string][]string{
+	model.PaymentStatusPending: {
+		model.PaymentStatusRequiresAction, model.PaymentStatusProcessing, model.PaymentStatusAuthorized,
+		model.PaymentStatusSucceeded, model.PaymentStatusFailed,
+	},
+	model.PaymentStatusRequiresAction: {
+		model.PaymentStatusProcessing, model.PaymentStatusAuthorized, model.PaymentStatusSucceeded, model.PaymentStatusFailed,
+	},
+	model.PaymentStatusProcessing: {
+		model.PaymentStatusAuthorized, model.PaymentStatusSucceeded, model.PaymentStatusFailed,
+	},
+	model.PaymentStatusAuthorized: {
+		model.PaymentStatusSucceeded, model.PaymentStatusFailed,
+	},
+	model.PaymentStatusSucceeded: {
+		model.PaymentStatusPartiallyRefunded, model.PaymentStatusRefunded,
+	},
+	model.PaymentStatusPartiallyRefunded: {
+		model.PaymentStatusRefunded,
+	},
+}
+
+// canMovePayment reports whether a payment may move from one status to another.
+func canMovePayment(from string, to string) bool {
+	return slices.Contains(paymentTransitions[from], to)
+}
+
+// paymentStatusFor maps a provider intent status to a payment status.
+func paymentStatusFor(intentStatus string) (string, bool) {
+	switch intentStatus {
+	case payments.IntentRequiresAction:
+		return model.PaymentStatusRequiresAction, true
+	case payments.IntentProcessing:
+		return model.PaymentStatusProcessing, true
+	case payments.IntentAuthorized:
+		return model.PaymentStatusAuthorized, true
+	case payments.IntentSucceeded:
+		return model.PaymentStatusSucceeded, true
+	case payments.IntentFailed:
+		return model.PaymentStatusFailed, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownCoupon is returned when a coupon code does not exist at the restaurant.
+	ErrUnknownCoupon = errors.New("coupon code is not valid")
+
+	// ErrPromotionNotRunning is returned when a coupon is disabled or outside its dates, days or hours.
+	ErrPromotionNotRunning = errors.New("promotion is not running at this time")
+
+	// ErrPromotionFirstOrder is returned when a first-order promotion is used by a returning customer.
+	ErrPromotionFirstOrder = errors.New("promotion is only available on a first order")
+
+	// ErrPromotionLimitReached is returned when a promotion was used the maximum number of times.
+	ErrPromotionLimitReached = errors.New("promotion has reached its usage limit")
+
+	// ErrPromotionMinSpend is returned when the order subtotal is below the minimum spend of a promotion.
+	ErrPromotionMinSpend = errors.New("order does not reach the minimum spend of the promotion")
+
+	// ErrPromotionNoQualifyingItems is returned when not enough items qualify for a promotion.
+	ErrPromotionNoQualifyingItems = errors.New("not enough items in the order qualify for the promotion")
+
+	// ErrPromotionNotStackable is returned when a coupon cannot be combined with the promotions already applied.
+	ErrPromotionNotStackable = errors.New("promotion cannot be combined with the promotions already applied")
+)
+
+// promotionLine is a cart item or order line being priced.
+type promotionLine struct {
+	DishID    uuid.UUID
+	Category  string
+	Tags      []string
+	UnitPrice float64
+	Quantity  int
+}
+
+// promotionCustomer is what the engine knows about the customer being priced. Guests
+// are priced as customers without orders or redemptions.
+type promotionCustomer struct {
+	Orders      int64               // Orders that were not cancelled or rejected
+	Redemptions map[uuid.UUID]int64 // Held redemptions by promotion
+	Usage       map[uuid.UUID]int64 // Held redemptions of every customer, for promotions with a usage limit
+	Coupon      string              // Normalized coupon code entered, if any
+}
+
+// appliedPromotion is a promotion applied to a cart or order and the amount it takes off.
+type appliedPromotion struct {
+	Promotion model.Promotion
+	Amount    float64
+}
+
+// promotionPricing is the outcome of applying promotions to a set of lines.
+type promotionPricing struct {
+	Subtotal  float64
+	Discount  float64
+	Total     float64
+	Applied   []appliedPromotion
+	CouponErr error // Why the entered coupon does not apply, nil when it does or none was entered
+}
+
+// applyPromotions prices lines with the promotions that apply at the given time. The result
+// only depends on its inputs: promotions are tried by descending priority, then by age and
+// ID, and each one discounts what the previous ones left of the qualifying lines, so totals
+// never go below zero. The first promotion that applies decides how they combine: when it
+// is not stackable it applies alone, otherwise only further stackable promotions join it.
+// Like the tax engine, it works in cents, so the discounts add up to the amounts shown.
+func applyPromotions(promotions []model.Promotion, lines []promotionLine, customer promotionCustomer, at time.Time) promotionPricing {
+	result := promotionPricing{}
+	remaining := make([]int64, len(lines))
+	var subtotal, discount int64
+	for i, line := range lines {
+		remaining[i] = toMinorUnits(line.UnitPrice) * int64(line.Quantity)
+		subtotal += remaining[i]
+	}
+
+	ordered := slices.Clone(promotions)
+	slices.SortStableFunc(ordered, comparePromotions)
+
+	couponSeen := false
+	exclusive := false
+	for _, promotion := range ordered {
+		isCoupon := promotion.Code != nil && *promotion.Code == customer.Coupon
+		if promotion.Code != nil && !isCoupon {
+			continue
+		}
+		couponSeen = couponSeen || isCoupon
+
+		err := checkPromotionEligibility(promotion, customer, at)
+		if err == nil && subtotal < toMinorUnits(promotion.MinSpend) {
+			err = fmt.Errorf("%w of %.2f", ErrPromotionMinSpend, promotion.MinSpend)
+		}
+		if err == nil && (exclusive || (len(result.Applied) > 0 && !promotion.Stackable)) {
+			err = ErrPromotionNotStackable
+		}
+		var discounts []int64
+		var amount int64
+		if err == nil {
+			discounts = promotionDiscounts(promotion, lines, remaining)
+			for _, lineDiscount := range discounts {
+				amount += lineDiscount
+			}
+			if amount == 0 {
+				err = ErrPromotionNoQualifyingItems
+			}
+		}
+		if err != nil {
+			if isCoupon {
+				result.CouponErr = err
+			}
+			continue
+		}
+
+		for i, lineDiscount := range discounts {
+			remaining[i] -= lineDiscount
+		}
+		result.Applied = append(result.Applied, appliedPromotion{Promotion: promotion, Amount: fromMinorUnits(amount)})
+		discount += amount
+		exclusive = !promotion.Stackable
+	}
+	if customer.Coupon != "" && !couponSeen {
+		result.CouponErr = ErrPromotionNotRunning
+	}
+
+	result.Subtotal = fromMinorUnits(subtotal)
+	result.Discount = fromMinorUnits(discount)
+	result.Total = fromMinorUnits(subtotal - discount)
+	return result
+}
+
+// comparePromotions orders promotions by descending priority, then oldest first, then by ID.
+func comparePromotions(a, b model.Promotion) int {
+	if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+		return c
+	}
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID.String(), b.ID.String())
+}
+
+// checkPromotionEligibility checks the conditions of a promotion that do not depend on the
+// items: whether it is running and whether the customer may still use it.
+func checkPromotionEligibility(promotion model.Promotion, customer promotionCustomer, at time.Time) error {
+	if !promotionRunning(promotion, at) {
+		return ErrPromotionNotRunning
+	}
+	if promotion.FirstOrderOnly && customer.Orders > 0 {
+		return ErrPromotionFirstOrder
+	}
+	if promotion.PerCustomerLimit > 0 && customer.Redemptions[promotion.ID] >= int64(promotion.PerCustomerLimit) {
+		return ErrPromotionLimitReached
+	}
+	if promotion.UsageLimit > 0 && customer.Usage[promotion.ID] >= int64(promotion.UsageLimit) {
+		return ErrPromotionLimitReached
+	}
+	return nil
+}
+
+// promotionRunning reports whether a promotion is active at the given time. Daily hours
+// ending at or before they start run past midnight and count towards the day they start.
+func promotionRunning(promotion model.Promotion, at time.Time) bool {
+	if !promotion.Active ||
+		(promotion.StartsAt != nil && at.Before(*promotion.StartsAt)) ||
+		(promotion.EndsAt != nil && !at.Before(*promotion.EndsAt)) {
+		return false
+	}
+
+	location, err := time.LoadLocation(promotion.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := at.In(location)
+	weekday := local.Weekday()
+	if promotion.DailyFrom != "" && promotion.DailyUntil != "" {
+		// Zero-padded HH:MM times compare correctly as strings
+		clock := local.Format("15:04")
+		if promotion.DailyFrom < promotion.DailyUntil {
+			if clock < promotion.DailyFrom || clock >= promotion.DailyUntil {
+				return false
+			}
+		} else if clock < promotion.DailyUntil {
+			weekday = (weekday + 6) % 7
+		} else if clock < promotion.DailyFrom {
+			return false
+		}
+	}
+	return len(promotion.Weekdays) == 0 || slices.Contains(promotion.Weekdays, int(weekday))
+}
+
+// promotionQualifies reports whether a line qualifies for a promotion. Promotions without
+// categories and tags apply to every line.
+func promotionQualifies(promotion model.Promotion, line promotionLine) bool {
+	if len(promotion.Categories) == 0 && len(promotion.Tags) == 0 {
+		return true
+	}
+	if slices.Contains(promotion.Categories, line.Category) {
+		return true
+	}
+	for _, tag := range line.Tags {
+		if slices.Contains(promotion.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// promotionDiscounts computes how much a promotion takes off each line, in cents, given
+// what is left of each line after the promotions applied before it. Percentages are held
+// in hundredths of a percent and each line's discount is rounded half away from zero.
+func promotionDiscounts(promotion model.Promotion, lines []promotionLine, remaining []int64) []int64 {
+	discounts := make([]int64, len(lines))
+	switch promotion.DiscountType {
+	case model.PromotionDiscountPercent:
+		basisPoints := int64(math.Round(promotion.Value * 100))
+		for i, line := range lines {
+			if promotionQualifies(promotion, line) {
+				discounts[i] = min(divideRounded(remaining[i]*basisPoints, 10000), remaining[i])
+			}
+		}
+
+	case model.PromotionDiscountFixed:
+		budget := toMinorUnits(promotion.Value)
+		for i, line := range lines {
+			if budget <= 0 {
+				break
+			}
+			if promotionQualifies(promotion, line) {
+				discounts[i] = min(budget, remaining[i])
+				budget -= discounts[i]
+			}
+		}
+
+	case model.PromotionDiscountBuyXGetY:
+		group := promotion.BuyQuantity + promotion.GetQuantity
+		if promotion.BuyQuantity < 1 || promotion.GetQuantity < 1 {
+			return discounts
+		}
+		type unit struct {
+			line  int
+			price int64
+		}
+		var units []unit
+		for i, line := range lines {
+			if promotionQualifies(promotion, line) {
+				for n := 0; n < line.Quantity; n++ {
+					units = append(units, unit{line: i, price: toMinorUnits(line.UnitPrice)})
+				}
+			}
+		}
+		// Most expensive first, so that the cheapest units of each group are the free ones
+		slices.SortStableFunc(units, func(a, b unit) int {
+			return cmp.Compare(b.price, a.price)
+		})
+		left := slices.Clone(remaining)
+		for n, u := range units {
+			if n%group < promotion.BuyQuantity {
+				continue
+			}
+			free := min(u.price, left[u.line])
+			discounts[u.line] += free
+			left[u.line] -= free
+		}
+	}
+	return discounts
+}
+
+// normalizeCouponCode trims a coupon code and upper-cases it, the form codes are stored in.
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// promotionLines builds the lines to price from items and the dishes they name.
+// Items whose dish is missing are left out.
+func promotionLines(items []model.OrderItem, dishes []model.Dish) []promotionLine {
+	menu := make(map[uuid.UUID]model.Dish, len(dishes))
+	for _, dish := range dishes {
+		menu[dish.ID] = dish
+	}
+	lines := make([]promotionLine, 0, len(items))
+	for _, item := range items {
+		dish, ok := menu[item.DishID]
+		if !ok {
+			continue
+		}
+		lines = append(lines, promotionLine{
+			DishID:    item.DishID,
+			Category:  dish.Category,
+			Tags:      dish.Tags,
+			UnitPrice: item.UnitPrice,
+			Quantity:  item.Quantity,
+		})
+	}
+	return lines
+}
+
+// priceWithPromotions loads the promotions that may apply to a customer's lines and applies
+// them. Guests are priced with customerId uuid.Nil. Limits are only read here; they are
+// enforced again under lock when the order is stored.
+func priceWithPromotions(promotions repository.PromotionsRepository, lines []promotionLine, customerId uuid.UUID, coupon string, restaurantId string, at time.Time) (promotionPricing, error) {
+	customer := promotionCustomer{Coupon: normalizeCouponCode(coupon)}
+	candidates, err := promotions.FindApplicable(restaurantId, customer.Coupon, at)
+	if err != nil {
+		return promotionPricing{}, err
+	}
+	if err := loadPromotionCustomer(promotions, candidates, &customer, customerId, restaurantId); err != nil {
+		return promotionPricing{}, err
+	}
+
+	result := applyPromotions(candidates, lines, customer, at)
+	if errors.Is(result.CouponErr, ErrPromotionNotRunning) {
+		// Tell a coupon that does not exist apart from one that is not running
+		promotion, err := promotions.FindByCode(customer.Coupon, restaurantId)
+		if err != nil {
+			return promotionPricing{}, err
+		}
+		if promotion.ID == uuid.Nil {
+			result.CouponErr = fmt.Errorf("%w: %s", ErrUnknownCoupon, customer.Coupon)
+		}
+	}
+	return result, nil
+}
+
+// loadPromotionCustomer reads the order and redemption counts the candidate promotions need.
+func loadPromotionCustomer(promotions repository.PromotionsRepository, candidates []model.Promotion, customer *promotionCustomer, customerId uuid.UUID, restaurantId string) error {
+	var needsOrders, needsRedemptions bool
+	for _, promotion := range candidates {
+		needsOrders = needsOrders || promotion.FirstOrderOnly
+		needsRedemptions = needsRedemptions || promotion.PerCustomerLimit > 0
+		if promotion.UsageLimit > 0 {
+			count, err := promotions.CountRedemptions(promotion.ID)
+			if err != nil {
+				return err
+			}
+			if customer.Usage == nil {
+				customer.Usage = make(map[uuid.UUID]int64)
+			}
+			customer.Usage[promotion.ID] = count
+		}
+	}
+	if customerId == uuid.Nil {
+		return nil
+	}
+
+	var err error
+	if needsOrders {
+		if customer.Orders, err = promotions.CountCustomerOrders(customerId, restaurantId); err != nil {
+			return err
+		}
+	}
+	if needsRedemptions {
+		if customer.Redemptions, err = promotions.CountCustomerRedemptions(customerId, restaurantId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toDiscountResponses converts the redemptions of an order, or those a cart would make, to their responses.
+func toDiscountResponses(redemptions []model.PromotionRedemption) []response.DiscountResponse {
+	discounts := make([]response.DiscountResponse, 0, len(redemptions))
+	for _, redemption := range redemptions {
+		discounts = append(discounts, response.DiscountResponse{
+			PromotionID: redemption.PromotionID,
+			Name:        redemption.Name,
+			Code:        redemption.Code,
+			Amount:      redemption.Amount,
+		})
+	}
+	return discounts
+}
+
+// toRedemptions records the promotions applied to an order; the order fills in the IDs.
+func toRedemptions(applied []appliedPromotion) []model.PromotionRedemption {
+	redemptions := make([]model.PromotionRedemption, 0, len(applied))
+	for _, a := range applied {
+		redemption := model.PromotionRedemption{
+			PromotionID: a.Promotion.ID,
+			Name:        a.Promotion.Name,
+			Amount:      a.Amount,
+		}
+		if a.Promotion.Code != nil {
+			redemption.Code = *a.Promotion.Code
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions
+}
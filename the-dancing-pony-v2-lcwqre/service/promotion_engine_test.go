@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"the-dancing-pony-v2-lcwqre/model"
+)
+
+// testPromotion returns an active, stackable percentage promotion that runs at any time.
+func testPromotion(name string, priority int, percent float64) model.Promotion {
+	return model.Promotion{
+		Model:        model.Model{ID: uuid.NewSHA1(uuid.NameSpaceOID, []byte(name))},
+		Name:         name,
+		Active:       true,
+		Priority:     priority,
+		Stackable:    true,
+		Timezone:     "UTC",
+		DiscountType: model.PromotionDiscountPercent,
+		Value:        percent,
+	}
+}
+
+func TestApplyPromotions(t *testing.T) {
+	friday := time.Date(2024, time.May, 3, 17, 30, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+	lines := []promotionLine{{Category: "mains", UnitPrice: 8.5, Quantity: 2}, {Category: "drinks", UnitPrice: 3, Quantity: 1}}
+
+	happyHour := testPromotion("happy hour", 0, 10)
+	happyHour.DailyFrom, happyHour.DailyUntil = "17:00", "19:00"
+	lateNight := testPromotion("late night", 0, 10)
+	lateNight.DailyFrom, lateNight.DailyUntil, lateNight.Weekdays = "22:00", "02:00", model.JSONList[int]{int(time.Friday)}
+	limited := testPromotion("limited", 0, 10)
+	limited.PerCustomerLimit = 2
+	coupon := testPromotion("coupon", -1, 10)
+	coupon.Code, coupon.UsageLimit = new(string), 1
+	*coupon.Code = "MELLON"
+	exclusive := testPromotion("exclusive", 2, 50)
+	exclusive.Stackable = false
+	couponOnly := coupon
+	couponOnly.Stackable = false
+	fixed := testPromotion("fixed", 0, 0)
+	fixed.DiscountType, fixed.Value = model.PromotionDiscountFixed, 50
+
+	tests := []struct {
+		name          string
+		promotions    []model.Promotion
+		lines         []promotionLine
+		customer      promotionCustomer
+		at            time.Time
+		wantApplied   []string
+		wantDiscount  float64
+		wantTotal     float64
+		wantCouponErr error
+	}{
+		{
+			name:         "happy hour running",
+			promotions:   []model.Promotion{happyHour},
+			at:           friday,
+			wantApplied:  []string{"happy hour"},
+			wantDiscount: 2,
+			wantTotal:    18,
+		},
+		{
+			name:       "happy hour over",
+			promotions: []model.Promotion{happyHour},
+			at:         friday.Add(90 * time.Minute),
+			wantTotal:  20,
+		},
+		{
+			name:         "hours past midnight count towards the day they start",
+			promotions:   []model.Promotion{lateNight},
+			at:           saturday.Add(-16*time.Hour - 30*time.Minute),
+			wantApplied:  []string{"late night"},
+			wantDiscount: 2,
+			wantTotal:    18,
+		},
+		{
+			name:       "hours past midnight on a day they do not start",
+			promotions: []model.Promotion{lateNight},
+			at:         saturday.Add(5*time.Hour + 30*time.Minute),
+			wantTotal:  20,
+		},
+		{
+			name:         "below the limit per customer",
+			promotions:   []model.Promotion{limited},
+			customer:     promotionCustomer{Redemptions: map[uuid.UUID]int64{limited.ID: 1}},
+			at:           friday,
+			wantApplied:  []string{"limited"},
+			wantDiscount: 2,
+			wantTotal:    18,
+		},
+		{
+			name:       "limit per customer reached",
+			promotions: []model.Promotion{limited},
+			customer:   promotionCustomer{Redemptions: map[uuid.UUID]int64{limited.ID: 2}},
+			at:         friday,
+			wantTotal:  20,
+		},
+		{
+			name:          "usage limit of a coupon reached",
+			promotions:    []model.Promotion{coupon},
+			customer:      promotionCustomer{Coupon: "MELLON", Usage: map[uuid.UUID]int64{coupon.ID: 1}},
+			at:            friday,
+			wantTotal:     20,
+			wantCouponErr: ErrPromotionLimitReached,
+		},
+		{
+			name:         "stackable promotions discount what is left",
+			promotions:   []model.Promotion{happyHour, coupon},
+			customer:     promotionCustomer{Coupon: "MELLON"},
+			at:           friday,
+			wantApplied:  []string{"happy hour", "coupon"},
+			wantDiscount: 3.8,
+			wantTotal:    16.2,
+		},
+		{
+			name:         "first promotion not stackable applies alone",
+			promotions:   []model.Promotion{happyHour, exclusive},
+			at:           friday,
+			wantApplied:  []string{"exclusive"},
+			wantDiscount: 10,
+			wantTotal:    10,
+		},
+		{
+			name:          "coupon not stackable after a promotion",
+			promotions:    []model.Promotion{happyHour, couponOnly},
+			customer:      promotionCustomer{Coupon: "MELLON"},
+			at:            friday,
+			wantApplied:   []string{"happy hour"},
+			wantDiscount:  2,
+			wantTotal:     18,
+			wantCouponErr: ErrPromotionNotStackable,
+		},
+		{
+			name:         "each line rounded to the cent",
+			promotions:   []model.Promotion{testPromotion("rounding", 0, 15)},
+			lines:        []promotionLine{{UnitPrice: 0.1, Quantity: 1}, {UnitPrice: 0.1, Quantity: 1}, {UnitPrice: 0.1, Quantity: 1}},
+			at:           friday,
+			wantApplied:  []string{"rounding"},
+			wantDiscount: 0.06,
+			wantTotal:    0.24,
+		},
+		{
+			name:         "fixed discount capped at the subtotal",
+			promotions:   []model.Promotion{fixed},
+			at:           friday,
+			wantApplied:  []string{"fixed"},
+			wantDiscount: 20,
+			wantTotal:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.lines == nil {
+				tt.lines = lines
+			}
+			got := applyPromotions(tt.promotions, tt.lines, tt.customer, tt.at)
+			var applied []string
+			var sum float64
+			for _, promotion := range got.Applied {
+				applied = append(applied, promotion.Promotion.Name)
+				sum += promotion.Amount
+			}
+			if !slices.Equal(applied, tt.wantApplied) {
+				t.Errorf("applyPromotions() applied = %q, want %q", applied, tt.wantApplied)
+			}
+			if got.Discount != tt.wantDiscount || got.Total != tt.wantTotal {
+				t.Errorf("applyPromotions() discount = %v, total = %v, want %v and %v", got.Discount, got.Total, tt.wantDiscount, tt.wantTotal)
+			}
+			if roundMoney(sum) != got.Discount {
+				t.Errorf("applyPromotions() amounts add up to %v, want %v", sum, got.Discount)
+			}
+			if !errors.Is(got.CouponErr, tt.wantCouponErr) {
+				t.Errorf("applyPromotions() coupon error = %v, want %v", got.CouponErr, tt.wantCouponErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/google/uuid"
+)
+
+// PromotionService defines the staff operations on a restaurant's promotions and coupons.
+type PromotionService interface {
+	// Create creates a promotion.
+	Create(promotionRequest request.PromotionRequest, userId uuid.UUID, requestId string, restaurantId string) (response.PromotionResponse, error)
+
+	// FindAll retrieves the promotions of a restaurant in the order they are applied.
+	FindAll(userId uuid.UUID, requestId string, restaurantId string) ([]response.PromotionResponse, error)
+
+	// FindById retrieves a promotion with the number of its redemptions.
+	FindById(promotionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.PromotionResponse, error)
+
+	// Replace replaces the conditions and action of a promotion.
+	Replace(promotionRequest request.PromotionRequest, promotionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.PromotionResponse, error)
+
+	// Delete deletes a promotion; orders keep the discounts it gave.
+	Delete(promotionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) error
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrDuplicateCouponCode is returned when a promotion is given the code of another promotion of the restaurant.
+	ErrDuplicateCouponCode = errors.New("another promotion of the restaurant already uses this code")
+
+	// ErrInvalidPromotion is returned when the conditions or action of a promotion are inconsistent.
+	ErrInvalidPromotion = errors.New("invalid promotion")
+)
+
+// PromotionServiceImpl provides the implementation for promotion operations.
+type PromotionServiceImpl struct {
+	PromotionsRepository repository.PromotionsRepository
+}
+
+// NewPromotionServiceImpl creates a new instance of PromotionServiceImpl.
+func NewPromotionServiceImpl(promotionsRepository repository.PromotionsRepository) PromotionService {
+	return &PromotionServiceImpl{PromotionsRepository: promotionsRepository}
+}
+
+// Create creates a promotion with a code unique within the restaurant.
+func (s *PromotionServiceImpl) Create(promotionRequest request.PromotionRequest, userId uuid.UUID, requestID string, restaurantId string) (response.PromotionResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.PromotionResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	promotion, err := newPromotion(promotionRequest)
+	if err != nil {
+		return response.PromotionResponse{}, err
+	}
+	promotion.RestaurantID = restaurantUUID
+	if err := s.checkCode(promotion, uuid.Nil, restaurantId); err != nil {
+		return response.PromotionResponse{}, err
+	}
+
+	created, err := s.PromotionsRepository.Create(promotion)
+	if err != nil {
+		return response.PromotionResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("promotion_id", created.ID.String()).
+		Msg("Promotion created successfully")
+	return toPromotionResponse(created), nil
+}
+
+// FindAll retrieves the promotions of a restaurant in the order they are applied.
+func (s *PromotionServiceImpl) FindAll(userId uuid.UUID, requestID string, restaurantId string) ([]response.PromotionResponse, error) {
+	promotions, err := s.PromotionsRepository.FindAll(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	promotionResponses := make([]response.PromotionResponse, 0, len(promotions))
+	for _, promotion := range promotions {
+		promotionResponses = append(promotionResponses, toPromotionResponse(promotion))
+	}
+	return promotionResponses, nil
+}
+
+// FindById retrieves a promotion with the number of its redemptions that still hold.
+func (s *PromotionServiceImpl) FindById(promotionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.PromotionResponse, error) {
+	promotion, err := s.PromotionsRepository.FindById(promotionId, restaurantId)
+	if err != nil {
+		return response.PromotionResponse{}, err
+	}
+	redemptions, err := s.PromotionsRepository.CountRedemptions(promotionId)
+	if err != nil {
+		return response.PromotionResponse{}, err
+	}
+	promotionResponse := toPromotionResponse(promotion)
+	promotionResponse.Redemptions = &redemptions
+	return promotionResponse, nil
+}
+
+// Replace replaces the conditions and action of a promotion. Orders that already
+// redeemed it keep their discounts.
+func (s *PromotionServiceImpl) Replace(promotionRequest request.PromotionRequest, promotionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.PromotionResponse, error) {
+	if _, err := s.PromotionsRepository.FindById(promotionId, restaurantId); err != nil {
+		return response.PromotionResponse{}, err
+	}
+	promotion, err := newPromotion(promotionRequest)
+	if err != nil {
+		return response.PromotionResponse{}, err
+	}
+	if err := s.checkCode(promotion, promotionId, restaurantId); err != nil {
+		return response.PromotionResponse{}, err
+	}
+
+	updated, err := s.PromotionsRepository.Update(promotionId, restaurantId, map[string]interface{}{
+		"Name":             promotion.Name,
+		"Code":             promotion.Code,
+		"Active":           promotion.Active,
+		"Priority":         promotion.Priority,
+		"Stackable":        promotion.Stackable,
+		"StartsAt":         promotion.StartsAt,
+		"EndsAt":           promotion.EndsAt,
+		"Timezone":         promotion.Timezone,
+		"Weekdays":         promotion.Weekdays,
+		"DailyFrom":        promotion.DailyFrom,
+		"DailyUntil":       promotion.DailyUntil,
+		"Categories":       promotion.Categories,
+		"Tags":             promotion.Tags,
+		"MinSpend":         promotion.MinSpend,
+		"FirstOrderOnly":   promotion.FirstOrderOnly,
+		"PerCustomerLimit": promotion.PerCustomerLimit,
+		"UsageLimit":       promotion.UsageLimit,
+		"DiscountType":     promotion.DiscountType,
+		"Value":            promotion.Value,
+		"BuyQuantity":      promotion.BuyQuantity,
+		"GetQuantity":      promotion.GetQuantity,
+	})
+	if err != nil {
+		return response.PromotionResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("promotion_id", promotionId.String()).
+		Msg("Promotion updated successfully")
+	return toPromotionResponse(updated), nil
+}
+
+// Delete deletes a promotion; orders keep the discounts it gave.
+func (s *PromotionServiceImpl) Delete(promotionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) error {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("promotion_id", promotionId.String()).
+		Msg("Deleting promotion")
+	return s.PromotionsRepository.Delete(promotionId, restaurantId)
+}
+
+// checkCode checks that no other promotion of the restaurant uses the promotion's code.
+func (s *PromotionServiceImpl) checkCode(promotion model.Promotion, promotionId uuid.UUID, restaurantId string) error {
+	if promotion.Code == nil {
+		return nil
+	}
+	existing, err := s.PromotionsRepository.FindByCode(*promotion.Code, restaurantId)
+	if err != nil {
+		return err
+	}
+	if existing.ID != uuid.Nil && existing.ID != promotionId {
+		return ErrDuplicateCouponCode
+	}
+	return nil
+}
+
+// newPromotion builds a promotion from a request, normalizing its code, categories and
+// tags the way dishes and carts store them.
+func newPromotion(promotionRequest request.PromotionRequest) (model.Promotion, error) {
+	promotion := model.Promotion{
+		Name:             promotionRequest.Name,
+		Active:           promotionRequest.Active,
+		Priority:         promotionRequest.Priority,
+		Stackable:        promotionRequest.Stackable,
+		StartsAt:         promotionRequest.StartsAt,
+		EndsAt:           promotionRequest.EndsAt,
+		Timezone:         promotionRequest.Timezone,
+		Weekdays:         model.JSONList[int]{},
+		DailyFrom:        promotionRequest.DailyFrom,
+		DailyUntil:       promotionRequest.DailyUntil,
+		Categories:       normalizeTags(promotionRequest.Categories),
+		Tags:             normalizeTags(promotionRequest.Tags),
+		MinSpend:         promotionRequest.MinSpend,
+		FirstOrderOnly:   promotionRequest.FirstOrderOnly,
+		PerCustomerLimit: promotionRequest.PerCustomerLimit,
+		UsageLimit:       promotionRequest.UsageLimit,
+		DiscountType:     promotionRequest.DiscountType,
+		Value:            promotionRequest.Value,
+	}
+	if code := normalizeCouponCode(promotionRequest.Code); code != "" {
+		promotion.Code = &code
+	}
+	if promotion.Timezone == "" {
+		promotion.Timezone = "UTC"
+	}
+	for _, weekday := range promotionRequest.Weekdays {
+		if !slices.Contains(promotion.Weekdays, weekday) {
+			promotion.Weekdays = append(promotion.Weekdays, weekday)
+		}
+	}
+	slices.Sort(promotion.Weekdays)
+
+	if (promotion.DailyFrom == "") != (promotion.DailyUntil == "") {
+		return model.Promotion{}, fmt.Errorf("%w: daily hours need both dailyFrom and dailyUntil", ErrInvalidPromotion)
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return model.Promotion{}, fmt.Errorf("%w: endsAt must be after startsAt", ErrInvalidPromotion)
+	}
+	switch promotion.DiscountType {
+	case model.PromotionDiscountPercent:
+		if promotion.Value <= 0 || promotion.Value > 100 {
+			return model.Promotion{}, fmt.Errorf("%w: a percent discount needs a value above 0 and at most 100", ErrInvalidPromotion)
+		}
+	case model.PromotionDiscountFixed:
+		if promotion.Value <= 0 {
+			return model.Promotion{}, fmt.Errorf("%w: a fixed discount needs a value above 0", ErrInvalidPromotion)
+		}
+	case model.PromotionDiscountBuyXGetY:
+		if promotionRequest.BuyQuantity < 1 || promotionRequest.GetQuantity < 1 {
+			return model.Promotion{}, fmt.Errorf("%w: a buy_x_get_y discount needs a buyQuantity and getQuantity of at least 1", ErrInvalidPromotion)
+		}
+		promotion.Value = 0
+		promotion.BuyQuantity = promotionRequest.BuyQuantity
+		promotion.GetQuantity = promotionRequest.GetQuantity
+	}
+	return promotion, nil
+}
+
+// toPromotionResponse converts a promotion to its response.
+func toPromotionResponse(promotion model.Promotion) response.PromotionResponse {
+	promotionResponse := response.PromotionResponse{
+		ID:               promotion.ID,
+		Name:             promotion.Name,
+		Active:           promotion.Active,
+		Priority:         promotion.Priority,
+		Stackable:        promotion.Stackable,
+		StartsAt:         promotion.StartsAt,
+		EndsAt:           promotion.EndsAt,
+		Timezone:         promotion.Timezone,
+		Weekdays:         []int{},
+		DailyFrom:        promotion.DailyFrom,
+		DailyUntil:       promotion.DailyUntil,
+		Categories:       dishTags(promotion.Categories),
+		Tags:             dishTags(promotion.Tags),
+		MinSpend:         promotion.MinSpend,
+		FirstOrderOnly:   promotion.FirstOrderOnly,
+		PerCustomerLimit: promotion.PerCustomerLimit,
+		UsageLimit:       promotion.UsageLimit,
+		DiscountType:     promotion.DiscountType,
+		Value:            promotion.Value,
+		BuyQuantity:      promotion.BuyQuantity,
+		GetQuantity:      promotion.GetQuantity,
+	}
+	if promotion.Code != nil {
+		promotionResponse.Code = *promotion.Code
+	}
+	if promotion.Weekdays != nil {
+		promotionResponse.Weekdays = promotion.Weekdays
+	}
+	return promotionResponse
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // Restaurant timezones must load on hosts without zoneinfo
+
+	"the-dancing-pony-v2-lcwqre/model"
+
+	"github.com/google/uuid"
+)
+
+// reservationSchedule is a restaurant's reservation settings with defaults applied and
+// its timezone loaded.
+type reservationSchedule struct {
+	settings model.ReservationSettings
+	location *time.Location
+	hours    []model.OpeningHours
+}
+
+// newReservationSchedule applies the defaults to settings that were never saved and loads the timezone.
+func newReservationSchedule(settings model.ReservationSettings, hours []model.OpeningHours) (reservationSchedule, error) {
+	if settings.ID == uuid.Nil {
+		settings = model.ReservationSettings{
+			RestaurantID:    settings.RestaurantID,
+			Timezone:        model.DefaultReservationTimezone,
+			SlotMinutes:     model.DefaultSlotMinutes,
+			DurationMinutes: model.DefaultDurationMinutes,
+			MaxPartySize:    model.DefaultMaxPartySize,
+			MaxAdvanceDays:  model.DefaultMaxAdvanceDays,
+		}
+	}
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return reservationSchedule{}, fmt.Errorf("error loading restaurant timezone: %w", err)
+	}
+	return reservationSchedule{settings: settings, location: location, hours: hours}, nil
+}
+
+// duration is how long a reservation holds its table.
+func (s reservationSchedule) duration() time.Duration {
+	return time.Duration(s.settings.DurationMinutes) * time.Minute
+}
+
+// parseDate parses a YYYY-MM-DD date as the start of that day in the restaurant's timezone.
+func (s reservationSchedule) parseDate(date string) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, date, s.location)
+}
+
+// today is the start of the current day in the restaurant's timezone.
+func (s reservationSchedule) today(now time.Time) time.Time {
+	local := now.In(s.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)
+}
+
+// slotsOn lists the start times of the opening periods of a day, in order. Every slot
+// ends by the close of its period; periods closing after midnight belong to the day
+// they open.
+func (s reservationSchedule) slotsOn(day time.Time) []time.Time {
+	var slots []time.Time
+	interval := time.Duration(s.settings.SlotMinutes) * time.Minute
+	for _, period := range s.hours {
+		if time.Weekday(period.Weekday) != day.Weekday() {
+			continue
+		}
+		opens, closes := s.clockOn(day, period.Opens), s.clockOn(day, period.Closes)
+		if !closes.After(opens) {
+			closes = s.clockOn(day.AddDate(0, 0, 1), period.Closes)
+		}
+		for start := opens; !start.Add(s.duration()).After(closes); start = start.Add(interval) {
+			slots = append(slots, start)
+		}
+	}
+	slices.SortFunc(slots, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(slots, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+// isSlot reports whether a time is one of the slots of its day or of the day before,
+// whose late periods may run past midnight.
+func (s reservationSchedule) isSlot(at time.Time) bool {
+	day := s.today(at)
+	for _, candidate := range append(s.slotsOn(day.AddDate(0, 0, -1)), s.slotsOn(day)...) {
+		if candidate.Equal(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// bookable reports whether a slot is in the future and within the advance booking window.
+func (s reservationSchedule) bookable(at time.Time, now time.Time) bool {
+	return at.After(now) && !at.After(now.AddDate(0, 0, s.settings.MaxAdvanceDays))
+}
+
+// clockOn returns the time of a HH:MM clock on a day in the restaurant's timezone.
+// Clocks are validated when the schedule is saved.
+func (s reservationSchedule) clockOn(day time.Time, clock string) time.Time {
+	parsed, _ := time.Parse("15:04", clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, s.location)
+}
+
+// fittingTables lists the active tables that seat a party, smallest first so that large
+// tables stay free for large parties. The order is stable, which keeps concurrent
+// bookings locking tables in the same order.
+func fittingTables(tables []model.DiningTable, partySize int) []model.DiningTable {
+	var fitting []model.DiningTable
+	for _, table := range tables {
+		if table.Active && table.MinCapacity <= partySize && partySize <= table.MaxCapacity {
+			fitting = append(fitting, table)
+		}
+	}
+	slices.SortFunc(fitting, func(a, b model.DiningTable) int {
+		if a.MaxCapacity != b.MaxCapacity {
+			return a.MaxCapacity - b.MaxCapacity
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	return fitting
+}
+
+// freeTables lists the tables not held by any of the reservations from start to end.
+func freeTables(tables []model.DiningTable, reservations []model.Reservation, start time.Time, end time.Time) []uuid.UUID {
+	free := make([]uuid.UUID, 0, len(tables))
+	for _, table := range tables {
+		taken := slices.ContainsFunc(reservations, func(reservation model.Reservation) bool {
+			return reservation.TableID == table.ID && reservation.StartsAt.Before(end) && reservation.EndsAt.After(start)
+		})
+		if !taken {
+			free = append(free, table.ID)
+		}
+	}
+	return free
+}
+
+// tableIds lists the IDs of tables in order.
+func tableIds(tables []model.DiningTable) []uuid.UUID {
+	ids := make([]uuid.UUID, len(tables))
+	for i, table := range tables {
+		ids[i] = table.ID
+	}
+	return ids
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// ReservationService defines the operations on table reservations and the waitlist.
+// Customers only see and act on their own reservations; staff see and act on every
+// reservation of the restaurant.
+type ReservationService interface {
+	// Availability lists the slots of a YYYY-MM-DD date in the restaurant's timezone and whether a party can be seated at each.
+	Availability(date string, partySize int, requestId string, restaurantId string) (response.AvailabilityResponse, error)
+
+	// Book reserves a table for a customer at one of the available slots.
+	Book(bookRequest request.BookReservationRequest, userId uuid.UUID, requestId string, restaurantId string) (response.ReservationResponse, error)
+
+	// FindById retrieves a reservation as seen by the given role.
+	FindById(reservationId uuid.UUID, userId uuid.UUID, role string, requestId string, restaurantId string) (response.ReservationResponse, error)
+
+	// FindByCustomer retrieves a page of a customer's reservations.
+	FindByCustomer(page pagination.Request, userId uuid.UUID, requestId string, restaurantId string) (response.ReservationListResponse, error)
+
+	// FindByDate retrieves the reservations of a YYYY-MM-DD date, today when empty, optionally only those in the given statuses.
+	FindByDate(date string, statuses []string, userId uuid.UUID, requestId string, restaurantId string) ([]response.ReservationResponse, error)
+
+	// Transition moves a reservation to another status if the state machine allows it for the given role.
+	Transition(transitionRequest request.ReservationTransitionRequest, reservationId uuid.UUID, userId uuid.UUID, role string, requestId string, restaurantId string) (response.ReservationResponse, error)
+
+	// JoinWaitlist puts a customer on the waitlist for a slot.
+	JoinWaitlist(waitlistRequest request.WaitlistRequest, userId uuid.UUID, requestId string, restaurantId string) (response.WaitlistEntryResponse, error)
+
+	// FindCustomerWaitlist retrieves a customer's waiting entries.
+	FindCustomerWaitlist(userId uuid.UUID, requestId string, restaurantId string) ([]response.WaitlistEntryResponse, error)
+
+	// LeaveWaitlist cancels one of a customer's waiting entries.
+	LeaveWaitlist(entryId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) error
+
+	// FindWaitlist retrieves the waiting entries of a YYYY-MM-DD date, today when empty.
+	FindWaitlist(date string, userId uuid.UUID, requestId string, restaurantId string) ([]response.WaitlistEntryResponse, error)
+}
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/realtime"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrInvalidReservationDate is returned when a date is not in YYYY-MM-DD form.
+	ErrInvalidReservationDate = errors.New("date must be in YYYY-MM-DD form")
+
+	// ErrPartyTooLarge is returned when a party is larger than the restaurant takes reservations for.
+	ErrPartyTooLarge = errors.New("party is too large to reserve, contact the restaurant")
+
+	// ErrNotBookableSlot is returned when a reservation does not start at a slot within the opening hours
+	// and the advance booking window.
+	ErrNotBookableSlot = errors.New("requested time is not a bookable slot")
+
+	// ErrUnknownReservationStatus is returned when reservations are filtered by a status that does not exist.
+	ErrUnknownReservationStatus = errors.New("unknown reservation status")
+)
+
+// reservationStatuses lists every reservation status.
+var reservationStatuses = []string{
+	model.ReservationStatusRequested, model.ReservationStatusConfirmed, model.ReservationStatusSeated,
+	model.ReservationStatusNoShow, model.ReservationStatusCancelled,
+}
+
+// ReservationServiceImpl provides the implementation for reservation-related operations.
+type ReservationServiceImpl struct {
+	ReservationsRepository repository.ReservationsRepository
+	FloorPlanRepository    repository.FloorPlanRepository
+	Cursors                *pagination.CursorSigner
+	Events                 realtime.Publisher
+}
+
+// NewReservationServiceImpl creates a new instance of ReservationServiceImpl.
+func NewReservationServiceImpl(reservationsRepository repository.ReservationsRepository, floorPlanRepository repository.FloorPlanRepository, cursors *pagination.CursorSigner, events realtime.Publisher) ReservationService {
+	return &ReservationServiceImpl{
+		ReservationsRepository: reservationsRepository,
+		FloorPlanRepository:    floorPlanRepository,
+		Cursors:                cursors,
+		Events:                 events,
+	}
+}
+
+// Availability lists the slots of a date and how many suitable tables are free at each.
+func (s *ReservationServiceImpl) Availability(date string, partySize int, requestID string, restaurantId string) (response.AvailabilityResponse, error) {
+	schedule, err := s.schedule(restaurantId)
+	if err != nil {
+		return response.AvailabilityResponse{}, err
+	}
+	day, err := schedule.parseDate(date)
+	if err != nil {
+		return response.AvailabilityResponse{}, ErrInvalidReservationDate
+	}
+	if partySize > schedule.settings.MaxPartySize {
+		return response.AvailabilityResponse{}, ErrPartyTooLarge
+	}
+
+	availability := response.AvailabilityResponse{
+		Date:      date,
+		Timezone:  schedule.settings.Timezone,
+		PartySize: partySize,
+		Slots:     []response.SlotResponse{},
+	}
+	now := time.Now()
+	var slots []time.Time
+	for _, slot := range schedule.slotsOn(day) {
+		if schedule.bookable(slot, now) {
+			slots = append(slots, slot)
+		}
+	}
+	if len(slots) == 0 {
+		return availability, nil
+	}
+
+	tables, err := s.fittingTables(restaurantId, partySize)
+	if err != nil {
+		return response.AvailabilityResponse{}, err
+	}
+	reservations, err := s.ReservationsRepository.FindOverlapping(restaurantId, slots[0], slots[len(slots)-1].Add(schedule.duration()), model.ReservationHoldingStatuses)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Err(err).
+			Msg("Error retrieving reservations for availability")
+		return response.AvailabilityResponse{}, err
+	}
+	for _, slot := range slots {
+		end := slot.Add(schedule.duration())
+		free := len(freeTables(tables, reservations, slot, end))
+		availability.Slots = append(availability.Slots, response.SlotResponse{
+			StartsAt:   slot,
+			EndsAt:     end,
+			Available:  free > 0,
+			FreeTables: free,
+		})
+	}
+	return availability, nil
+}
+
+// Book reserves the smallest free table that seats the party. Tables are locked while
+// booking, so two customers can never be given the same table at overlapping times.
+func (s *ReservationServiceImpl) Book(bookRequest request.BookReservationRequest, userId uuid.UUID, requestID string, restaurantId string) (response.ReservationResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Int("party_size", bookRequest.PartySize).
+		Time("starts_at", bookRequest.StartsAt).
+		Msg("Booking reservation")
+
+	reservation, tables, err := s.newReservation(bookRequest.PartySize, bookRequest.StartsAt, bookRequest.Notes, userId, restaurantId)
+	if err != nil {
+		return response.ReservationResponse{}, err
+	}
+	booked, err := s.ReservationsRepository.Book(reservation, tableIds(tables))
+	if err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Reservation not booked")
+		return response.ReservationResponse{}, err
+	}
+
+	publishReservationEvent(s.Events, realtime.EventReservationCreated, booked)
+	return toReservationResponse(booked, model.RoleCustomer), nil
+}
+
+// FindById retrieves a reservation as seen by the given role.
+func (s *ReservationServiceImpl) FindById(reservationId uuid.UUID, userId uuid.UUID, role string, requestID string, restaurantId string) (response.ReservationResponse, error) {
+	reservation, err := s.findReservation(reservationId, userId, role, restaurantId)
+	if err != nil {
+		return response.ReservationResponse{}, err
+	}
+	return toReservationResponse(reservation, role), nil
+}
+
+// FindByCustomer retrieves a page of a customer's reservations.
+func (s *ReservationServiceImpl) FindByCustomer(pageRequest pagination.Request, userId uuid.UUID, requestID string, restaurantId string) (response.ReservationListResponse, error) {
+	scope := "reservations:" + restaurantId + ":customer:" + userId.String()
+	page, err := s.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.ReservationListResponse{}, err
+	}
+
+	reservations, result, err := s.ReservationsRepository.FindByCustomer(userId, restaurantId, page)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving customer reservations")
+		return response.ReservationListResponse{}, err
+	}
+
+	reservationResponses := make([]response.ReservationResponse, 0, len(reservations))
+	keys := make([]pagination.Key, 0, len(reservations))
+	for _, reservation := range reservations {
+		reservationResponses = append(reservationResponses, toReservationResponse(reservation, model.RoleCustomer))
+		keys = append(keys, pagination.Key{CreatedAt: reservation.CreatedAt, ID: reservation.ID})
+	}
+	return response.ReservationListResponse{
+		Reservations: reservationResponses,
+		Pagination:   pageInfo(s.Cursors, scope, page, result, keys),
+	}, nil
+}
+
+// FindByDate retrieves the reservations overlapping a day in the restaurant's timezone.
+func (s *ReservationServiceImpl) FindByDate(date string, statuses []string, userId uuid.UUID, requestID string, restaurantId string) ([]response.ReservationResponse, error) {
+	for _, status := range statuses {
+		if !slices.Contains(reservationStatuses, status) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownReservationStatus, status)
+		}
+	}
+	from, to, err := s.day(date, restaurantId)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations, err := s.ReservationsRepository.FindOverlapping(restaurantId, from, to, statuses)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving reservations")
+		return nil, err
+	}
+	reservationResponses := make([]response.ReservationResponse, 0, len(reservations))
+	for _, reservation := range reservations {
+		reservationResponses = append(reservationResponses, toReservationResponse(reservation, model.RoleStaff))
+	}
+	return reservationResponses, nil
+}
+
+// Transition moves a reservation to another status if the state machine allows it for the
+// given role. A table freed by a cancellation or no-show is offered to the waitlist.
+func (s *ReservationServiceImpl) Transition(transitionRequest request.ReservationTransitionRequest, reservationId uuid.UUID, userId uuid.UUID, role string, requestID string, restaurantId string) (response.ReservationResponse, error) {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("reservation_id", reservationId.String()).
+		Str("status", transitionRequest.Status).
+		Msg("Changing reservation status")
+
+	reservation, err := s.findReservation(reservationId, userId, role, restaurantId)
+	if err != nil {
+		return response.ReservationResponse{}, err
+	}
+	if err := checkReservationTransition(reservation, transitionRequest.Status, role); err != nil {
+		log.Warn().
+			Str("request_id", requestID).
+			Str("reservation_id", reservationId.String()).
+			Err(err).
+			Msg("Reservation status change refused")
+		return response.ReservationResponse{}, err
+	}
+
+	fields := map[string]interface{}{
+		"Status":          transitionRequest.Status,
+		"StatusChangedAt": time.Now(),
+	}
+	if transitionRequest.Status == model.ReservationStatusCancelled {
+		fields["Reason"] = transitionRequest.Reason
+	}
+
+	updated, err := s.ReservationsRepository.Transition(reservationId, restaurantId, reservation.Status, fields)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("reservation_id", reservationId.String()).
+			Err(err).
+			Msg("Error changing reservation status")
+		return response.ReservationResponse{}, err
+	}
+	publishReservationEvent(s.Events, realtime.EventReservationUpdated, updated)
+
+	if !slices.Contains(model.ReservationHoldingStatuses, updated.Status) {
+		s.offerToWaitlist(updated, requestID)
+	}
+	return toReservationResponse(updated, role), nil
+}
+
+// JoinWaitlist puts a customer on the waitlist for a slot. The entry is booked
+// automatically when a suitable table frees up at that time.
+func (s *ReservationServiceImpl) JoinWaitlist(waitlistRequest request.WaitlistRequest, userId uuid.UUID, requestID string, restaurantId string) (response.WaitlistEntryResponse, error) {
+	schedule, err := s.schedule(restaurantId)
+	if err != nil {
+		return response.WaitlistEntryResponse{}, err
+	}
+	if waitlistRequest.PartySize > schedule.settings.MaxPartySize {
+		return response.WaitlistEntryResponse{}, ErrPartyTooLarge
+	}
+	if !schedule.isSlot(waitlistRequest.DesiredAt) || !schedule.bookable(waitlistRequest.DesiredAt, time.Now()) {
+		return response.WaitlistEntryResponse{}, ErrNotBookableSlot
+	}
+
+	entry, err := s.ReservationsRepository.CreateWaitlistEntry(model.WaitlistEntry{
+		RestaurantID: schedule.settings.RestaurantID,
+		CustomerID:   userId,
+		PartySize:    waitlistRequest.PartySize,
+		DesiredAt:    waitlistRequest.DesiredAt,
+		Status:       model.WaitlistStatusWaiting,
+		Notes:        waitlistRequest.Notes,
+	})
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error joining waitlist")
+		return response.WaitlistEntryResponse{}, err
+	}
+	return toWaitlistEntryResponse(entry), nil
+}
+
+// FindCustomerWaitlist retrieves a customer's waiting entries.
+func (s *ReservationServiceImpl) FindCustomerWaitlist(userId uuid.UUID, requestID string, restaurantId string) ([]response.WaitlistEntryResponse, error) {
+	entries, err := s.ReservationsRepository.FindWaitlistByCustomer(userId, restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	return toWaitlistEntryResponses(entries), nil
+}
+
+// LeaveWaitlist cancels one of a customer's waiting entries.
+func (s *ReservationServiceImpl) LeaveWaitlist(entryId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) error {
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("entry_id", entryId.String()).
+		Msg("Leaving waitlist")
+	return s.ReservationsRepository.CancelWaitlistEntry(entryId, userId, restaurantId)
+}
+
+// FindWaitlist retrieves the waiting entries desired on a day in the restaurant's timezone.
+func (s *ReservationServiceImpl) FindWaitlist(date string, userId uuid.UUID, requestID string, restaurantId string) ([]response.WaitlistEntryResponse, error) {
+	from, to, err := s.day(date, restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.ReservationsRepository.FindWaitlist(restaurantId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return toWaitlistEntryResponses(entries), nil
+}
+
+// offerToWaitlist books the waiting entries whose time overlaps a freed reservation,
+// oldest first, for as long as suitable tables remain free. Failures are logged; the
+// status change that freed the table has already been made.
+func (s *ReservationServiceImpl) offerToWaitlist(freed model.Reservation, requestID string) {
+	restaurantId := freed.RestaurantID.String()
+	schedule, err := s.schedule(restaurantId)
+	if err != nil {
+		return
+	}
+	from := freed.StartsAt.Add(-schedule.duration())
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	entries, err := s.ReservationsRepository.FindWaitlist(restaurantId, from, freed.EndsAt)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		reservation, tables, err := s.newReservation(entry.PartySize, entry.DesiredAt, entry.Notes, entry.CustomerID, restaurantId)
+		if err != nil {
+			continue
+		}
+		booked, err := s.ReservationsRepository.BookWaitlistEntry(entry.ID, reservation, tableIds(tables))
+		if err != nil {
+			if !errors.Is(err, repository.ErrNoTableAvailable) && !errors.Is(err, repository.ErrWaitlistEntryTaken) {
+				log.Error().
+					Str("request_id", requestID).
+					Str("entry_id", entry.ID.String()).
+					Err(err).
+					Msg("Error booking waitlist entry")
+			}
+			continue
+		}
+		log.Info().
+			Str("request_id", requestID).
+			Str("entry_id", entry.ID.String()).
+			Str("reservation_id", booked.ID.String()).
+			Msg("Waitlist entry booked")
+		publishReservationEvent(s.Events, realtime.EventReservationCreated, booked)
+	}
+}
+
+// newReservation validates a requested reservation against the restaurant's schedule and
+// returns it with the tables that could seat it, in the order they should be tried.
+func (s *ReservationServiceImpl) newReservation(partySize int, startsAt time.Time, notes string, customerId uuid.UUID, restaurantId string) (model.Reservation, []model.DiningTable, error) {
+	schedule, err := s.schedule(restaurantId)
+	if err != nil {
+		return model.Reservation{}, nil, err
+	}
+	if partySize > schedule.settings.MaxPartySize {
+		return model.Reservation{}, nil, ErrPartyTooLarge
+	}
+	if !schedule.isSlot(startsAt) || !schedule.bookable(startsAt, time.Now()) {
+		return model.Reservation{}, nil, ErrNotBookableSlot
+	}
+	tables, err := s.fittingTables(restaurantId, partySize)
+	if err != nil {
+		return model.Reservation{}, nil, err
+	}
+	if len(tables) == 0 {
+		return model.Reservation{}, nil, repository.ErrNoTableAvailable
+	}
+
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return model.Reservation{}, nil, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	return model.Reservation{
+		RestaurantID:    restaurantUUID,
+		CustomerID:      customerId,
+		PartySize:       partySize,
+		StartsAt:        startsAt.UTC(),
+		EndsAt:          startsAt.Add(schedule.duration()).UTC(),
+		Status:          model.ReservationStatusRequested,
+		Notes:           notes,
+		StatusChangedAt: time.Now(),
+	}, tables, nil
+}
+
+// schedule loads the restaurant's reservation schedule.
+func (s *ReservationServiceImpl) schedule(restaurantId string) (reservationSchedule, error) {
+	settings, hours, err := s.FloorPlanRepository.FindSchedule(restaurantId)
+	if err != nil {
+		return reservationSchedule{}, err
+	}
+	if settings.RestaurantID == uuid.Nil {
+		if settings.RestaurantID, err = uuid.Parse(restaurantId); err != nil {
+			return reservationSchedule{}, fmt.Errorf("invalid restaurant ID: %w", err)
+		}
+	}
+	return newReservationSchedule(settings, hours)
+}
+
+// fittingTables lists the restaurant's active tables that seat a party, in booking order.
+func (s *ReservationServiceImpl) fittingTables(restaurantId string, partySize int) ([]model.DiningTable, error) {
+	tables, err := s.FloorPlanRepository.FindTables(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	return fittingTables(tables, partySize), nil
+}
+
+// day returns the bounds of a YYYY-MM-DD date, today when empty, in the restaurant's timezone.
+func (s *ReservationServiceImpl) day(date string, restaurantId string) (time.Time, time.Time, error) {
+	schedule, err := s.schedule(restaurantId)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from := schedule.today(time.Now())
+	if date != "" {
+		if from, err = schedule.parseDate(date); err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidReservationDate
+		}
+	}
+	return from, from.AddDate(0, 0, 1), nil
+}
+
+// findReservation retrieves a reservation; customers can only retrieve their own.
+func (s *ReservationServiceImpl) findReservation(reservationId uuid.UUID, userId uuid.UUID, role string, restaurantId string) (model.Reservation, error) {
+	if role == model.RoleCustomer {
+		return s.ReservationsRepository.FindCustomerReservation(reservationId, userId, restaurantId)
+	}
+	return s.ReservationsRepository.FindById(reservationId, restaurantId)
+}
+
+// toReservationResponse converts a reservation to its response as seen by the given role.
+func toReservationResponse(reservation model.Reservation, role string) response.ReservationResponse {
+	return response.ReservationResponse{
+		ID:              reservation.ID,
+		CustomerID:      reservation.CustomerID,
+		TableID:         reservation.TableID,
+		PartySize:       reservation.PartySize,
+		StartsAt:        reservation.StartsAt,
+		EndsAt:          reservation.EndsAt,
+		Status:          reservation.Status,
+		Notes:           reservation.Notes,
+		Reason:          reservation.Reason,
+		StatusChangedAt: reservation.StatusChangedAt,
+		NextStatuses:    nextReservationStatuses(reservation, role),
+	}
+}
+
+// toWaitlistEntryResponses converts waitlist entries to their responses.
+func toWaitlistEntryResponses(entries []model.WaitlistEntry) []response.WaitlistEntryResponse {
+	entryResponses := make([]response.WaitlistEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		entryResponses = append(entryResponses, toWaitlistEntryResponse(entry))
+	}
+	return entryResponses
+}
+
+// toWaitlistEntryResponse converts a waitlist entry to its response.
+func toWaitlistEntryResponse(entry model.WaitlistEntry) response.WaitlistEntryResponse {
+	return response.WaitlistEntryResponse{
+		ID:            entry.ID,
+		CustomerID:    entry.CustomerID,
+		PartySize:     entry.PartySize,
+		DesiredAt:     entry.DesiredAt,
+		Status:        entry.Status,
+		Notes:         entry.Notes,
+		ReservationID: entry.ReservationID,
+		CreatedAt:     entry.CreatedAt,
+	}
+}
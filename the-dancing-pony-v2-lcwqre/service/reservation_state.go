@@ -0,0 +1,45 @@
+package service
+
+import (
+	"errors"
+
+	"the-dancing-pony-v2-lcwqre/model"
+)
+
+var (
+	// ErrInvalidReservationTransition is returned when a reservation cannot move from its status to the requested one.
+	ErrInvalidReservationTransition = errors.New("reservation cannot move to the requested status")
+
+	// ErrReservationTransitionForbidden is returned when the caller's role may not make a valid transition.
+	ErrReservationTransitionForbidden = errors.New("not allowed to move the reservation to the requested status")
+)
+
+// reservationStates is the reservation state machine. Cancelled and no-show reservations
+// free their table.
+var reservationStates = stateMachine[model.Reservation]{
+	noun:   "reservation",
+	status: func(reservation model.Reservation) string { return reservation.Status },
+	transitions: map[string][]transition[model.Reservation]{
+		model.ReservationStatusRequested: {
+			{to: model.ReservationStatusConfirmed, roles: []string{model.RoleStaff}},
+			{to: model.ReservationStatusCancelled, roles: []string{model.RoleCustomer, model.RoleStaff}},
+		},
+		model.ReservationStatusConfirmed: {
+			{to: model.ReservationStatusSeated, roles: []string{model.RoleStaff}},
+			{to: model.ReservationStatusNoShow, roles: []string{model.RoleStaff}},
+			{to: model.ReservationStatusCancelled, roles: []string{model.RoleCustomer, model.RoleStaff}},
+		},
+	},
+	invalid:   ErrInvalidReservationTransition,
+	forbidden: ErrReservationTransitionForbidden,
+}
+
+// checkReservationTransition reports whether role may move the reservation to the given status.
+func checkReservationTransition(reservation model.Reservation, to string, role string) error {
+	return reservationStates.check(reservation, to, role)
+}
+
+// nextReservationStatuses lists the statuses role may move the reservation to.
+func nextReservationStatuses(reservation model.Reservation, role string) []string {
+	return reservationStates.next(reservation, role)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"the-dancing-pony-v2-lcwqre/model"
+)
+
+func TestCheckReservationTransition(t *testing.T) {
+	const (
+		staff    = model.RoleStaff
+		customer = model.RoleCustomer
+	)
+	tests := []struct {
+		from    string
+		to      string
+		role    string
+		wantErr error
+	}{
+		{from: model.ReservationStatusRequested, to: model.ReservationStatusConfirmed, role: staff},
+		{from: model.ReservationStatusRequested, to: model.ReservationStatusConfirmed, role: customer, wantErr: ErrReservationTransitionForbidden},
+		{from: model.ReservationStatusRequested, to: model.ReservationStatusCancelled, role: customer},
+		{from: model.ReservationStatusRequested, to: model.ReservationStatusSeated, role: staff, wantErr: ErrInvalidReservationTransition},
+		{from: model.ReservationStatusConfirmed, to: model.ReservationStatusSeated, role: staff},
+		{from: model.ReservationStatusConfirmed, to: model.ReservationStatusNoShow, role: staff},
+		{from: model.ReservationStatusConfirmed, to: model.ReservationStatusNoShow, role: customer, wantErr: ErrReservationTransitionForbidden},
+		{from: model.ReservationStatusConfirmed, to: model.ReservationStatusCancelled, role: customer},
+		{from: model.ReservationStatusSeated, to: model.ReservationStatusCancelled, role: staff, wantErr: ErrInvalidReservationTransition},
+		{from: model.ReservationStatusCancelled, to: model.ReservationStatusConfirmed, role: staff, wantErr: ErrInvalidReservationTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.from+" to "+tt.to, func(t *testing.T) {
+			reservation := model.Reservation{Status: tt.from}
+			err := checkReservationTransition(reservation, tt.to, tt.role)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("checkReservationTransition() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkReservationTransition() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextReservationStatuses(t *testing.T) {
+	tests := []struct {
+		status string
+		role   string
+		want   []string
+	}{
+		{status: model.ReservationStatusRequested, role: model.RoleStaff, want: []string{model.ReservationStatusConfirmed, model.ReservationStatusCancelled}},
+		{status: model.ReservationStatusConfirmed, role: model.RoleCustomer, want: []string{model.ReservationStatusCancelled}},
+		{status: model.ReservationStatusSeated, role: model.RoleStaff, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.status, func(t *testing.T) {
+			reservation := model.Reservation{Status: tt.status}
+			if got := nextReservationStatuses(reservation, tt.role); !slices.Equal(got, tt.want) {
+				t.Errorf("nextReservationStatuses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"the-dancing-pony-v2-lcwqre/data/request"
 	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/pagination"
 
 	"github.com/google/uuid"
 )
@@ -15,15 +16,15 @@ type RestaurantsService interface {
 	// Update modifies an existing restaurant and returns the updated restaurant.
 	Update(restaurantUpdateRequest request.UpdateRestaurantRequest) (response.RestaurantResponse, error)
 
-	// Delete removes a restaurant by its ID.
-	Delete(restaurantId uuid.UUID) error
+	// Delete removes a restaurant by its ID, optionally only at one of the given versions.
+	Delete(restaurantId uuid.UUID, versions []int64) error
 
-	// FindAll retrieves a list of all restaurants with pagination.
-	FindAll(page, limit int) (response.RestaurantListResponse, error)
+	// FindAll retrieves a page of restaurants.
+	FindAll(page pagination.Request) (response.RestaurantListResponse, error)
 
 	// FindById retrieves a specific restaurant by its ID.
 	FindById(restaurantId uuid.UUID) (response.RestaurantResponse, error)
 
-	// Search searches for restaurants based on a search term with pagination.
-	Search(searchTerm string, page, limit int) (response.RestaurantListResponse, error)
+	// Search searches for a page of restaurants based on a search term.
+	Search(searchTerm string, page pagination.Request) (response.RestaurantListResponse, error)
 }
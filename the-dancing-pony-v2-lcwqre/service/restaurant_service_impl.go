@@ -2,14 +2,13 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
 
 	cache "the-dancing-pony-v2-lcwqre/caching"
 	"the-dancing-pony-v2-lcwqre/data/request"
 	"the-dancing-pony-v2-lcwqre/data/response"
 	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
 	"the-dancing-pony-v2-lcwqre/repository"
 
 	"github.com/go-playground/validator/v10"
@@ -21,13 +20,17 @@ import (
 type RestaurantsServiceImpl struct {
 	RestaurantsRepository repository.RestaurantsRepository
 	Validate              *validator.Validate
+	Cache                 cache.Cache
+	Cursors               *pagination.CursorSigner
 }
 
 // NewRestaurantsServiceImpl creates a new instance of RestaurantsServiceImpl.
-func NewRestaurantsServiceImpl(restaurantsRepository repository.RestaurantsRepository, validate *validator.Validate) RestaurantsService {
+func NewRestaurantsServiceImpl(restaurantsRepository repository.RestaurantsRepository, validate *validator.Validate, restaurantCache cache.Cache, cursors *pagination.CursorSigner) RestaurantsService {
 	return &RestaurantsServiceImpl{
 		RestaurantsRepository: restaurantsRepository,
 		Validate:              validate,
+		Cache:                 restaurantCache,
+		Cursors:               cursors,
 	}
 }
 
@@ -62,6 +65,7 @@ func (s *RestaurantsServiceImpl) Create(restaurantRequest request.CreateRestaura
 	}
 
 	restaurantResponse := toRestaurantResponse(createdRestaurant)
+	s.invalidateCache()
 	log.Info().
 		Str("request_id", requestID).
 		Msg("Restaurant created successfully")
@@ -69,75 +73,45 @@ func (s *RestaurantsServiceImpl) Create(restaurantRequest request.CreateRestaura
 }
 
 // Delete removes a restaurant by ID.
-func (s *RestaurantsServiceImpl) Delete(restaurantId uuid.UUID) error {
-	if err := s.RestaurantsRepository.Delete(restaurantId); err != nil {
+func (s *RestaurantsServiceImpl) Delete(restaurantId uuid.UUID, versions []int64) error {
+	if err := s.RestaurantsRepository.Delete(restaurantId, versions); err != nil {
 		log.Error().
 			Str("restaurant_id", restaurantId.String()).
 			Err(err).
 			Msg("Error deleting restaurant")
 		return err
 	}
+	s.invalidateCache()
 	log.Info().
 		Str("restaurant_id", restaurantId.String()).
 		Msg("Restaurant deleted successfully")
 	return nil
 }
 
-// FindAll retrieves restaurants with pagination, using cache if available.
-func (s *RestaurantsServiceImpl) FindAll(page, limit int) (response.RestaurantListResponse, error) {
-	cacheKey := fmt.Sprintf("all_restaurants_page_%d_limit_%d", page, limit)
-
-	// Attempt to retrieve cached data
-	cachedData, err := cache.RedisClient.Get(context.Background(), cacheKey).Result()
-	if err == nil {
-		var restaurantResponses []response.RestaurantResponse
-		if err := json.Unmarshal([]byte(cachedData), &restaurantResponses); err != nil {
-			log.Error().
-				Err(err).
-				Msg("Error unmarshalling cached restaurants")
-			return response.RestaurantListResponse{}, err
-		}
-		log.Info().
-			Msg("Restaurants retrieved from cache")
-		return response.RestaurantListResponse{Restaurants: restaurantResponses}, nil
-	}
-
-	// Fetch restaurants from repository with pagination
-	restaurants, total, err := s.RestaurantsRepository.FindAll(page, limit)
+// FindAll retrieves a page of restaurants, using cache if available.
+func (s *RestaurantsServiceImpl) FindAll(pageRequest pagination.Request) (response.RestaurantListResponse, error) {
+	scope := "restaurants"
+	page, err := s.Cursors.Page(pageRequest, scope)
 	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("Error retrieving restaurants from repository")
 		return response.RestaurantListResponse{}, err
 	}
 
-	var restaurantResponses []response.RestaurantResponse
-	for _, restaurant := range restaurants {
-		restaurantResponses = append(restaurantResponses, toRestaurantResponse(restaurant))
-	}
-
-	// Cache the fetched restaurants
-	restaurantsJSON, err := json.Marshal(restaurantResponses)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("Error marshalling restaurants for cache")
-		return response.RestaurantListResponse{}, err
+	entry := cache.Entry{
+		Namespace: cache.RestaurantsNamespace,
+		Type:      cache.RestaurantList,
+		Key:       pageCacheKey(page, pageRequest.Cursor, ""),
 	}
-
-	cache.RedisClient.Set(context.Background(), cacheKey, restaurantsJSON, 10*time.Minute) // Increased cache duration
-	log.Info().
-		Msg("Restaurants cached successfully")
-
-	// Calculate total pages
-	totalPages := (total + limit - 1) / limit // This calculates the ceiling of total/limit
-
-	return response.RestaurantListResponse{
-		Restaurants: restaurantResponses,
-		CurrentPage: page,
-		TotalPages:  totalPages,
-		TotalItems:  total,
-	}, nil
+	return cache.FetchJSON(context.Background(), s.Cache, entry, func() (response.RestaurantListResponse, error) {
+		// Fetch restaurants from repository with pagination
+		restaurants, result, err := s.RestaurantsRepository.FindAll(page)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Msg("Error retrieving restaurants from repository")
+			return response.RestaurantListResponse{}, err
+		}
+		return s.toRestaurantListResponse(restaurants, scope, page, result), nil
+	})
 }
 
 // FindById retrieves a restaurant by its ID.
@@ -149,14 +123,34 @@ func (s *RestaurantsServiceImpl) FindById(restaurantId uuid.UUID) (response.Rest
 	return toRestaurantResponse(restaurant), nil
 }
 
-// Update modifies an existing restaurant.
+// Update applies a merge patch to a restaurant. Members absent from the patch are left unchanged.
 func (s *RestaurantsServiceImpl) Update(restaurantUpdateRequest request.UpdateRestaurantRequest) (response.RestaurantResponse, error) {
-	restaurant, err := s.FindRestaurantById(restaurantUpdateRequest.ID)
-	if err != nil {
-		return response.RestaurantResponse{}, err
+	if restaurantUpdateRequest.IsEmpty() {
+		restaurant, err := s.FindRestaurantById(restaurantUpdateRequest.ID)
+		if err != nil {
+			return response.RestaurantResponse{}, err
+		}
+		if !versionMatches(restaurant.Version, restaurantUpdateRequest.Versions) {
+			return response.RestaurantResponse{}, repository.ErrVersionMismatch
+		}
+		return toRestaurantResponse(restaurant), nil
+	}
+
+	fields := make(map[string]interface{})
+	if restaurantUpdateRequest.Name.Set {
+		fields["Name"] = restaurantUpdateRequest.Name.Value
+	}
+	if restaurantUpdateRequest.Description.Set {
+		fields["Description"] = restaurantUpdateRequest.Description.Value
+	}
+	if restaurantUpdateRequest.Location.Set {
+		fields["Location"] = restaurantUpdateRequest.Location.Value
+	}
+	if restaurantUpdateRequest.ImageUrl.Set {
+		fields["ImageUrl"] = restaurantUpdateRequest.ImageUrl.Value
 	}
 
-	updatedRestaurant, err := s.RestaurantsRepository.Update(restaurant)
+	updatedRestaurant, err := s.RestaurantsRepository.Update(restaurantUpdateRequest.ID, fields, restaurantUpdateRequest.Versions)
 	if err != nil {
 		log.Error().
 			Str("restaurant_id", restaurantUpdateRequest.ID.String()).
@@ -164,6 +158,7 @@ func (s *RestaurantsServiceImpl) Update(restaurantUpdateRequest request.UpdateRe
 			Msg("Error updating restaurant")
 		return response.RestaurantResponse{}, fmt.Errorf("error updating restaurant: %w", err)
 	}
+	s.invalidateCache()
 
 	return toRestaurantResponse(updatedRestaurant), nil
 }
@@ -181,59 +176,51 @@ func (s *RestaurantsServiceImpl) FindRestaurantById(restaurantId uuid.UUID) (mod
 	return restaurant, nil
 }
 
-// Search searches for restaurants matching the search term with pagination and caching.
-func (s *RestaurantsServiceImpl) Search(searchTerm string, page, limit int) (response.RestaurantListResponse, error) {
-	// Create a cache key based on search term and pagination parameters
-	cacheKey := fmt.Sprintf("search_%s_page_%d_limit_%d", searchTerm, page, limit)
-
-	// Attempt to retrieve cached data
-	cachedData, err := cache.RedisClient.Get(context.Background(), cacheKey).Result()
-	if err == nil {
-		var cachedResponse response.RestaurantListResponse
-		if err := json.Unmarshal([]byte(cachedData), &cachedResponse); err != nil {
-			log.Error().
-				Err(err).
-				Msg("Error unmarshalling cached search results")
-			return response.RestaurantListResponse{}, err
-		}
-		log.Info().
-			Msg("Search results retrieved from cache")
-		return cachedResponse, nil
-	}
-
-	// Fetch paginated search results from the repository
-	restaurants, total, err := s.RestaurantsRepository.Search(searchTerm, page, limit)
+// Search searches for a page of restaurants matching the search term, using cache if available.
+func (s *RestaurantsServiceImpl) Search(searchTerm string, pageRequest pagination.Request) (response.RestaurantListResponse, error) {
+	scope := "restaurants:search:" + searchTerm
+	page, err := s.Cursors.Page(pageRequest, scope)
 	if err != nil {
 		return response.RestaurantListResponse{}, err
 	}
 
-	// Convert model.Restaurant to response.RestaurantResponse
-	var restaurantResponses []response.RestaurantResponse
+	entry := cache.Entry{
+		Namespace: cache.RestaurantsNamespace,
+		Type:      cache.RestaurantSearch,
+		Key:       pageCacheKey(page, pageRequest.Cursor, searchTerm),
+	}
+	return cache.FetchJSON(context.Background(), s.Cache, entry, func() (response.RestaurantListResponse, error) {
+		// Fetch paginated search results from the repository
+		restaurants, result, err := s.RestaurantsRepository.Search(searchTerm, page)
+		if err != nil {
+			return response.RestaurantListResponse{}, err
+		}
+		return s.toRestaurantListResponse(restaurants, scope, page, result), nil
+	})
+}
+
+// toRestaurantListResponse converts a page of restaurants to its response.
+func (s *RestaurantsServiceImpl) toRestaurantListResponse(restaurants []model.Restaurant, scope string, page pagination.Page, result pagination.Result) response.RestaurantListResponse {
+	restaurantResponses := make([]response.RestaurantResponse, 0, len(restaurants))
+	keys := make([]pagination.Key, 0, len(restaurants))
 	for _, restaurant := range restaurants {
 		restaurantResponses = append(restaurantResponses, toRestaurantResponse(restaurant))
+		keys = append(keys, pagination.Key{CreatedAt: restaurant.CreatedAt, ID: restaurant.ID})
 	}
-
-	// Construct response with pagination details
-	searchResponse := response.RestaurantListResponse{
+	return response.RestaurantListResponse{
 		Restaurants: restaurantResponses,
-		CurrentPage: page,
-		TotalPages:  calculateTotalPages(total, limit), // Calculate total pages based on total items
-		TotalItems:  total,
+		Pagination:  pageInfo(s.Cursors, scope, page, result, keys),
 	}
+}
 
-	// Cache the results
-	restaurantsJSON, err := json.Marshal(searchResponse)
-	if err != nil {
+// invalidateCache drops every cached restaurant list and search entry.
+// A failure is logged; the entries then expire with their TTL.
+func (s *RestaurantsServiceImpl) invalidateCache() {
+	if err := s.Cache.Invalidate(context.Background(), cache.RestaurantsNamespace); err != nil {
 		log.Error().
 			Err(err).
-			Msg("Error marshalling search results for cache")
-		return response.RestaurantListResponse{}, err
+			Msg("Error invalidating restaurant cache")
 	}
-	cache.RedisClient.Set(context.Background(), cacheKey, restaurantsJSON, 10*time.Minute) // Adjust cache duration as needed
-	log.Info().
-		Msg("Search results cached successfully")
-
-	return searchResponse, nil
 }
 
 // Helper function to convert model.Restaurant to response.RestaurantResponse
@@ -244,5 +231,6 @@ func toRestaurantResponse(restaurant model.Restaurant) response.RestaurantRespon
 		Description: restaurant.Description,
 		Location:    restaurant.Location,
 		ImageUrl:    restaurant.ImageUrl,
+		Version:     restaurant.Version,
 	}
 }
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+)
+
+// transition is a status a resource can move to and the roles allowed to move it there.
+type transition[T any] struct {
+	to    string
+	roles []string
+	when  func(T) bool // Only for resources it holds for, when set
+}
+
+// stateMachine holds the transitions out of each status of a resource such as an order.
+// Statuses without transitions are final.
+type stateMachine[T any] struct {
+	noun        string // Names the resource in errors
+	status      func(T) string
+	transitions map[string][]transition[T]
+	invalid     error // Wrapped when the resource cannot move to a status
+	forbidden   error // Wrapped when the resource can, but not by the caller's role
+}
+
+// check reports whether role may move the resource to the given status.
+func (m stateMachine[T]) check(resource T, to string, role string) error {
+	from := m.status(resource)
+	for _, transition := range m.transitions[from] {
+		if transition.to != to || (transition.when != nil && !transition.when(resource)) {
+			continue
+		}
+		if !slices.Contains(transition.roles, role) {
+			return fmt.Errorf("%w: %s cannot move a %s %s to %s", m.forbidden, role, from, m.noun, to)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: a %s %s cannot move to %s", m.invalid, from, m.noun, to)
+}
+
+// next lists the statuses role may move the resource to.
+func (m stateMachine[T]) next(resource T, role string) []string {
+	next := []string{}
+	for _, transition := range m.transitions[m.status(resource)] {
+		if m.check(resource, transition.to, role) == nil {
+			next = append(next, transition.to)
+		}
+	}
+	return next
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"math"
+	"slices"
+
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+)
+
+// The tax engine works out the tax of an order from the tax settings of its restaurant.
+// Every amount is handled in cents so that the figures printed on a receipt always add
+// up. The rules are:
+//
+//  1. Each line is taxed at the rate whose categories include the dish's category, or at
+//     the default rate, the one without categories.
+//  2. The discounts of the order (promotions, then the loyalty reward) are spread over
+//     the lines in proportion to their amounts. Each line first gets its share rounded
+//     down to the cent; the cents left over go one each to the lines with the largest
+//     remainders, the earliest line first on a tie.
+//  3. The discounted amounts are added up per rate and tax is computed once per rate, not
+//     per line, so rounding happens at most once for each rate of the order.
+//  4. Rates are held in hundredths of a percent and tax is rounded half away from zero,
+//     using integer arithmetic only:
+//     - when prices include tax, tax = gross × rate / (100 + rate) and net = gross − tax;
+//     - when prices exclude tax, tax = net × rate / 100 and gross = net + tax.
+//  5. The total of the order is the sum of the gross amounts of its rates.
+
+// taxableLine is a line of an order or cart as the tax engine sees it.
+type taxableLine struct {
+	Category string
+	Amount   int64 // Price times quantity, in cents, before the discounts of the order
+}
+
+// taxComputation is the tax of a set of lines.
+type taxComputation struct {
+	Lines     []model.TaxLine // One per rate used, in the order of the settings
+	LineRates []model.TaxRate // Rate applied to each line
+	Tax       int64           // In cents
+	Gross     int64           // Amount due, in cents
+}
+
+// taxRateFor returns the rate that applies to dishes of a category.
+func taxRateFor(settings model.TaxSettings, category string) model.TaxRate {
+	category = normalizeLabel(category)
+	var fallback model.TaxRate
+	for _, rate := range settings.Rates {
+		if len(rate.Categories) == 0 {
+			fallback = rate
+		} else if slices.Contains(rate.Categories, category) {
+			return rate
+		}
+	}
+	return fallback
+}
+
+// computeTax applies the tax settings to lines whose amounts add up to more than the
+// discount, which is spread over them first.
+func computeTax(settings model.TaxSettings, lines []taxableLine, discount int64) taxComputation {
+	shares := allocateDiscount(lines, discount)
+
+	computation := taxComputation{LineRates: make([]model.TaxRate, len(lines))}
+	bases := make(map[string]int64)
+	for i, line := range lines {
+		rate := taxRateFor(settings, line.Category)
+		computation.LineRates[i] = rate
+		bases[rate.Name] += line.Amount - shares[i]
+	}
+
+	for _, rate := range settings.Rates {
+		base, ok := bases[rate.Name]
+		if !ok {
+			continue
+		}
+		basisPoints := int64(math.Round(rate.Rate * 100))
+		var net, tax, gross int64
+		if settings.PricesIncludeTax {
+			gross = base
+			tax = divideRounded(gross*basisPoints, 10000+basisPoints)
+			net = gross - tax
+		} else {
+			net = base
+			tax = divideRounded(net*basisPoints, 10000)
+			gross = net + tax
+		}
+		computation.Lines = append(computation.Lines, model.TaxLine{
+			Name:  rate.Name,
+			Rate:  rate.Rate,
+			Net:   fromMinorUnits(net),
+			Tax:   fromMinorUnits(tax),
+			Gross: fromMinorUnits(gross),
+		})
+		computation.Tax += tax
+		computation.Gross += gross
+	}
+	return computation
+}
+
+// allocateDiscount spreads a discount over lines in proportion to their amounts, giving
+// the cents lost to rounding down to the lines with the largest remainders.
+func allocateDiscount(lines []taxableLine, discount int64) []int64 {
+	shares := make([]int64, len(lines))
+	var total int64
+	for _, line := range lines {
+		total += line.Amount
+	}
+	if discount <= 0 || total <= 0 {
+		return shares
+	}
+	discount = min(discount, total)
+
+	remainders := make([]int64, len(lines))
+	left := discount
+	for i, line := range lines {
+		shares[i] = discount * line.Amount / total
+		remainders[i] = discount * line.Amount % total
+		left -= shares[i]
+	}
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case remainders[a] > remainders[b]:
+			return -1
+		case remainders[a] < remainders[b]:
+			return 1
+		}
+		return 0
+	})
+	for _, i := range order[:left] {
+		shares[i]++
+	}
+	return shares
+}
+
+// divideRounded divides two non-negative integers, rounding half away from zero.
+func divideRounded(numerator int64, denominator int64) int64 {
+	return (2*numerator + denominator) / (2 * denominator)
+}
+
+// taxableLines returns the lines of an order with the categories of their dishes.
+func taxableLines(items []model.OrderItem, dishes []model.Dish) []taxableLine {
+	categories := make(map[uuid.UUID]string, len(dishes))
+	for _, dish := range dishes {
+		categories[dish.ID] = dish.Category
+	}
+	lines := make([]taxableLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, taxableLine{
+			Category: categories[item.DishID],
+			Amount:   toMinorUnits(item.UnitPrice * float64(item.Quantity)),
+		})
+	}
+	return lines
+}
+
+// applyOrderTax taxes a new order once its promotions and reward are applied, recording
+// the rate of each item and the tax of each rate. Orders of restaurants without tax
+// settings are left untaxed.
+func applyOrderTax(taxes repository.TaxRepository, order *model.Order, dishes []model.Dish, restaurantId string) error {
+	settings, err := taxes.FindSettings(restaurantId)
+	if err != nil {
+		return err
+	}
+	if settings.ID == uuid.Nil {
+		return nil
+	}
+
+	lines := taxableLines(order.Items, dishes)
+	var subtotal int64
+	for _, line := range lines {
+		subtotal += line.Amount
+	}
+	computation := computeTax(settings, lines, subtotal-toMinorUnits(order.Total))
+	for i, rate := range computation.LineRates {
+		order.Items[i].TaxName = rate.Name
+		order.Items[i].TaxRate = rate.Rate
+	}
+	order.PricesIncludeTax = settings.PricesIncludeTax
+	order.TaxLines = computation.Lines
+	order.Tax = fromMinorUnits(computation.Tax)
+	order.Total = fromMinorUnits(computation.Gross)
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"the-dancing-pony-v2-lcwqre/model"
+)
+
+func TestComputeTax(t *testing.T) {
+	standard := model.TaxRate{Name: "Standard", Rate: 20}
+	reduced := model.TaxRate{Name: "Reduced", Rate: 5, Categories: []string{"drinks"}}
+
+	tests := []struct {
+		name      string
+		settings  model.TaxSettings
+		lines     []taxableLine
+		discount  int64
+		wantTax   int64
+		wantGross int64
+		wantLines []model.TaxLine
+	}{
+		{
+			name:      "exclusive prices add tax on top",
+			settings:  model.TaxSettings{Rates: model.JSONList[model.TaxRate]{standard}},
+			lines:     []taxableLine{{Amount: 1000}},
+			wantTax:   200,
+			wantGross: 1200,
+			wantLines: []model.TaxLine{{Name: "Standard", Rate: 20, Net: 10, Tax: 2, Gross: 12}},
+		},
+		{
+			name:      "inclusive prices take tax out",
+			settings:  model.TaxSettings{PricesIncludeTax: true, Rates: model.JSONList[model.TaxRate]{standard}},
+			lines:     []taxableLine{{Amount: 1000}},
+			wantTax:   167,
+			wantGross: 1000,
+			wantLines: []model.TaxLine{{Name: "Standard", Rate: 20, Net: 8.33, Tax: 1.67, Gross: 10}},
+		},
+		{
+			name:      "half a cent rounds up",
+			settings:  model.TaxSettings{Rates: model.JSONList[model.TaxRate]{{Name: "Standard", Rate: 5}}},
+			lines:     []taxableLine{{Amount: 10}},
+			wantTax:   1,
+			wantGross: 11,
+		},
+		{
+			name:      "less than half a cent rounds down",
+			settings:  model.TaxSettings{Rates: model.JSONList[model.TaxRate]{{Name: "Standard", Rate: 5}}},
+			lines:     []taxableLine{{Amount: 9}},
+			wantTax:   0,
+			wantGross: 9,
+		},
+		{
+			name:      "rounded once per rate, not per line",
+			settings:  model.TaxSettings{Rates: model.JSONList[model.TaxRate]{{Name: "Standard", Rate: 5}}},
+			lines:     []taxableLine{{Amount: 10}, {Amount: 10}, {Amount: 10}},
+			wantTax:   2,
+			wantGross: 32,
+		},
+		{
+			name:      "rate with two decimals",
+			settings:  model.TaxSettings{Rates: model.JSONList[model.TaxRate]{{Name: "Sales", Rate: 7.25}}},
+			lines:     []taxableLine{{Amount: 1999}},
+			wantTax:   145,
+			wantGross: 2144,
+		},
+		{
+			name:      "categories pick their rate",
+			settings:  model.TaxSettings{Rates: model.JSONList[model.TaxRate]{standard, reduced}},
+			lines:     []taxableLine{{Category: " Drinks ", Amount: 500}, {Category: "mains", Amount: 1000}},
+			wantTax:   225,
+			wantGross: 1725,
+			wantLines: []model.TaxLine{
+				{Name: "Standard", Rate: 20, Net: 10, Tax: 2, Gross: 12},
+				{Name: "Reduced", Rate: 5, Net: 5, Tax: 0.25, Gross: 5.25},
+			},
+		},
+		{
+			name:      "discount is taken off before tax",
+			settings:  model.TaxSettings{PricesIncludeTax: true, Rates: model.JSONList[model.TaxRate]{standard}},
+			lines:     []taxableLine{{Amount: 600}, {Amount: 400}},
+			discount:  100,
+			wantTax:   150,
+			wantGross: 900,
+		},
+		{
+			name:      "no settings charge no tax",
+			lines:     []taxableLine{{Amount: 1000}},
+			wantTax:   0,
+			wantGross: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeTax(tt.settings, tt.lines, tt.discount)
+			if got.Tax != tt.wantTax || got.Gross != tt.wantGross {
+				t.Errorf("computeTax() tax = %d, gross = %d, want %d, %d", got.Tax, got.Gross, tt.wantTax, tt.wantGross)
+			}
+			if tt.wantLines != nil && !slices.EqualFunc(got.Lines, tt.wantLines, func(a, b model.TaxLine) bool {
+				return a.Name == b.Name && a.Rate == b.Rate && a.Net == b.Net && a.Tax == b.Tax && a.Gross == b.Gross
+			}) {
+				t.Errorf("computeTax() lines = %+v, want %+v", got.Lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		amounts  []int64
+		discount int64
+		want     []int64
+	}{
+		{name: "no discount", amounts: []int64{100, 200}, discount: 0, want: []int64{0, 0}},
+		{name: "proportional", amounts: []int64{100, 300}, discount: 40, want: []int64{10, 30}},
+		{name: "leftover cent to largest remainder", amounts: []int64{100, 200}, discount: 50, want: []int64{17, 33}},
+		{name: "tie goes to earliest line", amounts: []int64{100, 100, 100}, discount: 100, want: []int64{34, 33, 33}},
+		{name: "capped at the total", amounts: []int64{100, 200}, discount: 500, want: []int64{100, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]taxableLine, 0, len(tt.amounts))
+			for _, amount := range tt.amounts {
+				lines = append(lines, taxableLine{Amount: amount})
+			}
+			if got := allocateDiscount(lines, tt.discount); !slices.Equal(got, tt.want) {
+				t.Errorf("allocateDiscount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+
+	"github.com/google/uuid"
+)
+
+// TaxService defines the operations on a restaurant's tax configuration, its invoices and
+// the receipts of its orders.
+type TaxService interface {
+	// FindSettings retrieves the tax configuration of a restaurant.
+	FindSettings(userId uuid.UUID, requestId string, restaurantId string) (response.TaxSettingsResponse, error)
+
+	// SaveSettings replaces the tax configuration of a restaurant. Orders already placed keep their tax.
+	SaveSettings(settingsRequest request.TaxSettingsRequest, userId uuid.UUID, requestId string, restaurantId string) (response.TaxSettingsResponse, error)
+
+	// IssueInvoice numbers a served or collected order that has no invoice yet.
+	IssueInvoice(orderId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.InvoiceResponse, error)
+
+	// Receipt renders the receipt of an order, or its invoice once issued, as seen by the
+	// given role in the given format, "pdf" or "text".
+	Receipt(orderId uuid.UUID, format string, userId uuid.UUID, role string, requestId string, restaurantId string) (response.ReceiptFile, error)
+}
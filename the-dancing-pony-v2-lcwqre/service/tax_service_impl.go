@@ -0,0 +1,316 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/receipt"
+	"the-dancing-pony-v2-lcwqre/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Receipt formats.
+const (
+	ReceiptFormatPDF  = "pdf"
+	ReceiptFormatText = "text"
+)
+
+// defaultInvoicePrefix prefixes the invoice numbers of restaurants that did not choose a prefix.
+const defaultInvoicePrefix = "INV-"
+
+var (
+	// ErrInvalidTaxSettings is returned when tax rates are ambiguous or incomplete.
+	ErrInvalidTaxSettings = errors.New("invalid tax settings")
+
+	// ErrUnknownReceiptFormat is returned when a receipt is requested in a format other than pdf or text.
+	ErrUnknownReceiptFormat = errors.New("unknown receipt format")
+
+	// ErrOrderNotCompleted is returned when invoicing an order that was not served or collected.
+	ErrOrderNotCompleted = errors.New("only served or collected orders are invoiced")
+)
+
+// TaxServiceImpl provides the implementation for tax and receipt operations.
+type TaxServiceImpl struct {
+	TaxRepository         repository.TaxRepository
+	OrdersRepository      repository.OrdersRepository
+	RestaurantsRepository repository.RestaurantsRepository
+	Currency              string // Printed next to receipt totals
+}
+
+// NewTaxServiceImpl creates a new instance of TaxServiceImpl.
+func NewTaxServiceImpl(taxRepository repository.TaxRepository, ordersRepository repository.OrdersRepository, restaurantsRepository repository.RestaurantsRepository, currency string) TaxService {
+	return &TaxServiceImpl{
+		TaxRepository:         taxRepository,
+		OrdersRepository:      ordersRepository,
+		RestaurantsRepository: restaurantsRepository,
+		Currency:              strings.ToUpper(currency),
+	}
+}
+
+// FindSettings retrieves the tax configuration of a restaurant.
+func (s *TaxServiceImpl) FindSettings(userId uuid.UUID, requestID string, restaurantId string) (response.TaxSettingsResponse, error) {
+	settings, err := s.TaxRepository.FindSettings(restaurantId)
+	if err != nil {
+		return response.TaxSettingsResponse{}, err
+	}
+	return toTaxSettingsResponse(settings), nil
+}
+
+// SaveSettings replaces the tax configuration of a restaurant. Rates must have distinct
+// names, exactly one of them must be the default rate, and a category may only belong
+// to one rate.
+func (s *TaxServiceImpl) SaveSettings(settingsRequest request.TaxSettingsRequest, userId uuid.UUID, requestID string, restaurantId string) (response.TaxSettingsResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.TaxSettingsResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	settings := model.TaxSettings{
+		RestaurantID:     restaurantUUID,
+		PricesIncludeTax: settingsRequest.PricesIncludeTax,
+		Rates:            make(model.JSONList[model.TaxRate], 0, len(settingsRequest.Rates)),
+		LegalName:        strings.TrimSpace(settingsRequest.LegalName),
+		Address:          strings.TrimSpace(settingsRequest.Address),
+		TaxNumber:        strings.TrimSpace(settingsRequest.TaxNumber),
+		InvoicePrefix:    strings.TrimSpace(settingsRequest.InvoicePrefix),
+	}
+	if settings.InvoicePrefix == "" {
+		settings.InvoicePrefix = defaultInvoicePrefix
+	}
+
+	var names, categories []string
+	defaults := 0
+	for _, rateRequest := range settingsRequest.Rates {
+		rate := model.TaxRate{
+			Name:       strings.TrimSpace(rateRequest.Name),
+			Rate:       rateRequest.Rate,
+			Categories: normalizeTags(rateRequest.Categories),
+		}
+		if math.Abs(rate.Rate*100-math.Round(rate.Rate*100)) > 1e-6 {
+			return response.TaxSettingsResponse{}, fmt.Errorf("%w: rate %q has more than two decimals", ErrInvalidTaxSettings, rate.Name)
+		}
+		if slices.Contains(names, strings.ToLower(rate.Name)) {
+			return response.TaxSettingsResponse{}, fmt.Errorf("%w: more than one rate is named %q", ErrInvalidTaxSettings, rate.Name)
+		}
+		names = append(names, strings.ToLower(rate.Name))
+		if len(rate.Categories) == 0 {
+			defaults++
+		}
+		for _, category := range rate.Categories {
+			if slices.Contains(categories, category) {
+				return response.TaxSettingsResponse{}, fmt.Errorf("%w: category %q belongs to more than one rate", ErrInvalidTaxSettings, category)
+			}
+			categories = append(categories, category)
+		}
+		settings.Rates = append(settings.Rates, rate)
+	}
+	if defaults != 1 {
+		return response.TaxSettingsResponse{}, fmt.Errorf("%w: exactly one rate must have no categories and apply to every other dish", ErrInvalidTaxSettings)
+	}
+
+	saved, err := s.TaxRepository.SaveSettings(settings)
+	if err != nil {
+		return response.TaxSettingsResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Int("rates", len(saved.Rates)).
+		Msg("Tax settings saved successfully")
+	return toTaxSettingsResponse(saved), nil
+}
+
+// IssueInvoice numbers a served or collected order; an order that has an invoice keeps it.
+func (s *TaxServiceImpl) IssueInvoice(orderId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.InvoiceResponse, error) {
+	order, err := s.OrdersRepository.FindById(orderId, restaurantId)
+	if err != nil {
+		return response.InvoiceResponse{}, err
+	}
+	if order.Status != model.OrderStatusServed && order.Status != model.OrderStatusCollected {
+		return response.InvoiceResponse{}, fmt.Errorf("%w: order is %s", ErrOrderNotCompleted, order.Status)
+	}
+	invoice, err := s.TaxRepository.IssueInvoice(order)
+	if err != nil {
+		return response.InvoiceResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("order_id", orderId.String()).
+		Str("invoice", invoice.Number).
+		Msg("Order invoiced")
+	return response.InvoiceResponse{Number: invoice.Number, IssuedAt: invoice.IssuedAt}, nil
+}
+
+// Receipt renders the receipt of an order; customers can only retrieve their own.
+func (s *TaxServiceImpl) Receipt(orderId uuid.UUID, format string, userId uuid.UUID, role string, requestID string, restaurantId string) (response.ReceiptFile, error) {
+	if format == "" {
+		format = ReceiptFormatPDF
+	}
+	if format != ReceiptFormatPDF && format != ReceiptFormatText {
+		return response.ReceiptFile{}, fmt.Errorf("%w: %q", ErrUnknownReceiptFormat, format)
+	}
+
+	var order model.Order
+	var err error
+	if role == model.RoleCustomer {
+		order, err = s.OrdersRepository.FindCustomerOrder(orderId, userId, restaurantId)
+	} else {
+		order, err = s.OrdersRepository.FindById(orderId, restaurantId)
+	}
+	if err != nil {
+		return response.ReceiptFile{}, err
+	}
+	settings, err := s.TaxRepository.FindSettings(restaurantId)
+	if err != nil {
+		return response.ReceiptFile{}, err
+	}
+	restaurant, err := s.RestaurantsRepository.FindById(order.RestaurantID)
+	if err != nil {
+		return response.ReceiptFile{}, err
+	}
+
+	r := buildReceipt(order, settings, restaurant, s.Currency)
+	filename := "receipt-" + order.ID.String()
+	if order.Invoice != nil {
+		filename = order.Invoice.Number
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("order_id", orderId.String()).
+		Str("format", format).
+		Msg("Rendering receipt")
+	if format == ReceiptFormatText {
+		return response.ReceiptFile{Filename: filename + ".txt", ContentType: "text/plain; charset=utf-8", Body: receipt.Text(r)}, nil
+	}
+	return response.ReceiptFile{Filename: filename + ".pdf", ContentType: "application/pdf", Body: receipt.PDF(r)}, nil
+}
+
+// buildReceipt lays out the receipt of an order. Invoiced orders print the seller details
+// snapshotted on their invoice; other orders print the current ones.
+func buildReceipt(order model.Order, settings model.TaxSettings, restaurant model.Restaurant, currency string) receipt.Receipt {
+	r := receipt.Receipt{Title: "RECEIPT", Currency: currency, Total: order.Total}
+
+	sellerName, sellerAddress, taxNumber := settings.LegalName, settings.Address, settings.TaxNumber
+	if sellerName == "" {
+		sellerName = restaurant.Name
+	}
+	if sellerAddress == "" {
+		sellerAddress = restaurant.Location
+	}
+	if order.Invoice != nil {
+		sellerName, sellerAddress, taxNumber = order.Invoice.SellerName, order.Invoice.SellerAddress, order.Invoice.TaxNumber
+		r.Title = "INVOICE"
+		if len(order.TaxLines) > 0 {
+			r.Title = "TAX INVOICE"
+		}
+	}
+	r.Header = append(r.Header, sellerName)
+	if sellerAddress != "" {
+		r.Header = append(r.Header, sellerAddress)
+	}
+	if taxNumber != "" {
+		r.Header = append(r.Header, "VAT no. "+taxNumber)
+	}
+
+	if order.Invoice != nil {
+		r.Details = append(r.Details,
+			receipt.Detail{Label: "Invoice", Value: order.Invoice.Number},
+			receipt.Detail{Label: "Issued", Value: order.Invoice.IssuedAt.UTC().Format("2006-01-02 15:04 MST")},
+		)
+	}
+	r.Details = append(r.Details,
+		receipt.Detail{Label: "Order", Value: order.ID.String()},
+		receipt.Detail{Label: "Placed", Value: order.CreatedAt.UTC().Format("2006-01-02 15:04 MST")},
+		receipt.Detail{Label: "Fulfilment", Value: order.Fulfilment},
+		receipt.Detail{Label: "Status", Value: order.Status},
+	)
+
+	codes := make(map[string]string, len(order.TaxLines))
+	for i, line := range order.TaxLines {
+		code := string(rune('A' + i))
+		codes[line.Name] = code
+		r.Taxes = append(r.Taxes, receipt.Tax{Code: code, Name: line.Name, Rate: line.Rate, Net: line.Net, Tax: line.Tax, Gross: line.Gross})
+	}
+	var subtotal float64
+	for _, item := range order.Items {
+		amount := roundMoney(item.UnitPrice * float64(item.Quantity))
+		subtotal += amount
+		r.Items = append(r.Items, receipt.Item{
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Amount:    amount,
+			TaxCode:   codes[item.TaxName],
+		})
+	}
+	r.Subtotal = roundMoney(subtotal)
+
+	for _, redemption := range order.Redemptions {
+		r.Adjustments = append(r.Adjustments, receipt.Adjustment{Label: redemption.Name, Amount: -redemption.Amount})
+	}
+	if order.RewardID != nil {
+		r.Adjustments = append(r.Adjustments, receipt.Adjustment{Label: "Reward: " + order.RewardName, Amount: -order.RewardDiscount})
+	}
+	if len(order.TaxLines) > 0 && !order.PricesIncludeTax {
+		r.Adjustments = append(r.Adjustments, receipt.Adjustment{Label: "Tax", Amount: order.Tax})
+	}
+
+	if len(order.TaxLines) > 0 && order.PricesIncludeTax {
+		r.Notes = append(r.Notes, "Prices include tax. Discounts are spread over the items in proportion to their amounts.")
+	}
+	switch {
+	case order.Status == model.OrderStatusCancelled || order.Status == model.OrderStatusRejected:
+		r.Notes = append(r.Notes, "This order was "+order.Status+" and is not payable.")
+	case order.Invoice == nil:
+		r.Notes = append(r.Notes, "This receipt is not an invoice. An invoice is issued once the order is served or collected.")
+	}
+	return r
+}
+
+// toTaxSettingsResponse converts tax settings to their response.
+func toTaxSettingsResponse(settings model.TaxSettings) response.TaxSettingsResponse {
+	settingsResponse := response.TaxSettingsResponse{
+		Configured:       settings.ID != uuid.Nil,
+		PricesIncludeTax: settings.PricesIncludeTax,
+		Rates:            make([]response.TaxRateResponse, 0, len(settings.Rates)),
+		LegalName:        settings.LegalName,
+		Address:          settings.Address,
+		TaxNumber:        settings.TaxNumber,
+		InvoicePrefix:    settings.InvoicePrefix,
+	}
+	if settingsResponse.InvoicePrefix == "" {
+		settingsResponse.InvoicePrefix = defaultInvoicePrefix
+	}
+	for _, rate := range settings.Rates {
+		settingsResponse.Rates = append(settingsResponse.Rates, response.TaxRateResponse{
+			Name:       rate.Name,
+			Rate:       rate.Rate,
+			Categories: dishTags(rate.Categories),
+		})
+	}
+	return settingsResponse
+}
+
+// toTaxLineResponses converts the tax of an order or cart to its response.
+func toTaxLineResponses(lines []model.TaxLine) []response.TaxLineResponse {
+	lineResponses := make([]response.TaxLineResponse, 0, len(lines))
+	for _, line := range lines {
+		lineResponses = append(lineResponses, response.TaxLineResponse{
+			Name:  line.Name,
+			Rate:  line.Rate,
+			Net:   line.Net,
+			Tax:   line.Tax,
+			Gross: line.Gross,
+		})
+	}
+	return lineResponses
+}
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"the-dancing-pony-v2-lcwqre/data/request"
-	"the-dancing-pony-v2-lcwqre/helper"
 	"the-dancing-pony-v2-lcwqre/model"
 	"the-dancing-pony-v2-lcwqre/repository"
 
@@ -17,12 +16,13 @@ import (
 
 // AuthServiceImpl implements AuthService interface.
 type AuthServiceImpl struct {
-	UserRepo repository.UserRepository
+	UserRepo  repository.UserRepository
+	JWTSecret []byte // Signs access tokens
 }
 
 // NewAuthService creates a new instance of AuthServiceImpl.
-func NewAuthService(userRepo repository.UserRepository) AuthService {
-	return &AuthServiceImpl{UserRepo: userRepo}
+func NewAuthService(userRepo repository.UserRepository, jwtSecret []byte) AuthService {
+	return &AuthServiceImpl{UserRepo: userRepo, JWTSecret: jwtSecret}
 }
 
 // Register registers a new user.
@@ -90,7 +90,7 @@ func (s *AuthServiceImpl) Login(req request.LoginRequest, restaurantId uuid.UUID
 		return "", errors.New("invalid email or password")
 	}
 
-	token, err := generateToken(user.ID)
+	token, err := generateToken(user.ID, s.JWTSecret)
 	if err != nil {
 		log.Error().Str("email", req.Email).Err(err).Msg("Failed to generate token")
 		return "", err
@@ -100,8 +100,8 @@ func (s *AuthServiceImpl) Login(req request.LoginRequest, restaurantId uuid.UUID
 	return token, nil
 }
 
-// generateToken generates a JWT token for the given user ID.
-func generateToken(userID uuid.UUID) (string, error) {
+// generateToken generates a JWT token for the given user ID, signed with secret.
+func generateToken(userID uuid.UUID, secret []byte) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 	claims := &jwt.MapClaims{
 		"user_id": userID,
@@ -109,5 +109,5 @@ func generateToken(userID uuid.UUID) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(helper.GetJWTSecret()))
+	return token.SignedString(secret)
 }
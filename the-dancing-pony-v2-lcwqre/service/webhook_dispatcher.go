@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookDispatcher periodically sends the webhook deliveries that have come due.
+type WebhookDispatcher struct {
+	WebhookService WebhookService
+	Interval       time.Duration
+}
+
+// NewWebhookDispatcher creates a new instance of WebhookDispatcher.
+func NewWebhookDispatcher(webhookService WebhookService, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		WebhookService: webhookService,
+		Interval:       interval,
+	}
+}
+
+// Run sends due deliveries every interval until the context is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	log.Info().
+		Dur("interval", d.Interval).
+		Msg("Webhook dispatcher started")
+
+	for {
+		d.tick(ctx)
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick sends the deliveries due now and logs the outcome.
+func (d *WebhookDispatcher) tick(ctx context.Context) {
+	attempted, err := d.WebhookService.DeliverDue(ctx, time.Now())
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error sending due webhook deliveries")
+		return
+	}
+	if attempted > 0 {
+		log.Info().
+			Int("attempted", attempted).
+			Msg("Webhook deliveries sent")
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/realtime"
+	"the-dancing-pony-v2-lcwqre/repository"
+)
+
+// WebhookPublisher publishes events to the realtime subscribers and queues a delivery
+// of those webhooks can subscribe to for each matching subscription of the restaurant.
+// The payload is the event as streamed, including its ID.
+type WebhookPublisher struct {
+	Next               realtime.Publisher
+	WebhooksRepository repository.WebhooksRepository
+}
+
+// NewWebhookPublisher creates a new instance of WebhookPublisher.
+func NewWebhookPublisher(next realtime.Publisher, webhooksRepository repository.WebhooksRepository) realtime.Publisher {
+	return &WebhookPublisher{
+		Next:               next,
+		WebhooksRepository: webhooksRepository,
+	}
+}
+
+// Publish passes the event on and queues its webhook deliveries, even when passing it on failed.
+func (p *WebhookPublisher) Publish(ctx context.Context, event realtime.Event) error {
+	err := p.Next.Publish(ctx, event)
+	if !slices.Contains(webhookEventTypes, event.Type) {
+		return err
+	}
+	payload, encodeErr := json.Marshal(event)
+	if encodeErr != nil {
+		return errors.Join(err, fmt.Errorf("error encoding webhook payload: %w", encodeErr))
+	}
+	if _, enqueueErr := p.WebhooksRepository.Enqueue(event.RestaurantID, event.ID, event.Type, string(payload), time.Now()); enqueueErr != nil {
+		return errors.Join(err, enqueueErr)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/pagination"
+
+	"github.com/google/uuid"
+)
+
+// WebhookService defines the operations on a restaurant's webhook subscriptions and the
+// delivery of events to them.
+type WebhookService interface {
+	// EventTypes lists the event types subscriptions can ask for.
+	EventTypes() []string
+
+	// Create creates a subscription with a new signing secret, returned only this once.
+	Create(subscriptionRequest request.WebhookSubscriptionRequest, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookSubscriptionResponse, error)
+
+	// FindAll retrieves the subscriptions of a restaurant.
+	FindAll(userId uuid.UUID, requestId string, restaurantId string) ([]response.WebhookSubscriptionResponse, error)
+
+	// FindById retrieves a subscription.
+	FindById(subscriptionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookSubscriptionResponse, error)
+
+	// Replace replaces the URL, event types and state of a subscription.
+	Replace(subscriptionRequest request.WebhookSubscriptionRequest, subscriptionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookSubscriptionResponse, error)
+
+	// RotateSecret gives a subscription a new signing secret, returned only this once.
+	RotateSecret(subscriptionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookSubscriptionResponse, error)
+
+	// Delete deletes a subscription; its delivery log is kept.
+	Delete(subscriptionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) error
+
+	// FindDeliveries retrieves a page of a subscription's delivery log, optionally only
+	// the deliveries with the given status.
+	FindDeliveries(subscriptionId uuid.UUID, status string, pageRequest pagination.Request, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookDeliveryListResponse, error)
+
+	// FindDelivery retrieves a delivery with its payload and attempts.
+	FindDelivery(deliveryId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookDeliveryResponse, error)
+
+	// Replay delivers the event of a delivery again, as a new delivery.
+	Replay(deliveryId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookDeliveryResponse, error)
+
+	// Test sends a webhook.test event to a subscription right away and returns the outcome.
+	Test(subscriptionId uuid.UUID, userId uuid.UUID, requestId string, restaurantId string) (response.WebhookDeliveryResponse, error)
+
+	// DeliverDue attempts the deliveries that are due at the given time and returns how many were attempted.
+	DeliverDue(ctx context.Context, now time.Time) (int, error)
+}
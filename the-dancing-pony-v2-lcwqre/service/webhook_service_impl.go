@@ -0,0 +1,545 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"the-dancing-pony-v2-lcwqre/data/request"
+	"the-dancing-pony-v2-lcwqre/data/response"
+	"the-dancing-pony-v2-lcwqre/model"
+	"the-dancing-pony-v2-lcwqre/pagination"
+	"the-dancing-pony-v2-lcwqre/realtime"
+	"the-dancing-pony-v2-lcwqre/repository"
+	"the-dancing-pony-v2-lcwqre/webhooks"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// webhookEventTypes are the event types subscriptions can ask for.
+var webhookEventTypes = []string{
+	realtime.EventOrderPlaced,
+	realtime.EventOrderUpdated,
+	realtime.EventDishCreated,
+	realtime.EventDishUpdated,
+	realtime.EventDishDeleted,
+	realtime.EventDishAvailability,
+	realtime.EventReviewCreated,
+	realtime.EventReviewModerated,
+	realtime.EventReservationCreated,
+	realtime.EventReservationUpdated,
+}
+
+const (
+	webhookBatchSize   = 20              // Deliveries claimed at a time
+	webhookConcurrency = 4               // Deliveries sent at once
+	webhookLease       = 2 * time.Minute // How long a claimed delivery is kept from other dispatchers
+	webhookErrorLength = 500             // Characters of an attempt's error kept
+)
+
+var (
+	// ErrUnknownWebhookEvent is returned when a subscription asks for an event type that does not exist.
+	ErrUnknownWebhookEvent = errors.New("unknown webhook event type")
+
+	// ErrInvalidWebhookURL is returned when a subscription URL is not an absolute http or https URL.
+	ErrInvalidWebhookURL = errors.New("webhook URL must be an absolute http or https URL")
+
+	// ErrUnknownDeliveryStatus is returned when deliveries are filtered by a status that does not exist.
+	ErrUnknownDeliveryStatus = errors.New("unknown webhook delivery status")
+
+	// ErrWebhookDisabled is returned when a delivery is replayed to a subscription that is not active.
+	ErrWebhookDisabled = errors.New("webhook subscription is not active")
+)
+
+// WebhookServiceImpl provides the implementation for webhook operations.
+type WebhookServiceImpl struct {
+	WebhooksRepository repository.WebhooksRepository
+	Sender             *webhooks.Sender
+	Policy             webhooks.RetryPolicy
+	Cursors            *pagination.CursorSigner
+}
+
+// NewWebhookServiceImpl creates a new instance of WebhookServiceImpl.
+func NewWebhookServiceImpl(webhooksRepository repository.WebhooksRepository, sender *webhooks.Sender, policy webhooks.RetryPolicy, cursors *pagination.CursorSigner) WebhookService {
+	return &WebhookServiceImpl{
+		WebhooksRepository: webhooksRepository,
+		Sender:             sender,
+		Policy:             policy,
+		Cursors:            cursors,
+	}
+}
+
+// EventTypes lists the event types subscriptions can ask for.
+func (s *WebhookServiceImpl) EventTypes() []string {
+	return slices.Clone(webhookEventTypes)
+}
+
+// Create creates a subscription with a new signing secret, returned only this once.
+func (s *WebhookServiceImpl) Create(subscriptionRequest request.WebhookSubscriptionRequest, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookSubscriptionResponse, error) {
+	restaurantUUID, err := uuid.Parse(restaurantId)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, fmt.Errorf("invalid restaurant ID: %w", err)
+	}
+	eventTypes, err := validateWebhookRequest(subscriptionRequest)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+
+	subscription, err := s.WebhooksRepository.CreateSubscription(model.WebhookSubscription{
+		RestaurantID: restaurantUUID,
+		URL:          subscriptionRequest.URL,
+		Description:  subscriptionRequest.Description,
+		EventTypes:   eventTypes,
+		Secret:       secret,
+		Active:       subscriptionRequest.Active == nil || *subscriptionRequest.Active,
+	})
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error creating webhook subscription")
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("subscription_id", subscription.ID.String()).
+		Msg("Webhook subscription created successfully")
+
+	subscriptionResponse := toWebhookSubscriptionResponse(subscription)
+	subscriptionResponse.Secret = subscription.Secret
+	return subscriptionResponse, nil
+}
+
+// FindAll retrieves the subscriptions of a restaurant.
+func (s *WebhookServiceImpl) FindAll(userId uuid.UUID, requestID string, restaurantId string) ([]response.WebhookSubscriptionResponse, error) {
+	subscriptions, err := s.WebhooksRepository.FindSubscriptions(restaurantId)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving webhook subscriptions")
+		return nil, err
+	}
+	subscriptionResponses := make([]response.WebhookSubscriptionResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionResponses = append(subscriptionResponses, toWebhookSubscriptionResponse(subscription))
+	}
+	return subscriptionResponses, nil
+}
+
+// FindById retrieves a subscription.
+func (s *WebhookServiceImpl) FindById(subscriptionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookSubscriptionResponse, error) {
+	subscription, err := s.WebhooksRepository.FindSubscription(subscriptionId, restaurantId)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	return toWebhookSubscriptionResponse(subscription), nil
+}
+
+// Replace replaces the URL, event types and state of a subscription. Activating a
+// subscription that was disabled for failing resets its failure count.
+func (s *WebhookServiceImpl) Replace(subscriptionRequest request.WebhookSubscriptionRequest, subscriptionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookSubscriptionResponse, error) {
+	eventTypes, err := validateWebhookRequest(subscriptionRequest)
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	active := subscriptionRequest.Active == nil || *subscriptionRequest.Active
+	fields := map[string]interface{}{
+		"URL":         subscriptionRequest.URL,
+		"Description": subscriptionRequest.Description,
+		"EventTypes":  eventTypes,
+		"Active":      active,
+	}
+	if active {
+		fields["ConsecutiveFailures"] = 0
+		fields["DisabledAt"] = nil
+		fields["DisabledReason"] = ""
+	}
+
+	subscription, err := s.WebhooksRepository.UpdateSubscription(subscriptionId, restaurantId, fields)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("subscription_id", subscriptionId.String()).
+			Err(err).
+			Msg("Error updating webhook subscription")
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("subscription_id", subscriptionId.String()).
+		Bool("active", subscription.Active).
+		Msg("Webhook subscription updated successfully")
+	return toWebhookSubscriptionResponse(subscription), nil
+}
+
+// RotateSecret gives a subscription a new signing secret, returned only this once.
+// Deliveries not yet sent are signed with the new secret.
+func (s *WebhookServiceImpl) RotateSecret(subscriptionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookSubscriptionResponse, error) {
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	subscription, err := s.WebhooksRepository.UpdateSubscription(subscriptionId, restaurantId, map[string]interface{}{"Secret": secret})
+	if err != nil {
+		return response.WebhookSubscriptionResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("subscription_id", subscriptionId.String()).
+		Msg("Webhook secret rotated successfully")
+
+	subscriptionResponse := toWebhookSubscriptionResponse(subscription)
+	subscriptionResponse.Secret = subscription.Secret
+	return subscriptionResponse, nil
+}
+
+// Delete deletes a subscription; its delivery log is kept.
+func (s *WebhookServiceImpl) Delete(subscriptionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) error {
+	if err := s.WebhooksRepository.DeleteSubscription(subscriptionId, restaurantId); err != nil {
+		return err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("subscription_id", subscriptionId.String()).
+		Msg("Webhook subscription deleted successfully")
+	return nil
+}
+
+// FindDeliveries retrieves a page of a subscription's delivery log, oldest first,
+// optionally only the deliveries with the given status.
+func (s *WebhookServiceImpl) FindDeliveries(subscriptionId uuid.UUID, status string, pageRequest pagination.Request, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookDeliveryListResponse, error) {
+	if status != "" && status != model.WebhookDeliveryPending && status != model.WebhookDeliverySucceeded && status != model.WebhookDeliveryFailed {
+		return response.WebhookDeliveryListResponse{}, fmt.Errorf("%w: %q", ErrUnknownDeliveryStatus, status)
+	}
+	if _, err := s.WebhooksRepository.FindSubscription(subscriptionId, restaurantId); err != nil {
+		return response.WebhookDeliveryListResponse{}, err
+	}
+	scope := "webhooks:" + restaurantId + ":subscription:" + subscriptionId.String() + ":" + status
+	page, err := s.Cursors.Page(pageRequest, scope)
+	if err != nil {
+		return response.WebhookDeliveryListResponse{}, err
+	}
+
+	deliveries, result, err := s.WebhooksRepository.FindDeliveries(subscriptionId, restaurantId, status, page)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Err(err).
+			Msg("Error retrieving webhook deliveries")
+		return response.WebhookDeliveryListResponse{}, err
+	}
+	deliveryResponses := make([]response.WebhookDeliveryResponse, 0, len(deliveries))
+	keys := make([]pagination.Key, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryResponses = append(deliveryResponses, toWebhookDeliveryResponse(delivery))
+		keys = append(keys, pagination.Key{CreatedAt: delivery.CreatedAt, ID: delivery.ID})
+	}
+	return response.WebhookDeliveryListResponse{
+		Deliveries: deliveryResponses,
+		Pagination: pageInfo(s.Cursors, scope, page, result, keys),
+	}, nil
+}
+
+// FindDelivery retrieves a delivery with its payload and attempts.
+func (s *WebhookServiceImpl) FindDelivery(deliveryId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookDeliveryResponse, error) {
+	delivery, err := s.WebhooksRepository.FindDelivery(deliveryId, restaurantId)
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+	return toWebhookDeliveryDetailResponse(delivery), nil
+}
+
+// Replay delivers the event of a delivery again, as a new delivery due now. The event
+// keeps its ID so receivers that already processed it can recognise it.
+func (s *WebhookServiceImpl) Replay(deliveryId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookDeliveryResponse, error) {
+	original, err := s.WebhooksRepository.FindDelivery(deliveryId, restaurantId)
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+	subscription, err := s.WebhooksRepository.FindSubscription(original.SubscriptionID, restaurantId)
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+	if !subscription.Active {
+		return response.WebhookDeliveryResponse{}, ErrWebhookDisabled
+	}
+
+	now := time.Now()
+	delivery, err := s.WebhooksRepository.CreateDelivery(model.WebhookDelivery{
+		RestaurantID:   original.RestaurantID,
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         model.WebhookDeliveryPending,
+		NextAttemptAt:  &now,
+		ReplayOfID:     &original.ID,
+	})
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("delivery_id", deliveryId.String()).
+			Err(err).
+			Msg("Error replaying webhook delivery")
+		return response.WebhookDeliveryResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("delivery_id", delivery.ID.String()).
+		Str("replay_of", deliveryId.String()).
+		Msg("Webhook delivery replayed successfully")
+	return toWebhookDeliveryResponse(delivery), nil
+}
+
+// Test sends a webhook.test event to a subscription right away, whether it is active or
+// not, and returns the outcome. Test deliveries are not retried, and leave the failure
+// count of the subscription alone.
+func (s *WebhookServiceImpl) Test(subscriptionId uuid.UUID, userId uuid.UUID, requestID string, restaurantId string) (response.WebhookDeliveryResponse, error) {
+	subscription, err := s.WebhooksRepository.FindSubscription(subscriptionId, restaurantId)
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+	event, err := realtime.NewEvent(model.WebhookEventTest, restaurantId, realtime.AudienceStaff, "", map[string]string{
+		"subscriptionId": subscription.ID.String(),
+		"message":        "This is a test delivery from The Dancing Pony.",
+	})
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	// Leased so that the dispatcher leaves it alone while it is sent here.
+	lockedUntil := time.Now().Add(webhookLease)
+	delivery, err := s.WebhooksRepository.CreateDelivery(model.WebhookDelivery{
+		RestaurantID:   subscription.RestaurantID,
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         model.WebhookDeliveryPending,
+		LockedUntil:    &lockedUntil,
+	})
+	if err != nil {
+		return response.WebhookDeliveryResponse{}, err
+	}
+	delivery, err = s.attempt(context.Background(), delivery, subscription, false)
+	if err != nil {
+		log.Error().
+			Str("request_id", requestID).
+			Str("user_id", userId.String()).
+			Str("subscription_id", subscriptionId.String()).
+			Err(err).
+			Msg("Error recording webhook test delivery")
+		return response.WebhookDeliveryResponse{}, err
+	}
+	log.Info().
+		Str("request_id", requestID).
+		Str("user_id", userId.String()).
+		Str("subscription_id", subscriptionId.String()).
+		Str("status", delivery.Status).
+		Msg("Webhook test delivery sent")
+	return toWebhookDeliveryResponse(delivery), nil
+}
+
+// DeliverDue attempts the deliveries that are due at the given time, a batch at a time,
+// and returns how many were attempted.
+func (s *WebhookServiceImpl) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	attempted := 0
+	for ctx.Err() == nil {
+		deliveries, err := s.WebhooksRepository.ClaimDue(now, webhookBatchSize, webhookLease)
+		if err != nil {
+			return attempted, err
+		}
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, webhookConcurrency)
+		for _, delivery := range deliveries {
+			if delivery.Subscription == nil {
+				// Deleted since it was claimed; deleting it failed the delivery.
+				continue
+			}
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(delivery model.WebhookDelivery) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				if _, err := s.attempt(ctx, delivery, *delivery.Subscription, true); err != nil {
+					log.Error().
+						Str("delivery_id", delivery.ID.String()).
+						Err(err).
+						Msg("Error recording webhook delivery attempt")
+				}
+			}(delivery)
+		}
+		wg.Wait()
+
+		attempted += len(deliveries)
+		if len(deliveries) < webhookBatchSize {
+			break
+		}
+	}
+	return attempted, nil
+}
+
+// attempt sends a delivery once and records the outcome. Failed deliveries are retried
+// with backoff until the policy's attempts run out when retry is set, and fail otherwise.
+func (s *WebhookServiceImpl) attempt(ctx context.Context, delivery model.WebhookDelivery, subscription model.WebhookSubscription, retry bool) (model.WebhookDelivery, error) {
+	result := s.Sender.Send(ctx, webhooks.Request{
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		DeliveryID: delivery.ID.String(),
+		EventID:    delivery.EventID,
+		EventType:  delivery.EventType,
+		Body:       []byte(delivery.Payload),
+	})
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = result.StatusCode
+	delivery.NextAttemptAt = nil
+	delivery.Error = ""
+	switch {
+	case result.Succeeded():
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	case retry && delivery.Attempts < s.Policy.MaxAttempts:
+		next := now.Add(s.Policy.Delay(delivery.Attempts))
+		delivery.Status = model.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+	default:
+		delivery.Status = model.WebhookDeliveryFailed
+	}
+	if !result.Succeeded() {
+		delivery.Error = webhookAttemptError(result)
+	}
+
+	wasActive := subscription.Active
+	subscription, err := s.WebhooksRepository.RecordAttempt(delivery, model.WebhookAttempt{
+		DeliveryID:     delivery.ID,
+		Number:         delivery.Attempts,
+		ResponseStatus: result.StatusCode,
+		ResponseBody:   result.Body,
+		Error:          delivery.Error,
+		DurationMs:     result.Duration.Milliseconds(),
+	}, s.Policy.DisableAfter)
+	if err != nil {
+		return delivery, err
+	}
+	if wasActive && !subscription.Active {
+		log.Warn().
+			Str("subscription_id", subscription.ID.String()).
+			Int("consecutive_failures", subscription.ConsecutiveFailures).
+			Msg("Webhook subscription disabled after repeated failures")
+	}
+	return delivery, nil
+}
+
+// webhookAttemptError describes why an attempt failed.
+func webhookAttemptError(result webhooks.Result) string {
+	message := fmt.Sprintf("receiver answered with status %d", result.StatusCode)
+	if result.Err != nil {
+		message = result.Err.Error()
+	}
+	if runes := []rune(message); len(runes) > webhookErrorLength {
+		message = string(runes[:webhookErrorLength])
+	}
+	return message
+}
+
+// validateWebhookRequest checks the URL and event types of a subscription and returns
+// the event types without duplicates.
+func validateWebhookRequest(subscriptionRequest request.WebhookSubscriptionRequest) ([]string, error) {
+	target, err := url.Parse(subscriptionRequest.URL)
+	if err != nil || !target.IsAbs() || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	eventTypes := make([]string, 0, len(subscriptionRequest.EventTypes))
+	for _, eventType := range subscriptionRequest.EventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, eventType)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, nil
+}
+
+// toWebhookSubscriptionResponse converts a subscription model into its response, without its secret.
+func toWebhookSubscriptionResponse(subscription model.WebhookSubscription) response.WebhookSubscriptionResponse {
+	return response.WebhookSubscriptionResponse{
+		ID:                  subscription.ID,
+		URL:                 subscription.URL,
+		Description:         subscription.Description,
+		EventTypes:          subscription.EventTypes,
+		Active:              subscription.Active,
+		ConsecutiveFailures: subscription.ConsecutiveFailures,
+		DisabledAt:          subscription.DisabledAt,
+		DisabledReason:      subscription.DisabledReason,
+		CreatedAt:           subscription.CreatedAt,
+	}
+}
+
+// toWebhookDeliveryResponse converts a delivery model into its response.
+func toWebhookDeliveryResponse(delivery model.WebhookDelivery) response.WebhookDeliveryResponse {
+	return response.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+		DeliveredAt:    delivery.DeliveredAt,
+		ReplayOfID:     delivery.ReplayOfID,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+// toWebhookDeliveryDetailResponse converts a delivery model into its response, with its payload and attempts.
+func toWebhookDeliveryDetailResponse(delivery model.WebhookDelivery) response.WebhookDeliveryResponse {
+	deliveryResponse := toWebhookDeliveryResponse(delivery)
+	deliveryResponse.Payload = json.RawMessage(delivery.Payload)
+	deliveryResponse.Log = make([]response.WebhookAttemptResponse, 0, len(delivery.Log))
+	for _, attempt := range delivery.Log {
+		deliveryResponse.Log = append(deliveryResponse.Log, response.WebhookAttemptResponse{
+			Number:         attempt.Number,
+			ResponseStatus: attempt.ResponseStatus,
+			ResponseBody:   attempt.ResponseBody,
+			Error:          attempt.Error,
+			DurationMs:     attempt.DurationMs,
+			AttemptedAt:    attempt.CreatedAt,
+		})
+	}
+	return deliveryResponse
+}
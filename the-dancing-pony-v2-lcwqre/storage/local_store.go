@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LocalStore keeps objects on the local filesystem and serves them through signed links.
+type LocalStore struct {
+	dir    string
+	signer *URLSigner
+}
+
+// NewLocalStore creates a new instance of LocalStore rooted at dir.
+func NewLocalStore(dir string, signer *URLSigner) (*LocalStore, error) {
+	if dir == "" {
+		dir = "uploads"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	log.Info().Str("dir", dir).Msg("Using local object store")
+	return &LocalStore{dir: dir, signer: signer}, nil
+}
+
+// Put writes an object atomically by renaming a temporary file into place.
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store object: %w", err)
+	}
+	return nil
+}
+
+// Get opens an object for reading.
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ObjectInfo{}, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, ObjectInfo{}, fmt.Errorf("failed to open object: %w", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return file, ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(key)),
+	}, nil
+}
+
+// Stat returns the object's metadata.
+func (s *LocalStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stat, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ObjectInfo{}, ErrObjectNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(key)),
+	}, nil
+}
+
+// Delete removes an object.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// URL returns a signed link to the API's files route.
+func (s *LocalStore) URL(ctx context.Context, key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return s.signer.SignedURL(key), nil
+}
+
+// PresignUpload returns a signed PUT link to the API's files route.
+func (s *LocalStore) PresignUpload(ctx context.Context, key string, contentType string, maxBytes int64, ttl time.Duration) (PresignedUpload, error) {
+	if err := validateKey(key); err != nil {
+		return PresignedUpload{}, err
+	}
+	return s.signer.SignedUpload(key, contentType, maxBytes, ttl), nil
+}
+
+// VerifyUploadSignature checks a signed upload link produced by PresignUpload.
+func (s *LocalStore) VerifyUploadSignature(key string, expires int64, maxBytes int64, signature string) bool {
+	return s.signer.VerifyUpload(key, expires, maxBytes, signature)
+}
+
+// VerifySignature checks a signed link produced by URL.
+func (s *LocalStore) VerifySignature(key string, expires int64, signature string) bool {
+	return s.signer.Verify(key, expires, signature)
+}
+
+// path maps a key to a file below the store directory.
+func (s *LocalStore) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Ping checks that the storage directory still exists.
+func (s *LocalStore) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to stat storage directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("storage path %s is not a directory", s.dir)
+	}
+	return nil
+}
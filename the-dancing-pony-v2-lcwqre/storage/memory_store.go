@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps objects in memory. It is intended for tests and throwaway environments.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	signer  *URLSigner
+}
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+}
+
+// NewMemoryStore creates a new instance of MemoryStore.
+func NewMemoryStore(signer *URLSigner) *MemoryStore {
+	return &MemoryStore{
+		objects: make(map[string]memoryObject),
+		signer:  signer,
+	}
+}
+
+// Put stores a copy of the object.
+func (s *MemoryStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read object: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = memoryObject{data: data, contentType: contentType}
+	return nil
+}
+
+// Get returns a reader over the stored object.
+func (s *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, ObjectInfo{}, ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(object.data)), ObjectInfo{
+		Key:         key,
+		Size:        int64(len(object.data)),
+		ContentType: object.contentType,
+	}, nil
+}
+
+// Stat returns the object's metadata.
+func (s *MemoryStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[key]
+	if !ok {
+		return ObjectInfo{}, ErrObjectNotFound
+	}
+	return ObjectInfo{
+		Key:         key,
+		Size:        int64(len(object.data)),
+		ContentType: object.contentType,
+	}, nil
+}
+
+// Delete removes an object.
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// URL returns a signed link to the API's files route.
+func (s *MemoryStore) URL(ctx context.Context, key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return s.signer.SignedURL(key), nil
+}
+
+// PresignUpload returns a signed PUT link to the API's files route.
+func (s *MemoryStore) PresignUpload(ctx context.Context, key string, contentType string, maxBytes int64, ttl time.Duration) (PresignedUpload, error) {
+	if err := validateKey(key); err != nil {
+		return PresignedUpload{}, err
+	}
+	return s.signer.SignedUpload(key, contentType, maxBytes, ttl), nil
+}
+
+// VerifyUploadSignature checks a signed upload link produced by PresignUpload.
+func (s *MemoryStore) VerifyUploadSignature(key string, expires int64, maxBytes int64, signature string) bool {
+	return s.signer.VerifyUpload(key, expires, maxBytes, signature)
+}
+
+// VerifySignature checks a signed link produced by URL.
+func (s *MemoryStore) VerifySignature(key string, expires int64, signature string) bool {
+	return s.signer.Verify(key, expires, signature)
+}
+
+// Ping always succeeds, the objects being in memory.
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog/log"
+)
+
+// S3Store keeps objects in an S3-compatible bucket such as AWS S3 or MinIO.
+type S3Store struct {
+	Client     *s3.Client
+	Uploader   *manager.Uploader
+	Presigner  *s3.PresignClient
+	BucketName string
+	PublicURL  string
+	URLTTL     time.Duration
+}
+
+// NewS3Store creates a new instance of S3Store.
+// Static credentials are used when configured, otherwise the default AWS credential chain applies.
+func NewS3Store(ctx context.Context, cfg Config) (*S3Store, error) {
+	if cfg.S3Bucket == "" {
+		return nil, errors.New("S3 bucket is not configured")
+	}
+
+	options := []func(*config.LoadOptions) error{config.WithRegion(cfg.S3Region)}
+	if cfg.S3AccessKey != "" || cfg.S3SecretKey != "" {
+		options = append(options, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if cfg.S3Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.S3Endpoint)
+		}
+		o.UsePathStyle = cfg.S3ForcePathStyle
+	})
+
+	log.Info().
+		Str("bucket", cfg.S3Bucket).
+		Str("endpoint", cfg.S3Endpoint).
+		Msg("Using S3 object store")
+	return &S3Store{
+		Client:     client,
+		Uploader:   manager.NewUploader(client),
+		Presigner:  s3.NewPresignClient(client),
+		BucketName: cfg.S3Bucket,
+		PublicURL:  strings.TrimSuffix(cfg.S3PublicURL, "/"),
+		URLTTL:     cfg.URLTTL,
+	}, nil
+}
+
+// Put uploads an object to the bucket.
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if _, err := s.Uploader.Upload(ctx, input); err != nil {
+		return fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+	return nil
+}
+
+// Get downloads an object from the bucket.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	output, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ObjectInfo{}, ErrObjectNotFound
+		}
+		return nil, ObjectInfo{}, fmt.Errorf("failed to get object from S3: %w", err)
+	}
+	return output.Body, ObjectInfo{
+		Key:         key,
+		Size:        aws.ToInt64(output.ContentLength),
+		ContentType: aws.ToString(output.ContentType),
+	}, nil
+}
+
+// Stat returns the object's metadata from a HEAD request.
+func (s *S3Store) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	output, err := s.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return ObjectInfo{}, ErrObjectNotFound
+		}
+		return ObjectInfo{}, fmt.Errorf("failed to stat object in S3: %w", err)
+	}
+	return ObjectInfo{
+		Key:         key,
+		Size:        aws.ToInt64(output.ContentLength),
+		ContentType: aws.ToString(output.ContentType),
+	}, nil
+}
+
+// Delete removes an object from the bucket.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object from S3: %w", err)
+	}
+	return nil
+}
+
+// PresignUpload returns a presigned PUT request to the bucket.
+// S3 cannot enforce maxBytes on a presigned PUT, so the size is checked again when the upload is confirmed.
+func (s *S3Store) PresignUpload(ctx context.Context, key string, contentType string, maxBytes int64, ttl time.Duration) (PresignedUpload, error) {
+	if err := validateKey(key); err != nil {
+		return PresignedUpload{}, err
+	}
+	request, err := s.Presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.BucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return PresignedUpload{}, fmt.Errorf("failed to presign S3 upload: %w", err)
+	}
+
+	headers := make(map[string]string)
+	for name, values := range request.SignedHeader {
+		if len(values) > 0 && name != "Host" {
+			headers[name] = values[0]
+		}
+	}
+	return PresignedUpload{
+		URL:       request.URL,
+		Method:    request.Method,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// URL returns the public object URL when a public base URL is configured, otherwise a presigned GET link.
+func (s *S3Store) URL(ctx context.Context, key string) (string, error) {
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + key, nil
+	}
+	request, err := s.Presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(s.URLTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 URL: %w", err)
+	}
+	return request.URL, nil
+}
+
+// Ping checks that the bucket exists and the credentials may access it.
+func (s *S3Store) Ping(ctx context.Context) error {
+	if _, err := s.Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.BucketName)}); err != nil {
+		return fmt.Errorf("failed to reach S3 bucket: %w", err)
+	}
+	return nil
+}
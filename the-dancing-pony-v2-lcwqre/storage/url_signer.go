@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilesRoutePrefix is the API path under which the local and memory drivers serve objects.
+const FilesRoutePrefix = "/files/"
+
+// FileServer is implemented by stores whose objects are served and uploaded through the API's signed file route.
+type FileServer interface {
+	ObjectStore
+	VerifySignature(key string, expires int64, signature string) bool
+	VerifyUploadSignature(key string, expires int64, maxBytes int64, signature string) bool
+}
+
+// URLSigner builds and verifies expiring HMAC-SHA256 signed links to the files route.
+type URLSigner struct {
+	baseURL string
+	key     []byte
+	ttl     time.Duration
+}
+
+// NewURLSigner creates a new instance of URLSigner.
+func NewURLSigner(baseURL string, signingKey string, ttl time.Duration) *URLSigner {
+	return &URLSigner{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		key:     []byte(signingKey),
+		ttl:     ttl,
+	}
+}
+
+// SignedURL returns a link to the object that stays valid for the signer's TTL.
+func (s *URLSigner) SignedURL(key string) string {
+	expires := time.Now().Add(s.ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.sign(key, expires))
+	return fmt.Sprintf("%s%s%s?%s", s.baseURL, FilesRoutePrefix, key, query.Encode())
+}
+
+// Verify checks that the signature matches the key and has not expired.
+func (s *URLSigner) Verify(key string, expires int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	expected := s.sign(key, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// SignedUpload returns a PUT request to the files route that accepts at most maxBytes until it expires.
+func (s *URLSigner) SignedUpload(key string, contentType string, maxBytes int64, ttl time.Duration) PresignedUpload {
+	expiresAt := time.Now().Add(ttl)
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("max", strconv.FormatInt(maxBytes, 10))
+	query.Set("sig", s.signUpload(key, expiresAt.Unix(), maxBytes))
+	return PresignedUpload{
+		URL:       fmt.Sprintf("%s%s%s?%s", s.baseURL, FilesRoutePrefix, key, query.Encode()),
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: expiresAt,
+	}
+}
+
+// VerifyUpload checks an upload signature produced by SignedUpload.
+func (s *URLSigner) VerifyUpload(key string, expires int64, maxBytes int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	expected := s.signUpload(key, expires, maxBytes)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// sign computes the hex encoded HMAC of the key and expiry.
+func (s *URLSigner) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signUpload computes the HMAC of an upload link; the method prefix keeps it distinct from download links.
+func (s *URLSigner) signUpload(key string, expires int64, maxBytes int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "PUT\n%s\n%d\n%d", key, expires, maxBytes)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,149 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReceiverRoutePrefix is the path the local receiver is served under. Subscriptions
+// pointing at <public URL>/webhooks/receiver/<inbox> deliver to the inbox.
+const ReceiverRoutePrefix = "/webhooks/receiver/"
+
+// Limits of the local receiver, which keeps everything in memory.
+const (
+	receiverInboxes  = 100      // Inboxes kept; the least recently used is dropped first
+	receiverRequests = 50       // Requests kept per inbox, newest first
+	receiverMaxBody  = 64 << 10 // Bytes of a request body kept
+)
+
+// ReceivedRequest is a delivery as the local receiver got it.
+type ReceivedRequest struct {
+	ReceivedAt time.Time         `json:"receivedAt"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body"`
+	Verified   *bool             `json:"verified,omitempty"` // Whether the signature is valid for the secret given when listing
+	StatusSent int               `json:"statusSent"`
+	raw        []byte            // Body as received, which the signature covers
+}
+
+// Receiver is a webhook receiver for trying subscriptions out without running a server.
+// It records what it receives in named inboxes:
+//
+//   - POST <prefix><inbox> records a delivery and answers 200, or the status given in the
+//     "status" query parameter, to simulate a failing receiver;
+//   - GET <prefix><inbox> lists the deliveries of an inbox, newest first, checking their
+//     signatures when the subscription's secret is passed in the "secret" query parameter;
+//   - DELETE <prefix><inbox> empties an inbox.
+type Receiver struct {
+	mux     *http.ServeMux
+	mu      sync.Mutex
+	inboxes map[string][]ReceivedRequest
+	used    map[string]time.Time
+}
+
+// NewReceiver creates a new instance of Receiver.
+func NewReceiver() *Receiver {
+	rc := &Receiver{
+		mux:     http.NewServeMux(),
+		inboxes: make(map[string][]ReceivedRequest),
+		used:    make(map[string]time.Time),
+	}
+	rc.mux.HandleFunc("POST "+ReceiverRoutePrefix+"{inbox}", rc.receive)
+	rc.mux.HandleFunc("GET "+ReceiverRoutePrefix+"{inbox}", rc.list)
+	rc.mux.HandleFunc("DELETE "+ReceiverRoutePrefix+"{inbox}", rc.clear)
+	return rc
+}
+
+// ServeHTTP serves the inboxes under ReceiverRoutePrefix.
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mux.ServeHTTP(w, r)
+}
+
+// receive records a delivery.
+func (rc *Receiver) receive(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, receiverMaxBody))
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return
+	}
+	status := http.StatusOK
+	if requested, err := strconv.Atoi(r.URL.Query().Get("status")); err == nil && requested >= 200 && requested <= 599 {
+		status = requested
+	}
+	received := ReceivedRequest{
+		ReceivedAt: time.Now().UTC(),
+		Headers:    make(map[string]string),
+		Body:       body,
+		StatusSent: status,
+		raw:        body,
+	}
+	if !json.Valid(body) {
+		received.Body, _ = json.Marshal(string(body))
+	}
+	for name := range r.Header {
+		received.Headers[name] = r.Header.Get(name)
+	}
+
+	inbox := r.PathValue("inbox")
+	rc.mu.Lock()
+	if _, ok := rc.inboxes[inbox]; !ok && len(rc.inboxes) >= receiverInboxes {
+		rc.evictLocked()
+	}
+	requests := append([]ReceivedRequest{received}, rc.inboxes[inbox]...)
+	if len(requests) > receiverRequests {
+		requests = requests[:receiverRequests]
+	}
+	rc.inboxes[inbox] = requests
+	rc.used[inbox] = received.ReceivedAt
+	rc.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]bool{"received": true})
+}
+
+// list responds with the deliveries of an inbox.
+func (rc *Receiver) list(w http.ResponseWriter, r *http.Request) {
+	inbox := r.PathValue("inbox")
+	rc.mu.Lock()
+	requests := append([]ReceivedRequest{}, rc.inboxes[inbox]...)
+	rc.mu.Unlock()
+
+	if secret := r.URL.Query().Get("secret"); secret != "" {
+		for i := range requests {
+			header := http.Header{}
+			header.Set(HeaderSignature, requests[i].Headers[HeaderSignature])
+			// Replayed requests are checked as of when they were received
+			verified := Verify(secret, header, requests[i].raw, requests[i].ReceivedAt) == nil
+			requests[i].Verified = &verified
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"inbox": inbox, "requests": requests})
+}
+
+// clear empties an inbox.
+func (rc *Receiver) clear(w http.ResponseWriter, r *http.Request) {
+	inbox := r.PathValue("inbox")
+	rc.mu.Lock()
+	delete(rc.inboxes, inbox)
+	delete(rc.used, inbox)
+	rc.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// evictLocked drops the least recently used inbox.
+func (rc *Receiver) evictLocked() {
+	var oldest string
+	for inbox, used := range rc.used {
+		if oldest == "" || used.Before(rc.used[oldest]) {
+			oldest = inbox
+		}
+	}
+	delete(rc.inboxes, oldest)
+	delete(rc.used, oldest)
+}
@@ -0,0 +1,40 @@
+package webhooks
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides when failed deliveries are retried and when a subscription that
+// keeps failing is disabled.
+type RetryPolicy struct {
+	MaxAttempts  int           // Attempts per delivery, the first one included
+	BaseDelay    time.Duration // Delay before the first retry
+	MaxDelay     time.Duration // Cap of the delay between retries
+	DisableAfter int           // Consecutive failed attempts, across deliveries, that disable a subscription
+}
+
+// DefaultRetryPolicy retries for about a day: 8 attempts, 1 minute apart at first and
+// doubling up to 6 hours. A subscription is disabled after 30 failures in a row.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  8,
+	BaseDelay:    time.Minute,
+	MaxDelay:     6 * time.Hour,
+	DisableAfter: 30,
+}
+
+// Delay returns how long to wait after the given failed attempt, counted from 1, before
+// the next one. The delay doubles with every attempt up to MaxDelay, and is spread by
+// up to a fifth either way so that receivers coming back up are not hit all at once.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	jitter := time.Duration(rand.Int64N(int64(delay)/5 + 1))
+	if rand.IntN(2) == 0 {
+		return delay - jitter
+	}
+	return delay + jitter
+}
@@ -0,0 +1,41 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	tests := []struct {
+		attempt int
+		want    time.Duration // Before jitter
+	}{
+		{attempt: 1, want: time.Minute},
+		{attempt: 2, want: 2 * time.Minute},
+		{attempt: 3, want: 4 * time.Minute},
+		{attempt: 6, want: 32 * time.Minute},
+		{attempt: 7, want: time.Hour}, // 64 minutes, capped
+		{attempt: 50, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want.String(), func(t *testing.T) {
+			low, high := tt.want-tt.want/5, tt.want+tt.want/5
+			for range 100 {
+				if got := policy.Delay(tt.attempt); got < low || got > high {
+					t.Fatalf("Delay(%d) = %v, want between %v and %v", tt.attempt, got, low, high)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitters(t *testing.T) {
+	seen := map[time.Duration]bool{}
+	for range 100 {
+		seen[DefaultRetryPolicy.Delay(1)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Delay(1) returned %d distinct delays in 100 calls, want them spread", len(seen))
+	}
+}
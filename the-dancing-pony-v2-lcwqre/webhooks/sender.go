@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// userAgent identifies deliveries to receivers.
+const userAgent = "DancingPony-Webhooks/1.0"
+
+// maxResponseBody is how much of a receiver's response is kept in the delivery log.
+const maxResponseBody = 1000
+
+// ErrPrivateAddress is returned when a webhook URL resolves to a loopback, private or
+// link-local address and private networks are not allowed.
+var ErrPrivateAddress = errors.New("webhook URL resolves to a private address")
+
+// Config configures the sender.
+type Config struct {
+	Timeout              time.Duration // Per attempt, connecting and reading the response included
+	AllowPrivateNetworks bool          // Allows URLs on this host or the internal network, such as the local receiver
+}
+
+// Request is one attempt to deliver an event.
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	EventID    string
+	EventType  string
+	Body       []byte
+}
+
+// Result is the outcome of an attempt.
+type Result struct {
+	StatusCode int // 0 when no response was received
+	Body       string
+	Duration   time.Duration
+	Err        error // Set when no response was received
+}
+
+// Succeeded reports whether the receiver accepted the delivery with a 2xx status.
+func (r Result) Succeeded() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Sender posts signed deliveries.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a new instance of Sender. Redirects are not followed: a receiver
+// that moved must be updated in its subscription.
+func NewSender(cfg Config) *Sender {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = rejectPrivateAddresses
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &Sender{client: &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send makes one attempt to deliver a request, signed at the current time.
+func (s *Sender) Send(ctx context.Context, request Request) Result {
+	started := time.Now()
+	timestamp := started.Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Body))
+	if err != nil {
+		return Result{Err: fmt.Errorf("invalid webhook URL: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderID, request.EventID)
+	req.Header.Set(HeaderDelivery, request.DeliveryID)
+	req.Header.Set(HeaderEvent, request.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, SignatureHeader(request.Secret, timestamp, request.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Result{Duration: time.Since(started), Err: err}
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Drain a little more so the connection can be reused
+	io.CopyN(io.Discard, resp.Body, 64<<10)
+	return Result{
+		StatusCode: resp.StatusCode,
+		Body:       validUTF8(body),
+		Duration:   time.Since(started),
+	}
+}
+
+// rejectPrivateAddresses refuses connections to addresses that are not on the public
+// internet, so subscriptions cannot be used to reach internal services.
+func rejectPrivateAddresses(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// validUTF8 returns body as a string, dropping bytes that are not valid UTF-8, such as
+// a multi-byte character cut at the end.
+func validUTF8(body []byte) string {
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	return string(bytes.ToValidUTF8(body, nil))
+}
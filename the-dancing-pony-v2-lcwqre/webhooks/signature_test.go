@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"order.placed"}`)
+	signature := Sign("whsec_test", 1700000000, body)
+	if len(signature) != 64 || strings.Trim(signature, "0123456789abcdef") != "" {
+		t.Fatalf("Sign() = %q, want 64 hex digits", signature)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+	}{
+		{name: "other secret", secret: "whsec_other", timestamp: 1700000000, body: body},
+		{name: "other timestamp", secret: "whsec_test", timestamp: 1700000001, body: body},
+		{name: "other body", secret: "whsec_test", timestamp: 1700000000, body: []byte(`{"type":"order.updated"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, tt.body); got == signature {
+				t.Errorf("Sign() = %q, the signature of the original request", got)
+			}
+		})
+	}
+	if again := Sign("whsec_test", 1700000000, body); again != signature {
+		t.Errorf("Sign() again = %q, want %q", again, signature)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"type":"order.placed"}`)
+	sent := time.Unix(1700000000, 0)
+	valid := SignatureHeader(secret, sent.Unix(), body)
+
+	tests := []struct {
+		name      string
+		signature string
+		body      []byte
+		now       time.Time
+		wantErr   bool
+	}{
+		{name: "valid", signature: valid, body: body, now: sent},
+		{name: "within tolerance", signature: valid, body: body, now: sent.Add(SignatureTolerance)},
+		{name: "clock behind within tolerance", signature: valid, body: body, now: sent.Add(-SignatureTolerance)},
+		{name: "too old", signature: valid, body: body, now: sent.Add(SignatureTolerance + time.Second), wantErr: true},
+		{name: "from the future", signature: valid, body: body, now: sent.Add(-SignatureTolerance - time.Second), wantErr: true},
+		{name: "tampered body", signature: valid, body: []byte(`{"type":"order.paid"}`), now: sent, wantErr: true},
+		{name: "tampered timestamp", signature: strings.Replace(valid, "t=1700000000", "t=1700000060", 1), body: body, now: sent, wantErr: true},
+		{name: "other secret", signature: SignatureHeader("whsec_other", sent.Unix(), body), body: body, now: sent, wantErr: true},
+		{name: "fields in any order", signature: "v1=" + Sign(secret, sent.Unix(), body) + ", t=1700000000", body: body, now: sent},
+		{name: "missing timestamp", signature: "v1=" + Sign(secret, sent.Unix(), body), body: body, now: sent, wantErr: true},
+		{name: "missing signature", signature: "t=1700000000", body: body, now: sent, wantErr: true},
+		{name: "missing header", body: body, now: sent, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.signature != "" {
+				header.Set(HeaderSignature, tt.signature)
+			}
+			err := Verify(secret, header, tt.body, tt.now)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalidSignature)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		})
+	}
+}